@@ -19,6 +19,7 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 	"github.com/sirupsen/logrus"
 )
 
@@ -117,16 +118,16 @@ func main() {
 		}
 	}
 	// ------------------------------------------------------------------------
-	// 设置接口的 API TOKEN
+	// 是否开启 API 接口，之前单用户的账号密码、API Key 迁移到用户管理中
 	{
-		if settings.Get().ExperimentalFunction.ApiKeySettings.Enabled == true {
-			common.SetApiToken(settings.Get().ExperimentalFunction.ApiKeySettings.Key)
-		} else {
-			common.SetApiToken("")
+		common.SetApiEnabled(settings.Get().ExperimentalFunction.ApiKeySettings.Enabled)
+		_, err := user_center.Get(loggerBase).MigrateFromSettings(settings.Get())
+		if err != nil {
+			loggerBase.Panicln("user_center.MigrateFromSettings err:", err)
 		}
 		// 是否开启开发模式，跳过某些流程
 		settings.Get().SpeedDevMode = false
-		err := settings.Get().Save()
+		err = settings.Get().Save()
 		if err != nil {
 			loggerBase.Panicln("settings.Get().Save() err:", err)
 		}
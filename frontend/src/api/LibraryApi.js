@@ -54,5 +54,16 @@ class LibraryApi extends BaseApi {
   getVideoM3u8 = (videoPath) => this.http(`/v1/preview/playlist/${encode(encodeURIComponent(videoPath))}`);
 
   getUploadInfo = (data) => this.http(`/v1/subtitles/get_generate_upload_url_info`, data, 'POST');
+
+  searchCandidates = (data) => this.http(`/v1/subtitles/candidates/search`, data, 'POST');
+
+  getCandidates = (data) => this.http(`/v1/subtitles/candidates/list`, data, 'POST');
+
+  getCandidateFile = (data) =>
+    this.http(`/v1/subtitles/candidates/file`, data, 'POST', {
+      responseType: 'blob',
+    });
+
+  chooseCandidate = (data) => this.http(`/v1/subtitles/candidates/choose`, data, 'POST');
 }
 export default new LibraryApi();
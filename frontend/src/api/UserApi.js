@@ -0,0 +1,20 @@
+import BaseApi from './BaseApi';
+
+class UserApi extends BaseApi {
+  getMe = () => this.http('/v1/users/me');
+
+  getList = () => this.http('/v1/users');
+
+  add = (data) => this.http('/v1/users', data, 'POST');
+
+  update = (id, data) => this.http(`/v1/users/${id}`, data, 'PUT');
+
+  delete = (id) => this.http(`/v1/users/${id}`, {}, 'DELETE');
+
+  getApiKeys = () => this.http('/v1/api-keys');
+
+  addApiKey = (data) => this.http('/v1/api-keys', data, 'POST');
+
+  deleteApiKey = (id) => this.http(`/v1/api-keys/${id}`, {}, 'DELETE');
+}
+export default new UserApi();
@@ -44,6 +44,12 @@ const routes = [
         component: () => import('pages/settings/index.vue'),
         meta: { title: '配置中心', icon: 'settings' },
       },
+      {
+        name: 'users',
+        path: 'users',
+        component: () => import('pages/users/index.vue'),
+        meta: { title: '用户与 API Key', icon: 'people' },
+      },
     ],
   },
 
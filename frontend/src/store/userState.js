@@ -4,4 +4,8 @@ import { LocalStorage } from 'quasar';
 export const userState = reactive({
   username: LocalStorage.getItem('token')?.username,
   accessToken: LocalStorage.getItem('token')?.accessToken,
+  // admin operator read_only
+  role: LocalStorage.getItem('token')?.role,
 });
+
+export const isAdmin = () => userState.role === 'admin';
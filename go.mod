@@ -12,7 +12,7 @@ require (
 	github.com/beevik/etree v1.1.0
 	github.com/bodgit/sevenzip v1.4.0
 	github.com/emirpasic/gods v1.18.1
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/gin-contrib/cors v1.4.0
 	github.com/gin-gonic/gin v1.9.0
 	github.com/go-creed/sat v1.0.3
@@ -69,6 +69,7 @@ require (
 	github.com/arl/statsviz v0.5.1
 	github.com/cyruzin/golang-tmdb v1.5.0
 	github.com/elazarl/goproxy v0.0.0-20221015165544-a0805db90819
+	github.com/gorilla/websocket v1.5.0
 	github.com/longbridgeapp/opencc v0.1.7
 	github.com/pkg/errors v0.9.1
 	github.com/urfave/cli/v2 v2.25.3
@@ -101,7 +102,6 @@ require (
 	github.com/goccy/go-json v0.10.2 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
@@ -13,7 +13,9 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/local_http_proxy_server"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/frontend/dist"
 	"github.com/gin-contrib/cors"
@@ -67,6 +69,9 @@ func (b *BackEnd) start() {
 		return
 	}
 	// -----------------------------------------
+	// 事件通知，修改设置后会重启，这里重新创建
+	notify_center.ResetEventCenter(b.logger, settings.Get().AdvancedSettings.NotifySettings)
+	// -----------------------------------------
 	// 设置跨域
 	gin.SetMode(gin.ReleaseMode)
 	gin.DefaultWriter = ioutil.Discard
@@ -169,7 +174,7 @@ func (b *BackEnd) Restart() {
 // doPreJob 前置的任务，热修复、字幕修改文件名格式、提前下载好浏览器
 func (b *BackEnd) doPreJob() {
 
-	if settings.Get().UserInfo.Username == "" || settings.Get().UserInfo.Password == "" {
+	if user_center.Get(b.logger).IsSetup() == false {
 		// 如果没有完成，那么就不执行初始化
 		b.logger.Infoln("Need do Setup, then do PreJob")
 	} else {
@@ -195,7 +200,7 @@ func (b *BackEnd) doPreJob() {
 func (b *BackEnd) doCornJob() {
 	// 需要使用 go 来执行，因为这个函数是阻塞的
 	// 启动定时任务
-	if settings.Get().UserInfo.Username == "" || settings.Get().UserInfo.Password == "" {
+	if user_center.Get(b.logger).IsSetup() == false {
 		// 如果没有完成，那么就不开启
 		b.logger.Infoln("Need do Setup, then do CornJob")
 	} else {
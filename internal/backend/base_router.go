@@ -13,8 +13,10 @@ import (
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/controllers/base"
 	v1 "github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/controllers/v1"
+	v2 "github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/controllers/v2"
 	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/cron_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 	"github.com/gin-gonic/gin"
 )
 
@@ -46,6 +48,8 @@ func InitRouter(
 	// ----------------------------------------------
 	cbBase := base.NewControllerBase(cronHelper.FileDownloader, restartSignal, preJob)
 	cbV1 := v1.NewControllerBase(cronHelper, restartSignal)
+	cbV2 := v2.NewControllerBase(cronHelper)
+	userCenter := user_center.Get(cronHelper.Logger)
 	// --------------------------------------------------
 	// 静态文件服务器
 	// 添加电影的
@@ -83,9 +87,9 @@ func InitRouter(
 	router.POST("/setup", cbBase.SetupHandler)
 
 	router.POST("/login", cbBase.LoginHandler)
-	router.POST("/logout", middle.CheckAuth(), cbBase.LogoutHandler)
+	router.POST("/logout", middle.CheckAuth(userCenter), cbBase.LogoutHandler)
 
-	router.POST("/change-pwd", middle.CheckAuth(), cbBase.ChangePwdHandler)
+	router.POST("/change-pwd", middle.CheckAuth(userCenter), cbBase.ChangePwdHandler)
 
 	router.POST("/check-path", cbBase.CheckPathHandler)
 
@@ -101,59 +105,128 @@ func InitRouter(
 
 	router.POST("/check-tmdb-api-settings", cbBase.CheckTmdbApiHandler)
 
+	// WebSocket 无法设置 Header，在连接后的握手消息中校验 Token
+	router.GET("/ws", cbV1.WebSocketHandler)
+
 	// v1路由: /v1/xxx
 	GroupV1 := router.Group("/" + cbV1.GetVersion())
 	{
-		GroupV1.Use(middle.CheckAuth())
+		GroupV1.Use(middle.CheckAuth(userCenter))
+		// 没有加 needOperator、needAdmin 的接口，只读的用户也可以访问
+		needOperator := middle.RequireRole(user_center.RoleOperator)
+		needAdmin := middle.RequireRole(user_center.RoleAdmin)
 
+		// 非管理员拿到的是打码后的设置
 		GroupV1.GET("/settings", cbV1.SettingsHandler)
-		GroupV1.PUT("/settings", cbV1.SettingsHandler)
+		GroupV1.PUT("/settings", needAdmin, cbV1.SettingsHandler)
 
-		GroupV1.POST("/daemon/start", cbV1.DaemonStartHandler)
-		GroupV1.POST("/daemon/stop", cbV1.DaemonStopHandler)
+		GroupV1.POST("/daemon/start", needOperator, cbV1.DaemonStartHandler)
+		GroupV1.POST("/daemon/stop", needOperator, cbV1.DaemonStopHandler)
 		GroupV1.GET("/daemon/status", cbV1.DaemonStatusHandler)
 
+		GroupV1.POST("/notify/test", needAdmin, cbV1.NotifyTestHandler)
+
 		GroupV1.GET("/jobs/list", cbV1.JobsListHandler)
-		GroupV1.POST("/jobs/change-job-status", cbV1.ChangeJobStatusHandler)
+		GroupV1.POST("/jobs/change-job-status", needOperator, cbV1.ChangeJobStatusHandler)
 		GroupV1.POST("/jobs/log", cbV1.JobLogHandler)
+		GroupV1.POST("/jobs/post-save-hook-records", cbV1.JobPostSaveHookRecordsHandler)
+		GroupV1.POST("/jobs/sub-post-process-records", cbV1.JobSubPostProcessRecordsHandler)
+
+		GroupV1.GET("/suppliers/health", cbV1.SupplierHealthHandler)
+		GroupV1.POST("/suppliers/health/enable", needOperator, cbV1.SupplierHealthEnableHandler)
 
 		//GroupV1.POST("/video/list/refresh", cbV1.RefreshVideoListHandler)
 		GroupV1.GET("/video/list/refresh-status", cbV1.RefreshVideoListStatusHandler)
 		//GroupV1.GET("/video/list", cbV1.VideoListHandler)
-		GroupV1.POST("/video/list/add", cbV1.VideoListAddHandler)
+		GroupV1.POST("/video/list/add", needOperator, cbV1.VideoListAddHandler)
 
-		GroupV1.POST("/video/list/refresh_main_list", cbV1.RefreshMainList)
+		GroupV1.POST("/video/list/refresh_main_list", needOperator, cbV1.RefreshMainList)
 		GroupV1.GET("/video/list/video_main_list", cbV1.VideoMainList)
 		GroupV1.POST("/video/list/movie_poster", cbV1.MoviePoster)
 		GroupV1.POST("/video/list/series_poster", cbV1.SeriesPoster)
 		GroupV1.POST("/video/list/one_movie_subs", cbV1.OneMovieSubs)
 		GroupV1.POST("/video/list/one_series_subs", cbV1.OneSeriesSubs)
 		GroupV1.POST("/video/list/scan_skip_info", cbV1.ScanSkipInfo)
-		GroupV1.PUT("/video/list/scan_skip_info", cbV1.ScanSkipInfo)
+		GroupV1.PUT("/video/list/scan_skip_info", needOperator, cbV1.ScanSkipInfo)
 
-		GroupV1.POST("/subtitles/refresh_media_server_sub_list", cbV1.RefreshMediaServerSubList)
-		GroupV1.POST("/subtitles/manual_upload_2_local", cbV1.ManualUploadSubtitle2Local)
+		GroupV1.POST("/subtitles/refresh_media_server_sub_list", needOperator, cbV1.RefreshMediaServerSubList)
+		GroupV1.POST("/subtitles/manual_upload_2_local", needOperator, cbV1.ManualUploadSubtitle2Local)
 		GroupV1.POST("/subtitles/manual_upload_result", cbV1.ManualUploadSubtitleResult)
 		GroupV1.GET("/subtitles/list_manual_upload_2_local_job", cbV1.ListManualUploadSubtitle2LocalJob)
 		GroupV1.POST("/subtitles/is_manual_upload_2_local_in_queue", cbV1.IsManualUploadSubtitle2LocalJobInQueue)
-		GroupV1.POST("/subtitles/get_generate_upload_url_info", cbV1.GetGenerateUploadURLHandle)
+		GroupV1.POST("/subtitles/get_generate_upload_url_info", needOperator, cbV1.GetGenerateUploadURLHandle)
+
+		GroupV1.POST("/subtitles/candidates/search", needOperator, cbV1.CandidatesSearch)
+		GroupV1.POST("/subtitles/candidates/list", cbV1.CandidatesList)
+		GroupV1.POST("/subtitles/candidates/file", cbV1.CandidateFile)
+		GroupV1.POST("/subtitles/candidates/choose", needOperator, cbV1.CandidateChoose)
+		GroupV1.POST("/subtitles/candidates/choose-status", cbV1.CandidateChooseStatus)
+
+		GroupV1.POST("/subtitles/ass_style/normalize_library", needOperator, cbV1.AssStyleNormalizeLibrary)
+		GroupV1.GET("/subtitles/ass_style/normalize_library_status", cbV1.AssStyleNormalizeLibraryStatus)
+		GroupV1.POST("/subtitles/fonts/reports", cbV1.SubFontsReports)
+		GroupV1.POST("/subtitles/fonts/check", needOperator, cbV1.SubFontsCheck)
 
-		GroupV1.POST("/preview/clean_up", cbV1.PreviewCleanUp)
+		GroupV1.POST("/preview/clean_up", needOperator, cbV1.PreviewCleanUp)
 		GroupV1.GET("/preview/playlist/:videofpathbase64", cbV1.HlsPlaylist)
 		GroupV1.GET("/preview/segments/:resolution/:segment/:videofpathbase64", cbV1.HlsSegment)
 		GroupV1.POST("/preview/search_other_web", cbV1.PreviewSearchOtherWeb)
 		GroupV1.POST("/preview/video_f_path_2_imdb_info", cbV1.PreviewVideoFPath2IMDBInfo)
+
+		// 用户管理，API Key 每个用户管理自己的，管理员可以管理所有的
+		GroupV1.GET("/users/me", cbV1.UserMeHandler)
+		GroupV1.GET("/users", needAdmin, cbV1.UserListHandler)
+		GroupV1.POST("/users", needAdmin, cbV1.UserAddHandler)
+		GroupV1.PUT("/users/:id", needAdmin, cbV1.UserUpdateHandler)
+		GroupV1.DELETE("/users/:id", needAdmin, cbV1.UserDelHandler)
+		GroupV1.GET("/api-keys", cbV1.ApiKeyListHandler)
+		GroupV1.POST("/api-keys", cbV1.ApiKeyAddHandler)
+		GroupV1.DELETE("/api-keys/:id", cbV1.ApiKeyDelHandler)
 	}
 
 	GroupAPIV1 := router.Group("/api/v1")
 	{
-		GroupAPIV1.Use(middle.CheckApiAuth())
+		GroupAPIV1.Use(middle.CheckApiAuth(userCenter))
+		// API Key 的权限跟随所属用户的角色
+		needOperator := middle.RequireRole(user_center.RoleOperator)
 
-		GroupAPIV1.POST("/add-job", cbV1.AddJobHandler)
+		GroupAPIV1.POST("/add-job", needOperator, cbV1.AddJobHandler)
 		GroupAPIV1.GET("/job-status", cbV1.GetJobStatusHandler)
-		GroupAPIV1.POST("/change-job-status", cbV1.ChangeJobStatusHandler)
-		GroupAPIV1.POST("/add-video-played-info", cbV1.AddVideoPlayedInfoHandler)
-		GroupAPIV1.DELETE("/del-video-played-info", cbV1.DelVideoPlayedInfoHandler)
+		GroupAPIV1.POST("/change-job-status", needOperator, cbV1.ChangeJobStatusHandler)
+		GroupAPIV1.POST("/add-video-played-info", needOperator, cbV1.AddVideoPlayedInfoHandler)
+		GroupAPIV1.DELETE("/del-video-played-info", needOperator, cbV1.DelVideoPlayedInfoHandler)
+	}
+
+	// Sonarr、Radarr、Emby、Jellyfin 的 Webhook，不一定能设置 Header，只有这里可以使用 ?api_key=xx
+	GroupWebhook := router.Group("/api/v1/webhook")
+	{
+		GroupWebhook.Use(middle.CheckWebhookApiAuth(userCenter))
+		needOperator := middle.RequireRole(user_center.RoleOperator)
+
+		GroupWebhook.POST("/sonarr", needOperator, cbV1.SonarrWebhookHandler)
+		GroupWebhook.POST("/radarr", needOperator, cbV1.RadarrWebhookHandler)
+		GroupWebhook.POST("/emby", needOperator, cbV1.EmbyWebhookHandler)
+		GroupWebhook.POST("/jellyfin", needOperator, cbV1.JellyfinWebhookHandler)
+	}
+
+	// 局域网内其他实例查询字幕缓存，只读，不使用用户的鉴权
+	GroupPeerLibrary := router.Group("/peer-library/" + cbV1.GetVersion())
+	{
+		GroupPeerLibrary.Use(middle.CheckPeerLibraryToken())
+
+		GroupPeerLibrary.GET("/subtitles", cbV1.PeerLibrarySubtitlesHandler)
+		GroupPeerLibrary.GET("/file/:sha256", cbV1.PeerLibraryFileHandler)
+	}
+
+	// 对外的 API v2，接口的定义同时用于生成 OpenAPI 文档，文档本身不需要鉴权
+	router.GET("/api/"+cbV2.GetVersion()+"/openapi.json", cbV2.OpenAPIHandler)
+	GroupAPIV2 := router.Group("/api/" + cbV2.GetVersion())
+	{
+		GroupAPIV2.Use(middle.CheckApiAuthV2(userCenter))
+
+		for _, route := range cbV2.GetRoutes() {
+			GroupAPIV2.Handle(route.Method, route.Path, middle.RequireRoleV2(route.Role), route.Handler)
+		}
 	}
 
 	return cbBase, cbV1
@@ -1,12 +1,14 @@
 package base
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
+
 	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,24 +26,21 @@ func (cb *ControllerBase) ChangePwdHandler(c *gin.Context) {
 		return
 	}
 
-	if settings.Get().UserInfo.Username == "" || settings.Get().UserInfo.Password == "" {
-		// 配置文件中的账号和密码任意一个未空，提示用户需要进行 setup 流程
-		c.JSON(http.StatusNoContent, backend2.ReplyCommon{Message: "You need do `Setup`"})
+	identity := middle.GetIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, backend2.ReplyCheckAuth{Message: "AccessToken Error"})
 		return
 	}
 
-	if settings.Get().UserInfo.Password != changePwd.OrgPwd {
+	changeErr := user_center.Get(cb.fileDownloader.Log).ChangePassword(identity.UserID, changePwd.OrgPwd, changePwd.NewPwd)
+	if errors.Is(changeErr, user_center.ErrOrgPasswordError) == true {
 		// 原始的密码不对
-		c.JSON(http.StatusNoContent, backend2.ReplyCommon{Message: "Org Password Error"})
-	} else {
-		// 同意修改密码
-		settings.Get().UserInfo.Password = changePwd.NewPwd
-		err = settings.Get().Save()
-		if err != nil {
-			return
-		}
-		// 修改密码成功后，会清理 AccessToken，强制要求重写登录
-		common.SetAccessToken("")
-		c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok, need ReLogin"})
+		c.JSON(http.StatusNoContent, backend2.ReplyCommon{Message: changeErr.Error()})
+		return
+	} else if changeErr != nil {
+		err = changeErr
+		return
 	}
+	// 修改密码成功后，这个用户所有的会话都会失效，强制要求重新登录
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok, need ReLogin"})
 }
@@ -1,13 +1,13 @@
 package base
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 
 	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/gin-gonic/gin"
 )
@@ -25,23 +25,25 @@ func (cb *ControllerBase) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	if settings.Get().UserInfo.Username == "" || settings.Get().UserInfo.Password == "" {
-		// 配置文件中的账号和密码任意一个未空，提示用户需要进行 setup 流程
+	userCenter := user_center.Get(cb.fileDownloader.Log)
+	if userCenter.IsSetup() == false {
+		// 还没有任何用户，提示用户需要进行 setup 流程
 		c.JSON(http.StatusNoContent, backend2.ReplyCommon{Message: "You need do `Setup`"})
 		return
 	}
 
-	if settings.Get().UserInfo.Username != nowUserInfo.Username ||
-		settings.Get().UserInfo.Password != nowUserInfo.Password {
+	nowAccessToken, user, loginErr := userCenter.Login(nowUserInfo.Username, nowUserInfo.Password)
+	if errors.Is(loginErr, user_center.ErrUsernameOrPasswordError) == true {
 		// 账号密码不匹配
-		c.JSON(http.StatusBadRequest, backend2.ReplyCommon{Message: "Username or Password Error"})
+		c.JSON(http.StatusBadRequest, backend2.ReplyCommon{Message: loginErr.Error()})
 		return
-	} else {
-		// 用户账号密码匹配
-		nowAccessToken := pkg.GenerateAccessToken()
-		common.SetAccessToken(nowAccessToken)
-		c.JSON(http.StatusOK, backend2.ReplyLogin{AccessToken: nowAccessToken,
-			Settings: *settings.Get().GetNoPasswordSettings()})
+	} else if loginErr != nil {
+		err = loginErr
 		return
 	}
+	// 用户账号密码匹配，新建一个会话，不影响这个用户其他的会话。设置中的敏感信息需要管理员通过 GET /v1/settings 获取
+	c.JSON(http.StatusOK, backend2.ReplyLogin{AccessToken: nowAccessToken,
+		Username: user.Username,
+		Role:     user.Role,
+		Settings: *settings.Get().GetRedactedSettings()})
 }
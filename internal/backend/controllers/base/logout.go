@@ -3,15 +3,24 @@ package base
 import (
 	"net/http"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
 	"github.com/gin-gonic/gin"
 )
 
 func (cb *ControllerBase) LogoutHandler(c *gin.Context) {
 
-	// 注销
-	common.SetAccessToken("")
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "LogoutHandler", err)
+	}()
+	// 注销，只注销当前的会话
+	err = user_center.Get(cb.fileDownloader.Log).Logout(middle.GetAuthToken(c))
+	if err != nil {
+		return
+	}
 	c.JSON(http.StatusOK, backend.ReplyCommon{Message: "ok, need ReLogin"})
 }
@@ -14,6 +14,7 @@ import (
 
 	subSupplier "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/assrt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/custom"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/shooter"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/xunlei"
 
@@ -85,6 +86,13 @@ func (cb *ControllerBase) CheckProxyHandler(c *gin.Context) {
 		subSupplierHub.AddSubSupplier(subtitle_best.NewSupplier(cb.fileDownloader))
 	}
 
+	for _, customSettings := range settings.Get().SubtitleSources.CustomSuppliers {
+		if customSettings.Enabled == true {
+			// 由设置描述的字幕源，开启了的才需要测试
+			subSupplierHub.AddSubSupplier(custom.NewSupplier(cb.fileDownloader, customSettings))
+		}
+	}
+
 	outStatus := subSupplierHub.CheckSubSiteStatus()
 
 	c.JSON(http.StatusOK, outStatus)
@@ -6,6 +6,7 @@ import (
 	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,22 +23,28 @@ func (cb *ControllerBase) SetupHandler(c *gin.Context) {
 		return
 	}
 	// 只有当用户不存在的时候才能够执行初始化操作
-	found := false
-	if settings.Get().UserInfo.Username != "" && settings.Get().UserInfo.Password != "" {
-		found = true
-	}
-
-	if found == true {
+	userCenter := user_center.Get(cb.fileDownloader.Log)
+	if userCenter.IsSetup() == true {
 		// 存在则反馈无需初始化
 		c.JSON(http.StatusNoContent, backend2.ReplyCommon{Message: "already setup"})
-	} else {
-		// 需要创建用户，因为上述判断了没有用户存在，所以就默认直接新建了
-		err = settings.SetFullNewSettings(&setupInfo.Settings)
-		if err != nil {
-			return
-		}
-		c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+		return
+	}
+	if setupInfo.Settings.UserInfo == nil {
+		c.JSON(http.StatusBadRequest, backend2.ReplyCommon{Message: "user_info is empty"})
+		return
+	}
+	// 需要创建用户，因为上述判断了没有用户存在，所以第一个用户就是管理员
+	_, err = userCenter.AddUser(setupInfo.Settings.UserInfo.Username, setupInfo.Settings.UserInfo.Password, user_center.RoleAdmin)
+	if err != nil {
+		return
+	}
+	// 密码只保存在数据库中
+	setupInfo.Settings.UserInfo.Password = ""
+	err = settings.SetFullNewSettings(&setupInfo.Settings)
+	if err != nil {
+		return
 	}
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
 
 	// 回复完毕后，发送重启 http server 的信号
 	cb.restartSignal <- 1
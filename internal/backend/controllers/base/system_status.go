@@ -5,9 +5,10 @@ import (
 	"runtime"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_index"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	running "github.com/allanpk716/is_running_in_docker"
 	"github.com/gin-gonic/gin"
 )
@@ -21,16 +22,15 @@ func (cb *ControllerBase) SystemStatusHandler(c *gin.Context) {
 		cb.ErrorProcess(c, "SystemStatusHandler", err)
 	}()
 
-	isSetup := false
-	if settings.Get().UserInfo.Username != "" && settings.Get().UserInfo.Password != "" {
-		// 进行过 setup 了，那么就可以 Login 的流程
-		isSetup = true
-	}
+	// 有用户了，说明进行过 setup 了，那么就可以 Login 的流程
+	isSetup := user_center.Get(cb.fileDownloader.Log).IsSetup()
 
 	c.JSON(http.StatusOK, backend.ReplySystemStatus{
-		IsSetup:           isSetup,
-		Version:           pkg.AppVersion(),
-		OS:                runtime.GOOS,
-		ARCH:              runtime.GOARCH,
-		IsRunningInDocker: running.IsRunningInDocker()})
+		IsSetup:            isSetup,
+		Version:            pkg.AppVersion(),
+		OS:                 runtime.GOOS,
+		ARCH:               runtime.GOARCH,
+		IsRunningInDocker:  running.IsRunningInDocker(),
+		LastScanStatistics: scan_index.GetLastStatistics(),
+	})
 }
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// AssStyleNormalizeLibrary 对电影、连续剧目录中已有的 ass/ssa 字幕执行样式统一
+func (cb *ControllerBase) AssStyleNormalizeLibrary(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "AssStyleNormalizeLibrary", err)
+	}()
+
+	styleSettings := settings.Get().AdvancedSettings.AssStyleSettings
+	if styleSettings == nil || styleSettings.Enable == false {
+		err = fmt.Errorf("ass style normalizer is not enabled")
+		return
+	}
+	if ass_style_normalizer.StartNormalizeLibrary(cb.log) == false {
+		// 已经在执行，跳过
+		cb.log.Infoln("AssStyleNormalizeLibrary is running, skip")
+	}
+
+	c.JSON(http.StatusOK, backend2.ReplyAssStyleLibrary{
+		Status:   "running",
+		Progress: ass_style_normalizer.GetLibraryStatus(),
+	})
+	return
+}
+
+// AssStyleNormalizeLibraryStatus 获取对已有字幕执行样式统一的进度
+func (cb *ControllerBase) AssStyleNormalizeLibraryStatus(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "AssStyleNormalizeLibraryStatus", err)
+	}()
+
+	progress := ass_style_normalizer.GetLibraryStatus()
+	status := "running"
+	if progress.Running == false {
+		status = "stopped"
+	}
+	c.JSON(http.StatusOK, backend2.ReplyAssStyleLibrary{
+		Status:   status,
+		Progress: progress,
+	})
+	return
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// CandidatesSearch 使用所有可用的字幕源搜索一个视频的字幕，在后台执行
+func (cb *ControllerBase) CandidatesSearch(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidatesSearch", err)
+	}()
+
+	req := backend2.ReqCandidateSearch{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	// 只能是视频库中存在的视频
+	err = scan_rules.CheckInLibrary(req.VideoFPath)
+	if err != nil {
+		return
+	}
+
+	videoType := common.Movie
+	if req.VideoType == 1 {
+		videoType = common.Series
+	}
+	err = cb.cronHelper.Downloader.SearchCandidates(req.VideoFPath, videoType)
+	if err != nil {
+		return
+	}
+
+	nowSearch, _ := cb.cronHelper.Downloader.GetCandidateSearch(req.VideoFPath)
+	c.JSON(http.StatusOK, nowSearch)
+	return
+}
+
+// CandidatesList 一个视频的字幕搜索状态以及候选字幕列表，没有搜索过的时候 Status 为空
+func (cb *ControllerBase) CandidatesList(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidatesList", err)
+	}()
+
+	req := backend2.ReqCandidateSearch{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	// 只能是视频库中存在的视频
+	err = scan_rules.CheckInLibrary(req.VideoFPath)
+	if err != nil {
+		return
+	}
+
+	nowSearch, _ := cb.cronHelper.Downloader.GetCandidateSearch(req.VideoFPath)
+	c.JSON(http.StatusOK, nowSearch)
+	return
+}
+
+// CandidateFile 获取一个候选字幕的文件，用于在预览播放器中加载
+func (cb *ControllerBase) CandidateFile(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidateFile", err)
+	}()
+
+	req := backend2.ReqOneCandidate{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	// 只能是视频库中存在的视频
+	err = scan_rules.CheckInLibrary(req.VideoFPath)
+	if err != nil {
+		return
+	}
+
+	subFPath, err := cb.cronHelper.Downloader.GetCandidateSubFPath(req.VideoFPath, req.CandidateID)
+	if err != nil {
+		return
+	}
+
+	c.File(subFPath)
+	return
+}
+
+// CandidateChoose 选择一个候选字幕，在后台通过字幕命名格式化、时间轴校正等流程保存到视频目录，返回任务的 ID
+func (cb *ControllerBase) CandidateChoose(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidateChoose", err)
+	}()
+
+	req := backend2.ReqOneCandidate{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	// 只能是视频库中存在的视频
+	err = scan_rules.CheckInLibrary(req.VideoFPath)
+	if err != nil {
+		return
+	}
+
+	nowChoose, err := cb.cronHelper.Downloader.ChooseCandidate(req.VideoFPath, req.CandidateID)
+	if err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, nowChoose)
+	return
+}
+
+// CandidateChooseStatus 获取选择候选字幕任务的结果
+func (cb *ControllerBase) CandidateChooseStatus(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidateChooseStatus", err)
+	}()
+
+	req := backend2.ReqJobThings{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+
+	nowChoose, found := cb.cronHelper.Downloader.GetCandidateChoose(req.JobID)
+	if found == false {
+		c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nowChoose)
+	return
+}
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/post_save_hook"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_post_process"
 
 	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
@@ -136,3 +138,51 @@ func (cb *ControllerBase) JobLogHandler(c *gin.Context) {
 		return
 	}
 }
+
+// JobPostSaveHookRecordsHandler 获取一个任务的字幕保存后回调的执行记录
+func (cb *ControllerBase) JobPostSaveHookRecordsHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "JobPostSaveHookRecordsHandler", err)
+	}()
+
+	reqJobLog := backend2.ReqJobLog{}
+	err = c.ShouldBindJSON(&reqJobLog)
+	if err != nil {
+		return
+	}
+
+	records, err := post_save_hook.GetRecords(reqJobLog.Id)
+	if err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, backend2.ReplyPostSaveHookRecords{
+		Records: records,
+	})
+}
+
+// JobSubPostProcessRecordsHandler 获取一个任务写入字幕后每个处理步骤的结果
+func (cb *ControllerBase) JobSubPostProcessRecordsHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "JobSubPostProcessRecordsHandler", err)
+	}()
+
+	reqJobLog := backend2.ReqJobLog{}
+	err = c.ShouldBindJSON(&reqJobLog)
+	if err != nil {
+		return
+	}
+
+	records, err := sub_post_process.GetRecords(reqJobLog.Id)
+	if err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, backend2.ReplySubPostProcessRecords{
+		Records: records,
+	})
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// NotifyTestHandler 使用传入的通知渠道设置发送一条测试的通知，不需要先保存设置
+func (cb *ControllerBase) NotifyTestHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "NotifyTestHandler", err)
+	}()
+
+	channelSettings := settings.NotifyChannelSettings{}
+	err = c.ShouldBindJSON(&channelSettings)
+	if err != nil {
+		return
+	}
+
+	channel, err2 := notify_center.NewChannel(channelSettings.Name, channelSettings.Url, channelSettings.Template)
+	if err2 != nil {
+		c.JSON(http.StatusOK, backend2.ReplyCommon{Message: err2.Error()})
+		return
+	}
+	err2 = channel.Send(notify.NewEvent(notify.Test, "ChineseSubFinder notify test", nil))
+	if err2 != nil {
+		c.JSON(http.StatusOK, backend2.ReplyCommon{Message: err2.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+}
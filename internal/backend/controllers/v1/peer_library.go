@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/peer_library"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// PeerLibrarySubtitlesHandler 局域网内其他实例查询字幕缓存，?feature=xx 或者 ?imdb_id=xx&season=1&episode=2
+func (cb *ControllerBase) PeerLibrarySubtitlesHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "PeerLibrarySubtitlesHandler", err)
+	}()
+
+	season, episode := 0, 0
+	if c.Query("season") != "" {
+		season, err = strconv.Atoi(c.Query("season"))
+		if err != nil {
+			return
+		}
+	}
+	if c.Query("episode") != "" {
+		episode, err = strconv.Atoi(c.Query("episode"))
+		if err != nil {
+			return
+		}
+	}
+	items, err := peer_library.QuerySubtitles(c.Query("feature"), c.Query("imdb_id"), season, episode)
+	if err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyPeerLibrarySubtitles{Subtitles: items})
+}
+
+// PeerLibraryFileHandler 局域网内其他实例下载字幕缓存中的字幕文件
+func (cb *ControllerBase) PeerLibraryFileHandler(c *gin.Context) {
+
+	subFPath, err := peer_library.GetSubFPath(c.Param("sha256"))
+	if err == peer_library.ErrSubNotFound {
+		c.JSON(http.StatusNotFound, backend2.ReplyCommon{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		cb.ErrorProcess(c, "PeerLibraryFileHandler", err)
+		return
+	}
+	c.FileAttachment(subFPath, filepath.Base(subFPath))
+}
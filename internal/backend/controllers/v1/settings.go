@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 	"net/http"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,8 +23,14 @@ func (cb *ControllerBase) SettingsHandler(c *gin.Context) {
 	switch c.Request.Method {
 	case "GET":
 		{
-			// 回复没有密码的 settings
-			c.JSON(http.StatusOK, settings.Get().GetNoPasswordSettings())
+			// 只有管理员需要编辑设置，其他的角色只返回打码后的设置
+			identity := middle.GetIdentity(c)
+			if identity != nil && user_center.RoleAllowed(identity.Role, user_center.RoleAdmin) == true {
+				// 回复没有密码的 settings
+				c.JSON(http.StatusOK, settings.Get().GetNoPasswordSettings())
+			} else {
+				c.JSON(http.StatusOK, settings.Get().GetRedactedSettings())
+			}
 		}
 	case "PUT":
 		{
@@ -32,21 +40,21 @@ func (cb *ControllerBase) SettingsHandler(c *gin.Context) {
 			if err != nil {
 				return
 			}
-			// 需要去除 user 的 password 信息再保存，也就是继承之前的 password 即可
-			nowPassword := settings.Get().UserInfo.Password
-			reqSetupInfo.UserInfo.Password = nowPassword
+			// 密码以及 API Key 都在用户管理中，不保存到配置文件中
+			if reqSetupInfo.UserInfo != nil {
+				reqSetupInfo.UserInfo.Password = ""
+			}
+			reqSetupInfo.ExperimentalFunction.ApiKeySettings.Key = ""
+			// 从登录的回复中拿到的是打码后的设置，没有修改过的敏感字段保持原来的值
+			reqSetupInfo.RestoreRedacted(settings.Get())
 			err = settings.SetFullNewSettings(&reqSetupInfo)
 			if err != nil {
 				return
 			}
 			pkg.ResetWantedVideoExt()
 			// ----------------------------------------
-			// 设置接口的 API TOKEN
-			if settings.Get().ExperimentalFunction.ApiKeySettings.Enabled == true {
-				common.SetApiToken(settings.Get().ExperimentalFunction.ApiKeySettings.Key)
-			} else {
-				common.SetApiToken("")
-			}
+			// 是否开启 API 接口
+			common.SetApiEnabled(settings.Get().ExperimentalFunction.ApiKeySettings.Enabled)
 			// ----------------------------------------
 			c.JSON(http.StatusOK, backend.ReplyCommon{Message: "Settings Save Success"})
 			// 回复完毕后，发送重启 http server 的信号
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_fonts"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// SubFontsReports 获取一个视频的 ass/ssa 字幕的字体检查结果，包含缺少的字体
+func (cb *ControllerBase) SubFontsReports(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "SubFontsReports", err)
+	}()
+
+	req := backend2.ReqSubFonts{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	reports, err := ass_fonts.GetReports(req.VideoFPath)
+	if err != nil {
+		return
+	}
+	reply := backend2.ReplySubFontsReports{Reports: make([]backend2.SubFontsReport, 0, len(reports))}
+	for i := range reports {
+		reply.Reports = append(reply.Reports, subFontsReport2Reply(&reports[i]))
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// SubFontsCheck 重新检查一个字幕的字体，比如在字体目录中添加了缺少的字体之后
+func (cb *ControllerBase) SubFontsCheck(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "SubFontsCheck", err)
+	}()
+
+	req := backend2.ReqSubFonts{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	// 会读取字幕、写入字体甚至修改视频文件，只能是视频库中的文件
+	err = scan_rules.CheckInLibrary(req.VideoFPath, req.SubFPath)
+	if err != nil {
+		return
+	}
+	if ass_style_normalizer.IsAssOrSsa(req.SubFPath) == false {
+		err = fmt.Errorf("sub_f_path is not a ass or ssa file: %s", req.SubFPath)
+		return
+	}
+	// 可能是在字体目录中添加了缺少的字体之后再检查的，需要重新扫描字体目录
+	ass_fonts.ResetFontIndex()
+	report, err := ass_fonts.NewChecker(cb.log, settings.Get().AdvancedSettings.AssFontsSettings).Check(req.VideoFPath, req.SubFPath)
+	if err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplySubFontsReports{
+		Reports: []backend2.SubFontsReport{subFontsReport2Reply(report)},
+	})
+}
+
+func subFontsReport2Reply(report *models.SubFontsReport) backend2.SubFontsReport {
+	return backend2.SubFontsReport{
+		SubFPath:     report.SubFPath,
+		Fonts:        report.Fonts,
+		MissingFonts: report.MissingFonts,
+		OutputFiles:  report.OutputFiles,
+		UpdatedAt:    report.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// SupplierHealthHandler 字幕源的健康统计，?days=7 最近几天的，不会超过保留的天数
+func (cb *ControllerBase) SupplierHealthHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "SupplierHealthHandler", err)
+	}()
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil {
+		return
+	}
+	historyDays := settings.DefaultSupplierHistoryDays
+	if settings.Get().AdvancedSettings.SupplierHealthSettings != nil {
+		historyDays = settings.Get().AdvancedSettings.SupplierHealthSettings.HistoryDays
+	}
+	if days < 1 {
+		days = 1
+	}
+	if days > historyDays {
+		days = historyDays
+	}
+	reply, err := supplier_health.GetDashboard(days)
+	if err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// SupplierHealthEnableHandler 手动重新启用被自动禁用的字幕源，会在后台重新检查字幕源，更新参与下载的字幕源
+func (cb *ControllerBase) SupplierHealthEnableHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "SupplierHealthEnableHandler", err)
+	}()
+
+	req := backend2.ReqSupplierHealthEnable{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	err = supplier_health.Enable(req.Name)
+	if err != nil {
+		return
+	}
+	// 被禁用的字幕源已经从 SubSupplierHub 中去掉了，重新检查一次才会加回来，不用等下一次的定时检查
+	go cb.cronHelper.Downloader.SupplierCheck()
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+}
@@ -0,0 +1,251 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
+
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// UserMeHandler 当前登录的用户
+func (cb *ControllerBase) UserMeHandler(c *gin.Context) {
+
+	identity := middle.GetIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, backend2.ReplyCheckAuth{Message: "AccessToken Error"})
+		return
+	}
+	c.JSON(http.StatusOK, identity)
+}
+
+// UserListHandler 所有的用户
+func (cb *ControllerBase) UserListHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "UserListHandler", err)
+	}()
+
+	users, err := user_center.Get(cb.log).ListUsers()
+	if err != nil {
+		return
+	}
+	reply := backend2.ReplyUsers{Users: make([]backend2.UserItem, 0)}
+	for _, user := range users {
+		reply.Users = append(reply.Users, newUserItem(user))
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// UserAddHandler 新增一个用户
+func (cb *ControllerBase) UserAddHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "UserAddHandler", err)
+	}()
+
+	req := backend2.ReqAddUser{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+
+	user, addErr := user_center.Get(cb.log).AddUser(req.Username, req.Password, req.Role)
+	if cb.userCenterError(c, addErr, &err) == true {
+		return
+	}
+	c.JSON(http.StatusOK, newUserItem(*user))
+}
+
+// UserUpdateHandler 修改一个用户的角色或者重置密码
+func (cb *ControllerBase) UserUpdateHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "UserUpdateHandler", err)
+	}()
+
+	userID, err := paramID(c)
+	if err != nil {
+		return
+	}
+	req := backend2.ReqUpdateUser{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+
+	updateErr := user_center.Get(cb.log).UpdateUser(userID, req.Role, req.Password)
+	if cb.userCenterError(c, updateErr, &err) == true {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+}
+
+// UserDelHandler 删除一个用户，以及这个用户的会话和 API Key
+func (cb *ControllerBase) UserDelHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "UserDelHandler", err)
+	}()
+
+	userID, err := paramID(c)
+	if err != nil {
+		return
+	}
+
+	delErr := user_center.Get(cb.log).DelUser(userID)
+	if cb.userCenterError(c, delErr, &err) == true {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+}
+
+// ApiKeyListHandler API Key 列表，管理员可以看到所有用户的
+func (cb *ControllerBase) ApiKeyListHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "ApiKeyListHandler", err)
+	}()
+
+	userCenter := user_center.Get(cb.log)
+	apiKeys, err := userCenter.ListApiKeys(ownerFilter(c))
+	if err != nil {
+		return
+	}
+	users, err := userCenter.ListUsers()
+	if err != nil {
+		return
+	}
+	usernames := make(map[uint]string)
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	reply := backend2.ReplyApiKeys{ApiKeys: make([]backend2.ApiKeyItem, 0)}
+	for _, apiKey := range apiKeys {
+		reply.ApiKeys = append(reply.ApiKeys, newApiKeyItem(apiKey, usernames[apiKey.UserID]))
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// ApiKeyAddHandler 给当前的用户新建一个 API Key
+func (cb *ControllerBase) ApiKeyAddHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "ApiKeyAddHandler", err)
+	}()
+
+	req := backend2.ReqAddApiKey{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		return
+	}
+	identity := middle.GetIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, backend2.ReplyCheckAuth{Message: "AccessToken Error"})
+		return
+	}
+
+	key, apiKey, addErr := user_center.Get(cb.log).AddApiKey(identity.UserID, req.Name)
+	if cb.userCenterError(c, addErr, &err) == true {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyAddApiKey{
+		Key:    key,
+		ApiKey: newApiKeyItem(*apiKey, identity.Username),
+	})
+}
+
+// ApiKeyDelHandler 吊销一个 API Key，管理员可以吊销所有用户的
+func (cb *ControllerBase) ApiKeyDelHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "ApiKeyDelHandler", err)
+	}()
+
+	apiKeyID, err := paramID(c)
+	if err != nil {
+		return
+	}
+
+	delErr := user_center.Get(cb.log).DelApiKey(apiKeyID, ownerFilter(c))
+	if cb.userCenterError(c, delErr, &err) == true {
+		return
+	}
+	c.JSON(http.StatusOK, backend2.ReplyCommon{Message: "ok"})
+}
+
+// userCenterError 用户操作的错误回复 400，其他的错误交给 ErrorProcess，返回是否有错误
+func (cb *ControllerBase) userCenterError(c *gin.Context, ucErr error, err *error) bool {
+
+	if ucErr == nil {
+		return false
+	}
+	for _, badRequestErr := range []error{
+		user_center.ErrUserNotFound, user_center.ErrUserExists, user_center.ErrUsernameEmpty,
+		user_center.ErrInvalidRole, user_center.ErrPasswordLength, user_center.ErrLastAdmin,
+		user_center.ErrApiKeyNameEmpty, user_center.ErrApiKeyNotFound,
+	} {
+		if errors.Is(ucErr, badRequestErr) == true {
+			c.JSON(http.StatusBadRequest, backend2.ReplyCommon{Message: ucErr.Error()})
+			return true
+		}
+	}
+	*err = ucErr
+	return true
+}
+
+// ownerFilter 管理员返回 0 表示所有用户，其他角色只能操作自己的
+func ownerFilter(c *gin.Context) uint {
+
+	identity := middle.GetIdentity(c)
+	if identity == nil {
+		// 不会出现，CheckAuth 之后才会调用
+		return ^uint(0)
+	}
+	if identity.Role == user_center.RoleAdmin {
+		return 0
+	}
+	return identity.UserID
+}
+
+func paramID(c *gin.Context) (uint, error) {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func newUserItem(user models.User) backend2.UserItem {
+	return backend2.UserItem{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+func newApiKeyItem(apiKey models.UserApiKey, username string) backend2.ApiKeyItem {
+	return backend2.ApiKeyItem{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		KeyPrefix:  apiKey.KeyPrefix,
+		UserID:     apiKey.UserID,
+		Username:   username,
+		CreatedAt:  apiKey.CreatedAt,
+		LastUsedAt: apiKey.LastUsedAt,
+	}
+}
@@ -194,13 +194,16 @@ func (cb *ControllerBase) OneMovieSubs(c *gin.Context) {
 	}
 
 	movieSubsInfo := backend2.MovieSubsInfo{
-		SubUrlList: make([]string, 0),
+		SubUrlList:      make([]string, 0),
+		SubFPathList:    make([]string, 0),
+		SubIsForcedList: make([]bool, 0),
 	}
 	// 将匹配到的字幕文件转换为 URL
 	for _, sub := range matchedSubs {
 		subUrl := path_helper.ChangePhysicalPathToSharePath(sub, movieInfo.MainRootDirFPath, desUrl)
 		movieSubsInfo.SubUrlList = append(movieSubsInfo.SubUrlList, subUrl)
 		movieSubsInfo.SubFPathList = append(movieSubsInfo.SubFPathList, sub)
+		movieSubsInfo.SubIsForcedList = append(movieSubsInfo.SubIsForcedList, sub_helper.IsForcedSubFileName(sub))
 	}
 
 	c.JSON(http.StatusOK, movieSubsInfo)
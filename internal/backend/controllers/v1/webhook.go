@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/incoming_webhook"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	backend2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// SonarrWebhookHandler 接收 Sonarr 的 Webhook
+func (cb *ControllerBase) SonarrWebhookHandler(c *gin.Context) {
+	cb.incomingWebhook(c, "SonarrWebhookHandler", incoming_webhook.ParseSonarr)
+}
+
+// RadarrWebhookHandler 接收 Radarr 的 Webhook
+func (cb *ControllerBase) RadarrWebhookHandler(c *gin.Context) {
+	cb.incomingWebhook(c, "RadarrWebhookHandler", incoming_webhook.ParseRadarr)
+}
+
+// EmbyWebhookHandler 接收 Emby 的 Webhook，library.new
+func (cb *ControllerBase) EmbyWebhookHandler(c *gin.Context) {
+	cb.incomingWebhook(c, "EmbyWebhookHandler", incoming_webhook.ParseEmby)
+}
+
+// JellyfinWebhookHandler 接收 Jellyfin Webhook 插件的通知，ItemAdded
+func (cb *ControllerBase) JellyfinWebhookHandler(c *gin.Context) {
+	cb.incomingWebhook(c, "JellyfinWebhookHandler", incoming_webhook.ParseJellyfin)
+}
+
+func (cb *ControllerBase) incomingWebhook(c *gin.Context, funcName string, parse func(body []byte) ([]incoming_webhook.Action, error)) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, funcName, err)
+	}()
+
+	if settings.Get().AdvancedSettings.IncomingWebhookSettings.Enable == false {
+		err = errors.New("incoming webhook is disabled")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return
+	}
+	actions, err := parse(body)
+	if err != nil {
+		return
+	}
+
+	results := incoming_webhook.NewProcessor(cb.log, cb.cronHelper.DownloadQueue).Process(actions)
+
+	c.JSON(http.StatusOK, backend2.ReplyIncomingWebhook{
+		Message: "ok",
+		Results: results,
+	})
+}
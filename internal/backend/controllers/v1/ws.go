@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend/ws"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/log_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
+	task_queue3 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+/*
+	WebSocketHandler 实时推送当前扫描的日志以及任务状态的变化
+	1. 连接后 Client 需要在 wsAuthTimeout 内发送 auth 消息，data 是 ws.Login，Token 校验失败会断开连接
+	2. 校验通过后，Server 会定时推送当前正在扫描的日志中新增的行（running_log，data 是 log_hub.OnceLog）
+	3. 任务新增或者状态变化的时候，Server 会主动推送（sub_download_jobs_status，data 是 task_queue.OneJob）
+	浏览器的 WebSocket 不能自定义 Header，所以这个接口不走 CheckAuth，在握手消息中校验 Token
+*/
+func (cb *ControllerBase) WebSocketHandler(c *gin.Context) {
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		cb.log.Errorln("WebSocketHandler.Upgrade", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if cb.wsAuth(conn) == false {
+		return
+	}
+	// 任务状态变化的通知，回调中不能阻塞，所以满了就丢弃
+	jobChan := make(chan task_queue3.OneJob, wsJobChanSize)
+	listenerName := fmt.Sprintf("ws_%p", conn)
+	cb.cronHelper.DownloadQueue.AddJobStatusChangedListener(listenerName, func(oneJob task_queue3.OneJob) {
+		select {
+		case jobChan <- oneJob:
+		default:
+			cb.log.Warningln("WebSocketHandler job status chan is full, drop", oneJob.Id)
+		}
+	})
+	defer cb.cronHelper.DownloadQueue.RemoveJobStatusChangedListener(listenerName)
+	// 读取 Client 的消息，仅仅是为了感知连接的断开以及处理 ping pong，内容不做处理
+	readDone := make(chan interface{})
+	go func() {
+		defer close(readDone)
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// 所有的写都在这个循环中进行，gorilla/websocket 不支持并发写
+	logTicker := time.NewTicker(wsRunningLogInterval)
+	defer logTicker.Stop()
+	pingTicker := time.NewTicker(wsPingInterval)
+	defer pingTicker.Stop()
+	sentLineCount := 0
+	for {
+		select {
+		case <-readDone:
+			return
+		case oneJob := <-jobChan:
+			err = wsWriteMessage(conn, ws.SubDownloadJobsStatus, oneJob)
+			if err != nil {
+				cb.log.Debugln("WebSocketHandler.SubDownloadJobsStatus", err)
+				return
+			}
+		case <-logTicker.C:
+			nowOnceLog := log_helper.GetOnceLog4Running()
+			if len(nowOnceLog.LogLines) < sentLineCount {
+				// 新的一次扫描开始了，从头开始发送
+				sentLineCount = 0
+			}
+			if len(nowOnceLog.LogLines) == sentLineCount {
+				continue
+			}
+			newOnceLog := log_hub.NewOnceLog(nowOnceLog.Index)
+			newOnceLog.LogLines = nowOnceLog.LogLines[sentLineCount:]
+			err = wsWriteMessage(conn, ws.RunningLog, newOnceLog)
+			if err != nil {
+				cb.log.Debugln("WebSocketHandler.RunningLog", err)
+				return
+			}
+			sentLineCount = len(nowOnceLog.LogLines)
+		case <-pingTicker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsAuth 等待 Client 发送的 auth 消息，并回复校验的结果
+func (cb *ControllerBase) wsAuth(conn *websocket.Conn) bool {
+
+	_ = conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	authOk := false
+	_, message, err := conn.ReadMessage()
+	if err == nil {
+		baseMessage := ws.BaseMessage{}
+		login := ws.Login{}
+		if json.Unmarshal(message, &baseMessage) == nil && baseMessage.Type == ws.Auth.String() &&
+			json.Unmarshal([]byte(baseMessage.Data), &login) == nil {
+			_, authErr := user_center.Get(cb.log).CheckSession(login.Token)
+			authOk = authErr == nil
+		}
+	}
+	replyMessage := ws.AuthOk.String()
+	if authOk == false {
+		replyMessage = ws.AuthError.String()
+	}
+	err = wsWriteMessage(conn, ws.Auth, ws.Reply{Message: replyMessage})
+	if err != nil {
+		cb.log.Debugln("WebSocketHandler.wsAuth", err)
+		return false
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	return authOk
+}
+
+// wsWriteMessage 把 data 序列化后包装成 BaseMessage 发送出去
+func wsWriteMessage(conn *websocket.Conn, wsType ws.WSType, data interface{}) error {
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	messageBytes, err := ws.NewBaseMessage(wsType.String(), string(dataBytes)).Bytes()
+	if err != nil {
+		return err
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteMessage(websocket.TextMessage, messageBytes)
+}
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// 前端可能是单独部署的（开发的时候），所以不校验 Origin，靠握手的 Token 来鉴权
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+const (
+	wsAuthTimeout        = 5 * time.Second
+	wsWriteWait          = 10 * time.Second
+	wsPongWait           = 60 * time.Second
+	wsPingInterval       = wsPongWait * 9 / 10
+	wsRunningLogInterval = 1 * time.Second
+	wsJobChanSize        = 100
+)
@@ -0,0 +1,108 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler 使用所有可用的字幕源搜索一个视频的字幕，结果通过 CandidatesHandler 获取
+func (cb *ControllerBase) SearchHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "SearchHandler", err)
+	}()
+
+	req := backend.ReqCandidateSearch{}
+	if cb.bindJSON(c, &req) == false {
+		return
+	}
+	if cb.checkVideoExist(c, req.VideoFPath) == false {
+		return
+	}
+
+	videoType := common.Movie
+	if req.VideoType == 1 {
+		videoType = common.Series
+	}
+	searchErr := cb.cronHelper.Downloader.SearchCandidates(req.VideoFPath, videoType)
+	if errors.Is(searchErr, downloader.ErrCandidateSearchRunning) == true {
+		cb.replyError(c, http.StatusConflict, backend.V2ErrCodeConflict, searchErr.Error())
+		return
+	} else if searchErr != nil {
+		err = searchErr
+		return
+	}
+
+	nowSearch, _ := cb.cronHelper.Downloader.GetCandidateSearch(req.VideoFPath)
+	c.JSON(http.StatusAccepted, nowSearch)
+}
+
+// CandidatesHandler 一个视频的字幕搜索状态以及候选字幕列表
+func (cb *ControllerBase) CandidatesHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "CandidatesHandler", err)
+	}()
+
+	req := backend.ReqV2Video{}
+	if cb.bindQuery(c, &req) == false {
+		return
+	}
+
+	nowSearch, found := cb.cronHelper.Downloader.GetCandidateSearch(req.VideoFPath)
+	if found == false {
+		cb.replyError(c, http.StatusNotFound, backend.V2ErrCodeNotFound, "no search for this video, POST /videos/search first")
+		return
+	}
+
+	c.JSON(http.StatusOK, nowSearch)
+}
+
+// ChooseCandidateHandler 选择一个候选字幕，在后台保存到视频目录，返回任务的 ID
+func (cb *ControllerBase) ChooseCandidateHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "ChooseCandidateHandler", err)
+	}()
+
+	req := backend.ReqOneCandidate{}
+	if cb.bindJSON(c, &req) == false {
+		return
+	}
+	if cb.checkVideoExist(c, req.VideoFPath) == false {
+		return
+	}
+
+	nowChoose, chooseErr := cb.cronHelper.Downloader.ChooseCandidate(req.VideoFPath, req.CandidateID)
+	if errors.Is(chooseErr, downloader.ErrCandidateNotFound) == true {
+		cb.replyError(c, http.StatusNotFound, backend.V2ErrCodeNotFound, chooseErr.Error())
+		return
+	} else if chooseErr != nil {
+		err = chooseErr
+		return
+	}
+
+	c.JSON(http.StatusAccepted, nowChoose)
+}
+
+// ChooseCandidateStatusHandler 获取选择候选字幕任务的结果
+func (cb *ControllerBase) ChooseCandidateStatusHandler(c *gin.Context) {
+
+	jobID := c.Param("id")
+	nowChoose, found := cb.cronHelper.Downloader.GetCandidateChoose(jobID)
+	if found == false {
+		cb.replyError(c, http.StatusNotFound, backend.V2ErrCodeNotFound, fmt.Sprintf("choose job not found, %s", jobID))
+		return
+	}
+
+	c.JSON(http.StatusOK, nowChoose)
+}
@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/cron_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+)
+
+// ControllerBase /api/v2 对外的 API，接口都在 routes 中定义，同时用于生成 OpenAPI 文档
+type ControllerBase struct {
+	log         *logrus.Logger
+	cronHelper  *cron_helper.CronHelper
+	routes      []Route
+	openAPIOnce sync.Once
+	openAPISpec []byte
+}
+
+func NewControllerBase(cronHelper *cron_helper.CronHelper) *ControllerBase {
+	cb := &ControllerBase{
+		log:        cronHelper.Logger,
+		cronHelper: cronHelper,
+	}
+	cb.routes = cb.newRoutes()
+
+	return cb
+}
+
+func (cb *ControllerBase) GetVersion() string {
+	return "v2"
+}
+
+// GetRoutes 所有的接口，路径是相对于 /api/v2 的
+func (cb *ControllerBase) GetRoutes() []Route {
+	return cb.routes
+}
+
+// ErrorProcess 统一的异常处理，内部错误使用统一的错误结构回复
+func (cb *ControllerBase) ErrorProcess(c *gin.Context, funcName string, err error) {
+	if err != nil {
+		cb.log.Errorln(funcName, err.Error())
+		c.JSON(http.StatusInternalServerError, backend.NewReplyV2Error(backend.V2ErrCodeInternal, err.Error()))
+	}
+}
+
+// replyError 回复统一的错误结构
+func (cb *ControllerBase) replyError(c *gin.Context, httpStatus int, code string, message string) {
+	c.JSON(httpStatus, backend.NewReplyV2Error(code, message))
+}
+
+// bindJSON 解析并校验 JSON 请求，失败的时候已经回复了 400
+func (cb *ControllerBase) bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		cb.replyError(c, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// bindQuery 解析并校验查询参数，失败的时候已经回复了 400
+func (cb *ControllerBase) bindQuery(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindQuery(req)
+	if err != nil {
+		cb.replyError(c, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// bindForm 解析并校验 multipart/form-data 请求，失败的时候已经回复了 400
+func (cb *ControllerBase) bindForm(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindWith(req, binding.FormMultipart)
+	if err != nil {
+		cb.replyError(c, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	task_queue2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+	"github.com/gin-gonic/gin"
+)
+
+// JobListHandler 下载队列中的所有任务
+func (cb *ControllerBase) JobListHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "JobListHandler", err)
+	}()
+
+	bok, allJobs, err := cb.cronHelper.DownloadQueue.GetAllJobs()
+	if err != nil {
+		return
+	}
+	if bok == false {
+		allJobs = make([]task_queue.OneJob, 0)
+	}
+
+	c.JSON(http.StatusOK, backend.ReplyAllJobs{
+		AllJobs: allJobs,
+	})
+}
+
+// AddJobHandler 添加一个下载任务，已经在队列中的时候回复 409
+func (cb *ControllerBase) AddJobHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "AddJobHandler", err)
+	}()
+
+	req := backend.ReqV2AddJob{}
+	if cb.bindJSON(c, &req) == false {
+		return
+	}
+	// 蓝光的是伪造的路径，不需要检测文件是否存在
+	if req.IsBluray == false && cb.checkVideoExist(c, req.PhysicalVideoFileFullPath) == false {
+		return
+	}
+
+	videoType := common.Movie
+	if req.VideoType == 1 {
+		videoType = common.Series
+	}
+	nowJob := task_queue.NewOneJob(videoType, req.PhysicalVideoFileFullPath, req.TaskPriorityLevel, req.MediaServerInsideVideoID)
+	if videoType == common.Series {
+		// 连续剧的时候需要额外提交信息
+		epsVideoNfoInfo, nfoErr := decode.GetVideoNfoInfo4OneSeriesEpisode(req.PhysicalVideoFileFullPath)
+		if nfoErr != nil {
+			cb.replyError(c, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest, nfoErr.Error())
+			return
+		}
+		seriesInfoDirPath := decode.GetSeriesDirRootFPath(req.PhysicalVideoFileFullPath)
+		if seriesInfoDirPath == "" {
+			cb.replyError(c, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest,
+				fmt.Sprintf("series root dir (with tvshow.nfo) not found, %s", req.PhysicalVideoFileFullPath))
+			return
+		}
+		nowJob.Season = epsVideoNfoInfo.Season
+		nowJob.Episode = epsVideoNfoInfo.Episode
+		nowJob.SeriesRootDirPath = seriesInfoDirPath
+	}
+
+	bok, err := cb.cronHelper.DownloadQueue.Add(*nowJob)
+	if err != nil {
+		return
+	}
+	if bok == false {
+		cb.replyError(c, http.StatusConflict, backend.V2ErrCodeConflict, fmt.Sprintf("job is already in queue, %s", nowJob.Id))
+		return
+	}
+
+	c.JSON(http.StatusCreated, backend.ReplyV2Job{
+		JobID:   nowJob.Id,
+		Message: "ok",
+	})
+}
+
+// GetJobHandler 获取一个任务
+func (cb *ControllerBase) GetJobHandler(c *gin.Context) {
+
+	nowJob, found := cb.getJob(c)
+	if found == false {
+		return
+	}
+
+	c.JSON(http.StatusOK, nowJob)
+}
+
+// ChangeJobHandler 修改一个任务的优先级和状态，状态只允许 Waiting(0) or Ignore(5)
+func (cb *ControllerBase) ChangeJobHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "ChangeJobHandler", err)
+	}()
+
+	req := backend.ReqV2ChangeJob{}
+	if cb.bindJSON(c, &req) == false {
+		return
+	}
+	nowJob, found := cb.getJob(c)
+	if found == false {
+		return
+	}
+
+	switch req.TaskPriority {
+	case "high":
+		nowJob.TaskPriority = task_queue2.HighTaskPriorityLevel
+	case "middle":
+		nowJob.TaskPriority = task_queue2.DefaultTaskPriorityLevel
+	default:
+		nowJob.TaskPriority = task_queue2.LowTaskPriorityLevel
+	}
+	nowJob.JobStatus = req.JobStatus
+
+	bok, err := cb.cronHelper.DownloadQueue.Update(nowJob)
+	if err != nil {
+		return
+	}
+	if bok == false {
+		err = fmt.Errorf("update job failed, %s", nowJob.Id)
+		return
+	}
+
+	c.JSON(http.StatusOK, backend.ReplyV2Job{
+		JobID:   nowJob.Id,
+		Message: "ok",
+	})
+}
+
+// DelJobHandler 删除一个任务
+func (cb *ControllerBase) DelJobHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "DelJobHandler", err)
+	}()
+
+	nowJob, found := cb.getJob(c)
+	if found == false {
+		return
+	}
+
+	bok, err := cb.cronHelper.DownloadQueue.Del(nowJob.Id)
+	if err != nil {
+		return
+	}
+	if bok == false {
+		err = fmt.Errorf("del job failed, %s", nowJob.Id)
+		return
+	}
+
+	c.JSON(http.StatusOK, backend.ReplyV2Job{
+		JobID:   nowJob.Id,
+		Message: "ok",
+	})
+}
+
+// getJob 从路径中的 :id 找到任务，找不到的时候已经回复了 404
+func (cb *ControllerBase) getJob(c *gin.Context) (task_queue.OneJob, bool) {
+
+	jobID := c.Param("id")
+	found, nowJob := cb.cronHelper.DownloadQueue.GetOneJobByID(jobID)
+	if found == false {
+		cb.replyError(c, http.StatusNotFound, backend.V2ErrCodeNotFound, fmt.Sprintf("job not found, %s", jobID))
+		return task_queue.OneJob{}, false
+	}
+	return nowJob, true
+}
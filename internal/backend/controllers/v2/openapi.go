@@ -0,0 +1,365 @@
+package v2
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIHandler 由 routes 生成的 OpenAPI 3.0 文档，不需要鉴权
+func (cb *ControllerBase) OpenAPIHandler(c *gin.Context) {
+
+	cb.openAPIOnce.Do(func() {
+		var err error
+		cb.openAPISpec, err = json.Marshal(NewOpenAPISpec(cb.routes, "/api/"+cb.GetVersion(), pkg.AppVersion()))
+		if err != nil {
+			cb.log.Errorln("OpenAPIHandler.Marshal", err)
+		}
+	})
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", cb.openAPISpec)
+}
+
+/*
+	NewOpenAPISpec 从接口的定义生成 OpenAPI 3.0 文档
+	1. 请求、回复的结构通过反射 json、form tag 生成，有名字的结构放到 components/schemas 中
+	2. binding tag 中有 required 的字段是必填的
+	3. 所有的接口错误的时候都回复 ReplyV2Error
+*/
+func NewOpenAPISpec(routes []Route, basePath string, appVersion string) map[string]interface{} {
+
+	g := newSchemaGenerator()
+	errorSchema := g.schemaOf(reflect.TypeOf(backend.ReplyV2Error{}))
+
+	paths := make(map[string]interface{})
+	for _, route := range routes {
+
+		replyStatus := route.ReplyStatus
+		if replyStatus == 0 {
+			replyStatus = http.StatusOK
+		}
+		openAPIPath, pathParams := convertPath(route.Path)
+		parameters := make([]interface{}, 0)
+		for _, param := range pathParams {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     param,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		if route.Query != nil {
+			parameters = append(parameters, g.queryParameters(reflect.TypeOf(route.Query))...)
+		}
+
+		operation := map[string]interface{}{
+			"operationId": operationID(route.Method, route.Path),
+			"summary":     route.Summary,
+			"tags":        []string{route.Tag},
+			"responses": map[string]interface{}{
+				strconv.Itoa(replyStatus): map[string]interface{}{
+					"description": http.StatusText(replyStatus),
+					"content":     jsonContent(g.schemaOf(reflect.TypeOf(route.Reply))),
+				},
+				"default": map[string]interface{}{
+					"description": "错误，error.code 见 invalid_request unauthorized forbidden not_found conflict internal_error",
+					"content":     jsonContent(errorSchema),
+				},
+			},
+		}
+		if route.Role != "" {
+			operation["description"] = "需要的最低角色：" + route.Role
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(g.schemaOf(reflect.TypeOf(route.Body))),
+			}
+		} else if route.Form != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"multipart/form-data": map[string]interface{}{
+						"schema": g.structSchema(reflect.TypeOf(route.Form), "form"),
+					},
+				},
+			}
+		}
+
+		pathItem, found := paths[openAPIPath]
+		if found == false {
+			pathItem = make(map[string]interface{})
+			paths[openAPIPath] = pathItem
+		}
+		pathItem.(map[string]interface{})[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "ChineseSubFinder API",
+			"description": "需要在设置中开启 API Key，使用 Authorization: Bearer <api_key> 鉴权",
+			"version":     appVersion,
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": basePath},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKeyQuery": []string{}},
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+				"apiKeyQuery": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "query",
+					"name": "api_key",
+				},
+			},
+		},
+	}
+}
+
+// schemaGenerator 通过反射生成 JSON Schema，有名字的结构只生成一次
+type schemaGenerator struct {
+	schemas map[string]interface{}  // components/schemas
+	names   map[reflect.Type]string // 结构对应的 schema 名称
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]interface{}),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == fileHeaderType {
+		return map[string]interface{}{"type": "string", "format": "binary"}
+	}
+	// time.Time 以及 emby.Time 这种自定义序列化的时间
+	if t.Kind() == reflect.Struct && t.ConvertibleTo(timeType) == true {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	// 其他自定义序列化的结构，无法得知序列化的结果
+	if t.Implements(jsonMarshalerType) == true || reflect.PtrTo(t).Implements(jsonMarshalerType) == true {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, "json")
+		}
+		name := g.schemaName(t)
+		if _, found := g.schemas[name]; found == false {
+			// 先占位，避免结构嵌套自己的时候死循环
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.structSchema(t, "json")
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema 结构的 schema，tagName 是 json 或者 form
+func (g *schemaGenerator) structSchema(t reflect.Type, tagName string) map[string]interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	g.fillProperties(t, tagName, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) fillProperties(t reflect.Type, tagName string, properties map[string]interface{}, required *[]string) {
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, skip := fieldName(field, tagName)
+		if skip == true {
+			continue
+		}
+		// 匿名嵌入且没有指定名称的结构，字段是平铺的
+		if field.Anonymous == true && name == "" {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.fillProperties(fieldType, tagName, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaOf(field.Type)
+		if isRequired(field) == true {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// queryParameters 查询参数的结构转换为 OpenAPI 的 parameters
+func (g *schemaGenerator) queryParameters(t reflect.Type) []interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	parameters := make([]interface{}, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, skip := fieldName(field, "form")
+		if skip == true {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "query",
+			"required": isRequired(field),
+			"schema":   g.schemaOf(field.Type),
+		})
+	}
+	return parameters
+}
+
+// schemaName 结构的名称，不同的包有同名结构的时候加上包名
+func (g *schemaGenerator) schemaName(t reflect.Type) string {
+
+	if name, found := g.names[t]; found == true {
+		return name
+	}
+	name := t.Name()
+	for other, otherName := range g.names {
+		if otherName == name && other != t {
+			name = path.Base(t.PkgPath()) + "." + t.Name()
+			break
+		}
+	}
+	g.names[t] = name
+	return name
+}
+
+// fieldName 字段在 tag 中的名称，没有 tag 的时候返回空，skip 是不需要输出的字段
+func fieldName(field reflect.StructField, tagName string) (string, bool) {
+
+	if field.PkgPath != "" && field.Anonymous == false {
+		// 未导出的字段
+		return "", true
+	}
+	switch field.Type.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return "", true
+	}
+	tag := field.Tag.Get(tagName)
+	if tag == "-" {
+		return "", true
+	}
+	return strings.Split(tag, ",")[0], false
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// convertPath gin 的路径 /jobs/:id 转换为 OpenAPI 的 /jobs/{id}，同时返回路径参数
+func convertPath(ginPath string) (string, []string) {
+
+	params := make([]string, 0)
+	parts := strings.Split(ginPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") == true || strings.HasPrefix(part, "*") == true {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// operationID GET /jobs/:id -> get_jobs_id
+func operationID(method string, ginPath string) string {
+
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(ginPath, "/") {
+		part = strings.TrimLeft(part, ":*")
+		if part == "" {
+			continue
+		}
+		id += "_" + part
+	}
+	return id
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": schema,
+		},
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	fileHeaderType    = reflect.TypeOf(multipart.FileHeader{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
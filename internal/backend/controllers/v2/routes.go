@@ -0,0 +1,110 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
+	"github.com/gin-gonic/gin"
+)
+
+// Route /api/v2 的一个接口，注册路由以及生成 OpenAPI 文档都使用这个定义
+type Route struct {
+	Method      string          // GET POST PUT DELETE
+	Path        string          // 相对于 /api/v2 的路径，gin 的格式，比如 /jobs/:id
+	Tag         string          // 接口的分组
+	Summary     string          // 接口的说明
+	Role        string          // 需要的最低角色，API Key 的权限跟随所属用户的角色
+	Query       interface{}     // 查询参数的结构，使用 form tag，没有就是 nil
+	Body        interface{}     // JSON 请求的结构，没有就是 nil
+	Form        interface{}     // multipart/form-data 请求的结构，使用 form tag，没有就是 nil
+	ReplyStatus int             // 成功的时候回复的 HTTP 状态码
+	Reply       interface{}     // 成功的时候回复的结构
+	Handler     gin.HandlerFunc // 处理函数
+}
+
+func (cb *ControllerBase) newRoutes() []Route {
+
+	return []Route{
+		{
+			Method: http.MethodGet, Path: "/videos", Tag: "videos", Role: user_center.RoleReadOnly,
+			Summary:     "视频库的电影和连续剧列表，需要先扫描过一次视频库",
+			ReplyStatus: http.StatusOK, Reply: backend.ReplyV2VideoList{},
+			Handler: cb.VideoListHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/videos/subtitles", Tag: "videos", Role: user_center.RoleReadOnly,
+			Summary: "一个视频目录下已有的字幕",
+			Query:   backend.ReqV2Video{}, ReplyStatus: http.StatusOK, Reply: backend.ReplyV2Subtitles{},
+			Handler: cb.VideoSubtitlesHandler,
+		},
+		{
+			Method: http.MethodPost, Path: "/videos/subtitles/upload", Tag: "videos", Role: user_center.RoleOperator,
+			Summary: "上传一个字幕，在后台保存到视频目录",
+			Form:    backend.ReqV2UploadSubtitle{}, ReplyStatus: http.StatusAccepted, Reply: backend.ReplyCommon{},
+			Handler: cb.UploadSubtitleHandler,
+		},
+		{
+			Method: http.MethodPost, Path: "/videos/search", Tag: "candidates", Role: user_center.RoleOperator,
+			Summary: "使用所有可用的字幕源搜索一个视频的字幕，在后台执行",
+			Body:    backend.ReqCandidateSearch{}, ReplyStatus: http.StatusAccepted, Reply: backend.ReplyCandidateSearch{},
+			Handler: cb.SearchHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/videos/candidates", Tag: "candidates", Role: user_center.RoleReadOnly,
+			Summary: "一个视频的字幕搜索状态以及候选字幕列表",
+			Query:   backend.ReqV2Video{}, ReplyStatus: http.StatusOK, Reply: backend.ReplyCandidateSearch{},
+			Handler: cb.CandidatesHandler,
+		},
+		{
+			Method: http.MethodPost, Path: "/videos/candidates/choose", Tag: "candidates", Role: user_center.RoleOperator,
+			Summary: "选择一个候选字幕，在后台保存到视频目录，返回任务的 ID",
+			Body:    backend.ReqOneCandidate{}, ReplyStatus: http.StatusAccepted, Reply: backend.ReplyCandidateChoose{},
+			Handler: cb.ChooseCandidateHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/videos/candidates/choose/:id", Tag: "candidates", Role: user_center.RoleReadOnly,
+			Summary:     "选择候选字幕任务的状态",
+			ReplyStatus: http.StatusOK, Reply: backend.ReplyCandidateChoose{},
+			Handler: cb.ChooseCandidateStatusHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/jobs", Tag: "jobs", Role: user_center.RoleReadOnly,
+			Summary:     "下载队列中的所有任务",
+			ReplyStatus: http.StatusOK, Reply: backend.ReplyAllJobs{},
+			Handler: cb.JobListHandler,
+		},
+		{
+			Method: http.MethodPost, Path: "/jobs", Tag: "jobs", Role: user_center.RoleOperator,
+			Summary: "添加一个下载任务",
+			Body:    backend.ReqV2AddJob{}, ReplyStatus: http.StatusCreated, Reply: backend.ReplyV2Job{},
+			Handler: cb.AddJobHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/jobs/:id", Tag: "jobs", Role: user_center.RoleReadOnly,
+			Summary:     "获取一个任务",
+			ReplyStatus: http.StatusOK, Reply: task_queue.OneJob{},
+			Handler: cb.GetJobHandler,
+		},
+		{
+			Method: http.MethodPut, Path: "/jobs/:id", Tag: "jobs", Role: user_center.RoleOperator,
+			Summary: "修改一个任务的优先级和状态",
+			Body:    backend.ReqV2ChangeJob{}, ReplyStatus: http.StatusOK, Reply: backend.ReplyV2Job{},
+			Handler: cb.ChangeJobHandler,
+		},
+		{
+			Method: http.MethodDelete, Path: "/jobs/:id", Tag: "jobs", Role: user_center.RoleOperator,
+			Summary:     "删除一个任务",
+			ReplyStatus: http.StatusOK, Reply: backend.ReplyV2Job{},
+			Handler: cb.DelJobHandler,
+		},
+		{
+			Method: http.MethodGet, Path: "/settings", Tag: "settings", Role: user_center.RoleAdmin,
+			Summary:     "当前的设置，密码、Token、API Key 等敏感的信息替换为 ******",
+			ReplyStatus: http.StatusOK, Reply: settings.Settings{},
+			Handler: cb.SettingsHandler,
+		},
+	}
+}
@@ -0,0 +1,14 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler 当前的设置，只读，密码、Token、API Key 等敏感的信息会替换为 ******
+func (cb *ControllerBase) SettingsHandler(c *gin.Context) {
+
+	c.JSON(http.StatusOK, settings.Get().GetRedactedSettings())
+}
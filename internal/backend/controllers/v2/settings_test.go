@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+// TestSettingsHandler_Redacted 每一个敏感的字段都不能出现在回复中，非敏感的字段原样返回
+func TestSettingsHandler_Redacted(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	nowSettings := settings.Get()
+	nowSettings.UserInfo = settings.NewUserInfo("admin", "secret-user-password")
+	nowSettings.EmbySettings.AddressUrl = "http://192.168.1.10:8096"
+	nowSettings.EmbySettings.APIKey = "secret-emby-api-key"
+	nowSettings.DeveloperSettings.BarkServerAddress = "https://api.day.app/secret-bark-key"
+	nowSettings.SubtitleSources.AssrtSettings.Token = "secret-assrt-token"
+	nowSettings.SubtitleSources.SubtitleBestSettings.ApiKey = "secret-subtitle-best-api-key"
+	nowSettings.SubtitleSources.OpenSubtitlesSettings.ApiKey = "secret-opensubtitles-api-key"
+	nowSettings.SubtitleSources.OpenSubtitlesSettings.Password = "secret-opensubtitles-password"
+	nowSettings.SubtitleSources.CustomSuppliers = []settings.CustomSupplierSettings{
+		{Name: "custom", Headers: map[string]string{"Api-Key": "secret-custom-header"},
+			SearchMovieByImdbUrl: "/api/search?imdb={imdb_id}&apikey=secret-custom-query"},
+	}
+	nowSettings.ExperimentalFunction.ApiKeySettings.Key = "secret-api-key"
+	nowSettings.ExperimentalFunction.ShareSubSettings.SubShareCenter.SenderEmailPwd = "secret-smtp-password"
+	nowSettings.ExperimentalFunction.ShareSubSettings.HttpUploadToken = "secret-http-upload-token"
+	nowSettings.AdvancedSettings.ProxySettings.InputProxyPassword = "secret-proxy-password"
+	nowSettings.AdvancedSettings.TmdbApiSettings.ApiKey = "secret-tmdb-api-key"
+	nowSettings.AdvancedSettings.NotifySettings.Channels = []settings.NotifyChannelSettings{
+		{Name: "tg", Url: "tgram://secret-bot-token/chat_id"},
+	}
+	nowSettings.AdvancedSettings.PostSaveHookSettings.Hooks = []settings.PostSaveHook{
+		{Name: "hook", Headers: map[string]string{"Authorization": "secret-hook-header"}},
+	}
+	nowSettings.AdvancedSettings.PeerLibrarySettings.ServeToken = "secret-serve-token"
+	nowSettings.AdvancedSettings.PeerLibrarySettings.Peers = []settings.PeerLibraryPeer{
+		{Name: "peer", Url: "http://192.168.1.11:19035", Token: "secret-peer-token"},
+	}
+	err := nowSettings.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/settings", nil)
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %v, want %v", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "secret-") == true {
+		t.Fatalf("secret found in reply: %v", body)
+	}
+	var reply settings.Settings
+	err = json.Unmarshal(w.Body.Bytes(), &reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.EmbySettings.AddressUrl != "http://192.168.1.10:8096" {
+		t.Errorf("emby address_url = %v, not secret should be kept", reply.EmbySettings.AddressUrl)
+	}
+	if reply.EmbySettings.APIKey != "******" || reply.AdvancedSettings.PeerLibrarySettings.Peers[0].Token != "******" ||
+		reply.SubtitleSources.CustomSuppliers[0].Headers["Api-Key"] != "******" ||
+		reply.SubtitleSources.CustomSuppliers[0].SearchMovieByImdbUrl != "/api/search?imdb={imdb_id}&apikey=******" {
+		t.Errorf("secret should be replaced by ******")
+	}
+
+	// Web 端原样提交打码后的设置，敏感字段保持原来的值
+	reply.RestoreRedacted(settings.Get())
+	if reply.EmbySettings.APIKey != "secret-emby-api-key" ||
+		reply.AdvancedSettings.PeerLibrarySettings.Peers[0].Token != "secret-peer-token" ||
+		reply.SubtitleSources.CustomSuppliers[0].Headers["Api-Key"] != "secret-custom-header" ||
+		reply.SubtitleSources.CustomSuppliers[0].SearchMovieByImdbUrl != "/api/search?imdb={imdb_id}&apikey=secret-custom-query" ||
+		reply.AdvancedSettings.NotifySettings.Channels[0].Url != "tgram://secret-bot-token/chat_id" {
+		t.Errorf("RestoreRedacted should restore the secrets")
+	}
+}
@@ -0,0 +1,251 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/backend/middle"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testApiKey = "test-api-key"
+
+// newTestRouter 这里的请求都会在参数校验阶段返回，不会用到 cronHelper
+func newTestRouter(t *testing.T) *gin.Engine {
+
+	gin.SetMode(gin.TestMode)
+	common.SetApiEnabled(true)
+	cb := &ControllerBase{log: logrus.New()}
+	cb.routes = cb.newRoutes()
+
+	router := gin.New()
+	group := router.Group("/api/v2")
+	group.Use(middle.CheckApiAuthV2(newTestUserCenter(t)))
+	for _, route := range cb.GetRoutes() {
+		group.Handle(route.Method, route.Path, middle.RequireRoleV2(route.Role), route.Handler)
+	}
+	return router
+}
+
+// newTestUserCenter 使用临时的数据库，testApiKey 从 settings 迁移，属于管理员，testReadOnlyApiKey 属于只读用户
+func newTestUserCenter(t *testing.T) *user_center.UserCenter {
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.UserSession{}, &models.UserApiKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := user_center.NewUserCenter(logrus.New(), db)
+	nowSettings := &settings.Settings{
+		UserInfo:             settings.NewUserInfo("admin", "123456"),
+		ExperimentalFunction: &settings.ExperimentalFunction{ApiKeySettings: settings.ApiKeySettings{Key: testApiKey}},
+	}
+	_, err = uc.MigrateFromSettings(nowSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnlyUser, err := uc.AddUser("reader", "123456", user_center.RoleReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReadOnlyApiKey, _, err = uc.AddApiKey(readOnlyUser.ID, "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uc
+}
+
+var testReadOnlyApiKey string
+
+func TestRequestValidation(t *testing.T) {
+
+	router := newTestRouter(t)
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		wantCode int
+		wantErr  string
+	}{
+		{"subtitles missing video_f_path", http.MethodGet, "/api/v2/videos/subtitles", "", http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"subtitles video not found", http.MethodGet, "/api/v2/videos/subtitles?video_f_path=/not/exist.mp4", "", http.StatusNotFound, backend.V2ErrCodeNotFound},
+		{"search wrong json", http.MethodPost, "/api/v2/videos/search", "{", http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"search missing video_f_path", http.MethodPost, "/api/v2/videos/search", `{"video_type":0}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"search wrong video_type", http.MethodPost, "/api/v2/videos/search", `{"video_f_path":"/a.mp4","video_type":2}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"search video not found", http.MethodPost, "/api/v2/videos/search", `{"video_f_path":"/not/exist.mp4","video_type":0}`, http.StatusNotFound, backend.V2ErrCodeNotFound},
+		{"candidates missing video_f_path", http.MethodGet, "/api/v2/videos/candidates", "", http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"choose missing candidate_id", http.MethodPost, "/api/v2/videos/candidates/choose", `{"video_f_path":"/a.mp4"}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"add job missing path", http.MethodPost, "/api/v2/jobs", `{"video_type":0}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"add job wrong priority", http.MethodPost, "/api/v2/jobs", `{"physical_video_file_full_path":"/a.mp4","task_priority_level":11}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"add job video not found", http.MethodPost, "/api/v2/jobs", `{"physical_video_file_full_path":"/not/exist.mp4"}`, http.StatusNotFound, backend.V2ErrCodeNotFound},
+		{"change job wrong priority", http.MethodPut, "/api/v2/jobs/123", `{"task_priority":"urgent","job_status":0}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+		{"change job wrong status", http.MethodPut, "/api/v2/jobs/123", `{"task_priority":"high","job_status":3}`, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testApiKey)
+			checkErrorReply(t, router, req, tt.wantCode, tt.wantErr)
+		})
+	}
+}
+
+func TestUploadSubtitleValidation(t *testing.T) {
+
+	router := newTestRouter(t)
+	// 缺少字幕文件
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("video_f_path", "/a.mp4")
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/videos/subtitles/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	checkErrorReply(t, router, req, http.StatusBadRequest, backend.V2ErrCodeInvalidRequest)
+	// 视频不存在
+	body = &bytes.Buffer{}
+	writer = multipart.NewWriter(body)
+	_ = writer.WriteField("video_f_path", "/not/exist.mp4")
+	part, _ := writer.CreateFormFile("file", "a.srt")
+	_, _ = part.Write([]byte("1\n00:00:01,000 --> 00:00:02,000\n你好\n"))
+	_ = writer.Close()
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/videos/subtitles/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	checkErrorReply(t, router, req, http.StatusNotFound, backend.V2ErrCodeNotFound)
+}
+
+// TestVideoNotInLibrary 视频库以外的文件不能搜索、上传字幕
+func TestVideoNotInLibrary(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	router := newTestRouter(t)
+	videoFPath := filepath.Join(t.TempDir(), "Foo.mkv")
+	if err := os.WriteFile(videoFPath, []byte(""), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(backend.ReqCandidateSearch{VideoFPath: videoFPath})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/videos/search", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	checkErrorReply(t, router, req, http.StatusForbidden, backend.V2ErrCodeForbidden)
+}
+
+func TestApiAuth(t *testing.T) {
+
+	router := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/jobs", nil)
+	checkErrorReply(t, router, req, http.StatusUnauthorized, backend.V2ErrCodeUnauthorized)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/jobs", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	checkErrorReply(t, router, req, http.StatusUnauthorized, backend.V2ErrCodeUnauthorized)
+
+	// 只有 Webhook 可以使用 ?api_key=xx
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/jobs?api_key="+testApiKey, nil)
+	checkErrorReply(t, router, req, http.StatusUnauthorized, backend.V2ErrCodeUnauthorized)
+
+	// 只读用户的 API Key 不能添加任务、查看设置
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/jobs", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer "+testReadOnlyApiKey)
+	checkErrorReply(t, router, req, http.StatusForbidden, backend.V2ErrCodeForbidden)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/settings", nil)
+	req.Header.Set("Authorization", "Bearer "+testReadOnlyApiKey)
+	checkErrorReply(t, router, req, http.StatusForbidden, backend.V2ErrCodeForbidden)
+
+	// 关闭了 API 接口
+	common.SetApiEnabled(false)
+	defer common.SetApiEnabled(true)
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/jobs", nil)
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	checkErrorReply(t, router, req, http.StatusUnauthorized, backend.V2ErrCodeUnauthorized)
+}
+
+func checkErrorReply(t *testing.T, router *gin.Engine, req *http.Request, wantCode int, wantErr string) {
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != wantCode {
+		t.Fatalf("%s %s, status = %d, want %d, body: %s", req.Method, req.URL, w.Code, wantCode, w.Body.String())
+	}
+	reply := backend.ReplyV2Error{}
+	err := json.Unmarshal(w.Body.Bytes(), &reply)
+	if err != nil {
+		t.Fatalf("%s %s, reply is not ReplyV2Error: %s", req.Method, req.URL, w.Body.String())
+	}
+	if reply.Error.Code != wantErr || reply.Error.Message == "" {
+		t.Fatalf("%s %s, error = %+v, want code %s", req.Method, req.URL, reply.Error, wantErr)
+	}
+}
+
+func TestNewOpenAPISpec(t *testing.T) {
+
+	cb := &ControllerBase{log: logrus.New()}
+	routes := cb.newRoutes()
+	spec := NewOpenAPISpec(routes, "/api/v2", "v0.0.1")
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重新解析，按 JSON 的结构检查
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	err = json.Unmarshal(specBytes, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 所有的接口都需要在文档中
+	for _, route := range routes {
+		openAPIPath, _ := convertPath(route.Path)
+		if _, found := doc.Paths[openAPIPath][strings.ToLower(route.Method)]; found == false {
+			t.Errorf("route %s %s not in spec", route.Method, openAPIPath)
+		}
+	}
+	if _, found := doc.Paths["/jobs/{id}"]; found == false {
+		t.Errorf("path param not converted, paths: %v", doc.Paths)
+	}
+	// 所有的引用都要存在
+	for _, ref := range strings.Split(string(specBytes), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, found := doc.Components.Schemas[name]; found == false {
+			t.Errorf("schema %s not found", name)
+		}
+	}
+	// 必填的字段
+	var search struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	err = json.Unmarshal(doc.Components.Schemas["ReqCandidateSearch"], &search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(search.Required) != 1 || search.Required[0] != "video_f_path" || len(search.Properties) != 2 {
+		t.Errorf("ReqCandidateSearch schema = %s", doc.Components.Schemas["ReqCandidateSearch"])
+	}
+}
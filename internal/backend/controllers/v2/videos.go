@@ -0,0 +1,122 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/manual_upload_sub_2_local"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/gin-gonic/gin"
+)
+
+// VideoListHandler 视频库的电影和连续剧列表
+func (cb *ControllerBase) VideoListHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "VideoListHandler", err)
+	}()
+
+	outMovieInfos, outSeasonInfos, err := cb.cronHelper.Downloader.GetMovieInfoAndSeasonInfoV2()
+	if err != nil {
+		return
+	}
+	if outMovieInfos == nil {
+		outMovieInfos = make([]backend.MovieInfoV2, 0)
+	}
+	if outSeasonInfos == nil {
+		outSeasonInfos = make([]backend.SeasonInfoV2, 0)
+	}
+
+	c.JSON(http.StatusOK, backend.ReplyV2VideoList{
+		Movies: outMovieInfos,
+		Series: outSeasonInfos,
+	})
+}
+
+// VideoSubtitlesHandler 一个视频目录下已有的字幕
+func (cb *ControllerBase) VideoSubtitlesHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "VideoSubtitlesHandler", err)
+	}()
+
+	req := backend.ReqV2Video{}
+	if cb.bindQuery(c, &req) == false {
+		return
+	}
+	if cb.checkVideoExist(c, req.VideoFPath) == false {
+		return
+	}
+
+	matchedSubs, err := sub_helper.SearchMatchedSubFileByOneVideo(cb.log, req.VideoFPath)
+	if err != nil {
+		return
+	}
+	reply := backend.ReplyV2Subtitles{
+		VideoFPath: req.VideoFPath,
+		Subtitles:  make([]backend.V2SubtitleInfo, 0),
+	}
+	for _, subFPath := range matchedSubs {
+		reply.Subtitles = append(reply.Subtitles, backend.V2SubtitleInfo{
+			SubFPath: subFPath,
+			Ext:      filepath.Ext(subFPath),
+			IsForced: sub_helper.IsForcedSubFileName(subFPath),
+		})
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// UploadSubtitleHandler 上传一个字幕，跟 Web 界面的手动上传一样，在后台保存到视频目录
+func (cb *ControllerBase) UploadSubtitleHandler(c *gin.Context) {
+	var err error
+	defer func() {
+		// 统一的异常处理
+		cb.ErrorProcess(c, "UploadSubtitleHandler", err)
+	}()
+
+	req := backend.ReqV2UploadSubtitle{}
+	if cb.bindForm(c, &req) == false {
+		return
+	}
+	if cb.checkVideoExist(c, req.VideoFPath) == false {
+		return
+	}
+
+	basePath, err := pkg.GetManualSubUploadCacheFolder()
+	if err != nil {
+		return
+	}
+	subFPath := filepath.Join(basePath, filepath.Base(req.File.Filename))
+	err = c.SaveUploadedFile(req.File, subFPath)
+	if err != nil {
+		return
+	}
+
+	cb.cronHelper.Downloader.ManualUploadSub2Local.Add(&manual_upload_sub_2_local.Job{
+		VideoFPath: req.VideoFPath,
+		SubFPath:   subFPath,
+	})
+
+	c.JSON(http.StatusAccepted, backend.ReplyCommon{Message: "ok"})
+}
+
+// checkVideoExist 视频文件需要存在，并且在视频库中，不存在的时候已经回复了 404，不在视频库中回复 403
+func (cb *ControllerBase) checkVideoExist(c *gin.Context, videoFPath string) bool {
+	err := scan_rules.CheckInLibrary(videoFPath)
+	if errors.Is(err, scan_rules.ErrFileNotFound) == true {
+		cb.replyError(c, http.StatusNotFound, backend.V2ErrCodeNotFound, fmt.Sprintf("video file not found, %s", videoFPath))
+		return false
+	} else if err != nil {
+		cb.replyError(c, http.StatusForbidden, backend.V2ErrCodeForbidden, err.Error())
+		return false
+	}
+	return true
+}
@@ -1,16 +1,19 @@
 package middle
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
-
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/user_center"
 	"github.com/gin-gonic/gin"
 )
 
-func CheckAuth() gin.HandlerFunc {
+// CheckAuth Web UI 的鉴权，使用登录后得到的会话 Token
+func CheckAuth(uc *user_center.UserCenter) gin.HandlerFunc {
 
 	return func(context *gin.Context) {
 		authHeader := context.Request.Header.Get("Authorization")
@@ -20,33 +23,105 @@ func CheckAuth() gin.HandlerFunc {
 			context.Abort()
 			return
 		}
-		nowAccessToken := fields[1]
-		if nowAccessToken == "" || nowAccessToken != common.GetAccessToken() {
-			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: "AccessToken Error"})
+		identity, err := uc.CheckSession(fields[1])
+		if err != nil {
+			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: err.Error()})
 			context.Abort()
 			return
 		}
+		context.Set(identityKey, identity)
 		// 向下传递消息
 		context.Next()
 	}
 }
 
-func CheckApiAuth() gin.HandlerFunc {
+// CheckApiAuth API Key 的鉴权，只能通过 Header Authorization 传递
+func CheckApiAuth(uc *user_center.UserCenter) gin.HandlerFunc {
+
+	return checkApiAuth(uc, false, func(context *gin.Context, message string) {
+		context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: message})
+	})
+}
+
+// CheckWebhookApiAuth 与 CheckApiAuth 一样，Webhook 的调用方不一定能设置 Header，没有 Header 的时候允许使用 ?api_key=xx 传递
+func CheckWebhookApiAuth(uc *user_center.UserCenter) gin.HandlerFunc {
+
+	return checkApiAuth(uc, true, func(context *gin.Context, message string) {
+		context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: message})
+	})
+}
+
+// CheckApiAuthV2 与 CheckApiAuth 一样，只是错误的回复使用 /api/v2 统一的错误结构
+func CheckApiAuthV2(uc *user_center.UserCenter) gin.HandlerFunc {
+
+	return checkApiAuth(uc, false, func(context *gin.Context, message string) {
+		context.JSON(http.StatusUnauthorized, backend.NewReplyV2Error(backend.V2ErrCodeUnauthorized, message))
+	})
+}
+
+// checkApiAuth allowQueryKey 为 true 的时候才允许使用 ?api_key=xx，URL 会出现在访问日志、浏览器历史中，只给 Webhook 使用
+func checkApiAuth(uc *user_center.UserCenter, allowQueryKey bool, replyError func(context *gin.Context, message string)) gin.HandlerFunc {
 
 	return func(context *gin.Context) {
+		if common.GetApiEnabled() == false {
+			replyError(context, "api_key_enabled == false")
+			context.Abort()
+			return
+		}
 		authHeader := context.Request.Header.Get("Authorization")
-		if len(authHeader) <= 1 {
-			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: "Request Header Authorization Error"})
+		nowApiKey := ""
+		if authHeader == "" {
+			if allowQueryKey == true {
+				nowApiKey = context.Query("api_key")
+			}
+		} else {
+			fields := strings.Fields(authHeader)
+			if len(fields) != 2 {
+				replyError(context, "Request Header Authorization Error")
+				context.Abort()
+				return
+			}
+			nowApiKey = fields[1]
+		}
+		if nowApiKey == "" {
+			replyError(context, "api_key is empty")
+			context.Abort()
+			return
+		}
+		identity, err := uc.CheckApiKey(nowApiKey)
+		if err != nil {
+			replyError(context, err.Error())
+			context.Abort()
+			return
+		}
+		context.Set(identityKey, identity)
+		// 向下传递消息
+		context.Next()
+	}
+}
+
+// CheckPeerLibraryToken 局域网内其他实例查询字幕缓存的鉴权，没有开启的时候当作接口不存在，没有设置 Token 的时候拒绝访问，Token 可以使用 ?token=xx 传递
+func CheckPeerLibraryToken() gin.HandlerFunc {
+
+	return func(context *gin.Context) {
+		peerSettings := settings.Get().AdvancedSettings.PeerLibrarySettings
+		if peerSettings.ServeEnabled == false {
+			context.JSON(http.StatusNotFound, backend.ReplyCheckAuth{Message: "peer library is disabled"})
 			context.Abort()
 			return
 		}
-		nowAccessToken := strings.Fields(authHeader)[1]
-		if nowAccessToken == "" {
-			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: "api_key_enabled == false or api_key is empty"})
+		// 没有设置 Token 的时候不对外提供，字幕缓存不能在局域网内无鉴权的访问
+		if peerSettings.ServeToken == "" {
+			context.JSON(http.StatusForbidden, backend.ReplyCheckAuth{Message: "peer library serve_token is empty"})
 			context.Abort()
 			return
-		} else if nowAccessToken != common.GetApiToken() {
-			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: "AccessToken Error"})
+		}
+		nowToken := GetAuthToken(context)
+		if nowToken == "" {
+			nowToken = context.Query("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(nowToken), []byte(peerSettings.ServeToken)) != 1 {
+			context.JSON(http.StatusUnauthorized, backend.ReplyCheckAuth{Message: "peer library token error"})
 			context.Abort()
 			return
 		}
@@ -54,3 +129,59 @@ func CheckApiAuth() gin.HandlerFunc {
 		context.Next()
 	}
 }
+
+// RequireRole 需要在 CheckAuth、CheckApiAuth 之后使用，用户的角色至少是 needRole
+func RequireRole(needRole string) gin.HandlerFunc {
+
+	return requireRole(needRole, func(context *gin.Context, message string) {
+		context.JSON(http.StatusForbidden, backend.ReplyCheckAuth{Message: message})
+	})
+}
+
+// RequireRoleV2 与 RequireRole 一样，只是错误的回复使用 /api/v2 统一的错误结构
+func RequireRoleV2(needRole string) gin.HandlerFunc {
+
+	return requireRole(needRole, func(context *gin.Context, message string) {
+		context.JSON(http.StatusForbidden, backend.NewReplyV2Error(backend.V2ErrCodeForbidden, message))
+	})
+}
+
+func requireRole(needRole string, replyError func(context *gin.Context, message string)) gin.HandlerFunc {
+
+	return func(context *gin.Context) {
+		identity := GetIdentity(context)
+		if identity == nil || user_center.RoleAllowed(identity.Role, needRole) == false {
+			replyError(context, "need role "+needRole)
+			context.Abort()
+			return
+		}
+		// 向下传递消息
+		context.Next()
+	}
+}
+
+// GetIdentity 获取鉴权后的用户信息，没有经过鉴权返回 nil
+func GetIdentity(context *gin.Context) *user_center.Identity {
+
+	value, found := context.Get(identityKey)
+	if found == false {
+		return nil
+	}
+	identity, ok := value.(*user_center.Identity)
+	if ok == false {
+		return nil
+	}
+	return identity
+}
+
+// GetAuthToken 请求 Header 中的 Token
+func GetAuthToken(context *gin.Context) string {
+
+	fields := strings.Fields(context.Request.Header.Get("Authorization"))
+	if len(fields) != 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+const identityKey = "identity"
@@ -77,6 +77,11 @@ func InitDb() error {
 		&models.LowVideoSubInfo{},
 		&models.Info{},
 		&models.SkipScanInfo{},
+		&models.VideoSubScore{}, &models.VideoSubCandidateScore{},
+		&models.PostSaveHookRecord{}, &models.SubPostProcessRecord{}, &models.SubFontsReport{},
+		&models.ScanIndexDir{}, &models.ScanIndexVideo{},
+		&models.User{}, &models.UserSession{}, &models.UserApiKey{},
+		&models.SupplierHealthDaily{}, &models.SupplierHealthState{},
 	)
 	if err != nil {
 		return errors.New(fmt.Sprintf("db AutoMigrate error, %s", err.Error()))
@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// PostSaveHookRecord 字幕保存后回调的执行结果，归属于某一个下载任务
+type PostSaveHookRecord struct {
+	gorm.Model
+	JobID      string `gorm:"type:varchar(64);index"` // 下载任务的 ID
+	HookName   string `gorm:"type:varchar(255)"`      // 回调的名称
+	HookType   string `gorm:"type:varchar(32)"`       // http or command
+	VideoFPath string `gorm:"type:varchar(255)"`      // 视频的全路径
+	Success    bool   // 最终是否执行成功
+	Attempts   int    // 一共执行了几次（包含重试）
+	Output     string // http 的返回内容或者命令的输出，会截断
+	ErrorInfo  string // 最后一次失败的原因
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// ScanIndexDir 增量扫描的目录索引，目录的修改时间没有变化，就不需要再次读取这个目录的内容
+type ScanIndexDir struct {
+	UID         string     `gorm:"type:varchar(64);primarykey"` // 由目录的全路径计算 sha256 得到
+	DirFPath    string     `gorm:"type:varchar(255)"`           // 目录的全路径
+	RootDirPath string     `gorm:"type:varchar(255);index"`     // 属于哪一个电影、连续剧的根目录
+	ModTime     int64      // 目录的修改时间，UnixNano
+	SubDirs     StringList `gorm:"type:text"` // 子目录的全路径
+	VideoFPaths StringList `gorm:"type:text"` // 视频的全路径，蓝光是伪造的视频路径
+	SubFPaths   StringList `gorm:"type:text"` // 字幕的全路径
+	HasTVNfo    bool       // 是否有 tvshow.nfo，有就是一部连续剧的根目录
+}
+
+func NewScanIndexDir(dirFPath, rootDirPath string, modTime int64) *ScanIndexDir {
+	return &ScanIndexDir{
+		UID:         GenerateUID4ScanIndex(dirFPath),
+		DirFPath:    dirFPath,
+		RootDirPath: rootDirPath,
+		ModTime:     modTime,
+		SubDirs:     make(StringList, 0),
+		VideoFPaths: make(StringList, 0),
+		SubFPaths:   make(StringList, 0),
+	}
+}
+
+// ScanIndexVideo 增量扫描的视频索引，视频的大小、修改时间没有变化，就认为这个视频没有变化
+type ScanIndexVideo struct {
+	UID         string     `gorm:"type:varchar(64);primarykey"` // 由视频的全路径计算 sha256 得到
+	VideoFPath  string     `gorm:"type:varchar(255)"`           // 视频的全路径
+	RootDirPath string     `gorm:"type:varchar(255);index"`     // 属于哪一个电影、连续剧的根目录
+	Size        int64      // 视频的大小
+	ModTime     int64      // 视频的修改时间，UnixNano
+	SubFPaths   StringList `gorm:"type:text"` // 视频所在目录中已有的字幕
+}
+
+func GenerateUID4ScanIndex(fPath string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fPath)))
+}
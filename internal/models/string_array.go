@@ -12,5 +12,9 @@ func (p StringList) Value() (driver.Value, error) {
 }
 
 func (p *StringList) Scan(data interface{}) error {
+	// text 类型的字段，sqlite 驱动返回的可能是 string
+	if str, ok := data.(string); ok == true {
+		return json.Unmarshal([]byte(str), &p)
+	}
 	return json.Unmarshal(data.([]byte), &p)
 }
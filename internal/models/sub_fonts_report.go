@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// SubFontsReport ass/ssa 字幕使用的字体检查的结果，每个字幕一条，重新检查的时候覆盖
+type SubFontsReport struct {
+	gorm.Model
+	VideoFPath   string     `gorm:"type:varchar(255);index"`       // 视频的全路径
+	SubFPath     string     `gorm:"type:varchar(255);uniqueIndex"` // 字幕的全路径
+	Fonts        StringList `gorm:"type:text"`                     // 字幕中使用的字体
+	MissingFonts StringList `gorm:"type:text"`                     // 在本地字体目录中没有找到的字体
+	FontChars    string     `gorm:"type:text"`                     // 每个字体用到的字符，json 格式，Key 是小写的字体名称，子集化的时候合并同一个视频的所有字幕
+	OutputFiles  StringList `gorm:"type:text"`                     // 输出的字体文件，或者写入 mkv 的附件名称
+}
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// SubPostProcessRecord 字幕写入后一个处理步骤的执行结果，归属于某一个下载任务
+type SubPostProcessRecord struct {
+	gorm.Model
+	JobID      string `gorm:"type:varchar(64);index"` // 下载任务的 ID
+	VideoFPath string `gorm:"type:varchar(255)"`      // 视频的全路径
+	SubFPath   string `gorm:"type:varchar(255)"`      // 字幕的全路径
+	Stage      string `gorm:"type:varchar(32)"`       // 步骤的名称
+	Status     string `gorm:"type:varchar(16)"`       // success skip failure
+	Message    string // 跳过的原因或者失败的原因
+	DurationMs int64  // 执行的耗时，毫秒
+}
@@ -0,0 +1,33 @@
+package models
+
+import "gorm.io/gorm"
+
+// SupplierHealthDaily 字幕源每天的统计，每个字幕源每天一条
+type SupplierHealthDaily struct {
+	gorm.Model
+	SupplierName        string `gorm:"type:varchar(64);uniqueIndex:idx_supplier_health_day"` // 字幕源的名称
+	Day                 string `gorm:"type:varchar(10);uniqueIndex:idx_supplier_health_day"` // 2006-01-02
+	QueryCount          int64  // 搜索的次数，一部电影、一部连续剧算一次
+	HitCount            int64  // 至少返回了一个字幕的次数
+	ErrorCount          int64  // 搜索出错的次数
+	LatencyMsSum        int64  // 搜索的总耗时，毫秒，用于计算平均耗时
+	DownloadCount       int64  // 返回的字幕中有内容的个数
+	DownloadFailedCount int64  // 返回的字幕中没有内容的个数
+	WinnerCount         int64  // 被选为最终字幕的次数
+	CheckCount          int64  // CheckAlive 的次数
+	CheckFailedCount    int64  // CheckAlive 失败的次数
+	QuotaUsed           int    // 当天已经使用的下载次数，以 DailyDownloadInfo 为准
+	QuotaLimit          int    // 当天的下载次数限制，小于 0 是没有限制
+}
+
+// SupplierHealthState 字幕源当前的状态，每个字幕源一条，用于连续失败后的自动禁用
+type SupplierHealthState struct {
+	gorm.Model
+	SupplierName        string `gorm:"type:varchar(64);uniqueIndex"` // 字幕源的名称
+	ConsecutiveFailures int    // 连续失败的次数，成功一次就清零
+	DisabledUntil       int64  // 自动禁用到什么时候，Unix 秒，0 是没有禁用
+	DisabledCount       int64  // 被自动禁用的次数
+	LastError           string // 最后一次失败的原因
+	LastErrorTime       int64  // 最后一次失败的时间，Unix 秒
+	LastSuccessTime     int64  // 最后一次成功的时间，Unix 秒
+}
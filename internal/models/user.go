@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User Web UI 的用户，密码只保存 bcrypt 的结果
+type User struct {
+	gorm.Model
+	Username     string `gorm:"type:varchar(64);uniqueIndex"` // 用户名
+	PasswordHash string `gorm:"type:varchar(128)"`            // bcrypt 后的密码
+	Role         string `gorm:"type:varchar(16)"`             // admin operator read_only
+}
+
+// UserSession 用户登录后的会话，一个用户可以同时有多个会话，只保存 Token 的 sha256
+type UserSession struct {
+	TokenHash string    `gorm:"type:varchar(64);primarykey"` // Token 的 sha256
+	UserID    uint      `gorm:"index"`                       // 属于哪一个用户
+	ExpiresAt time.Time `gorm:"index"`                       // 过期的时间
+	CreatedAt time.Time
+}
+
+// UserApiKey 用户创建的 API Key，有名称，可以单独的吊销，只保存 Key 的 sha256
+type UserApiKey struct {
+	gorm.Model
+	Name       string    `gorm:"type:varchar(64)"`             // 名称，比如 sonarr、emby
+	KeyHash    string    `gorm:"type:varchar(64);uniqueIndex"` // Key 的 sha256
+	KeyPrefix  string    `gorm:"type:varchar(16)"`             // Key 的开头几位，用于界面上区分
+	UserID     uint      `gorm:"index"`                        // 属于哪一个用户，权限跟随这个用户的角色
+	LastUsedAt time.Time // 最后一次使用的时间
+}
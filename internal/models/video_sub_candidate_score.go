@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// VideoSubCandidateScore 一个视频最近一次选择字幕时，每一个候选字幕的评分明细，用于解释为什么选了（或者没有选）某个字幕
+type VideoSubCandidateScore struct {
+	gorm.Model
+	VideoUID      string  `gorm:"type:varchar(64);index"` // 同 VideoSubScore 的 UID
+	VideoFPath    string  `gorm:"type:varchar(255)"`      // 视频的全路径
+	SubFPath      string  `gorm:"type:varchar(255)"`      // 候选字幕在下载缓存中的全路径
+	FromWhereSite string  `gorm:"type:varchar(64)"`       // 从哪个字幕源下载的
+	IsForced      bool    // 是否是 forced 字幕
+	IsWinner      bool    // 是否是这次写入到视频旁边的主字幕，没有升级的时候都是 false
+	Score         float64 // 总分 0 - 100
+	Breakdown     string  // 评分明细，json 格式
+}
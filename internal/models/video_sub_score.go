@@ -0,0 +1,29 @@
+package models
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// VideoSubScore 一个视频当前使用的字幕（主字幕）的评分，用于后续判断新下载的字幕是否值得替换（升级）
+type VideoSubScore struct {
+	UID        string  `gorm:"type:varchar(64);primarykey"` // 由视频的全路径计算 sha256 得到
+	VideoFPath string  `gorm:"type:varchar(255)"`           // 视频的全路径
+	SubFPath   string  `gorm:"type:varchar(255)"`           // 字幕的全路径
+	Score      float64 // 总分 0 - 100
+	Breakdown  string  // 评分明细，json 格式
+}
+
+func NewVideoSubScore(videoFPath, subFPath string, score float64, breakdown string) *VideoSubScore {
+	return &VideoSubScore{
+		UID:        GenerateUID4VideoSubScore(videoFPath),
+		VideoFPath: videoFPath,
+		SubFPath:   subFPath,
+		Score:      score,
+		Breakdown:  breakdown,
+	}
+}
+
+func GenerateUID4VideoSubScore(videoFPath string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(videoFPath)))
+}
@@ -0,0 +1,62 @@
+package anime_helper
+
+import (
+	"testing"
+)
+
+func TestParseAnimeFileName(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		wantFound bool
+		want      AnimeNameInfo
+	}{
+		{name: "00", fileName: "[SubsPlease] One Piece - 1037 (1080p) [A1B2C3D4].mkv", wantFound: true,
+			want: AnimeNameInfo{Group: "SubsPlease", Title: "One Piece", Episode: 1037, IsAbsolute: true}},
+		{name: "01", fileName: "[Group] Title - 12v2 [BD 1080p].mkv", wantFound: true,
+			want: AnimeNameInfo{Group: "Group", Title: "Title", Episode: 12, Version: 2, IsAbsolute: true}},
+		{name: "02", fileName: "[Nekomoe kissaten][Title][05][1080p][CHS].mp4", wantFound: true,
+			want: AnimeNameInfo{Group: "Nekomoe kissaten", Title: "Title", Episode: 5, IsAbsolute: true}},
+		{name: "03", fileName: "[Group] Title S2 - 05 [1080p].mkv", wantFound: true,
+			want: AnimeNameInfo{Group: "Group", Title: "Title", Season: 2, Episode: 5}},
+		{name: "04", fileName: "[Group] Title 2nd Season - 05.chs.ass", wantFound: true,
+			want: AnimeNameInfo{Group: "Group", Title: "Title", Season: 2, Episode: 5}},
+		{name: "05", fileName: "[Group] 某动画 第137话.ass", wantFound: true,
+			want: AnimeNameInfo{Group: "Group", Title: "某动画", Episode: 137, IsAbsolute: true}},
+		{name: "06", fileName: "Title.S01E02.1080p.WEB-DL.mkv", wantFound: false},
+		{name: "07", fileName: "[Group] Movie Title [2021][1080p].mkv", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := ParseAnimeFileName(tt.fileName)
+			if found != tt.wantFound {
+				t.Fatalf("ParseAnimeFileName() found = %v, want %v", found, tt.wantFound)
+			}
+			if found == true && *got != tt.want {
+				t.Errorf("ParseAnimeFileName() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEpisodeMap(t *testing.T) {
+
+	episodeMap := NewEpisodeMapFromSeasonEpisodeCounts(map[int]int{0: 3, 1: 12, 2: 13, 3: 12})
+	if episodeMap.Len() != 37 {
+		t.Fatalf("Len() = %v, want 37", episodeMap.Len())
+	}
+	absolute, ok := episodeMap.GetAbsolute(2, 1)
+	if ok == false || absolute != 13 {
+		t.Errorf("GetAbsolute(2, 1) = %v %v, want 13", absolute, ok)
+	}
+	season, episode, ok := episodeMap.GetSeasonEpisode(37)
+	if ok == false || season != 3 || episode != 12 {
+		t.Errorf("GetSeasonEpisode(37) = S%vE%v %v, want S3E12", season, episode, ok)
+	}
+	if _, _, ok = episodeMap.GetSeasonEpisode(38); ok == true {
+		t.Errorf("GetSeasonEpisode(38) want not found")
+	}
+	if _, ok = episodeMap.GetAbsolute(0, 1); ok == true {
+		t.Errorf("GetAbsolute(0, 1) want not found")
+	}
+}
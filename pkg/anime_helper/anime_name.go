@@ -0,0 +1,130 @@
+package anime_helper
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AnimeNameInfo 从动画的视频或者字幕的文件名中解析出来的信息
+type AnimeNameInfo struct {
+	Group      string // 字幕组、发布组
+	Title      string // 动画的名称
+	Season     int    // 季，文件名中没有季的信息的时候是 0
+	Episode    int    // 集数，IsAbsolute 为 true 的时候是绝对集数
+	Version    int    // v2 这样的修正版本，没有就是 0
+	IsAbsolute bool   // 是否是绝对集数，也就是文件名中没有季的信息
+}
+
+/*
+	ParseAnimeFileName 解析动画常见的命名方式，SxxEyy 这种常规的命名不在这里处理，返回 false
+	1. [Group] Title - 137 [1080p].mkv
+	2. [Group] Title - 12v2 (BD 1080p).mkv
+	3. [Group][Title][12][1080P][CHS].mp4
+	4. [Group] Title S2 - 05 [1080p].mkv
+	5. [Group] Title 2nd Season - 05.mkv
+	6. [Group] Title 第05话.mkv
+*/
+func ParseAnimeFileName(fileName string) (*AnimeNameInfo, bool) {
+
+	nowName := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	if regMatchSxxEyy.MatchString(nowName) == true {
+		return nil, false
+	}
+	var info AnimeNameInfo
+	group := regMatchGroup.FindStringSubmatch(nowName)
+	if group != nil {
+		info.Group = strings.TrimSpace(group[1])
+		nowName = strings.TrimSpace(nowName[len(group[0]):])
+	}
+	// 先找到集数，集数前面的就是名称（可能还带着季的信息）
+	titlePart := ""
+	found := false
+	for _, re := range regMatchEpisodes {
+		matched := re.FindStringSubmatchIndex(nowName)
+		if matched == nil {
+			continue
+		}
+		episode, err := strconv.Atoi(nowName[matched[2]:matched[3]])
+		if err != nil || isNotEpisodeNumber(episode) == true {
+			continue
+		}
+		info.Episode = episode
+		if matched[4] >= 0 {
+			info.Version, _ = strconv.Atoi(nowName[matched[4]:matched[5]])
+		}
+		titlePart = nowName[:matched[0]]
+		found = true
+		break
+	}
+	if found == false {
+		return nil, false
+	}
+	// [Group][Title][12] 这种，名称在第一个中括号里面
+	titlePart = strings.TrimSpace(titlePart)
+	bracketTitle := regMatchGroup.FindStringSubmatch(titlePart)
+	if bracketTitle != nil {
+		titlePart = bracketTitle[1]
+	}
+	for _, re := range regMatchSeasons {
+		season := re.FindStringSubmatch(titlePart)
+		if season == nil {
+			continue
+		}
+		info.Season, _ = strconv.Atoi(season[1])
+		titlePart = strings.Replace(titlePart, season[0], "", 1)
+		break
+	}
+	info.Title = strings.TrimSpace(regMatchSplit.ReplaceAllString(titlePart, " "))
+	info.IsAbsolute = info.Season == 0
+	if info.Title == "" {
+		return nil, false
+	}
+
+	return &info, true
+}
+
+// IsAnimeGenres 从 nfo 中读取到的类型判断是否是动画
+func IsAnimeGenres(genres []string) bool {
+	for _, genre := range genres {
+		switch strings.ToLower(strings.TrimSpace(genre)) {
+		case "anime", "动漫", "動漫", "アニメ":
+			return true
+		}
+	}
+	return false
+}
+
+// isNotEpisodeNumber 排除年份、分辨率这些容易被误认为集数的数字
+func isNotEpisodeNumber(number int) bool {
+	if number <= 0 {
+		return true
+	}
+	if number >= 1900 && number <= 2100 {
+		return true
+	}
+	switch number {
+	case 480, 576, 720, 1080, 2160:
+		return true
+	}
+	return false
+}
+
+var (
+	regMatchSxxEyy = regexp.MustCompile(`(?i)(^|[^a-z0-9])S\d{1,2}[ .]?E\d{1,4}([^0-9]|$)`)
+	regMatchGroup  = regexp.MustCompile(`^\[([^\]]+)\]`)
+	// 按顺序匹配，第一个分组是集数，第二个分组是修正版本
+	regMatchEpisodes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\s-\s(\d{1,4})(?:v(\d))?(?:\s*END)?(?:[\s\[\(._]|$)`),
+		regexp.MustCompile(`(?i)\[(\d{1,4})(?:v(\d))?(?:\s*END)?\]`),
+		regexp.MustCompile(`第(\d{1,4})(?:v(\d))?[话話集]`),
+	}
+	regMatchSeasons = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(?:^|\s)S(\d{1,2})$`),
+		regexp.MustCompile(`(?i)(?:^|\s)(\d{1,2})(?:st|nd|rd|th)\s+Season$`),
+		regexp.MustCompile(`(?i)(?:^|\s)Season\s*(\d{1,2})$`),
+		regexp.MustCompile(`第(\d{1,2})季$`),
+	}
+	regMatchSplit = regexp.MustCompile(`[._\s]+`)
+)
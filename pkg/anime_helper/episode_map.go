@@ -0,0 +1,90 @@
+package anime_helper
+
+import "sort"
+
+// EpisodeMapItem 一集的 季、集 与绝对集数的对应关系
+type EpisodeMapItem struct {
+	Season   int
+	Episode  int
+	Absolute int
+}
+
+// EpisodeMap 动画的绝对集数与 季、集 之间的相互转换
+type EpisodeMap struct {
+	items             []EpisodeMapItem
+	absolute2Item     map[int]EpisodeMapItem
+	seasonEpisode2Abs map[[2]int]int
+}
+
+// NewEpisodeMap 传入的每一项都需要有 Season、Episode、Absolute，重复的以第一个为准
+func NewEpisodeMap(items []EpisodeMapItem) *EpisodeMap {
+
+	e := EpisodeMap{
+		items:             make([]EpisodeMapItem, 0, len(items)),
+		absolute2Item:     make(map[int]EpisodeMapItem),
+		seasonEpisode2Abs: make(map[[2]int]int),
+	}
+	for _, item := range items {
+		if item.Absolute <= 0 || item.Episode <= 0 {
+			continue
+		}
+		if _, ok := e.absolute2Item[item.Absolute]; ok == true {
+			continue
+		}
+		if _, ok := e.seasonEpisode2Abs[[2]int{item.Season, item.Episode}]; ok == true {
+			continue
+		}
+		e.items = append(e.items, item)
+		e.absolute2Item[item.Absolute] = item
+		e.seasonEpisode2Abs[[2]int{item.Season, item.Episode}] = item.Absolute
+	}
+	sort.Slice(e.items, func(i, j int) bool {
+		return e.items[i].Absolute < e.items[j].Absolute
+	})
+	return &e
+}
+
+/*
+	NewEpisodeMapFromSeasonEpisodeCounts 从每一季的集数推算绝对集数，Key 是季，Value 是这一季的集数
+	特别篇（第 0 季）不参与绝对集数的计算
+*/
+func NewEpisodeMapFromSeasonEpisodeCounts(seasonEpisodeCounts map[int]int) *EpisodeMap {
+
+	seasons := make([]int, 0, len(seasonEpisodeCounts))
+	for season := range seasonEpisodeCounts {
+		if season <= 0 {
+			continue
+		}
+		seasons = append(seasons, season)
+	}
+	sort.Ints(seasons)
+	items := make([]EpisodeMapItem, 0)
+	absolute := 0
+	for _, season := range seasons {
+		for episode := 1; episode <= seasonEpisodeCounts[season]; episode++ {
+			absolute++
+			items = append(items, EpisodeMapItem{Season: season, Episode: episode, Absolute: absolute})
+		}
+	}
+	return NewEpisodeMap(items)
+}
+
+// GetAbsolute 季、集 转绝对集数
+func (e EpisodeMap) GetAbsolute(season, episode int) (int, bool) {
+	absolute, ok := e.seasonEpisode2Abs[[2]int{season, episode}]
+	return absolute, ok
+}
+
+// GetSeasonEpisode 绝对集数转 季、集
+func (e EpisodeMap) GetSeasonEpisode(absolute int) (int, int, bool) {
+	item, ok := e.absolute2Item[absolute]
+	if ok == false {
+		return 0, 0, false
+	}
+	return item.Season, item.Episode, true
+}
+
+// Len 一共有多少集
+func (e EpisodeMap) Len() int {
+	return len(e.items)
+}
@@ -244,7 +244,7 @@ func un7zOneFile(file *sevenzip.File, desRootPath string) error {
 
 func IsWantedArchiveExtName(fileName string) bool {
 	switch strings.ToLower(filepath.Ext(fileName)) {
-	case ".zip", ".tar", ".rar", "7z":
+	case ".zip", ".tar", ".rar", ".7z":
 		return true
 	default:
 		return false
@@ -0,0 +1,330 @@
+package ass_fonts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	Checker 检查 ass/ssa 字幕使用的字体是否在本地字体目录中，并按照设置输出找到的字体
+	子集化的时候，会合并同一个视频的所有字幕用到的字符，避免后面的字幕覆盖了前面字幕需要的字符
+*/
+type Checker struct {
+	log           *logrus.Logger
+	fontsSettings *settings.AssFontsSettings
+}
+
+func NewChecker(log *logrus.Logger, fontsSettings *settings.AssFontsSettings) *Checker {
+	return &Checker{log: log, fontsSettings: fontsSettings}
+}
+
+// Check 检查一个字幕使用的字体，输出找到的字体，结果保存到数据库中
+func (c *Checker) Check(videoFPath, subFPath string) (*models.SubFontsReport, error) {
+
+	fBytes, err := os.ReadFile(subFPath)
+	if err != nil {
+		return nil, err
+	}
+	inBytes, err := language.ChangeFileCoding2UTF8(fBytes)
+	if err != nil {
+		return nil, err
+	}
+	fonts := ListFonts(string(inBytes))
+
+	var fontIndex *FontIndex
+	if c.fontsSettings.FontsDirPath != "" {
+		fontIndex, err = GetFontIndex(c.fontsSettings.FontsDirPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := models.SubFontsReport{
+		VideoFPath:   videoFPath,
+		SubFPath:     subFPath,
+		Fonts:        make(models.StringList, 0),
+		MissingFonts: make(models.StringList, 0),
+		OutputFiles:  make(models.StringList, 0),
+	}
+	fontChars := make(map[string]string)
+	foundFaces := make(map[string]FontFace)
+	for _, font := range fonts {
+		report.Fonts = append(report.Fonts, font.Name)
+		fontChars[strings.ToLower(font.Name)] = font.CharsString()
+		if fontIndex == nil {
+			report.MissingFonts = append(report.MissingFonts, font.Name)
+			continue
+		}
+		face, found := fontIndex.Find(font.Name)
+		if found == false {
+			report.MissingFonts = append(report.MissingFonts, font.Name)
+			continue
+		}
+		foundFaces[strings.ToLower(font.Name)] = face
+	}
+	fontCharsBytes, err := json.Marshal(fontChars)
+	if err != nil {
+		return nil, err
+	}
+	report.FontChars = string(fontCharsBytes)
+
+	if c.fontsSettings.OutputMode != settings.AssFontsOutputModeNone && len(foundFaces) > 0 {
+		report.OutputFiles, err = c.output(videoFPath, subFPath, foundFaces, fontChars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = saveReport(&report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// output 输出找到的字体，同一个字体文件只输出一次，返回输出的文件或者附件的名称
+func (c *Checker) output(videoFPath, subFPath string, foundFaces map[string]FontFace, fontChars map[string]string) ([]string, error) {
+
+	mode := c.fontsSettings.OutputMode
+	if mode == settings.AssFontsOutputModeMKVAttachment && strings.ToLower(filepath.Ext(videoFPath)) != ".mkv" {
+		mode = settings.AssFontsOutputModeFontsFolder
+	}
+
+	// 同一个字体文件可能有多个名称被引用，用到的字符合并在一起
+	faceChars := make(map[FontFace]map[rune]bool)
+	if c.fontsSettings.SubsetFonts == true {
+		videoFontChars, err := getVideoFontChars(videoFPath, subFPath)
+		if err != nil {
+			return nil, err
+		}
+		for fontKey, face := range foundFaces {
+			if _, found := faceChars[face]; found == false {
+				faceChars[face] = make(map[rune]bool)
+			}
+			for _, char := range fontChars[fontKey] + videoFontChars[fontKey] {
+				faceChars[face][char] = true
+			}
+		}
+	} else {
+		// 不子集化的时候输出整个字体文件，ttc、otc 中的多个字体只需要输出一次
+		for _, face := range foundFaces {
+			faceChars[FontFace{FPath: face.FPath}] = nil
+		}
+	}
+
+	tmpFolder, err := pkg.GetTmpFolderByName(tmpFolderName)
+	if err != nil {
+		return nil, err
+	}
+	outputFiles := make([]string, 0)
+	for face, chars := range faceChars {
+
+		fontFPath := face.FPath
+		outName := filepath.Base(face.FPath)
+		if c.fontsSettings.SubsetFonts == true {
+			outName = subsetFontName(face)
+			fontFPath = filepath.Join(tmpFolder, outName)
+			err = c.subset(face, chars, fontFPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch mode {
+		case settings.AssFontsOutputModeFontsFolder:
+			fontsFolder := filepath.Join(filepath.Dir(videoFPath), FontsFolderName)
+			err = os.MkdirAll(fontsFolder, os.ModePerm)
+			if err != nil {
+				return nil, err
+			}
+			desFPath := filepath.Join(fontsFolder, outName)
+			err = pkg.CopyFile(fontFPath, desFPath)
+			if err != nil {
+				return nil, err
+			}
+			outputFiles = append(outputFiles, desFPath)
+		case settings.AssFontsOutputModeMKVAttachment:
+			err = c.attach2MKV(videoFPath, fontFPath, outName)
+			if err != nil {
+				return nil, err
+			}
+			outputFiles = append(outputFiles, outName)
+		}
+		if c.fontsSettings.SubsetFonts == true {
+			_ = os.Remove(fontFPath)
+		}
+	}
+	return outputFiles, nil
+}
+
+// subset 使用 pyftsubset 只保留用到的字符，保留所有的名称，播放器可能是按照中文的名称匹配的
+func (c *Checker) subset(face FontFace, chars map[rune]bool, outFPath string) error {
+
+	textFPath := outFPath + ".txt"
+	text := make([]rune, 0, len(chars))
+	for char := range chars {
+		text = append(text, char)
+	}
+	err := pkg.WriteFile(textFPath, []byte(string(text)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(textFPath)
+	}()
+
+	args := []string{
+		face.FPath,
+		"--font-number=" + strconv.Itoa(face.Index),
+		"--text-file=" + textFPath,
+		"--output-file=" + outFPath,
+		"--name-IDs=*",
+		"--name-languages=*",
+		"--name-legacy",
+	}
+	_, err = c.execTool(c.fontsSettings.SubsetToolPath, args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+/*
+	attach2MKV 使用 mkvpropedit 把字体作为附件写入 mkv，不需要重新封装
+	同名的附件先删除再添加，没有同名附件的时候 mkvpropedit 会返回警告（退出码 1），不算失败
+*/
+func (c *Checker) attach2MKV(videoFPath, fontFPath, attachmentName string) error {
+
+	args := []string{
+		videoFPath,
+		"--delete-attachment", "name:" + attachmentName,
+		"--attachment-name", attachmentName,
+		"--attachment-mime-type", fontMimeType(attachmentName),
+		"--add-attachment", fontFPath,
+	}
+	output, err := c.execTool(c.fontsSettings.MKVPropEditToolPath, args)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) == true && exitErr.ExitCode() == 1 {
+			c.log.Debugln("attach2MKV", attachmentName, output)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Checker) execTool(exeName string, args []string) (string, error) {
+
+	cmd := exec.Command(exeName, args...)
+	buf := bytes.NewBufferString("")
+	//指定输出位置
+	cmd.Stderr = buf
+	cmd.Stdout = buf
+	err := cmd.Start()
+	if err != nil {
+		return buf.String(), err
+	}
+	err = cmd.Wait()
+	if err != nil {
+		c.log.Errorln(exeName, buf.String())
+		return buf.String(), err
+	}
+	return buf.String(), nil
+}
+
+// GetReports 获取一个视频所有字幕的字体检查结果
+func GetReports(videoFPath string) ([]models.SubFontsReport, error) {
+
+	reports := make([]models.SubFontsReport, 0)
+	err := dao.GetDb().Where("video_f_path = ?", videoFPath).Order("id desc").Find(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// saveReport 每个字幕只保留最新的一条
+func saveReport(report *models.SubFontsReport) error {
+
+	var oldReports []models.SubFontsReport
+	err := dao.GetDb().Where("sub_f_path = ?", report.SubFPath).Find(&oldReports).Error
+	if err != nil {
+		return err
+	}
+	if len(oldReports) > 0 {
+		report.ID = oldReports[0].ID
+		report.CreatedAt = oldReports[0].CreatedAt
+	}
+	return dao.GetDb().Save(report).Error
+}
+
+// getVideoFontChars 同一个视频其他字幕用到的字符，Key 是小写的字体名称
+func getVideoFontChars(videoFPath, subFPath string) (map[string]string, error) {
+
+	reports, err := GetReports(videoFPath)
+	if err != nil {
+		return nil, err
+	}
+	videoFontChars := make(map[string]string)
+	for _, report := range reports {
+		// 字幕已经被删除的，不需要保留它的字符
+		if report.SubFPath == subFPath || pkg.IsFile(report.SubFPath) == false {
+			continue
+		}
+		fontChars := make(map[string]string)
+		err = json.Unmarshal([]byte(report.FontChars), &fontChars)
+		if err != nil {
+			continue
+		}
+		for fontKey, chars := range fontChars {
+			videoFontChars[fontKey] += chars
+		}
+	}
+	return videoFontChars, nil
+}
+
+// subsetFontName 子集化后的字体文件名，ttc、otc 中的字体会输出为单独的 ttf、otf
+func subsetFontName(face FontFace) string {
+
+	ext := strings.ToLower(filepath.Ext(face.FPath))
+	name := strings.TrimSuffix(filepath.Base(face.FPath), filepath.Ext(face.FPath))
+	switch ext {
+	case ".ttc":
+		ext = ".ttf"
+		name = fmt.Sprintf("%s_%d", name, face.Index)
+	case ".otc":
+		ext = ".otf"
+		name = fmt.Sprintf("%s_%d", name, face.Index)
+	}
+	return name + ".subset" + ext
+}
+
+// fontMimeType mkv 中字体附件的 MIME 类型，使用 libass、mpv 能识别的
+func fontMimeType(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".otf", ".otc":
+		return "application/vnd.ms-opentype"
+	default:
+		return "application/x-truetype-font"
+	}
+}
+
+const (
+	FontsFolderName = "fonts" // 视频旁边的字体目录，mpv、一些电视上的播放器会读取
+	tmpFolderName   = "ass_fonts"
+)
@@ -0,0 +1,180 @@
+package ass_fonts
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+const testAss = "[V4+ Styles]\r\n" +
+	"Format: Name, Fontname, Fontsize\r\n" +
+	"Style: Default,@方正黑体_GBK,20\r\n" +
+	"Style: Sign,Arial,30\r\n" +
+	"Style: Unused,Comic Sans MS,30\r\n" +
+	"\r\n" +
+	"[Events]\r\n" +
+	"Format: Layer, Start, End, Style, Text\r\n" +
+	"Dialogue: 0,0:00:01.00,0:00:02.00,Default,你好\\N世界\r\n" +
+	"Dialogue: 0,0:00:03.00,0:00:04.00,*Default,{\\fnArial}AB{\\fn}好{\\rSign}C\r\n" +
+	"Dialogue: 0,0:00:05.00,0:00:06.00,Sign,{\\p1}m 0 0 l 10 10{\\p0}D, E\r\n"
+
+func TestListFonts(t *testing.T) {
+
+	fonts := ListFonts(testAss)
+	wants := map[string]string{
+		"Arial":      " ,ABCDE",
+		"方正黑体_GBK": "世你好界",
+	}
+	if len(fonts) != len(wants) {
+		t.Fatalf("ListFonts() got %d fonts, want %d", len(fonts), len(wants))
+	}
+	for _, font := range fonts {
+		if font.CharsString() != wants[font.Name] {
+			t.Errorf("ListFonts() %s chars = %q, want %q", font.Name, font.CharsString(), wants[font.Name])
+		}
+	}
+}
+
+func TestNewFontIndex(t *testing.T) {
+
+	fontsDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(fontsDir, "test.ttf"), buildTestFont([]string{"Test Sans", "测试黑体"}), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(fontsDir, "sub"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(fontsDir, "sub", "test.ttc"), buildTestCollection([]string{"Test Serif"}, []string{"Test Mono"}), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不是字体的文件跳过
+	err = os.WriteFile(filepath.Join(fontsDir, "bad.otf"), []byte("bad"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := NewFontIndex(fontsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		wantFound bool
+		wantFile  string
+		wantIndex int
+	}{
+		{name: "test sans", wantFound: true, wantFile: "test.ttf", wantIndex: 0},
+		{name: "测试黑体", wantFound: true, wantFile: "test.ttf", wantIndex: 0},
+		{name: "Test Serif", wantFound: true, wantFile: "test.ttc", wantIndex: 0},
+		{name: "Test Mono", wantFound: true, wantFile: "test.ttc", wantIndex: 1},
+		{name: "Arial", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			face, found := index.Find(tt.name)
+			if found != tt.wantFound {
+				t.Fatalf("Find() found = %v, want %v", found, tt.wantFound)
+			}
+			if found == true && (filepath.Base(face.FPath) != tt.wantFile || face.Index != tt.wantIndex) {
+				t.Errorf("Find() = %v, want %v %v", face, tt.wantFile, tt.wantIndex)
+			}
+		})
+	}
+}
+
+// buildTestFont 只有 name 表的字体，names 写入 Windows 平台的家族名称
+func buildTestFont(names []string) []byte {
+	return buildTestFace(names, 0)
+}
+
+func buildTestCollection(faces ...[]string) []byte {
+
+	header := make([]byte, 12+4*len(faces))
+	copy(header, "ttcf")
+	binary.BigEndian.PutUint32(header[4:], 0x00010000)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(faces)))
+	out := header
+	for i, names := range faces {
+		binary.BigEndian.PutUint32(out[12+i*4:], uint32(len(out)))
+		out = append(out, buildTestFace(names, len(out))...)
+	}
+	return out
+}
+
+// buildTestFace offset 是这个字体在文件中的偏移，表的偏移是相对于文件开头的
+func buildTestFace(names []string, offset int) []byte {
+
+	strs := make([]byte, 0)
+	records := make([]byte, 0)
+	for _, name := range names {
+		u16 := utf16.Encode([]rune(name))
+		record := make([]byte, 12)
+		binary.BigEndian.PutUint16(record[0:], 3)
+		binary.BigEndian.PutUint16(record[2:], 1)
+		binary.BigEndian.PutUint16(record[4:], 0x409)
+		binary.BigEndian.PutUint16(record[6:], 1)
+		binary.BigEndian.PutUint16(record[8:], uint16(len(u16)*2))
+		binary.BigEndian.PutUint16(record[10:], uint16(len(strs)))
+		records = append(records, record...)
+		for _, u := range u16 {
+			strs = append(strs, byte(u>>8), byte(u))
+		}
+	}
+	nameTable := make([]byte, 6)
+	binary.BigEndian.PutUint16(nameTable[2:], uint16(len(names)))
+	binary.BigEndian.PutUint16(nameTable[4:], uint16(6+len(records)))
+	nameTable = append(nameTable, records...)
+	nameTable = append(nameTable, strs...)
+
+	face := make([]byte, 12+16)
+	binary.BigEndian.PutUint32(face[0:], 0x00010000)
+	binary.BigEndian.PutUint16(face[4:], 1)
+	copy(face[12:], "name")
+	binary.BigEndian.PutUint32(face[20:], uint32(offset+len(face)))
+	binary.BigEndian.PutUint32(face[24:], uint32(len(nameTable)))
+	return append(face, nameTable...)
+}
+
+// TestGetFontIndex 字体目录只扫描一次，ResetFontIndex 之后才能找到新添加的字体
+func TestGetFontIndex(t *testing.T) {
+
+	ResetFontIndex()
+	defer ResetFontIndex()
+	fontsDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(fontsDir, "test.ttf"), buildTestFont([]string{"Test Sans"}), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := GetFontIndex(fontsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(fontsDir, "new.ttf"), buildTestFont([]string{"Test New"}), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachedIndex, err := GetFontIndex(fontsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedIndex != index {
+		t.Fatal("GetFontIndex should return the cached index")
+	}
+	if _, found := cachedIndex.Find("Test New"); found == true {
+		t.Fatal("cached index should not scan the fonts dir again")
+	}
+
+	ResetFontIndex()
+	newIndex, err := GetFontIndex(fontsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := newIndex.Find("Test New"); found == false {
+		t.Fatal("ResetFontIndex should scan the fonts dir again")
+	}
+}
@@ -0,0 +1,256 @@
+package ass_fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// FontFace 字体文件中的一个字体，ttc、otc 中有多个
+type FontFace struct {
+	FPath string // 字体文件的全路径
+	Index int    // 在 ttc、otc 中的索引，ttf、otf 为 0
+}
+
+// FontIndex 本地字体目录的索引，Key 是小写的字体名称（家族名称、全名、PostScript 名称）
+type FontIndex struct {
+	faces map[string]FontFace
+}
+
+// NewFontIndex 读取目录以及子目录中所有字体文件的名称，解析失败的字体文件跳过
+func NewFontIndex(fontsDirPath string) (*FontIndex, error) {
+
+	index := FontIndex{faces: make(map[string]FontFace)}
+	err := filepath.WalkDir(fontsDirPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() == true || isFontFile(d.Name()) == false {
+			return nil
+		}
+		faceNames, err := readFontNames(path)
+		if err != nil {
+			return nil
+		}
+		for i, names := range faceNames {
+			for _, name := range names {
+				key := strings.ToLower(name)
+				// 同名的字体，使用先找到的
+				if _, found := index.faces[key]; found == false {
+					index.faces[key] = FontFace{FPath: path, Index: i}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// GetFontIndex 字体目录的索引，扫描整个目录比较慢，结果缓存 fontIndexCacheTime，字体目录修改了会重新扫描
+func GetFontIndex(fontsDirPath string) (*FontIndex, error) {
+
+	fontIndexCacheLocker.Lock()
+	defer fontIndexCacheLocker.Unlock()
+
+	if fontIndexCache != nil && fontIndexCacheDirPath == fontsDirPath && time.Since(fontIndexCacheCheckTime) < fontIndexCacheTime {
+		return fontIndexCache, nil
+	}
+	index, err := NewFontIndex(fontsDirPath)
+	if err != nil {
+		return nil, err
+	}
+	fontIndexCache = index
+	fontIndexCacheDirPath = fontsDirPath
+	fontIndexCacheCheckTime = time.Now()
+	return index, nil
+}
+
+// ResetFontIndex 清除缓存的字体目录索引，在字体目录中添加了字体之后，下次检查会重新扫描
+func ResetFontIndex() {
+
+	fontIndexCacheLocker.Lock()
+	defer fontIndexCacheLocker.Unlock()
+	fontIndexCache = nil
+}
+
+// Find 按照字体名称查找，不区分大小写
+func (f *FontIndex) Find(fontName string) (FontFace, bool) {
+	face, found := f.faces[strings.ToLower(fontName)]
+	return face, found
+}
+
+func isFontFile(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ttf", ".otf", ".ttc", ".otc":
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+	readFontNames 读取字体文件中每一个字体的名称，只读取 sfnt 的表头以及 name 表
+	ttc、otc 的头部是 ttcf，后面是每个字体的偏移
+*/
+func readFontNames(fontFPath string) ([][]string, error) {
+
+	f, err := os.Open(fontFPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	header := make([]byte, 12)
+	_, err = f.ReadAt(header, 0)
+	if err != nil {
+		return nil, err
+	}
+	offsets := []uint32{0}
+	if string(header[:4]) == "ttcf" {
+		numFonts := binary.BigEndian.Uint32(header[8:12])
+		if numFonts == 0 || numFonts > maxFontsInCollection {
+			return nil, errors.New("bad font collection")
+		}
+		buf := make([]byte, 4*numFonts)
+		_, err = f.ReadAt(buf, 12)
+		if err != nil {
+			return nil, err
+		}
+		offsets = make([]uint32, numFonts)
+		for i := range offsets {
+			offsets[i] = binary.BigEndian.Uint32(buf[i*4:])
+		}
+	}
+
+	faceNames := make([][]string, 0, len(offsets))
+	for _, offset := range offsets {
+		names, err := readFaceNames(f, int64(offset))
+		if err != nil {
+			return nil, err
+		}
+		faceNames = append(faceNames, names)
+	}
+	return faceNames, nil
+}
+
+// readFaceNames 读取一个字体的 name 表中的家族名称、全名、PostScript 名称
+func readFaceNames(r io.ReaderAt, offset int64) ([]string, error) {
+
+	header := make([]byte, 12)
+	_, err := r.ReadAt(header, offset)
+	if err != nil {
+		return nil, err
+	}
+	numTables := int(binary.BigEndian.Uint16(header[4:6]))
+	tables := make([]byte, 16*numTables)
+	_, err = r.ReadAt(tables, offset+12)
+	if err != nil {
+		return nil, err
+	}
+	var nameOffset, nameLength uint32
+	for i := 0; i < numTables; i++ {
+		record := tables[i*16 : i*16+16]
+		if string(record[:4]) == "name" {
+			nameOffset = binary.BigEndian.Uint32(record[8:12])
+			nameLength = binary.BigEndian.Uint32(record[12:16])
+			break
+		}
+	}
+	if nameLength < 6 || nameLength > maxNameTableLength {
+		return nil, errors.New("name table not found")
+	}
+	nameTable := make([]byte, nameLength)
+	_, err = r.ReadAt(nameTable, int64(nameOffset))
+	if err != nil {
+		return nil, err
+	}
+	return parseNameTable(nameTable)
+}
+
+func parseNameTable(nameTable []byte) ([]string, error) {
+
+	count := int(binary.BigEndian.Uint16(nameTable[2:4]))
+	stringOffset := int(binary.BigEndian.Uint16(nameTable[4:6]))
+	if 6+count*12 > len(nameTable) {
+		return nil, errors.New("bad name table")
+	}
+	names := make([]string, 0)
+	found := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		record := nameTable[6+i*12 : 6+i*12+12]
+		platformID := binary.BigEndian.Uint16(record[0:2])
+		encodingID := binary.BigEndian.Uint16(record[2:4])
+		nameID := binary.BigEndian.Uint16(record[6:8])
+		length := int(binary.BigEndian.Uint16(record[8:10]))
+		start := stringOffset + int(binary.BigEndian.Uint16(record[10:12]))
+		switch nameID {
+		case nameIDFamily, nameIDFullName, nameIDPostScript, nameIDTypographicFamily:
+		default:
+			continue
+		}
+		if start+length > len(nameTable) {
+			continue
+		}
+		raw := nameTable[start : start+length]
+		name := ""
+		switch {
+		case platformID == platformUnicode || platformID == platformWindows:
+			name = decodeUTF16BE(raw)
+		case platformID == platformMacintosh && encodingID == 0:
+			// Mac Roman，名称一般是 ASCII
+			name = string(raw)
+		default:
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == "" || found[strings.ToLower(name)] == true {
+			continue
+		}
+		found[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func decodeUTF16BE(raw []byte) string {
+	u16 := make([]uint16, len(raw)/2)
+	for i := range u16 {
+		u16[i] = binary.BigEndian.Uint16(raw[i*2:])
+	}
+	return string(utf16.Decode(u16))
+}
+
+const (
+	platformUnicode   = 0
+	platformMacintosh = 1
+	platformWindows   = 3
+
+	nameIDFamily            = 1
+	nameIDFullName          = 4
+	nameIDPostScript        = 6
+	nameIDTypographicFamily = 16
+
+	maxFontsInCollection = 256
+	maxNameTableLength   = 1024 * 1024
+)
+
+var (
+	fontIndexCache          *FontIndex
+	fontIndexCacheDirPath   string
+	fontIndexCacheCheckTime time.Time
+	fontIndexCacheLocker    sync.Mutex
+)
+
+// fontIndexCacheTime 字体目录索引缓存的时间，避免每个字幕都扫描一次字体目录
+const fontIndexCacheTime = 10 * time.Minute
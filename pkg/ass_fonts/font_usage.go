@@ -0,0 +1,178 @@
+package ass_fonts
+
+import (
+	"sort"
+	"strings"
+)
+
+// FontUsage 字幕中使用的一个字体，以及用这个字体显示的字符
+type FontUsage struct {
+	Name  string        // 字体名称，去掉了竖排字体的 @
+	Chars map[rune]bool // 用到的字符
+}
+
+// CharsString 用到的字符，按编码排序
+func (f *FontUsage) CharsString() string {
+
+	chars := make([]rune, 0, len(f.Chars))
+	for char := range f.Chars {
+		chars = append(chars, char)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return chars[i] < chars[j]
+	})
+	return string(chars)
+}
+
+/*
+	ListFonts 列出 ass/ssa 字幕中使用的字体，按名称排序
+	1. 对白使用的样式中的 Fontname，没有对白使用的样式不算
+	2. 对白中的 \fn 会切换字体，\fn 为空或者 \r 会恢复为样式的字体
+	3. 绘图模式（\p1）中的内容不是文字，不计入字符
+*/
+func ListFonts(content string) []*FontUsage {
+
+	styleFonts := make(map[string]string)
+	var styleFormat, eventFormat []string
+	usages := make(map[string]*FontUsage)
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimLine, "[") == true {
+			section = strings.ToLower(trimLine)
+			continue
+		}
+		key, value, found := cutLine(trimLine)
+		if found == false {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch section {
+		case "[v4+ styles]", "[v4 styles]":
+			if key == "format" {
+				styleFormat = splitFormat(value)
+			} else if key == "style" && len(styleFormat) > 0 {
+				fields := strings.SplitN(value, ",", len(styleFormat))
+				styleName := strings.ToLower(strings.TrimSpace(getField(fields, styleFormat, "name")))
+				styleFonts[styleName] = trimFontName(getField(fields, styleFormat, "fontname"))
+			}
+		case "[events]":
+			if key == "format" {
+				eventFormat = splitFormat(value)
+			} else if key == "dialogue" && len(eventFormat) > 0 {
+				fields := strings.SplitN(value, ",", len(eventFormat))
+				styleName := strings.TrimSpace(getField(fields, eventFormat, "style"))
+				styleName = strings.ToLower(strings.TrimPrefix(styleName, "*"))
+				collectDialogue(getField(fields, eventFormat, "text"), styleName, styleFonts, usages)
+			}
+		}
+	}
+
+	fonts := make([]*FontUsage, 0, len(usages))
+	for _, usage := range usages {
+		fonts = append(fonts, usage)
+	}
+	sort.Slice(fonts, func(i, j int) bool {
+		return strings.ToLower(fonts[i].Name) < strings.ToLower(fonts[j].Name)
+	})
+	return fonts
+}
+
+// collectDialogue 按照 {} 中的 \fn \r \p 切换当前的字体，记录每个字体显示的字符
+func collectDialogue(text, styleName string, styleFonts map[string]string, usages map[string]*FontUsage) {
+
+	styleFont := styleFonts[styleName]
+	if styleFont == "" {
+		styleFont = styleFonts["default"]
+	}
+	nowFont := styleFont
+	drawing := false
+	getUsage := func(fontName string) *FontUsage {
+		key := strings.ToLower(fontName)
+		usage, found := usages[key]
+		if found == false {
+			usage = &FontUsage{Name: fontName, Chars: make(map[rune]bool)}
+			usages[key] = usage
+		}
+		return usage
+	}
+	if nowFont != "" {
+		getUsage(nowFont)
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '{' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end >= len(runes) {
+				break
+			}
+			block := string(runes[i+1 : end])
+			i = end
+			for _, tag := range strings.Split(block, `\`) {
+				switch {
+				case strings.HasPrefix(tag, "fn"):
+					nowFont = trimFontName(tag[2:])
+					if nowFont == "" {
+						nowFont = styleFont
+					}
+				case strings.HasPrefix(tag, "r"):
+					nowFont = styleFont
+					if otherFont, found := styleFonts[strings.ToLower(strings.TrimSpace(tag[1:]))]; found == true {
+						nowFont = otherFont
+					}
+				case strings.HasPrefix(tag, "p") && len(tag) > 1 && tag[1] >= '0' && tag[1] <= '9':
+					drawing = tag[1:] != "0"
+				}
+			}
+			if nowFont != "" {
+				getUsage(nowFont)
+			}
+			continue
+		}
+		// \N \n 换行，\h 不换行的空格
+		if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == 'N' || runes[i+1] == 'n' || runes[i+1] == 'h') {
+			i++
+			continue
+		}
+		if drawing == true || nowFont == "" {
+			continue
+		}
+		getUsage(nowFont).Chars[runes[i]] = true
+	}
+}
+
+// trimFontName 去掉竖排字体的 @
+func trimFontName(fontName string) string {
+	return strings.TrimPrefix(strings.TrimSpace(fontName), "@")
+}
+
+// cutLine 按第一个冒号分为 Key 以及 Value，比如 Style: Default,Arial,20
+func cutLine(line string) (string, string, bool) {
+	index := strings.Index(line, ":")
+	if index < 0 {
+		return line, "", false
+	}
+	return line[:index], line[index+1:], true
+}
+
+func splitFormat(value string) []string {
+	format := strings.Split(value, ",")
+	for i := range format {
+		format[i] = strings.ToLower(strings.TrimSpace(format[i]))
+	}
+	return format
+}
+
+func getField(fields []string, format []string, name string) string {
+	for i := range format {
+		if format[i] == name && i < len(fields) {
+			return fields[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,466 @@
+package ass_style_normalizer
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+/*
+	Normalize 统一 ass/ssa 字幕的样式，返回修改后的内容以及是否有修改
+	按 [Script Info]、[V4+ Styles]、[Events] 中的 Format 逐行处理，其他内容保持原样
+	1. 统计每个样式被多少句对白使用，以及其中带有 \pos \move \k 等特效标签的比例，找出主要对白的样式
+	2. 缩放 PlayResX、PlayResY，所有样式以及对白中的坐标、字号、边框按比例缩放
+	3. 主要对白的样式设置为指定的字体、字号、边框、阴影，其他样式只做字体的替换
+*/
+func Normalize(content string, styleSettings *settings.AssStyleSettings) (string, bool) {
+
+	lines := strings.Split(content, "\n")
+	doc := parseDoc(lines)
+
+	n := normalizer{styleSettings: styleSettings, scaleX: 1, scaleY: 1, scaleBorder: doc.scaledBorderAndShadow}
+	if styleSettings.PlayResX > 0 && styleSettings.PlayResY > 0 {
+		oldX, oldY := doc.getPlayRes()
+		n.scaleX = float64(styleSettings.PlayResX) / oldX
+		n.scaleY = float64(styleSettings.PlayResY) / oldY
+	}
+	mainStyles := doc.getMainStyles()
+
+	outLines := make([]string, 0, len(lines)+2)
+	for i, line := range lines {
+		lineBody, lineEnd := splitLineEnd(line)
+		switch {
+		case i == doc.playResXLine && n.needRescale() == true:
+			lineBody = "PlayResX: " + strconv.Itoa(styleSettings.PlayResX)
+		case i == doc.playResYLine && n.needRescale() == true:
+			lineBody = "PlayResY: " + strconv.Itoa(styleSettings.PlayResY)
+		case doc.styleLines[i] == true:
+			lineBody = n.normalizeStyleLine(lineBody, doc.styleFormat, mainStyles)
+		case doc.dialogueLines[i] == true:
+			lineBody = n.normalizeDialogueLine(lineBody, doc.eventFormat)
+		}
+		outLines = append(outLines, lineBody+lineEnd)
+		// 没有 PlayResX、PlayResY 的时候，加到 [Script Info] 的下面
+		if i == doc.scriptInfoLine && n.needRescale() == true {
+			if doc.playResXLine < 0 {
+				outLines = append(outLines, "PlayResX: "+strconv.Itoa(styleSettings.PlayResX)+lineEnd)
+			}
+			if doc.playResYLine < 0 {
+				outLines = append(outLines, "PlayResY: "+strconv.Itoa(styleSettings.PlayResY)+lineEnd)
+			}
+		}
+	}
+	newContent := strings.Join(outLines, "\n")
+	return newContent, newContent != content
+}
+
+// NormalizeFile 统一一个 ass/ssa 字幕文件的样式，会转换为 UTF-8 编码，返回是否有修改
+func NormalizeFile(subFPath string, styleSettings *settings.AssStyleSettings) (bool, error) {
+
+	fBytes, err := os.ReadFile(subFPath)
+	if err != nil {
+		return false, err
+	}
+	inBytes, err := language.ChangeFileCoding2UTF8(fBytes)
+	if err != nil {
+		return false, err
+	}
+	newContent, changed := Normalize(string(inBytes), styleSettings)
+	if changed == false {
+		return false, nil
+	}
+	// 重复执行的时候，已有的备份才是最原始的字幕，不能覆盖
+	if styleSettings.KeepBackup == true && pkg.IsFile(subFPath+BackUpExt) == false {
+		err = pkg.CopyFile(subFPath, subFPath+BackUpExt)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = pkg.WriteFile(subFPath, []byte(newContent))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsAssOrSsa 是否是需要处理的字幕
+func IsAssOrSsa(subFPath string) bool {
+	ext := strings.ToLower(subFPath[strings.LastIndex(subFPath, ".")+1:])
+	return ext == "ass" || ext == "ssa"
+}
+
+type assDoc struct {
+	scriptInfoLine        int
+	playResXLine          int
+	playResYLine          int
+	playResX              float64
+	playResY              float64
+	scaledBorderAndShadow bool
+	styleFormat           []string           // [V4+ Styles] 中的 Format
+	eventFormat           []string           // [Events] 中的 Format
+	styleLines            map[int]bool       // 样式所在的行
+	dialogueLines         map[int]bool       // 对白所在的行
+	styleCount            map[string]int     // 每个样式被多少句对白使用
+	styleEffectCount      map[string]int     // 每个样式中带有特效标签的对白数量
+	styleFontSize         map[string]float64 // 每个样式原始的字号，Key 是小写的样式名称
+}
+
+func parseDoc(lines []string) *assDoc {
+
+	doc := assDoc{
+		scriptInfoLine:        -1,
+		playResXLine:          -1,
+		playResYLine:          -1,
+		scaledBorderAndShadow: true,
+		styleLines:            make(map[int]bool),
+		dialogueLines:         make(map[int]bool),
+		styleCount:            make(map[string]int),
+		styleEffectCount:      make(map[string]int),
+		styleFontSize:         make(map[string]float64),
+	}
+	section := ""
+	for i, line := range lines {
+		lineBody, _ := splitLineEnd(line)
+		trimLine := strings.TrimSpace(lineBody)
+		if strings.HasPrefix(trimLine, "[") == true {
+			section = strings.ToLower(trimLine)
+			if section == "[script info]" {
+				doc.scriptInfoLine = i
+			}
+			continue
+		}
+		key, value, found := cutLine(trimLine)
+		if found == false {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch section {
+		case "[script info]":
+			switch key {
+			case "playresx":
+				doc.playResXLine = i
+				doc.playResX, _ = strconv.ParseFloat(value, 64)
+			case "playresy":
+				doc.playResYLine = i
+				doc.playResY, _ = strconv.ParseFloat(value, 64)
+			case "scaledborderandshadow":
+				doc.scaledBorderAndShadow = strings.ToLower(value) != "no"
+			}
+		case "[v4+ styles]", "[v4 styles]":
+			if key == "format" {
+				doc.styleFormat = splitFormat(value)
+			} else if key == "style" && len(doc.styleFormat) > 0 {
+				doc.styleLines[i] = true
+				fields := splitFields(value, len(doc.styleFormat))
+				styleName := strings.ToLower(strings.TrimSpace(getField(fields, doc.styleFormat, "name")))
+				doc.styleFontSize[styleName], _ = strconv.ParseFloat(strings.TrimSpace(getField(fields, doc.styleFormat, "fontsize")), 64)
+			}
+		case "[events]":
+			if key == "format" {
+				doc.eventFormat = splitFormat(value)
+			} else if key == "dialogue" && len(doc.eventFormat) > 0 {
+				doc.dialogueLines[i] = true
+				fields := splitFields(value, len(doc.eventFormat))
+				styleName := strings.TrimSpace(getField(fields, doc.eventFormat, "style"))
+				styleName = strings.TrimPrefix(styleName, "*")
+				doc.styleCount[styleName]++
+				if reEffectTag.MatchString(getField(fields, doc.eventFormat, "text")) == true {
+					doc.styleEffectCount[styleName]++
+				}
+			}
+		}
+	}
+	return &doc
+}
+
+// getPlayRes 字幕原始的分辨率，都没有设置的时候是 384x288，只设置了一个的时候按 4:3 推算，与 libass 一致
+func (d *assDoc) getPlayRes() (float64, float64) {
+
+	x, y := d.playResX, d.playResY
+	if x <= 0 && y <= 0 {
+		return 384, 288
+	}
+	if x <= 0 {
+		x = y * 4 / 3
+	}
+	if y <= 0 {
+		y = x * 3 / 4
+	}
+	return x, y
+}
+
+/*
+	getMainStyles 主要对白的样式，使用次数至少是最多那个的 20%，且带有特效标签的对白不超过 30%
+	返回的是每个主要样式的字号相对于使用最多的那个主要样式的比例，Key 是小写的样式名称
+*/
+func (d *assDoc) getMainStyles() map[string]float64 {
+
+	maxCount := 0
+	for _, count := range d.styleCount {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	mainStyles := make(map[string]float64)
+	topStyle, topCount := "", 0
+	for styleName, count := range d.styleCount {
+		if float64(count) < float64(maxCount)*mainStyleMinRatio {
+			continue
+		}
+		if float64(d.styleEffectCount[styleName]) > float64(count)*effectMaxRatio {
+			continue
+		}
+		mainStyles[strings.ToLower(styleName)] = 1
+		if count > topCount || (count == topCount && styleName < topStyle) {
+			topStyle, topCount = styleName, count
+		}
+	}
+	topFontSize := d.styleFontSize[strings.ToLower(topStyle)]
+	if topFontSize <= 0 {
+		return mainStyles
+	}
+	for styleName := range mainStyles {
+		if fontSize := d.styleFontSize[styleName]; fontSize > 0 {
+			mainStyles[styleName] = fontSize / topFontSize
+		}
+	}
+	return mainStyles
+}
+
+type normalizer struct {
+	styleSettings *settings.AssStyleSettings
+	scaleX        float64
+	scaleY        float64
+	scaleBorder   bool // ScaledBorderAndShadow 为 no 的时候，边框、阴影是视频的像素，不需要缩放
+}
+
+func (n *normalizer) needRescale() bool {
+	return n.scaleX != 1 || n.scaleY != 1
+}
+
+func (n *normalizer) normalizeStyleLine(lineBody string, format []string, mainStyles map[string]float64) string {
+
+	if len(format) == 0 {
+		return lineBody
+	}
+	prefix, value, _ := cutLine(lineBody)
+	fields := splitFields(strings.TrimLeft(value, " "), len(format))
+	styleName := strings.TrimSpace(getField(fields, format, "name"))
+	fontSizeRatio, isMain := mainStyles[strings.ToLower(styleName)]
+
+	for i := range fields {
+		if i >= len(format) {
+			break
+		}
+		switch format[i] {
+		case "fontname":
+			fields[i] = n.mapFont(fields[i])
+			if isMain == true && n.styleSettings.FontName != "" {
+				fields[i] = n.styleSettings.FontName
+			}
+		case "fontsize":
+			fields[i] = scaleNumber(fields[i], n.scaleY, false)
+			// 多个主要对白的样式（双语字幕）保持原有的字号比例，使用最多的那个设置为 FontSize
+			if isMain == true && n.styleSettings.FontSize > 0 {
+				fields[i] = formatNumber(n.styleSettings.FontSize * fontSizeRatio)
+			}
+		case "outline":
+			if n.scaleBorder == true {
+				fields[i] = scaleNumber(fields[i], n.scaleY, false)
+			}
+			if isMain == true && n.styleSettings.Outline >= 0 {
+				fields[i] = formatNumber(n.styleSettings.Outline)
+			}
+		case "shadow":
+			if n.scaleBorder == true {
+				fields[i] = scaleNumber(fields[i], n.scaleY, false)
+			}
+			if isMain == true && n.styleSettings.Shadow >= 0 {
+				fields[i] = formatNumber(n.styleSettings.Shadow)
+			}
+		case "spacing":
+			fields[i] = scaleNumber(fields[i], n.scaleX, false)
+		case "marginl", "marginr":
+			fields[i] = scaleNumber(fields[i], n.scaleX, true)
+		case "marginv":
+			fields[i] = scaleNumber(fields[i], n.scaleY, true)
+		}
+	}
+	return prefix + ": " + strings.Join(fields, ",")
+}
+
+func (n *normalizer) normalizeDialogueLine(lineBody string, format []string) string {
+
+	prefix, value, _ := cutLine(lineBody)
+	fields := splitFields(strings.TrimLeft(value, " "), len(format))
+	for i := range fields {
+		if i >= len(format) {
+			break
+		}
+		switch format[i] {
+		case "marginl", "marginr":
+			fields[i] = scaleNumber(fields[i], n.scaleX, true)
+		case "marginv":
+			fields[i] = scaleNumber(fields[i], n.scaleY, true)
+		case "text":
+			fields[i] = reOverrideBlock.ReplaceAllStringFunc(fields[i], n.normalizeOverrideBlock)
+		}
+	}
+	return prefix + ": " + strings.Join(fields, ",")
+}
+
+// normalizeOverrideBlock 处理一个 {} 中的特效标签
+func (n *normalizer) normalizeOverrideBlock(block string) string {
+
+	block = reFontNameTag.ReplaceAllStringFunc(block, func(tag string) string {
+		return `\fn` + n.mapFont(tag[3:])
+	})
+	if n.needRescale() == false {
+		return block
+	}
+	block = reCoordinateTag.ReplaceAllStringFunc(block, func(tag string) string {
+		matched := reCoordinateTag.FindStringSubmatch(tag)
+		args := strings.Split(matched[2], ",")
+		switch matched[1] {
+		case "pos", "org":
+			if len(args) != 2 {
+				return tag
+			}
+		case "move":
+			if len(args) != 4 && len(args) != 6 {
+				return tag
+			}
+		case "clip", "iclip":
+			// 矢量的 clip 不处理
+			if len(args) != 4 {
+				return tag
+			}
+		}
+		for i := 0; i < 4 && i < len(args); i++ {
+			if i%2 == 0 {
+				args[i] = scaleNumber(args[i], n.scaleX, false)
+			} else {
+				args[i] = scaleNumber(args[i], n.scaleY, false)
+			}
+		}
+		return `\` + matched[1] + "(" + strings.Join(args, ",") + ")"
+	})
+	block = reFontSizeTag.ReplaceAllStringFunc(block, func(tag string) string {
+		return `\fs` + scaleNumber(tag[3:], n.scaleY, false)
+	})
+	if n.scaleBorder == true {
+		block = reBorderTag.ReplaceAllStringFunc(block, func(tag string) string {
+			matched := reBorderTag.FindStringSubmatch(tag)
+			scale := n.scaleY
+			if strings.HasPrefix(matched[1], "x") == true {
+				scale = n.scaleX
+			}
+			return `\` + matched[1] + scaleNumber(matched[2], scale, false)
+		})
+	}
+	return block
+}
+
+// mapFont 替换字体，@ 开头的竖排字体保留 @
+func (n *normalizer) mapFont(fontName string) string {
+
+	vertical := strings.HasPrefix(strings.TrimSpace(fontName), "@")
+	name := strings.TrimPrefix(strings.TrimSpace(fontName), "@")
+	for from, to := range n.styleSettings.FontMap {
+		if strings.EqualFold(from, name) == true {
+			if vertical == true {
+				return "@" + to
+			}
+			return to
+		}
+	}
+	return fontName
+}
+
+func splitLineEnd(line string) (string, string) {
+	if strings.HasSuffix(line, "\r") == true {
+		return line[:len(line)-1], "\r"
+	}
+	return line, ""
+}
+
+// cutLine 按第一个冒号分为 Key 以及 Value，比如 Style: Default,Arial,20
+func cutLine(line string) (string, string, bool) {
+	index := strings.Index(line, ":")
+	if index < 0 {
+		return line, "", false
+	}
+	return line[:index], line[index+1:], true
+}
+
+func splitFormat(value string) []string {
+	format := strings.Split(value, ",")
+	for i := range format {
+		format[i] = strings.ToLower(strings.TrimSpace(format[i]))
+	}
+	return format
+}
+
+// splitFields 最后一个字段（Text）中可能有逗号
+func splitFields(value string, count int) []string {
+	return strings.SplitN(value, ",", count)
+}
+
+func getField(fields []string, format []string, name string) string {
+	for i := range format {
+		if format[i] == name && i < len(fields) {
+			return fields[i]
+		}
+	}
+	return ""
+}
+
+// scaleNumber 缩放一个数值，解析失败的时候保持原样
+func scaleNumber(value string, scale float64, isInt bool) string {
+
+	if scale == 1 {
+		return value
+	}
+	trimValue := strings.TrimSpace(value)
+	number, err := strconv.ParseFloat(trimValue, 64)
+	if err != nil {
+		return value
+	}
+	if isInt == true {
+		// 边距是整数，ass 中通常是 4 位补零的
+		if number == 0 {
+			return value
+		}
+		return strconv.Itoa(int(math.Round(number * scale)))
+	}
+	return formatNumber(number * scale)
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(math.Round(number*100)/100, 'f', -1, 64)
+}
+
+// BackUpExt 样式统一之前的原始字幕的备份后缀名，与时间轴校正的 .csf-bk 区分开
+const BackUpExt = ".csf-style-bk"
+
+const (
+	mainStyleMinRatio = 0.2
+	effectMaxRatio    = 0.3
+)
+
+var (
+	reOverrideBlock = regexp.MustCompile(`\{[^}]*\}`)
+	// 定位、移动、卡拉 OK、动画、绘图，带有这些标签的对白是特效，不是普通的对白
+	reEffectTag     = regexp.MustCompile(`\\(pos|move|org|i?clip|t)\(|\\(k|K|kf|ko)\d|\\p[1-9]`)
+	reCoordinateTag = regexp.MustCompile(`\\(pos|move|org|i?clip)\(([^)]*)\)`)
+	reFontSizeTag   = regexp.MustCompile(`\\fs\d+(\.\d+)?`)
+	reFontNameTag   = regexp.MustCompile(`\\fn[^\\}]*`)
+	reBorderTag     = regexp.MustCompile(`\\(bord|shad|xbord|ybord|xshad|yshad)(-?\d+(\.\d+)?)`)
+)
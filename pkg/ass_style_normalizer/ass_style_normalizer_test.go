@@ -0,0 +1,117 @@
+package ass_style_normalizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+const testAss = "[Script Info]\r\n" +
+	"ScriptType: v4.00+\r\n" +
+	"PlayResX: 384\r\n" +
+	"PlayResY: 288\r\n" +
+	"\r\n" +
+	"[V4+ Styles]\r\n" +
+	"Format: Name, Fontname, Fontsize, Outline, Shadow, MarginL, MarginR, MarginV\r\n" +
+	"Style: Default,Arial,20,2,1,10,10,15\r\n" +
+	"Style: Sign,SimHei,30,1,0,0,0,0\r\n" +
+	"\r\n" +
+	"[Events]\r\n" +
+	"Format: Layer, Start, End, Style, MarginL, MarginR, MarginV, Text\r\n" +
+	"Dialogue: 0,0:00:01.00,0:00:02.00,Default,0,0,0,你好\r\n" +
+	"Dialogue: 0,0:00:03.00,0:00:04.00,Default,0,0,0,世界, 再见\r\n" +
+	"Dialogue: 0,0:00:05.00,0:00:06.00,Default,0,0,0,{\\fnSimHei}再见\r\n" +
+	"Dialogue: 0,0:00:07.00,0:00:08.00,Sign,0,0,0,{\\pos(100,50)\\fnSimHei\\fs20\\bord2}招牌\r\n" +
+	"Dialogue: 0,0:00:09.00,0:00:10.00,Sign,0,0,0,{\\pos(10,20)}招牌\r\n"
+
+func TestNormalize(t *testing.T) {
+
+	styleSettings := settings.NewAssStyleSettings()
+	styleSettings.PlayResX = 768
+	styleSettings.PlayResY = 576
+	styleSettings.FontName = "Noto Sans CJK SC"
+	styleSettings.FontSize = 50
+	styleSettings.Outline = 3
+	styleSettings.FontMap = map[string]string{"simhei": "Source Han Sans"}
+
+	newContent, changed := Normalize(testAss, styleSettings)
+	if changed == false {
+		t.Fatal("Normalize should change the content")
+	}
+	wants := []string{
+		"PlayResX: 768\r\n",
+		"PlayResY: 576\r\n",
+		// 主要对白的样式，使用设置的字体、字号、边框，阴影没有设置则按比例缩放
+		"Style: Default,Noto Sans CJK SC,50,3,2,20,20,30\r\n",
+		// 招牌的样式只缩放以及替换字体
+		"Style: Sign,Source Han Sans,60,2,0,0,0,0\r\n",
+		"Default,0,0,0,世界, 再见\r\n",
+		"Default,0,0,0,{\\fnSource Han Sans}再见\r\n",
+		"Sign,0,0,0,{\\pos(200,100)\\fnSource Han Sans\\fs40\\bord4}招牌\r\n",
+	}
+	for _, want := range wants {
+		if strings.Contains(newContent, want) == false {
+			t.Errorf("Normalize() missing %q, got:\n%s", want, newContent)
+		}
+	}
+}
+
+func TestNormalize_AddPlayRes(t *testing.T) {
+
+	content := strings.ReplaceAll(testAss, "PlayResX: 384\r\n", "")
+	content = strings.ReplaceAll(content, "PlayResY: 288\r\n", "")
+	styleSettings := settings.NewAssStyleSettings()
+	styleSettings.PlayResX = 768
+	styleSettings.PlayResY = 576
+
+	newContent, changed := Normalize(content, styleSettings)
+	if changed == false {
+		t.Fatal("Normalize should change the content")
+	}
+	if strings.HasPrefix(newContent, "[Script Info]\r\nPlayResX: 768\r\nPlayResY: 576\r\n") == false {
+		t.Errorf("Normalize() should add PlayRes, got:\n%s", newContent)
+	}
+}
+
+func TestNormalize_NothingToChange(t *testing.T) {
+
+	styleSettings := settings.NewAssStyleSettings()
+	styleSettings.PlayResX = 384
+	styleSettings.PlayResY = 288
+
+	newContent, changed := Normalize(testAss, styleSettings)
+	if changed == true || newContent != testAss {
+		t.Errorf("Normalize() should not change the content, got:\n%s", newContent)
+	}
+}
+
+// TestNormalizeFile_KeepFirstBackup 修改了设置重新执行，备份的还是最原始的字幕
+func TestNormalizeFile_KeepFirstBackup(t *testing.T) {
+
+	subFPath := filepath.Join(t.TempDir(), "Foo.ass")
+	err := os.WriteFile(subFPath, []byte(testAss), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	styleSettings := settings.NewAssStyleSettings()
+	for _, fontName := range []string{"Microsoft YaHei", "SimHei"} {
+		styleSettings.FontName = fontName
+		changed, err := NormalizeFile(subFPath, styleSettings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed == false {
+			t.Fatalf("NormalizeFile should change the sub, font_name: %v", fontName)
+		}
+	}
+	bkBytes, err := os.ReadFile(subFPath + BackUpExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bkBytes) != testAss {
+		t.Errorf("backup should be the original sub, got:\n%s", string(bkBytes))
+	}
+}
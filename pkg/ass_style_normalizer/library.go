@@ -0,0 +1,134 @@
+package ass_style_normalizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+// LibraryStatus 对已有的媒体库执行样式统一的进度
+type LibraryStatus struct {
+	Running    bool   `json:"running"`
+	Total      int    `json:"total"`   // 找到的 ass/ssa 字幕数量
+	Done       int    `json:"done"`    // 已经处理的数量
+	Changed    int    `json:"changed"` // 有修改的数量
+	Failed     int    `json:"failed"`  // 处理失败的数量
+	ErrMessage string `json:"err_message"`
+}
+
+var (
+	libraryLocker sync.Mutex
+	libraryStatus LibraryStatus
+)
+
+/*
+	StartNormalizeLibrary 在后台对电影、连续剧目录中已有的 ass/ssa 字幕执行样式统一
+	只处理与视频在同一个目录下的字幕，已经在执行的时候返回 false
+*/
+func StartNormalizeLibrary(log *logrus.Logger) bool {
+
+	libraryLocker.Lock()
+	defer libraryLocker.Unlock()
+	if libraryStatus.Running == true {
+		return false
+	}
+	libraryStatus = LibraryStatus{Running: true}
+
+	go func() {
+		defer func() {
+			libraryLocker.Lock()
+			libraryStatus.Running = false
+			libraryLocker.Unlock()
+		}()
+
+		styleSettings := settings.Get().AdvancedSettings.AssStyleSettings
+		rootDirs := make([]string, 0)
+		rootDirs = append(rootDirs, settings.Get().CommonSettings.MoviePaths...)
+		rootDirs = append(rootDirs, settings.Get().CommonSettings.SeriesPaths...)
+		subFPaths, err := searchLibrarySubs(rootDirs)
+		if err != nil {
+			log.Errorln("StartNormalizeLibrary.searchLibrarySubs", err)
+			libraryLocker.Lock()
+			libraryStatus.ErrMessage = err.Error()
+			libraryLocker.Unlock()
+			return
+		}
+		libraryLocker.Lock()
+		libraryStatus.Total = len(subFPaths)
+		libraryLocker.Unlock()
+
+		for _, subFPath := range subFPaths {
+			changed, err := NormalizeFile(subFPath, styleSettings)
+			libraryLocker.Lock()
+			libraryStatus.Done++
+			if err != nil {
+				log.Warningln("StartNormalizeLibrary.NormalizeFile", subFPath, err)
+				libraryStatus.Failed++
+			} else if changed == true {
+				libraryStatus.Changed++
+			}
+			libraryLocker.Unlock()
+		}
+		log.Infoln("StartNormalizeLibrary Done, Total:", len(subFPaths))
+	}()
+
+	return true
+}
+
+// GetLibraryStatus 获取对已有的媒体库执行样式统一的进度
+func GetLibraryStatus() LibraryStatus {
+	libraryLocker.Lock()
+	defer libraryLocker.Unlock()
+	return libraryStatus
+}
+
+// searchLibrarySubs 找到目录下与视频在同一个目录中的 ass/ssa 字幕，跳过隐藏的目录
+func searchLibrarySubs(rootDirs []string) ([]string, error) {
+
+	subFPaths := make([]string, 0)
+	for _, rootDir := range rootDirs {
+		if pkg.IsDir(rootDir) == false {
+			continue
+		}
+		err := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() == false {
+				return nil
+			}
+			if path != rootDir && strings.HasPrefix(d.Name(), ".") == true {
+				return filepath.SkipDir
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			hasVideo := false
+			subs := make([]string, 0)
+			for _, entry := range entries {
+				if entry.IsDir() == true {
+					continue
+				}
+				if pkg.IsWantedVideoExtDef(entry.Name()) == true {
+					hasVideo = true
+				} else if IsAssOrSsa(entry.Name()) == true {
+					subs = append(subs, filepath.Join(path, entry.Name()))
+				}
+			}
+			if hasVideo == true {
+				subFPaths = append(subFPaths, subs...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return subFPaths, nil
+}
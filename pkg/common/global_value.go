@@ -4,40 +4,23 @@ import (
 	"sync"
 )
 
-// SetAccessToken 设置 Web UI 访问的 Token
-func SetAccessToken(newToken string) {
+// SetApiEnabled 设置是否开启 API 接口，开启后使用用户的 API Key 鉴权
+func SetApiEnabled(enabled bool) {
 
-	defer mutexAccessToken.Unlock()
-	mutexAccessToken.Lock()
-	accessToken = newToken
+	defer mutexApiEnabled.Unlock()
+	mutexApiEnabled.Lock()
+	apiEnabled = enabled
 }
 
-// GetAccessToken 获取 Web UI 访问的 Token
-func GetAccessToken() string {
+// GetApiEnabled 是否开启了 API 接口
+func GetApiEnabled() bool {
 
-	defer mutexAccessToken.Unlock()
-	mutexAccessToken.Lock()
-	return accessToken
-}
-
-// SetApiToken 设置 API 接口访问的 Token
-func SetApiToken(newToken string) {
-
-	defer mutexAccessToken.Unlock()
-	mutexAccessToken.Lock()
-	apiToken = newToken
-}
-
-// GetApiToken 获取 API 接口访问的 Token
-func GetApiToken() string {
-
-	defer mutexAccessToken.Unlock()
-	mutexAccessToken.Lock()
-	return apiToken
+	defer mutexApiEnabled.Unlock()
+	mutexApiEnabled.Lock()
+	return apiEnabled
 }
 
 var (
-	accessToken      = ""
-	apiToken         = ""
-	mutexAccessToken sync.Mutex
+	apiEnabled      = false
+	mutexApiEnabled sync.Mutex
 )
@@ -196,6 +196,28 @@ func getVideoNfoInfo(nfoFilePath string, rootKey string) (types.VideoNfoInfo, er
 		imdbInfo.Episode = episode
 		break
 	}
+	// 动画的绝对集数
+	for _, t := range doc.FindElements("./" + rootKey + "/absolute_number") {
+		absoluteEpisode, err := strconv.Atoi(t.Text())
+		if err != nil {
+			continue
+		}
+		imdbInfo.AbsoluteEpisode = absoluteEpisode
+		break
+	}
+	for _, t := range doc.FindElements("./" + rootKey + "/absolute_episode") {
+		absoluteEpisode, err := strconv.Atoi(t.Text())
+		if err != nil {
+			continue
+		}
+		imdbInfo.AbsoluteEpisode = absoluteEpisode
+		break
+	}
+	//---------------------------------------------------------------------
+	imdbInfo.Genres = make([]string, 0)
+	for _, t := range doc.FindElements("./" + rootKey + "/genre") {
+		imdbInfo.Genres = append(imdbInfo.Genres, strings.TrimSpace(t.Text()))
+	}
 	//---------------------------------------------------------------------
 	for _, t := range doc.FindElements("./" + rootKey + "/year") {
 		imdbInfo.Year = t.Text()
@@ -305,6 +327,34 @@ func GetVideoNfoInfo4Movie(movieFileFullPath string) (types.VideoNfoInfo, error)
 	return videoNfoInfo, common.NoMetadataFile
 }
 
+/*
+	GetVideoNfoInfoFromNfoFile 只解析指定的这一个 nfo 文件，不会去目录中查找其他的 nfo
+	只支持电影（movie）、连续剧（tvshow）的 nfo，单集（episodedetails）的 nfo 中是这一集的 ID，返回空的信息
+*/
+func GetVideoNfoInfoFromNfoFile(nfoFilePath string) (types.VideoNfoInfo, error) {
+
+	doc := etree.NewDocument()
+	doc.ReadSettings.Permissive = true
+	err := doc.ReadFromFile(nfoFilePath)
+	if err != nil {
+		return types.VideoNfoInfo{}, err
+	}
+	root := doc.Root()
+	if root == nil {
+		return types.VideoNfoInfo{}, common.NoMetadataFile
+	}
+	switch strings.ToLower(root.Tag) {
+	case "movie":
+		videoNfoInfo, err := getVideoNfoInfo(nfoFilePath, root.Tag)
+		videoNfoInfo.IsMovie = true
+		return videoNfoInfo, err
+	case "tvshow":
+		return getVideoNfoInfo(nfoFilePath, root.Tag)
+	default:
+		return types.VideoNfoInfo{}, nil
+	}
+}
+
 // GetVideoNfoInfo4SeriesDir 从一个连续剧的根目录获取 IMDB info
 func GetVideoNfoInfo4SeriesDir(seriesDir string) (types.VideoNfoInfo, error) {
 	imdbInfo := types.VideoNfoInfo{}
@@ -520,6 +570,42 @@ func GetSeasonAndEpisodeFromSubFileName(videoFileName string) (bool, int, int, e
 	}
 }
 
+/*
+	GetEpisodeRangeFromFileName 从文件名推断多集合并的视频（或者字幕）的 季 以及 集 的范围，只有一集的时候 found 为 false
+	1. Show.S01E01E02.1080p.mkv
+	2. Show.S01E01-E02.1080p.mkv
+	3. Show.S01E01-02.1080p.mkv
+*/
+func GetEpisodeRangeFromFileName(fileName string) (bool, int, int, int) {
+
+	upperName := strings.ToUpper(filepath.Base(fileName))
+	matched := regMatchEpisodeRange.FindStringSubmatch(upperName)
+	if matched == nil {
+		return false, 0, 0, 0
+	}
+	season, err := GetNumber2int(matched[1])
+	if err != nil {
+		return false, 0, 0, 0
+	}
+	episodes := regMatchEpisodeNumber.FindAllStringSubmatch(matched[2], -1)
+	if len(episodes) < 2 {
+		return false, 0, 0, 0
+	}
+	episodeStart, err := strconv.Atoi(episodes[0][1])
+	if err != nil {
+		return false, 0, 0, 0
+	}
+	episodeEnd, err := strconv.Atoi(episodes[len(episodes)-1][1])
+	if err != nil {
+		return false, 0, 0, 0
+	}
+	// 范围需要是递增的，且不能太离谱
+	if episodeEnd <= episodeStart || episodeEnd-episodeStart > maxEpisodeRange {
+		return false, 0, 0, 0
+	}
+	return true, season, episodeStart, episodeEnd
+}
+
 func GetNumber2Float(input string) (float32, error) {
 	compile := regexp.MustCompile(regGetNumber)
 	params := compile.FindStringSubmatch(input)
@@ -593,3 +679,10 @@ const (
 	// 获取数字
 	regGetNumber = "(?:\\-)?\\d{1,}(?:\\.\\d{1,})?"
 )
+
+// 多集合并的视频 S01E01E02 S01E01-E02 S01E01-02
+var regMatchEpisodeRange = regexp.MustCompile(`(?:^|[^A-Z0-9])S(\d{1,4})((?:E\d{1,4})(?:(?:-?E|-)\d{1,4})+)(?:[^0-9]|$)`)
+var regMatchEpisodeNumber = regexp.MustCompile(`(\d{1,4})`)
+
+// 一个视频文件最多合并多少集
+const maxEpisodeRange = 10
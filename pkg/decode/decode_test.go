@@ -221,3 +221,32 @@ func TestGetImdbInfo4Movie(t *testing.T) {
 	//	})
 	//}
 }
+
+func TestGetEpisodeRangeFromFileName(t *testing.T) {
+	tests := []struct {
+		name         string
+		fileName     string
+		wantFound    bool
+		wantSeason   int
+		wantEpsStart int
+		wantEpsEnd   int
+	}{
+		{name: "00", fileName: "Show.S01E01E02.1080p.WEB-DL.mkv", wantFound: true, wantSeason: 1, wantEpsStart: 1, wantEpsEnd: 2},
+		{name: "01", fileName: "Show - S02E03-E05 - Title.mkv", wantFound: true, wantSeason: 2, wantEpsStart: 3, wantEpsEnd: 5},
+		{name: "02", fileName: "Show.S01E09-10.chs.srt", wantFound: true, wantSeason: 1, wantEpsStart: 9, wantEpsEnd: 10},
+		{name: "03", fileName: "Show.S01E01.1080p.mkv", wantFound: false},
+		{name: "04", fileName: "Show.S01E01-1080p.mkv", wantFound: false},
+		{name: "05", fileName: "Show.S01E05E02.mkv", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, season, epsStart, epsEnd := GetEpisodeRangeFromFileName(tt.fileName)
+			if found != tt.wantFound {
+				t.Fatalf("GetEpisodeRangeFromFileName() found = %v, want %v", found, tt.wantFound)
+			}
+			if found == true && (season != tt.wantSeason || epsStart != tt.wantEpsStart || epsEnd != tt.wantEpsEnd) {
+				t.Errorf("GetEpisodeRangeFromFileName() = S%dE%d-E%d, want S%dE%d-E%d", season, epsStart, epsEnd, tt.wantSeason, tt.wantEpsStart, tt.wantEpsEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/series_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/post_save_hook"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	subcommon "github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+)
+
+// candidateSearch 一个视频的手动字幕搜索
+type candidateSearch struct {
+	reply      backend.ReplyCandidateSearch
+	candidates map[string]candidateFile // Key: 候选字幕的 ID
+}
+
+// candidateFile 候选字幕缓存的文件以及评分
+type candidateFile struct {
+	subFPath       string
+	isForced       bool
+	scoreBreakdown *sub_score.Breakdown
+}
+
+/*
+	SearchCandidates 手动搜索一个视频的字幕，在后台执行，通过 GetCandidateSearch 获取结果
+	1. 所有可用的字幕源都会参与搜索，不会判断是否需要下载字幕
+	2. 下载的字幕会复制到单独的缓存目录，避免被下载队列清理
+*/
+func (d *Downloader) SearchCandidates(videoFPath string, videoType common.VideoType) error {
+
+	if d.subSupplierHub == nil || len(d.subSupplierHub.Suppliers) < 1 {
+		return ErrNoSubSupplier
+	}
+
+	d.candidateLocker.Lock()
+	nowSearch, found := d.candidateSearches[videoFPath]
+	if found == true && nowSearch.reply.Status == backend.CandidateSearchRunning {
+		d.candidateLocker.Unlock()
+		return ErrCandidateSearchRunning
+	}
+	// 清理已经结束很久的搜索，缓存的字幕也一并删除
+	expiredVideoFPaths := make([]string, 0)
+	for oneVideoFPath, oneSearch := range d.candidateSearches {
+		if oneSearch.reply.Status != backend.CandidateSearchRunning && time.Since(oneSearch.reply.EndTime) > candidateKeepTime {
+			delete(d.candidateSearches, oneVideoFPath)
+			expiredVideoFPaths = append(expiredVideoFPaths, oneVideoFPath)
+		}
+	}
+	d.candidateSearches[videoFPath] = &candidateSearch{
+		reply: backend.ReplyCandidateSearch{
+			VideoFPath: videoFPath,
+			VideoType:  int(videoType),
+			Status:     backend.CandidateSearchRunning,
+			StartTime:  time.Now(),
+			Candidates: make([]backend.CandidateInfo, 0),
+		},
+		candidates: make(map[string]candidateFile),
+	}
+	d.candidateLocker.Unlock()
+
+	for _, oneVideoFPath := range expiredVideoFPaths {
+		if oneVideoFPath == videoFPath {
+			// 这次搜索会重新清理这个缓存目录
+			continue
+		}
+		err := pkg.ClearTmpFolderByName(getCandidateCacheFolderName(oneVideoFPath))
+		if err != nil {
+			d.log.Warningln("SearchCandidates.ClearTmpFolderByName", oneVideoFPath, err)
+		}
+	}
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				d.log.Errorln("SearchCandidates panic", videoFPath, p)
+				d.setCandidateSearchResult(videoFPath, nil, nil, fmt.Errorf("panic: %v", p))
+			}
+		}()
+
+		d.log.Infoln("SearchCandidates Start", videoFPath)
+		subFPaths, releaseNames, err := d.searchCandidateSubFiles(videoFPath, videoType)
+		if err != nil {
+			d.log.Errorln("SearchCandidates", videoFPath, err)
+		}
+		d.setCandidateSearchResult(videoFPath, subFPaths, releaseNames, err)
+		d.log.Infoln("SearchCandidates End, Found", len(subFPaths), "subtitles", videoFPath)
+	}()
+
+	return nil
+}
+
+// GetCandidateSearch 获取一个视频的手动字幕搜索的结果
+func (d *Downloader) GetCandidateSearch(videoFPath string) (backend.ReplyCandidateSearch, bool) {
+
+	d.candidateLocker.Lock()
+	defer d.candidateLocker.Unlock()
+	nowSearch, found := d.candidateSearches[videoFPath]
+	if found == false {
+		return backend.ReplyCandidateSearch{}, false
+	}
+	return nowSearch.reply, true
+}
+
+// GetCandidateSubFPath 获取一个候选字幕缓存的文件，用于预览
+func (d *Downloader) GetCandidateSubFPath(videoFPath string, candidateID string) (string, error) {
+
+	d.candidateLocker.Lock()
+	defer d.candidateLocker.Unlock()
+	nowSearch, found := d.candidateSearches[videoFPath]
+	if found == false {
+		return "", ErrCandidateNotFound
+	}
+	candidate, found := nowSearch.candidates[candidateID]
+	if found == false || pkg.IsFile(candidate.subFPath) == false {
+		return "", ErrCandidateNotFound
+	}
+	return candidate.subFPath, nil
+}
+
+/*
+	ChooseCandidate 选择一个候选字幕，跟自动下载的字幕一样，通过字幕命名格式化、时间轴校正等流程保存到视频目录
+	时间轴校正可能比较耗时，所以在后台执行，返回任务的 ID，通过 GetCandidateChoose 获取结果
+	多个选择依次执行，避免同一个视频的字幕相互覆盖
+*/
+func (d *Downloader) ChooseCandidate(videoFPath string, candidateID string) (backend.ReplyCandidateChoose, error) {
+
+	d.candidateLocker.Lock()
+	var candidate candidateFile
+	found := false
+	nowSearch, ok := d.candidateSearches[videoFPath]
+	if ok == true {
+		candidate, found = nowSearch.candidates[candidateID]
+	}
+	if found == false || pkg.IsFile(candidate.subFPath) == false {
+		d.candidateLocker.Unlock()
+		return backend.ReplyCandidateChoose{}, ErrCandidateNotFound
+	}
+	// 清理已经结束很久的任务
+	for jobID, oneChoose := range d.candidateChooses {
+		if oneChoose.Status != backend.CandidateSearchRunning && time.Since(oneChoose.EndTime) > candidateKeepTime {
+			delete(d.candidateChooses, jobID)
+		}
+	}
+	nowChoose := backend.ReplyCandidateChoose{
+		JobID:       fmt.Sprintf("%x", sha256.Sum256([]byte(videoFPath+candidateID+time.Now().String()))),
+		VideoFPath:  videoFPath,
+		CandidateID: candidateID,
+		Status:      backend.CandidateSearchRunning,
+		StartTime:   time.Now(),
+	}
+	d.candidateChooses[nowChoose.JobID] = &nowChoose
+	reply := nowChoose
+	d.candidateLocker.Unlock()
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				d.log.Errorln("ChooseCandidate panic", videoFPath, p)
+				d.setCandidateChooseResult(reply.JobID, fmt.Errorf("panic: %v", p))
+			}
+		}()
+
+		d.candidateChooseLocker.Lock()
+		defer d.candidateChooseLocker.Unlock()
+		err := d.chooseCandidate(reply.JobID, videoFPath, candidate)
+		if err != nil {
+			d.log.Errorln("ChooseCandidate", videoFPath, err)
+		}
+		d.setCandidateChooseResult(reply.JobID, err)
+	}()
+
+	return reply, nil
+}
+
+// GetCandidateChoose 获取一个选择候选字幕任务的结果
+func (d *Downloader) GetCandidateChoose(jobID string) (backend.ReplyCandidateChoose, bool) {
+
+	d.candidateLocker.Lock()
+	defer d.candidateLocker.Unlock()
+	nowChoose, found := d.candidateChooses[jobID]
+	if found == false {
+		return backend.ReplyCandidateChoose{}, false
+	}
+	return *nowChoose, true
+}
+
+func (d *Downloader) setCandidateChooseResult(jobID string, err error) {
+
+	d.candidateLocker.Lock()
+	defer d.candidateLocker.Unlock()
+	nowChoose, found := d.candidateChooses[jobID]
+	if found == false {
+		return
+	}
+	nowChoose.EndTime = time.Now()
+	if err != nil {
+		nowChoose.Status = backend.CandidateSearchFailed
+		nowChoose.ErrorInfo = err.Error()
+	} else {
+		nowChoose.Status = backend.CandidateSearchDone
+	}
+}
+
+// chooseCandidate 把选择的候选字幕保存到视频目录，然后执行字幕保存后的回调
+func (d *Downloader) chooseCandidate(jobID string, videoFPath string, candidate candidateFile) error {
+
+	bok, subFileInfo, err := d.fileDownloader.SubParserHub.DetermineFileTypeFromFile(candidate.subFPath)
+	if err != nil {
+		return err
+	}
+	if bok == false {
+		return fmt.Errorf("not support sub type, %s", filepath.Base(candidate.subFPath))
+	}
+	subFileInfo.IsForced = candidate.isForced
+	subFileInfo.ScoreBreakdown = candidate.scoreBreakdown
+
+	if subFileInfo.IsForced == true {
+		// forced 字幕与主字幕并存
+		savedSub, err := d.SaveSubHelper.WriteForcedSubFile2VideoPath(jobID, videoFPath, *subFileInfo, subFileInfo.FromWhereSite)
+		if err != nil {
+			return err
+		}
+		post_save_hook.RunAsync(d.log, post_save_hook.NewPayload4Video(jobID, videoFPath, []save_sub_helper.SavedSub{*savedSub}))
+		return nil
+	}
+
+	d.searchVideoMatchSubFileAndRemoveExtMark(videoFPath)
+	bSetDefault := true
+	if d.getSubNameFormatter(videoFPath) == subcommon.Normal {
+		bSetDefault = false
+	}
+	savedSub, err := d.SaveSubHelper.WriteSubFile2VideoPath(jobID, videoFPath, *subFileInfo, "", bSetDefault, false)
+	if err != nil {
+		return err
+	}
+	d.log.Infoln("ChooseCandidate", subFileInfo.FromWhereSite, filepath.Base(candidate.subFPath), "->", videoFPath)
+	if savedSub != nil {
+		d.saveVideoSubScore(videoFPath, savedSub.SubFPath, subFileInfo)
+		d.addSavedSub2Share(videoFPath, savedSub.SubFPath, subFileInfo)
+		post_save_hook.RunAsync(d.log, post_save_hook.NewPayload4Video(jobID, videoFPath, []save_sub_helper.SavedSub{*savedSub}))
+	}
+
+	return nil
+}
+
+/*
+	searchCandidateSubFiles 从所有的字幕源下载这个视频的字幕，返回复制到候选字幕缓存目录的字幕列表
+	以及每个字幕对应的发布名称，Key: 缓存的字幕路径
+*/
+func (d *Downloader) searchCandidateSubFiles(videoFPath string, videoType common.VideoType) ([]string, map[string]string, error) {
+
+	var organizeSubFiles []string
+	var subInfos []supplier.SubInfo
+	var err error
+	if videoType == common.Movie {
+		organizeSubFiles, subInfos, err = d.subSupplierHub.DownloadSub4MovieEx(videoFPath, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		season, episode, err := getSeasonAndEpisode(videoFPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		seriesRootDirPath := decode.GetSeriesDirRootFPath(videoFPath)
+		if seriesRootDirPath == "" {
+			return nil, nil, fmt.Errorf("decode.GetSeriesDirRootFPath == Empty, %s", videoFPath)
+		}
+		epsMap := make(map[int][]int, 0)
+		epsMap[season] = []int{episode}
+		// 手动搜索的时候，已经有字幕了也需要搜索
+		seriesInfo, err := series_helper.ReadSeriesInfoFromDir(
+			d.fileDownloader.MediaInfoDealers, seriesRootDirPath,
+			settings.Get().AdvancedSettings.TaskQueue.ExpirationTime,
+			true,
+			false,
+			epsMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		var seriesSubFiles map[string][]string
+		seriesSubFiles, subInfos, err = d.subSupplierHub.DownloadSub4SeriesEx(seriesRootDirPath, seriesInfo, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		organizeSubFiles = seriesSubFiles[pkg.GetEpisodeKeyName(season, episode)]
+	}
+
+	// 复制到这个视频单独的缓存目录
+	cacheFolderName := getCandidateCacheFolderName(videoFPath)
+	err = pkg.ClearTmpFolderByName(cacheFolderName)
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheFolder, err := pkg.GetTmpFolderByName(cacheFolderName)
+	if err != nil {
+		return nil, nil, err
+	}
+	outSubFPaths := make([]string, 0)
+	releaseNames := make(map[string]string)
+	for _, subFPath := range organizeSubFiles {
+		desSubFPath := filepath.Join(cacheFolder, filepath.Base(subFPath))
+		err = pkg.CopyFile(subFPath, desSubFPath)
+		if err != nil {
+			d.log.Warningln("searchCandidateSubFiles.CopyFile", subFPath, err)
+			continue
+		}
+		outSubFPaths = append(outSubFPaths, desSubFPath)
+		releaseNames[desSubFPath] = matchReleaseName(filepath.Base(subFPath), subInfos)
+	}
+
+	return outSubFPaths, releaseNames, nil
+}
+
+// setCandidateSearchResult 对搜索到的字幕进行评分，记录手动搜索的结果
+func (d *Downloader) setCandidateSearchResult(videoFPath string, subFPaths []string, releaseNames map[string]string, searchErr error) {
+
+	candidateInfos := make([]backend.CandidateInfo, 0)
+	candidates := make(map[string]candidateFile)
+	if searchErr == nil && len(subFPaths) > 0 {
+		subInfos := d.mk.ScoreSubFiles(subFPaths, d.newScoreContext(videoFPath))
+		for _, subInfo := range subInfos {
+			candidateID := fmt.Sprintf("%x", sha256.Sum256([]byte(subInfo.FileFullPath)))[:12]
+			candidateInfos = append(candidateInfos, backend.CandidateInfo{
+				ID:           candidateID,
+				SupplierName: subInfo.FromWhereSite,
+				FileName:     filepath.Base(subInfo.FileFullPath),
+				ReleaseName:  releaseNames[subInfo.FileFullPath],
+				Ext:          subInfo.Ext,
+				Language:     language.Lang2ChineseString(subInfo.Lang),
+				Score:        subInfo.GetScore(),
+				IsForced:     subInfo.IsForced,
+			})
+			candidates[candidateID] = candidateFile{
+				subFPath:       subInfo.FileFullPath,
+				isForced:       subInfo.IsForced,
+				scoreBreakdown: subInfo.ScoreBreakdown,
+			}
+		}
+	}
+
+	d.candidateLocker.Lock()
+	defer d.candidateLocker.Unlock()
+	nowSearch, found := d.candidateSearches[videoFPath]
+	if found == false {
+		return
+	}
+	nowSearch.reply.EndTime = time.Now()
+	nowSearch.reply.Candidates = candidateInfos
+	nowSearch.candidates = candidates
+	if searchErr != nil {
+		nowSearch.reply.Status = backend.CandidateSearchFailed
+		nowSearch.reply.ErrorInfo = searchErr.Error()
+	} else {
+		nowSearch.reply.Status = backend.CandidateSearchDone
+	}
+}
+
+// getSeasonAndEpisode 这一集的季、集，优先从 nfo 文件获取，没有 nfo 的时候从文件名推断
+func getSeasonAndEpisode(videoFPath string) (int, int, error) {
+
+	epsVideoNfoInfo, nfoErr := decode.GetVideoNfoInfo4OneSeriesEpisode(videoFPath)
+	if nfoErr == nil {
+		return epsVideoNfoInfo.Season, epsVideoNfoInfo.Episode, nil
+	}
+	found, season, episode, err := decode.GetSeasonAndEpisodeFromSubFileName(filepath.Base(videoFPath))
+	if err != nil || found == false {
+		return 0, 0, fmt.Errorf("can not get season and episode from nfo or file name, %s, %v", videoFPath, nfoErr)
+	}
+	return season, episode, nil
+}
+
+// getCandidateCacheFolderName 这个视频的候选字幕缓存目录
+func getCandidateCacheFolderName(videoFPath string) string {
+	return filepath.Join(candidateTmpFolderName, fmt.Sprintf("%x", sha256.Sum256([]byte(videoFPath)))[:16])
+}
+
+// matchReleaseName 整理后的字幕文件名以 [FromWhere]_TopN_ 开头，以此找到下载时的字幕信息
+func matchReleaseName(organizedFileName string, subInfos []supplier.SubInfo) string {
+
+	for _, subInfo := range subInfos {
+		prefix := "[" + subInfo.FromWhere + "]_" + strconv.FormatInt(subInfo.TopN, 10) + "_"
+		if strings.HasPrefix(organizedFileName, prefix) == false {
+			continue
+		}
+		if subInfo.ReleaseName != "" {
+			return subInfo.ReleaseName
+		}
+		return strings.TrimSuffix(subInfo.Name, filepath.Ext(subInfo.Name))
+	}
+	return ""
+}
+
+const candidateTmpFolderName = "candidates"
+
+var (
+	ErrNoSubSupplier          = errors.New("no sub supplier available, wait for SupplierCheck")
+	ErrCandidateSearchRunning = errors.New("candidate search is already running")
+	ErrCandidateNotFound      = errors.New("candidate not found")
+)
+
+// candidateKeepTime 手动搜索、选择候选字幕的任务结束后，结果保留的时间
+const candidateKeepTime = time.Hour
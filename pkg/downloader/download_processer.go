@@ -12,8 +12,12 @@ import (
 	taskQueue2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/series_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/post_save_hook"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
 	"golang.org/x/net/context"
 )
@@ -40,13 +44,15 @@ func (d *Downloader) movieDlFunc(ctx context.Context, job taskQueue2.OneJob, dow
 		return nil
 	}
 
-	err = d.oneVideoSelectBestSub(job.VideoFPath, organizeSubFiles)
+	savedSubs, err := d.oneVideoSelectBestSub(job.Id, job.VideoFPath, organizeSubFiles)
 	if err != nil {
 		d.downloadQueue.AutoDetectUpdateJobStatus(job, err)
 		return err
 	}
 
 	d.downloadQueue.AutoDetectUpdateJobStatus(job, nil)
+	// 字幕保存后的回调
+	d.runPostSaveHooks(job, nil, map[string][]save_sub_helper.SavedSub{job.VideoFPath: savedSubs})
 
 	// TODO 刷新字幕，这里是 Emby 的，如果是其他的，需要再对接对应的媒体服务器
 	if settings.Get().EmbySettings.Enable == true && d.embyHelper != nil && job.MediaServerInsideVideoID != "" {
@@ -81,6 +87,10 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 	// 设置只有一集需要下载
 	epsMap := make(map[int][]int, 0)
 	epsMap[job.Season] = []int{job.Episode}
+	// 多集合并的视频，需要下载每一集的字幕
+	for episode := job.Episode + 1; episode <= job.EpisodeEnd; episode++ {
+		epsMap[job.Season] = append(epsMap[job.Season], episode)
+	}
 	// 这里拿到了这一部连续剧的所有的剧集信息，以及所有下载到的字幕信息
 	seriesInfo, err := series_helper.ReadSeriesInfoFromDir(
 		d.fileDownloader.MediaInfoDealers, job.SeriesRootDirPath,
@@ -113,12 +123,30 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 
 	var errSave2Local error
 	save2LocalSubCount := 0
+	// 本次任务写入的字幕，Key 是视频的全路径，多集合并、整季字幕包可能会写入多个视频
+	savedSubs := make(map[string][]save_sub_helper.SavedSub)
 	// 只针对需要下载字幕的视频进行字幕的选择保存
 	subVideoCount := 0
+	// 多集合并的视频，NeedDlEpsKeyList 中每一集都有一份，但是只需要处理一次，Key 是视频的全路径
+	multiEpsInfos := make(map[string]series.EpisodeInfo)
+	for _, episodeInfo := range seriesInfo.EpList {
+		if episodeInfo.IsMultiEpisode() == true {
+			multiEpsInfos[episodeInfo.FileFullPath] = episodeInfo
+		}
+	}
+	multiEpsDone := make(map[string]bool)
 	for epsKey, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
 
+		multiEpsInfo, isMultiEps := multiEpsInfos[episodeInfo.FileFullPath]
+		if isMultiEps == true {
+			if multiEpsDone[episodeInfo.FileFullPath] == true {
+				continue
+			}
+			multiEpsDone[episodeInfo.FileFullPath] = true
+		}
+
 		// 创建一个 chan 用于任务的中断和超时
-		done := make(chan interface{}, 1)
+		done := make(chan selectBestSubResult, 1)
 		// 接收内部任务的 panic
 		panicChan := make(chan interface{}, 1)
 
@@ -132,14 +160,21 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 				close(panicChan)
 			}()
 			// 匹配对应的 Eps 去处理
-			done <- d.oneVideoSelectBestSub(episodeInfo.FileFullPath, organizeSubFiles[epsKey])
+			if isMultiEps == true {
+				subs, err := d.multiEpisodeSelectBestSub(job.Id, multiEpsInfo, organizeSubFiles)
+				done <- selectBestSubResult{videoFPath: episodeInfo.FileFullPath, savedSubs: subs, err: err}
+				return
+			}
+			subs, err := d.oneVideoSelectBestSub(job.Id, episodeInfo.FileFullPath, organizeSubFiles[epsKey])
+			done <- selectBestSubResult{videoFPath: episodeInfo.FileFullPath, savedSubs: subs, err: err}
 		}()
 
 		select {
-		case errInterface := <-done:
-			if errInterface != nil {
-				errSave2Local = errInterface.(error)
-				d.log.Errorln(errInterface.(error))
+		case result := <-done:
+			savedSubs[result.videoFPath] = append(savedSubs[result.videoFPath], result.savedSubs...)
+			if result.err != nil {
+				errSave2Local = result.err
+				d.log.Errorln(result.err)
 			} else {
 				save2LocalSubCount++
 			}
@@ -170,7 +205,7 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 		}
 
 		// 创建一个 chan 用于任务的中断和超时
-		done := make(chan interface{}, 1)
+		done := make(chan selectBestSubResult, 1)
 		// 接收内部任务的 panic
 		panicChan := make(chan interface{}, 1)
 		go func() {
@@ -181,21 +216,36 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 				close(done)
 				close(panicChan)
 			}()
+			// 季度的字幕包中没有这一集（多集合并的视频，任意一集没有）的字幕，跳过，继续处理下一集
+			for _, episode := range episodeInfo.GetEpisodes() {
+				seasonEpsKey := pkg.GetEpisodeKeyName(episodeInfo.Season, episode)
+				if len(fullSeasonSubDict[seasonEpsKey]) < 1 {
+					d.log.Infoln("seriesDlFunc.saveFullSeasonSub, no sub found, Skip", seasonEpsKey)
+					done <- selectBestSubResult{videoFPath: episodeInfo.FileFullPath, err: errSkipFullSeasonSub}
+					return
+				}
+			}
 			// 匹配对应的 Eps 去处理
-			seasonEpsKey := pkg.GetEpisodeKeyName(episodeInfo.Season, episodeInfo.Episode)
-			if fullSeasonSubDict[seasonEpsKey] == nil || len(fullSeasonSubDict[seasonEpsKey]) < 1 {
-				d.log.Infoln("seriesDlFunc.saveFullSeasonSub, no sub found, Skip", seasonEpsKey)
-				done <- nil
+			if episodeInfo.IsMultiEpisode() == true {
+				subs, err := d.multiEpisodeSelectBestSub(job.Id, episodeInfo, fullSeasonSubDict)
+				done <- selectBestSubResult{videoFPath: episodeInfo.FileFullPath, savedSubs: subs, err: err}
+				return
 			}
+			seasonEpsKey := pkg.GetEpisodeKeyName(episodeInfo.Season, episodeInfo.Episode)
 
-			done <- d.oneVideoSelectBestSub(episodeInfo.FileFullPath, fullSeasonSubDict[seasonEpsKey])
+			subs, err := d.oneVideoSelectBestSub(job.Id, episodeInfo.FileFullPath, fullSeasonSubDict[seasonEpsKey])
+			done <- selectBestSubResult{videoFPath: episodeInfo.FileFullPath, savedSubs: subs, err: err}
 		}()
 
 		select {
-		case errInterface := <-done:
-			if errInterface != nil {
-				errSave2Local = errInterface.(error)
-				d.log.Errorln(errInterface.(error))
+		case result := <-done:
+			if result.err == errSkipFullSeasonSub {
+				break
+			}
+			savedSubs[result.videoFPath] = append(savedSubs[result.videoFPath], result.savedSubs...)
+			if result.err != nil {
+				errSave2Local = result.err
+				d.log.Errorln(result.err)
 			} else {
 				save2LocalSubCount++
 			}
@@ -228,6 +278,8 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 	}
 	// 哪怕有一个写入到本地成功了，也无需对本次任务报错
 	d.downloadQueue.AutoDetectUpdateJobStatus(job, nil)
+	// 字幕保存后的回调
+	d.runPostSaveHooks(job, seriesInfo, savedSubs)
 	// TODO 刷新字幕，这里是 Emby 的，如果是其他的，需要再对接对应的媒体服务器
 	if settings.Get().EmbySettings.Enable == true && d.embyHelper != nil {
 
@@ -245,3 +297,38 @@ func (d *Downloader) seriesDlFunc(ctx context.Context, job taskQueue2.OneJob, do
 
 	return nil
 }
+
+// runPostSaveHooks 本次任务写入字幕的每一个视频，执行一次字幕保存后的回调，连续剧可能因为多集合并、整季字幕包写入了多个视频
+func (d *Downloader) runPostSaveHooks(job taskQueue2.OneJob, seriesInfo *series.SeriesInfo, savedSubs map[string][]save_sub_helper.SavedSub) {
+
+	for videoFPath, subs := range savedSubs {
+
+		if len(subs) < 1 {
+			continue
+		}
+		var payload *post_save_hook.Payload
+		if seriesInfo == nil {
+			payload = post_save_hook.NewPayload(job.Id, common.Movie.String(), videoFPath, "", 0, 0, subs)
+		} else {
+			season, episode := job.Season, job.Episode
+			for _, episodeInfo := range seriesInfo.EpList {
+				if episodeInfo.FileFullPath == videoFPath {
+					season, episode = episodeInfo.Season, episodeInfo.Episode
+					break
+				}
+			}
+			payload = post_save_hook.NewPayload(job.Id, common.Series.String(), videoFPath, job.SeriesRootDirPath, season, episode, subs)
+		}
+		post_save_hook.RunAsync(d.log, payload)
+	}
+}
+
+// selectBestSubResult 一个视频选择并写入字幕的结果
+type selectBestSubResult struct {
+	videoFPath string
+	savedSubs  []save_sub_helper.SavedSub
+	err        error
+}
+
+// errSkipFullSeasonSub 季度的字幕包中没有这一集的字幕，不算是错误
+var errSkipFullSeasonSub = errors.New("full season sub not found, skip")
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ffmpeg_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/preview_queue"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/manual_upload_sub_2_local"
@@ -12,6 +13,7 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_logic"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 
@@ -22,12 +24,13 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
 	markSystem "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/mark_system"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/pre_download_process"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_scorer"
 	subSupplier "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 	common2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
-	subCommon "github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -42,7 +45,6 @@ type Downloader struct {
 	subSupplierHub           *subSupplier.SubSupplierHub                      // 字幕提供源的集合，这个需要定时进行扫描，这些字幕源是否有效，以及下载验证码信息
 	mk                       *markSystem.MarkingSystem                        // MarkingSystem，字幕的评价系统
 	subFormatter             ifaces.ISubFormatter                             // 字幕格式化命名的实现
-	subNameFormatter         subCommon.FormatterName                          // 从 inSubFormatter 推断出来
 	subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx     // 字幕时间轴校正
 	downloaderLock           sync.Mutex                                       // 取消执行 task control 的 Lock
 	downloadQueue            *task_queue.TaskQueue                            // 需要下载的视频的队列
@@ -51,11 +53,19 @@ type Downloader struct {
 	SaveSubHelper            *save_sub_helper.SaveSubHelper                   // 保存字幕的逻辑
 	ManualUploadSub2Local    *manual_upload_sub_2_local.ManualUploadSub2Local // 手动上传字幕到本地
 	PreviewQueue             *preview_queue.PreviewQueue                      // 预览队列
+	ffmpegHelper             *ffmpeg_helper.FFMPEGHelper                      // 获取视频的时长等信息
+	ffmpegReady              bool                                             // 是否检测到了 ffmpeg 和 ffprobe，没有的时候不导出音频做时间轴匹配的评分
 
 	cacheLocker   sync.Mutex
 	movieInfoMap  map[string]MovieInfo  // 给 Web 界面使用的，Key: VideoFPath
 	seasonInfoMap map[string]SeasonInfo // 给 Web 界面使用的,Key: RootDirPath
 
+	candidateLocker   sync.Mutex
+	candidateSearches map[string]*candidateSearch              // 手动搜索字幕的结果，Key: VideoFPath
+	candidateChooses  map[string]*backend.ReplyCandidateChoose // 选择候选字幕的任务，Key: JobID
+
+	candidateChooseLocker sync.Mutex // 选择候选字幕的任务依次执行
+
 	needSkipCloudTask bool // 是否跳过云端任务，比如当前的 App 版本低于服务器的要求（过低可能爬虫已经失效，意义不大）
 }
 
@@ -69,24 +79,40 @@ func NewDownloader(inSubFormatter ifaces.ISubFormatter, fileDownloader *file_dow
 	// 参入设置信息
 	// 检测是否某些参数超出范围
 	settings.Get().Check()
-	// 这里就不单独弄一个 settings.SubNameFormatter 字段来传递值了，因为 inSubFormatter 就已经知道是什么 formatter 了
-	downloader.subNameFormatter = subCommon.FormatterName(downloader.subFormatter.GetFormatterFormatterName())
 
 	var sitesSequence = make([]string, 0)
 	// TODO 这里写固定了抉择字幕的顺序
+	// 局域网内其他实例的字幕缓存，都是已经确认过的字幕，优先级最高
+	sitesSequence = append(sitesSequence, common2.SubSitePeerLibrary)
 	sitesSequence = append(sitesSequence, common2.SubSiteSubtitleBest)
 	sitesSequence = append(sitesSequence, common2.SubSiteAssrt)
 	sitesSequence = append(sitesSequence, common2.SubSiteA4K)
 	sitesSequence = append(sitesSequence, common2.SubSiteShooter)
 	sitesSequence = append(sitesSequence, common2.SubSiteXunLei)
-	downloader.mk = markSystem.NewMarkingSystem(downloader.log, sitesSequence, settings.Get().AdvancedSettings.SubTypePriority)
+	// OpenSubtitles 主要是非中文的字幕
+	sitesSequence = append(sitesSequence, common2.SubSiteOpenSubtitles)
+	// 由设置描述的字幕源，按设置中的顺序
+	for _, customSettings := range settings.Get().SubtitleSources.CustomSuppliers {
+		sitesSequence = append(sitesSequence, common2.SubSiteCustomPrefix+customSettings.Name)
+	}
+	sitesSequence = append(sitesSequence, common2.SubSiteLocalFolder)
 
 	// 初始化，字幕校正的实例
 	downloader.subTimelineFixerHelperEx = sub_timeline_fixer.NewSubTimelineFixerHelperEx(downloader.log, *settings.Get().TimelineFixerSettings)
 
-	if settings.Get().AdvancedSettings.FixTimeLine == true {
-		downloader.subTimelineFixerHelperEx.Check()
+	// 字幕的评分系统，时间轴匹配的评分需要 ffmpeg，比较耗时，权重为 0 的时候不初始化
+	scoreSettings := settings.Get().AdvancedSettings.SubScoreSettings
+	// 时间轴校正以及时间轴匹配的评分都需要 ffmpeg，只检测一次
+	if settings.Get().AdvancedSettings.FixTimeLine == true || scan_rules.AnyRuleFixTimeLine() == true ||
+		scoreSettings.TimelineMatchWeight > 0 {
+		downloader.ffmpegReady = downloader.subTimelineFixerHelperEx.Check()
+	}
+	var scoreTimelineFixer *sub_timeline_fixer.SubTimelineFixerHelperEx
+	if scoreSettings.TimelineMatchWeight > 0 && downloader.ffmpegReady == true {
+		scoreTimelineFixer = downloader.subTimelineFixerHelperEx
 	}
+	downloader.mk = markSystem.NewMarkingSystem(downloader.log, sitesSequence, settings.Get().AdvancedSettings.SubTypePriority,
+		sub_scorer.NewDefaultScorers(scoreTimelineFixer), scoreSettings)
 	// 任务队列
 	downloader.downloadQueue = downloadQueue
 	// 单个任务的超时设置
@@ -105,9 +131,12 @@ func NewDownloader(inSubFormatter ifaces.ISubFormatter, fileDownloader *file_dow
 
 	downloader.ManualUploadSub2Local = manual_upload_sub_2_local.NewManualUploadSub2Local(downloader.log, downloader.SaveSubHelper, downloader.ScanLogic)
 	downloader.PreviewQueue = preview_queue.NewPreviewQueue(downloader.log)
+	downloader.ffmpegHelper = ffmpeg_helper.NewFFMPEGHelper(downloader.log)
 
 	downloader.movieInfoMap = make(map[string]MovieInfo)
 	downloader.seasonInfoMap = make(map[string]SeasonInfo)
+	downloader.candidateSearches = make(map[string]*candidateSearch)
+	downloader.candidateChooses = make(map[string]*backend.ReplyCandidateChoose)
 
 	err := downloader.loadVideoListCache()
 	if err != nil {
@@ -1,31 +1,45 @@
 package downloader
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/mark_system"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/vad"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
 
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
 	subcommon "github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_post_process"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_share_center"
 )
 
-// oneVideoSelectBestSub 一个视频，选择最佳的一个字幕（也可以保存所有网站第一个最佳字幕）
-func (d *Downloader) oneVideoSelectBestSub(oneVideoFullPath string, organizeSubFiles []string) error {
+// oneVideoSelectBestSub 一个视频，选择最佳的一个字幕（也可以保存所有网站第一个最佳字幕），返回本次写入的字幕
+func (d *Downloader) oneVideoSelectBestSub(jobID string, oneVideoFullPath string, organizeSubFiles []string) ([]save_sub_helper.SavedSub, error) {
 
 	// 如果没有则直接跳过
 	if organizeSubFiles == nil || len(organizeSubFiles) < 1 {
-		return common.AllSiteDownloadSubNotFound
+		return nil, common.AllSiteDownloadSubNotFound
 	}
 
 	var err error
+	var savedSub *save_sub_helper.SavedSub
+	savedSubs := make([]save_sub_helper.SavedSub, 0)
 	// 得到目标视频文件的文件名
 	videoFileName := filepath.Base(oneVideoFullPath)
 	// -------------------------------------------------
@@ -39,27 +53,42 @@ func (d *Downloader) oneVideoSelectBestSub(oneVideoFullPath string, organizeSubF
 		}
 	}
 	// -------------------------------------------------
-	/*
-		这里需要额外考虑一点，有可能当前目录已经有一个 .Default .Forced 标记的字幕了
-		那么下载字幕丢进来的时候就需要提前把这个字幕找出来，去除整个 .Default .Forced  标记
-		然后进行正常的下载，存储和替换字幕，最后将本次操作的第一次标记为 .Default
-	*/
-	// 不管是不是保存多个字幕，都要先扫描本地的字幕，进行 .Default .Forced 去除
-	// 这个视频的所有字幕，去除 .default .Forced 标记
-	err = sub_helper.SearchVideoMatchSubFileAndRemoveExtMark(d.log, oneVideoFullPath)
+	// 所有的字幕只评分一次，后续的选择都基于这个结果
+	scoredSubFiles := d.mk.ScoreAll(organizeSubFiles, d.newScoreContext(oneVideoFullPath))
+	// 非中文的字幕不参与评分，需要在写入字幕之前保存，写入后的处理步骤中双语合并会使用
+	err = sub_post_process.SaveReferenceSub(oneVideoFullPath, scoredSubFiles.BestReference())
 	if err != nil {
-		// 找个错误可以忍
-		d.log.Errorln("SearchVideoMatchSubFileAndRemoveExtMark,", oneVideoFullPath, err)
+		// 这个错误可以忍
+		d.log.Warningln("SaveReferenceSub,", oneVideoFullPath, err)
+	}
+	// forced 字幕不会被选为主字幕，但是可以额外的保存
+	if settings.Get().AdvancedSettings.SaveForcedSub == true {
+		forcedSubFile := scoredSubFiles.BestForced()
+		if forcedSubFile != nil {
+			savedSub, err = d.SaveSubHelper.WriteForcedSubFile2VideoPath(jobID, oneVideoFullPath, *forcedSubFile, forcedSubFile.FromWhereSite)
+			if err != nil {
+				// 这个错误可以忍，不影响主字幕的保存
+				d.log.Errorln("WriteForcedSubFile2VideoPath,", oneVideoFullPath, err)
+			} else if savedSub != nil {
+				savedSubs = append(savedSubs, *savedSub)
+			}
+		}
 	}
 	if settings.Get().AdvancedSettings.SaveMultiSub == false {
-		// 选择最优的一个字幕
+		// 选择评分最高的一个字幕
 		var finalSubFile *subparser.FileInfo
-		finalSubFile = d.mk.SelectOneSubFile(organizeSubFiles)
+		finalSubFile = scoredSubFiles.BestOne()
 		if finalSubFile == nil {
 			outString := fmt.Sprintln("Found", len(organizeSubFiles), " subtitles but not one fit:", oneVideoFullPath)
 			d.log.Warnln(outString)
-			return errors.New(outString)
+			return savedSubs, errors.New(outString)
 		}
+		// 已有的字幕评分足够高，就不进行替换了，需要在去除 .default 标记之前判断
+		if d.isNeedUpgradeSub(oneVideoFullPath, finalSubFile) == false {
+			d.storeCandidateScores(oneVideoFullPath, scoredSubFiles, "")
+			return savedSubs, nil
+		}
+		d.searchVideoMatchSubFileAndRemoveExtMark(oneVideoFullPath)
 		/*
 			这里还有一个梗，Emby、jellyfin 支持 default 和 forced 扩展字段
 			但是，plex 只支持 forced
@@ -67,22 +96,37 @@ func (d *Downloader) oneVideoSelectBestSub(oneVideoFullPath string, organizeSubF
 		*/
 		// 判断配置文件中的字幕命名格式化的选择
 		bSetDefault := true
-		if d.subNameFormatter == subcommon.Normal {
+		if d.getSubNameFormatter(oneVideoFullPath) == subcommon.Normal {
 			bSetDefault = false
 		}
 		// 找到了，写入文件
-		err = d.SaveSubHelper.WriteSubFile2VideoPath(oneVideoFullPath, *finalSubFile, "", bSetDefault, false)
+		savedSub, err = d.SaveSubHelper.WriteSubFile2VideoPath(jobID, oneVideoFullPath, *finalSubFile, "", bSetDefault, false)
 		if err != nil {
-			return errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+			return savedSubs, errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+		}
+		d.storeCandidateScores(oneVideoFullPath, scoredSubFiles, finalSubFile.FileFullPath)
+		if savedSub != nil {
+			savedSubs = append(savedSubs, *savedSub)
+			d.saveVideoSubScore(oneVideoFullPath, savedSub.SubFPath, finalSubFile)
+			d.addSavedSub2Share(oneVideoFullPath, savedSub.SubFPath, finalSubFile)
 		}
 	} else {
 		// 每个网站 Top1 的字幕
-		siteNames, finalSubFiles := d.mk.SelectEachSiteTop1SubFile(organizeSubFiles)
-		if len(siteNames) < 0 {
+		siteNames, finalSubFiles := scoredSubFiles.EachSiteTop1()
+		if len(siteNames) < 1 {
 			outString := fmt.Sprintln("SelectEachSiteTop1SubFile found none sub file")
 			d.log.Warnln(outString)
-			return errors.New(outString)
+			return savedSubs, errors.New(outString)
+		}
+		// 多个字幕的时候，以评分最高的那一个进行升级的判断，以及记录评分，已经是按评分从高到低排序的了
+		const bestIndex = 0
+		if d.isNeedUpgradeSub(oneVideoFullPath, &finalSubFiles[bestIndex]) == false {
+			d.storeCandidateScores(oneVideoFullPath, scoredSubFiles, "")
+			return savedSubs, nil
 		}
+		d.searchVideoMatchSubFileAndRemoveExtMark(oneVideoFullPath)
+		// 记录评分使用的字幕路径，以处理步骤之后的为准，格式转换可能改变了扩展名
+		bestSubFPath := ""
 		// 多网站 Top 1 字幕保存的时候，第一个设置为 Default 即可
 		/*
 			由于新功能支持了字幕命名格式的选择，那么如果触发了多个字幕保存的逻辑，如果不调整
@@ -90,15 +134,21 @@ func (d *Downloader) oneVideoSelectBestSub(oneVideoFullPath string, organizeSubF
 			所以如果开启了 Normal SubNameFormatter 的功能，则要反序写入文件
 			如果是 Emby 的字幕命名格式则无需考虑此问题，因为每个网站只会有一个字幕，且字幕命名格式决定了不会重复写入覆盖
 		*/
-		if d.subNameFormatter == subcommon.Emby {
+		if d.getSubNameFormatter(oneVideoFullPath) == subcommon.Emby {
 			for i, file := range finalSubFiles {
 				setDefault := false
 				if i == 0 {
 					setDefault = true
 				}
-				err = d.SaveSubHelper.WriteSubFile2VideoPath(oneVideoFullPath, file, siteNames[i], setDefault, false)
+				savedSub, err = d.SaveSubHelper.WriteSubFile2VideoPath(jobID, oneVideoFullPath, file, siteNames[i], setDefault, false)
 				if err != nil {
-					return errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+					return savedSubs, errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+				}
+				if savedSub != nil {
+					savedSubs = append(savedSubs, *savedSub)
+					if i == bestIndex {
+						bestSubFPath = savedSub.SubFPath
+					}
 				}
 			}
 		} else {
@@ -110,16 +160,257 @@ func (d *Downloader) oneVideoSelectBestSub(oneVideoFullPath string, organizeSubF
 				那么就比较麻烦，干脆，normal 的命名格式化实例，就不设置 default 了，forced 不想用，因为可能会跟你手动选择的字幕冲突（下次观看的时候，理论上也可能不会）
 			*/
 			for i := len(finalSubFiles) - 1; i > -1; i-- {
-				err = d.SaveSubHelper.WriteSubFile2VideoPath(oneVideoFullPath, finalSubFiles[i], siteNames[i], false, false)
+				savedSub, err = d.SaveSubHelper.WriteSubFile2VideoPath(jobID, oneVideoFullPath, finalSubFiles[i], siteNames[i], false, false)
 				if err != nil {
-					return errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+					return savedSubs, errors.New(fmt.Sprintf("SaveMultiSub: %v, writeSubFile2VideoPath, Error: %v ", settings.Get().AdvancedSettings.SaveMultiSub, err))
+				}
+				if savedSub != nil {
+					savedSubs = append(savedSubs, *savedSub)
+					if i == bestIndex {
+						bestSubFPath = savedSub.SubFPath
+					}
 				}
 			}
 		}
+		d.storeCandidateScores(oneVideoFullPath, scoredSubFiles, finalSubFiles[bestIndex].FileFullPath)
+		d.storeVideoSubScore(oneVideoFullPath, bestSubFPath, &finalSubFiles[bestIndex])
+		if bestSubFPath != "" {
+			d.addSavedSub2Share(oneVideoFullPath, bestSubFPath, &finalSubFiles[bestIndex])
+		}
+		notify_center.Publish(notify.NewEvent(notify.SubDownloaded, filepath.Base(oneVideoFullPath), map[string]string{
+			"video_f_path": oneVideoFullPath,
+			"suppliers":    strings.Join(siteNames, ","),
+		}))
 	}
 	// -------------------------------------------------
 
-	return nil
+	return savedSubs, nil
+}
+
+/*
+	multiEpisodeSelectBestSub 多集合并的视频（S01E01E02），选择字幕
+	1. 优先使用覆盖了所有集数的“合并”字幕，这样就跟普通的视频一样处理
+	2. 没有的话，每一集各选出一个字幕，按前面几集的时长之和偏移后，拼接为一个字幕，保留 ASS 的样式
+	每一集的时长优先使用 ffprobe 读取的章节信息，没有合适的章节才按视频的时长均分
+*/
+func (d *Downloader) multiEpisodeSelectBestSub(jobID string, epsInfo series.EpisodeInfo, organizeSubFiles map[string][]string) ([]save_sub_helper.SavedSub, error) {
+
+	oneVideoFullPath := epsInfo.FileFullPath
+	episodes := epsInfo.GetEpisodes()
+	allSubFiles := make([]string, 0)
+	for _, episode := range episodes {
+		allSubFiles = append(allSubFiles, organizeSubFiles[pkg.GetEpisodeKeyName(epsInfo.Season, episode)]...)
+	}
+	if len(allSubFiles) < 1 {
+		return nil, common.AllSiteDownloadSubNotFound
+	}
+	videoDuration := d.ffmpegHelper.GetVideoDuration(oneVideoFullPath)
+	combinedSubFiles := d.mk.SelectCombinedSubFiles(allSubFiles, d.mk.NewScoreContext(oneVideoFullPath, videoDuration, nil),
+		epsInfo.Season, epsInfo.Episode, epsInfo.EpisodeEnd)
+	if len(combinedSubFiles) > 0 {
+		d.log.Infoln("multiEpisodeSelectBestSub, found", len(combinedSubFiles), "combined subs,", oneVideoFullPath)
+		return d.oneVideoSelectBestSub(jobID, oneVideoFullPath, combinedSubFiles)
+	}
+	// 需要拼接每一集的字幕
+	chapterStartTimes := d.ffmpegHelper.GetVideoChapterStartTimes(oneVideoFullPath)
+	if len(chapterStartTimes) < len(episodes) {
+		d.log.Warningln("multiEpisodeSelectBestSub, not enough chapters, split the video duration evenly,", oneVideoFullPath)
+	}
+	partDurations := sub_helper.GetMultiEpisodePartDurations(videoDuration, chapterStartTimes, len(episodes))
+	if len(partDurations) != len(episodes) {
+		return nil, errors.New(fmt.Sprintf("multiEpisodeSelectBestSub, can not get video duration, %v", oneVideoFullPath))
+	}
+	partSubFiles := make([]subparser.FileInfo, 0)
+	var lowestScoreSubFile *subparser.FileInfo
+	for i, episode := range episodes {
+		epsKey := pkg.GetEpisodeKeyName(epsInfo.Season, episode)
+		partSubFile := d.mk.SelectOneSubFile(organizeSubFiles[epsKey], d.mk.NewScoreContext(oneVideoFullPath, partDurations[i], nil))
+		if partSubFile == nil {
+			outString := fmt.Sprintln("multiEpisodeSelectBestSub, no sub fit", epsKey, oneVideoFullPath)
+			d.log.Warnln(outString)
+			return nil, errors.New(outString)
+		}
+		if lowestScoreSubFile == nil || partSubFile.GetScore() < lowestScoreSubFile.GetScore() {
+			lowestScoreSubFile = partSubFile
+		}
+		partSubFiles = append(partSubFiles, *partSubFile)
+	}
+	finalSubFile, err := sub_helper.MergeMultiEpisodeSubtitles(partSubFiles, partDurations)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("multiEpisodeSelectBestSub.MergeMultiEpisodeSubtitles, %v, Error: %v", oneVideoFullPath, err))
+	}
+	// 拼接的字幕，评分以最低的那一集为准
+	finalSubFile.ScoreBreakdown = lowestScoreSubFile.ScoreBreakdown
+	d.log.Infoln("multiEpisodeSelectBestSub, merge", len(partSubFiles), "subs, durations:", partDurations, oneVideoFullPath)
+	if d.isNeedUpgradeSub(oneVideoFullPath, finalSubFile) == false {
+		return nil, nil
+	}
+	d.searchVideoMatchSubFileAndRemoveExtMark(oneVideoFullPath)
+	bSetDefault := true
+	if d.getSubNameFormatter(oneVideoFullPath) == subcommon.Normal {
+		bSetDefault = false
+	}
+	savedSub, err := d.SaveSubHelper.WriteSubFile2VideoPath(jobID, oneVideoFullPath, *finalSubFile, "", bSetDefault, false)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("multiEpisodeSelectBestSub, writeSubFile2VideoPath, Error: %v ", err))
+	}
+	if savedSub == nil {
+		return nil, nil
+	}
+	d.saveVideoSubScore(oneVideoFullPath, savedSub.SubFPath, finalSubFile)
+	d.addSavedSub2Share(oneVideoFullPath, savedSub.SubFPath, finalSubFile)
+
+	return []save_sub_helper.SavedSub{*savedSub}, nil
+}
+
+/*
+	searchVideoMatchSubFileAndRemoveExtMark
+	这里需要额外考虑一点，有可能当前目录已经有一个 .Default .Forced 标记的字幕了
+	那么下载字幕丢进来的时候就需要提前把这个字幕找出来，去除整个 .Default .Forced  标记
+	然后进行正常的下载，存储和替换字幕，最后将本次操作的第一次标记为 .Default
+	这个视频的所有字幕，去除 .default 标记，.forced 标记的是真正的 forced 字幕，需要保留
+*/
+func (d *Downloader) searchVideoMatchSubFileAndRemoveExtMark(oneVideoFullPath string) {
+
+	err := sub_helper.SearchVideoMatchSubFileAndRemoveExtMark(d.log, oneVideoFullPath)
+	if err != nil {
+		// 找个错误可以忍
+		d.log.Errorln("SearchVideoMatchSubFileAndRemoveExtMark,", oneVideoFullPath, err)
+	}
+}
+
+// newScoreContext 构建这个视频的评分上下文
+func (d *Downloader) newScoreContext(oneVideoFullPath string) *sub_score.ScoreContext {
+
+	// 视频的时长，用于判断 forced 字幕以及评分，获取不到的时候是 0，会使用字幕本身的时长替代
+	videoDuration := d.ffmpegHelper.GetVideoDuration(oneVideoFullPath)
+	var audioVADInfos []vad.VADInfo
+	if settings.Get().AdvancedSettings.SubScoreSettings.TimelineMatchWeight > 0 && d.ffmpegReady == true {
+		var err error
+		audioVADInfos, err = d.subTimelineFixerHelperEx.GetAudioVADInfos(oneVideoFullPath)
+		if err != nil {
+			// 这个错误可以忍，时间轴匹配这一项不参与评分即可
+			d.log.Warnln("GetAudioVADInfos,", oneVideoFullPath, err)
+		}
+	}
+	return d.mk.NewScoreContext(oneVideoFullPath, videoDuration, audioVADInfos)
+}
+
+// getSubNameFormatter 这个视频使用的字幕命名格式，视频库的扫描规则中可能覆盖了全局的设置
+func (d *Downloader) getSubNameFormatter(oneVideoFullPath string) subcommon.FormatterName {
+	return subcommon.FormatterName(d.SaveSubHelper.GetSubFormatter(oneVideoFullPath).GetFormatterFormatterName())
+}
+
+// isNeedUpgradeSub 新选出来的字幕评分，需要比已有字幕的评分高出 UpgradeMinScoreDiff 才进行替换，已有字幕的文件不存在了也需要替换
+func (d *Downloader) isNeedUpgradeSub(oneVideoFullPath string, newSubFile *subparser.FileInfo) bool {
+
+	var videoSubScores []models.VideoSubScore
+	dao.GetDb().Where("uid = ?", models.GenerateUID4VideoSubScore(oneVideoFullPath)).Find(&videoSubScores)
+	if len(videoSubScores) < 1 {
+		d.log.Infoln("No Sub Score Record, Save New Sub, Score:", newSubFile.GetScore(), oneVideoFullPath)
+		return true
+	}
+	if pkg.IsFile(videoSubScores[0].SubFPath) == false {
+		d.log.Infoln("Sub File Not Exist, Save New Sub, Score:", newSubFile.GetScore(), videoSubScores[0].SubFPath)
+		return true
+	}
+	minScoreDiff := settings.Get().AdvancedSettings.SubScoreSettings.UpgradeMinScoreDiff
+	if newSubFile.GetScore() < videoSubScores[0].Score+minScoreDiff {
+		d.log.Infoln("Skip Upgrade Sub, Now Score:", videoSubScores[0].Score, "New Score:", newSubFile.GetScore(), "MinScoreDiff:", minScoreDiff, oneVideoFullPath)
+		return false
+	}
+	d.log.Infoln("Upgrade Sub, Now Score:", videoSubScores[0].Score, "New Score:", newSubFile.GetScore(), oneVideoFullPath)
+	return true
+}
+
+// saveVideoSubScore 保存当前视频使用的字幕的评分，用于后续的升级判断，并发送字幕下载或者升级的通知，subFPath 是处理步骤之后字幕的全路径
+func (d *Downloader) saveVideoSubScore(oneVideoFullPath, subFPath string, finalSubFile *subparser.FileInfo) {
+
+	// 之前有字幕的评分记录，且新的评分更高，就是字幕的升级（之前的字幕文件被删除了重新下载的，算作下载）
+	videoSubScores := d.storeVideoSubScore(oneVideoFullPath, subFPath, finalSubFile)
+
+	fields := map[string]string{
+		"video_f_path": oneVideoFullPath,
+		"sub_f_path":   subFPath,
+		"supplier":     finalSubFile.FromWhereSite,
+		"score":        fmt.Sprintf("%.1f", finalSubFile.GetScore()),
+	}
+	if len(videoSubScores) > 0 && finalSubFile.GetScore() > videoSubScores[0].Score {
+		fields["old_score"] = fmt.Sprintf("%.1f", videoSubScores[0].Score)
+		notify_center.Publish(notify.NewEvent(notify.SubUpgraded,
+			fmt.Sprintf("%s, %s -> %s", filepath.Base(oneVideoFullPath), fields["old_score"], fields["score"]), fields))
+	} else {
+		notify_center.Publish(notify.NewEvent(notify.SubDownloaded, filepath.Base(oneVideoFullPath), fields))
+	}
+}
+
+// storeVideoSubScore 保存字幕的评分，返回之前的评分记录
+func (d *Downloader) storeVideoSubScore(oneVideoFullPath, subFPath string, finalSubFile *subparser.FileInfo) []models.VideoSubScore {
+
+	breakdown := ""
+	if finalSubFile.ScoreBreakdown != nil {
+		breakdownBytes, err := json.Marshal(finalSubFile.ScoreBreakdown)
+		if err != nil {
+			d.log.Errorln("storeVideoSubScore.Marshal", oneVideoFullPath, err)
+		} else {
+			breakdown = string(breakdownBytes)
+		}
+	}
+	var videoSubScores []models.VideoSubScore
+	dao.GetDb().Where("uid = ?", models.GenerateUID4VideoSubScore(oneVideoFullPath)).Find(&videoSubScores)
+	dao.GetDb().Save(models.NewVideoSubScore(oneVideoFullPath, subFPath, finalSubFile.GetScore(), breakdown))
+	supplier_health.RecordWinner(d.log, finalSubFile.FromWhereSite)
+	return videoSubScores
+}
+
+// addSavedSub2Share 写入视频旁边的主字幕加入到共享的缓存中等待上传，是否共享由 sub_share_center.AddSavedSub 判断
+func (d *Downloader) addSavedSub2Share(oneVideoFullPath, subFPath string, finalSubFile *subparser.FileInfo) {
+
+	err := sub_share_center.AddSavedSub(d.log, d.fileDownloader.MediaInfoDealers, oneVideoFullPath, subFPath, finalSubFile.FromWhereSite)
+	if err != nil {
+		// 这个错误可以忍，不影响字幕的保存
+		d.log.Warningln("addSavedSub2Share", oneVideoFullPath, err)
+	}
+}
+
+// storeCandidateScores 保存这次所有候选字幕的评分明细，会替换这个视频之前的记录，winnerSubFPath 是写入的主字幕在下载缓存中的路径，没有写入则为空
+func (d *Downloader) storeCandidateScores(oneVideoFullPath string, scoredSubFiles *mark_system.ScoredSubFiles, winnerSubFPath string) {
+
+	videoUID := models.GenerateUID4VideoSubScore(oneVideoFullPath)
+	candidates := make([]models.VideoSubCandidateScore, 0)
+	for _, subFile := range scoredSubFiles.All() {
+		breakdown := ""
+		if subFile.ScoreBreakdown != nil {
+			breakdownBytes, err := json.Marshal(subFile.ScoreBreakdown)
+			if err != nil {
+				d.log.Errorln("storeCandidateScores.Marshal", subFile.FileFullPath, err)
+			} else {
+				breakdown = string(breakdownBytes)
+			}
+		}
+		candidates = append(candidates, models.VideoSubCandidateScore{
+			VideoUID:      videoUID,
+			VideoFPath:    oneVideoFullPath,
+			SubFPath:      subFile.FileFullPath,
+			FromWhereSite: subFile.FromWhereSite,
+			IsForced:      subFile.IsForced,
+			IsWinner:      winnerSubFPath != "" && subFile.FileFullPath == winnerSubFPath,
+			Score:         subFile.GetScore(),
+			Breakdown:     breakdown,
+		})
+	}
+	err := dao.GetDb().Unscoped().Where("video_uid = ?", videoUID).Delete(&models.VideoSubCandidateScore{}).Error
+	if err != nil {
+		d.log.Errorln("storeCandidateScores.Delete", oneVideoFullPath, err)
+		return
+	}
+	if len(candidates) < 1 {
+		return
+	}
+	err = dao.GetDb().Create(&candidates).Error
+	if err != nil {
+		d.log.Errorln("storeCandidateScores.Create", oneVideoFullPath, err)
+	}
 }
 
 // saveFullSeasonSub 这里就需要单独存储到连续剧每一季的文件夹的特殊文件夹中。需要跟 DeleteOneSeasonSubCacheFolder 关联起来
@@ -151,7 +442,7 @@ func (d *Downloader) saveFullSeasonSub(seriesInfo *series.SeriesInfo, organizeSu
 				continue
 			}
 			// 从字幕的文件名推断是 哪一季 的 那一集
-			_, gusSeason, gusEpisode, err := decode.GetSeasonAndEpisodeFromSubFileName(subFileName)
+			_, gusSeason, gusEpisode, err := sub_helper.GetSeasonAndEpisodeFromSubFileNameEx(subFileName, seriesInfo)
 			if err != nil {
 				return nil
 			}
@@ -109,6 +109,13 @@ func (d *Downloader) queueDownloaderLocal() {
 			oneJob.SeriesRootDirPath = seriesInfoDirPath
 		}
 	}
+	// 多集合并的视频（S01E01E02），需要补全最后一集的信息，后续需要下载每一集的字幕
+	if oneJob.VideoType == common2.Series && oneJob.EpisodeEnd <= 0 {
+		found, season, episodeStart, episodeEnd := decode.GetEpisodeRangeFromFileName(oneJob.VideoFPath)
+		if found == true && season == oneJob.Season && episodeStart == oneJob.Episode {
+			oneJob.EpisodeEnd = episodeEnd
+		}
+	}
 	// --------------------------------------------------
 	// 这个视频文件不存在了
 	{
@@ -0,0 +1,99 @@
+package ffmpeg_helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/tidwall/gjson"
+)
+
+/*
+	EmbedSubtitle2MKV 把外挂字幕作为一条字幕轨道封装进 mkv，只复制不转码
+	1. 之前由本程序封装进去的同名轨道（title 相同）会被替换掉，避免每次下载都多出一条轨道
+	2. 先输出到视频目录的临时文件，成功后再替换原视频，失败的时候原视频不受影响
+	language 使用 ISO 639-2，比如 chi、eng
+*/
+func (f *FFMPEGHelper) EmbedSubtitle2MKV(videoFPath, subFPath, language, title string, isForced bool) error {
+
+	if strings.ToLower(filepath.Ext(videoFPath)) != extMKV {
+		return fmt.Errorf("only support mkv, %s", videoFPath)
+	}
+	if pkg.IsFile(videoFPath) == false {
+		return fmt.Errorf("video file not found, %s", videoFPath)
+	}
+	if pkg.IsFile(subFPath) == false {
+		return fmt.Errorf("sub file not found, %s", subFPath)
+	}
+	subTitles, err := f.getSubtitleStreamTitles(videoFPath)
+	if err != nil {
+		return err
+	}
+
+	tmpVideoFPath := strings.TrimSuffix(videoFPath, filepath.Ext(videoFPath)) + embedTmpExt
+	args := []string{"-y", "-i", videoFPath, "-i", subFPath, "-map", "0"}
+	keepCount := 0
+	for i, subTitle := range subTitles {
+		if subTitle == title {
+			args = append(args, "-map", "-0:s:"+strconv.Itoa(i))
+			continue
+		}
+		keepCount++
+	}
+	newIndex := strconv.Itoa(keepCount)
+	args = append(args, "-map", "1:0", "-c", "copy",
+		"-metadata:s:s:"+newIndex, "language="+language,
+		"-metadata:s:s:"+newIndex, "title="+title,
+	)
+	if isForced == true {
+		args = append(args, "-disposition:s:"+newIndex, "forced")
+	}
+	// 临时文件不是视频的后缀名，避免被扫描为视频，需要指定输出的格式
+	args = append(args, "-f", "matroska", tmpVideoFPath)
+
+	execFFMPEG, err := f.execFFMPEG(args)
+	if err != nil {
+		_ = os.Remove(tmpVideoFPath)
+		return errors.New(execFFMPEG + err.Error())
+	}
+	err = os.Rename(tmpVideoFPath, videoFPath)
+	if err != nil {
+		_ = os.Remove(tmpVideoFPath)
+		return err
+	}
+	return nil
+}
+
+// getSubtitleStreamTitles 视频中所有字幕轨道的 title，按字幕轨道的顺序，没有 title 的是空
+func (f *FFMPEGHelper) getSubtitleStreamTitles(videoFPath string) ([]string, error) {
+
+	const args = "-v error -select_streams s -show_entries stream=index:stream_tags=title -print_format json -i"
+	cmdArgs := strings.Fields(args)
+	cmdArgs = append(cmdArgs, videoFPath)
+	cmd := exec.Command("ffprobe", cmdArgs...)
+	buf := bytes.NewBufferString("")
+	errBuf := bytes.NewBufferString("")
+	cmd.Stdout = buf
+	cmd.Stderr = errBuf
+	err := cmd.Run()
+	if err != nil {
+		return nil, errors.New(errBuf.String() + err.Error())
+	}
+
+	titles := make([]string, 0)
+	for _, stream := range gjson.Get(buf.String(), "streams").Array() {
+		titles = append(titles, stream.Get("tags.title").String())
+	}
+	return titles, nil
+}
+
+const (
+	extMKV      = ".mkv"
+	embedTmpExt = ".csf_embed.tmp"
+)
@@ -930,6 +930,32 @@ func (f *FFMPEGHelper) GetVideoDuration(videoFileFullPath string) float64 {
 	return duration
 }
 
+// GetVideoChapterStartTimes 获取视频所有章节的开始时间（秒），没有章节或者出错的时候返回空列表
+func (f *FFMPEGHelper) GetVideoChapterStartTimes(videoFileFullPath string) []float64 {
+
+	const args = "-v error -show_chapters -print_format json -i"
+	cmdArgs := strings.Fields(args)
+	cmdArgs = append(cmdArgs, videoFileFullPath)
+	cmd := exec.Command("ffprobe", cmdArgs...)
+	buf := bytes.NewBufferString("")
+	//指定输出位置
+	cmd.Stdout = buf
+	err := cmd.Start()
+	if err != nil {
+		return []float64{}
+	}
+	err = cmd.Wait()
+	if err != nil {
+		return []float64{}
+	}
+
+	startTimes := make([]float64, 0)
+	for _, chapter := range gjson.Get(buf.String(), "chapters").Array() {
+		startTimes = append(startTimes, chapter.Get("start_time").Float())
+	}
+	return startTimes
+}
+
 const (
 	codecTypeSub   = "subtitle"
 	codecTypeAudio = "audio"
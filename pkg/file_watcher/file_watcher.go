@@ -0,0 +1,368 @@
+package file_watcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/filter"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// VideoReadyFunc 一个新的视频复制完成了，rootDirPath 是它所在的电影、连续剧的根目录
+type VideoReadyFunc func(videoType common.VideoType, rootDirPath, videoFPath string)
+
+/*
+	FileWatcher 监控电影、连续剧的目录，新的视频文件大小稳定后（复制完成）回调 VideoReadyFunc
+	inotify 不是递归的，需要为每一个子目录添加监控，新建的子目录也需要添加
+	网络挂载的目录收不到事件，或者添加监控失败（比如超过了 max_user_watches），会退化为定时轮询这个根目录
+*/
+type FileWatcher struct {
+	log             *logrus.Logger
+	watcherSettings settings.FileWatcherSettings
+	debounce        time.Duration // 文件大小稳定多久才认为复制完成了
+	pollingInterval time.Duration // 轮询的间隔
+	watchRoots      []watchRoot
+	pollingRoots    []watchRoot
+	videoReady      VideoReadyFunc
+	watcher         *fsnotify.Watcher
+	pendingLock     sync.Mutex
+	pending         map[string]*pendingFile // 等待复制完成的视频，Key 是视频的全路径
+	polledFiles     map[string]bool         // 轮询的时候上一次看到的视频，只在轮询的协程中使用
+	stopChan        chan interface{}
+	wg              sync.WaitGroup
+}
+
+type watchRoot struct {
+	videoType common.VideoType
+	dirPath   string
+}
+
+type pendingFile struct {
+	root       watchRoot
+	size       int64     // 上一次检查的文件大小，-1 是还没检查过
+	lastChange time.Time // 上一次收到事件或者文件大小变化的时间
+}
+
+func NewFileWatcher(log *logrus.Logger, watcherSettings settings.FileWatcherSettings, movieRootDirs, seriesRootDirs []string, videoReady VideoReadyFunc) *FileWatcher {
+
+	w := FileWatcher{
+		log:             log,
+		watcherSettings: watcherSettings,
+		debounce:        time.Duration(watcherSettings.DebounceSeconds) * time.Second,
+		pollingInterval: time.Duration(watcherSettings.PollingIntervalMinutes) * time.Minute,
+		watchRoots:      make([]watchRoot, 0),
+		pollingRoots:    make([]watchRoot, 0),
+		videoReady:      videoReady,
+		pending:         make(map[string]*pendingFile),
+		polledFiles:     make(map[string]bool),
+		stopChan:        make(chan interface{}),
+	}
+	for _, dirPath := range movieRootDirs {
+		w.watchRoots = append(w.watchRoots, watchRoot{videoType: common.Movie, dirPath: dirPath})
+	}
+	for _, dirPath := range seriesRootDirs {
+		w.watchRoots = append(w.watchRoots, watchRoot{videoType: common.Series, dirPath: dirPath})
+	}
+	return &w
+}
+
+// Start 添加监控，非阻塞
+func (w *FileWatcher) Start() error {
+
+	var err error
+	w.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for _, root := range w.watchRoots {
+		if pkg.IsDir(root.dirPath) == false {
+			w.log.Warningln("FileWatcher Skip, dir not found:", root.dirPath)
+			continue
+		}
+		if w.needPolling(root.dirPath) == true {
+			w.log.Infoln("FileWatcher Polling:", root.dirPath)
+			w.pollingRoots = append(w.pollingRoots, root)
+			continue
+		}
+		err = w.addWatchRecursive(root.dirPath)
+		if err != nil {
+			// 监控的目录太多了，或者是文件系统不支持，退化为轮询
+			w.log.Warningln("FileWatcher Add Watch Error, fallback to polling:", root.dirPath, err)
+			w.removeWatchRecursive(root.dirPath)
+			w.pollingRoots = append(w.pollingRoots, root)
+			continue
+		}
+		w.log.Infoln("FileWatcher Watching:", root.dirPath)
+	}
+
+	w.wg.Add(2)
+	go w.eventLoop()
+	go w.debounceLoop()
+	if len(w.pollingRoots) > 0 {
+		w.wg.Add(1)
+		go w.pollingLoop()
+	}
+	return nil
+}
+
+// Stop 会等待内部的协程退出
+func (w *FileWatcher) Stop() {
+
+	if w.watcher == nil {
+		return
+	}
+	close(w.stopChan)
+	_ = w.watcher.Close()
+	w.wg.Wait()
+	w.watcher = nil
+}
+
+func (w *FileWatcher) needPolling(dirPath string) bool {
+
+	for _, pollingPath := range w.watcherSettings.PollingPaths {
+		if filepath.Clean(pollingPath) == filepath.Clean(dirPath) {
+			return true
+		}
+	}
+	return isNetworkFileSystem(dirPath)
+}
+
+func (w *FileWatcher) addWatchRecursive(dirPath string) error {
+
+	return filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() == false {
+			return nil
+		}
+		if path != dirPath && skipDir(d.Name()) == true {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *FileWatcher) removeWatchRecursive(dirPath string) {
+
+	_ = filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() == true {
+			_ = w.watcher.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (w *FileWatcher) eventLoop() {
+
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case event, ok := <-w.watcher.Events:
+			if ok == false {
+				return
+			}
+			w.onEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if ok == false {
+				return
+			}
+			w.log.Errorln("FileWatcher:", err)
+		}
+	}
+}
+
+func (w *FileWatcher) onEvent(event fsnotify.Event) {
+
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// 重命名之后的新路径会收到 Create 事件
+		w.pendingLock.Lock()
+		delete(w.pending, event.Name)
+		w.pendingLock.Unlock()
+		return
+	}
+	if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+		return
+	}
+	root, found := w.findRoot(event.Name)
+	if found == false {
+		return
+	}
+	if event.Op&fsnotify.Create != 0 && pkg.IsDir(event.Name) == true {
+		if skipDir(filepath.Base(event.Name)) == true {
+			return
+		}
+		// 新的目录，比如整个电影的文件夹复制或者移动进来了，需要监控，里面已经存在的视频也要处理
+		err := w.addWatchRecursive(event.Name)
+		if err != nil {
+			w.log.Errorln("FileWatcher Add Watch Error:", event.Name, err)
+		}
+		_ = filepath.WalkDir(event.Name, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() == false {
+				w.touch(root, path)
+			}
+			return nil
+		})
+		return
+	}
+	w.touch(root, event.Name)
+}
+
+// touch 视频文件有变化，需要等待文件大小稳定
+func (w *FileWatcher) touch(root watchRoot, videoFPath string) {
+
+	if pkg.IsWantedVideoExtDef(videoFPath) == false {
+		return
+	}
+	w.pendingLock.Lock()
+	defer w.pendingLock.Unlock()
+	nowPending, found := w.pending[videoFPath]
+	if found == false {
+		w.pending[videoFPath] = &pendingFile{root: root, size: -1, lastChange: time.Now()}
+		return
+	}
+	nowPending.lastChange = time.Now()
+}
+
+func (w *FileWatcher) debounceLoop() {
+
+	defer w.wg.Done()
+	checkInterval := w.debounce / 2
+	if checkInterval < 100*time.Millisecond {
+		checkInterval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			for _, ready := range w.popReady(w.debounce) {
+				w.onVideoReady(ready.root, ready.videoFPath)
+			}
+		}
+	}
+}
+
+type readyFile struct {
+	root       watchRoot
+	videoFPath string
+}
+
+// popReady 超过 debounce 没有事件，且文件大小与上一次检查时一致的，认为复制完成了
+func (w *FileWatcher) popReady(debounce time.Duration) []readyFile {
+
+	w.pendingLock.Lock()
+	defer w.pendingLock.Unlock()
+	readyFiles := make([]readyFile, 0)
+	for videoFPath, nowPending := range w.pending {
+		if time.Since(nowPending.lastChange) < debounce {
+			continue
+		}
+		fi, err := os.Stat(videoFPath)
+		if err != nil {
+			delete(w.pending, videoFPath)
+			continue
+		}
+		if fi.Size() != nowPending.size {
+			nowPending.size = fi.Size()
+			nowPending.lastChange = time.Now()
+			continue
+		}
+		delete(w.pending, videoFPath)
+		readyFiles = append(readyFiles, readyFile{root: nowPending.root, videoFPath: videoFPath})
+	}
+	return readyFiles
+}
+
+func (w *FileWatcher) onVideoReady(root watchRoot, videoFPath string) {
+
+	fi, err := os.Stat(videoFPath)
+	if err != nil {
+		return
+	}
+	if filter.SkipFileInfo(w.log, fs.FileInfoToDirEntry(fi), videoFPath) == true {
+		return
+	}
+	w.log.Infoln("FileWatcher New Video:", videoFPath)
+	w.videoReady(root.videoType, root.dirPath, videoFPath)
+}
+
+// pollingLoop 定时遍历轮询的根目录，第一次只是记录已经存在的视频，之后新出现的视频才需要处理
+func (w *FileWatcher) pollingLoop() {
+
+	defer w.wg.Done()
+	w.poll(true)
+	ticker := time.NewTicker(w.pollingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			w.poll(false)
+		}
+	}
+}
+
+func (w *FileWatcher) poll(isFirstTime bool) {
+
+	nowFiles := make(map[string]bool)
+	for _, root := range w.pollingRoots {
+		_ = filepath.WalkDir(root.dirPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() == true {
+				if path != root.dirPath && skipDir(d.Name()) == true {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if pkg.IsWantedVideoExtDef(path) == false {
+				return nil
+			}
+			nowFiles[path] = true
+			if isFirstTime == false && w.polledFiles[path] == false {
+				w.touch(root, path)
+			}
+			return nil
+		})
+	}
+	w.polledFiles = nowFiles
+}
+
+// findRoot 找到这个路径属于哪一个根目录，嵌套的时候使用最长的
+func (w *FileWatcher) findRoot(fileFPath string) (watchRoot, bool) {
+
+	found := false
+	var matchedRoot watchRoot
+	for _, root := range w.watchRoots {
+		rel, err := filepath.Rel(root.dirPath, fileFPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) == true {
+			continue
+		}
+		if found == false || len(root.dirPath) > len(matchedRoot.dirPath) {
+			matchedRoot = root
+			found = true
+		}
+	}
+	return matchedRoot, found
+}
+
+// skipDir 缓存、回收站之类的目录不需要监控，见 filter.SkipFileInfo
+func skipDir(dirName string) bool {
+	return strings.HasPrefix(dirName, ".@__thumb") == true || strings.HasPrefix(dirName, "@eaDir") == true ||
+		strings.HasPrefix(dirName, "#recycle") == true
+}
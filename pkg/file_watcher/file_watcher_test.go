@@ -0,0 +1,125 @@
+package file_watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+type readyVideo struct {
+	videoType   common.VideoType
+	rootDirPath string
+	videoFPath  string
+}
+
+func newTestFileWatcher(t *testing.T, pollingPaths []string, movieRootDir, seriesRootDir string) (*FileWatcher, chan readyVideo) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	readyChan := make(chan readyVideo, 10)
+	watcherSettings := settings.NewFileWatcherSettings()
+	watcherSettings.PollingPaths = pollingPaths
+	w := NewFileWatcher(log_helper.GetLogger4Tester(), *watcherSettings, []string{movieRootDir}, []string{seriesRootDir},
+		func(videoType common.VideoType, rootDirPath, videoFPath string) {
+			readyChan <- readyVideo{videoType: videoType, rootDirPath: rootDirPath, videoFPath: videoFPath}
+		})
+	w.debounce = 300 * time.Millisecond
+	w.pollingInterval = 200 * time.Millisecond
+	return w, readyChan
+}
+
+// writeSlowly 模拟复制一个大文件，分多次写入
+func writeSlowly(t *testing.T, fileFPath string, times int, interval time.Duration) {
+
+	err := os.MkdirAll(filepath.Dir(fileFPath), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(fileFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for i := 0; i < times; i++ {
+		_, err = f.WriteString(strings.Repeat("a", 4096))
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func TestFileWatcher_Watch(t *testing.T) {
+
+	movieRootDir := t.TempDir()
+	seriesRootDir := t.TempDir()
+	w, readyChan := newTestFileWatcher(t, nil, movieRootDir, seriesRootDir)
+	err := w.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if len(w.pollingRoots) != 0 {
+		t.Skip("temp dir is not support inotify")
+	}
+
+	// 新建的目录里面的视频，复制的过程中不能回调
+	videoFPath := filepath.Join(seriesRootDir, "Foo", "Season 1", "Foo.S01E01.mkv")
+	writeSlowly(t, videoFPath, 5, 150*time.Millisecond)
+	// 不是视频
+	writeSlowly(t, filepath.Join(movieRootDir, "Bar", "Bar.txt"), 1, 0)
+
+	select {
+	case ready := <-readyChan:
+		if ready.videoFPath != videoFPath || ready.videoType != common.Series || ready.rootDirPath != seriesRootDir {
+			t.Fatalf("ready = %+v", ready)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	select {
+	case ready := <-readyChan:
+		t.Fatalf("ready twice, %+v", ready)
+	case <-time.After(time.Second):
+	}
+}
+
+func TestFileWatcher_Polling(t *testing.T) {
+
+	movieRootDir := t.TempDir()
+	seriesRootDir := t.TempDir()
+	// 已经存在的视频不需要处理
+	writeSlowly(t, filepath.Join(movieRootDir, "Old", "Old.mkv"), 1, 0)
+	w, readyChan := newTestFileWatcher(t, []string{movieRootDir}, movieRootDir, seriesRootDir)
+	err := w.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if len(w.pollingRoots) != 1 || w.pollingRoots[0].dirPath != movieRootDir {
+		t.Fatalf("pollingRoots = %+v", w.pollingRoots)
+	}
+	// 等待第一次轮询完成
+	time.Sleep(100 * time.Millisecond)
+
+	videoFPath := filepath.Join(movieRootDir, "New", "New.mp4")
+	writeSlowly(t, videoFPath, 1, 0)
+	select {
+	case ready := <-readyChan:
+		if ready.videoFPath != videoFPath || ready.videoType != common.Movie {
+			t.Fatalf("ready = %+v", ready)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	select {
+	case ready := <-readyChan:
+		t.Fatalf("ready twice, %+v", ready)
+	case <-time.After(time.Second):
+	}
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package file_watcher
+
+import "syscall"
+
+// isNetworkFileSystem 网络挂载的目录，在本机上收不到其他机器写入文件的 inotify 事件
+func isNetworkFileSystem(dirPath string) bool {
+
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(dirPath, &stat)
+	if err != nil {
+		return false
+	}
+	switch uint32(stat.Type) {
+	case nfsSuperMagic, smbSuperMagic, cifsSuperMagic, smb2SuperMagic, fuseSuperMagic, v9fsSuperMagic:
+		return true
+	}
+	return false
+}
+
+const (
+	nfsSuperMagic  = 0x6969
+	smbSuperMagic  = 0x517B
+	cifsSuperMagic = 0xFF534D42
+	smb2SuperMagic = 0xFE534D42
+	fuseSuperMagic = 0x65735546 // rclone、sshfs 等
+	v9fsSuperMagic = 0x01021997 // WSL、Docker Desktop 的目录挂载
+)
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package file_watcher
+
+// isNetworkFileSystem 非 Linux 系统暂时不判断，需要的话在设置中指定轮询的目录
+func isNetworkFileSystem(dirPath string) bool {
+	return false
+}
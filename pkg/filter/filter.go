@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
 	"github.com/sirupsen/logrus"
 )
 
@@ -63,6 +64,10 @@ func SkipFileInfo(l *logrus.Logger, curFile os.DirEntry, fileFullPath string) bo
 		l.Debugln("curFile Name has -trailer:", curFile.Name())
 		return true
 	}
+	// 视频库根目录的包含、排除规则，以及 .csfignore
+	if scan_rules.IsSkipped(l, fileFullPath, curFile.IsDir()) == true {
+		return true
+	}
 
 	return false
 }
@@ -449,6 +449,40 @@ func ClearVideoAndSubPreviewCacheFolder() error {
 // Common
 // --------------------------------------------------------------
 
+// --------------------------------------------------------------
+// 本地字幕库
+// --------------------------------------------------------------
+
+// GetLocalFolderSubCacheFolder 本地字幕库中压缩包解压后的缓存，按压缩包的路径、大小、修改时间区分，不会在任务结束后清理
+func GetLocalFolderSubCacheFolder() (string, error) {
+
+	nowProcessRoot, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	nowProcessRoot = filepath.Join(nowProcessRoot, cacheRootFolderName, LocalFolderSubCacheFolder)
+	err = os.MkdirAll(nowProcessRoot, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+	return nowProcessRoot, err
+}
+
+// GetReferenceSubCacheFolder 下载到的非中文字幕的缓存，不参与评分，作为字幕处理步骤中双语合并的参考
+func GetReferenceSubCacheFolder() (string, error) {
+
+	nowProcessRoot, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	nowProcessRoot = filepath.Join(nowProcessRoot, cacheRootFolderName, ReferenceSubCacheFolder)
+	err = os.MkdirAll(nowProcessRoot, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+	return nowProcessRoot, err
+}
+
 // ClearFolder 清空文件夹
 func ClearFolder(folderFullPath string) error {
 	pathSep := string(os.PathSeparator)
@@ -597,6 +631,8 @@ const (
 	CacheCenterFolder             = "CSF-CacheCenter"             // 下载缓存、队列缓存、下载次数缓存的文件夹
 	ManualSubUploadCacheFolder    = "CSF-ManualSubUploadCache"    // 手动上传字幕的缓存文件夹
 	VideoAndSubPreviewCacheFolder = "CSF-VideoAndSubPreviewCache" // 视频和字幕的预览缓存
+	LocalFolderSubCacheFolder     = "CSF-LocalFolderSubCache"     // 本地字幕库中压缩包解压后的缓存
+	ReferenceSubCacheFolder       = "CSF-ReferenceSubCache"       // 下载到的非中文字幕，作为双语合并的参考
 )
 
 const (
@@ -0,0 +1,13 @@
+package ifaces
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// INotifyChannel 一个通知渠道，比如 Telegram、Bark、邮件，新增的渠道需要在 notify_center.NewChannel 中注册 URL 的 scheme
+type INotifyChannel interface {
+	// GetChannelName 渠道的名称，用于日志
+	GetChannelName() string
+	// Send 发送一个事件，需要是同步的，失败返回错误
+	Send(event *notify.Event) error
+}
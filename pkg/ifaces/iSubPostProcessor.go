@@ -0,0 +1,13 @@
+package ifaces
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+)
+
+// ISubPostProcessor 字幕写入后的一个处理步骤，新增的步骤需要在 sub_post_process.NewDefaultPipeline 中注册，并在 settings 中补充默认的顺序
+type ISubPostProcessor interface {
+	// GetStageName 步骤的名称，与 settings.SubPostProcessStage 中的 Name 对应
+	GetStageName() string
+	// Process 处理一个字幕，没有处理返回 false 以及跳过的原因，失败返回错误
+	Process(pCtx *sub_post_process.Context) (bool, string, error)
+}
@@ -0,0 +1,17 @@
+package ifaces
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// ISubScorer 字幕评分系统中的一项评分，新增的评分项需要在 sub_scorer.NewDefaultScorers 中注册
+type ISubScorer interface {
+	// GetScorerName 评分项的名称，会记录在评分明细中
+	GetScorerName() string
+	// GetWeight 从设置中获取这一项的权重，0 就是不参与评分
+	GetWeight(scoreSettings *settings.SubScoreSettings) float64
+	// Score 评分 0 - 1，如果当前的条件无法评分（比如获取不到视频时长），返回 false，不参与加权
+	Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool)
+}
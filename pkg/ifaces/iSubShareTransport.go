@@ -0,0 +1,13 @@
+package ifaces
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+// ISubShareTransport 把字幕上传到字幕共享中心的方式，新增的方式需要在 sub_share_center.NewTransport 中注册
+type ISubShareTransport interface {
+	// GetTransportName 方式的名称，用于日志
+	GetTransportName() string
+	// Send 上传一批字幕，需要是同步的，失败返回错误，这一批会整体重试
+	Send(items []*sub_share.ShareItem) error
+}
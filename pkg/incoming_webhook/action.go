@@ -0,0 +1,39 @@
+package incoming_webhook
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+
+type ActionType int
+
+const (
+	AddVideo    ActionType = iota // 新的视频入库（包括升级替换），添加高优先级的下载任务
+	RemoveVideo                   // 视频被删除，删除对应的任务
+	RemoveDir                     // 整部电影、连续剧被删除，删除这个目录下所有的任务
+	RenameVideo                   // 视频被重命名，删除旧的任务，添加新的任务
+)
+
+func (a ActionType) String() string {
+	switch a {
+	case AddVideo:
+		return "add"
+	case RemoveVideo:
+		return "remove"
+	case RemoveDir:
+		return "remove_dir"
+	case RenameVideo:
+		return "rename"
+	}
+	return "N/A"
+}
+
+// Action 从 Webhook 中解析出来需要对任务队列做的操作，这里的路径都是远端的路径，还没有经过路径映射
+type Action struct {
+	Type                     ActionType
+	VideoType                common.VideoType
+	VideoFPath               string // 视频的全路径，RemoveDir 的时候是目录
+	OldVideoFPath            string // RenameVideo 的时候，重命名之前的路径
+	SeriesRootDirPath        string // 连续剧的根目录，可能为空，为空的时候从视频的路径推断
+	Season                   int    // 连续剧的季，可能为 0，为 0 的时候从 nfo 中读取
+	Episode                  int    // 连续剧的集，可能为 0，为 0 的时候从 nfo 中读取
+	EpisodeEnd               int    // 多集合并的视频，最后一集的集数
+	MediaServerInsideVideoID string // 媒体服务器中的视频 ID
+}
@@ -0,0 +1,116 @@
+package incoming_webhook
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+func TestParseSonarr(t *testing.T) {
+
+	// 升级，多集合并的视频
+	body := `{"eventType":"Download","isUpgrade":true,
+"series":{"title":"Foo","path":"/tv/Foo"},
+"episodes":[{"seasonNumber":1,"episodeNumber":1},{"seasonNumber":1,"episodeNumber":2}],
+"episodeFile":{"relativePath":"Season 01/Foo - S01E01E02.mkv"},
+"deletedFiles":[{"path":"/tv/Foo/Season 01/Foo - S01E01E02.avi"}]}`
+	actions, err := ParseSonarr([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 || actions[0].Type != RemoveVideo || actions[1].Type != AddVideo {
+		t.Fatalf("actions = %+v", actions)
+	}
+	add := actions[1]
+	if add.VideoFPath != "/tv/Foo/Season 01/Foo - S01E01E02.mkv" || add.SeriesRootDirPath != "/tv/Foo" ||
+		add.Season != 1 || add.Episode != 1 || add.EpisodeEnd != 2 || add.VideoType != common.Series {
+		t.Fatalf("add = %+v", add)
+	}
+
+	body = `{"eventType":"Rename","series":{"path":"/tv/Foo"},
+"renamedEpisodeFiles":[{"path":"/tv/Foo/Season 01/b.mkv","previousPath":"/tv/Foo/Season 01/a.mkv"}]}`
+	actions, err = ParseSonarr([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Type != RenameVideo || actions[0].OldVideoFPath != "/tv/Foo/Season 01/a.mkv" {
+		t.Fatalf("actions = %+v", actions)
+	}
+
+	actions, err = ParseSonarr([]byte(`{"eventType":"Test"}`))
+	if err != nil || len(actions) != 0 {
+		t.Fatalf("actions = %+v, err = %v", actions, err)
+	}
+}
+
+func TestParseRadarr(t *testing.T) {
+
+	body := `{"eventType":"MovieDelete","movie":{"folderPath":"/movies/Foo (2020)"}}`
+	actions, err := ParseRadarr([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Type != RemoveDir || actions[0].VideoFPath != "/movies/Foo (2020)" {
+		t.Fatalf("actions = %+v", actions)
+	}
+
+	body = `{"eventType":"Download","movie":{"folderPath":"D:\\movies\\Foo (2020)"},"movieFile":{"relativePath":"Foo.mkv"}}`
+	actions, err = ParseRadarr([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].VideoFPath != "D:\\movies\\Foo (2020)\\Foo.mkv" || actions[0].VideoType != common.Movie {
+		t.Fatalf("actions = %+v", actions)
+	}
+}
+
+func TestParseEmby(t *testing.T) {
+
+	body := `{"Event":"library.new","Item":{"Id":"123","Type":"Episode","Path":"/media/tv/Foo/S01E03.mkv","ParentIndexNumber":1,"IndexNumber":3}}`
+	actions, err := ParseEmby([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].MediaServerInsideVideoID != "123" || actions[0].Episode != 3 {
+		t.Fatalf("actions = %+v", actions)
+	}
+	// 连续剧、季不需要处理
+	actions, err = ParseEmby([]byte(`{"Event":"library.new","Item":{"Id":"1","Type":"Series","Path":"/media/tv/Foo"}}`))
+	if err != nil || len(actions) != 0 {
+		t.Fatalf("actions = %+v, err = %v", actions, err)
+	}
+	actions, err = ParseJellyfin([]byte(`{"NotificationType":"ItemDeleted","ItemType":"Movie","Path":"/media/movies/Foo.mkv"}`))
+	if err != nil || len(actions) != 1 || actions[0].Type != RemoveVideo {
+		t.Fatalf("actions = %+v, err = %v", actions, err)
+	}
+}
+
+func TestMapPath(t *testing.T) {
+
+	mapping := map[string]string{
+		filepath.FromSlash("/data/tv"):       "/tv",
+		filepath.FromSlash("/data/anime"):    "/tv/anime",
+		filepath.FromSlash("/data/windows"):  "D:\\Videos",
+		filepath.FromSlash("/data/tv-other"): "/tv2/",
+	}
+	tests := []struct {
+		remotePath string
+		want       string
+		found      bool
+	}{
+		{"/tv/Foo/Season 01/a.mkv", "/data/tv/Foo/Season 01/a.mkv", true},
+		// 嵌套的路径匹配最长的
+		{"/tv/anime/Bar/a.mkv", "/data/anime/Bar/a.mkv", true},
+		{"D:\\Videos\\Foo\\a.mkv", "/data/windows/Foo/a.mkv", true},
+		{"/tv2/Foo", "/data/tv-other/Foo", true},
+		// 不能只是前缀相同
+		{"/tvshows/Foo/a.mkv", "", false},
+	}
+	for _, tt := range tests {
+		got, found := mapPath(tt.remotePath, mapping)
+		if found != tt.found || (found == true && got != filepath.FromSlash(tt.want)) {
+			t.Errorf("mapPath(%v) = %v, %v, want %v, %v", tt.remotePath, got, found, tt.want, tt.found)
+		}
+	}
+}
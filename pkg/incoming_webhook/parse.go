@@ -0,0 +1,243 @@
+package incoming_webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+type sonarrPayload struct {
+	EventType string `json:"eventType"`
+	Series    struct {
+		Path string `json:"path"`
+	} `json:"series"`
+	Episodes []struct {
+		SeasonNumber  int `json:"seasonNumber"`
+		EpisodeNumber int `json:"episodeNumber"`
+	} `json:"episodes"`
+	EpisodeFile struct {
+		RelativePath string `json:"relativePath"`
+		Path         string `json:"path"`
+	} `json:"episodeFile"`
+	DeletedFiles []struct {
+		Path string `json:"path"`
+	} `json:"deletedFiles"`
+	RenamedEpisodeFiles []struct {
+		Path         string `json:"path"`
+		PreviousPath string `json:"previousPath"`
+	} `json:"renamedEpisodeFiles"`
+}
+
+/*
+	ParseSonarr 解析 Sonarr 的 Webhook
+	Download（包括 isUpgrade）、Rename、EpisodeFileDelete、SeriesDelete，其他的事件（比如 Test、Grab）返回空
+*/
+func ParseSonarr(body []byte) ([]Action, error) {
+
+	var payload sonarrPayload
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]Action, 0)
+	switch payload.EventType {
+	case "Download":
+		// 升级的时候，旧的视频文件被删除了
+		for _, deletedFile := range payload.DeletedFiles {
+			actions = append(actions, Action{Type: RemoveVideo, VideoType: common.Series, VideoFPath: deletedFile.Path})
+		}
+		videoFPath := payload.EpisodeFile.Path
+		if videoFPath == "" && payload.EpisodeFile.RelativePath != "" {
+			videoFPath = joinRemotePath(payload.Series.Path, payload.EpisodeFile.RelativePath)
+		}
+		if videoFPath == "" {
+			return nil, errors.New("sonarr webhook Download event, episodeFile.path is empty")
+		}
+		action := Action{Type: AddVideo, VideoType: common.Series, VideoFPath: videoFPath, SeriesRootDirPath: payload.Series.Path}
+		// 多集合并的视频，episodes 中会有多集
+		for i, episode := range payload.Episodes {
+			if i == 0 {
+				action.Season = episode.SeasonNumber
+				action.Episode = episode.EpisodeNumber
+			} else if episode.SeasonNumber == action.Season && episode.EpisodeNumber > action.EpisodeEnd {
+				action.EpisodeEnd = episode.EpisodeNumber
+			}
+		}
+		actions = append(actions, action)
+	case "Rename":
+		// Sonarr v3 的 Rename 事件没有 renamedEpisodeFiles，只能等待下一次扫描
+		for _, renamedFile := range payload.RenamedEpisodeFiles {
+			actions = append(actions, Action{Type: RenameVideo, VideoType: common.Series,
+				VideoFPath: renamedFile.Path, OldVideoFPath: renamedFile.PreviousPath, SeriesRootDirPath: payload.Series.Path})
+		}
+	case "EpisodeFileDelete":
+		if payload.EpisodeFile.Path != "" {
+			actions = append(actions, Action{Type: RemoveVideo, VideoType: common.Series, VideoFPath: payload.EpisodeFile.Path})
+		}
+	case "SeriesDelete":
+		if payload.Series.Path != "" {
+			actions = append(actions, Action{Type: RemoveDir, VideoType: common.Series, VideoFPath: payload.Series.Path})
+		}
+	}
+	return actions, nil
+}
+
+type radarrPayload struct {
+	EventType string `json:"eventType"`
+	Movie     struct {
+		FolderPath string `json:"folderPath"`
+	} `json:"movie"`
+	MovieFile struct {
+		RelativePath string `json:"relativePath"`
+		Path         string `json:"path"`
+	} `json:"movieFile"`
+	DeletedFiles []struct {
+		Path string `json:"path"`
+	} `json:"deletedFiles"`
+	RenamedMovieFiles []struct {
+		Path         string `json:"path"`
+		PreviousPath string `json:"previousPath"`
+	} `json:"renamedMovieFiles"`
+}
+
+/*
+	ParseRadarr 解析 Radarr 的 Webhook
+	Download（包括 isUpgrade）、Rename、MovieFileDelete、MovieDelete，其他的事件（比如 Test、Grab）返回空
+*/
+func ParseRadarr(body []byte) ([]Action, error) {
+
+	var payload radarrPayload
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]Action, 0)
+	switch payload.EventType {
+	case "Download":
+		for _, deletedFile := range payload.DeletedFiles {
+			actions = append(actions, Action{Type: RemoveVideo, VideoType: common.Movie, VideoFPath: deletedFile.Path})
+		}
+		videoFPath := payload.MovieFile.Path
+		if videoFPath == "" && payload.MovieFile.RelativePath != "" {
+			videoFPath = joinRemotePath(payload.Movie.FolderPath, payload.MovieFile.RelativePath)
+		}
+		if videoFPath == "" {
+			return nil, errors.New("radarr webhook Download event, movieFile.path is empty")
+		}
+		actions = append(actions, Action{Type: AddVideo, VideoType: common.Movie, VideoFPath: videoFPath})
+	case "Rename":
+		for _, renamedFile := range payload.RenamedMovieFiles {
+			actions = append(actions, Action{Type: RenameVideo, VideoType: common.Movie,
+				VideoFPath: renamedFile.Path, OldVideoFPath: renamedFile.PreviousPath})
+		}
+	case "MovieFileDelete":
+		if payload.MovieFile.Path != "" {
+			actions = append(actions, Action{Type: RemoveVideo, VideoType: common.Movie, VideoFPath: payload.MovieFile.Path})
+		}
+	case "MovieDelete":
+		if payload.Movie.FolderPath != "" {
+			actions = append(actions, Action{Type: RemoveDir, VideoType: common.Movie, VideoFPath: payload.Movie.FolderPath})
+		}
+	}
+	return actions, nil
+}
+
+type embyPayload struct {
+	Event string `json:"Event"`
+	Item  struct {
+		Id                string `json:"Id"`
+		Type              string `json:"Type"`
+		Path              string `json:"Path"`
+		ParentIndexNumber int    `json:"ParentIndexNumber"`
+		IndexNumber       int    `json:"IndexNumber"`
+		IndexNumberEnd    int    `json:"IndexNumberEnd"`
+	} `json:"Item"`
+}
+
+// ParseEmby 解析 Emby 的 Webhook（通知中的 Webhooks），只处理 library.new、library.deleted 中的 Movie、Episode
+func ParseEmby(body []byte) ([]Action, error) {
+
+	var payload embyPayload
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+	return mediaServerItemActions(payload.Event == "library.new", payload.Event == "library.deleted",
+		payload.Item.Type, payload.Item.Path, payload.Item.Id,
+		payload.Item.ParentIndexNumber, payload.Item.IndexNumber, payload.Item.IndexNumberEnd)
+}
+
+/*
+	jellyfinPayload Jellyfin 的 Webhook 插件（Generic Destination）
+	插件默认的模板中没有视频的路径，需要在模板中加上 "Path" 字段，比如 "Path": "{{ItemPath}}"，插件不同版本的变量名可能不同
+*/
+type jellyfinPayload struct {
+	NotificationType string `json:"NotificationType"`
+	ItemId           string `json:"ItemId"`
+	ItemType         string `json:"ItemType"`
+	Path             string `json:"Path"`
+	SeasonNumber     int    `json:"SeasonNumber"`
+	EpisodeNumber    int    `json:"EpisodeNumber"`
+	EpisodeNumberEnd int    `json:"EpisodeNumberEnd"`
+}
+
+// ParseJellyfin 解析 Jellyfin 的 Webhook，只处理 ItemAdded、ItemDeleted 中的 Movie、Episode
+func ParseJellyfin(body []byte) ([]Action, error) {
+
+	var payload jellyfinPayload
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+	return mediaServerItemActions(payload.NotificationType == "ItemAdded", payload.NotificationType == "ItemDeleted",
+		payload.ItemType, payload.Path, payload.ItemId,
+		payload.SeasonNumber, payload.EpisodeNumber, payload.EpisodeNumberEnd)
+}
+
+func mediaServerItemActions(isAdded, isDeleted bool, itemType, path, itemId string, season, episode, episodeEnd int) ([]Action, error) {
+
+	actions := make([]Action, 0)
+	if isAdded == false && isDeleted == false {
+		return actions, nil
+	}
+	var videoType common.VideoType
+	switch itemType {
+	case "Movie":
+		videoType = common.Movie
+	case "Episode":
+		videoType = common.Series
+	default:
+		// 连续剧、季等其他的类型，不需要处理
+		return actions, nil
+	}
+	if path == "" {
+		return nil, errors.New(fmt.Sprintf("media server webhook, %s item %s path is empty", itemType, itemId))
+	}
+	if isDeleted == true {
+		return append(actions, Action{Type: RemoveVideo, VideoType: videoType, VideoFPath: path}), nil
+	}
+	action := Action{Type: AddVideo, VideoType: videoType, VideoFPath: path, MediaServerInsideVideoID: itemId}
+	if videoType == common.Series {
+		action.Season = season
+		action.Episode = episode
+		if episodeEnd > episode {
+			action.EpisodeEnd = episodeEnd
+		}
+	}
+	return append(actions, action), nil
+}
+
+// joinRemotePath 远端的路径可能是 Windows 也可能是 Linux 的，不能使用 filepath.Join
+func joinRemotePath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	sep := "/"
+	if strings.Contains(dir, "\\") == true {
+		sep = "\\"
+	}
+	return strings.TrimRight(dir, "/\\") + sep + strings.TrimLeft(name, "/\\")
+}
@@ -0,0 +1,82 @@
+package incoming_webhook
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sort_things"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+/*
+	MapRemotePath 把远端（Sonarr、Radarr、媒体服务器）的路径转换为本程序的物理路径
+	先使用 IncomingWebhookSettings 的路径映射，没有匹配上再使用 EmbySettings 中电影、连续剧的路径映射
+	都没有匹配上就原样返回，比如 Docker 中挂载的路径是一致的
+	映射的时候需要考虑嵌套路径的问题，比如映射了 /tv 以及 /tv/anime，那么应该匹配最长的路径
+*/
+func MapRemotePath(remotePath string, videoType common.VideoType) string {
+
+	if remotePath == "" {
+		return ""
+	}
+	mappings := make([]map[string]string, 0)
+	if settings.Get().AdvancedSettings.IncomingWebhookSettings != nil {
+		mappings = append(mappings, settings.Get().AdvancedSettings.IncomingWebhookSettings.PathsMapping)
+	}
+	if settings.Get().EmbySettings != nil {
+		if videoType == common.Movie {
+			mappings = append(mappings, settings.Get().EmbySettings.MoviePathsMapping)
+		} else {
+			mappings = append(mappings, settings.Get().EmbySettings.SeriesPathsMapping)
+		}
+	}
+	for _, mapping := range mappings {
+		physicalPath, found := mapPath(remotePath, mapping)
+		if found == true {
+			return physicalPath
+		}
+	}
+	return remotePath
+}
+
+func mapPath(remotePath string, mapping map[string]string) (string, bool) {
+
+	matchedRemotePaths := make([]string, 0)
+	for _, remoteRootPath := range mapping {
+		if remoteRootPath != "" && isSubPath(remotePath, remoteRootPath) == true {
+			matchedRemotePaths = append(matchedRemotePaths, remoteRootPath)
+		}
+	}
+	if len(matchedRemotePaths) < 1 {
+		return "", false
+	}
+	remoteRootPath := sort_things.SortStringSliceByLength(matchedRemotePaths)[0].Path
+	for physicalRootPath, nowRemoteRootPath := range mapping {
+		if nowRemoteRootPath != remoteRootPath {
+			continue
+		}
+		// 剩下的部分统一分隔符，远端与本程序可能一个是 Windows 一个是 Linux
+		relPath := strings.TrimPrefix(remotePath, strings.TrimRight(remoteRootPath, "/\\"))
+		relPath = strings.Trim(strings.ReplaceAll(relPath, "\\", "/"), "/")
+		if relPath == "" {
+			return filepath.Clean(physicalRootPath), true
+		}
+		return filepath.Join(physicalRootPath, filepath.FromSlash(relPath)), true
+	}
+	return "", false
+}
+
+// isSubPath 需要按目录来匹配，/tv 不能匹配到 /tv2/xx.mkv
+func isSubPath(remotePath, remoteRootPath string) bool {
+
+	remoteRootPath = strings.TrimRight(remoteRootPath, "/\\")
+	if strings.HasPrefix(remotePath, remoteRootPath) == false {
+		return false
+	}
+	if len(remotePath) == len(remoteRootPath) {
+		return true
+	}
+	next := remotePath[len(remoteRootPath)]
+	return next == '/' || next == '\\'
+}
@@ -0,0 +1,204 @@
+package incoming_webhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	TTaskqueue "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+	"github.com/sirupsen/logrus"
+)
+
+// Result 一个 Action 的处理结果，返回给 Webhook 的调用方
+type Result struct {
+	Action     string `json:"action"`
+	VideoFPath string `json:"video_f_path"` // 经过路径映射后的路径
+	JobID      string `json:"job_id"`       // 新增、更新的任务 ID，删除的时候是被删除的任务 ID
+	Message    string `json:"message"`
+}
+
+// Processor 把 Webhook 解析出来的 Action 应用到下载队列上
+type Processor struct {
+	log           *logrus.Logger
+	downloadQueue *task_queue.TaskQueue
+}
+
+func NewProcessor(log *logrus.Logger, downloadQueue *task_queue.TaskQueue) *Processor {
+	return &Processor{log: log, downloadQueue: downloadQueue}
+}
+
+// Process 按顺序处理，升级的时候是先删除旧的视频的任务，再添加新的视频的任务
+func (p *Processor) Process(actions []Action) []Result {
+
+	results := make([]Result, 0)
+	for _, action := range actions {
+		// 路径映射
+		action.VideoFPath = MapRemotePath(action.VideoFPath, action.VideoType)
+		action.OldVideoFPath = MapRemotePath(action.OldVideoFPath, action.VideoType)
+		action.SeriesRootDirPath = MapRemotePath(action.SeriesRootDirPath, action.VideoType)
+
+		var nowResults []Result
+		var err error
+		switch action.Type {
+		case AddVideo:
+			nowResults, err = p.addVideo(action, nil)
+		case RemoveVideo:
+			nowResults, err = p.removeJobs(action, func(oneJob TTaskqueue.OneJob) bool {
+				return oneJob.VideoFPath == action.VideoFPath
+			})
+		case RemoveDir:
+			nowResults, err = p.removeJobs(action, func(oneJob TTaskqueue.OneJob) bool {
+				return oneJob.SeriesRootDirPath == action.VideoFPath || isSubPath(oneJob.VideoFPath, action.VideoFPath) == true
+			})
+		case RenameVideo:
+			nowResults, err = p.renameVideo(action)
+		}
+		if err != nil {
+			p.log.Errorln("IncomingWebhook", action.Type.String(), action.VideoFPath, err)
+			nowResults = append(nowResults, Result{Action: action.Type.String(), VideoFPath: action.VideoFPath, Message: err.Error()})
+		}
+		results = append(results, nowResults...)
+	}
+	return results
+}
+
+// addVideo 添加高优先级的任务，oldJob 不为空的时候是重命名，继承旧任务的状态
+func (p *Processor) addVideo(action Action, oldJob *TTaskqueue.OneJob) ([]Result, error) {
+
+	isBlue, _, _ := decode.IsFakeBDMVWorked(action.VideoFPath)
+	if isBlue == false && pkg.IsFile(action.VideoFPath) == false {
+		return nil, errors.New("physical video file not found, check the paths mapping")
+	}
+	nowJob := TTaskqueue.NewOneJob(action.VideoType, action.VideoFPath, task_queue.HighTaskPriorityLevel, action.MediaServerInsideVideoID)
+	if action.VideoType == common.Series {
+		err := fillSeriesInfo(nowJob, action)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if oldJob != nil {
+		nowJob.JobStatus = oldJob.JobStatus
+		nowJob.TaskPriority = oldJob.TaskPriority
+		nowJob.RetryTimes = oldJob.RetryTimes
+		nowJob.DownloadTimes = oldJob.DownloadTimes
+		if nowJob.MediaServerInsideVideoID == "" {
+			nowJob.MediaServerInsideVideoID = oldJob.MediaServerInsideVideoID
+		}
+	}
+
+	bok, err := p.downloadQueue.Add(*nowJob)
+	if err != nil {
+		return nil, err
+	}
+	if bok == true {
+		p.log.Infoln("IncomingWebhook", action.Type.String(), "Add Job", nowJob.VideoFPath)
+		return []Result{{Action: action.Type.String(), VideoFPath: nowJob.VideoFPath, JobID: nowJob.Id, Message: "ok"}}, nil
+	}
+	// 已经在队列中了，重新入库的视频需要尽快再下载一次，正在下载的、被用户忽略的不动
+	_, existJob := p.downloadQueue.GetOneJobByID(nowJob.Id)
+	if oldJob == nil && (existJob.JobStatus == TTaskqueue.Waiting || existJob.JobStatus == TTaskqueue.Failed || existJob.JobStatus == TTaskqueue.Done) {
+		existJob.JobStatus = TTaskqueue.Waiting
+		if existJob.TaskPriority > task_queue.HighTaskPriorityLevel {
+			existJob.TaskPriority = task_queue.HighTaskPriorityLevel
+		}
+		if action.MediaServerInsideVideoID != "" {
+			existJob.MediaServerInsideVideoID = action.MediaServerInsideVideoID
+		}
+		_, err = p.downloadQueue.Update(existJob)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []Result{{Action: action.Type.String(), VideoFPath: nowJob.VideoFPath, JobID: nowJob.Id, Message: "job is already in queue"}}, nil
+}
+
+// fillSeriesInfo 优先使用 nfo 中的信息，刚入库的视频可能还没有 nfo（媒体服务器还没刮削），那么就使用 Webhook 中的信息
+func fillSeriesInfo(nowJob *TTaskqueue.OneJob, action Action) error {
+
+	epsVideoNfoInfo, err := decode.GetVideoNfoInfo4OneSeriesEpisode(action.VideoFPath)
+	if err == nil {
+		nowJob.Season = epsVideoNfoInfo.Season
+		nowJob.Episode = epsVideoNfoInfo.Episode
+	} else {
+		nowJob.Season = action.Season
+		nowJob.Episode = action.Episode
+	}
+	if nowJob.Season == action.Season && nowJob.Episode == action.Episode {
+		nowJob.EpisodeEnd = action.EpisodeEnd
+	}
+	nowJob.SeriesRootDirPath = decode.GetSeriesDirRootFPath(action.VideoFPath)
+	if nowJob.SeriesRootDirPath == "" {
+		nowJob.SeriesRootDirPath = action.SeriesRootDirPath
+	}
+	if nowJob.SeriesRootDirPath == "" || nowJob.Episode <= 0 {
+		return errors.New(fmt.Sprintf("can't get series root dir or season/episode info, %s", action.VideoFPath))
+	}
+	return nil
+}
+
+func (p *Processor) removeJobs(action Action, match func(oneJob TTaskqueue.OneJob) bool) ([]Result, error) {
+
+	results := make([]Result, 0)
+	removedJobs, err := p.delJobs(match)
+	if err != nil {
+		return nil, err
+	}
+	for _, removedJob := range removedJobs {
+		p.log.Infoln("IncomingWebhook", action.Type.String(), "Del Job", removedJob.VideoFPath)
+		results = append(results, Result{Action: action.Type.String(), VideoFPath: removedJob.VideoFPath, JobID: removedJob.Id, Message: "ok"})
+	}
+	if len(results) < 1 {
+		results = append(results, Result{Action: action.Type.String(), VideoFPath: action.VideoFPath, Message: "job not found"})
+	}
+	return results, nil
+}
+
+// renameVideo 删除旧路径的任务，添加新路径的任务。Sonarr、Radarr 重命名的时候会一起重命名字幕，所以继承旧任务的状态
+func (p *Processor) renameVideo(action Action) ([]Result, error) {
+
+	removedJobs, err := p.delJobs(func(oneJob TTaskqueue.OneJob) bool {
+		return oneJob.VideoFPath == action.OldVideoFPath
+	})
+	if err != nil {
+		return nil, err
+	}
+	var oldJob *TTaskqueue.OneJob
+	if len(removedJobs) > 0 {
+		oldJob = &removedJobs[0]
+		if action.VideoType == common.Series && action.Episode <= 0 {
+			action.Season, action.Episode, action.EpisodeEnd = oldJob.Season, oldJob.Episode, oldJob.EpisodeEnd
+		}
+		if action.SeriesRootDirPath == "" {
+			action.SeriesRootDirPath = oldJob.SeriesRootDirPath
+		}
+	}
+	return p.addVideo(action, oldJob)
+}
+
+func (p *Processor) delJobs(match func(oneJob TTaskqueue.OneJob) bool) ([]TTaskqueue.OneJob, error) {
+
+	removedJobs := make([]TTaskqueue.OneJob, 0)
+	bok, allJobs, err := p.downloadQueue.GetAllJobs()
+	if err != nil {
+		return nil, err
+	}
+	if bok == false {
+		return removedJobs, nil
+	}
+	for _, oneJob := range allJobs {
+		if match(oneJob) == false {
+			continue
+		}
+		bok, err = p.downloadQueue.Del(oneJob.Id)
+		if err != nil {
+			return nil, err
+		}
+		if bok == true {
+			removedJobs = append(removedJobs, oneJob)
+		}
+	}
+	return removedJobs, nil
+}
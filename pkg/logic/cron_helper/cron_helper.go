@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/file_watcher"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_share_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/video_scan_and_refresh_helper"
 	"github.com/robfig/cron/v3"
@@ -32,8 +34,9 @@ type CronHelper struct {
 	entryIDSupplierCheck      cron.EntryID                                             // 检查字幕源有效性的定时器的 ID
 	entryIDQueueDownloader    cron.EntryID                                             // 下载队列的定时器的 ID
 	entryIDFeedBack           cron.EntryID                                             // 信息反馈
+	fileWatcher               *file_watcher.FileWatcher                                // 监控视频目录的变化
+	entryIDUploadSub          cron.EntryID                                             // 上传字幕到字幕共享中心
 	//entryIDScanPlayedVideoSubInfo cron.EntryID
-	//entryIDUploadPlayedVideoSub cron.EntryID
 }
 
 func NewCronHelper(fileDownloader *file_downloader.FileDownloader) *CronHelper {
@@ -143,17 +146,19 @@ func (ch *CronHelper) Start(runImmediately bool) {
 	}
 	// 字幕的上传逻辑
 	if settings.Get().ExperimentalFunction.ShareSubSettings.ShareSubEnabled == true {
-		// 取消上传字幕的逻辑，目前评估的第一阶段已经完成，后续的逻辑需要重新评估
-		//intervalNowTask := "@every 10s"
-		//if settings.Get().SpeedDevMode == true {
-		//	intervalNowTask = "@every 1s"
-		//}
-		//ch.entryIDUploadPlayedVideoSub, err = ch.c.AddFunc(intervalNowTask, ch.uploadVideoSub)
-		//if err != nil {
-		//	ch.Logger.Panicln("CronHelper QueueDownloader, uploadVideoSub Cron entryID:", ch.entryIDUploadPlayedVideoSub, "Error:", err)
-		//}
+		intervalNowTask := "@every 30m"
+		if settings.Get().SpeedDevMode == true {
+			intervalNowTask = "@every 1m"
+		}
+		ch.entryIDUploadSub, err = ch.c.AddFunc(intervalNowTask, ch.uploadSub)
+		if err != nil {
+			ch.Logger.Panicln("CronHelper uploadSub, uploadSub Cron entryID:", ch.entryIDUploadSub, "Error:", err)
+		}
 	}
 
+	// ----------------------------------------------
+	// 监控视频目录，新的视频立即加入下载队列
+	ch.startFileWatcher()
 	// ----------------------------------------------
 	if runImmediately == true {
 		// 是否在定时器开启前先执行一次视频扫描任务
@@ -222,6 +227,7 @@ func (ch *CronHelper) Stop() {
 	ch.stopping = true
 	ch.cronLock.Unlock()
 
+	ch.stopFileWatcher()
 	ch.videoScanAndRefreshHelper.Cancel()
 	ch.Downloader.Cancel()
 	//ch.scanPlayedVideoSubInfo.Cancel()
@@ -256,6 +262,22 @@ func (ch *CronHelper) feedBack() {
 	}
 }
 
+// uploadSub 把缓存中还没有发送的字幕上传到字幕共享中心
+func (ch *CronHelper) uploadSub() {
+
+	shareSettings := settings.Get().ExperimentalFunction.ShareSubSettings
+	transport, err := sub_share_center.NewTransport(shareSettings)
+	if err != nil {
+		ch.Logger.Errorln("uploadSub.NewTransport", err)
+		return
+	}
+	_, err = sub_share_center.NewSubShareCenter(ch.Logger, transport, shareSettings).UploadPending()
+	if err != nil {
+		ch.Logger.Errorln("uploadSub.UploadPending", err)
+		return
+	}
+}
+
 //func (ch *CronHelper) scanPlayedVideoSub() {
 //
 //	ch.Logger.Infoln("Update Info...")
@@ -0,0 +1,113 @@
+package cron_helper
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/file_watcher"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/task_queue"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	TTaskqueue "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+)
+
+// startFileWatcher 监控电影、连续剧目录，新的视频不需要等待定时扫描就加入下载队列
+func (ch *CronHelper) startFileWatcher() {
+
+	watcherSettings := settings.Get().AdvancedSettings.FileWatcherSettings
+	if watcherSettings == nil || watcherSettings.Enable == false {
+		return
+	}
+	ch.fileWatcher = file_watcher.NewFileWatcher(ch.Logger, *watcherSettings,
+		settings.Get().CommonSettings.MoviePaths, settings.Get().CommonSettings.SeriesPaths,
+		ch.addNewVideo2DownloadQueue)
+	err := ch.fileWatcher.Start()
+	if err != nil {
+		ch.Logger.Errorln("FileWatcher Start Error:", err)
+		ch.fileWatcher = nil
+	}
+}
+
+func (ch *CronHelper) stopFileWatcher() {
+
+	if ch.fileWatcher == nil {
+		return
+	}
+	ch.fileWatcher.Stop()
+	ch.fileWatcher = nil
+}
+
+// addNewVideo2DownloadQueue 与定时扫描一样的优先级加入队列，后续的检查（是否看过、是否过期）由下载者在取出任务的时候进行
+func (ch *CronHelper) addNewVideo2DownloadQueue(videoType common.VideoType, rootDirPath, videoFPath string) {
+
+	scanLogicVideoType := 0
+	if videoType == common.Series {
+		scanLogicVideoType = 1
+	}
+	if ch.Downloader.ScanLogic.Get(scanLogicVideoType, videoFPath) == true {
+		ch.Logger.Infoln("FileWatcher Skip, ScanLogic:", videoFPath)
+		return
+	}
+
+	oneJob := TTaskqueue.NewOneJob(videoType, videoFPath, task_queue.DefaultTaskPriorityLevel)
+	if videoType == common.Series {
+		found := fillNewEpisodeInfo(oneJob, rootDirPath)
+		if found == false {
+			ch.Logger.Warningln("FileWatcher Skip, can't get season and episode info:", videoFPath)
+			return
+		}
+	}
+	bok, err := ch.DownloadQueue.Add(*oneJob)
+	if err != nil {
+		ch.Logger.Errorln("FileWatcher DownloadQueue.Add", videoFPath, err)
+		return
+	}
+	if bok == false {
+		ch.Logger.Infoln("FileWatcher", videoFPath, "downloadQueue isExisted")
+		return
+	}
+	ch.Logger.Infoln("FileWatcher Add Job:", videoFPath)
+}
+
+/*
+	fillNewEpisodeInfo 刚复制进来的视频，媒体服务器可能还没有刮削，没有 nfo
+	那么就从文件名中解析季、集，连续剧的目录就是连续剧根目录下的第一级目录
+*/
+func fillNewEpisodeInfo(oneJob *TTaskqueue.OneJob, rootDirPath string) bool {
+
+	epsVideoNfoInfo, err := decode.GetVideoNfoInfo4OneSeriesEpisode(oneJob.VideoFPath)
+	if err == nil {
+		oneJob.Season = epsVideoNfoInfo.Season
+		oneJob.Episode = epsVideoNfoInfo.Episode
+	} else {
+		torrentInfo, err := decode.GetVideoInfoFromFileName(filepath.Base(oneJob.VideoFPath))
+		if err != nil {
+			return false
+		}
+		oneJob.Season = torrentInfo.Season
+		oneJob.Episode = torrentInfo.Episode
+	}
+	if oneJob.Episode <= 0 {
+		return false
+	}
+	found, season, episodeStart, episodeEnd := decode.GetEpisodeRangeFromFileName(oneJob.VideoFPath)
+	if found == true && season == oneJob.Season && episodeStart == oneJob.Episode {
+		oneJob.EpisodeEnd = episodeEnd
+	}
+
+	oneJob.SeriesRootDirPath = decode.GetSeriesDirRootFPath(oneJob.VideoFPath)
+	if oneJob.SeriesRootDirPath == "" {
+		rel, err := filepath.Rel(rootDirPath, oneJob.VideoFPath)
+		if err != nil {
+			return false
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) < 2 {
+			// 视频直接放在了连续剧的根目录下
+			return false
+		}
+		oneJob.SeriesRootDirPath = filepath.Join(rootDirPath, parts[0])
+	}
+	return true
+}
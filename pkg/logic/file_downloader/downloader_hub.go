@@ -3,6 +3,7 @@ package file_downloader
 import (
 	"crypto/sha256"
 	"fmt"
+	"net/http"
 	"path/filepath"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/media_info_dealers"
@@ -21,6 +22,8 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/cache_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/subtitle_best_api"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
+	"github.com/go-resty/resty/v2"
 	"github.com/go-rod/rod"
 	"github.com/sirupsen/logrus"
 )
@@ -68,13 +71,16 @@ func (f *FileDownloader) Get(supplierName string, topN int64, videoFileName stri
 	if found == false {
 		fileData, downloadFileName, err := pkg.DownFile(f.Log, fileDownloadUrl)
 		if err != nil {
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
 			return nil, err
 		}
 		// 下载成功需要统计到今天的次数中
-		_, err = f.CacheCenter.DailyDownloadCountAdd(supplierName,
+		downloadCount, err := f.CacheCenter.DailyDownloadCountAdd(supplierName,
 			pkg.GetPublicIP(f.Log, settings.Get().AdvancedSettings.TaskQueue))
 		if err != nil {
 			f.Log.Warningln(supplierName, "FileDownloader.Get.DailyDownloadCountAdd", err)
+		} else {
+			supplier_health.RecordQuotaUsed(f.Log, supplierName, downloadCount)
 		}
 		// 需要获取下载文件的后缀名，后续才指导是要解压还是直接解析字幕
 		ext := ""
@@ -85,6 +91,7 @@ func (f *FileDownloader) Get(supplierName string, topN int64, videoFileName stri
 		}
 		// 默认存入都是简体中文的语言类型，后续取出来的时候需要再次调用 SubParser 进行解析
 		inSubInfo := supplier.NewSubInfo(supplierName, topN, videoFileName, language.ChineseSimple, fileDownloadUrl, score, offset, ext, fileData)
+		inSubInfo.ReleaseName = downloadFileName
 
 		if len(cacheString) > 0 {
 			// 专门为 ASSRT 这种下载连接是临时情况而定制的
@@ -118,13 +125,16 @@ func (f *FileDownloader) GetA4k(supplierName string, topN int64, season, eps int
 	if found == false {
 		fileData, downloadFileName, err := pkg.DownFile(f.Log, fileDownloadUrl)
 		if err != nil {
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
 			return nil, err
 		}
 		// 下载成功需要统计到今天的次数中
-		_, err = f.CacheCenter.DailyDownloadCountAdd(supplierName,
+		downloadCount, err := f.CacheCenter.DailyDownloadCountAdd(supplierName,
 			pkg.GetPublicIP(f.Log, settings.Get().AdvancedSettings.TaskQueue))
 		if err != nil {
 			f.Log.Warningln(supplierName, "FileDownloader.Get.DailyDownloadCountAdd", err)
+		} else {
+			supplier_health.RecordQuotaUsed(f.Log, supplierName, downloadCount)
 		}
 		// 需要获取下载文件的后缀名，后续才指导是要解压还是直接解析字幕
 		ext := ""
@@ -135,6 +145,64 @@ func (f *FileDownloader) GetA4k(supplierName string, topN int64, season, eps int
 		}
 		// 默认存入都是简体中文的语言类型，后续取出来的时候需要再次调用 SubParser 进行解析
 		inSubInfo := supplier.NewSubInfo(supplierName, topN, videoFileName, language.ChineseSimple, fileDownloadUrl, 0, 0, ext, fileData)
+		inSubInfo.ReleaseName = downloadFileName
+		inSubInfo.Season = season
+		inSubInfo.Episode = eps
+		inSubInfo.GetUID()
+
+		err = f.CacheCenter.DownloadFileAdd(inSubInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		return inSubInfo, nil
+	} else {
+		// 如果已经存在缓存中，那么就直接返回
+		return subInfo, nil
+	}
+}
+
+// GetWithClient supplierName 这个参数一定得是字幕源的名称，通过 s.GetSupplierName() 获取，否则后续的字幕源今日下载量将不能正确统计和判断
+// custom 字幕源使用这个，下载的时候需要使用带有设置的 Header、请求间隔的 httpClient
+func (f *FileDownloader) GetWithClient(supplierName string, httpClient *resty.Client, topN int64, season, eps int,
+	videoFileName string, fileDownloadUrl string) (*supplier.SubInfo, error) {
+
+	fileUID := fmt.Sprintf("%x", sha256.Sum256([]byte(fileDownloadUrl)))
+	found, subInfo, err := f.CacheCenter.DownloadFileGet(fileUID)
+	if err != nil {
+		return nil, err
+	}
+	// 如果不存在那么就先下载，然后再存入缓存中
+	if found == false {
+		resp, err := httpClient.R().Get(fileDownloadUrl)
+		if err != nil {
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
+			return nil, err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			err = supplier.NewStatusCodeError("download file", resp.StatusCode(), "")
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
+			return nil, err
+		}
+		downloadFileName := pkg.GetFileName(f.Log, resp.RawResponse)
+		// 下载成功需要统计到今天的次数中
+		downloadCount, err := f.CacheCenter.DailyDownloadCountAdd(supplierName,
+			pkg.GetPublicIP(f.Log, settings.Get().AdvancedSettings.TaskQueue))
+		if err != nil {
+			f.Log.Warningln(supplierName, "FileDownloader.GetWithClient.DailyDownloadCountAdd", err)
+		} else {
+			supplier_health.RecordQuotaUsed(f.Log, supplierName, downloadCount)
+		}
+		// 需要获取下载文件的后缀名，后续才指导是要解压还是直接解析字幕
+		ext := ""
+		if downloadFileName == "" {
+			ext = filepath.Ext(fileDownloadUrl)
+		} else {
+			ext = filepath.Ext(downloadFileName)
+		}
+		// 默认存入都是简体中文的语言类型，后续取出来的时候需要再次调用 SubParser 进行解析
+		inSubInfo := supplier.NewSubInfo(supplierName, topN, videoFileName, language.ChineseSimple, fileDownloadUrl, 0, 0, ext, resp.Body())
+		inSubInfo.ReleaseName = downloadFileName
 		inSubInfo.Season = season
 		inSubInfo.Episode = eps
 		inSubInfo.GetUID()
@@ -165,13 +233,16 @@ func (f *FileDownloader) GetEx(supplierName string, browser *rod.Browser, subDow
 
 		subInfo, err = downFileFunc(browser, subDownloadPageUrl, TopN, Season, Episode)
 		if err != nil {
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
 			return nil, err
 		}
 		// 下载成功需要统计到今天的次数中
-		_, err = f.CacheCenter.DailyDownloadCountAdd(supplierName,
+		downloadCount, err := f.CacheCenter.DailyDownloadCountAdd(supplierName,
 			pkg.GetPublicIP(f.Log, settings.Get().AdvancedSettings.TaskQueue))
 		if err != nil {
 			f.Log.Warningln(supplierName, "FileDownloader.GetEx.DailyDownloadCountAdd", err)
+		} else {
+			supplier_health.RecordQuotaUsed(f.Log, supplierName, downloadCount)
 		}
 		// 默认存入都是简体中文的语言类型，后续取出来的时候需要再次调用 SubParser 进行解析
 		err = f.CacheCenter.DownloadFileAdd(subInfo)
@@ -199,13 +270,16 @@ func (f *FileDownloader) GetSubtitleBest(supplierName string, topN int64, season
 
 		fileData, _, err := pkg.DownFile(f.Log, fileDownloadUrl)
 		if err != nil {
+			supplier_health.RecordDownloadFailed(f.Log, supplierName, err)
 			return nil, err
 		}
 		// 下载成功需要统计到今天的次数中
-		_, err = f.CacheCenter.DailyDownloadCountAdd(supplierName,
+		downloadCount, err := f.CacheCenter.DailyDownloadCountAdd(supplierName,
 			pkg.GetPublicIP(f.Log, settings.Get().AdvancedSettings.TaskQueue))
 		if err != nil {
 			f.Log.Warningln(supplierName, "FileDownloader.Get.DailyDownloadCountAdd", err)
+		} else {
+			supplier_health.RecordQuotaUsed(f.Log, supplierName, downloadCount)
 		}
 		// 默认存入都是简体中文的语言类型，后续取出来的时候需要再次调用 SubParser 进行解析
 		inSubInfo := supplier.NewSubInfo(supplierName, topN, title, language.ChineseSimple, fileDownloadUrl, 0, 0, ext, fileData)
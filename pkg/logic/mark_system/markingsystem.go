@@ -1,11 +1,20 @@
 package mark_system
 
 import (
+	"sort"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_scorer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	language2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/vad"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,88 +24,81 @@ type MarkingSystem struct {
 	subSiteSequence []string // 网站的优先级，从高到低
 	SubTypePriority int      // 字幕格式的优先级
 	subParserHub    *sub_parser_hub.SubParserHub
+	scorers         []ifaces.ISubScorer        // 评分项
+	scoreSettings   *settings.SubScoreSettings // 评分项的权重
 }
 
-func NewMarkingSystem(log *logrus.Logger, subSiteSequence []string, subTypePriority int) *MarkingSystem {
+func NewMarkingSystem(log *logrus.Logger, subSiteSequence []string, subTypePriority int, scorers []ifaces.ISubScorer, scoreSettings *settings.SubScoreSettings) *MarkingSystem {
+	if scoreSettings == nil {
+		scoreSettings = settings.NewSubScoreSettings()
+	}
 	mk := MarkingSystem{subSiteSequence: subSiteSequence,
 		log:             log,
 		SubTypePriority: subTypePriority,
-		subParserHub:    sub_parser_hub.NewSubParserHub(log, ass.NewParser(log), srt.NewParser(log))}
+		subParserHub:    sub_parser_hub.NewSubParserHub(log, ass.NewParser(log), srt.NewParser(log)),
+		scorers:         scorers,
+		scoreSettings:   scoreSettings,
+	}
 	return &mk
 }
 
-// SelectOneSubFile 选择最优的一个字幕文件
-func (m MarkingSystem) SelectOneSubFile(organizeSubFiles []string) *subparser.FileInfo {
-	var finalSubFile *subparser.FileInfo
-	subInfoDict := m.parseSubFileInfo(organizeSubFiles)
-	// 优先级别暂定 subSiteSequence: zimuku -> subhd -> xunlei -> shooter
-	// 这里需要循环四轮：
-	// 第一轮，双语、字幕类型自定义，优先
-	// 第二轮，单语言（中文）、字幕类型自定义，优先
-	// 第三轮，双语、字幕类型0，优先
-	// 第四轮，单语言（中文）、字幕类型0，优先
-	for i := 0; i < 4; i++ {
-		for _, subSite := range m.subSiteSequence {
-			infos, ok := subInfoDict[subSite]
-			if ok == false {
-				continue
-			}
-			if i == 0 {
-				finalSubFile = sub_helper.SelectChineseBestBilingualSubtitle(infos, m.SubTypePriority)
-			} else if i == 1 {
-				finalSubFile = sub_helper.SelectChineseBestSubtitle(infos, m.SubTypePriority)
-			} else if i == 2 {
-				finalSubFile = sub_helper.SelectChineseBestBilingualSubtitle(infos, 0)
-			} else if i == 3 {
-				finalSubFile = sub_helper.SelectChineseBestSubtitle(infos, 0)
-			}
-			if finalSubFile != nil {
-				return finalSubFile
-			}
-		}
-	}
-	return nil
+// NewScoreContext 构建一个视频的评分上下文，audioVADInfos 没有开启时间轴匹配评分的时候传 nil 即可
+func (m MarkingSystem) NewScoreContext(videoFPath string, videoDuration float64, audioVADInfos []vad.VADInfo) *sub_score.ScoreContext {
+	return sub_score.NewScoreContext(videoFPath, videoDuration, audioVADInfos, m.SubTypePriority, m.subSiteSequence)
 }
 
-// SelectEachSiteTop1SubFile 每个网站最优的文件
-func (m MarkingSystem) SelectEachSiteTop1SubFile(organizeSubFiles []string) ([]string, []subparser.FileInfo) {
-	// 每个文件都带有出处 [subhd]
-	var finalSubFile *subparser.FileInfo
-	var outSiteName = make([]string, 0)
-	var outSubParserFileInfos = make([]subparser.FileInfo, 0)
-	subInfoDict := m.parseSubFileInfo(organizeSubFiles)
-	// 这里需要循环四轮：
-	// 第一轮，双语、字幕类型自定义，优先
-	// 第二轮，单语言（中文）、字幕类型自定义，优先
-	// 第三轮，双语、字幕类型0，优先
-	// 第四轮，单语言（中文）、字幕类型0，优先
-	for siteName, infos := range subInfoDict {
-		// 每个网站保存一个
-		for i := 0; i < 4; i++ {
-			if i == 0 {
-				finalSubFile = sub_helper.SelectChineseBestBilingualSubtitle(infos, m.SubTypePriority)
-			} else if i == 1 {
-				finalSubFile = sub_helper.SelectChineseBestSubtitle(infos, m.SubTypePriority)
-			} else if i == 2 {
-				finalSubFile = sub_helper.SelectChineseBestBilingualSubtitle(infos, 0)
-			} else if i == 3 {
-				finalSubFile = sub_helper.SelectChineseBestSubtitle(infos, 0)
-			}
-			if finalSubFile != nil {
-				outSiteName = append(outSiteName, siteName)
-				outSubParserFileInfos = append(outSubParserFileInfos, *finalSubFile)
-				break
-			}
+// SelectOneSubFile 选择评分最高的一个字幕文件，forced 字幕不会被选为主字幕
+func (m MarkingSystem) SelectOneSubFile(organizeSubFiles []string, scoreContext *sub_score.ScoreContext) *subparser.FileInfo {
+	return m.ScoreAll(organizeSubFiles, scoreContext).BestOne()
+}
+
+// SelectOneForcedSubFile 选择评分最高的一个 forced 字幕文件，没有则返回 nil
+func (m MarkingSystem) SelectOneForcedSubFile(organizeSubFiles []string, scoreContext *sub_score.ScoreContext) *subparser.FileInfo {
+	return m.ScoreAll(organizeSubFiles, scoreContext).BestForced()
+}
+
+// ScoreAll 给所有的字幕文件评分，之后的选择都在这个结果上进行，这样每个字幕只需要评分一次，也能保存所有候选字幕的评分
+func (m MarkingSystem) ScoreAll(organizeSubFiles []string, scoreContext *sub_score.ScoreContext) *ScoredSubFiles {
+
+	subInfos, forcedSubInfos, referenceSubInfos := m.parseSubFileInfo(organizeSubFiles, scoreContext, true)
+	return &ScoredSubFiles{SubInfos: subInfos, ForcedSubInfos: forcedSubInfos, ReferenceSubInfos: referenceSubInfos}
+}
+
+// SelectCombinedSubFiles 多集合并的视频，找出覆盖了所有集数的“合并”字幕，返回的是字幕文件的全路径，按评分从高到低排序
+func (m MarkingSystem) SelectCombinedSubFiles(organizeSubFiles []string, scoreContext *sub_score.ScoreContext, season, episodeStart, episodeEnd int) []string {
+
+	combinedSubFiles := make([]string, 0)
+	subInfos, _, _ := m.parseSubFileInfo(organizeSubFiles, scoreContext, true)
+	for i := range subInfos {
+		if sub_helper.IsCombinedMultiEpisodeSub(&subInfos[i], season, episodeStart, episodeEnd, scoreContext.VideoDuration) == true {
+			combinedSubFiles = append(combinedSubFiles, subInfos[i].FileFullPath)
 		}
 	}
+	return combinedSubFiles
+}
 
-	return outSiteName, outSubParserFileInfos
+// SelectEachSiteTop1SubFile 每个网站评分最高的文件，forced 字幕不参与选择，返回的顺序是按评分从高到低
+func (m MarkingSystem) SelectEachSiteTop1SubFile(organizeSubFiles []string, scoreContext *sub_score.ScoreContext) ([]string, []subparser.FileInfo) {
+	return m.ScoreAll(organizeSubFiles, scoreContext).EachSiteTop1()
 }
 
-// parseSubFileInfo 从文件解析字幕信息
-func (m MarkingSystem) parseSubFileInfo(organizeSubFiles []string) map[string][]subparser.FileInfo {
-	// 一个网站可能就算取了 Top1 字幕，也可能是返回一个压缩包，然后解压完就是多个字幕，所以
-	var subInfoDict = make(map[string][]subparser.FileInfo)
+// ScoreSubFiles 给所有的字幕文件评分，正常的字幕在前，forced 字幕在后，各自按评分从高到低排序，给手动挑选字幕的时候展示使用，不会过滤掉非中文的字幕
+func (m MarkingSystem) ScoreSubFiles(organizeSubFiles []string, scoreContext *sub_score.ScoreContext) []subparser.FileInfo {
+
+	subInfos, forcedSubInfos, _ := m.parseSubFileInfo(organizeSubFiles, scoreContext, false)
+	return ScoredSubFiles{SubInfos: subInfos, ForcedSubInfos: forcedSubInfos}.All()
+}
+
+/*
+	parseSubFileInfo 从文件解析字幕信息并评分，返回的是正常的字幕、forced 字幕以及参考字幕
+	1. onlyChinese 为 true 的时候，只有中文字幕才会参与评分，识别出语言的非中文字幕不评分，作为参考字幕返回
+	2. 正常的字幕、forced 字幕都是按评分从高到低排序的，评分相同的时候，按网站的优先级排序
+*/
+func (m MarkingSystem) parseSubFileInfo(organizeSubFiles []string, scoreContext *sub_score.ScoreContext, onlyChinese bool) ([]subparser.FileInfo, []subparser.FileInfo, []subparser.FileInfo) {
+
+	var subInfos = make([]subparser.FileInfo, 0)
+	var forcedSubInfos = make([]subparser.FileInfo, 0)
+	var referenceSubInfos = make([]subparser.FileInfo, 0)
 	// 拿到现有的字幕列表，开始抉择
 	// 先判断当前字幕是什么语言（如果是简体，还需要考虑，判断这个字幕是简体还是繁体）
 	for _, oneSubFileFullPath := range organizeSubFiles {
@@ -109,13 +111,111 @@ func (m MarkingSystem) parseSubFileInfo(organizeSubFiles []string) map[string][]
 			m.log.Warnln("DetermineFileTypeFromFile", oneSubFileFullPath, "not support SubType")
 			continue
 		}
-		_, ok := subInfoDict[subFileInfo.FromWhereSite]
+		if onlyChinese == true && language.HasChineseLang(subFileInfo.Lang) == false {
+			if subFileInfo.Lang != language2.Unknown {
+				m.log.Debugln("DetermineFileTypeFromFile", oneSubFileFullPath, "not Chinese sub, as reference")
+				referenceSubInfos = append(referenceSubInfos, *subFileInfo)
+			} else {
+				m.log.Debugln("DetermineFileTypeFromFile", oneSubFileFullPath, "unknown language, skip")
+			}
+			continue
+		}
+		subFileInfo.ScoreBreakdown = sub_scorer.Calculate(m.scorers, m.scoreSettings, scoreContext, subFileInfo)
+		// forced 字幕单独存放，不能参与主字幕的选择
+		subFileInfo.IsForced = sub_helper.IsForcedSubtitle(subFileInfo, scoreContext.VideoDuration)
+		if subFileInfo.IsForced == true {
+			m.log.Infoln("Score:", subFileInfo.ScoreBreakdown.String(), "(forced)", oneSubFileFullPath)
+			forcedSubInfos = append(forcedSubInfos, *subFileInfo)
+		} else {
+			m.log.Infoln("Score:", subFileInfo.ScoreBreakdown.String(), oneSubFileFullPath)
+			subInfos = append(subInfos, *subFileInfo)
+		}
+	}
+	m.sortByScore(subInfos)
+	m.sortByScore(forcedSubInfos)
+
+	return subInfos, forcedSubInfos, referenceSubInfos
+}
+
+// sortByScore 按评分从高到低排序，评分相同的时候，按网站的优先级排序
+func (m MarkingSystem) sortByScore(subInfos []subparser.FileInfo) {
+
+	siteIndex := make(map[string]int)
+	for i, site := range m.subSiteSequence {
+		siteIndex[site] = i
+	}
+	getSiteIndex := func(site string) int {
+		index, ok := siteIndex[site]
 		if ok == false {
-			// 新建
-			subInfoDict[subFileInfo.FromWhereSite] = make([]subparser.FileInfo, 0)
+			return len(m.subSiteSequence)
+		}
+		return index
+	}
+	sort.SliceStable(subInfos, func(i, j int) bool {
+		if subInfos[i].GetScore() != subInfos[j].GetScore() {
+			return subInfos[i].GetScore() > subInfos[j].GetScore()
 		}
-		// 添加
-		subInfoDict[subFileInfo.FromWhereSite] = append(subInfoDict[subFileInfo.FromWhereSite], *subFileInfo)
+		return getSiteIndex(subInfos[i].FromWhereSite) < getSiteIndex(subInfos[j].FromWhereSite)
+	})
+}
+
+// ScoredSubFiles 一个视频所有字幕的评分结果，正常的字幕、forced 字幕各自按评分从高到低排序
+type ScoredSubFiles struct {
+	SubInfos          []subparser.FileInfo
+	ForcedSubInfos    []subparser.FileInfo
+	ReferenceSubInfos []subparser.FileInfo // 非中文的字幕，没有评分，作为双语合并的参考
+}
+
+// BestOne 评分最高的一个字幕，forced 字幕不会被选为主字幕
+func (s ScoredSubFiles) BestOne() *subparser.FileInfo {
+	if len(s.SubInfos) < 1 {
+		return nil
 	}
-	return subInfoDict
+	return &s.SubInfos[0]
+}
+
+// BestForced 评分最高的一个 forced 字幕，没有则返回 nil
+func (s ScoredSubFiles) BestForced() *subparser.FileInfo {
+	if len(s.ForcedSubInfos) < 1 {
+		return nil
+	}
+	return &s.ForcedSubInfos[0]
+}
+
+// BestReference 参考字幕，优先使用英文的，没有则返回 nil
+func (s ScoredSubFiles) BestReference() *subparser.FileInfo {
+	if len(s.ReferenceSubInfos) < 1 {
+		return nil
+	}
+	for i := range s.ReferenceSubInfos {
+		if s.ReferenceSubInfos[i].Lang == language2.English {
+			return &s.ReferenceSubInfos[i]
+		}
+	}
+	return &s.ReferenceSubInfos[0]
+}
+
+// EachSiteTop1 每个网站评分最高的字幕，返回的顺序是按评分从高到低，所以第一个就是评分最高的
+func (s ScoredSubFiles) EachSiteTop1() ([]string, []subparser.FileInfo) {
+	// 每个文件都带有出处 [subhd]
+	var outSiteName = make([]string, 0)
+	var outSubParserFileInfos = make([]subparser.FileInfo, 0)
+	// 已经是按评分排序的了，每个网站取第一个即可
+	siteDone := make(map[string]bool)
+	for _, info := range s.SubInfos {
+		if siteDone[info.FromWhereSite] == true {
+			continue
+		}
+		siteDone[info.FromWhereSite] = true
+		outSiteName = append(outSiteName, info.FromWhereSite)
+		outSubParserFileInfos = append(outSubParserFileInfos, info)
+	}
+	return outSiteName, outSubParserFileInfos
+}
+
+// All 所有的字幕，正常的字幕在前，forced 字幕在后
+func (s ScoredSubFiles) All() []subparser.FileInfo {
+	all := make([]subparser.FileInfo, 0, len(s.SubInfos)+len(s.ForcedSubInfos))
+	all = append(all, s.SubInfos...)
+	return append(all, s.ForcedSubInfos...)
 }
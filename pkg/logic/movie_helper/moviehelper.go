@@ -9,7 +9,10 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/media_info_dealers"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
@@ -19,6 +22,7 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/imdb_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
 	"github.com/jinzhu/now"
 	"github.com/sirupsen/logrus"
 )
@@ -36,17 +40,31 @@ func OneMovieDlSubInAllSite(logger *logrus.Logger, Suppliers []ifaces.ISupplier,
 
 		logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), oneVideoFullPath)
 
+		if supplier_health.IsDisabled(oneSupplier.GetSupplierName()) == true {
+			logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Disabled By Too Many Failures")
+			continue
+		}
+
 		if oneSupplier.OverDailyDownloadLimit() == true {
 			logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Over Daily Download Limit")
+			notify_center.Publish(notify.NewEvent(notify.DailyQuotaReached, oneSupplier.GetSupplierName(), map[string]string{
+				"supplier": oneSupplier.GetSupplierName(),
+			}).SetOnceADay(oneSupplier.GetSupplierName()))
 			continue
 		}
 
+		startT := time.Now()
 		subInfos, err := OneMovieDlSubInOneSite(logger, oneVideoFullPath, i, oneSupplier)
+		supplier_health.RecordQuery(logger, oneSupplier.GetSupplierName(), subInfos, err, time.Since(startT))
 		if err != nil {
 			logger.Errorln(common.QueueName, i, oneSupplier.GetSupplierName(), "oneMovieDlSubInOneSite", err)
 			continue
 		}
 		outSUbInfos = append(outSUbInfos, subInfos...)
+		if skipOthersByPeerLibrary(oneSupplier, subInfos) == true {
+			logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Found Subs, Skip Other Suppliers")
+			break
+		}
 	}
 
 	for index, info := range outSUbInfos {
@@ -56,6 +74,15 @@ func OneMovieDlSubInAllSite(logger *logrus.Logger, Suppliers []ifaces.ISupplier,
 	return outSUbInfos
 }
 
+// skipOthersByPeerLibrary 局域网内其他实例的字幕缓存中找到了字幕，是否不再去其他字幕源搜索
+func skipOthersByPeerLibrary(oneSupplier ifaces.ISupplier, subInfos []supplier.SubInfo) bool {
+
+	if oneSupplier.GetSupplierName() != common.SubSitePeerLibrary || len(subInfos) < 1 {
+		return false
+	}
+	return settings.Get().AdvancedSettings.PeerLibrarySettings.SkipOthersWhenFound
+}
+
 // OneMovieDlSubInOneSite 一部电影在一个站点下载字幕
 func OneMovieDlSubInOneSite(logger *logrus.Logger, oneVideoFullPath string, i int64, supplier ifaces.ISupplier) ([]supplier.SubInfo, error) {
 	defer func() {
@@ -15,6 +15,10 @@ import (
 	subSupplier "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/a4k"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/assrt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/custom"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/local_folder"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/opensubtitles"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/peer_library"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/shooter"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_supplier/xunlei"
 
@@ -123,6 +127,28 @@ func (p *PreDownloadProcess) Init() *PreDownloadProcess {
 			p.SubSupplierHub.AddSubSupplier(subtitle_best.NewSupplier(p.fileDownloader))
 		}
 
+		if settings.Get().SubtitleSources.OpenSubtitlesSettings.Enabled == true &&
+			settings.Get().SubtitleSources.OpenSubtitlesSettings.ApiKey != "" {
+			// 如果开启了 OpenSubtitles 字幕源，则需要新增
+			p.SubSupplierHub.AddSubSupplier(opensubtitles.NewSupplier(p.fileDownloader))
+		}
+
+		for _, warning := range settings.Get().SubtitleSources.CustomSupplierWarnings() {
+			p.log.Warningln("PreDownloadProcess.Init()", warning)
+		}
+		for _, customSettings := range settings.Get().SubtitleSources.CustomSuppliers {
+			if customSettings.Enabled == true {
+				// 由设置描述的字幕源，开启了的才需要新增
+				p.SubSupplierHub.AddSubSupplier(custom.NewSupplier(p.fileDownloader, customSettings))
+			}
+		}
+
+		if settings.Get().SubtitleSources.LocalFolderSettings.Enabled == true &&
+			len(settings.Get().SubtitleSources.LocalFolderSettings.FolderPaths) > 0 {
+			// 如果开启了本地字幕库，则需要新增
+			p.SubSupplierHub.AddSubSupplier(local_folder.NewSupplier(p.fileDownloader))
+		}
+
 		if pkg.LiteMode() == false {
 			// 如果不是 Lite 模式，那么就可以开启这个功能
 			if common2.SubhdCode != "" {
@@ -131,6 +157,11 @@ func (p *PreDownloadProcess) Init() *PreDownloadProcess {
 			}
 		}
 	}
+	peerLibrarySettings := settings.Get().AdvancedSettings.PeerLibrarySettings
+	if peerLibrarySettings.PeerEnabled == true && len(peerLibrarySettings.Peers) > 0 {
+		// 局域网内其他实例的字幕缓存，最先去搜索
+		p.SubSupplierHub.AddSubSupplierFirst(peer_library.NewSupplier(p.fileDownloader))
+	}
 	// ------------------------------------------------------------------------
 	// 清理自定义的 rod 缓存目录
 	err := pkg.ClearRodTmpRootFolder()
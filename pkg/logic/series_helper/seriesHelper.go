@@ -11,7 +11,10 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/emby"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
@@ -20,10 +23,12 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/imdb_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
 	"github.com/emirpasic/gods/maps/treemap"
 	"github.com/jinzhu/now"
 	"github.com/sirupsen/logrus"
@@ -127,6 +132,7 @@ func ReadSeriesInfoFromDir(dealers *media_info_dealers.Dealers,
 		seriesInfo.EpList = append(seriesInfo.EpList, episodeInfo)
 		seriesInfo.SeasonDict[episodeInfo.Season] = episodeInfo.Season
 	}
+	fillAnimeEpisodeInfo(dealers, seriesInfo)
 
 	seriesInfo.NeedDlEpsKeyList, seriesInfo.NeedDlSeasonDict = whichSeasonEpsNeedDownloadSub(dealers.Logger, seriesInfo, ExpirationTime, forcedScanAndDownloadSub)
 
@@ -150,12 +156,48 @@ func ReadSeriesInfoFromEmby(dealers *media_info_dealers.Dealers, seriesDir strin
 		seriesInfo.EpList = append(seriesInfo.EpList, episodeInfo)
 		seriesInfo.SeasonDict[episodeInfo.Season] = episodeInfo.Season
 	}
+	fillAnimeEpisodeInfo(dealers, seriesInfo)
 
 	seriesInfo.NeedDlEpsKeyList, seriesInfo.NeedDlSeasonDict = whichSeasonEpsNeedDownloadSub(dealers.Logger, seriesInfo, ExpirationTime, forcedScanAndDownloadSub)
 
 	return seriesInfo, nil
 }
 
+/*
+	fillAnimeEpisodeInfo 动画需要绝对集数与 季、集 的对应关系
+	1. 文件名是绝对集数命名的，也认为是动画
+	2. 优先从 TMDB 的剧集组获取，获取不到的时候，仅使用 nfo 以及文件名中得到的绝对集数
+*/
+func fillAnimeEpisodeInfo(dealers *media_info_dealers.Dealers, seriesInfo *series.SeriesInfo) {
+
+	if seriesInfo.IsAnime == false {
+		for _, episodeInfo := range seriesInfo.EpList {
+			if episodeInfo.AbsoluteEpisode > 0 {
+				seriesInfo.IsAnime = true
+				break
+			}
+		}
+	}
+	if seriesInfo.IsAnime == false || seriesInfo.TmdbId == "" {
+		return
+	}
+	absoluteEpisodeMap, err := dealers.GetAbsoluteEpisodeMap(seriesInfo.TmdbId)
+	if err != nil {
+		dealers.Logger.Debugln("fillAnimeEpisodeInfo.GetAbsoluteEpisodeMap", seriesInfo.Name, err)
+		return
+	}
+	seriesInfo.AbsoluteEpisodeMap = absoluteEpisodeMap
+	for i, episodeInfo := range seriesInfo.EpList {
+		if episodeInfo.AbsoluteEpisode > 0 {
+			continue
+		}
+		absolute, ok := absoluteEpisodeMap.GetAbsolute(episodeInfo.Season, episodeInfo.Episode)
+		if ok == true {
+			seriesInfo.EpList[i].AbsoluteEpisode = absolute
+		}
+	}
+}
+
 // SkipChineseSeries 跳过中文连续剧
 func SkipChineseSeries(dealers *media_info_dealers.Dealers, seriesRootPath string) (bool, *models.IMDBInfo, error) {
 
@@ -195,6 +237,7 @@ func DownloadSubtitleInAllSiteByOneSeries(logger *logrus.Logger, Suppliers []ifa
 
 	for _, oneSupplier := range Suppliers {
 
+		skipOthers := false
 		oneSupplierFunc := func() {
 			defer func() {
 				logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "End")
@@ -205,29 +248,68 @@ func DownloadSubtitleInAllSiteByOneSeries(logger *logrus.Logger, Suppliers []ifa
 			logger.Infoln("------------------------------------------")
 			logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Start...")
 
+			if supplier_health.IsDisabled(oneSupplier.GetSupplierName()) == true {
+				logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Disabled By Too Many Failures")
+				return
+			}
+
 			if oneSupplier.OverDailyDownloadLimit() == true {
 				logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Over Daily Download Limit")
+				notify_center.Publish(notify.NewEvent(notify.DailyQuotaReached, oneSupplier.GetSupplierName(), map[string]string{
+					"supplier": oneSupplier.GetSupplierName(),
+				}).SetOnceADay(oneSupplier.GetSupplierName()))
 				return
 			}
 
-			// 一次性把这一部连续剧的所有字幕下载完
-			subInfos, err := oneSupplier.GetSubListFromFile4Series(seriesInfo)
+			// 一次性把这一部连续剧的所有字幕下载完，动画需要额外考虑绝对集数
+			var err error
+			startT := time.Now()
+			if seriesInfo.IsAnime == true {
+				subInfos, err = oneSupplier.GetSubListFromFile4Anime(seriesInfo)
+			} else {
+				subInfos, err = oneSupplier.GetSubListFromFile4Series(seriesInfo)
+			}
+			supplier_health.RecordQuery(logger, oneSupplier.GetSupplierName(), subInfos, err, time.Since(startT))
 			if err != nil {
-				logger.Errorln(common.QueueName, i, oneSupplier.GetSupplierName(), "GetSubListFromFile4Series", err)
+				logger.Errorln(common.QueueName, i, oneSupplier.GetSupplierName(), "GetSubListFromFile4Series", "IsAnime:", seriesInfo.IsAnime, err)
 				return
 			}
 			// 把后缀名给改好
 			sub_helper.ChangeVideoExt2SubExt(subInfos)
 
 			outSUbInfos = append(outSUbInfos, subInfos...)
+			skipOthers = skipOthersByPeerLibrary(oneSupplier, seriesInfo, subInfos)
 		}
 
 		oneSupplierFunc()
+		if skipOthers == true {
+			logger.Infoln(common.QueueName, i, oneSupplier.GetSupplierName(), "Found Subs For All Eps, Skip Other Suppliers")
+			break
+		}
 	}
 
 	return outSUbInfos
 }
 
+// skipOthersByPeerLibrary 局域网内其他实例的字幕缓存中找到了所有需要下载的集的字幕，是否不再去其他字幕源搜索
+func skipOthersByPeerLibrary(oneSupplier ifaces.ISupplier, seriesInfo *series.SeriesInfo, subInfos []supplier.SubInfo) bool {
+
+	if oneSupplier.GetSupplierName() != common.SubSitePeerLibrary ||
+		settings.Get().AdvancedSettings.PeerLibrarySettings.SkipOthersWhenFound == false {
+		return false
+	}
+	foundEps := make(map[string]bool)
+	for _, subInfo := range subInfos {
+		foundEps[strconv.Itoa(subInfo.Season)+"-"+strconv.Itoa(subInfo.Episode)] = true
+	}
+	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
+		if foundEps[strconv.Itoa(episodeInfo.Season)+"-"+strconv.Itoa(episodeInfo.Episode)] == false {
+			return false
+		}
+	}
+	return true
+}
+
 // GetSeriesListFromDirs 获取这个目录下的所有文件夹名称，默认为一个连续剧的目录的List
 func GetSeriesListFromDirs(logger *logrus.Logger, dirs []string) (*treemap.Map, error) {
 
@@ -285,8 +367,7 @@ func whichSeasonEpsNeedDownloadSub(logger *logrus.Logger, seriesInfo *series.Ser
 	if forcedScanAndDownloadSub == true {
 		for _, epsInfo := range seriesInfo.EpList {
 			// 添加
-			epsKey := pkg.GetEpisodeKeyName(epsInfo.Season, epsInfo.Episode)
-			needDlSubEpsList[epsKey] = epsInfo
+			addNeedDlEps(needDlSubEpsList, epsInfo)
 			needDlSeasonList[epsInfo.Season] = epsInfo.Season
 		}
 
@@ -311,8 +392,7 @@ func whichSeasonEpsNeedDownloadSub(logger *logrus.Logger, seriesInfo *series.Ser
 
 		if len(epsInfo.SubAlreadyDownloadedList) < 1 || baseTime.AddDate(0, 0, ExpirationTime).After(currentTime) == true {
 			// 添加
-			epsKey := pkg.GetEpisodeKeyName(epsInfo.Season, epsInfo.Episode)
-			needDlSubEpsList[epsKey] = epsInfo
+			addNeedDlEps(needDlSubEpsList, epsInfo)
 			needDlSeasonList[epsInfo.Season] = epsInfo.Season
 		} else {
 			if len(epsInfo.SubAlreadyDownloadedList) > 0 {
@@ -325,6 +405,16 @@ func whichSeasonEpsNeedDownloadSub(logger *logrus.Logger, seriesInfo *series.Ser
 	return needDlSubEpsList, needDlSeasonList
 }
 
+// addNeedDlEps 添加需要下载字幕的一集，多集合并的视频，每一集都需要添加，字幕网站是按一集去搜索的
+func addNeedDlEps(needDlSubEpsList map[string]series.EpisodeInfo, epsInfo series.EpisodeInfo) {
+
+	for _, episode := range epsInfo.GetEpisodes() {
+		oneEpsInfo := epsInfo
+		oneEpsInfo.Episode = episode
+		needDlSubEpsList[pkg.GetEpisodeKeyName(epsInfo.Season, episode)] = oneEpsInfo
+	}
+}
+
 func GetSeriesInfoFromDir(dealers *media_info_dealers.Dealers, seriesDir string) (*series.SeriesInfo, error) {
 	seriesInfo := series.SeriesInfo{}
 	// 只考虑 IMDB 去查询，文件名目前发现可能会跟电影重复，导致很麻烦，本来也有前置要求要削刮器处理的
@@ -369,6 +459,8 @@ func GetSeriesInfoFromDir(dealers *media_info_dealers.Dealers, seriesDir string)
 	}
 
 	seriesInfo.ReleaseDate = videoInfo.ReleaseDate
+	seriesInfo.TmdbId = videoInfo.TmdbId
+	seriesInfo.IsAnime = anime_helper.IsAnimeGenres(videoInfo.Genres)
 	seriesInfo.DirPath = seriesDir
 	seriesInfo.EpList = make([]series.EpisodeInfo, 0)
 	seriesInfo.SeasonDict = make(map[int]int)
@@ -387,6 +479,22 @@ func getEpsInfoAndSubDic(logger *logrus.Logger,
 		return
 	}
 
+	// 多集合并的视频（S01E01E02），nfo 中读取到的是第一集的信息，最后一集从文件名推断
+	episodeEnd := 0
+	found, rangeSeason, rangeEpisodeStart, rangeEpisodeEnd := decode.GetEpisodeRangeFromFileName(videoFile)
+	if found == true && rangeSeason == episodeInfo.Season && rangeEpisodeStart == episodeInfo.Episode {
+		episodeEnd = rangeEpisodeEnd
+	}
+
+	// 动画的绝对集数，nfo 中没有的话，从 [Group] Title - 137.mkv 这样的文件名推断
+	absoluteEpisode := episodeInfo.AbsoluteEpisode
+	if absoluteEpisode <= 0 {
+		animeNameInfo, found := anime_helper.ParseAnimeFileName(videoFile)
+		if found == true && animeNameInfo.IsAbsolute == true {
+			absoluteEpisode = animeNameInfo.Episode
+		}
+	}
+
 	if len(epsMap) > 0 {
 		// 如果这个视频不在需要下载的 Eps 列表中，那么就跳过后续的逻辑
 		epsList, ok := epsMap[0][episodeInfo.Season]
@@ -395,7 +503,7 @@ func getEpsInfoAndSubDic(logger *logrus.Logger,
 		}
 		found := false
 		for _, oneEpsID := range epsList {
-			if oneEpsID == episodeInfo.Episode {
+			if oneEpsID == episodeInfo.Episode || (oneEpsID > episodeInfo.Episode && oneEpsID <= episodeEnd) {
 				// 在需要下载的 Eps 列表中
 				found = true
 				break
@@ -411,13 +519,15 @@ func getEpsInfoAndSubDic(logger *logrus.Logger,
 	if ok == false {
 		// 初始化
 		oneFileEpInfo := series.EpisodeInfo{
-			Title:        episodeInfo.Title,
-			Season:       episodeInfo.Season,
-			Episode:      episodeInfo.Episode,
-			Dir:          filepath.Dir(videoFile),
-			FileFullPath: videoFile,
-			ModifyTime:   modifyTime,
-			AiredTime:    episodeInfo.ReleaseDate,
+			Title:           episodeInfo.Title,
+			Season:          episodeInfo.Season,
+			Episode:         episodeInfo.Episode,
+			EpisodeEnd:      episodeEnd,
+			AbsoluteEpisode: absoluteEpisode,
+			Dir:             filepath.Dir(videoFile),
+			FileFullPath:    videoFile,
+			ModifyTime:      modifyTime,
+			AiredTime:       episodeInfo.ReleaseDate,
 		}
 		// 需要匹配同级目录下的字幕
 		oneFileEpInfo.SubAlreadyDownloadedList = make([]series.SubInfo, 0)
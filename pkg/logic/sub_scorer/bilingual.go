@@ -0,0 +1,35 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// BilingualScorer 中文双语字幕优先，然后是中文字幕
+type BilingualScorer struct {
+}
+
+func NewBilingualScorer() *BilingualScorer {
+	return &BilingualScorer{}
+}
+
+func (b BilingualScorer) GetScorerName() string {
+	return ScorerNameBilingual
+}
+
+func (b BilingualScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.BilingualWeight
+}
+
+func (b BilingualScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if language.HasChineseLang(fileInfo.Lang) == false {
+		return 0, true
+	}
+	if language.IsBilingualSubtitle(fileInfo.Lang) == true {
+		return 1, true
+	}
+	return 0.5, true
+}
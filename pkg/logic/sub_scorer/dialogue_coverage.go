@@ -0,0 +1,51 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+/*
+DialogueCoverageScorer 字幕对白覆盖的时长与视频时长的匹配程度
+1. 字幕的最后一句对白的结束时间，超过了视频的时长，那么多半是其他版本（加长版、导演剪辑版）的字幕
+2. 字幕的最后一句对白的结束时间，远小于视频的时长，那么可能是不完整的字幕
+*/
+type DialogueCoverageScorer struct {
+}
+
+func NewDialogueCoverageScorer() *DialogueCoverageScorer {
+	return &DialogueCoverageScorer{}
+}
+
+func (d DialogueCoverageScorer) GetScorerName() string {
+	return ScorerNameDialogueCoverage
+}
+
+func (d DialogueCoverageScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.DialogueCoverageWeight
+}
+
+func (d DialogueCoverageScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if scoreContext.VideoDuration <= 0 || len(fileInfo.Dialogues) < 1 {
+		return 0, false
+	}
+	subEndTime := pkg.Time2SecondNumber(fileInfo.GetEndTime())
+	per := subEndTime / scoreContext.VideoDuration
+	if per > coverageMaxPer {
+		// 超出视频的时长，超出越多越不可信
+		return 1 - (per-coverageMaxPer)*coverageOverflowPenalty, true
+	}
+	if per >= coverageGoodPer {
+		return 1, true
+	}
+	return per / coverageGoodPer, true
+}
+
+const (
+	coverageMaxPer          = 1.02 // 允许字幕的结束时间比视频的时长稍微多一点
+	coverageGoodPer         = 0.85 // 片尾字幕的部分一般不会有对白
+	coverageOverflowPenalty = 5.0  // 超出 20% 就是 0 分了
+)
@@ -0,0 +1,64 @@
+package sub_scorer
+
+import (
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// EncodingScorer 字幕是否存在乱码，比如编码转换失败的替换字符，或者 GBK 与 UTF-8 混淆后出现的 锟斤拷
+type EncodingScorer struct {
+}
+
+func NewEncodingScorer() *EncodingScorer {
+	return &EncodingScorer{}
+}
+
+func (e EncodingScorer) GetScorerName() string {
+	return ScorerNameEncoding
+}
+
+func (e EncodingScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.EncodingWeight
+}
+
+func (e EncodingScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	allLines := 0
+	badLines := 0
+	for _, dialogue := range fileInfo.Dialogues {
+		for _, line := range dialogue.Lines {
+			if line == "" {
+				continue
+			}
+			allLines++
+			if IsGarbledLine(line) == true {
+				badLines++
+			}
+		}
+	}
+	if allLines < 1 {
+		return 0, false
+	}
+	// 有 10% 的对白是乱码就是 0 分了
+	return 1 - float64(badLines)/float64(allLines)*10, true
+}
+
+// IsGarbledLine 这一句对白是否是乱码
+func IsGarbledLine(line string) bool {
+	for _, keyWord := range garbledKeyWords {
+		if strings.Contains(line, keyWord) == true {
+			return true
+		}
+	}
+	return false
+}
+
+var garbledKeyWords = []string{
+	"�",   // 编码转换失败的替换字符
+	"锟斤拷", // UTF-8 的替换字符被当做 GBK 解码
+	"烫烫烫",
+	"Ã©", "Ã¨", "â€", // UTF-8 被当做 Latin-1 解码
+}
@@ -0,0 +1,50 @@
+package sub_scorer
+
+import (
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// FormatScorer 字幕格式是否符合 SubTypePriority 的设置
+type FormatScorer struct {
+}
+
+func NewFormatScorer() *FormatScorer {
+	return &FormatScorer{}
+}
+
+func (f FormatScorer) GetScorerName() string {
+	return ScorerNameFormat
+}
+
+func (f FormatScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.FormatWeight
+}
+
+func (f FormatScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	nowExt := strings.ToLower(fileInfo.Ext)
+	isASS := nowExt == common.SubExtASS || nowExt == common.SubExtSSA
+	// 字幕的优先级 0 - 原样, 1 - srt , 2 - ass/ssa
+	switch scoreContext.SubTypePriority {
+	case 1:
+		if nowExt == common.SubExtSRT {
+			return 1, true
+		}
+		return formatNotPreferredScore, true
+	case 2:
+		if isASS == true {
+			return 1, true
+		}
+		return formatNotPreferredScore, true
+	default:
+		// 没有偏好，那么这一项不参与评分
+		return 0, false
+	}
+}
+
+const formatNotPreferredScore = 0.3
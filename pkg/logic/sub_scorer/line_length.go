@@ -0,0 +1,51 @@
+package sub_scorer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// LineLengthScorer 每一句对白的长度是否正常，过长的对白一般是解析出错、或者把多句对白合并了
+type LineLengthScorer struct {
+}
+
+func NewLineLengthScorer() *LineLengthScorer {
+	return &LineLengthScorer{}
+}
+
+func (l LineLengthScorer) GetScorerName() string {
+	return ScorerNameLineLength
+}
+
+func (l LineLengthScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.LineLengthWeight
+}
+
+func (l LineLengthScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	allLines := 0
+	badLines := 0
+	for _, dialogue := range fileInfo.DialoguesFilter {
+		for _, line := range dialogue.Lines {
+			nowLen := utf8.RuneCountInString(strings.TrimSpace(line))
+			if nowLen < 1 {
+				continue
+			}
+			allLines++
+			if nowLen > lineMaxRuneCount {
+				badLines++
+			}
+		}
+	}
+	if allLines < 1 {
+		return 0, false
+	}
+	// 有 20% 的对白过长就是 0 分了
+	return 1 - float64(badLines)/float64(allLines)*5, true
+}
+
+const lineMaxRuneCount = 100 // 一句对白中一种语言的最大字数
@@ -0,0 +1,35 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/release_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// ReleaseMatchScorer 字幕与视频的发布组、片源、版本是否一致，WEB-DL 的字幕用在 BluRay 的视频上，时间轴往往是对不上的
+type ReleaseMatchScorer struct {
+}
+
+func NewReleaseMatchScorer() *ReleaseMatchScorer {
+	return &ReleaseMatchScorer{}
+}
+
+func (r ReleaseMatchScorer) GetScorerName() string {
+	return ScorerNameReleaseMatch
+}
+
+func (r ReleaseMatchScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.ReleaseMatchWeight
+}
+
+func (r ReleaseMatchScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if scoreContext.VideoFPath == "" || fileInfo.Name == "" {
+		return 0, false
+	}
+	return release_helper.MatchScore(
+		release_helper.GetReleaseInfoFromFileName(scoreContext.VideoFPath),
+		release_helper.GetReleaseInfoFromFileName(fileInfo.Name),
+	)
+}
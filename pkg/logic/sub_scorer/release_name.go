@@ -0,0 +1,75 @@
+package sub_scorer
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// ReleaseNameScorer 字幕名称与视频文件名的相似度，视频文件名中的关键词有多少出现在字幕名称中
+type ReleaseNameScorer struct {
+}
+
+func NewReleaseNameScorer() *ReleaseNameScorer {
+	return &ReleaseNameScorer{}
+}
+
+func (r ReleaseNameScorer) GetScorerName() string {
+	return ScorerNameReleaseName
+}
+
+func (r ReleaseNameScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.ReleaseNameWeight
+}
+
+func (r ReleaseNameScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if scoreContext.VideoFPath == "" || fileInfo.Name == "" {
+		return 0, false
+	}
+	videoTokens := NameTokens(filepath.Base(scoreContext.VideoFPath))
+	if len(videoTokens) < 1 {
+		return 0, false
+	}
+	// 下载的字幕会带有 [site]_0_ 这样的前缀
+	subName := reMatchSiteFrontName.ReplaceAllString(filepath.Base(fileInfo.Name), "")
+	subTokens := NameTokens(subName)
+	subTokenMap := make(map[string]bool)
+	for _, token := range subTokens {
+		subTokenMap[token] = true
+	}
+	matched := 0
+	for _, token := range videoTokens {
+		if subTokenMap[token] == true {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(len(videoTokens)), true
+}
+
+// NameTokens 把文件名（去除后缀名）按照非字母数字的字符切分为小写的关键词，会去重
+func NameTokens(fileName string) []string {
+
+	nameWithOutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	fields := strings.FieldsFunc(strings.ToLower(nameWithOutExt), func(r rune) bool {
+		return unicode.IsLetter(r) == false && unicode.IsNumber(r) == false
+	})
+	tokens := make([]string, 0)
+	tokenMap := make(map[string]bool)
+	for _, field := range fields {
+		if tokenMap[field] == true {
+			continue
+		}
+		tokenMap[field] = true
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+var reMatchSiteFrontName = regexp.MustCompile(`^\[\w+\]_\d+_`)
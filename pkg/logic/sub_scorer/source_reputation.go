@@ -0,0 +1,36 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// SourceReputationScorer 字幕来源网站的信誉，按照网站的优先级从高到低递减，不在列表中的网站是 0 分
+type SourceReputationScorer struct {
+}
+
+func NewSourceReputationScorer() *SourceReputationScorer {
+	return &SourceReputationScorer{}
+}
+
+func (s SourceReputationScorer) GetScorerName() string {
+	return ScorerNameSourceReputation
+}
+
+func (s SourceReputationScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.SourceReputationWeight
+}
+
+func (s SourceReputationScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if len(scoreContext.SiteSequence) < 1 {
+		return 0, false
+	}
+	for i, site := range scoreContext.SiteSequence {
+		if site == fileInfo.FromWhereSite {
+			return 1 - float64(i)/float64(len(scoreContext.SiteSequence)), true
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,77 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// NewDefaultScorers 默认的评分项，timelineFixer 为 nil 的时候，时间轴匹配这一项不能评分，但是权重依然计入总分
+// TODO 如果新增了评分项，这里也需要添加对应的实例
+func NewDefaultScorers(timelineFixer *sub_timeline_fixer.SubTimelineFixerHelperEx) []ifaces.ISubScorer {
+
+	scorers := []ifaces.ISubScorer{
+		NewReleaseNameScorer(),
+		NewReleaseMatchScorer(),
+		NewDialogueCoverageScorer(),
+		NewBilingualScorer(),
+		NewFormatScorer(),
+		NewSourceReputationScorer(),
+		NewLineLengthScorer(),
+		NewEncodingScorer(),
+		NewTimelineMatchScorer(timelineFixer),
+	}
+	return scorers
+}
+
+/*
+	Calculate 使用所有的评分项对一个字幕进行评分，得到评分的明细
+	总分按所有设置了权重的评分项归一化，而不是只按这次参与评分的项，否则少评了一项的字幕总分反而可能更高
+*/
+func Calculate(scorers []ifaces.ISubScorer, scoreSettings *settings.SubScoreSettings, scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) *sub_score.Breakdown {
+
+	weightSum := 0.0
+	for _, scorer := range scorers {
+		if weight := scorer.GetWeight(scoreSettings); weight > 0 {
+			weightSum += weight
+		}
+	}
+	breakdown := sub_score.NewBreakdown(weightSum)
+	for _, scorer := range scorers {
+		weight := scorer.GetWeight(scoreSettings)
+		if weight <= 0 {
+			continue
+		}
+		score, ok := scorer.Score(scoreContext, fileInfo)
+		if ok == false {
+			continue
+		}
+		breakdown.Add(scorer.GetScorerName(), clamp01(score), weight)
+	}
+	return breakdown
+}
+
+// clamp01 限制在 0 - 1 之间
+func clamp01(in float64) float64 {
+	if in < 0 {
+		return 0
+	}
+	if in > 1 {
+		return 1
+	}
+	return in
+}
+
+const (
+	ScorerNameReleaseName      = "release_name"
+	ScorerNameReleaseMatch     = "release_match"
+	ScorerNameDialogueCoverage = "dialogue_coverage"
+	ScorerNameTimelineMatch    = "timeline_match"
+	ScorerNameBilingual        = "bilingual"
+	ScorerNameFormat           = "format"
+	ScorerNameSourceReputation = "source_reputation"
+	ScorerNameLineLength       = "line_length"
+	ScorerNameEncoding         = "encoding"
+)
@@ -0,0 +1,122 @@
+package sub_scorer
+
+import (
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+func TestReleaseNameScorer_Score(t *testing.T) {
+
+	scoreContext := sub_score.NewScoreContext("/movie/Movie.2020.1080p.BluRay.x264-GROUP.mkv", 0, nil, 0, nil)
+	tests := []struct {
+		name    string
+		subName string
+		want    float64
+	}{
+		{name: "all match", subName: "[assrt]_0_Movie.2020.1080p.BluRay.x264-GROUP.chs.ass", want: 1},
+		{name: "half match", subName: "Movie.2020.720p.WEB-DL.chs.ass", want: 2.0 / 6.0},
+		{name: "none match", subName: "Other.chs.ass", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewReleaseNameScorer().Score(scoreContext, &subparser.FileInfo{Name: tt.subName})
+			if ok == false {
+				t.Fatal("Score() not ok")
+			}
+			if got != tt.want {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialogueCoverageScorer_Score(t *testing.T) {
+
+	makeSub := func(endTime string) *subparser.FileInfo {
+		return &subparser.FileInfo{Dialogues: []subparser.OneDialogue{{StartTime: "0:00:01.00", EndTime: endTime, Lines: []string{"对白"}}}}
+	}
+	tests := []struct {
+		name          string
+		fileInfo      *subparser.FileInfo
+		videoDuration float64
+		want          float64
+		wantOk        bool
+	}{
+		{name: "full", fileInfo: makeSub("1:35:00.00"), videoDuration: 6000, want: 1, wantOk: true},
+		{name: "half", fileInfo: makeSub("0:42:30.00"), videoDuration: 6000, want: 0.5, wantOk: true},
+		{name: "too long", fileInfo: makeSub("2:00:00.00"), videoDuration: 6000, want: 1 - (1.2-1.02)*5, wantOk: true},
+		{name: "no duration", fileInfo: makeSub("1:35:00.00"), videoDuration: 0, want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewDialogueCoverageScorer().Score(sub_score.NewScoreContext("", tt.videoDuration, nil, 0, nil), tt.fileInfo)
+			if ok != tt.wantOk {
+				t.Fatalf("Score() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok == true && (got-tt.want > 0.001 || tt.want-got > 0.001) {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingScorer_Score(t *testing.T) {
+
+	makeSub := func(lines ...string) *subparser.FileInfo {
+		fileInfo := subparser.FileInfo{Dialogues: make([]subparser.OneDialogue, 0)}
+		for _, line := range lines {
+			fileInfo.Dialogues = append(fileInfo.Dialogues, subparser.OneDialogue{Lines: []string{line}})
+		}
+		return &fileInfo
+	}
+	tests := []struct {
+		name     string
+		fileInfo *subparser.FileInfo
+		want     float64
+	}{
+		{name: "clean", fileInfo: makeSub("你好", "世界"), want: 1},
+		{name: "garbled", fileInfo: makeSub("锟斤拷锟斤拷", "世界"), want: -4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := NewEncodingScorer().Score(sub_score.NewScoreContext("", 0, nil, 0, nil), tt.fileInfo)
+			if got != tt.want {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+
+	siteSequence := []string{common.SubSiteSubtitleBest, common.SubSiteAssrt}
+	scoreContext := sub_score.NewScoreContext("/movie/Movie.2020.mkv", 0, nil, 2, siteSequence)
+	bilingualSub := &subparser.FileInfo{Name: "Movie.2020.ass", Ext: common.SubExtASS, Lang: language.ChineseSimpleEnglish, FromWhereSite: common.SubSiteAssrt}
+	chineseSub := &subparser.FileInfo{Name: "Movie.2020.srt", Ext: common.SubExtSRT, Lang: language.ChineseSimple, FromWhereSite: common.SubSiteSubtitleBest}
+
+	scorers := NewDefaultScorers(nil)
+	scoreSettings := settings.NewSubScoreSettings()
+	bilingualBreakdown := Calculate(scorers, scoreSettings, scoreContext, bilingualSub)
+	chineseBreakdown := Calculate(scorers, scoreSettings, scoreContext, chineseSub)
+	if bilingualBreakdown.Total <= chineseBreakdown.Total {
+		t.Errorf("bilingual %v should be higher than chinese %v", bilingualBreakdown, chineseBreakdown)
+	}
+	// 没有视频时长，不参与评分
+	if _, ok := bilingualBreakdown.Get(ScorerNameDialogueCoverage); ok == true {
+		t.Errorf("%s should not be scored", ScorerNameDialogueCoverage)
+	}
+	// 没能评分的项，权重依然计入总分，少评了一项的总分不会更高
+	if bilingualBreakdown.WeightSum <= 0 || bilingualBreakdown.Total > 100*(1-scoreSettings.DialogueCoverageWeight/bilingualBreakdown.WeightSum) {
+		t.Errorf("total should be normalized by all weights, %v", bilingualBreakdown)
+	}
+	// 权重为 0 的项不参与评分
+	scoreSettings.BilingualWeight = 0
+	if _, ok := Calculate(scorers, scoreSettings, scoreContext, bilingualSub).Get(ScorerNameBilingual); ok == true {
+		t.Errorf("%s should not be scored", ScorerNameBilingual)
+	}
+}
@@ -0,0 +1,53 @@
+package sub_scorer
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+/*
+TimelineMatchScorer 字幕与视频音频 VAD 的时间轴匹配程度
+使用时间轴校正的 Pipeline 计算出最佳偏移时候的分数，这个分数是对白 VAD 与音频 VAD 重合的帧数（10ms 一帧）
+所以需要除以字幕对白的总帧数，归一化到 0 - 1
+*/
+type TimelineMatchScorer struct {
+	timelineFixer *sub_timeline_fixer.SubTimelineFixerHelperEx
+}
+
+func NewTimelineMatchScorer(timelineFixer *sub_timeline_fixer.SubTimelineFixerHelperEx) *TimelineMatchScorer {
+	return &TimelineMatchScorer{timelineFixer: timelineFixer}
+}
+
+func (t TimelineMatchScorer) GetScorerName() string {
+	return ScorerNameTimelineMatch
+}
+
+func (t TimelineMatchScorer) GetWeight(scoreSettings *settings.SubScoreSettings) float64 {
+	return scoreSettings.TimelineMatchWeight
+}
+
+func (t TimelineMatchScorer) Score(scoreContext *sub_score.ScoreContext, fileInfo *subparser.FileInfo) (float64, bool) {
+
+	if t.timelineFixer == nil || scoreContext.AudioVADInfos == nil || len(scoreContext.AudioVADInfos) < 1 || len(fileInfo.Dialogues) < 1 {
+		return 0, false
+	}
+	dialogueFrames := 0.0
+	for _, dialogue := range fileInfo.Dialogues {
+		oneDuration := pkg.Time2SecondNumber(dialogue.GetEndTime()) - pkg.Time2SecondNumber(dialogue.GetStartTime())
+		if oneDuration > 0 {
+			dialogueFrames += oneDuration * 100
+		}
+	}
+	if dialogueFrames <= 0 {
+		return 0, false
+	}
+	bProcess, _, pipeResult, err := t.timelineFixer.ProcessByAudioVAD(scoreContext.AudioVADInfos, fileInfo)
+	if err != nil || bProcess == false {
+		return 0, false
+	}
+
+	return pipeResult.Score / dialogueFrames, true
+}
@@ -3,6 +3,7 @@ package a4k
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -20,6 +21,7 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/jinzhu/now"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
 
 	"github.com/PuerkitoBio/goquery"
@@ -156,6 +158,17 @@ func (s *Supplier) GetSubListFromFile4Movie(videoFPath string) ([]supplier.SubIn
 
 func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
 
+	return s.downloadSub4Series(seriesInfo, false)
+}
+
+func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	return s.downloadSub4Series(seriesInfo, true)
+}
+
+// downloadSub4Series isAnime 为 true 的时候，S01E01 搜索不到，再使用绝对集数搜索
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo, isAnime bool) ([]supplier.SubInfo, error) {
+
 	defer func() {
 		s.log.Debugln(s.GetSupplierName(), seriesInfo.Name, "End...")
 	}()
@@ -185,6 +198,22 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 		if err != nil {
 			return nil, err
 		}
+		if len(searchResultItems) == 0 && isAnime == true && episodeInfo.AbsoluteEpisode > 0 {
+			// 动画再使用绝对集数搜索 海贼王 1037
+			keyWord, err = mix_media_info.KeyWordSelect4Anime(mediaInfo, episodeInfo.FileFullPath, episodeInfo.AbsoluteEpisode, "cn")
+			if err != nil {
+				s.log.Errorln(s.GetSupplierName(), "keyWordSelect4Anime", err)
+				return nil, err
+			}
+			s.log.Infoln(s.GetSupplierName(), "searchKeyword", keyWord)
+			searchResultItems, err = s.searchKeyword(keyWord, false)
+			if err != nil {
+				return nil, err
+			}
+			for i := range searchResultItems {
+				fillAnimeSearchResultItem(&searchResultItems[i], episodeInfo)
+			}
+		}
 		if len(searchResultItems) == 0 {
 			// 没有找到则更换关键词
 			// 黄石 第四季
@@ -234,11 +263,6 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 	return outSubInfos, nil
 }
 
-func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
-
-	panic("not implemented")
-}
-
 // searchKeyword 通过关键词获取所有的字幕列表
 func (s *Supplier) searchKeyword(keyword string, isMovie bool) (searchResultItems []SearchResultItem, err error) {
 
@@ -288,7 +312,11 @@ func (s *Supplier) listPageItems(keyword string, pageIndex int, isMovie bool) (s
 	searPageUrl := fmt.Sprintf(settings.Get().AdvancedSettings.SuppliersSettings.A4k.RootUrl+"/search?term=%s&page=%d", url.QueryEscape(keyword), pageIndex)
 	resp, err := httpClient.R().Get(searPageUrl)
 	if err != nil {
-		err = errors.New("http get error:" + err.Error())
+		err = fmt.Errorf("http get error: %w", err)
+		return
+	}
+	if resp.StatusCode() != http.StatusOK {
+		err = supplier.NewStatusCodeError("a4k search", resp.StatusCode(), "")
 		return
 	}
 	var doc *goquery.Document
@@ -391,7 +419,11 @@ func (s *Supplier) downloadSub(videoFileName, downloadPageUrl string, season, ep
 	var resp *resty.Response
 	resp, err = httpClient.R().Get(downloadPageUrl)
 	if err != nil {
-		err = errors.New("http get error:" + err.Error())
+		err = fmt.Errorf("http get error: %w", err)
+		return
+	}
+	if resp.StatusCode() != http.StatusOK {
+		err = supplier.NewStatusCodeError("a4k download page", resp.StatusCode(), "")
 		return
 	}
 	var doc *goquery.Document
@@ -415,13 +447,27 @@ func (s *Supplier) downloadSub(videoFileName, downloadPageUrl string, season, ep
 	downloadFileUrl := settings.Get().AdvancedSettings.SuppliersSettings.A4k.RootUrl + downloadBtHrefUrl
 	subInfo, err = s.fileDownloader.GetA4k(s.GetSupplierName(), 0, season, eps, videoFileName, downloadFileUrl)
 	if err != nil {
-		err = errors.New("fileDownloader.Get error:" + err.Error())
+		err = fmt.Errorf("fileDownloader.Get error: %w", err)
 		return
 	}
 
 	return
 }
 
+// fillAnimeSearchResultItem 绝对集数命名的字幕，标题中没有季、集的信息，绝对集数对得上就以这一集的季、集为准
+func fillAnimeSearchResultItem(searchResultItem *SearchResultItem, episodeInfo series.EpisodeInfo) {
+
+	if searchResultItem.Season != 0 || searchResultItem.Episode != 0 {
+		return
+	}
+	animeNameInfo, found := anime_helper.ParseAnimeFileName(searchResultItem.Title)
+	if found == false || animeNameInfo.IsAbsolute == false || animeNameInfo.Episode != episodeInfo.AbsoluteEpisode {
+		return
+	}
+	searchResultItem.Season = episodeInfo.Season
+	searchResultItem.Episode = episodeInfo.Episode
+}
+
 type SearchResultItem struct {
 	Title        string `json:"title"`
 	RUrl         string `json:"r_url"`
@@ -79,7 +79,7 @@ func TestSupplier_GetSubListFromFile4Series(t *testing.T) {
 		println(i, got.Name, len(got.Data), got.Ext)
 	}
 
-	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(log_helper.GetLogger4Tester(), filepath.Base(seriesInfo.DirPath), gots, false)
+	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(log_helper.GetLogger4Tester(), filepath.Base(seriesInfo.DirPath), gots, false, seriesInfo)
 	if err != nil {
 		t.Fatal(err)
 	}
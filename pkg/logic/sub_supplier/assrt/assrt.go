@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -106,7 +107,7 @@ func (s *Supplier) GetSubListFromFile4Movie(filePath string) ([]supplier.SubInfo
 		return nil, errors.New("Token is empty")
 	}
 
-	return s.getSubListFromFile(filePath, true)
+	return s.getSubListFromFile(filePath, true, 0)
 }
 
 func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
@@ -120,7 +121,7 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 		return nil, errors.New("Token is empty")
 	}
 
-	return s.downloadSub4Series(seriesInfo)
+	return s.downloadSub4Series(seriesInfo, false)
 }
 
 func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
@@ -134,10 +135,11 @@ func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]su
 		return nil, errors.New("Token is empty")
 	}
 
-	return s.downloadSub4Series(seriesInfo)
+	return s.downloadSub4Series(seriesInfo, true)
 }
 
-func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool) ([]supplier.SubInfo, error) {
+// getSubListFromFile absoluteEpisode 大于 0 的时候，使用动画的绝对集数去搜索
+func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool, absoluteEpisode int) ([]supplier.SubInfo, error) {
 
 	defer func() {
 		s.log.Debugln(s.GetSupplierName(), videoFPath, "End...")
@@ -152,7 +154,7 @@ func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool) ([]suppli
 		return nil, err
 	}
 	// 需要找到中文名称去搜索，找不到就是用英文名称，还找不到就是 OriginalTitle
-	found, searchSubResult, err := s.getSubInfoEx(mediaInfo, videoFPath, isMovie, "cn")
+	found, searchSubResult, err := s.getSubInfoEx(mediaInfo, videoFPath, isMovie, "cn", absoluteEpisode)
 	if err != nil {
 		s.log.Errorln(s.GetSupplierName(), videoFPath, "GetSubInfoEx", err)
 		return nil, err
@@ -182,6 +184,8 @@ func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool) ([]suppli
 	videoFileName := filepath.Base(videoFPath)
 	for index, subInfo := range searchSubResult.Sub.Subs {
 
+		releaseName := subInfo.Videoname
+
 		// 获取具体的下载地址
 		oneSubDetail, err := s.getSubDetail(subInfo.Id)
 		if err != nil {
@@ -204,6 +208,9 @@ func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool) ([]suppli
 			s.log.Error("FileDownloader.Get", err)
 			continue
 		}
+		if releaseName != "" {
+			subInfo.ReleaseName = releaseName
+		}
 
 		outSubInfoList = append(outSubInfoList, *subInfo)
 		// 如果够了那么多个字幕就返回
@@ -215,11 +222,16 @@ func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool) ([]suppli
 	return outSubInfoList, nil
 }
 
-func (s *Supplier) getSubInfoEx(mediaInfo *models.MediaInfo, videoFPath string, isMovie bool, keyWordType string) (bool, *SearchSubResult, error) {
+func (s *Supplier) getSubInfoEx(mediaInfo *models.MediaInfo, videoFPath string, isMovie bool, keyWordType string, absoluteEpisode int) (bool, *SearchSubResult, error) {
 
 	var searchSubResult *SearchSubResult
 	var err error
-	keyWord, err := mix_media_info.KeyWordSelect(mediaInfo, videoFPath, isMovie, keyWordType)
+	var keyWord string
+	if absoluteEpisode > 0 {
+		keyWord, err = mix_media_info.KeyWordSelect4Anime(mediaInfo, videoFPath, absoluteEpisode, keyWordType)
+	} else {
+		keyWord, err = mix_media_info.KeyWordSelect(mediaInfo, videoFPath, isMovie, keyWordType)
+	}
 	if err != nil {
 		s.log.Errorln(s.GetSupplierName(), videoFPath, "keyWordSelect", err)
 		return false, searchSubResult, err
@@ -239,7 +251,8 @@ func (s *Supplier) getSubInfoEx(mediaInfo *models.MediaInfo, videoFPath string,
 	}
 }
 
-func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+// downloadSub4Series isAnime 为 true 的时候，S01E01 搜索不到，再使用绝对集数搜索
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo, isAnime bool) ([]supplier.SubInfo, error) {
 	var allSupplierSubInfo = make([]supplier.SubInfo, 0)
 
 	index := 0
@@ -247,11 +260,18 @@ func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier
 	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
 
 		index++
-		one, err := s.getSubListFromFile(episodeInfo.FileFullPath, false)
+		one, err := s.getSubListFromFile(episodeInfo.FileFullPath, false, 0)
 		if err != nil {
 			s.log.Errorln(s.GetSupplierName(), "getSubListFromFile", episodeInfo.FileFullPath, err)
 			continue
 		}
+		if len(one) < 1 && isAnime == true && episodeInfo.AbsoluteEpisode > 0 {
+			one, err = s.getSubListFromFile(episodeInfo.FileFullPath, false, episodeInfo.AbsoluteEpisode)
+			if err != nil {
+				s.log.Errorln(s.GetSupplierName(), "getSubListFromFile", episodeInfo.FileFullPath, "AbsoluteEpisode:", episodeInfo.AbsoluteEpisode, err)
+				continue
+			}
+		}
 		if one == nil {
 			// 没有搜索到字幕
 			s.log.Infoln(s.GetSupplierName(), "Not Find Sub can be download",
@@ -292,6 +312,9 @@ func (s *Supplier) getSubByKeyWord(keyword string) (*SearchSubResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, supplier.NewStatusCodeError("assrt search", resp.StatusCode(), "")
+	}
 	/*
 		这里有个梗， Sub 有值的时候是一个列表，但是如果为空的时候，又是一个空的结构体
 		所以出现两个结构体需要去尝试解析
@@ -363,6 +386,9 @@ func (s *Supplier) getSubDetail(subID int) (OneSubDetail, error) {
 		}
 		return subDetail, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return subDetail, supplier.NewStatusCodeError("assrt sub detail", resp.StatusCode(), "")
+	}
 
 	return subDetail, nil
 }
@@ -388,6 +414,9 @@ func (s *Supplier) getUserInfo() (UserInfo, error) {
 		}
 		return userInfo, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return userInfo, supplier.NewStatusCodeError("assrt user quota", resp.StatusCode(), "")
+	}
 
 	return userInfo, nil
 }
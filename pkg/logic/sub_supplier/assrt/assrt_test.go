@@ -1,6 +1,9 @@
 package assrt
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
@@ -10,6 +13,8 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/cache_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/random_auth_key"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
 )
 
 var assrtInstance *Supplier
@@ -39,7 +44,7 @@ func TestSupplier_getSubListFromFile(t *testing.T) {
 	//videoFPath := "X:\\连续剧\\风骚律师 (2015)\\Season 6\\Better Call Saul - S06E05 - Black and Blue WEBDL-1080p.mkv"
 	isMovie := false
 
-	got, err := assrtInstance.getSubListFromFile(videoFPath, isMovie)
+	got, err := assrtInstance.getSubListFromFile(videoFPath, isMovie, 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -55,3 +60,25 @@ func TestSupplier_CheckAlive(t *testing.T) {
 	println(bok, speed)
 
 }
+
+// TestSupplier_StatusCodeError 非 2xx 的回复返回 StatusCodeError，健康统计中算作字幕源的失败
+func TestSupplier_StatusCodeError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	settings.SetConfigRootPath(t.TempDir())
+	settings.Get().AdvancedSettings.SuppliersSettings.Assrt.RootUrl = server.URL
+	s := &Supplier{log: log_helper.GetLogger4Tester()}
+
+	var statusCodeError *supplier.StatusCodeError
+	_, err := s.getSubByKeyWord("keyword")
+	if errors.As(err, &statusCodeError) == false || statusCodeError.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("getSubByKeyWord err = %v, want StatusCodeError", err)
+	}
+	_, err = s.getUserInfo()
+	if errors.As(err, &statusCodeError) == false || statusCodeError.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("getUserInfo err = %v, want StatusCodeError", err)
+	}
+}
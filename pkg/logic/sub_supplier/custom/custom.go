@@ -0,0 +1,386 @@
+package custom
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/mix_media_info"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/go-resty/resty/v2"
+	"github.com/jinzhu/now"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	Supplier 由设置（SubtitleSources.CustomSuppliers 中的一个）描述的字幕源
+	1. 电影先按 IMDB ID 的模板搜索，没有结果再按 名称 + 年份 的模板搜索，连续剧同理，使用 Episode 开头的模板
+	2. 搜索结果按选择器提取，连续剧只保留季、集对得上的，以及同一季的全季字幕
+	3. 同一个字幕源的所有请求（包括下载）共用一个请求间隔的限制
+*/
+type Supplier struct {
+	log            *logrus.Logger
+	fileDownloader *file_downloader.FileDownloader
+	customSettings settings.CustomSupplierSettings
+	isAlive        bool
+}
+
+func NewSupplier(fileDownloader *file_downloader.FileDownloader, customSettings settings.CustomSupplierSettings) *Supplier {
+
+	sup := Supplier{}
+	sup.log = fileDownloader.Log
+	sup.fileDownloader = fileDownloader
+	sup.customSettings = customSettings
+	sup.isAlive = true // 默认是可以使用的，如果 check 后，再调整状态
+
+	return &sup
+}
+
+func (s *Supplier) CheckAlive() (bool, int64) {
+
+	// 计算当前时间
+	startT := time.Now()
+	httpClient, err := s.newHttpClient()
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive.NewHttpClient", err)
+		s.isAlive = false
+		return false, 0
+	}
+	checkAliveUrl := s.customSettings.CheckAliveUrl
+	if checkAliveUrl == "" {
+		checkAliveUrl = s.customSettings.RootUrl
+	}
+	resp, err := httpClient.R().Get(pkg.AddBaseUrl(s.customSettings.RootUrl, checkAliveUrl))
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive.Get", err)
+		s.isAlive = false
+		return false, 0
+	}
+	if resp.StatusCode() >= http.StatusBadRequest {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive.StatusCode", resp.StatusCode())
+		s.isAlive = false
+		return false, 0
+	}
+	s.isAlive = true
+	return true, time.Since(startT).Milliseconds()
+}
+
+func (s *Supplier) IsAlive() bool {
+	return s.isAlive
+}
+
+func (s *Supplier) OverDailyDownloadLimit() bool {
+
+	if s.customSettings.DailyDownloadLimit <= 0 {
+		// 没有限制
+		return false
+	}
+	count, err := s.fileDownloader.CacheCenter.DailyDownloadCountGet(s.GetSupplierName(),
+		pkg.GetPublicIP(s.log, settings.Get().AdvancedSettings.TaskQueue))
+	if err != nil {
+		s.log.Warningln(s.GetSupplierName(), "DailyDownloadCountGet", err)
+		return false
+	}
+	if count >= s.customSettings.DailyDownloadLimit {
+		return true
+	}
+	// 没有超出限制
+	return false
+}
+
+func (s *Supplier) GetLogger() *logrus.Logger {
+	return s.log
+}
+
+func (s *Supplier) GetSupplierName() string {
+	return common.SubSiteCustomPrefix + s.customSettings.Name
+}
+
+func (s *Supplier) GetSubListFromFile4Movie(filePath string) ([]supplier.SubInfo, error) {
+
+	outSubInfos := make([]supplier.SubInfo, 0)
+	if s.customSettings.Enabled == false {
+		return outSubInfos, nil
+	}
+	mediaInfo, err := mix_media_info.GetMixMediaInfo(s.fileDownloader.MediaInfoDealers, filePath, true)
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "GetMixMediaInfo", err)
+		return nil, err
+	}
+	vars := searchVars{
+		ImdbID:  mediaInfo.ImdbId,
+		TmdbID:  mediaInfo.TmdbId,
+		Title:   mediaInfo.TitleEn,
+		TitleCn: mediaInfo.TitleCn,
+	}
+	if vars.Title == "" {
+		vars.Title = mediaInfo.OriginalTitle
+	}
+	airTime, err := now.Parse(mediaInfo.Year)
+	if err == nil {
+		vars.Year = airTime.Year()
+	}
+
+	return s.getSubList(filePath, true, vars,
+		s.customSettings.SearchMovieByImdbUrl, s.customSettings.SearchMovieByTitleUrl)
+}
+
+func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	outSubInfos := make([]supplier.SubInfo, 0)
+	if s.customSettings.Enabled == false {
+		return outSubInfos, nil
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+// GetSubListFromFile4Anime 动画也是按 季、集 搜索的，同连续剧的逻辑
+func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+	return s.GetSubListFromFile4Series(seriesInfo)
+}
+
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	var allSupplierSubInfo = make([]supplier.SubInfo, 0)
+	baseVars := searchVars{
+		ImdbID: seriesInfo.ImdbId,
+		TmdbID: seriesInfo.TmdbId,
+		Title:  seriesInfo.Name,
+		Year:   seriesInfo.Year,
+	}
+	mediaInfoGot := false
+	// 这里拿到的 seriesInfo ，里面包含了，需要下载字幕的 Eps 信息
+	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
+
+		if mediaInfoGot == false {
+			// 一部连续剧只需要获取一次，获取不到就使用 seriesInfo 中的信息
+			mediaInfoGot = true
+			mediaInfo, err := mix_media_info.GetMixMediaInfo(s.fileDownloader.MediaInfoDealers, episodeInfo.FileFullPath, false)
+			if err != nil {
+				s.log.Warningln(s.GetSupplierName(), "GetMixMediaInfo", episodeInfo.FileFullPath, err)
+			} else {
+				if mediaInfo.ImdbId != "" {
+					baseVars.ImdbID = mediaInfo.ImdbId
+				}
+				if mediaInfo.TitleEn != "" {
+					baseVars.Title = mediaInfo.TitleEn
+				}
+				baseVars.TitleCn = mediaInfo.TitleCn
+			}
+		}
+		vars := baseVars
+		vars.Season = episodeInfo.Season
+		vars.Episode = episodeInfo.Episode
+
+		one, err := s.getSubList(episodeInfo.FileFullPath, false, vars,
+			s.customSettings.SearchEpisodeByImdbUrl, s.customSettings.SearchEpisodeByTitleUrl)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "getSubList", episodeInfo.FileFullPath, err)
+			continue
+		}
+		if len(one) < 1 {
+			// 没有搜索到字幕
+			s.log.Infoln(s.GetSupplierName(), "Not Find Sub can be download",
+				episodeInfo.Title, episodeInfo.Season, episodeInfo.Episode)
+			continue
+		}
+		allSupplierSubInfo = append(allSupplierSubInfo, one...)
+	}
+	return allSupplierSubInfo, nil
+}
+
+// getSubList 按顺序使用搜索模板，有结果就不再使用后面的模板
+func (s *Supplier) getSubList(videoFPath string, isMovie bool, vars searchVars, urlTemplates ...string) ([]supplier.SubInfo, error) {
+
+	defer func() {
+		s.log.Debugln(s.GetSupplierName(), videoFPath, "End...")
+	}()
+	s.log.Debugln(s.GetSupplierName(), videoFPath, "Start...")
+
+	httpClient, err := s.newHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	var results []resultItem
+	for _, urlTemplate := range urlTemplates {
+		searchUrl, ok := buildUrl(s.customSettings.RootUrl, urlTemplate, vars)
+		if ok == false {
+			continue
+		}
+		s.log.Infoln(s.GetSupplierName(), "search", searchUrl)
+		results, err = s.search(httpClient, searchUrl, isMovie, vars.Season, vars.Episode)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 {
+			break
+		}
+	}
+
+	outSubInfoList := make([]supplier.SubInfo, 0)
+	videoFileName := filepath.Base(videoFPath)
+	for _, result := range results {
+
+		downloadUrl, err := s.getDownloadUrl(httpClient, result.DownloadUrl)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "getDownloadUrl", result.DownloadUrl, err)
+			continue
+		}
+		// 全季的字幕，以当前这一集为准
+		season, episode := vars.Season, vars.Episode
+		subInfo, err := s.fileDownloader.GetWithClient(s.GetSupplierName(), httpClient, int64(len(outSubInfoList)),
+			season, episode, videoFileName, downloadUrl)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "GetWithClient", downloadUrl, err)
+			continue
+		}
+		if subInfo.ReleaseName == "" {
+			subInfo.ReleaseName = result.Title
+		}
+		outSubInfoList = append(outSubInfoList, *subInfo)
+		// 如果够了那么多个字幕就返回
+		if len(outSubInfoList) >= settings.Get().AdvancedSettings.Topic {
+			break
+		}
+		if s.OverDailyDownloadLimit() == true {
+			s.log.Infoln(s.GetSupplierName(), "Over Daily Download Limit")
+			break
+		}
+	}
+	return outSubInfoList, nil
+}
+
+// search 搜索并提取结果，连续剧只保留季、集对得上的，以及同一季的全季字幕
+func (s *Supplier) search(httpClient *resty.Client, searchUrl string, isMovie bool, season, episode int) ([]resultItem, error) {
+
+	resp, err := httpClient.R().Get(searchUrl)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		// 有的接口没有结果的时候返回 404
+		return make([]resultItem, 0), nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, supplier.NewStatusCodeError("search", resp.StatusCode(), "")
+	}
+	results, err := extractResults(s.customSettings.ResponseType, resp.Body(), s.customSettings.Selectors)
+	if err != nil {
+		return nil, err
+	}
+	if isMovie == true {
+		return results, nil
+	}
+	return filterEpisode(results, season, episode), nil
+}
+
+// getDownloadUrl 设置了 DetailDownloadUrl 的时候，先打开详情页，再从中提取下载地址
+func (s *Supplier) getDownloadUrl(httpClient *resty.Client, resultUrl string) (string, error) {
+
+	resultUrl = pkg.AddBaseUrl(s.customSettings.RootUrl, resultUrl)
+	if s.customSettings.Selectors.DetailDownloadUrl == "" {
+		return resultUrl, nil
+	}
+	resp, err := httpClient.R().Get(resultUrl)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", supplier.NewStatusCodeError("detail page", resp.StatusCode(), "")
+	}
+	downloadUrl, err := extractValue(s.customSettings.ResponseType, resp.Body(), s.customSettings.Selectors.DetailDownloadUrl)
+	if err != nil {
+		return "", err
+	}
+	if downloadUrl == "" {
+		return "", errors.New("download url not found in detail page")
+	}
+	return pkg.AddBaseUrl(s.customSettings.RootUrl, downloadUrl), nil
+}
+
+// newHttpClient 带上设置的 Header，同一个字幕源的请求之间需要满足 RequestInterval
+func (s *Supplier) newHttpClient() (*resty.Client, error) {
+
+	httpClient, err := pkg.NewHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	if s.customSettings.TimeOut > 0 {
+		httpClient.SetTimeout(time.Duration(s.customSettings.TimeOut) * time.Second)
+	}
+	for key, value := range s.customSettings.Headers {
+		httpClient.SetHeader(key, value)
+	}
+	if s.customSettings.RequestInterval > 0 {
+		limiter := getRateLimiter(s.GetSupplierName())
+		interval := time.Duration(s.customSettings.RequestInterval) * time.Millisecond
+		httpClient.OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
+			limiter.wait(interval)
+			return nil
+		})
+	}
+	return httpClient, nil
+}
+
+// filterEpisode 没有解析出季、集的结果无法判断，也保留
+func filterEpisode(results []resultItem, season, episode int) []resultItem {
+
+	outResults := make([]resultItem, 0)
+	for _, result := range results {
+		if result.Season == 0 && result.Episode == 0 && result.IsFullSeason == false {
+			outResults = append(outResults, result)
+			continue
+		}
+		if result.Season != 0 && result.Season != season {
+			continue
+		}
+		if result.IsFullSeason == true || result.Episode == episode {
+			outResults = append(outResults, result)
+		}
+	}
+	return outResults
+}
+
+// rateLimiter 字幕源每次搜索都会新建 Supplier 的实例，所以请求间隔按字幕源的名称全局记录
+type rateLimiter struct {
+	locker      sync.Mutex
+	lastRequest time.Time
+}
+
+func (r *rateLimiter) wait(interval time.Duration) {
+
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	waitTime := interval - time.Since(r.lastRequest)
+	if waitTime > 0 {
+		time.Sleep(waitTime)
+	}
+	r.lastRequest = time.Now()
+}
+
+func getRateLimiter(supplierName string) *rateLimiter {
+
+	rateLimitersLocker.Lock()
+	defer rateLimitersLocker.Unlock()
+
+	limiter, found := rateLimiters[strings.ToLower(supplierName)]
+	if found == false {
+		limiter = &rateLimiter{}
+		rateLimiters[strings.ToLower(supplierName)] = limiter
+	}
+	return limiter
+}
+
+var (
+	rateLimitersLocker sync.Mutex
+	rateLimiters       = make(map[string]*rateLimiter)
+)
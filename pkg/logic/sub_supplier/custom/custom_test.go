@@ -0,0 +1,200 @@
+package custom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/go-resty/resty/v2"
+)
+
+func TestBuildUrl(t *testing.T) {
+
+	vars := searchVars{
+		ImdbID:  "tt0111161",
+		Title:   "The Shawshank Redemption",
+		Year:    1994,
+		Season:  1,
+		Episode: 2,
+	}
+	tests := []struct {
+		urlTemplate string
+		want        string
+		wantOk      bool
+	}{
+		{"/api/search?imdb={imdb_id}", "http://root/api/search?imdb=tt0111161", true},
+		{"/api/search?id={imdb_number}&s={season}&e={episode}", "http://root/api/search?id=0111161&s=1&e=2", true},
+		{"/search?q={title}+S{season_pad}E{episode_pad}", "http://root/search?q=The+Shawshank+Redemption+S01E02", true},
+		{"https://other/search?q={title}&y={year}", "https://other/search?q=The+Shawshank+Redemption&y=1994", true},
+		// 路径中的占位符
+		{"/movie/{title}/{year}?q={title}", "http://root/movie/The%20Shawshank%20Redemption/1994?q=The+Shawshank+Redemption", true},
+		// 没有值的占位符
+		{"/search?q={title_cn}", "", false},
+		// 不支持的占位符
+		{"/search?q={name}", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := buildUrl("http://root", tt.urlTemplate, vars)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("buildUrl(%s) = %s, %v, want %s, %v", tt.urlTemplate, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestExtractResults(t *testing.T) {
+
+	jsonBody := []byte(`{"data":{"items":[
+		{"name":"Movie.2021.1080p.srt","link":"/dl/1"},
+		{"name":"Series.S01E02.srt","s":1,"e":2,"link":"/dl/2"},
+		{"name":"no link"}
+	]}}`)
+	results, err := extractResults(settings.CustomSupplierResponseJson, jsonBody, settings.CustomSupplierSelectors{
+		Results:     "data.items",
+		Title:       "name",
+		Season:      "s",
+		Episode:     "e",
+		DownloadUrl: "link",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].DownloadUrl != "/dl/1" || results[1].Season != 1 || results[1].Episode != 2 {
+		t.Fatal("json", results)
+	}
+
+	htmlBody := []byte(`<html><body><ul class="subs">
+		<li><a class="name" href="/sub/1">Series.S01E02.1080p.WEB.ass</a><a class="dl" href="/dl/1">下载</a></li>
+		<li><a class="name" href="/sub/2">Series.S01.Complete.zip</a><a class="dl" href="/dl/2">下载</a></li>
+		<li><a class="name" href="/sub/3">Series.S02E02.ass</a></li>
+	</ul></body></html>`)
+	results, err = extractResults(settings.CustomSupplierResponseHtml, htmlBody, settings.CustomSupplierSelectors{
+		Results:     "ul.subs li",
+		Title:       "a.name",
+		DownloadUrl: "a.dl@href",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Title != "Series.S01E02.1080p.WEB.ass" || results[0].DownloadUrl != "/dl/1" {
+		t.Fatal("html", results)
+	}
+	// 季、集从字幕的名称中解析
+	if results[0].Season != 1 || results[0].Episode != 2 || results[1].Season != 1 || results[1].IsFullSeason != true {
+		t.Fatal("html season episode", results)
+	}
+
+	downloadUrl, err := extractValue(settings.CustomSupplierResponseHtml,
+		[]byte(`<div class="buttons"><a class="green" href="/file/1.zip">下载</a></div>`), "div.buttons a.green@href")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloadUrl != "/file/1.zip" {
+		t.Fatal("extractValue", downloadUrl)
+	}
+}
+
+// TestExtractResults_JsonPath 设置中 JSONPath 的选择器，Check 时转换为 gjson 的路径，无法转换的字幕源被去掉
+func TestExtractResults_JsonPath(t *testing.T) {
+
+	subtitleSources := settings.NewSubtitleSources()
+	subtitleSources.CustomSuppliers = []settings.CustomSupplierSettings{
+		{Name: "good", SearchMovieByImdbUrl: "/search?imdb={imdb_id}", Selectors: settings.CustomSupplierSelectors{
+			Results: "$.data['items']", Title: "$.name", DownloadUrl: "$.files[0].url"}},
+		{Name: "bad", SearchMovieByImdbUrl: "/search?imdb={imdb_id}", Selectors: settings.CustomSupplierSelectors{
+			Results: "$..items"}},
+		{Name: "filter", SearchMovieByImdbUrl: "/search?imdb={imdb_id}", Selectors: settings.CustomSupplierSelectors{
+			Results: "$.data.items[?(@.lang=='zh')]"}},
+	}
+	subtitleSources.Check()
+	if len(subtitleSources.CustomSuppliers) != 1 || len(subtitleSources.CustomSupplierWarnings()) != 2 {
+		t.Fatalf("suppliers = %v, warnings = %v", subtitleSources.CustomSuppliers, subtitleSources.CustomSupplierWarnings())
+	}
+	jsonBody := []byte(`{"data":{"items":[{"name":"Movie.2021.1080p.srt","files":[{"url":"/dl/1"}]}]}}`)
+	results, err := extractResults(settings.CustomSupplierResponseJson, jsonBody, subtitleSources.CustomSuppliers[0].Selectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "Movie.2021.1080p.srt" || results[0].DownloadUrl != "/dl/1" {
+		t.Fatalf("results = %+v", results)
+	}
+}
+
+func TestFilterEpisode(t *testing.T) {
+
+	results := []resultItem{
+		{Title: "S01E02", Season: 1, Episode: 2},
+		{Title: "S01E03", Season: 1, Episode: 3},
+		{Title: "S01 Complete", Season: 1, IsFullSeason: true},
+		{Title: "S02 Complete", Season: 2, IsFullSeason: true},
+		{Title: "unknown"},
+	}
+	got := filterEpisode(results, 1, 2)
+	if len(got) != 3 || got[0].Title != "S01E02" || got[1].Title != "S01 Complete" || got[2].Title != "unknown" {
+		t.Fatal("filterEpisode", got)
+	}
+}
+
+func TestSupplierSearch(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key_1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("imdb") != "tt0000001" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"title":"Series.S01E02.srt","url":"/dl/2"},{"title":"Series.S01E03.srt","url":"/dl/3"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s := &Supplier{customSettings: settings.CustomSupplierSettings{
+		Name:         "private",
+		RootUrl:      server.URL,
+		ResponseType: settings.CustomSupplierResponseJson,
+		Selectors: settings.CustomSupplierSelectors{
+			Title:       "title",
+			DownloadUrl: "url",
+		},
+	}}
+	httpClient := resty.New().SetHeader("X-Api-Key", "key_1")
+
+	searchUrl, ok := buildUrl(server.URL, "/api/search?imdb={imdb_id}", searchVars{ImdbID: "tt0000001"})
+	if ok == false {
+		t.Fatal("buildUrl")
+	}
+	results, err := s.search(httpClient, searchUrl, false, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].DownloadUrl != "/dl/2" {
+		t.Fatal("search", results)
+	}
+	// 没有结果返回 404 的时候不是错误
+	searchUrl, _ = buildUrl(server.URL, "/api/search?imdb={imdb_id}", searchVars{ImdbID: "tt0000002"})
+	results, err = s.search(httpClient, searchUrl, true, 0, 0)
+	if err != nil || len(results) != 0 {
+		t.Fatal("search not found", results, err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+
+	limiter := getRateLimiter("custom_test_rate")
+	if limiter != getRateLimiter("custom_test_rate") {
+		t.Fatal("getRateLimiter should return the same limiter")
+	}
+	startT := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait(50 * time.Millisecond)
+	}
+	if time.Since(startT) < 100*time.Millisecond {
+		t.Fatal("rateLimiter.wait", time.Since(startT))
+	}
+}
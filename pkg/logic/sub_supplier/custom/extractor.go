@@ -0,0 +1,146 @@
+package custom
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tidwall/gjson"
+)
+
+// resultItem 一个搜索结果
+type resultItem struct {
+	Title        string
+	Season       int
+	Episode      int
+	IsFullSeason bool
+	DownloadUrl  string // 可能是相对地址，也可能是详情页的地址
+}
+
+// extractResults 按选择器从搜索结果中提取字幕列表，没有下载地址的会被忽略
+func extractResults(responseType string, body []byte, selectors settings.CustomSupplierSelectors) ([]resultItem, error) {
+
+	if selectors.DownloadUrl == "" {
+		return nil, errors.New("selectors.download_url is empty")
+	}
+	results := make([]resultItem, 0)
+	addOne := func(getValue func(selector string) string) {
+		item := resultItem{
+			Title:       getValue(selectors.Title),
+			DownloadUrl: getValue(selectors.DownloadUrl),
+		}
+		if item.DownloadUrl == "" {
+			return
+		}
+		item.Season = str2Int(getValue(selectors.Season))
+		item.Episode = str2Int(getValue(selectors.Episode))
+		if item.Title != "" && (selectors.Season == "" || selectors.Episode == "" || (item.Season == 0 && item.Episode == 0)) {
+			// 没有设置，或者取不到季、集的时候，从字幕的名称中解析
+			isFullSeason, season, episode, err := decode.GetSeasonAndEpisodeFromSubFileName(item.Title)
+			if err == nil {
+				if item.Season == 0 {
+					item.Season = season
+				}
+				if item.Episode == 0 {
+					item.Episode = episode
+				}
+				item.IsFullSeason = isFullSeason
+			}
+		}
+		results = append(results, item)
+	}
+
+	if responseType == settings.CustomSupplierResponseHtml {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.New("goquery NewDocumentFromReader error:" + err.Error())
+		}
+		if selectors.Results == "" {
+			return nil, errors.New("selectors.results is empty")
+		}
+		doc.Find(selectors.Results).Each(func(i int, selection *goquery.Selection) {
+			addOne(func(selector string) string {
+				return htmlValue(selection, selector)
+			})
+		})
+		return results, nil
+	}
+
+	if gjson.ValidBytes(body) == false {
+		return nil, errors.New("response is not a valid json")
+	}
+	list := gjson.ParseBytes(body)
+	if selectors.Results != "" {
+		list = list.Get(selectors.Results)
+	}
+	if list.IsArray() == false {
+		return nil, errors.New("selectors.results is not an array")
+	}
+	list.ForEach(func(key, value gjson.Result) bool {
+		addOne(func(selector string) string {
+			return jsonValue(value, selector)
+		})
+		return true
+	})
+	return results, nil
+}
+
+// extractValue 从整个页面中提取一个值，用于详情页中的下载地址
+func extractValue(responseType string, body []byte, selector string) (string, error) {
+
+	if responseType == settings.CustomSupplierResponseHtml {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return "", errors.New("goquery NewDocumentFromReader error:" + err.Error())
+		}
+		return htmlValue(doc.Selection, selector), nil
+	}
+	if gjson.ValidBytes(body) == false {
+		return "", errors.New("response is not a valid json")
+	}
+	return jsonValue(gjson.ParseBytes(body), selector), nil
+}
+
+// jsonValue selector 是 gjson 的路径，为空返回空字符串
+func jsonValue(value gjson.Result, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	return strings.TrimSpace(value.Get(selector).String())
+}
+
+// htmlValue selector 是 CSS 选择器，后面可以加 @属性名，没有则取文本，CSS 选择器为空则是 selection 本身，比如 @href
+func htmlValue(selection *goquery.Selection, selector string) string {
+
+	if selector == "" {
+		return ""
+	}
+	css, attr := selector, ""
+	if index := strings.LastIndex(selector, "@"); index >= 0 {
+		css, attr = strings.TrimSpace(selector[:index]), strings.TrimSpace(selector[index+1:])
+	}
+	found := selection
+	if css != "" {
+		found = selection.Find(css).First()
+	}
+	if found.Length() < 1 {
+		return ""
+	}
+	if attr != "" {
+		value, _ := found.Attr(attr)
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(found.Text())
+}
+
+func str2Int(value string) int {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+	return number
+}
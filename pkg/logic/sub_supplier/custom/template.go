@@ -0,0 +1,98 @@
+package custom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+)
+
+/*
+	searchVars 搜索地址模板中可以使用的占位符
+	{imdb_id}      tt0111161
+	{imdb_number}  0111161，去掉了 tt
+	{tmdb_id}
+	{title}        英文（或者原始）的名称
+	{title_cn}     中文的名称
+	{year}
+	{season}       1
+	{episode}      2
+	{season_pad}   01
+	{episode_pad}  02
+*/
+type searchVars struct {
+	ImdbID  string
+	TmdbID  string
+	Title   string
+	TitleCn string
+	Year    int
+	Season  int
+	Episode int
+}
+
+func (v searchVars) toMap() map[string]string {
+
+	vars := map[string]string{
+		"imdb_id":     v.ImdbID,
+		"imdb_number": strings.TrimPrefix(strings.ToLower(v.ImdbID), "tt"),
+		"tmdb_id":     v.TmdbID,
+		"title":       v.Title,
+		"title_cn":    v.TitleCn,
+		"year":        "",
+		"season":      "",
+		"episode":     "",
+		"season_pad":  "",
+		"episode_pad": "",
+	}
+	if v.Year > 0 {
+		vars["year"] = strconv.Itoa(v.Year)
+	}
+	if v.Season > 0 {
+		vars["season"] = strconv.Itoa(v.Season)
+		vars["season_pad"] = fmt.Sprintf("%02d", v.Season)
+	}
+	if v.Episode > 0 {
+		vars["episode"] = strconv.Itoa(v.Episode)
+		vars["episode_pad"] = fmt.Sprintf("%02d", v.Episode)
+	}
+	return vars
+}
+
+/*
+	buildUrl 替换模板中的占位符，值会进行 url 编码，相对地址拼接 rootUrl
+	? 之前的占位符是路径的一部分，使用 PathEscape，之后的是查询参数，使用 QueryEscape
+	模板为空、使用了不支持的占位符、或者占位符没有值的时候，返回 false，这个模板就不用搜索了
+*/
+func buildUrl(rootUrl, urlTemplate string, vars searchVars) (string, bool) {
+
+	if strings.TrimSpace(urlTemplate) == "" {
+		return "", false
+	}
+	varsMap := vars.toMap()
+	queryStart := strings.Index(urlTemplate, "?")
+	if queryStart < 0 {
+		queryStart = len(urlTemplate)
+	}
+	var sb strings.Builder
+	lastEnd := 0
+	for _, loc := range placeholderRegex.FindAllStringIndex(urlTemplate, -1) {
+		value, found := varsMap[strings.Trim(urlTemplate[loc[0]:loc[1]], "{}")]
+		if found == false || value == "" {
+			return "", false
+		}
+		sb.WriteString(urlTemplate[lastEnd:loc[0]])
+		if loc[0] < queryStart {
+			sb.WriteString(url.PathEscape(value))
+		} else {
+			sb.WriteString(url.QueryEscape(value))
+		}
+		lastEnd = loc[1]
+	}
+	sb.WriteString(urlTemplate[lastEnd:])
+	return pkg.AddBaseUrl(rootUrl, sb.String()), true
+}
+
+var placeholderRegex = regexp.MustCompile(`\{[a-z_]+\}`)
@@ -0,0 +1,379 @@
+package local_folder
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/archive_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// indexItem 字幕库中的一个字幕文件
+type indexItem struct {
+	SubFPath     string // 字幕文件的全路径，压缩包中的字幕是解压后的路径
+	ArchiveFPath string // 来自哪个压缩包，不是压缩包中的则为空
+	ImdbID       string // 来自字幕旁边的 nfo
+	TmdbID       string // 来自字幕旁边的 nfo
+	Title        string // 从文件名解析出的名称，已经归一化
+	Year         int
+	Season       int
+	Episode      int
+}
+
+// Index 字幕库的索引
+type Index struct {
+	items []indexItem
+}
+
+// query 一个视频的信息，用于在索引中查找
+type query struct {
+	ImdbID  string
+	TmdbID  string
+	Titles  []string // 可能的名称，比如文件名中解析出的、nfo 中的原始名称，已经归一化
+	Year    int
+	IsMovie bool
+	Season  int
+	Episode int
+}
+
+/*
+	BuildIndex 遍历字幕库的目录，建立索引
+	压缩包会解压到 extractRoot 中，按压缩包的路径、大小、修改时间区分，已经解压过的不会重复解压
+*/
+func BuildIndex(log *logrus.Logger, folderPaths []string, extractRoot string) (*Index, error) {
+
+	b := indexBuilder{
+		log:          log,
+		nfoCache:     make(map[string]types.VideoNfoInfo),
+		nfoFileCache: make(map[string]types.VideoNfoInfo),
+		nfoListCache: make(map[string]map[string]string),
+	}
+	idx := Index{items: make([]indexItem, 0)}
+	for _, folderPath := range folderPaths {
+		if pkg.IsDir(folderPath) == false {
+			log.Warningln("LocalFolder.BuildIndex", "folder not exist", folderPath)
+			continue
+		}
+		err := filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() == true {
+				// 跳过隐藏的目录
+				if path != folderPath && strings.HasPrefix(d.Name(), ".") == true {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if sub_parser_hub.IsSubExtWanted(path) == true {
+				idx.items = append(idx.items, b.newItem(path, "", folderPath))
+				return nil
+			}
+			if archive_helper.IsWantedArchiveExtName(path) == true {
+				items, err := b.indexArchive(path, folderPath, extractRoot)
+				if err != nil {
+					// 一个压缩包解压失败，不影响其他的
+					log.Warningln("LocalFolder.BuildIndex", "indexArchive", path, err)
+					return nil
+				}
+				idx.items = append(idx.items, items...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &idx, nil
+}
+
+// Len 索引中字幕的数量
+func (i *Index) Len() int {
+	return len(i.items)
+}
+
+/*
+	search 查找与视频匹配的字幕，IMDB、TMDB ID 匹配上的排在前面
+	1. 字幕有 ID，视频也有同类的 ID，那么只看 ID 是否一致
+	2. 否则比较名称，两边都有年份的时候年份也需要一致
+	3. 电影只匹配没有集数的字幕，连续剧的季、集需要一致，通过 ID 匹配上的字幕可以没有季
+*/
+func (i *Index) search(q query) []indexItem {
+
+	idMatched := make([]indexItem, 0)
+	titleMatched := make([]indexItem, 0)
+	for _, item := range i.items {
+
+		matchedByID, matched := q.matchID(item)
+		if matched == false {
+			continue
+		}
+		if q.IsMovie == true {
+			if item.Episode != 0 {
+				continue
+			}
+		} else {
+			if item.Episode != q.Episode {
+				continue
+			}
+			if item.Season != q.Season && (item.Season != 0 || matchedByID == false) {
+				continue
+			}
+		}
+		if matchedByID == true {
+			idMatched = append(idMatched, item)
+		} else {
+			titleMatched = append(titleMatched, item)
+		}
+	}
+	sortItems(idMatched)
+	sortItems(titleMatched)
+	return append(idMatched, titleMatched...)
+}
+
+// matchID 返回 是否是通过 ID 匹配的，以及 是否匹配
+func (q query) matchID(item indexItem) (bool, bool) {
+
+	if q.ImdbID != "" && item.ImdbID != "" {
+		return true, strings.EqualFold(q.ImdbID, item.ImdbID)
+	}
+	if q.TmdbID != "" && item.TmdbID != "" {
+		return true, q.TmdbID == item.TmdbID
+	}
+	if item.Title == "" {
+		return false, false
+	}
+	if q.Year > 0 && item.Year > 0 && q.Year != item.Year {
+		return false, false
+	}
+	for _, title := range q.Titles {
+		if title != "" && title == item.Title {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func sortItems(items []indexItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].SubFPath < items[j].SubFPath
+	})
+}
+
+type indexBuilder struct {
+	log          *logrus.Logger
+	nfoCache     map[string]types.VideoNfoInfo // Key: 目录
+	nfoFileCache map[string]types.VideoNfoInfo // Key: nfo 文件的全路径
+	nfoListCache map[string]map[string]string  // Key: 目录，Value: 小写的 nfo 文件名（不含后缀） -> 全路径
+}
+
+// indexArchive 解压压缩包，索引其中的字幕，压缩包中没有 nfo 的时候使用压缩包旁边的
+func (b *indexBuilder) indexArchive(archiveFPath, folderPath, extractRoot string) ([]indexItem, error) {
+
+	fInfo, err := os.Stat(archiveFPath)
+	if err != nil {
+		return nil, err
+	}
+	extractDir := filepath.Join(extractRoot, fmt.Sprintf("%x",
+		sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", archiveFPath, fInfo.Size(), fInfo.ModTime().Unix())))))
+	doneFPath := filepath.Join(extractDir, extractDoneFileName)
+	if pkg.IsFile(doneFPath) == false {
+		err = os.RemoveAll(extractDir)
+		if err != nil {
+			return nil, err
+		}
+		err = os.MkdirAll(extractDir, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+		err = archive_helper.UnArchiveFileEx(archiveFPath, extractDir)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(doneFPath, []byte(archiveFPath), os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	archiveItem := b.newItem(archiveFPath, "", folderPath)
+	items := make([]indexItem, 0)
+	err = filepath.WalkDir(extractDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() == true || sub_parser_hub.IsSubExtWanted(path) == false {
+			return nil
+		}
+		item := b.newItem(path, archiveFPath, extractDir)
+		// 压缩包中的字幕，文件名中没有的信息，从压缩包的文件名、旁边的 nfo 中获取
+		if item.ImdbID == "" && item.TmdbID == "" {
+			item.ImdbID = archiveItem.ImdbID
+			item.TmdbID = archiveItem.TmdbID
+		}
+		if item.Title == "" {
+			item.Title = archiveItem.Title
+		}
+		if item.Year == 0 {
+			item.Year = archiveItem.Year
+		}
+		if item.Season == 0 {
+			item.Season = archiveItem.Season
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+/*
+	newItem 从文件名、nfo 中获取信息
+	优先使用与字幕同名的 nfo，比如 Name.nfo 对应 Name.ass、Name.chs.ass
+	没有的话才使用所在目录（直到 rootDir）的 movie.nfo、tvshow.nfo
+*/
+func (b *indexBuilder) newItem(fileFPath, archiveFPath, rootDir string) indexItem {
+
+	item := indexItem{
+		SubFPath:     fileFPath,
+		ArchiveFPath: archiveFPath,
+	}
+	nfoInfo := b.getSameNameNfoInfo(fileFPath)
+	if nfoInfo.ImdbId == "" && nfoInfo.TmdbId == "" {
+		nfoInfo = b.getNfoInfo(filepath.Dir(fileFPath), rootDir)
+	}
+	item.ImdbID = strings.TrimSpace(nfoInfo.ImdbId)
+	item.TmdbID = strings.TrimSpace(nfoInfo.TmdbId)
+
+	fileName := filepath.Base(fileFPath)
+	torrentInfo, err := decode.GetVideoInfoFromFileName(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+	if err != nil {
+		b.log.Debugln("LocalFolder.newItem", "GetVideoInfoFromFileName", fileFPath, err)
+		return item
+	}
+	item.Title = normalizeTitle(torrentInfo.Title)
+	item.Year = torrentInfo.Year
+	item.Season = torrentInfo.Season
+	item.Episode = torrentInfo.Episode
+	return item
+}
+
+// getSameNameNfoInfo 找与文件同名的 nfo，字幕名称可能多了语言的后缀，有多个匹配的时候取名称最长的
+func (b *indexBuilder) getSameNameNfoInfo(fileFPath string) types.VideoNfoInfo {
+
+	fileName := strings.ToLower(filepath.Base(fileFPath))
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	matchedName := ""
+	matchedFPath := ""
+	for nfoName, nfoFPath := range b.getNfoList(filepath.Dir(fileFPath)) {
+		if fileName != nfoName && strings.HasPrefix(fileName, nfoName+".") == false {
+			continue
+		}
+		if len(nfoName) > len(matchedName) {
+			matchedName = nfoName
+			matchedFPath = nfoFPath
+		}
+	}
+	if matchedFPath == "" {
+		return types.VideoNfoInfo{}
+	}
+	return b.getNfoFileInfo(matchedFPath)
+}
+
+/*
+	getNfoInfo 在目录中查找 movie.nfo、tvshow.nfo，没有 ID 的话向上一级查找，直到 rootDir，比如 连续剧/Season 1/xx.ass
+	不会使用目录中其他名称的 nfo，平铺的字幕库中这些 nfo 只属于同名的视频、字幕
+*/
+func (b *indexBuilder) getNfoInfo(dir, rootDir string) types.VideoNfoInfo {
+
+	if nfoInfo, found := b.nfoCache[dir]; found == true {
+		return nfoInfo
+	}
+	nfoInfo := types.VideoNfoInfo{}
+	nfoList := b.getNfoList(dir)
+	if nfoFPath, found := nfoList[movieNfoName]; found == true {
+		nfoInfo = b.getNfoFileInfo(nfoFPath)
+	}
+	if nfoInfo.ImdbId == "" && nfoInfo.TmdbId == "" {
+		if nfoFPath, found := nfoList[tvShowNfoName]; found == true {
+			nfoInfo = b.getNfoFileInfo(nfoFPath)
+		}
+	}
+	if nfoInfo.ImdbId == "" && nfoInfo.TmdbId == "" {
+		parentDir := filepath.Dir(dir)
+		relPath, err := filepath.Rel(rootDir, dir)
+		if err == nil && relPath != "." && strings.HasPrefix(relPath, "..") == false && parentDir != dir {
+			nfoInfo = b.getNfoInfo(parentDir, rootDir)
+		}
+	}
+	b.nfoCache[dir] = nfoInfo
+	return nfoInfo
+}
+
+// getNfoList 目录中的 nfo 文件，Key 为小写的、不含后缀的文件名
+func (b *indexBuilder) getNfoList(dir string) map[string]string {
+
+	if nfoList, found := b.nfoListCache[dir]; found == true {
+		return nfoList
+	}
+	nfoList := make(map[string]string)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		b.log.Debugln("LocalFolder.getNfoList", dir, err)
+	}
+	for _, f := range files {
+		if f.IsDir() == true || strings.ToLower(filepath.Ext(f.Name())) != nfoExt {
+			continue
+		}
+		nfoName := strings.ToLower(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())))
+		nfoList[nfoName] = filepath.Join(dir, f.Name())
+	}
+	b.nfoListCache[dir] = nfoList
+	return nfoList
+}
+
+// getNfoFileInfo 解析一个 nfo 文件，单集的 nfo 中没有需要的 ID，返回空的信息
+func (b *indexBuilder) getNfoFileInfo(nfoFPath string) types.VideoNfoInfo {
+
+	if nfoInfo, found := b.nfoFileCache[nfoFPath]; found == true {
+		return nfoInfo
+	}
+	nfoInfo, err := decode.GetVideoNfoInfoFromNfoFile(nfoFPath)
+	if err != nil {
+		b.log.Debugln("LocalFolder.getNfoFileInfo", nfoFPath, err)
+		nfoInfo = types.VideoNfoInfo{}
+	}
+	b.nfoFileCache[nfoFPath] = nfoInfo
+	return nfoInfo
+}
+
+// normalizeTitle 只保留字母、数字并转为小写，The.Big.Bang.Theory 与 the big bang theory 一致
+func normalizeTitle(title string) string {
+
+	var sb strings.Builder
+	for _, r := range title {
+		if unicode.IsLetter(r) == true || unicode.IsDigit(r) == true {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+const (
+	nfoExt              = ".nfo"
+	movieNfoName        = "movie"             // movie.nfo
+	tvShowNfoName       = "tvshow"            // tvshow.nfo
+	extractDoneFileName = ".csf-extract-done" // 解压完成的标记
+)
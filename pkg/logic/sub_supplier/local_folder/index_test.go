@@ -0,0 +1,137 @@
+package local_folder
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+)
+
+const testSrtContent = "1\n00:00:01,000 --> 00:00:02,000\n你好\n"
+
+func writeTestFile(t *testing.T, fPath, content string) {
+
+	err := os.MkdirAll(filepath.Dir(fPath), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fPath, []byte(content), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestZip(t *testing.T, fPath string, files map[string]string) {
+
+	f, err := os.Create(fPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		oneFile, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = oneFile.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestIndex(t *testing.T) (*Index, string) {
+
+	rootDir := t.TempDir()
+	writeTestFile(t, filepath.Join(rootDir, "Movie A (2010)", "movie.nfo"),
+		"<movie><title>Movie A</title><imdbid>tt1234567</imdbid></movie>")
+	writeTestFile(t, filepath.Join(rootDir, "Movie A (2010)", "Whatever.chs.srt"), testSrtContent)
+	writeTestFile(t, filepath.Join(rootDir, "Some.Movie.2015.1080p.chs.srt"), testSrtContent)
+	writeTestFile(t, filepath.Join(rootDir, "Show", "tvshow.nfo"),
+		"<tvshow><title>Show</title><uniqueid type=\"imdb\">tt7654321</uniqueid></tvshow>")
+	writeTestFile(t, filepath.Join(rootDir, "Show", "Season 1", "Show.S01E02.chs.srt"), testSrtContent)
+	writeTestZip(t, filepath.Join(rootDir, "Other.Show.S02.zip"), map[string]string{
+		"Other.Show.S02E03.srt": testSrtContent,
+	})
+
+	idx, err := BuildIndex(log_helper.GetLogger4Tester(), []string{rootDir}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 4 {
+		t.Fatal("Index Len", idx.Len(), idx.items)
+	}
+	return idx, rootDir
+}
+
+func TestIndexSearchMovie(t *testing.T) {
+
+	idx, rootDir := newTestIndex(t)
+
+	items := idx.search(query{ImdbID: "tt1234567", IsMovie: true})
+	if len(items) != 1 || items[0].SubFPath != filepath.Join(rootDir, "Movie A (2010)", "Whatever.chs.srt") {
+		t.Fatal("search by imdb id", items)
+	}
+	items = idx.search(query{ImdbID: "tt0000000", Titles: []string{normalizeTitle("Some Movie")}, Year: 2015, IsMovie: true})
+	if len(items) != 1 || items[0].SubFPath != filepath.Join(rootDir, "Some.Movie.2015.1080p.chs.srt") {
+		t.Fatal("search by title", items)
+	}
+	items = idx.search(query{Titles: []string{normalizeTitle("Some Movie")}, Year: 2016, IsMovie: true})
+	if len(items) != 0 {
+		t.Fatal("search by title with wrong year", items)
+	}
+}
+
+func TestIndexSearchSeries(t *testing.T) {
+
+	idx, rootDir := newTestIndex(t)
+
+	items := idx.search(query{ImdbID: "tt7654321", Season: 1, Episode: 2})
+	if len(items) != 1 || items[0].SubFPath != filepath.Join(rootDir, "Show", "Season 1", "Show.S01E02.chs.srt") {
+		t.Fatal("search by imdb id", items)
+	}
+	items = idx.search(query{ImdbID: "tt7654321", Season: 1, Episode: 3})
+	if len(items) != 0 {
+		t.Fatal("search by imdb id with wrong episode", items)
+	}
+	// 压缩包中的字幕
+	items = idx.search(query{Titles: []string{normalizeTitle("Other Show")}, Season: 2, Episode: 3})
+	if len(items) != 1 || items[0].ArchiveFPath != filepath.Join(rootDir, "Other.Show.S02.zip") {
+		t.Fatal("search in archive", items)
+	}
+	if _, err := os.Stat(items[0].SubFPath); err != nil {
+		t.Fatal("extracted sub not exist", err)
+	}
+}
+
+func TestIndexSameNameNfo(t *testing.T) {
+
+	rootDir := t.TempDir()
+	// 平铺的字幕库，nfo 只属于同名的字幕
+	writeTestFile(t, filepath.Join(rootDir, "Other.nfo"),
+		"<movie><title>Other</title><imdbid>tt1111111</imdbid></movie>")
+	writeTestFile(t, filepath.Join(rootDir, "Name.nfo"),
+		"<movie><title>Name</title><imdbid>tt2222222</imdbid></movie>")
+	writeTestFile(t, filepath.Join(rootDir, "Name.chs.srt"), testSrtContent)
+	writeTestFile(t, filepath.Join(rootDir, "Unrelated.chs.srt"), testSrtContent)
+
+	idx, err := BuildIndex(log_helper.GetLogger4Tester(), []string{rootDir}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := idx.search(query{ImdbID: "tt2222222", IsMovie: true})
+	if len(items) != 1 || items[0].SubFPath != filepath.Join(rootDir, "Name.chs.srt") {
+		t.Fatal("search by same name nfo", items)
+	}
+	items = idx.search(query{ImdbID: "tt1111111", IsMovie: true})
+	if len(items) != 0 {
+		t.Fatal("unrelated nfo should not be used", items)
+	}
+}
@@ -0,0 +1,247 @@
+package local_folder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	Supplier 本地（或者 NAS 上）的字幕库，不需要联网
+	第一次搜索（或者 CheckAlive）的时候建立索引，超过 IndexRefreshInterval 后重新建立
+	视频的 IMDB、TMDB ID 只从本地的 nfo 中读取，名称、年份、季、集从文件名中解析
+*/
+type Supplier struct {
+	log                 *logrus.Logger
+	fileDownloader      *file_downloader.FileDownloader
+	localFolderSettings settings.LocalFolderSettings
+	subParserHub        *sub_parser_hub.SubParserHub
+	isAlive             bool
+
+	indexLocker    sync.Mutex
+	index          *Index
+	indexBuildTime time.Time
+}
+
+func NewSupplier(fileDownloader *file_downloader.FileDownloader) *Supplier {
+
+	sup := Supplier{}
+	sup.log = fileDownloader.Log
+	sup.fileDownloader = fileDownloader
+	sup.localFolderSettings = settings.Get().SubtitleSources.LocalFolderSettings
+	sup.subParserHub = sub_parser_hub.NewSubParserHub(sup.log, ass.NewParser(sup.log), srt.NewParser(sup.log))
+	sup.isAlive = true // 默认是可以使用的，如果 check 后，再调整状态
+
+	return &sup
+}
+
+// CheckAlive 建立一次索引，返回的是建立索引的耗时
+func (s *Supplier) CheckAlive() (bool, int64) {
+
+	startT := time.Now()
+	idx, err := s.getIndex()
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive", "getIndex", err)
+		s.isAlive = false
+		return false, 0
+	}
+	s.log.Infoln(s.GetSupplierName(), "Index Subs Count:", idx.Len())
+	s.isAlive = true
+	return true, time.Since(startT).Milliseconds()
+}
+
+func (s *Supplier) IsAlive() bool {
+	return s.isAlive
+}
+
+// OverDailyDownloadLimit 本地的字幕库没有下载次数的限制
+func (s *Supplier) OverDailyDownloadLimit() bool {
+	return false
+}
+
+func (s *Supplier) GetLogger() *logrus.Logger {
+	return s.log
+}
+
+func (s *Supplier) GetSupplierName() string {
+	return common.SubSiteLocalFolder
+}
+
+func (s *Supplier) GetSubListFromFile4Movie(filePath string) ([]supplier.SubInfo, error) {
+
+	if s.localFolderSettings.Enabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+
+	q := query{IsMovie: true}
+	nfoInfo, err := decode.GetVideoNfoInfo4Movie(filePath)
+	if err != nil {
+		s.log.Debugln(s.GetSupplierName(), "GetVideoNfoInfo4Movie", filePath, err)
+	}
+	q.ImdbID = strings.TrimSpace(nfoInfo.ImdbId)
+	q.TmdbID = strings.TrimSpace(nfoInfo.TmdbId)
+	q.Year = nfoInfo.GetYear()
+	q.Titles = append(q.Titles, normalizeTitle(nfoInfo.OriginalTitle), normalizeTitle(nfoInfo.Title))
+	s.addFileNameInfo(&q, filePath)
+
+	return s.getSubList(q, filePath)
+}
+
+func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	if s.localFolderSettings.Enabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+// GetSubListFromFile4Anime 字幕库中的动画也是按 季、集 整理的，同连续剧的逻辑
+func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	if s.localFolderSettings.Enabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	var allSupplierSubInfo = make([]supplier.SubInfo, 0)
+	// 这里拿到的 seriesInfo ，里面包含了，需要下载字幕的 Eps 信息
+	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
+
+		q := query{
+			ImdbID:  seriesInfo.ImdbId,
+			TmdbID:  seriesInfo.TmdbId,
+			Titles:  []string{normalizeTitle(seriesInfo.Name), normalizeTitle(filepath.Base(seriesInfo.DirPath))},
+			IsMovie: false,
+			Season:  episodeInfo.Season,
+			Episode: episodeInfo.Episode,
+		}
+		s.addFileNameInfo(&q, episodeInfo.FileFullPath)
+		// 年份只用于电影，连续剧的字幕文件名中的年份不一定是首播的年份
+		q.Year = 0
+
+		one, err := s.getSubList(q, episodeInfo.FileFullPath)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "getSubList", episodeInfo.FileFullPath, err)
+			continue
+		}
+		if len(one) < 1 {
+			// 没有搜索到字幕
+			s.log.Infoln(s.GetSupplierName(), "Not Find Sub can be download",
+				episodeInfo.Title, episodeInfo.Season, episodeInfo.Episode)
+			continue
+		}
+		// 需要赋值给字幕结构
+		for i := range one {
+			one[i].Season = episodeInfo.Season
+			one[i].Episode = episodeInfo.Episode
+		}
+		allSupplierSubInfo = append(allSupplierSubInfo, one...)
+	}
+	return allSupplierSubInfo, nil
+}
+
+// addFileNameInfo 从视频的文件名中解析名称、年份
+func (s *Supplier) addFileNameInfo(q *query, videoFPath string) {
+
+	fileName := filepath.Base(videoFPath)
+	torrentInfo, err := decode.GetVideoInfoFromFileName(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+	if err != nil {
+		s.log.Debugln(s.GetSupplierName(), "GetVideoInfoFromFileName", videoFPath, err)
+		return
+	}
+	q.Titles = append(q.Titles, normalizeTitle(torrentInfo.Title))
+	if q.Year == 0 {
+		q.Year = torrentInfo.Year
+	}
+}
+
+func (s *Supplier) getSubList(q query, videoFPath string) ([]supplier.SubInfo, error) {
+
+	defer func() {
+		s.log.Debugln(s.GetSupplierName(), videoFPath, "End...")
+	}()
+	s.log.Debugln(s.GetSupplierName(), videoFPath, "Start...")
+
+	idx, err := s.getIndex()
+	if err != nil {
+		return nil, err
+	}
+	outSubInfoList := make([]supplier.SubInfo, 0)
+	for _, item := range idx.search(q) {
+
+		// 使用字幕解析器确认是能用的字幕，顺便得到字幕的语言
+		bok, fileInfo, err := s.subParserHub.DetermineFileTypeFromFile(item.SubFPath)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), "DetermineFileTypeFromFile", item.SubFPath, err)
+			continue
+		}
+		if bok == false {
+			continue
+		}
+		fileData, err := os.ReadFile(item.SubFPath)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), "ReadFile", item.SubFPath, err)
+			continue
+		}
+		subInfo := supplier.NewSubInfo(s.GetSupplierName(), int64(len(outSubInfoList)), filepath.Base(item.SubFPath),
+			fileInfo.Lang, item.SubFPath, 0, 0, filepath.Ext(item.SubFPath), fileData)
+		outSubInfoList = append(outSubInfoList, *subInfo)
+		// 如果够了那么多个字幕就返回
+		if len(outSubInfoList) >= settings.Get().AdvancedSettings.Topic {
+			break
+		}
+	}
+	return outSubInfoList, nil
+}
+
+// getIndex 没有索引或者索引过期的时候重新建立
+func (s *Supplier) getIndex() (*Index, error) {
+
+	s.indexLocker.Lock()
+	defer s.indexLocker.Unlock()
+
+	if s.index != nil && time.Since(s.indexBuildTime) < s.getIndexRefreshInterval() {
+		return s.index, nil
+	}
+	if len(s.localFolderSettings.FolderPaths) < 1 {
+		return nil, errors.New("folder_paths is empty")
+	}
+	extractRoot, err := pkg.GetLocalFolderSubCacheFolder()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := BuildIndex(s.log, s.localFolderSettings.FolderPaths, extractRoot)
+	if err != nil {
+		return nil, err
+	}
+	s.index = idx
+	s.indexBuildTime = time.Now()
+	return s.index, nil
+}
+
+func (s *Supplier) getIndexRefreshInterval() time.Duration {
+	if s.localFolderSettings.IndexRefreshInterval <= 0 {
+		return defIndexRefreshInterval
+	}
+	return time.Duration(s.localFolderSettings.IndexRefreshInterval) * time.Minute
+}
+
+const defIndexRefreshInterval = 60 * time.Minute
@@ -0,0 +1,161 @@
+package opensubtitles
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/go-resty/resty/v2"
+)
+
+// Api OpenSubtitles.com 的 REST API，每个请求都需要带上 Api-Key 以及 User-Agent
+type Api struct {
+	rootUrl string
+	apiKey  string
+}
+
+func NewApi(rootUrl string, apiKey string) *Api {
+	return &Api{rootUrl: strings.TrimRight(rootUrl, "/"), apiKey: apiKey}
+}
+
+// SearchParams 查询字幕的参数，电影使用 ImdbID，连续剧使用 ParentImdbID + Season + Episode
+type SearchParams struct {
+	MovieHash    string
+	ImdbID       string
+	ParentImdbID string
+	Season       int
+	Episode      int
+	Languages    []string
+}
+
+// Login 登录，得到的 Token 用于下载
+func (a *Api) Login(client *resty.Client, userName, password string) (*LoginResponse, error) {
+
+	var loginResponse LoginResponse
+	resp, err := a.newRequest(client, "").
+		SetBody(map[string]string{
+			"username": userName,
+			"password": password,
+		}).
+		Post(a.rootUrl + common.SubOpenSubtitlesLoginUrl)
+	if err != nil {
+		return nil, err
+	}
+	err = a.parseResponse(resp, &loginResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &loginResponse, nil
+}
+
+// Search 查询字幕，token 可以为空
+func (a *Api) Search(client *resty.Client, token string, params SearchParams) (*SearchResponse, error) {
+
+	queryParams := make(map[string]string)
+	if params.MovieHash != "" {
+		queryParams["moviehash"] = params.MovieHash
+	}
+	if params.ImdbID != "" {
+		queryParams["imdb_id"] = imdbID2Number(params.ImdbID)
+	}
+	if params.ParentImdbID != "" {
+		queryParams["parent_imdb_id"] = imdbID2Number(params.ParentImdbID)
+	}
+	if params.Season > 0 {
+		queryParams["season_number"] = strconv.Itoa(params.Season)
+	}
+	if params.Episode > 0 {
+		queryParams["episode_number"] = strconv.Itoa(params.Episode)
+	}
+	if len(params.Languages) > 0 {
+		queryParams["languages"] = joinLanguages(params.Languages)
+	}
+
+	var searchResponse SearchResponse
+	resp, err := a.newRequest(client, token).
+		SetQueryParams(queryParams).
+		Get(a.rootUrl + common.SubOpenSubtitlesSearchUrl)
+	if err != nil {
+		return nil, err
+	}
+	err = a.parseResponse(resp, &searchResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &searchResponse, nil
+}
+
+// Download 获取字幕文件的下载地址，会消耗一次今日的下载次数
+func (a *Api) Download(client *resty.Client, token string, fileID int) (*DownloadResponse, error) {
+
+	var downloadResponse DownloadResponse
+	resp, err := a.newRequest(client, token).
+		SetBody(map[string]int{
+			"file_id": fileID,
+		}).
+		Post(a.rootUrl + common.SubOpenSubtitlesDownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	err = a.parseResponse(resp, &downloadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &downloadResponse, nil
+}
+
+func (a *Api) newRequest(client *resty.Client, token string) *resty.Request {
+
+	req := client.R().
+		SetHeader("Api-Key", a.apiKey).
+		SetHeader("Accept", "application/json").
+		// OpenSubtitles 要求使用 App 的名称以及版本作为 User-Agent
+		SetHeader("User-Agent", "ChineseSubFinder v"+pkg.AppVersion())
+	if token != "" {
+		req.SetHeader("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func (a *Api) parseResponse(resp *resty.Response, out interface{}) error {
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode() != http.StatusOK {
+		var errorResponse ErrorResponse
+		_ = json.Unmarshal(resp.Body(), &errorResponse)
+		return supplier.NewStatusCodeError("opensubtitles", resp.StatusCode(), errorResponse.Message)
+	}
+	return json.Unmarshal(resp.Body(), out)
+}
+
+// imdbID2Number OpenSubtitles 的 IMDB ID 是数字，去掉 tt 以及前面的 0
+func imdbID2Number(imdbID string) string {
+
+	imdbID = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(imdbID)), "tt")
+	number := strings.TrimLeft(imdbID, "0")
+	if number == "" {
+		return "0"
+	}
+	return number
+}
+
+// joinLanguages OpenSubtitles 建议语言按字母顺序排列，小写，逗号分隔
+func joinLanguages(languages []string) string {
+
+	langs := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return strings.Join(langs, ",")
+}
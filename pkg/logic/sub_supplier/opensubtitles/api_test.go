@@ -0,0 +1,191 @@
+package opensubtitles
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/go-resty/resty/v2"
+)
+
+func newTestServer(t *testing.T, apiKey string) *httptest.Server {
+
+	writeFixture := func(w http.ResponseWriter, fileName string) {
+		data, err := os.ReadFile(filepath.Join("testdata", fileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}
+	checkHeader := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Api-Key") != apiKey {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		if strings.HasPrefix(r.Header.Get("User-Agent"), "ChineseSubFinder v") == false {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(common.SubOpenSubtitlesLoginUrl, func(w http.ResponseWriter, r *http.Request) {
+		if checkHeader(w, r) == false {
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPost || body["username"] != "user_1" || body["password"] != "pass_1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeFixture(w, "login.json")
+	})
+	mux.HandleFunc(common.SubOpenSubtitlesSearchUrl, func(w http.ResponseWriter, r *http.Request) {
+		if checkHeader(w, r) == false {
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("languages") != "en,fr" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if query.Get("moviehash") == "8e245d9679d31e12" {
+			writeFixture(w, "search_hash.json")
+			return
+		}
+		if query.Get("parent_imdb_id") == "7654321" && query.Get("season_number") == "1" && query.Get("episode_number") == "2" {
+			writeFixture(w, "search_imdb.json")
+			return
+		}
+		_, _ = w.Write([]byte(`{"total_pages":0,"total_count":0,"page":1,"data":[]}`))
+	})
+	mux.HandleFunc(common.SubOpenSubtitlesDownloadUrl, func(w http.ResponseWriter, r *http.Request) {
+		if checkHeader(w, r) == false {
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token_1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]int
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["file_id"] != 2001 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeFixture(w, "download.json")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestApi(t *testing.T) {
+
+	server := newTestServer(t, "key_1")
+	defer server.Close()
+
+	client := resty.New()
+	api := NewApi(server.URL+"/", "key_1")
+
+	loginResponse, err := api.Login(client, "user_1", "pass_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginResponse.Token != "token_1" || loginResponse.User.AllowedDownloads != 100 {
+		t.Fatal("Login", loginResponse)
+	}
+	_, err = api.Login(client, "user_1", "wrong")
+	if err != ErrUnauthorized {
+		t.Fatal("Login with wrong password, want ErrUnauthorized, got", err)
+	}
+
+	languages := []string{"FR", " en"}
+	searchResponse, err := api.Search(client, "", SearchParams{MovieHash: "8e245d9679d31e12", Languages: languages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(searchResponse.Data) != 2 || searchResponse.Data[0].Attributes.MovieHashMatch != true ||
+		searchResponse.Data[0].Attributes.Files[0].FileID != 2001 {
+		t.Fatal("Search by moviehash", searchResponse)
+	}
+	searchResponse, err = api.Search(client, "", SearchParams{ParentImdbID: "tt7654321", Season: 1, Episode: 2, Languages: languages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(searchResponse.Data) != 1 || searchResponse.Data[0].Attributes.FeatureDetails.EpisodeNumber != 2 {
+		t.Fatal("Search by parent_imdb_id", searchResponse)
+	}
+
+	downloadResponse, err := api.Download(client, "token_1", 2001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloadResponse.Link == "" || downloadResponse.Remaining != 99 {
+		t.Fatal("Download", downloadResponse)
+	}
+	_, err = api.Download(client, "token_expired", 2001)
+	if err != ErrUnauthorized {
+		t.Fatal("Download with expired token, want ErrUnauthorized, got", err)
+	}
+
+	_, err = NewApi(server.URL, "key_2").Search(client, "", SearchParams{ImdbID: "tt0000001", Languages: languages})
+	if err == nil {
+		t.Fatal("Search with wrong Api-Key, want error")
+	}
+}
+
+func TestComputeFileHash(t *testing.T) {
+
+	tmpDir := t.TempDir()
+	size := int64(hashChunkSize * 3)
+	data := make([]byte, size)
+	// 开头、结尾的 64KB 参与计算，中间的不参与
+	data[0] = 1
+	data[hashChunkSize+8] = 9
+	data[size-8] = 2
+
+	fPath := filepath.Join(tmpDir, "video.mkv")
+	err := os.WriteFile(fPath, data, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := ComputeFileHash(fPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "0000000000030003" {
+		t.Fatal("ComputeFileHash", hash)
+	}
+
+	smallFPath := filepath.Join(tmpDir, "small.mkv")
+	err = os.WriteFile(smallFPath, make([]byte, hashChunkSize), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ComputeFileHash(smallFPath)
+	if err != common.VideoFileIsTooSmall {
+		t.Fatal("ComputeFileHash small file, want VideoFileIsTooSmall, got", err)
+	}
+}
+
+func TestImdbID2Number(t *testing.T) {
+
+	tests := map[string]string{
+		"tt0111161": "111161",
+		"TT7654321": "7654321",
+		"1234":      "1234",
+		"tt0000000": "0",
+	}
+	for imdbID, want := range tests {
+		if got := imdbID2Number(imdbID); got != want {
+			t.Errorf("imdbID2Number(%s) = %s, want %s", imdbID, got, want)
+		}
+	}
+}
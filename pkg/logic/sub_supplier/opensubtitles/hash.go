@@ -0,0 +1,47 @@
+package opensubtitles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+/*
+	ComputeFileHash OpenSubtitles 的 moviehash，与 shooter.ComputeFileHash 的作用一样，是视频文件的特征值
+	文件的大小，加上开头、结尾各 64KB 按 uint64（小端）累加，溢出忽略，输出 16 位的十六进制
+*/
+func ComputeFileHash(filePath string) (string, error) {
+
+	fp, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = fp.Close()
+	}()
+	stat, err := fp.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := stat.Size()
+	if size < hashChunkSize*2 {
+		return "", common.VideoFileIsTooSmall
+	}
+
+	hash := uint64(size)
+	buf := make([]byte, hashChunkSize)
+	for _, position := range []int64{0, size - hashChunkSize} {
+		_, err = fp.ReadAt(buf, position)
+		if err != nil {
+			return "", err
+		}
+		for i := 0; i < hashChunkSize; i += 8 {
+			hash += binary.LittleEndian.Uint64(buf[i : i+8])
+		}
+	}
+	return fmt.Sprintf("%016x", hash), nil
+}
+
+const hashChunkSize = 64 * 1024
@@ -0,0 +1,71 @@
+package opensubtitles
+
+// LoginResponse POST /login 的返回
+type LoginResponse struct {
+	User struct {
+		AllowedDownloads int    `json:"allowed_downloads"` // 每日允许的下载次数
+		Level            string `json:"level"`
+		UserID           int    `json:"user_id"`
+		Vip              bool   `json:"vip"`
+	} `json:"user"`
+	BaseUrl string `json:"base_url"` // VIP 用户会返回不同的域名
+	Token   string `json:"token"`
+	Status  int    `json:"status"`
+}
+
+// SearchResponse GET /subtitles 的返回
+type SearchResponse struct {
+	TotalPages int            `json:"total_pages"`
+	TotalCount int            `json:"total_count"`
+	Page       int            `json:"page"`
+	Data       []SubtitleData `json:"data"`
+}
+
+type SubtitleData struct {
+	ID         string             `json:"id"`
+	Type       string             `json:"type"`
+	Attributes SubtitleAttributes `json:"attributes"`
+}
+
+type SubtitleAttributes struct {
+	SubtitleID      string         `json:"subtitle_id"`
+	Language        string         `json:"language"`
+	DownloadCount   int            `json:"download_count"`
+	HearingImpaired bool           `json:"hearing_impaired"`
+	Fps             float64        `json:"fps"`
+	Release         string         `json:"release"`
+	MovieHashMatch  bool           `json:"moviehash_match"` // 是通过视频的 hash 匹配上的
+	FeatureDetails  FeatureDetails `json:"feature_details"`
+	Files           []SubtitleFile `json:"files"`
+}
+
+type FeatureDetails struct {
+	FeatureID     int    `json:"feature_id"`
+	Year          int    `json:"year"`
+	Title         string `json:"title"`
+	ImdbID        int    `json:"imdb_id"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
+type SubtitleFile struct {
+	FileID   int    `json:"file_id"`
+	CdNumber int    `json:"cd_number"`
+	FileName string `json:"file_name"`
+}
+
+// DownloadResponse POST /download 的返回，link 是有时效性的
+type DownloadResponse struct {
+	Link         string `json:"link"`
+	FileName     string `json:"file_name"`
+	Requests     int    `json:"requests"`  // 今日已经下载的次数
+	Remaining    int    `json:"remaining"` // 今日剩余的下载次数
+	Message      string `json:"message"`
+	ResetTimeUtc string `json:"reset_time_utc"`
+}
+
+// ErrorResponse 出错时的返回
+type ErrorResponse struct {
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
@@ -0,0 +1,410 @@
+package opensubtitles
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/mix_media_info"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	Supplier OpenSubtitles.com 的字幕源，主要用于下载英文等非中文的字幕，作为双语合并的参考
+	1. 先按视频的 moviehash 查询，没有结果再按 IMDB ID（连续剧是 parent_imdb_id + 季 + 集）查询
+	2. 设置了用户名、密码的时候会先登录，Token 过期（或者被拒绝）后重新登录
+	3. 下载次数记录在 DailyDownloadInfo 中，DailyDownloadLimit 为 -1 的时候，以登录返回的每日下载次数为准，下载时返回的剩余次数为 0 则不再下载
+*/
+type Supplier struct {
+	log                   *logrus.Logger
+	fileDownloader        *file_downloader.FileDownloader
+	openSubtitlesSettings settings.OpenSubtitlesSettings
+	api                   *Api
+	isAlive               bool
+
+	tokenLocker      sync.Mutex
+	token            string
+	tokenTime        time.Time
+	allowedDownloads int // 登录返回的每日下载次数，没有登录的时候是 0
+	remaining        int // 下载时返回的今日剩余次数，-1 是未知
+}
+
+func NewSupplier(fileDownloader *file_downloader.FileDownloader) *Supplier {
+
+	sup := Supplier{}
+	sup.log = fileDownloader.Log
+	sup.fileDownloader = fileDownloader
+	sup.openSubtitlesSettings = settings.Get().SubtitleSources.OpenSubtitlesSettings
+	sup.api = NewApi(getRootUrl(), sup.openSubtitlesSettings.ApiKey)
+	sup.isAlive = true // 默认是可以使用的，如果 check 后，再调整状态
+	sup.remaining = -1
+
+	return &sup
+}
+
+func (s *Supplier) CheckAlive() (bool, int64) {
+
+	// 如果没有设置这个 API 接口，那么就任务是不可用的
+	if s.openSubtitlesSettings.ApiKey == "" {
+		s.isAlive = false
+		return false, 0
+	}
+	// 计算当前时间
+	startT := time.Now()
+
+	client, err := pkg.NewHttpClient()
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive", "NewHttpClient", err)
+		s.isAlive = false
+		return false, 0
+	}
+	token, api, err := s.getToken(client, false)
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive", "Login", err)
+		s.isAlive = false
+		return false, 0
+	}
+	_, err = api.Search(client, token, SearchParams{ImdbID: checkImdbID, Languages: s.getLanguages()})
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive", "Search", err)
+		s.isAlive = false
+		return false, 0
+	}
+
+	s.isAlive = true
+	return true, time.Since(startT).Milliseconds()
+}
+
+func (s *Supplier) IsAlive() bool {
+	return s.isAlive
+}
+
+func (s *Supplier) OverDailyDownloadLimit() bool {
+
+	// 如果没有设置这个 API 接口，那么就任务是不可用的
+	if s.openSubtitlesSettings.ApiKey == "" {
+		return true
+	}
+	oneSupplierSettings := settings.Get().AdvancedSettings.SuppliersSettings.OpenSubtitles
+	if oneSupplierSettings.DailyDownloadLimit == 0 {
+		s.log.Warningln(s.GetSupplierName(), "DailyDownloadLimit is 0, will Skip Download")
+		return true
+	}
+
+	s.tokenLocker.Lock()
+	remaining := s.remaining
+	dailyDownloadLimit := oneSupplierSettings.DailyDownloadLimit
+	if dailyDownloadLimit < 0 {
+		dailyDownloadLimit = s.allowedDownloads
+	}
+	s.tokenLocker.Unlock()
+
+	// OpenSubtitles 返回的今日剩余次数
+	if remaining == 0 {
+		return true
+	}
+	if dailyDownloadLimit > 0 {
+		count, err := s.fileDownloader.CacheCenter.DailyDownloadCountGet(s.GetSupplierName(),
+			pkg.GetPublicIP(s.log, settings.Get().AdvancedSettings.TaskQueue))
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), "DailyDownloadCountGet", err)
+			return false
+		}
+		if count >= dailyDownloadLimit {
+			return true
+		}
+	}
+	// 没有超出限制
+	return false
+}
+
+func (s *Supplier) GetLogger() *logrus.Logger {
+	return s.log
+}
+
+func (s *Supplier) GetSupplierName() string {
+	return common.SubSiteOpenSubtitles
+}
+
+func (s *Supplier) GetSubListFromFile4Movie(filePath string) ([]supplier.SubInfo, error) {
+
+	outSubInfos := make([]supplier.SubInfo, 0)
+	if s.openSubtitlesSettings.Enabled == false {
+		return outSubInfos, nil
+	}
+	if s.openSubtitlesSettings.ApiKey == "" {
+		return nil, errors.New("ApiKey is empty")
+	}
+
+	imdbID := ""
+	mediaInfo, err := mix_media_info.GetMixMediaInfo(s.fileDownloader.MediaInfoDealers, filePath, true)
+	if err != nil {
+		// 还可以按 moviehash 查询
+		s.log.Warningln(s.GetSupplierName(), filePath, "GetMixMediaInfo", err)
+	} else {
+		imdbID = mediaInfo.ImdbId
+	}
+	return s.getSubListFromFile(filePath, true, imdbID, 0, 0)
+}
+
+func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	outSubInfos := make([]supplier.SubInfo, 0)
+	if s.openSubtitlesSettings.Enabled == false {
+		return outSubInfos, nil
+	}
+	if s.openSubtitlesSettings.ApiKey == "" {
+		return nil, errors.New("ApiKey is empty")
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+// GetSubListFromFile4Anime OpenSubtitles 中的动画也是按 季、集 编号的，同连续剧的逻辑
+func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+	return s.GetSubListFromFile4Series(seriesInfo)
+}
+
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	var allSupplierSubInfo = make([]supplier.SubInfo, 0)
+	// 这里拿到的 seriesInfo ，里面包含了，需要下载字幕的 Eps 信息
+	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
+
+		one, err := s.getSubListFromFile(episodeInfo.FileFullPath, false, seriesInfo.ImdbId, episodeInfo.Season, episodeInfo.Episode)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "getSubListFromFile", episodeInfo.FileFullPath, err)
+			continue
+		}
+		if len(one) < 1 {
+			// 没有搜索到字幕
+			s.log.Infoln(s.GetSupplierName(), "Not Find Sub can be download",
+				episodeInfo.Title, episodeInfo.Season, episodeInfo.Episode)
+			continue
+		}
+		// 需要赋值给字幕结构
+		for i := range one {
+			one[i].Season = episodeInfo.Season
+			one[i].Episode = episodeInfo.Episode
+		}
+		allSupplierSubInfo = append(allSupplierSubInfo, one...)
+	}
+	return allSupplierSubInfo, nil
+}
+
+func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool, imdbID string, season, episode int) ([]supplier.SubInfo, error) {
+
+	defer func() {
+		s.log.Debugln(s.GetSupplierName(), videoFPath, "End...")
+	}()
+	s.log.Debugln(s.GetSupplierName(), videoFPath, "Start...")
+
+	client, err := pkg.NewHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	subtitles, err := s.search(client, videoFPath, isMovie, imdbID, season, episode)
+	if err != nil {
+		return nil, err
+	}
+
+	outSubInfoList := make([]supplier.SubInfo, 0)
+	videoFileName := filepath.Base(videoFPath)
+	for index, subtitle := range subtitles {
+
+		if len(subtitle.Attributes.Files) < 1 {
+			continue
+		}
+		// 多 CD 的字幕只下载第一个文件
+		fileID := subtitle.Attributes.Files[0].FileID
+		subInfo, err := s.downloadOne(client, int64(index), videoFileName, fileID)
+		if err != nil {
+			if errors.Is(err, ErrOverDailyDownloadLimit) == true {
+				s.log.Infoln(s.GetSupplierName(), "Over Daily Download Limit")
+				break
+			}
+			s.log.Errorln(s.GetSupplierName(), "downloadOne", fileID, err)
+			continue
+		}
+		if subtitle.Attributes.Release != "" {
+			subInfo.ReleaseName = subtitle.Attributes.Release
+		}
+		outSubInfoList = append(outSubInfoList, *subInfo)
+		// 如果够了那么多个字幕就返回
+		if len(outSubInfoList) >= settings.Get().AdvancedSettings.Topic {
+			break
+		}
+	}
+	return outSubInfoList, nil
+}
+
+// search 先按 moviehash 查询，只保留 hash 匹配上的，没有结果再按 IMDB ID 查询
+func (s *Supplier) search(client *resty.Client, videoFPath string, isMovie bool, imdbID string, season, episode int) ([]SubtitleData, error) {
+
+	token, api, err := s.getToken(client, false)
+	if err != nil {
+		return nil, err
+	}
+	languages := s.getLanguages()
+
+	if pkg.IsFile(videoFPath) == true {
+		movieHash, err := ComputeFileHash(videoFPath)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), videoFPath, "ComputeFileHash", err)
+		} else {
+			searchResponse, err := api.Search(client, token, SearchParams{MovieHash: movieHash, Languages: languages})
+			if err != nil {
+				return nil, err
+			}
+			subtitles := make([]SubtitleData, 0)
+			for _, subtitle := range searchResponse.Data {
+				if subtitle.Attributes.MovieHashMatch == false {
+					continue
+				}
+				// 连续剧需要确认是同一集
+				details := subtitle.Attributes.FeatureDetails
+				if isMovie == false && details.SeasonNumber > 0 &&
+					(details.SeasonNumber != season || details.EpisodeNumber != episode) {
+					continue
+				}
+				subtitles = append(subtitles, subtitle)
+			}
+			if len(subtitles) > 0 {
+				return subtitles, nil
+			}
+		}
+	}
+
+	if imdbID == "" {
+		return make([]SubtitleData, 0), nil
+	}
+	params := SearchParams{Languages: languages}
+	if isMovie == true {
+		params.ImdbID = imdbID
+	} else {
+		params.ParentImdbID = imdbID
+		params.Season = season
+		params.Episode = episode
+	}
+	searchResponse, err := api.Search(client, token, params)
+	if err != nil {
+		return nil, err
+	}
+	return searchResponse.Data, nil
+}
+
+// downloadOne 下载地址是有时效性的，缓存使用 file_id 作为唯一标识，缓存中存在的不会消耗下载次数
+func (s *Supplier) downloadOne(client *resty.Client, topN int64, videoFileName string, fileID int) (*supplier.SubInfo, error) {
+
+	fileUID := fmt.Sprintf("%s-%d", s.GetSupplierName(), fileID)
+	found, subInfo, err := s.fileDownloader.CacheCenter.DownloadFileGet(fileUID)
+	if err != nil {
+		return nil, err
+	}
+	if found == true {
+		return subInfo, nil
+	}
+
+	s.tokenLocker.Lock()
+	remaining := s.remaining
+	s.tokenLocker.Unlock()
+	if remaining == 0 {
+		return nil, ErrOverDailyDownloadLimit
+	}
+
+	token, api, err := s.getToken(client, false)
+	if err != nil {
+		return nil, err
+	}
+	downloadResponse, err := api.Download(client, token, fileID)
+	if errors.Is(err, ErrUnauthorized) == true && s.openSubtitlesSettings.UserName != "" {
+		// Token 过期了，重新登录一次
+		token, api, err = s.getToken(client, true)
+		if err != nil {
+			return nil, err
+		}
+		downloadResponse, err = api.Download(client, token, fileID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.tokenLocker.Lock()
+	s.remaining = downloadResponse.Remaining
+	s.tokenLocker.Unlock()
+	if downloadResponse.Link == "" {
+		if downloadResponse.Remaining <= 0 {
+			return nil, ErrOverDailyDownloadLimit
+		}
+		return nil, errors.New("download link is empty, " + downloadResponse.Message)
+	}
+
+	// 下载成功会统计到今日的下载次数中
+	return s.fileDownloader.Get(s.GetSupplierName(), topN, videoFileName, downloadResponse.Link, 0, 0, fileUID)
+}
+
+/*
+	getToken 没有设置用户名的时候返回空的 Token，forceLogin 为 true 的时候忽略已有的 Token
+	登录后 api 可能会换成 VIP 的域名，所以 api 只在 tokenLocker 中读写，与 Token 一起返回
+*/
+func (s *Supplier) getToken(client *resty.Client, forceLogin bool) (string, *Api, error) {
+
+	s.tokenLocker.Lock()
+	defer s.tokenLocker.Unlock()
+
+	if s.openSubtitlesSettings.UserName == "" {
+		return "", s.api, nil
+	}
+	if forceLogin == false && s.token != "" && time.Since(s.tokenTime) < tokenExpiration {
+		return s.token, s.api, nil
+	}
+	loginResponse, err := s.api.Login(client, s.openSubtitlesSettings.UserName, s.openSubtitlesSettings.Password)
+	if err != nil {
+		return "", nil, err
+	}
+	if loginResponse.Token == "" {
+		return "", nil, errors.New("login token is empty")
+	}
+	s.token = loginResponse.Token
+	s.tokenTime = time.Now()
+	s.allowedDownloads = loginResponse.User.AllowedDownloads
+	// VIP 用户需要使用返回的域名，自定义了 RootUrl 的时候不替换
+	if loginResponse.BaseUrl != "" && getRootUrl() == common.SubOpenSubtitlesRootUrlDef {
+		s.api = NewApi("https://"+loginResponse.BaseUrl+"/api/v1", s.openSubtitlesSettings.ApiKey)
+	}
+	return s.token, s.api, nil
+}
+
+// getLanguages 没有设置的时候只下载英文
+func (s *Supplier) getLanguages() []string {
+	if len(s.openSubtitlesSettings.Languages) < 1 {
+		return []string{"en"}
+	}
+	return s.openSubtitlesSettings.Languages
+}
+
+func getRootUrl() string {
+	oneSupplierSettings := settings.Get().AdvancedSettings.SuppliersSettings.OpenSubtitles
+	if oneSupplierSettings == nil || oneSupplierSettings.RootUrl == "" {
+		return common.SubOpenSubtitlesRootUrlDef
+	}
+	return oneSupplierSettings.RootUrl
+}
+
+var (
+	ErrUnauthorized           = supplier.NewStatusCodeError("opensubtitles unauthorized", http.StatusUnauthorized, "")
+	ErrOverDailyDownloadLimit = fmt.Errorf("opensubtitles %w", supplier.ErrOverDailyDownloadLimit)
+)
+
+const (
+	checkImdbID     = "tt0111161"
+	tokenExpiration = 23 * time.Hour // Token 的有效期是 24 小时
+)
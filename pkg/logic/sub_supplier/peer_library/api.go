@@ -0,0 +1,97 @@
+package peer_library
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/go-resty/resty/v2"
+)
+
+// Api 访问一个其他实例的 /peer-library/v1 接口
+type Api struct {
+	peer settings.PeerLibraryPeer
+}
+
+func NewApi(peer settings.PeerLibraryPeer) *Api {
+	return &Api{peer: peer}
+}
+
+// NewClient 局域网内的实例，不走代理
+func NewClient(timeOut int) (*resty.Client, error) {
+
+	client, err := pkg.NewHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	client.RemoveProxy()
+	client.SetTimeout(time.Duration(timeOut) * time.Second)
+	return client, nil
+}
+
+// QueryByFeature 按视频的特征码查询
+func (a *Api) QueryByFeature(client *resty.Client, feature string) ([]sub_share.ShareItem, error) {
+	return a.query(client, map[string]string{"feature": feature})
+}
+
+// QueryByIMDB 按 IMDB ID 查询，电影的 season、episode 都为 0
+func (a *Api) QueryByIMDB(client *resty.Client, imdbID string, season, episode int) ([]sub_share.ShareItem, error) {
+	return a.query(client, map[string]string{
+		"imdb_id": imdbID,
+		"season":  strconv.Itoa(season),
+		"episode": strconv.Itoa(episode),
+	})
+}
+
+// DownloadFile 下载字幕文件
+func (a *Api) DownloadFile(client *resty.Client, sha256String string) ([]byte, error) {
+
+	resp, err := a.newRequest(client).Get(a.GetFileUrl(sha256String))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, supplier.NewStatusCodeError(fmt.Sprintf("peer %s download file", a.peer.Name), resp.StatusCode(), "")
+	}
+	return resp.Body(), nil
+}
+
+// GetFileUrl 字幕文件的下载地址，不包含 Token
+func (a *Api) GetFileUrl(sha256String string) string {
+	return a.peer.Url + apiRoot + "/file/" + sha256String
+}
+
+func (a *Api) query(client *resty.Client, params map[string]string) ([]sub_share.ShareItem, error) {
+
+	resp, err := a.newRequest(client).SetQueryParams(params).Get(a.peer.Url + apiRoot + "/subtitles")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, supplier.NewStatusCodeError(fmt.Sprintf("peer %s query", a.peer.Name), resp.StatusCode(), "")
+	}
+	var reply backend.ReplyPeerLibrarySubtitles
+	err = json.Unmarshal(resp.Body(), &reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Subtitles, nil
+}
+
+func (a *Api) newRequest(client *resty.Client) *resty.Request {
+
+	req := client.R()
+	if a.peer.Token != "" {
+		req.SetHeader("Authorization", "Bearer "+a.peer.Token)
+	}
+	return req
+}
+
+const apiRoot = "/peer-library/v1"
@@ -0,0 +1,104 @@
+package peer_library
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+func newTestPeerServer(t *testing.T, token string) *httptest.Server {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiRoot+"/subtitles", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reply := backend.ReplyPeerLibrarySubtitles{Subtitles: make([]sub_share.ShareItem, 0)}
+		query := r.URL.Query()
+		if query.Get("feature") == "feature_1" ||
+			(query.Get("imdb_id") == "tt0000001" && query.Get("season") == "1" && query.Get("episode") == "2") {
+			reply.Subtitles = append(reply.Subtitles, sub_share.ShareItem{
+				SHA256:     "sha_1",
+				SubName:    "sub_1.chinese(简英,shooter).ass",
+				MyLanguage: "简英",
+			})
+		}
+		_ = json.NewEncoder(w).Encode(reply)
+	})
+	mux.HandleFunc(apiRoot+"/file/sha_1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("sub_1_content"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestApi(t *testing.T) {
+
+	server := newTestPeerServer(t, "token_1")
+	defer server.Close()
+
+	client, err := NewClient(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewApi(settings.PeerLibraryPeer{Name: "peer_1", Url: server.URL, Token: "token_1"})
+
+	items, err := api.QueryByFeature(client, "feature_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].SHA256 != "sha_1" || items[0].MyLanguage != "简英" {
+		t.Fatal("QueryByFeature", items)
+	}
+	items, err = api.QueryByFeature(client, "feature_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Fatal("QueryByFeature not found", items)
+	}
+	items, err = api.QueryByIMDB(client, "tt0000001", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatal("QueryByIMDB", items)
+	}
+
+	fileData, err := api.DownloadFile(client, "sha_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fileData) != "sub_1_content" {
+		t.Fatal("DownloadFile", string(fileData))
+	}
+	_, err = api.DownloadFile(client, "sha_2")
+	if err == nil {
+		t.Fatal("DownloadFile not found should return error")
+	}
+}
+
+func TestApiTokenError(t *testing.T) {
+
+	server := newTestPeerServer(t, "token_1")
+	defer server.Close()
+
+	client, err := NewClient(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewApi(settings.PeerLibraryPeer{Name: "peer_1", Url: server.URL, Token: "token_2"})
+	_, err = api.QueryByFeature(client, "feature_1")
+	if err == nil {
+		t.Fatal("QueryByFeature with wrong token should return error")
+	}
+}
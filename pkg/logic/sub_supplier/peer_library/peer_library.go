@@ -0,0 +1,273 @@
+package peer_library
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/mix_media_info"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_file_hash"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	Supplier 从局域网内其他实例的字幕缓存中查询字幕，这些字幕都是对方已经下载、确认过的
+	先按视频的特征码查询，没有找到再按 IMDB ID（连续剧加上季、集）查询，Peers 按设置的顺序查询
+	下载的字幕以 sha256 作为缓存的唯一标识，同一个字幕在多个 Peer 中存在，只下载一次
+	下载后会校验字幕内容的 sha256 与 Peer 给出的是否一致，不一致的丢弃，避免错误的内容写入缓存
+*/
+type Supplier struct {
+	log            *logrus.Logger
+	fileDownloader *file_downloader.FileDownloader
+	peerSettings   settings.PeerLibrarySettings
+	isAlive        bool
+}
+
+func NewSupplier(fileDownloader *file_downloader.FileDownloader) *Supplier {
+
+	sup := Supplier{}
+	sup.log = fileDownloader.Log
+	sup.fileDownloader = fileDownloader
+	sup.peerSettings = *settings.Get().AdvancedSettings.PeerLibrarySettings
+	sup.isAlive = true // 默认是可以使用的，如果 check 后，再调整状态
+
+	return &sup
+}
+
+// CheckAlive 只要有一个 Peer 能访问就认为是可用的，返回最快的那个的耗时
+func (s *Supplier) CheckAlive() (bool, int64) {
+
+	s.isAlive = false
+	if len(s.peerSettings.Peers) < 1 {
+		return false, 0
+	}
+	client, err := NewClient(s.peerSettings.TimeOut)
+	if err != nil {
+		s.log.Errorln(s.GetSupplierName(), "CheckAlive", "NewClient", err)
+		return false, 0
+	}
+	var speed int64
+	for _, peer := range s.peerSettings.Peers {
+		startT := time.Now()
+		_, err = NewApi(peer).QueryByFeature(client, checkAliveFeature)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), "CheckAlive", peer.Name, err)
+			continue
+		}
+		nowSpeed := time.Since(startT).Milliseconds()
+		if s.isAlive == false || nowSpeed < speed {
+			speed = nowSpeed
+		}
+		s.isAlive = true
+	}
+	return s.isAlive, speed
+}
+
+func (s *Supplier) IsAlive() bool {
+	return s.isAlive
+}
+
+// OverDailyDownloadLimit 局域网内的实例没有下载次数的限制
+func (s *Supplier) OverDailyDownloadLimit() bool {
+	return false
+}
+
+func (s *Supplier) GetLogger() *logrus.Logger {
+	return s.log
+}
+
+func (s *Supplier) GetSupplierName() string {
+	return common.SubSitePeerLibrary
+}
+
+func (s *Supplier) GetSubListFromFile4Movie(filePath string) ([]supplier.SubInfo, error) {
+
+	if s.peerSettings.PeerEnabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+	return s.getSubListFromFile(filePath, true, 0, 0)
+}
+
+func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	if s.peerSettings.PeerEnabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+// GetSubListFromFile4Anime 缓存中记录的是 季、集 的编号，同连续剧的逻辑
+func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	if s.peerSettings.PeerEnabled == false {
+		return make([]supplier.SubInfo, 0), nil
+	}
+	return s.downloadSub4Series(seriesInfo)
+}
+
+func (s *Supplier) downloadSub4Series(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
+
+	var allSupplierSubInfo = make([]supplier.SubInfo, 0)
+	// 这里拿到的 seriesInfo ，里面包含了，需要下载字幕的 Eps 信息
+	for _, episodeInfo := range seriesInfo.NeedDlEpsKeyList {
+
+		one, err := s.getSubListFromFile(episodeInfo.FileFullPath, false, episodeInfo.Season, episodeInfo.Episode)
+		if err != nil {
+			s.log.Errorln(s.GetSupplierName(), "getSubListFromFile", episodeInfo.FileFullPath, err)
+			continue
+		}
+		if len(one) < 1 {
+			// 没有搜索到字幕
+			s.log.Infoln(s.GetSupplierName(), "Not Find Sub can be download",
+				episodeInfo.Title, episodeInfo.Season, episodeInfo.Episode)
+			continue
+		}
+		// 需要赋值给字幕结构
+		for i := range one {
+			one[i].Season = episodeInfo.Season
+			one[i].Episode = episodeInfo.Episode
+		}
+		allSupplierSubInfo = append(allSupplierSubInfo, one...)
+	}
+	return allSupplierSubInfo, nil
+}
+
+func (s *Supplier) getSubListFromFile(videoFPath string, isMovie bool, season, episode int) ([]supplier.SubInfo, error) {
+
+	defer func() {
+		s.log.Debugln(s.GetSupplierName(), videoFPath, "End...")
+	}()
+	s.log.Debugln(s.GetSupplierName(), videoFPath, "Start...")
+
+	outSubInfoList := make([]supplier.SubInfo, 0)
+	if len(s.peerSettings.Peers) < 1 {
+		return outSubInfoList, nil
+	}
+	feature, err := sub_file_hash.Calculate(videoFPath)
+	if err != nil {
+		// 特征码计算失败，还可以按 IMDB ID 查询
+		s.log.Warningln(s.GetSupplierName(), videoFPath, "sub_file_hash.Calculate", err)
+		feature = ""
+	}
+	client, err := NewClient(s.peerSettings.TimeOut)
+	if err != nil {
+		return nil, err
+	}
+
+	imdbID := ""
+	imdbIDGot := false
+	getIMDBID := func() string {
+		if imdbIDGot == true {
+			return imdbID
+		}
+		imdbIDGot = true
+		mediaInfo, err := mix_media_info.GetMixMediaInfo(s.fileDownloader.MediaInfoDealers, videoFPath, isMovie)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), videoFPath, "GetMixMediaInfo", err)
+			return ""
+		}
+		imdbID = mediaInfo.ImdbId
+		return imdbID
+	}
+
+	sha256Map := make(map[string]bool)
+	for _, peer := range s.peerSettings.Peers {
+
+		api := NewApi(peer)
+		items, err := s.queryOnePeer(client, api, feature, getIMDBID, isMovie, season, episode)
+		if err != nil {
+			s.log.Warningln(s.GetSupplierName(), peer.Name, videoFPath, err)
+			continue
+		}
+		for _, item := range items {
+			if _, found := sha256Map[item.SHA256]; found == true {
+				continue
+			}
+			sha256Map[item.SHA256] = true
+
+			subInfo, err := s.downloadOne(client, api, len(outSubInfoList), item)
+			if err != nil {
+				s.log.Warningln(s.GetSupplierName(), peer.Name, "downloadOne", item.SubName, err)
+				continue
+			}
+			outSubInfoList = append(outSubInfoList, *subInfo)
+			// 如果够了那么多个字幕就返回
+			if len(outSubInfoList) >= settings.Get().AdvancedSettings.Topic {
+				return outSubInfoList, nil
+			}
+		}
+	}
+
+	return outSubInfoList, nil
+}
+
+// queryOnePeer 先按特征码查询，没有找到再按 IMDB ID 查询
+func (s *Supplier) queryOnePeer(client *resty.Client, api *Api, feature string, getIMDBID func() string,
+	isMovie bool, season, episode int) ([]sub_share.ShareItem, error) {
+
+	if feature != "" {
+		items, err := api.QueryByFeature(client, feature)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) > 0 {
+			return items, nil
+		}
+	}
+	imdbID := getIMDBID()
+	if imdbID == "" {
+		return nil, nil
+	}
+	if isMovie == true {
+		season, episode = 0, 0
+	}
+	return api.QueryByIMDB(client, imdbID, season, episode)
+}
+
+// downloadOne 先从下载缓存中获取，没有再去 Peer 下载，以 sha256 作为缓存的唯一标识，只有校验通过的才会写入缓存
+func (s *Supplier) downloadOne(client *resty.Client, api *Api, topN int, item sub_share.ShareItem) (*supplier.SubInfo, error) {
+
+	if item.SHA256 == "" {
+		return nil, errors.New("sha256 is empty")
+	}
+	found, subInfo, err := s.fileDownloader.CacheCenter.DownloadFileGet(item.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	if found == true {
+		return subInfo, nil
+	}
+
+	fileData, err := api.DownloadFile(client, item.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	fileSHA256 := fmt.Sprintf("%x", sha256.Sum256(fileData))
+	if fileSHA256 != strings.ToLower(item.SHA256) {
+		return nil, errors.New(fmt.Sprintf("sha256 not match, want: %s, got: %s", item.SHA256, fileSHA256))
+	}
+	subInfo = supplier.NewSubInfo(s.GetSupplierName(), int64(topN), item.SubName,
+		language.ChineseString2Lang(item.MyLanguage), api.GetFileUrl(item.SHA256),
+		0, 0, filepath.Ext(item.SubName), fileData)
+	subInfo.SetFileUrlSha256(item.SHA256)
+
+	err = s.fileDownloader.CacheCenter.DownloadFileAdd(subInfo)
+	if err != nil {
+		return nil, err
+	}
+	return subInfo, nil
+}
+
+// checkAliveFeature 检查是否可用的时候，查询一个不存在的特征码
+const checkAliveFeature = "csf_peer_library_check_alive"
@@ -0,0 +1,87 @@
+package peer_library
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/cache_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/file_downloader"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/random_auth_key"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+// TestSupplier_DownloadOne 字幕内容的 sha256 与 Peer 给出的不一致的时候丢弃，不写入下载缓存
+func TestSupplier_DownloadOne(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	// 下载缓存在当前的工作目录下
+	nowWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(nowWd)
+	}()
+
+	subContent := []byte("sub_content")
+	goodSHA256 := fmt.Sprintf("%x", sha256.Sum256(subContent))
+	badSHA256 := fmt.Sprintf("%x", sha256.Sum256([]byte("other_content")))
+	mux := http.NewServeMux()
+	for _, sha256String := range []string{goodSHA256, badSHA256} {
+		mux.HandleFunc(apiRoot+"/file/"+sha256String, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(subContent)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	l := log_helper.GetLogger4Tester()
+	cacheCenter := cache_center.NewCacheCenter("peer_library_test", l)
+	defer cacheCenter.Close()
+	sup := &Supplier{
+		log:            l,
+		fileDownloader: file_downloader.NewFileDownloader(cacheCenter, random_auth_key.AuthKey{}),
+	}
+	client, err := NewClient(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewApi(settings.PeerLibraryPeer{Name: "peer_1", Url: server.URL, Token: "token_1"})
+
+	_, err = sup.downloadOne(client, api, 0, sub_share.ShareItem{SHA256: badSHA256, SubName: "bad.chinese(简).srt", MyLanguage: "简"})
+	if err == nil {
+		t.Fatal("downloadOne should reject the sub when sha256 not match")
+	}
+	found, _, err := cacheCenter.DownloadFileGet(badSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == true {
+		t.Fatal("sha256 not match sub should not be cached")
+	}
+
+	subInfo, err := sup.downloadOne(client, api, 0, sub_share.ShareItem{SHA256: goodSHA256, SubName: "good.chinese(简).srt", MyLanguage: "简"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(subInfo.Data) != string(subContent) {
+		t.Fatalf("downloadOne data = %q", string(subInfo.Data))
+	}
+	found, _, err = cacheCenter.DownloadFileGet(goodSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == false {
+		t.Fatal("sub should be cached")
+	}
+}
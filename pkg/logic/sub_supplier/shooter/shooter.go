@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -88,6 +89,7 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 	return s.downloadSub4Series(seriesInfo)
 }
 
+// GetSubListFromFile4Anime 射手是按视频文件的特征值去查询的，与集数的编号方式无关，同连续剧的逻辑
 func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
 	return s.downloadSub4Series(seriesInfo)
 }
@@ -179,6 +181,9 @@ func (s *Supplier) getSubInfos(fileHash, fileName, qLan string) ([]SublistShoote
 		}
 		return nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, supplier.NewStatusCodeError("shooter search", resp.StatusCode(), "")
+	}
 
 	return jsonList, nil
 }
@@ -7,14 +7,18 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/media_info_dealers"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/emby"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
 
 	movieHelper "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/movie_helper"
 	seriesHelper "github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/series_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/supplier_health"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/errgo.v2/fmt/errors"
 )
@@ -44,6 +48,11 @@ func (d *SubSupplierHub) AddSubSupplier(one ifaces.ISupplier) {
 	d.Suppliers = append(d.Suppliers, one)
 }
 
+// AddSubSupplierFirst 添加一个下载器，放在最前面，最先去搜索，目前目标是给局域网内的其他实例使用
+func (d *SubSupplierHub) AddSubSupplierFirst(one ifaces.ISupplier) {
+	d.Suppliers = append([]ifaces.ISupplier{one}, d.Suppliers...)
+}
+
 // DelSubSupplier 移除一个下载器
 func (d *SubSupplierHub) DelSubSupplier(one ifaces.ISupplier) {
 
@@ -147,16 +156,23 @@ func (d *SubSupplierHub) SeriesNeedDlSubFromEmby(dealers *media_info_dealers.Dea
 // DownloadSub4Movie 某一个电影字幕下载，下载完毕后，返回下载缓存每个字幕的位置，这里将只关心下载字幕，判断是否在时间范围内要不要下载不在这里判断，包括是否是中文视频的问题
 func (d *SubSupplierHub) DownloadSub4Movie(videoFullPath string, index int64) ([]string, error) {
 
+	outSubFileFullPathList, _, err := d.DownloadSub4MovieEx(videoFullPath, index)
+	return outSubFileFullPathList, err
+}
+
+// DownloadSub4MovieEx 与 DownloadSub4Movie 一样，额外返回从各个网站下载到的字幕信息，用于展示字幕的发布名称等
+func (d *SubSupplierHub) DownloadSub4MovieEx(videoFullPath string, index int64) ([]string, []supplier.SubInfo, error) {
+
 	// 下载所有字幕
 	subInfos := movieHelper.OneMovieDlSubInAllSite(d.log, d.Suppliers, videoFullPath, index)
 	if subInfos == nil || len(subInfos) < 1 {
 		d.log.Warningln("OneMovieDlSubInAllSite.subInfos == 0, No Sub Downloaded.")
-		return nil, nil
+		return nil, nil, nil
 	}
 	// 整理字幕，比如解压什么的
-	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(d.log, filepath.Base(videoFullPath), subInfos, true)
+	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(d.log, filepath.Base(videoFullPath), subInfos, true, nil)
 	if err != nil {
-		return nil, errors.Newf("OrganizeDlSubFiles %v %v", videoFullPath, err)
+		return nil, nil, errors.Newf("OrganizeDlSubFiles %v %v", videoFullPath, err)
 	}
 	// 因为是下载电影，需要合并返回
 	var outSubFileFullPathList = make([]string, 0)
@@ -168,19 +184,25 @@ func (d *SubSupplierHub) DownloadSub4Movie(videoFullPath string, index int64) ([
 		d.log.Debugln("OneMovieDlSubInAllSite", videoFullPath, i, "SubFileFPath:", subFile)
 	}
 
-	return outSubFileFullPathList, nil
+	return outSubFileFullPathList, subInfos, nil
 }
 
 // DownloadSub4Series 某一部连续剧的字幕下载，下载完毕后，返回下载缓存每个字幕的位置（通用的下载逻辑，前面把常规（没有媒体服务器模式）和 Emby 这样的模式都转换到想到的下载接口上
 func (d *SubSupplierHub) DownloadSub4Series(seriesDirPath string, seriesInfo *series.SeriesInfo, index int64) (map[string][]string, error) {
 
-	organizeSubFiles, err := d.dlSubFromSeriesInfo(seriesDirPath, index, seriesInfo)
+	organizeSubFiles, _, err := d.dlSubFromSeriesInfo(seriesDirPath, index, seriesInfo)
 	if err != nil {
 		return nil, err
 	}
 	return organizeSubFiles, nil
 }
 
+// DownloadSub4SeriesEx 与 DownloadSub4Series 一样，额外返回从各个网站下载到的字幕信息，用于展示字幕的发布名称等
+func (d *SubSupplierHub) DownloadSub4SeriesEx(seriesDirPath string, seriesInfo *series.SeriesInfo, index int64) (map[string][]string, []supplier.SubInfo, error) {
+
+	return d.dlSubFromSeriesInfo(seriesDirPath, index, seriesInfo)
+}
+
 // CheckSubSiteStatus 检测多个字幕提供的网站是否是有效的，是否下载次数超限
 func (d *SubSupplierHub) CheckSubSiteStatus() backend.ReplyCheckStatus {
 
@@ -197,8 +219,12 @@ func (d *SubSupplierHub) CheckSubSiteStatus() backend.ReplyCheckStatus {
 		go func(supplier ifaces.ISupplier) {
 			defer wg.Done()
 			bAlive, speed := supplier.CheckAlive()
+			supplier_health.RecordCheck(d.log, supplier.GetSupplierName(), bAlive)
 			if bAlive == false {
 				d.log.Warningln(supplier.GetSupplierName(), "Check Alive = false")
+				notify_center.Publish(notify.NewEvent(notify.SupplierDown, supplier.GetSupplierName(), map[string]string{
+					"supplier": supplier.GetSupplierName(),
+				}).SetOnceADay(supplier.GetSupplierName()))
 			} else {
 				d.log.Infoln(supplier.GetSupplierName(), "Check Alive = true, Speed =", speed, "ms")
 			}
@@ -218,8 +244,9 @@ func (d *SubSupplierHub) CheckSubSiteStatus() backend.ReplyCheckStatus {
 	suppliersLen := len(d.Suppliers)
 	for i := 0; i < suppliersLen; {
 
-		// 网络检测是否有效，以及每次的下载次数限制检测
-		if d.Suppliers[i].IsAlive() == false || d.Suppliers[i].OverDailyDownloadLimit() == true {
+		// 网络检测是否有效，以及每次的下载次数限制检测，连续失败被自动禁用的也去掉
+		if d.Suppliers[i].IsAlive() == false || d.Suppliers[i].OverDailyDownloadLimit() == true ||
+			supplier_health.IsDisabled(d.Suppliers[i].GetSupplierName()) == true {
 
 			d.DelSubSupplier(d.Suppliers[i])
 			// 删除后，从头再来
@@ -241,7 +268,7 @@ func (d *SubSupplierHub) CheckSubSiteStatus() backend.ReplyCheckStatus {
 	return outStatus
 }
 
-func (d *SubSupplierHub) dlSubFromSeriesInfo(seriesDirPath string, index int64, seriesInfo *series.SeriesInfo) (map[string][]string, error) {
+func (d *SubSupplierHub) dlSubFromSeriesInfo(seriesDirPath string, index int64, seriesInfo *series.SeriesInfo) (map[string][]string, []supplier.SubInfo, error) {
 	// 下载好的字幕
 	subInfos := seriesHelper.DownloadSubtitleInAllSiteByOneSeries(d.log, d.Suppliers, seriesInfo, index)
 	// 整理字幕，比如解压什么的
@@ -251,9 +278,9 @@ func (d *SubSupplierHub) dlSubFromSeriesInfo(seriesDirPath string, index int64,
 		d.log.Warningln("DownloadSubtitleInAllSiteByOneSeries.subInfos == 0, No Sub Downloaded.")
 	}
 
-	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(d.log, filepath.Base(seriesDirPath), subInfos, false)
+	organizeSubFiles, err := sub_helper.OrganizeDlSubFiles(d.log, filepath.Base(seriesDirPath), subInfos, false, seriesInfo)
 	if err != nil {
-		return nil, errors.Newf("OrganizeDlSubFiles %v %v", seriesDirPath, err)
+		return nil, nil, errors.Newf("OrganizeDlSubFiles %v %v", seriesDirPath, err)
 	}
-	return organizeSubFiles, nil
+	return organizeSubFiles, subInfos, nil
 }
@@ -2,9 +2,12 @@ package subtitle_best
 
 import (
 	"encoding/json"
+	"net/http"
+	"strconv"
+
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
 	"github.com/go-resty/resty/v2"
-	"strconv"
 )
 
 type Api struct {
@@ -32,6 +35,9 @@ func (a *Api) QueryMovieSubtitle(client *resty.Client, imdbID string) (*Subtitle
 	if err != nil {
 		return nil, nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, supplier.NewStatusCodeError("subtitle_best search movie", resp.StatusCode(), "")
+	}
 
 	// 解析响应
 	var subtitleResponse SubtitleResponse
@@ -61,6 +67,9 @@ func (a *Api) QueryTVEpsSubtitle(client *resty.Client, imdbID string, season, ep
 	if err != nil {
 		return nil, nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, supplier.NewStatusCodeError("subtitle_best search tv episode", resp.StatusCode(), "")
+	}
 
 	// 解析响应
 	var subtitleResponse SubtitleResponse
@@ -89,6 +98,9 @@ func (a *Api) QueryTVSeasonPackages(client *resty.Client, imdbID string, season
 	if err != nil {
 		return nil, nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, supplier.NewStatusCodeError("subtitle_best search tv season packages", resp.StatusCode(), "")
+	}
 
 	// 解析响应
 	var seasonPackageResponse SeasonPackagesResponse
@@ -117,6 +129,9 @@ func (a *Api) QueryTVSeasonPackageByID(client *resty.Client, imdbID string, seas
 	if err != nil {
 		return nil, nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, supplier.NewStatusCodeError("subtitle_best search tv season package", resp.StatusCode(), "")
+	}
 
 	// 解析响应
 	var subtitleResponse SubtitleResponse
@@ -154,6 +169,9 @@ func (a *Api) GetDownloadUrl(client *resty.Client, subSha256, imdbID string,
 	if err != nil {
 		return nil, nil, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, supplier.NewStatusCodeError("subtitle_best get download url", resp.StatusCode(), "")
+	}
 
 	// 解析响应
 	var getUrlResponse GetUrlResponse
@@ -123,6 +123,7 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 	return s.downloadSub4Series(seriesInfo)
 }
 
+// GetSubListFromFile4Anime 这里是按 IMDB ID 以及 季、集 去查询的，动画使用的也是 季、集 的编号，同连续剧的逻辑
 func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
 
 	outSubInfos := make([]supplier.SubInfo, 0)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -98,6 +99,7 @@ func (s *Supplier) GetSubListFromFile4Series(seriesInfo *series.SeriesInfo) ([]s
 	return s.downloadSub4Series(seriesInfo)
 }
 
+// GetSubListFromFile4Anime 迅雷是按视频文件的特征值去查询的，与集数的编号方式无关，同连续剧的逻辑
 func (s *Supplier) GetSubListFromFile4Anime(seriesInfo *series.SeriesInfo) ([]supplier.SubInfo, error) {
 	return s.downloadSub4Series(seriesInfo)
 }
@@ -200,6 +202,9 @@ func (s *Supplier) getSubInfos(filePath, cid string) (SublistSliceXunLei, error)
 		}
 		return jsonList, err
 	}
+	if resp.StatusCode() != http.StatusOK {
+		return jsonList, supplier.NewStatusCodeError("xunlei search", resp.StatusCode(), "")
+	}
 
 	return jsonList, nil
 }
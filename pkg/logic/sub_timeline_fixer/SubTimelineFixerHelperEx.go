@@ -2,11 +2,15 @@ package sub_timeline_fixer
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ffmpeg_helper"
@@ -148,6 +152,12 @@ func (s *SubTimelineFixerHelperEx) Process(videoFileFullPath, srcSubFPath string
 	}
 	s.log.Infoln("TimeLine Fix -- Score:", pipeResultMax.Score, srcSubFPath)
 	s.log.Infoln("Fix Offset:", pipeResultMax.GetOffsetTime(), srcSubFPath)
+	notify_center.Publish(notify.NewEvent(notify.TimelineFixed,
+		fmt.Sprintf("%s, offset: %.2fs", filepath.Base(srcSubFPath), pipeResultMax.GetOffsetTime()), map[string]string{
+			"video_f_path": videoFileFullPath,
+			"sub_f_path":   srcSubFPath,
+			"offset":       fmt.Sprintf("%.2f", pipeResultMax.GetOffsetTime()),
+		}))
 	s.log.Infoln("BackUp Org SubFile:", pipeResultMax.GetOffsetTime(), srcSubFPath+sub_timeline_fixer.BackUpExt)
 
 	return nil
@@ -221,6 +231,24 @@ func (s *SubTimelineFixerHelperEx) ProcessByAudioFile(baseAudioFileFPath, srcSub
 	return s.ProcessByAudioVAD(audioVADInfos, infoSrc)
 }
 
+// GetAudioVADInfos 导出视频的第一个音轨，获取 VAD 信息，用于对多个字幕进行时间轴匹配的评分，避免重复导出音频
+func (s *SubTimelineFixerHelperEx) GetAudioVADInfos(videoFileFullPath string) ([]vad.VADInfo, error) {
+
+	bok, ffmpegInfo, err := s.ffmpegHelper.ExportFFMPEGInfo(videoFileFullPath, ffmpeg_helper.Audio)
+	if err != nil {
+		return nil, err
+	}
+	if bok == false || len(ffmpegInfo.AudioInfoList) <= 0 {
+		return nil, errors.New("SubTimelineFixerHelperEx.GetAudioVADInfos Can`t Find Audio To Export -- " + videoFileFullPath)
+	}
+
+	return vad.GetVADInfoFromAudio(vad.AudioInfo{
+		FileFullPath: ffmpegInfo.AudioInfoList[0].FullPath,
+		SampleRate:   16000,
+		BitDepth:     16,
+	}, true)
+}
+
 func (s *SubTimelineFixerHelperEx) IsVideoCanExportSubtitleAndAudio(videoFileFullPath string) (bool, *ffmpeg_helper.FFMPEGInfo, []vad.VADInfo, *subparser.FileInfo, error) {
 
 	// 先尝试获取内置字幕的信息
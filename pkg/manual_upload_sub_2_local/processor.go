@@ -7,6 +7,7 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_logic"
 	"github.com/pkg/errors"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/post_save_hook"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
 	subCommon "github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter/common"
 
@@ -24,17 +25,16 @@ import (
 )
 
 type ManualUploadSub2Local struct {
-	log              *logrus.Logger
-	saveSubHelper    *save_sub_helper.SaveSubHelper // 保存字幕的逻辑
-	scanLogic        *scan_logic.ScanLogic          // 是否扫描逻辑
-	subNameFormatter subCommon.FormatterName        // 从 inSubFormatter 推断出来
-	processQueue     *llq.Queue
-	jobSet           *hashset.Set
-	jobResultMap     sync.Map
-	addOneSignal     chan interface{}
-	addLocker        sync.Mutex
-	subParserHub     *sub_parser_hub.SubParserHub
-	workingJob       *Job // 正在操作的任务的路径
+	log           *logrus.Logger
+	saveSubHelper *save_sub_helper.SaveSubHelper // 保存字幕的逻辑
+	scanLogic     *scan_logic.ScanLogic          // 是否扫描逻辑
+	processQueue  *llq.Queue
+	jobSet        *hashset.Set
+	jobResultMap  sync.Map
+	addOneSignal  chan interface{}
+	addLocker     sync.Mutex
+	subParserHub  *sub_parser_hub.SubParserHub
+	workingJob    *Job // 正在操作的任务的路径
 }
 
 func NewManualUploadSub2Local(log *logrus.Logger, saveSubHelper *save_sub_helper.SaveSubHelper, scanLogic *scan_logic.ScanLogic) *ManualUploadSub2Local {
@@ -51,9 +51,6 @@ func NewManualUploadSub2Local(log *logrus.Logger, saveSubHelper *save_sub_helper
 		workingJob:    nil,
 	}
 
-	// 这里就不单独弄一个 settings.SubNameFormatter 字段来传递值了，因为 inSubFormatter 就已经知道是什么 formatter 了
-	m.subNameFormatter = subCommon.FormatterName(saveSubHelper.SubFormatter.GetFormatterFormatterName())
-
 	go func(mu *ManualUploadSub2Local) {
 		for {
 			select {
@@ -202,8 +199,11 @@ func (m *ManualUploadSub2Local) processSub(job *Job) error {
 	}
 
 	var skipInfo *models.SkipScanInfo
-	if m.subNameFormatter == subCommon.Emby {
-		err = m.saveSubHelper.WriteSubFile2VideoPath(job.VideoFPath, *subFileInfo, "manual", true, false)
+	var savedSub *save_sub_helper.SavedSub
+	// 视频库的扫描规则中可能覆盖了全局的字幕命名格式
+	subNameFormatter := subCommon.FormatterName(m.saveSubHelper.GetSubFormatter(job.VideoFPath).GetFormatterFormatterName())
+	if subNameFormatter == subCommon.Emby {
+		savedSub, err = m.saveSubHelper.WriteSubFile2VideoPath("", job.VideoFPath, *subFileInfo, "manual", true, false)
 		if err != nil {
 			err = errors.New("WriteSubFile2VideoPath," + job.VideoFPath + "," + err.Error())
 			return err
@@ -211,7 +211,7 @@ func (m *ManualUploadSub2Local) processSub(job *Job) error {
 		// 默认设置这个视频“跳过”（跳过扫描和下载字幕）属性
 		skipInfo = models.NewSkipScanInfoByMovie(job.VideoFPath, true)
 	} else {
-		err = m.saveSubHelper.WriteSubFile2VideoPath(job.VideoFPath, *subFileInfo, "manual", false, false)
+		savedSub, err = m.saveSubHelper.WriteSubFile2VideoPath("", job.VideoFPath, *subFileInfo, "manual", false, false)
 		if err != nil {
 			err = errors.New("WriteSubFile2VideoPath," + job.VideoFPath + "," + err.Error())
 			return err
@@ -219,6 +219,10 @@ func (m *ManualUploadSub2Local) processSub(job *Job) error {
 		// 默认设置这个视频“跳过”（跳过扫描和下载字幕）属性
 		skipInfo = models.NewSkipScanInfoBySeriesEx(job.VideoFPath, true)
 	}
+	// 手动上传的字幕同样执行字幕保存后的回调，没有下载任务的 ID
+	if savedSub != nil {
+		post_save_hook.RunAsync(m.log, post_save_hook.NewPayload4Video("", job.VideoFPath, []save_sub_helper.SavedSub{*savedSub}))
+	}
 
 	m.scanLogic.Set(skipInfo)
 
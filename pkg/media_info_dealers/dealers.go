@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/subtitle_best_api"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/tmdb_api"
@@ -64,6 +65,15 @@ func (d *Dealers) GetMediaInfo(id, source, videoType string) (*models.MediaInfo,
 	}
 }
 
+// GetAbsoluteEpisodeMap 获取动画绝对集数与 季、集 的对应关系，tmdbID：连续剧的 TMDB ID，只有使用用户自己的 tmdb api 才支持
+func (d *Dealers) GetAbsoluteEpisodeMap(tmdbID string) (*anime_helper.EpisodeMap, error) {
+
+	if d.tmdbHelper != nil && settings.Get().AdvancedSettings.TmdbApiSettings.Enable == true && settings.Get().AdvancedSettings.TmdbApiSettings.ApiKey != "" {
+		return d.tmdbHelper.GetAbsoluteEpisodeMap(tmdbID)
+	}
+	return nil, errors.New("GetAbsoluteEpisodeMap need tmdb api enabled")
+}
+
 // getMediaInfoFromSelfApi 通过用户自己的 tmdb api 查询媒体信息 "source"=imdb|tmdb  "video_type"=movie|series
 func (d *Dealers) getMediaInfoFromSelfApi(id, source, videoType string) (*models.MediaInfo, error) {
 
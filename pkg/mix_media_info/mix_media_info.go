@@ -2,6 +2,7 @@ package mix_media_info
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/media_info_dealers"
 
@@ -91,25 +92,9 @@ func GetMediaInfoAndSave(dealers *media_info_dealers.Dealers, imdbInfo *models.I
 // KeyWordSelect keyWordType cn, 中文， en，英文，org，原始名称
 func KeyWordSelect(mediaInfo *models.MediaInfo, videoFPath string, isMovie bool, keyWordType string) (string, error) {
 
-	keyWord := ""
-
-	if keyWordType == "cn" {
-		keyWord = mediaInfo.TitleCn
-		if keyWord == "" {
-			return "", errors.New("TitleCn is empty")
-		}
-	} else if keyWordType == "en" {
-		keyWord = mediaInfo.TitleEn
-		if keyWord == "" {
-			return "", errors.New("TitleEn is empty")
-		}
-	} else if keyWordType == "org" {
-		keyWord = mediaInfo.OriginalTitle
-		if keyWord == "" {
-			return "", errors.New("OriginalTitle is empty")
-		}
-	} else {
-		return "", errors.New("keyWordType is not cn, en, org")
+	keyWord, err := getTitleByKeyWordType(mediaInfo, keyWordType)
+	if err != nil {
+		return "", err
 	}
 
 	if isMovie == false {
@@ -123,3 +108,39 @@ func KeyWordSelect(mediaInfo *models.MediaInfo, videoFPath string, isMovie bool,
 
 	return keyWord, nil
 }
+
+// KeyWordSelect4Anime 动画使用绝对集数搜索的关键词，比如 海贼王 1037
+func KeyWordSelect4Anime(mediaInfo *models.MediaInfo, videoFPath string, absoluteEpisode int, keyWordType string) (string, error) {
+
+	if absoluteEpisode < 1 {
+		return "", errors.New("absoluteEpisode is not set, " + videoFPath)
+	}
+	// 不需要 S01E01 的信息，使用名称加上绝对集数
+	keyWord, err := getTitleByKeyWordType(mediaInfo, keyWordType)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %02d", keyWord, absoluteEpisode), nil
+}
+
+// getTitleByKeyWordType keyWordType cn, 中文， en，英文，org，原始名称
+func getTitleByKeyWordType(mediaInfo *models.MediaInfo, keyWordType string) (string, error) {
+
+	if keyWordType == "cn" {
+		if mediaInfo.TitleCn == "" {
+			return "", errors.New("TitleCn is empty")
+		}
+		return mediaInfo.TitleCn, nil
+	} else if keyWordType == "en" {
+		if mediaInfo.TitleEn == "" {
+			return "", errors.New("TitleEn is empty")
+		}
+		return mediaInfo.TitleEn, nil
+	} else if keyWordType == "org" {
+		if mediaInfo.OriginalTitle == "" {
+			return "", errors.New("OriginalTitle is empty")
+		}
+		return mediaInfo.OriginalTitle, nil
+	}
+	return "", errors.New("keyWordType is not cn, en, org")
+}
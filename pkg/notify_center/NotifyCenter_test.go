@@ -2,11 +2,13 @@ package notify_center
 
 import (
 	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
 )
 
 func TestNewNotifyCenter(t *testing.T) {
 
-	center := NewNotifyCenter("https://www.baidu.com/")
+	center := NewNotifyCenter(log_helper.GetLogger4Tester(), "https://www.baidu.com/")
 	center.Add("groupName", "Info asd 哈哈")
 	center.Send()
 }
@@ -0,0 +1,66 @@
+package notify_center
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+/*
+	appriseChannel 转发给 Apprise API 服务，由它再去通知
+	apprise://host[:port]/key 使用 Apprise API 中保存的 key 对应的配置
+	apprise://host[:port]?urls=tgram://xx,mailto://xx 无状态的方式，直接传递需要通知的 URL
+*/
+type appriseChannel struct {
+	channelBase
+	notifyUrl string
+	urls      string
+}
+
+func newAppriseChannel(base channelBase, u *url.URL) (*appriseChannel, error) {
+
+	c := appriseChannel{channelBase: base, urls: u.Query().Get("urls")}
+	key := strings.Trim(u.Path, "/")
+	if key != "" {
+		c.notifyUrl = httpUrl(u, "/notify/"+key)
+	} else {
+		c.notifyUrl = httpUrl(u, "/notify")
+	}
+	return &c, nil
+}
+
+func (c *appriseChannel) Send(event *notify.Event) error {
+
+	message := event.Message
+	var err error
+	if c.template != "" {
+		message, err = renderTemplate(c.template, event)
+		if err != nil {
+			return err
+		}
+	}
+	body := map[string]string{
+		"title": event.Title,
+		"body":  message,
+		"type":  appriseMessageType(event.Type),
+	}
+	if c.urls != "" {
+		body["urls"] = c.urls
+	}
+	_, err = postJson(c.notifyUrl, body, nil)
+	return err
+}
+
+// appriseMessageType Apprise 的消息类型 info、success、warning、failure
+func appriseMessageType(eventType notify.EventType) string {
+	switch eventType {
+	case notify.SubDownloaded, notify.SubUpgraded, notify.TimelineFixed, notify.ScanFinished:
+		return "success"
+	case notify.DailyQuotaReached:
+		return "warning"
+	case notify.JobFailed, notify.SupplierDown:
+		return "failure"
+	}
+	return "info"
+}
@@ -0,0 +1,56 @@
+package notify_center
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// barkChannel bark://host[:port]/device_key，可以有多个 device_key
+type barkChannel struct {
+	channelBase
+	pushUrl    string
+	deviceKeys []string
+}
+
+func newBarkChannel(base channelBase, u *url.URL) (*barkChannel, error) {
+
+	deviceKeys := make([]string, 0)
+	for _, part := range strings.Split(u.Path, "/") {
+		if part != "" {
+			deviceKeys = append(deviceKeys, part)
+		}
+	}
+	if u.Host == "" || len(deviceKeys) < 1 {
+		return nil, errors.New("bark notify url need host and device_key, bark://host/device_key")
+	}
+	return &barkChannel{channelBase: base, pushUrl: httpUrl(u, "/push"), deviceKeys: deviceKeys}, nil
+}
+
+func (c *barkChannel) Send(event *notify.Event) error {
+
+	message := event.Message
+	var err error
+	if c.template != "" {
+		message, err = renderTemplate(c.template, event)
+		if err != nil {
+			return err
+		}
+	}
+	for _, deviceKey := range c.deviceKeys {
+		_, err = postJson(c.pushUrl, map[string]string{
+			"device_key": deviceKey,
+			"title":      event.Title,
+			"body":       message,
+			"group":      barkGroupName,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const barkGroupName = "ChineseSubFinder"
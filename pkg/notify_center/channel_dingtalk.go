@@ -0,0 +1,85 @@
+package notify_center
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// dingTalkChannel 钉钉群机器人，dingtalk://token 或者 dingtalk://secret@token（开启了加签）
+type dingTalkChannel struct {
+	channelBase
+	token  string
+	secret string
+}
+
+func newDingTalkChannel(base channelBase, u *url.URL) (*dingTalkChannel, error) {
+
+	parts := urlPathParts(u)
+	if len(parts) < 1 {
+		return nil, errors.New("dingtalk notify url need token, dingtalk://[secret@]token")
+	}
+	c := dingTalkChannel{channelBase: base, token: parts[0]}
+	if u.User != nil {
+		c.secret = u.User.Username()
+	}
+	return &c, nil
+}
+
+func (c *dingTalkChannel) Send(event *notify.Event) error {
+
+	message, err := c.renderMessage(event)
+	if err != nil {
+		return err
+	}
+	sendUrl := fmt.Sprintf("%s/robot/send?access_token=%s", c.getApiUrl(dingTalkApiUrl), url.QueryEscape(c.token))
+	if c.secret != "" {
+		timestamp, sign := dingTalkSign(c.secret, time.Now())
+		sendUrl += fmt.Sprintf("&timestamp=%d&sign=%s", timestamp, url.QueryEscape(sign))
+	}
+	resp, err := postJson(sendUrl, map[string]interface{}{
+		"msgtype": "text",
+		"text": map[string]string{
+			"content": message,
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkRobotReply(resp.Body())
+}
+
+// dingTalkSign 钉钉机器人的加签，timestamp + "\n" + secret 使用 secret 做 HmacSHA256 后 Base64
+func dingTalkSign(secret string, now time.Time) (int64, string) {
+
+	timestamp := now.UnixNano() / int64(time.Millisecond)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkRobotReply 企业微信、钉钉的机器人，出错的时候 HTTP 状态码依然是 200，需要判断 errcode
+func checkRobotReply(body []byte) error {
+
+	reply := struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	err := json.Unmarshal(body, &reply)
+	if err != nil {
+		return err
+	}
+	if reply.ErrCode != 0 {
+		return errors.New(fmt.Sprintf("robot reply errcode: %d, errmsg: %s", reply.ErrCode, reply.ErrMsg))
+	}
+	return nil
+}
+
+const dingTalkApiUrl = "https://oapi.dingtalk.com"
@@ -0,0 +1,43 @@
+package notify_center
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// serverChanChannel schan://send_key
+type serverChanChannel struct {
+	channelBase
+	sendKey string
+}
+
+func newServerChanChannel(base channelBase, u *url.URL) (*serverChanChannel, error) {
+
+	parts := urlPathParts(u)
+	if len(parts) < 1 {
+		return nil, errors.New("serverchan notify url need send_key, schan://send_key")
+	}
+	return &serverChanChannel{channelBase: base, sendKey: parts[0]}, nil
+}
+
+func (c *serverChanChannel) Send(event *notify.Event) error {
+
+	message := event.Message
+	var err error
+	if c.template != "" {
+		message, err = renderTemplate(c.template, event)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = postJson(fmt.Sprintf("%s/%s.send", c.getApiUrl(serverChanApiUrl), c.sendKey), map[string]string{
+		"title": event.Title,
+		"desp":  message,
+	}, nil)
+	return err
+}
+
+const serverChanApiUrl = "https://sctapi.ftqq.com"
@@ -0,0 +1,46 @@
+package notify_center
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// telegramChannel tgram://bot_token/chat_id/chat_id2
+type telegramChannel struct {
+	channelBase
+	botToken string
+	chatIDs  []string
+}
+
+func newTelegramChannel(base channelBase, u *url.URL) (*telegramChannel, error) {
+
+	parts := urlPathParts(u)
+	if len(parts) < 2 {
+		return nil, errors.New("telegram notify url need bot_token and chat_id, tgram://bot_token/chat_id")
+	}
+	return &telegramChannel{channelBase: base, botToken: parts[0], chatIDs: parts[1:]}, nil
+}
+
+func (c *telegramChannel) Send(event *notify.Event) error {
+
+	message, err := c.renderMessage(event)
+	if err != nil {
+		return err
+	}
+	sendUrl := fmt.Sprintf("%s/bot%s/sendMessage", c.getApiUrl(telegramApiUrl), c.botToken)
+	for _, chatID := range c.chatIDs {
+		_, err = postJson(sendUrl, map[string]string{
+			"chat_id": chatID,
+			"text":    message,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const telegramApiUrl = "https://api.telegram.org"
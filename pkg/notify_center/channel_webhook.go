@@ -0,0 +1,63 @@
+package notify_center
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// webhookChannel 通用的 JSON Webhook，没有模板的时候 POST 事件本身的 JSON，有模板的时候 POST 模板渲染的结果
+type webhookChannel struct {
+	channelBase
+	postUrl string
+	headers map[string]string
+}
+
+func newWebhookChannel(base channelBase, u *url.URL) (*webhookChannel, error) {
+
+	c := webhookChannel{channelBase: base, headers: make(map[string]string)}
+	// +Key=Value 作为 Header，其他的参数原样保留，u.Query() 会把 + 解析为空格，所以这里自己解析
+	query := make([]string, 0)
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if strings.HasPrefix(param, "+") == false {
+			if param != "" {
+				query = append(query, param)
+			}
+			continue
+		}
+		keyValue := strings.SplitN(param[1:], "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+		key, err := url.QueryUnescape(keyValue[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := url.QueryUnescape(keyValue[1])
+		if err != nil {
+			return nil, err
+		}
+		c.headers[key] = value
+	}
+	c.postUrl = httpUrl(u, u.Path)
+	if len(query) > 0 {
+		c.postUrl += "?" + strings.Join(query, "&")
+	}
+	return &c, nil
+}
+
+func (c *webhookChannel) Send(event *notify.Event) error {
+
+	var body interface{}
+	body = event
+	if c.template != "" {
+		message, err := renderTemplate(c.template, event)
+		if err != nil {
+			return err
+		}
+		body = message
+	}
+	_, err := postJson(c.postUrl, body, c.headers)
+	return err
+}
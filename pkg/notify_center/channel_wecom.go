@@ -0,0 +1,45 @@
+package notify_center
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// weComChannel 企业微信群机器人，wecombot://key
+type weComChannel struct {
+	channelBase
+	key string
+}
+
+func newWeComChannel(base channelBase, u *url.URL) (*weComChannel, error) {
+
+	parts := urlPathParts(u)
+	if len(parts) < 1 {
+		return nil, errors.New("wecom bot notify url need key, wecombot://key")
+	}
+	return &weComChannel{channelBase: base, key: parts[0]}, nil
+}
+
+func (c *weComChannel) Send(event *notify.Event) error {
+
+	message, err := c.renderMessage(event)
+	if err != nil {
+		return err
+	}
+	resp, err := postJson(fmt.Sprintf("%s/cgi-bin/webhook/send?key=%s", c.getApiUrl(weComApiUrl), url.QueryEscape(c.key)),
+		map[string]interface{}{
+			"msgtype": "text",
+			"text": map[string]string{
+				"content": message,
+			},
+		}, nil)
+	if err != nil {
+		return err
+	}
+	return checkRobotReply(resp.Body())
+}
+
+const weComApiUrl = "https://qyapi.weixin.qq.com"
@@ -0,0 +1,190 @@
+package notify_center
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	EventCenter 事件通知中心，与 NotifyCenter（开发者使用的 Bark 通知）是独立的
+	各个模块在事件发生的时候调用 Publish，这里会根据每个通知渠道设置的事件过滤，异步的发送出去
+*/
+type EventCenter struct {
+	log          *logrus.Logger
+	channels     []eventChannel
+	eventChan    chan *notify.Event
+	onceADayKeys map[string]string // OnceADayKey -- 通知的日期
+	mu           sync.Mutex
+	closeOnce    sync.Once
+	exitDone     chan interface{}
+}
+
+// eventChannel 一个通知渠道，以及它需要通知的事件
+type eventChannel struct {
+	channel ifaces.INotifyChannel
+	events  map[notify.EventType]bool // 为空则通知所有的事件
+}
+
+func NewEventCenter(log *logrus.Logger, notifySettings *settings.NotifySettings) *EventCenter {
+
+	e := EventCenter{
+		log:          log,
+		channels:     make([]eventChannel, 0),
+		eventChan:    make(chan *notify.Event, eventChanSize),
+		onceADayKeys: make(map[string]string),
+		exitDone:     make(chan interface{}),
+	}
+	if notifySettings != nil && notifySettings.Enable == true {
+		for _, channelSettings := range notifySettings.Channels {
+			if channelSettings.Enable == false {
+				continue
+			}
+			nowChannel, err := newEventChannel(channelSettings)
+			if err != nil {
+				log.Errorln("NewEventCenter", channelSettings.Name, err)
+				continue
+			}
+			e.channels = append(e.channels, *nowChannel)
+		}
+	}
+	go e.sendLoop()
+
+	return &e
+}
+
+func newEventChannel(channelSettings settings.NotifyChannelSettings) (*eventChannel, error) {
+
+	channel, err := NewChannel(channelSettings.Name, channelSettings.Url, channelSettings.Template)
+	if err != nil {
+		return nil, err
+	}
+	nowChannel := eventChannel{channel: channel}
+	if len(channelSettings.Events) > 0 {
+		nowChannel.events = make(map[notify.EventType]bool)
+		for _, eventName := range channelSettings.Events {
+			eventType, ok := notify.ParseEventType(strings.TrimSpace(eventName))
+			if ok == false {
+				return nil, errors.New(fmt.Sprintf("not support event: %s", eventName))
+			}
+			nowChannel.events[eventType] = true
+		}
+	}
+	return &nowChannel, nil
+}
+
+// match 这个渠道是否需要通知这个事件，测试的事件所有渠道都通知
+func (c eventChannel) match(eventType notify.EventType) bool {
+	if c.events == nil || eventType == notify.Test {
+		return true
+	}
+	return c.events[eventType]
+}
+
+// Publish 异步的发送事件，队列满了就丢弃，不会阻塞调用者
+func (e *EventCenter) Publish(event *notify.Event) {
+
+	if e == nil || event == nil || len(e.channels) == 0 {
+		return
+	}
+	if e.isNotifiedToday(event) == true {
+		return
+	}
+	select {
+	case e.eventChan <- event:
+	default:
+		e.log.Warningln("EventCenter.Publish event chan is full, drop", event.Name)
+	}
+}
+
+// PublishSync 同步的发送事件，返回每个渠道发送的错误，Key 是渠道的名称
+func (e *EventCenter) PublishSync(event *notify.Event) map[string]error {
+
+	errs := make(map[string]error)
+	if e == nil || event == nil {
+		return errs
+	}
+	for _, channel := range e.channels {
+		if channel.match(event.Type) == false {
+			continue
+		}
+		err := channel.channel.Send(event)
+		if err != nil {
+			errs[channel.channel.GetChannelName()] = err
+		}
+	}
+	return errs
+}
+
+func (e *EventCenter) Close() {
+
+	if e == nil {
+		return
+	}
+	e.closeOnce.Do(func() {
+		close(e.eventChan)
+		<-e.exitDone
+	})
+}
+
+func (e *EventCenter) sendLoop() {
+
+	defer close(e.exitDone)
+	for event := range e.eventChan {
+		for name, err := range e.PublishSync(event) {
+			e.log.Errorln("EventCenter send", event.Name, "to", name, "error:", err)
+		}
+	}
+}
+
+// isNotifiedToday 设置了 OnceADayKey 的事件，今天是否已经通知过了，没有通知过会进行标记
+func (e *EventCenter) isNotifiedToday(event *notify.Event) bool {
+
+	if event.OnceADayKey == "" {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	if e.onceADayKeys[event.OnceADayKey] == today {
+		return true
+	}
+	e.onceADayKeys[event.OnceADayKey] = today
+	return false
+}
+
+// ResetEventCenter 根据设置重新创建事件通知中心，启动以及修改设置后调用
+func ResetEventCenter(log *logrus.Logger, notifySettings *settings.NotifySettings) {
+
+	eventCenterLocker.Lock()
+	oldEventCenter := eventCenter
+	eventCenter = NewEventCenter(log, notifySettings)
+	eventCenterLocker.Unlock()
+
+	oldEventCenter.Close()
+}
+
+// Publish 发送事件，事件通知中心没有初始化的时候忽略
+func Publish(event *notify.Event) {
+
+	// Publish 不会阻塞，这里一直持有锁，防止发送到已经 Close 的事件通知中心
+	eventCenterLocker.Lock()
+	defer eventCenterLocker.Unlock()
+
+	eventCenter.Publish(event)
+}
+
+var (
+	eventCenter       *EventCenter
+	eventCenterLocker sync.Mutex
+)
+
+const eventChanSize = 100
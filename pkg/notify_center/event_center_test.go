@@ -0,0 +1,248 @@
+package notify_center
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
+)
+
+// stubRequest 本地 HTTP 桩收到的请求
+type stubRequest struct {
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// newHttpStub 本地的 HTTP 桩，记录收到的请求，回复 reply
+func newHttpStub(t *testing.T, reply string) (*httptest.Server, func() []stubRequest) {
+
+	var mu sync.Mutex
+	requests := make([]stubRequest, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, stubRequest{Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: string(body)})
+		mu.Unlock()
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []stubRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]stubRequest{}, requests...)
+	}
+}
+
+func newTestEvent() *notify.Event {
+	return notify.NewEvent(notify.SubDownloaded, "Movie.2021.mkv", map[string]string{"video_f_path": "/movie/Movie.2021.mkv"})
+}
+
+func TestChannels(t *testing.T) {
+
+	server, getRequests := newHttpStub(t, `{"errcode":0,"errmsg":"ok"}`)
+	host := strings.TrimPrefix(server.URL, "http://")
+	apiUrl := url.QueryEscape(server.URL)
+
+	tests := []struct {
+		name      string
+		url       string
+		template  string
+		wantPath  string
+		wantInMsg string
+		check     func(t *testing.T, r stubRequest)
+	}{
+		{name: "json", url: "json://" + host + "/hook?+X-Token=abc", wantPath: "/hook", wantInMsg: `"event":"sub_downloaded"`,
+			check: func(t *testing.T, r stubRequest) {
+				if r.Header.Get("X-Token") != "abc" {
+					t.Errorf("header X-Token = %v, want abc", r.Header.Get("X-Token"))
+				}
+			}},
+		{name: "json_template", url: "json://" + host + "/hook", template: `{"text":"{{.Title}} {{index .Fields "video_f_path"}}"}`,
+			wantPath: "/hook", wantInMsg: `"text":"字幕下载完成 /movie/Movie.2021.mkv"`},
+		{name: "tgram", url: "tgram://123:token/10086?api_url=" + apiUrl, wantPath: "/bot123:token/sendMessage", wantInMsg: `"chat_id":"10086"`},
+		{name: "schan", url: "schan://SCT123?api_url=" + apiUrl, wantPath: "/SCT123.send", wantInMsg: `"desp":"Movie.2021.mkv"`},
+		{name: "bark", url: "bark://" + host + "/devicekey", wantPath: "/push", wantInMsg: `"device_key":"devicekey"`},
+		{name: "wecombot", url: "wecombot://botkey?api_url=" + apiUrl, wantPath: "/cgi-bin/webhook/send", wantInMsg: `"msgtype":"text"`,
+			check: func(t *testing.T, r stubRequest) {
+				if r.Query.Get("key") != "botkey" {
+					t.Errorf("query key = %v, want botkey", r.Query.Get("key"))
+				}
+			}},
+		{name: "dingtalk", url: "dingtalk://secret@token?api_url=" + apiUrl, wantPath: "/robot/send", wantInMsg: `"content":"字幕下载完成\nMovie.2021.mkv"`,
+			check: func(t *testing.T, r stubRequest) {
+				if r.Query.Get("access_token") != "token" || r.Query.Get("sign") == "" || r.Query.Get("timestamp") == "" {
+					t.Errorf("query = %v, want access_token sign timestamp", r.Query)
+				}
+			}},
+		{name: "apprise", url: "apprise://" + host + "/mykey", wantPath: "/notify/mykey", wantInMsg: `"type":"success"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel, err := NewChannel(tt.name, tt.url, tt.template)
+			if err != nil {
+				t.Fatal("NewChannel", err)
+			}
+			before := len(getRequests())
+			err = channel.Send(newTestEvent())
+			if err != nil {
+				t.Fatal("Send", err)
+			}
+			requests := getRequests()
+			if len(requests) != before+1 {
+				t.Fatalf("stub got %d requests, want 1", len(requests)-before)
+			}
+			r := requests[len(requests)-1]
+			if r.Path != tt.wantPath {
+				t.Errorf("path = %v, want %v", r.Path, tt.wantPath)
+			}
+			body := r.Body
+			// 模板渲染的结果是以字符串的方式 POST 的，其他的都是 JSON 对象，统一解析后再判断
+			var bodyObj interface{}
+			if json.Unmarshal([]byte(body), &bodyObj) == nil {
+				bodyBytes, _ := json.Marshal(bodyObj)
+				body = string(bodyBytes)
+			}
+			if strings.Contains(body, tt.wantInMsg) == false {
+				t.Errorf("body = %v, want contains %v", body, tt.wantInMsg)
+			}
+			if tt.check != nil {
+				tt.check(t, r)
+			}
+		})
+	}
+}
+
+func TestChannelRobotErrCode(t *testing.T) {
+
+	server, _ := newHttpStub(t, `{"errcode":93000,"errmsg":"invalid webhook url"}`)
+	channel, err := NewChannel("wecombot", "wecombot://botkey?api_url="+url.QueryEscape(server.URL), "")
+	if err != nil {
+		t.Fatal("NewChannel", err)
+	}
+	if channel.Send(newTestEvent()) == nil {
+		t.Fatal("Send want error when errcode != 0")
+	}
+}
+
+func TestNewChannelError(t *testing.T) {
+
+	for _, rawUrl := range []string{"xxx://abc", "tgram://onlytoken", "bark://host", "mailto://"} {
+		if _, err := NewChannel("error", rawUrl, ""); err == nil {
+			t.Errorf("NewChannel(%v) want error", rawUrl)
+		}
+	}
+}
+
+func TestEmailChannel(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// 一个最简单的 SMTP 桩，不支持 STARTTLS 以及 AUTH
+	mailData := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte("220 localhost ESMTP\r\n"))
+		inData := false
+		var data strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData == true {
+				if line == ".\r\n" {
+					inData = false
+					mailData <- data.String()
+					_, _ = conn.Write([]byte("250 OK\r\n"))
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch strings.ToUpper(strings.Fields(line)[0]) {
+			case "EHLO", "HELO":
+				_, _ = conn.Write([]byte("250 localhost\r\n"))
+			case "DATA":
+				inData = true
+				_, _ = conn.Write([]byte("354 Go ahead\r\n"))
+			case "QUIT":
+				_, _ = conn.Write([]byte("221 Bye\r\n"))
+				return
+			default:
+				_, _ = conn.Write([]byte("250 OK\r\n"))
+			}
+		}
+	}()
+
+	channel, err := NewChannel("mail", "mailto://"+listener.Addr().String()+"?from=csf@test.com&to=a@test.com,b@test.com", "")
+	if err != nil {
+		t.Fatal("NewChannel", err)
+	}
+	err = channel.Send(newTestEvent())
+	if err != nil {
+		t.Fatal("Send", err)
+	}
+	select {
+	case data := <-mailData:
+		if strings.Contains(data, "To: a@test.com, b@test.com") == false {
+			t.Errorf("mail data = %v, want To", data)
+		}
+		body := data[strings.Index(data, "\r\n\r\n")+4:]
+		decoded, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+		if string(decoded) != "Movie.2021.mkv" {
+			t.Errorf("mail body = %v, want Movie.2021.mkv", string(decoded))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp stub not got mail")
+	}
+}
+
+func TestEventCenter(t *testing.T) {
+
+	server, getRequests := newHttpStub(t, "ok")
+	host := strings.TrimPrefix(server.URL, "http://")
+	notifySettings := settings.NewNotifySettings()
+	notifySettings.Enable = true
+	notifySettings.Channels = append(notifySettings.Channels,
+		settings.NotifyChannelSettings{Name: "all", Enable: true, Url: "json://" + host + "/all"},
+		settings.NotifyChannelSettings{Name: "failed", Enable: true, Url: "json://" + host + "/failed", Events: []string{notify.JobFailed.String()}},
+		settings.NotifyChannelSettings{Name: "disabled", Enable: false, Url: "json://" + host + "/disabled"},
+	)
+	eventCenter := NewEventCenter(log_helper.GetLogger4Tester(), notifySettings)
+	eventCenter.Publish(newTestEvent())
+	eventCenter.Publish(notify.NewEvent(notify.JobFailed, "job failed", nil))
+	// 同一天只通知一次
+	eventCenter.Publish(notify.NewEvent(notify.DailyQuotaReached, "assrt", nil).SetOnceADay("assrt"))
+	eventCenter.Publish(notify.NewEvent(notify.DailyQuotaReached, "assrt", nil).SetOnceADay("assrt"))
+	eventCenter.Close()
+
+	pathCount := make(map[string]int)
+	for _, r := range getRequests() {
+		pathCount[r.Path]++
+	}
+	if pathCount["/all"] != 3 || pathCount["/failed"] != 1 || pathCount["/disabled"] != 0 {
+		t.Errorf("pathCount = %v, want /all 3, /failed 1", pathCount)
+	}
+}
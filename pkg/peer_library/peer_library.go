@@ -0,0 +1,96 @@
+package peer_library
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+/*
+	QuerySubtitles 在字幕缓存中查询已经确认过的字幕（VideoSubInfo，下载后评分选中的字幕，见 sub_share_center.AddSavedSub），低可信度的字幕（LowVideoSubInfo）不对外提供
+	feature 不为空的时候按视频的特征码查询，否则按 IMDB ID 查询，season、episode 都为 0 的时候认为是电影
+*/
+func QuerySubtitles(feature, imdbID string, season, episode int) ([]sub_share.ShareItem, error) {
+
+	feature = strings.TrimSpace(feature)
+	imdbID = strings.TrimSpace(imdbID)
+	var videoSubInfos []models.VideoSubInfo
+	var err error
+	if feature != "" {
+		err = dao.GetDb().Where("feature = ?", feature).Find(&videoSubInfos).Error
+	} else if imdbID != "" {
+		if season == 0 && episode == 0 {
+			err = dao.GetDb().Where("imdb_info_id = ? AND is_movie = ?", imdbID, true).Find(&videoSubInfos).Error
+		} else {
+			err = dao.GetDb().Where("imdb_info_id = ? AND season = ? AND episode = ?", imdbID, season, episode).Find(&videoSubInfos).Error
+		}
+	} else {
+		return nil, errors.New("feature and imdb_id are both empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shareRootDir, err := pkg.GetShareSubRootFolder()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sub_share.ShareItem, 0)
+	for _, info := range videoSubInfos {
+		if pkg.IsFile(filepath.Join(shareRootDir, info.StoreRPath)) == false {
+			continue
+		}
+		items = append(items, sub_share.ShareItem{
+			IMDBID:       info.IMDBInfoID,
+			Feature:      info.Feature,
+			IsMovie:      info.IsMovie,
+			Season:       info.Season,
+			Episode:      info.Episode,
+			LanguageISO:  info.LanguageISO,
+			ChineseISO:   info.ChineseISO,
+			MyLanguage:   info.MyLanguage,
+			IsDouble:     info.IsDouble,
+			ExtraPreName: info.ExtraPreName,
+			SHA256:       info.SHA256,
+			SubName:      info.SubName,
+		})
+	}
+	return items, nil
+}
+
+// GetSubFPath 字幕缓存中 sha256 对应的字幕文件的全路径，只能是字幕缓存目录中的文件
+func GetSubFPath(sha256String string) (string, error) {
+
+	sha256String = strings.TrimSpace(sha256String)
+	if sha256String == "" {
+		return "", errors.New("sha256 is empty")
+	}
+	var videoSubInfos []models.VideoSubInfo
+	err := dao.GetDb().Where("sha256 = ?", sha256String).Limit(1).Find(&videoSubInfos).Error
+	if err != nil {
+		return "", err
+	}
+	if len(videoSubInfos) < 1 {
+		return "", ErrSubNotFound
+	}
+	shareRootDir, err := pkg.GetShareSubRootFolder()
+	if err != nil {
+		return "", err
+	}
+	subFPath := filepath.Join(shareRootDir, videoSubInfos[0].StoreRPath)
+	relPath, err := filepath.Rel(shareRootDir, subFPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) == true {
+		return "", ErrSubNotFound
+	}
+	if pkg.IsFile(subFPath) == false {
+		return "", ErrSubNotFound
+	}
+	return subFPath, nil
+}
+
+var ErrSubNotFound = errors.New("sub not found in share cache")
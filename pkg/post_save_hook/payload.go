@@ -0,0 +1,108 @@
+package post_save_hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+// Payload 回调时传递的信息，http 回调是 POST 的 Body，command 回调会转换为环境变量
+type Payload struct {
+	Event             string                     `json:"event"`                // 固定为 sub_saved
+	JobID             string                     `json:"job_id"`               // 下载任务的 ID
+	VideoType         string                     `json:"video_type"`           // movie or series
+	VideoFPath        string                     `json:"video_f_path"`         // 视频的全路径
+	SeriesRootDirPath string                     `json:"series_root_dir_path"` // 连续剧的根目录，电影为空
+	Season            int                        `json:"season"`
+	Episode           int                        `json:"episode"`
+	SubFPaths         []string                   `json:"sub_f_paths"` // 写入的字幕的全路径
+	Languages         []string                   `json:"languages"`   // 字幕的语言，去重
+	Sources           []string                   `json:"sources"`     // 字幕的来源网站，去重
+	Subs              []save_sub_helper.SavedSub `json:"subs"`        // 每个字幕的详细信息
+}
+
+func NewPayload(jobID, videoType, videoFPath, seriesRootDirPath string, season, episode int, subs []save_sub_helper.SavedSub) *Payload {
+
+	p := Payload{
+		Event:             "sub_saved",
+		JobID:             jobID,
+		VideoType:         videoType,
+		VideoFPath:        videoFPath,
+		SeriesRootDirPath: seriesRootDirPath,
+		Season:            season,
+		Episode:           episode,
+		SubFPaths:         make([]string, 0),
+		Languages:         make([]string, 0),
+		Sources:           make([]string, 0),
+		Subs:              subs,
+	}
+	for _, sub := range subs {
+		p.SubFPaths = append(p.SubFPaths, sub.SubFPath)
+		p.Languages = appendUnique(p.Languages, sub.Language)
+		p.Sources = appendUnique(p.Sources, sub.Source)
+	}
+	return &p
+}
+
+/*
+	NewPayload4Video 只知道视频路径的时候使用（手动上传、候选字幕的选择），从目录结构推断是电影还是连续剧
+	季和集优先从这一集的 nfo 中读取，没有再从文件名推断
+*/
+func NewPayload4Video(jobID, videoFPath string, subs []save_sub_helper.SavedSub) *Payload {
+
+	seriesRootDirPath := decode.GetSeriesDirRootFPath(videoFPath)
+	if seriesRootDirPath == "" {
+		return NewPayload(jobID, common.Movie.String(), videoFPath, "", 0, 0, subs)
+	}
+	season, episode := 0, 0
+	epsNfoInfo, err := decode.GetVideoNfoInfo4OneSeriesEpisode(videoFPath)
+	if err == nil && epsNfoInfo.Season > 0 {
+		season, episode = epsNfoInfo.Season, epsNfoInfo.Episode
+	} else {
+		found, s, e, err := decode.GetSeasonAndEpisodeFromSubFileName(filepath.Base(videoFPath))
+		if err == nil && found == true {
+			season, episode = s, e
+		}
+	}
+	return NewPayload(jobID, common.Series.String(), videoFPath, seriesRootDirPath, season, episode, subs)
+}
+
+/*
+	Environ 转换为 command 回调使用的环境变量
+	多个字幕的路径用换行分隔（路径中可能有空格、逗号），语言和来源用逗号分隔
+	CSF_PAYLOAD 是完整的 JSON
+*/
+func (p Payload) Environ() []string {
+
+	payloadBytes, _ := json.Marshal(p)
+	return []string{
+		"CSF_EVENT=" + p.Event,
+		"CSF_JOB_ID=" + p.JobID,
+		"CSF_VIDEO_TYPE=" + p.VideoType,
+		"CSF_VIDEO_F_PATH=" + p.VideoFPath,
+		"CSF_SERIES_ROOT_DIR_PATH=" + p.SeriesRootDirPath,
+		fmt.Sprintf("CSF_SEASON=%d", p.Season),
+		fmt.Sprintf("CSF_EPISODE=%d", p.Episode),
+		"CSF_SUB_F_PATHS=" + strings.Join(p.SubFPaths, "\n"),
+		"CSF_SUB_LANGUAGES=" + strings.Join(p.Languages, ","),
+		"CSF_SUB_SOURCES=" + strings.Join(p.Sources, ","),
+		"CSF_PAYLOAD=" + string(payloadBytes),
+	}
+}
+
+func appendUnique(list []string, one string) []string {
+	if one == "" {
+		return list
+	}
+	for _, s := range list {
+		if s == one {
+			return list
+		}
+	}
+	return append(list, one)
+}
@@ -0,0 +1,214 @@
+package post_save_hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	RunAsync 在后台执行回调，不阻塞下载任务，回调的超时和重试的等待都不会占用下载的协程
+	多个任务的回调依次执行，避免同时启动过多的外部命令
+*/
+func RunAsync(log *logrus.Logger, payload *Payload) {
+
+	hookSettings := settings.Get().AdvancedSettings.PostSaveHookSettings
+	if hookSettings == nil || hookSettings.Enable == false || payload == nil || len(payload.SubFPaths) < 1 {
+		return
+	}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Errorln("PostSaveHook.RunAsync panic:", p)
+			}
+		}()
+		runLocker.Lock()
+		defer runLocker.Unlock()
+		Run(log, hookSettings, payload)
+	}()
+}
+
+/*
+	Run 字幕保存后，按顺序执行所有启用的回调，每个回调有独立的超时和重试
+	回调失败不会影响下载任务本身，结果会记录到 PostSaveHookRecord 中，可以按任务 ID 查询
+*/
+func Run(log *logrus.Logger, hookSettings *settings.PostSaveHookSettings, payload *Payload) []models.PostSaveHookRecord {
+
+	records := make([]models.PostSaveHookRecord, 0)
+	if hookSettings == nil || hookSettings.Enable == false || payload == nil || len(payload.SubFPaths) < 1 {
+		return records
+	}
+
+	for _, hook := range hookSettings.Hooks {
+		if hook.Enable == false {
+			continue
+		}
+		record := runOneHook(log, hook, payload)
+		if record.Success == true {
+			log.Infoln("PostSaveHook", hook.Name, "Done, Attempts:", record.Attempts, payload.VideoFPath)
+		} else {
+			log.Errorln("PostSaveHook", hook.Name, "Failed, Attempts:", record.Attempts, payload.VideoFPath, record.ErrorInfo)
+		}
+		err := dao.GetDb().Create(&record).Error
+		if err != nil {
+			log.Errorln("PostSaveHook", hook.Name, "Save Record Error:", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// GetRecords 获取一个任务的回调执行记录，最新的在前面
+func GetRecords(jobID string) ([]models.PostSaveHookRecord, error) {
+
+	records := make([]models.PostSaveHookRecord, 0)
+	err := dao.GetDb().Where("job_id = ?", jobID).Order("id desc").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func runOneHook(log *logrus.Logger, hook settings.PostSaveHook, payload *Payload) models.PostSaveHookRecord {
+
+	record := models.PostSaveHookRecord{
+		JobID:      payload.JobID,
+		HookName:   hook.Name,
+		HookType:   hook.Type,
+		VideoFPath: payload.VideoFPath,
+	}
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = settings.DefaultPostSaveHookTimeoutSeconds * time.Second
+	}
+
+	for i := 0; i <= hook.RetryTimes; i++ {
+		if i > 0 {
+			// 简单的退避，1s 2s 3s ...
+			time.Sleep(time.Duration(i) * retryInterval)
+			log.Infoln("PostSaveHook", hook.Name, "Retry", i)
+		}
+		record.Attempts++
+
+		var output string
+		var err error
+		switch hook.Type {
+		case settings.PostSaveHookTypeHttp:
+			output, err = runHttpHook(hook, payload, timeout)
+		case settings.PostSaveHookTypeCommand:
+			output, err = runCommandHook(hook, payload, timeout)
+		default:
+			// 类型写错了，重试也没有意义
+			record.ErrorInfo = fmt.Sprintf("not support post save hook type: %s", hook.Type)
+			return record
+		}
+		record.Output = truncate(output)
+		if err == nil {
+			record.Success = true
+			record.ErrorInfo = ""
+			return record
+		}
+		record.ErrorInfo = err.Error()
+	}
+	return record
+}
+
+func runHttpHook(hook settings.PostSaveHook, payload *Payload, timeout time.Duration) (string, error) {
+
+	if hook.Url == "" {
+		return "", errors.New("post save hook url is empty")
+	}
+	// 跟其他对外的请求一样走设置的代理，重试由回调自己的 RetryTimes 控制
+	client, err := pkg.NewHttpClient()
+	if err != nil {
+		return "", err
+	}
+	client.SetTimeout(timeout)
+	client.SetRetryCount(0)
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeaders(hook.Headers).
+		SetBody(payload).
+		Post(hook.Url)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsSuccess() == false {
+		return resp.String(), errors.New(fmt.Sprintf("post save hook http status code: %d", resp.StatusCode()))
+	}
+	return resp.String(), nil
+}
+
+func runCommandHook(hook settings.PostSaveHook, payload *Payload, timeout time.Duration) (string, error) {
+
+	if hook.Command == "" {
+		return "", errors.New("post save hook command is empty")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Env = append(os.Environ(), payload.Environ()...)
+	output := &lockedBuffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Start()
+	if err != nil {
+		return "", err
+	}
+	// 超时只会杀掉命令本身，如果命令又启动了子进程，Wait 会一直等到子进程退出（输出的管道被占用），所以这里不等了
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- cmd.Wait()
+	}()
+	select {
+	case err = <-waitDone:
+		return output.String(), err
+	case <-ctx.Done():
+		return output.String(), errors.New(fmt.Sprintf("post save hook command timeout after %v", timeout))
+	}
+}
+
+// lockedBuffer 命令超时后，输出可能还在被写入
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func truncate(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxOutputLen {
+		return output[:maxOutputLen]
+	}
+	return output
+}
+
+var runLocker sync.Mutex
+
+const (
+	maxOutputLen  = 4096
+	retryInterval = time.Second
+)
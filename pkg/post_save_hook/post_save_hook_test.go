@@ -0,0 +1,90 @@
+package post_save_hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/save_sub_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+func newTestPayload() *Payload {
+	return NewPayload("job-id", "movie", "/movies/Foo (2020)/Foo.mkv", "", 0, 0, []save_sub_helper.SavedSub{
+		{SubFPath: "/movies/Foo (2020)/Foo.chinese(简英,zimuku).default.ass", Language: "简英", Source: "zimuku"},
+		{SubFPath: "/movies/Foo (2020)/Foo.chinese(简,xunlei).ass", Language: "简", Source: "xunlei"},
+	})
+}
+
+func TestRunOneHook_Http(t *testing.T) {
+
+	requestCount := 0
+	var gotPayload Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		// 第一次返回错误，测试重试
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("X-Token") != "123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&gotPayload)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	record := runOneHook(log_helper.GetLogger4Tester(), settings.PostSaveHook{
+		Name:           "http",
+		Enable:         true,
+		Type:           settings.PostSaveHookTypeHttp,
+		Url:            server.URL,
+		Headers:        map[string]string{"X-Token": "123"},
+		TimeoutSeconds: 5,
+		RetryTimes:     1,
+	}, newTestPayload())
+
+	if record.Success == false || record.Attempts != 2 || record.Output != "ok" {
+		t.Fatalf("record = %+v", record)
+	}
+	if gotPayload.JobID != "job-id" || len(gotPayload.SubFPaths) != 2 || len(gotPayload.Sources) != 2 {
+		t.Fatalf("payload = %+v", gotPayload)
+	}
+}
+
+func TestRunOneHook_Command(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("need sh")
+	}
+	record := runOneHook(log_helper.GetLogger4Tester(), settings.PostSaveHook{
+		Name:           "command",
+		Enable:         true,
+		Type:           settings.PostSaveHookTypeCommand,
+		Command:        "sh",
+		Args:           []string{"-c", "echo \"$CSF_JOB_ID|$CSF_SUB_SOURCES\""},
+		TimeoutSeconds: 5,
+	}, newTestPayload())
+	if record.Success == false || record.Output != "job-id|zimuku,xunlei" {
+		t.Fatalf("record = %+v", record)
+	}
+
+	// 超时
+	record = runOneHook(log_helper.GetLogger4Tester(), settings.PostSaveHook{
+		Name:           "timeout",
+		Enable:         true,
+		Type:           settings.PostSaveHookTypeCommand,
+		Command:        "sh",
+		Args:           []string{"-c", "sleep 5"},
+		TimeoutSeconds: 1,
+	}, newTestPayload())
+	if record.Success == true || strings.Contains(record.ErrorInfo, "timeout") == false {
+		t.Fatalf("record = %+v", record)
+	}
+}
@@ -0,0 +1,75 @@
+package release_helper
+
+// MatchLevel 视频与字幕发布信息的匹配程度
+type MatchLevel int
+
+const (
+	MatchLevelUnknown    MatchLevel = iota // 字幕或者视频没有发布信息，无法判断
+	MatchLevelMismatch                     // 片源或者版本冲突，比如 WEB-DL 的字幕对应 BluRay 的视频
+	MatchLevelResolution                   // 只有分辨率一致，没有冲突
+	MatchLevelSource                       // 片源一致
+	MatchLevelGroup                        // 发布组以及片源都一致，最好的情况
+)
+
+func (m MatchLevel) String() string {
+	switch m {
+	case MatchLevelMismatch:
+		return "mismatch"
+	case MatchLevelResolution:
+		return "resolution"
+	case MatchLevelSource:
+		return "source"
+	case MatchLevelGroup:
+		return "group"
+	default:
+		return "unknown"
+	}
+}
+
+// Match 判断视频与字幕发布信息的匹配程度
+func Match(videoInfo, subInfo ReleaseInfo) MatchLevel {
+
+	if videoInfo.IsEmpty() == true || subInfo.IsEmpty() == true {
+		return MatchLevelUnknown
+	}
+	// 片源、版本冲突的，时间轴基本是对不上的
+	if videoInfo.Source != "" && subInfo.Source != "" && videoInfo.Source != subInfo.Source {
+		return MatchLevelMismatch
+	}
+	if videoInfo.Edition != "" && subInfo.Edition != "" && videoInfo.Edition != subInfo.Edition {
+		return MatchLevelMismatch
+	}
+	// 字幕没有标注片源，仅仅发布组一致也是可以的
+	if videoInfo.Group != "" && videoInfo.Group == subInfo.Group {
+		return MatchLevelGroup
+	}
+	if videoInfo.Source != "" && videoInfo.Source == subInfo.Source {
+		return MatchLevelSource
+	}
+	if videoInfo.Resolution != "" && videoInfo.Resolution == subInfo.Resolution {
+		return MatchLevelResolution
+	}
+
+	return MatchLevelUnknown
+}
+
+// MatchScore 匹配程度对应的评分 0 - 1，ok 为 false 的时候，说明无法判断，不应该参与评分
+func MatchScore(videoInfo, subInfo ReleaseInfo) (float64, bool) {
+
+	switch Match(videoInfo, subInfo) {
+	case MatchLevelMismatch:
+		return 0, true
+	case MatchLevelResolution:
+		return 0.6, true
+	case MatchLevelSource:
+		return 0.8, true
+	case MatchLevelGroup:
+		// REPACK 一般修正了视频本身的问题，时间轴可能会有变化
+		if videoInfo.Repack != subInfo.Repack {
+			return 0.9, true
+		}
+		return 1, true
+	default:
+		return 0, false
+	}
+}
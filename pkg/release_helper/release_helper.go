@@ -0,0 +1,134 @@
+package release_helper
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ReleaseInfo 从视频或者字幕的文件名中解析出来的发布信息
+type ReleaseInfo struct {
+	Group      string // 发布组，比如 NTb、FraMeSToR，统一转为小写
+	Source     string // 片源，统一为 WEB、BluRay、HDTV、DVD 这几类
+	Resolution string // 分辨率，比如 1080p
+	Edition    string // 版本，比如 extended、directors.cut
+	Repack     bool   // 是否是 REPACK、PROPER 的重新发布版本
+}
+
+// IsEmpty 没有解析出任何有用的信息
+func (r ReleaseInfo) IsEmpty() bool {
+	return r.Group == "" && r.Source == "" && r.Resolution == "" && r.Edition == ""
+}
+
+// String 用于拼接到字幕的文件名中，保留发布信息，比如 BluRay.1080p.extended-ntb
+func (r ReleaseInfo) String() string {
+
+	tokens := make([]string, 0)
+	for _, token := range []string{r.Source, r.Resolution, r.Edition} {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if r.Repack == true {
+		tokens = append(tokens, "REPACK")
+	}
+	outString := strings.Join(tokens, ".")
+	if r.Group != "" {
+		outString += "-" + r.Group
+	}
+	return outString
+}
+
+/*
+	GetReleaseInfoFromFileName 从文件名解析发布信息，支持视频以及字幕的文件名
+	1. Movie.2020.1080p.BluRay.x264-GROUP.mkv
+	2. Show.S01E02.REPACK.720p.WEBRip.x265-GROUP.chs.srt
+	3. Movie (2020) WEBDL-1080p.mkv，这种 Radarr、Sonarr 的命名是没有发布组的
+	go-parse-torrent-name 对于发布组的解析会带上后缀名以及字幕的语言标记，所以这里自己解析
+*/
+func GetReleaseInfoFromFileName(fileName string) ReleaseInfo {
+
+	var releaseInfo ReleaseInfo
+	nowName := trimExtAndMarks(filepath.Base(fileName))
+	for _, source := range reMatchSources {
+		if source.re.MatchString(nowName) == true {
+			releaseInfo.Source = source.name
+			break
+		}
+	}
+	resolution := reMatchResolution.FindStringSubmatch(nowName)
+	if resolution != nil {
+		releaseInfo.Resolution = strings.ToLower(resolution[1])
+		if releaseInfo.Resolution == "4k" {
+			releaseInfo.Resolution = "2160p"
+		}
+	}
+	edition := reMatchEdition.FindStringSubmatch(nowName)
+	if edition != nil {
+		releaseInfo.Edition = strings.ToLower(reMatchSplit.ReplaceAllString(edition[1], "."))
+		releaseInfo.Edition = strings.ReplaceAll(releaseInfo.Edition, "'", "")
+	}
+	releaseInfo.Repack = reMatchRepack.MatchString(nowName)
+	group := reMatchGroup.FindStringSubmatch(nowName)
+	if group != nil && reMatchNotGroup.MatchString(group[1]) == false &&
+		hasReleaseToken(strings.TrimSuffix(nowName, group[0])) == true {
+		releaseInfo.Group = strings.ToLower(group[1])
+	}
+
+	return releaseInfo
+}
+
+// hasReleaseToken 发布组前面需要有片源、分辨率、编码这些信息，否则 Spider-Man 这种名称中的 Man 会被当作发布组
+func hasReleaseToken(nameBeforeGroup string) bool {
+
+	for _, source := range reMatchSources {
+		if source.re.MatchString(nameBeforeGroup) == true {
+			return true
+		}
+	}
+	return reMatchResolution.MatchString(nameBeforeGroup) == true || reMatchCodec.MatchString(nameBeforeGroup) == true
+}
+
+// trimExtAndMarks 去除后缀名，以及字幕文件名末尾的语言、default、forced 这些标记
+func trimExtAndMarks(fileName string) string {
+
+	nowName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	for {
+		ext := filepath.Ext(nowName)
+		if ext == "" || reMatchSubMark.MatchString(strings.TrimPrefix(ext, ".")) == false {
+			break
+		}
+		nowName = strings.TrimSuffix(nowName, ext)
+	}
+	return nowName
+}
+
+var (
+	// 按顺序匹配，先匹配到的优先
+	reMatchSources = []struct {
+		name string
+		re   *regexp.Regexp
+	}{
+		{name: SourceBluRay, re: regexp.MustCompile(`(?i)(^|[^a-z0-9])(blu[-. ]?ray|bd[-. ]?rip|br[-. ]?rip|bd[-. ]?remux|remux|bd|uhd)([^a-z0-9]|$)`)},
+		{name: SourceWEB, re: regexp.MustCompile(`(?i)(^|[^a-z0-9])(web[-. ]?dl|web[-. ]?rip|web|amzn|nf|dsnp|hmax|atvp)([^a-z0-9]|$)`)},
+		{name: SourceHDTV, re: regexp.MustCompile(`(?i)(^|[^a-z0-9])(hdtv|pdtv|hdtvrip)([^a-z0-9]|$)`)},
+		{name: SourceDVD, re: regexp.MustCompile(`(?i)(^|[^a-z0-9])(dvd[-. ]?rip|dvd[-. ]?scr|dvd\d?|dvdr)([^a-z0-9]|$)`)},
+	}
+	reMatchResolution = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(480p|576p|720p|1080[pi]|2160p|4k)(?:[^a-z0-9]|$)`)
+	reMatchEdition    = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(extended(?:[. ]cut)?|unrated|uncut|theatrical(?:[. ]cut)?|director'?s[. ]cut|remastered|imax)(?:[^a-z0-9]|$)`)
+	reMatchRepack     = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(repack|proper|rerip)(?:[^a-z0-9]|$)`)
+	reMatchGroup      = regexp.MustCompile(`-([a-zA-Z0-9]+)$`)
+	reMatchCodec      = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(x26[45]|h\.?26[45]|hevc|avc|xvid|divx|10bit)(?:[^a-z0-9]|$)`)
+	// 这些是 Radarr、Sonarr 命名中 - 后面跟着的片源、分辨率，不是发布组
+	reMatchNotGroup = regexp.MustCompile(`(?i)^(\d{3,4}[pi]|4k|web|dl|rip|hdtv|bluray|remux|dvd|x26[45]|h26[45])$`)
+	reMatchSplit    = regexp.MustCompile(`[. ]`)
+	// 字幕文件名末尾的标记
+	reMatchSubMark = regexp.MustCompile(`(?i)^(chs|cht|chi|zh|zho|chn|cn|tw|hk|sc|tc|gb|big5|eng|en|jp|jpn|kor|default|forced|chs&eng|cht&eng|简体|繁体|简英|繁英|双语|chinese.*|简.*|繁.*|英.*)$`)
+)
+
+const (
+	SourceWEB    = "WEB"
+	SourceBluRay = "BluRay"
+	SourceHDTV   = "HDTV"
+	SourceDVD    = "DVD"
+)
@@ -0,0 +1,63 @@
+package release_helper
+
+import (
+	"testing"
+)
+
+func TestGetReleaseInfoFromFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		want     ReleaseInfo
+	}{
+		{name: "00", fileName: "Movie.2020.1080p.BluRay.x264-GROUP.mkv", want: ReleaseInfo{Group: "group", Source: SourceBluRay, Resolution: "1080p"}},
+		{name: "01", fileName: "Movie.2020.1080p.WEB-DL.DDP5.1.H.264-NTb.mkv", want: ReleaseInfo{Group: "ntb", Source: SourceWEB, Resolution: "1080p"}},
+		{name: "02", fileName: "Show.S01E02.REPACK.720p.WEBRip.x265-MiNX.chs.srt", want: ReleaseInfo{Group: "minx", Source: SourceWEB, Resolution: "720p", Repack: true}},
+		{name: "03", fileName: "Movie (2020) WEBDL-1080p.mkv", want: ReleaseInfo{Source: SourceWEB, Resolution: "1080p"}},
+		{name: "04", fileName: "The.Movie.2019.Extended.Cut.2160p.UHD.BluRay.REMUX-FraMeSToR.chinese(简英,assrt).default.ass", want: ReleaseInfo{Group: "framestor", Source: SourceBluRay, Resolution: "2160p", Edition: "extended.cut"}},
+		{name: "05", fileName: "[assrt]_0_Movie_S0E0.srt", want: ReleaseInfo{}},
+		{name: "06", fileName: "[assrt]_0_Movie_S0E0.BluRay.1080p-group.srt", want: ReleaseInfo{Group: "group", Source: SourceBluRay, Resolution: "1080p"}},
+		// 名称中的 - 不是发布组
+		{name: "07", fileName: "Spider-Man.mkv", want: ReleaseInfo{}},
+		{name: "08", fileName: "Show.S01E02.The.Spider-Man.chs.srt", want: ReleaseInfo{}},
+		{name: "09", fileName: "Spider-Man.2002.x264-GROUP.mkv", want: ReleaseInfo{Group: "group"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetReleaseInfoFromFileName(tt.fileName); got != tt.want {
+				t.Errorf("GetReleaseInfoFromFileName() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseInfo_String(t *testing.T) {
+
+	releaseInfo := GetReleaseInfoFromFileName("Movie.2020.1080p.BluRay.x264-GROUP.mkv")
+	if got := GetReleaseInfoFromFileName("Movie_S0E0." + releaseInfo.String() + ".srt"); got != releaseInfo {
+		t.Errorf("String() round trip = %+v, want %+v", got, releaseInfo)
+	}
+}
+
+func TestMatch(t *testing.T) {
+
+	video := GetReleaseInfoFromFileName("Movie.2020.1080p.WEB-DL.H.264-NTb.mkv")
+	tests := []struct {
+		name    string
+		subName string
+		want    MatchLevel
+	}{
+		{name: "group", subName: "Movie.2020.1080p.WEB-DL.H.264-NTb.chs.srt", want: MatchLevelGroup},
+		{name: "source", subName: "Movie.2020.720p.WEBRip.x264-Other.chs.srt", want: MatchLevelSource},
+		{name: "mismatch", subName: "Movie.2020.1080p.BluRay.x264-NTb.chs.srt", want: MatchLevelMismatch},
+		{name: "resolution", subName: "Movie.2020.1080p.chs.srt", want: MatchLevelResolution},
+		{name: "unknown", subName: "Movie.chs.srt", want: MatchLevelUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(video, GetReleaseInfoFromFileName(tt.subName)); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,32 +3,65 @@ package save_sub_helper
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/change_file_encode"
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/chs_cht_changer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_formatter"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_post_process"
+	sub_post_process2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
 	"github.com/sirupsen/logrus"
 )
 
 type SaveSubHelper struct {
-	log                      *logrus.Logger
-	SubFormatter             ifaces.ISubFormatter                         // 字幕格式化命名的实现
-	subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx // 字幕时间轴校正
+	log                   *logrus.Logger
+	SubFormatter          ifaces.ISubFormatter       // 字幕格式化命名的实现
+	postProcessPipeline   *sub_post_process.Pipeline // 字幕写入后的处理步骤
+	ruleSubFormattersLock sync.Mutex
+	ruleSubFormatters     map[int]ifaces.ISubFormatter // 扫描规则中覆盖的字幕命名格式，Key 是 SubNameFormatter
+}
+
+// SavedSub 一个写入到视频旁边的字幕，由写入的函数返回，调用者传递给字幕保存后的回调
+type SavedSub struct {
+	SubFPath    string                          `json:"sub_f_path"`   // 字幕的全路径
+	Language    string                          `json:"language"`     // 字幕的语言
+	Source      string                          `json:"source"`       // 字幕的来源网站
+	IsForced    bool                            `json:"is_forced"`    // 是否是 forced 字幕
+	PostProcess []sub_post_process2.StageResult `json:"post_process"` // 写入后每个处理步骤的结果
 }
 
 func NewSaveSubHelper(log *logrus.Logger, subFormatter ifaces.ISubFormatter, subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx) *SaveSubHelper {
-	return &SaveSubHelper{log: log, SubFormatter: subFormatter, subTimelineFixerHelperEx: subTimelineFixerHelperEx}
+	return &SaveSubHelper{log: log, SubFormatter: subFormatter, postProcessPipeline: sub_post_process.NewDefaultPipeline(log, subTimelineFixerHelperEx),
+		ruleSubFormatters: make(map[int]ifaces.ISubFormatter)}
+}
+
+// GetSubFormatter 这个视频使用的字幕命名格式化实现，视频库的扫描规则中可能覆盖了全局的设置
+func (s *SaveSubHelper) GetSubFormatter(videoFileFullPath string) ifaces.ISubFormatter {
+
+	subNameFormatter := scan_rules.GetSubNameFormatter(videoFileFullPath)
+	if subNameFormatter == s.SubFormatter.GetFormatterFormatterName() {
+		return s.SubFormatter
+	}
+	s.ruleSubFormattersLock.Lock()
+	defer s.ruleSubFormattersLock.Unlock()
+	subFormatter, found := s.ruleSubFormatters[subNameFormatter]
+	if found == false {
+		subFormatter = sub_formatter.GetSubFormatter(s.log, subNameFormatter)
+		s.ruleSubFormatters[subNameFormatter] = subFormatter
+	}
+	return subFormatter
 }
 
 // WriteSubFile2VideoPath 在前面需要进行语言的筛选、排序，这里仅仅是存储， extraSubPreName 这里传递是字幕的网站，有就认为是多字幕的存储。空就是单字幕，单字幕就可以setDefault
-func (s *SaveSubHelper) WriteSubFile2VideoPath(videoFileFullPath string, finalSubFile subparser.FileInfo, extraSubPreName string, setDefault bool, skipExistFile bool) error {
+// 返回写入的字幕，skipExistFile 跳过的时候返回 nil。jobID 用于把处理步骤的结果记录到任务的历史中，没有任务的时候为空
+func (s *SaveSubHelper) WriteSubFile2VideoPath(jobID string, videoFileFullPath string, finalSubFile subparser.FileInfo, extraSubPreName string, setDefault bool, skipExistFile bool) (*SavedSub, error) {
 	defer s.log.Infoln("----------------------------------")
 	videoRootPath := filepath.Dir(videoFileFullPath)
-	subNewName, subNewNameWithDefault, _ := s.SubFormatter.GenerateMixSubName(videoFileFullPath, finalSubFile.Ext, finalSubFile.Lang, extraSubPreName)
+	subNewName, subNewNameWithDefault, _ := s.GetSubFormatter(videoFileFullPath).GenerateMixSubName(videoFileFullPath, finalSubFile.Ext, finalSubFile.Lang, extraSubPreName)
 
 	desSubFullPath := filepath.Join(videoRootPath, subNewName)
 	if setDefault == true {
@@ -44,48 +77,50 @@ func (s *SaveSubHelper) WriteSubFile2VideoPath(videoFileFullPath string, finalSu
 		if pkg.IsFile(desSubFullPath) == true {
 			s.log.Infoln("OrgSubName:", finalSubFile.Name)
 			s.log.Infoln("Sub Skip DownAt:", desSubFullPath)
-			return nil
+			return nil, nil
 		}
 	}
 	// 最后写入字幕
+	return s.writeAndProcess(jobID, videoFileFullPath, desSubFullPath, finalSubFile)
+}
+
+// WriteForcedSubFile2VideoPath 存储 forced 字幕，会带上 .forced 的标记，与主字幕并存
+func (s *SaveSubHelper) WriteForcedSubFile2VideoPath(jobID string, videoFileFullPath string, finalSubFile subparser.FileInfo, extraSubPreName string) (*SavedSub, error) {
+	defer s.log.Infoln("----------------------------------")
+	videoRootPath := filepath.Dir(videoFileFullPath)
+	_, _, subNewNameWithForced := s.GetSubFormatter(videoFileFullPath).GenerateMixSubName(videoFileFullPath, finalSubFile.Ext, finalSubFile.Lang, extraSubPreName)
+
+	return s.writeAndProcess(jobID, videoFileFullPath, filepath.Join(videoRootPath, subNewNameWithForced), finalSubFile)
+}
+
+// writeAndProcess 写入字幕，然后按设置的处理步骤进行时间轴校正、编码转换、简繁转换等，每个步骤的结果马上记录到任务的历史中
+func (s *SaveSubHelper) writeAndProcess(jobID, videoFileFullPath, desSubFullPath string, finalSubFile subparser.FileInfo) (*SavedSub, error) {
+
 	err := pkg.WriteFile(desSubFullPath, finalSubFile.Data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.log.Infoln("----------------------------------")
 	s.log.Infoln("OrgSubName:", finalSubFile.Name)
 	s.log.Infoln("SubDownAt:", desSubFullPath)
-
-	// 然后还需要判断是否需要校正字幕的时间轴
-	if settings.Get().AdvancedSettings.FixTimeLine == true {
-		err = s.subTimelineFixerHelperEx.Process(videoFileFullPath, desSubFullPath)
+	// 写入的是新的字幕，之前的样式统一的备份已经不对应了，样式统一只在没有备份的时候才备份
+	if pkg.IsFile(desSubFullPath+ass_style_normalizer.BackUpExt) == true {
+		err = os.Remove(desSubFullPath + ass_style_normalizer.BackUpExt)
 		if err != nil {
-			return err
-		}
-	}
-	// 判断是否需要转换字幕的编码
-	if settings.Get().ExperimentalFunction.AutoChangeSubEncode.Enable == true {
-		s.log.Infoln("----------------------------------")
-		s.log.Infoln("change_file_encode to", settings.Get().ExperimentalFunction.AutoChangeSubEncode.GetDesEncodeType())
-		err = change_file_encode.Process(desSubFullPath, settings.Get().ExperimentalFunction.AutoChangeSubEncode.DesEncodeType)
-		if err != nil {
-			return err
+			// 这个错误可以忍
+			s.log.Warningln("Remove Old Style BackUp,", desSubFullPath, err)
 		}
 	}
 
-	// 判断是否需要进行简繁互转
-	// 一定得是 UTF-8 才能够执行简繁转换
-	// 测试了先转 UTF-8 进行简繁转换然后再转 GBK，有些时候会出错，所以还是不支持这样先
-	if settings.Get().ExperimentalFunction.AutoChangeSubEncode.Enable == true &&
-		settings.Get().ExperimentalFunction.AutoChangeSubEncode.DesEncodeType == 0 &&
-		settings.Get().ExperimentalFunction.ChsChtChanger.Enable == true {
-		s.log.Infoln("----------------------------------")
-		s.log.Infoln("chs_cht_changer to", settings.Get().ExperimentalFunction.ChsChtChanger.GetDesChineseLanguageTypeString())
-		err = chs_cht_changer.Process(desSubFullPath, settings.Get().ExperimentalFunction.ChsChtChanger.DesChineseLanguageType)
-		if err != nil {
-			return err
-		}
-	}
+	// 某个步骤失败了不影响字幕的保存，结果记录到任务的历史中，也跟随 SavedSub 传递给字幕保存后的回调
+	stageResults, desSubFullPath := s.postProcessPipeline.Process(videoFileFullPath, desSubFullPath, finalSubFile)
+	sub_post_process.SaveRecords(s.log, jobID, videoFileFullPath, desSubFullPath, stageResults)
 
-	return nil
+	return &SavedSub{
+		SubFPath:    desSubFullPath,
+		Language:    finalSubFile.Lang.String(),
+		Source:      finalSubFile.FromWhereSite,
+		IsForced:    finalSubFile.IsForced,
+		PostProcess: stageResults,
+	}, nil
 }
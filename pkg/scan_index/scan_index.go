@@ -0,0 +1,511 @@
+package scan_index
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/filter"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/emirpasic/gods/maps/treemap"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	ScanIndex 增量扫描使用的视频索引，持久化到数据库中
+	1. 目录的修改时间没有变化，就直接使用缓存的目录内容（子目录、视频、字幕），不再读取这个目录
+	2. 视频的大小、修改时间没有变化，就认为这个视频没有变化，后续的扫描会跳过它
+	3. 原地覆盖写入文件（比如修改了 nfo）不会改变目录的修改时间，所以需要定期的进行全量扫描来修正
+*/
+type ScanIndex struct {
+	log            *logrus.Logger
+	useDb          bool // 是否持久化到数据库中，单元测试的时候不需要
+	loaded         bool
+	locker         sync.Mutex
+	dirs           map[string]*models.ScanIndexDir   // DirFPath -- 目录
+	videos         map[string]*models.ScanIndexVideo // VideoFPath -- 视频
+	isFullScan     bool
+	startTime      time.Time
+	seenDirs       map[string]bool
+	seenVideos     map[string]bool
+	changedDirs    map[string]bool // 本次扫描重新读取了内容的目录
+	changedVideos  map[string]bool // 本次扫描新增、变化的视频
+	removedPaths   []string        // 本次扫描被移除的视频、目录
+	dirtyDirs      []*models.ScanIndexDir
+	dirtyVideos    []*models.ScanIndexVideo
+	statistics     Statistics
+	lastStatistics *Statistics
+	lastFullScan   time.Time
+}
+
+func newScanIndex(log *logrus.Logger, useDb bool) *ScanIndex {
+	return &ScanIndex{
+		log:    log,
+		useDb:  useDb,
+		dirs:   make(map[string]*models.ScanIndexDir),
+		videos: make(map[string]*models.ScanIndexVideo),
+	}
+}
+
+// Get 获取持久化到数据库的视频索引
+func Get(log *logrus.Logger) *ScanIndex {
+	scanIndexOnce.Do(func() {
+		scanIndexInstance = newScanIndex(log, true)
+	})
+	return scanIndexInstance
+}
+
+// GetLastStatistics 上一次扫描的统计信息，还没有扫描过返回 nil
+func GetLastStatistics() *Statistics {
+	if scanIndexInstance == nil {
+		return nil
+	}
+	scanIndexInstance.locker.Lock()
+	defer scanIndexInstance.locker.Unlock()
+	if scanIndexInstance.lastStatistics == nil {
+		return nil
+	}
+	statistics := *scanIndexInstance.lastStatistics
+	return &statistics
+}
+
+// NeedFullScan 距离上一次全量扫描是否超过了间隔的天数，程序启动后的第一次扫描也是全量扫描
+func (s *ScanIndex) NeedFullScan(fullScanIntervalDays int) bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.lastFullScan.IsZero() == true {
+		return true
+	}
+	return time.Since(s.lastFullScan) > time.Duration(fullScanIntervalDays)*24*time.Hour
+}
+
+// BeginScan 开始一次扫描，isFullScan 会重新读取所有的目录
+func (s *ScanIndex) BeginScan(isFullScan bool) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.load()
+	s.isFullScan = isFullScan
+	s.startTime = time.Now()
+	s.seenDirs = make(map[string]bool)
+	s.seenVideos = make(map[string]bool)
+	s.changedDirs = make(map[string]bool)
+	s.changedVideos = make(map[string]bool)
+	s.removedPaths = make([]string, 0)
+	s.dirtyDirs = make([]*models.ScanIndexDir, 0)
+	s.dirtyVideos = make([]*models.ScanIndexVideo, 0)
+	s.statistics = Statistics{IsFullScan: isFullScan, StartTime: s.startTime}
+}
+
+// WalkRoots 遍历电影或者连续剧的根目录，更新索引
+func (s *ScanIndex) WalkRoots(roots []string) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	for _, root := range roots {
+		err := s.walkDir(root, root)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VideoFilesByRoot 根目录 -- 视频的全路径，从最新到最早排序，同 search.MatchedVideoFileFromDirs
+func (s *ScanIndex) VideoFilesByRoot(roots []string) *treemap.Map {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	outMap := treemap.NewWithStringComparator()
+	for _, root := range roots {
+		videoFPaths := make([]string, 0)
+		for videoFPath, video := range s.videos {
+			// 目录没有变化的时候不会重新过滤，这里需要再判断一次扫描规则，规则可能修改了
+			if video.RootDirPath == root && s.seenVideos[videoFPath] == true && scan_rules.IsSkipped(s.log, videoFPath, false) == false {
+				videoFPaths = append(videoFPaths, videoFPath)
+			}
+		}
+		sort.SliceStable(videoFPaths, func(i, j int) bool {
+			return s.videos[videoFPaths[i]].ModTime > s.videos[videoFPaths[j]].ModTime
+		})
+		outMap.Put(root, videoFPaths)
+	}
+	return outMap
+}
+
+// SeriesDirsByRoot 根目录 -- 连续剧的目录（有 tvshow.nfo 的目录），同 series_helper.GetSeriesListFromDirs
+func (s *ScanIndex) SeriesDirsByRoot(roots []string) *treemap.Map {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	outMap := treemap.NewWithStringComparator()
+	for _, root := range roots {
+		seriesDirs := make([]string, 0)
+		for dirFPath, dir := range s.dirs {
+			if dir.RootDirPath == root && dir.HasTVNfo == true && s.seenDirs[dirFPath] == true &&
+				scan_rules.IsSkipped(s.log, filepath.Join(dirFPath, decode.MetadateTVNfo), false) == false {
+				seriesDirs = append(seriesDirs, dirFPath)
+			}
+		}
+		sort.Strings(seriesDirs)
+		outMap.Put(root, seriesDirs)
+	}
+	return outMap
+}
+
+// IsChanged 本次扫描中，这个视频（或者连续剧目录下的内容）是否有变化，全量扫描总是返回 true
+func (s *ScanIndex) IsChanged(fPath string) bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	if s.isFullScan == true {
+		return true
+	}
+	if s.changedVideos[fPath] == true || s.changedDirs[filepath.Dir(fPath)] == true {
+		return true
+	}
+	// 连续剧的目录，下面任意的目录、视频有变化都需要重新处理
+	if s.dirs[fPath] != nil {
+		for changedDir := range s.changedDirs {
+			if isSubPath(fPath, changedDir) == true {
+				return true
+			}
+		}
+		for changedVideo := range s.changedVideos {
+			if isSubPath(fPath, changedVideo) == true {
+				return true
+			}
+		}
+		for _, removedPath := range s.removedPaths {
+			if isSubPath(fPath, removedPath) == true {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetVideo 获取视频的索引信息
+func (s *ScanIndex) GetVideo(videoFPath string) (*models.ScanIndexVideo, bool) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	video, found := s.videos[videoFPath]
+	return video, found
+}
+
+// EndScan 结束一次扫描，找出被移除的视频、目录，持久化索引，记录统计信息
+func (s *ScanIndex) EndScan(roots []string) (*Statistics, error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	isScannedRoot := func(root string) bool {
+		for _, one := range roots {
+			if one == root {
+				return true
+			}
+		}
+		return false
+	}
+
+	removedDirUIDs := make([]string, 0)
+	for dirFPath, dir := range s.dirs {
+		if isScannedRoot(dir.RootDirPath) == false || s.seenDirs[dirFPath] == true {
+			continue
+		}
+		removedDirUIDs = append(removedDirUIDs, dir.UID)
+		s.removedPaths = append(s.removedPaths, dirFPath)
+		delete(s.dirs, dirFPath)
+	}
+	removedVideoUIDs := make([]string, 0)
+	for videoFPath, video := range s.videos {
+		if isScannedRoot(video.RootDirPath) == false || s.seenVideos[videoFPath] == true {
+			continue
+		}
+		removedVideoUIDs = append(removedVideoUIDs, video.UID)
+		s.removedPaths = append(s.removedPaths, videoFPath)
+		delete(s.videos, videoFPath)
+	}
+	s.statistics.VideosRemoved = len(removedVideoUIDs)
+	s.statistics.DirsTotal = len(s.seenDirs)
+	s.statistics.VideosTotal = len(s.seenVideos)
+	s.statistics.IndexCost = time.Since(s.startTime).Seconds()
+
+	var err error
+	if s.useDb == true {
+		err = s.save(removedDirUIDs, removedVideoUIDs)
+	}
+	if s.isFullScan == true {
+		s.lastFullScan = s.startTime
+	}
+	statistics := s.statistics
+	s.lastStatistics = &statistics
+
+	s.log.Infoln("ScanIndex", statistics.String())
+
+	return &statistics, err
+}
+
+// SetTotalCost 记录整个扫描流程（包括过滤需要下载的视频）的耗时
+func (s *ScanIndex) SetTotalCost(cost time.Duration) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.statistics.TotalCost = cost.Seconds()
+	if s.lastStatistics != nil {
+		s.lastStatistics.TotalCost = cost.Seconds()
+	}
+}
+
+// walkDir 递归的遍历目录，目录的修改时间没有变化就使用缓存的内容
+func (s *ScanIndex) walkDir(rootDirPath, dirFPath string) error {
+
+	stat, err := os.Stat(dirFPath)
+	if err != nil {
+		return err
+	}
+	s.seenDirs[dirFPath] = true
+
+	dir, found := s.dirs[dirFPath]
+	if found == false || s.isFullScan == true || dir.ModTime != stat.ModTime().UnixNano() || dir.RootDirPath != rootDirPath {
+		dir, err = s.readDir(rootDirPath, dirFPath, stat.ModTime().UnixNano())
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, videoFPath := range dir.VideoFPaths {
+			if _, found = s.videos[videoFPath]; found == true {
+				s.seenVideos[videoFPath] = true
+			}
+		}
+	}
+
+	for _, subDir := range dir.SubDirs {
+		// 内层的错误就无视了，同 search.MatchedVideoFile
+		err = s.walkDir(rootDirPath, subDir)
+		if err != nil {
+			s.log.Debugln("ScanIndex.walkDir", subDir, err)
+		}
+	}
+	return nil
+}
+
+// readDir 重新读取目录的内容，并更新这个目录下视频的索引
+func (s *ScanIndex) readDir(rootDirPath, dirFPath string, modTime int64) (*models.ScanIndexDir, error) {
+
+	files, err := os.ReadDir(dirFPath)
+	if err != nil {
+		return nil, err
+	}
+	dir := models.NewScanIndexDir(dirFPath, rootDirPath, modTime)
+	// 视频的全路径 -- 用于判断变化的文件，蓝光是 id.bdmv
+	videoStatFPaths := make(map[string]string)
+	for _, curFile := range files {
+		fullPath := filepath.Join(dirFPath, curFile.Name())
+		if curFile.IsDir() == true {
+			dir.SubDirs = append(dir.SubDirs, fullPath)
+			continue
+		}
+		if curFile.Name() == decode.MetadateTVNfo {
+			dir.HasTVNfo = true
+			continue
+		}
+		if sub_parser_hub.IsSubExtWanted(curFile.Name()) == true {
+			dir.SubFPaths = append(dir.SubFPaths, fullPath)
+			continue
+		}
+		// 视频的过滤规则同 search.MatchedVideoFile
+		bok, fakeBDMVVideoFile := pkg.FileNameIsBDMV(fullPath)
+		if bok == true {
+			dir.VideoFPaths = append(dir.VideoFPaths, fakeBDMVVideoFile)
+			videoStatFPaths[fakeBDMVVideoFile] = fullPath
+			continue
+		}
+		if pkg.IsWantedVideoExtDef(curFile.Name()) == false {
+			continue
+		}
+		if filepath.Base(dirFPath) == "STREAM" {
+			continue
+		}
+		if filter.SkipFileInfo(s.log, curFile, fullPath) == true {
+			continue
+		}
+		dir.VideoFPaths = append(dir.VideoFPaths, fullPath)
+		videoStatFPaths[fullPath] = fullPath
+	}
+	for _, videoFPath := range dir.VideoFPaths {
+		subFPaths := dir.SubFPaths
+		if filepath.Dir(videoFPath) != dirFPath {
+			// 蓝光的视频，字幕在上两层的目录中
+			subFPaths = listSubFPaths(filepath.Dir(videoFPath))
+		}
+		s.updateVideo(rootDirPath, videoFPath, videoStatFPaths[videoFPath], subFPaths)
+	}
+
+	oldDir, found := s.dirs[dirFPath]
+	if found == false || oldDir.ModTime != dir.ModTime || isSameList(oldDir.VideoFPaths, dir.VideoFPaths) == false ||
+		isSameList(oldDir.SubFPaths, dir.SubFPaths) == false || oldDir.HasTVNfo != dir.HasTVNfo {
+		s.changedDirs[dirFPath] = true
+		s.statistics.DirsChanged++
+	}
+	s.dirs[dirFPath] = dir
+	s.dirtyDirs = append(s.dirtyDirs, dir)
+	return dir, nil
+}
+
+// updateVideo 视频的大小、修改时间有变化，标记为有变化的视频。statFPath 是用于判断变化的文件，蓝光是 id.bdmv
+func (s *ScanIndex) updateVideo(rootDirPath, videoFPath, statFPath string, dirSubFPaths []string) {
+
+	stat, err := os.Stat(statFPath)
+	if err != nil {
+		s.log.Debugln("ScanIndex.updateVideo", statFPath, err)
+		return
+	}
+	s.seenVideos[videoFPath] = true
+
+	subFPaths := getVideoSubFPaths(videoFPath, dirSubFPaths)
+	video, found := s.videos[videoFPath]
+	if found == true && video.Size == stat.Size() && video.ModTime == stat.ModTime().UnixNano() {
+		if isSameList(video.SubFPaths, subFPaths) == false {
+			video.SubFPaths = subFPaths
+			s.dirtyVideos = append(s.dirtyVideos, video)
+		}
+		return
+	}
+	if found == true {
+		s.statistics.VideosChanged++
+	} else {
+		s.statistics.VideosNew++
+	}
+
+	video = &models.ScanIndexVideo{
+		UID:         models.GenerateUID4ScanIndex(videoFPath),
+		VideoFPath:  videoFPath,
+		RootDirPath: rootDirPath,
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime().UnixNano(),
+		SubFPaths:   subFPaths,
+	}
+
+	s.videos[videoFPath] = video
+	s.changedVideos[videoFPath] = true
+	s.dirtyVideos = append(s.dirtyVideos, video)
+}
+
+// getVideoSubFPaths 视频所在目录的字幕中，文件名以视频文件名开头的字幕
+func getVideoSubFPaths(videoFPath string, dirSubFPaths []string) models.StringList {
+
+	subFPaths := make(models.StringList, 0)
+	videoName := strings.TrimSuffix(filepath.Base(videoFPath), filepath.Ext(videoFPath))
+	for _, subFPath := range dirSubFPaths {
+		if strings.HasPrefix(filepath.Base(subFPath), videoName) == true {
+			subFPaths = append(subFPaths, subFPath)
+		}
+	}
+	return subFPaths
+}
+
+// listSubFPaths 目录中的字幕
+func listSubFPaths(dirFPath string) []string {
+
+	subFPaths := make([]string, 0)
+	entries, err := os.ReadDir(dirFPath)
+	if err != nil {
+		return subFPaths
+	}
+	for _, entry := range entries {
+		if entry.IsDir() == false && sub_parser_hub.IsSubExtWanted(entry.Name()) == true {
+			subFPaths = append(subFPaths, filepath.Join(dirFPath, entry.Name()))
+		}
+	}
+	return subFPaths
+}
+
+// load 从数据库中读取索引，只会读取一次
+func (s *ScanIndex) load() {
+
+	if s.loaded == true || s.useDb == false {
+		return
+	}
+	s.loaded = true
+
+	var dirs []models.ScanIndexDir
+	dao.GetDb().Find(&dirs)
+	for i := range dirs {
+		s.dirs[dirs[i].DirFPath] = &dirs[i]
+	}
+	var videos []models.ScanIndexVideo
+	dao.GetDb().Find(&videos)
+	for i := range videos {
+		s.videos[videos[i].VideoFPath] = &videos[i]
+	}
+	s.log.Infoln("ScanIndex loaded, dirs:", len(s.dirs), "videos:", len(s.videos))
+}
+
+// save 持久化本次扫描有变化的索引
+func (s *ScanIndex) save(removedDirUIDs, removedVideoUIDs []string) error {
+
+	tx := dao.GetDb().Begin()
+	for _, dir := range s.dirtyDirs {
+		if s.dirs[dir.DirFPath] != dir {
+			// 已经被移除或者被替换了
+			continue
+		}
+		if err := tx.Save(dir).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, video := range s.dirtyVideos {
+		if s.videos[video.VideoFPath] != video {
+			continue
+		}
+		if err := tx.Save(video).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if len(removedDirUIDs) > 0 {
+		if err := tx.Where("uid IN ?", removedDirUIDs).Delete(&models.ScanIndexDir{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if len(removedVideoUIDs) > 0 {
+		if err := tx.Where("uid IN ?", removedVideoUIDs).Delete(&models.ScanIndexVideo{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func isSameList(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubPath fPath 是否在 dirFPath 目录下
+func isSubPath(dirFPath, fPath string) bool {
+	return fPath == dirFPath || strings.HasPrefix(fPath, strings.TrimRight(dirFPath, "/\\")+string(os.PathSeparator))
+}
+
+var (
+	scanIndexInstance *ScanIndex
+	scanIndexOnce     sync.Once
+)
@@ -0,0 +1,107 @@
+package scan_index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+func writeTestFile(t *testing.T, fileFPath string, content string) {
+
+	err := os.MkdirAll(filepath.Dir(fileFPath), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fileFPath, []byte(content), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestVideo 小于 1000 字节的视频会被过滤掉
+func writeTestVideo(t *testing.T, videoFPath string) {
+	writeTestFile(t, videoFPath, strings.Repeat(filepath.Base(videoFPath), 1000))
+}
+
+func scanOnce(t *testing.T, s *ScanIndex, isFullScan bool, movieRoot, seriesRoot string) *Statistics {
+
+	s.BeginScan(isFullScan)
+	err := s.WalkRoots([]string{movieRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WalkRoots([]string{seriesRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statistics, err := s.EndScan([]string{movieRoot, seriesRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statistics
+}
+
+func TestScanIndex_Incremental(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	movieRoot := t.TempDir()
+	seriesRoot := t.TempDir()
+	movieA := filepath.Join(movieRoot, "Foo (2020)", "Foo (2020).mkv")
+	movieB := filepath.Join(movieRoot, "Bar (2021)", "Bar (2021).mp4")
+	seriesDir := filepath.Join(seriesRoot, "Show")
+	episode1 := filepath.Join(seriesDir, "Season 1", "Show - S01E01.mkv")
+	writeTestVideo(t, movieA)
+	writeTestVideo(t, movieB)
+	writeTestFile(t, filepath.Join(seriesDir, "tvshow.nfo"), "<tvshow></tvshow>")
+	writeTestVideo(t, episode1)
+
+	s := newScanIndex(log_helper.GetLogger4Tester(), false)
+	// 第一次扫描，都是新增的
+	statistics := scanOnce(t, s, false, movieRoot, seriesRoot)
+	if statistics.VideosTotal != 3 || statistics.VideosNew != 3 || statistics.VideosRemoved != 0 {
+		t.Fatalf("first scan statistics wrong: %s", statistics.String())
+	}
+	movies, _ := s.VideoFilesByRoot([]string{movieRoot}).Get(movieRoot)
+	if len(movies.([]string)) != 2 {
+		t.Fatalf("VideoFilesByRoot want 2 movies, got %v", movies)
+	}
+	seriesDirs, _ := s.SeriesDirsByRoot([]string{seriesRoot}).Get(seriesRoot)
+	if len(seriesDirs.([]string)) != 1 || seriesDirs.([]string)[0] != seriesDir {
+		t.Fatalf("SeriesDirsByRoot want %s, got %v", seriesDir, seriesDirs)
+	}
+	// 没有任何变化
+	statistics = scanOnce(t, s, false, movieRoot, seriesRoot)
+	if statistics.VideosTotal != 3 || statistics.VideosNew != 0 || statistics.DirsChanged != 0 {
+		t.Fatalf("unchanged scan statistics wrong: %s", statistics.String())
+	}
+	if s.IsChanged(movieA) == true || s.IsChanged(seriesDir) == true {
+		t.Fatal("IsChanged should be false when nothing changed")
+	}
+	// 电影 A 新增了字幕，电影 B 被删除，连续剧新增了一集
+	writeTestFile(t, filepath.Join(filepath.Dir(movieA), "Foo (2020).chinese(简英).ass"), "sub")
+	err := os.RemoveAll(filepath.Dir(movieB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestVideo(t, filepath.Join(seriesDir, "Season 1", "Show - S01E02.mkv"))
+	statistics = scanOnce(t, s, false, movieRoot, seriesRoot)
+	if statistics.VideosTotal != 3 || statistics.VideosNew != 1 || statistics.VideosRemoved != 1 {
+		t.Fatalf("changed scan statistics wrong: %s", statistics.String())
+	}
+	if s.IsChanged(movieA) == false || s.IsChanged(seriesDir) == false {
+		t.Fatal("IsChanged should be true for changed movie and series")
+	}
+	video, found := s.GetVideo(movieA)
+	if found == false || len(video.SubFPaths) != 1 {
+		t.Fatalf("movie a sub list wrong: %v", video)
+	}
+	// 全量扫描，都需要重新处理
+	statistics = scanOnce(t, s, true, movieRoot, seriesRoot)
+	if statistics.IsFullScan == false || statistics.VideosNew != 0 || s.IsChanged(episode1) == false {
+		t.Fatalf("full scan statistics wrong: %s", statistics.String())
+	}
+}
@@ -0,0 +1,25 @@
+package scan_index
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statistics 一次扫描的统计信息
+type Statistics struct {
+	IsFullScan    bool      `json:"is_full_scan"`   // 是否是全量扫描
+	StartTime     time.Time `json:"start_time"`     // 扫描开始的时间
+	IndexCost     float64   `json:"index_cost"`     // 遍历目录、更新索引的耗时，秒
+	TotalCost     float64   `json:"total_cost"`     // 整个扫描流程的耗时，秒
+	DirsTotal     int       `json:"dirs_total"`     // 目录的总数
+	DirsChanged   int       `json:"dirs_changed"`   // 重新读取了内容的目录数量
+	VideosTotal   int       `json:"videos_total"`   // 视频的总数
+	VideosNew     int       `json:"videos_new"`     // 新增的视频数量
+	VideosChanged int       `json:"videos_changed"` // 大小、修改时间有变化的视频数量
+	VideosRemoved int       `json:"videos_removed"` // 被移除的视频数量
+}
+
+func (s Statistics) String() string {
+	return fmt.Sprintf("full scan: %v, dirs: %d (changed %d), videos: %d (new %d, changed %d, removed %d), cost: %.1f s",
+		s.IsFullScan, s.DirsTotal, s.DirsChanged, s.VideosTotal, s.VideosNew, s.VideosChanged, s.VideosRemoved, s.IndexCost)
+}
@@ -0,0 +1,116 @@
+package scan_rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	IgnoreFileName 放到任意目录中的忽略文件，对这个目录以及子目录生效
+	1. 文件内容为空，整个目录都跳过
+	2. 每一行是一条规则，语法同 settings.ScanRule，匹配的是相对于这个目录的路径
+*/
+const IgnoreFileName = ".csfignore"
+
+type ignoreFile struct {
+	exists    bool
+	patterns  []string
+	checkTime time.Time
+}
+
+// isIgnoredByIgnoreFile 从文件所在的目录向上查找 .csfignore，直到视频库的根目录
+func isIgnoredByIgnoreFile(l *logrus.Logger, fileFullPath string, isDir bool) bool {
+
+	dir := filepath.Dir(fileFullPath)
+	if isDir == true {
+		dir = fileFullPath
+	}
+	stopDir := getLibraryRootDir(fileFullPath)
+	if stopDir == "" {
+		stopDir = dir
+	}
+	for {
+		ignore := readIgnoreFile(l, dir)
+		if ignore.exists == true {
+			if len(ignore.patterns) == 0 {
+				return true
+			}
+			relPath := getRelPath(dir, fileFullPath)
+			for _, pattern := range ignore.patterns {
+				if matchPattern(l, pattern, relPath) == true {
+					return true
+				}
+			}
+		}
+		parentDir := filepath.Dir(dir)
+		if dir == stopDir || parentDir == dir || isSubPath(stopDir, parentDir) == false {
+			break
+		}
+		dir = parentDir
+	}
+	return false
+}
+
+// getLibraryRootDir 文件所在的视频库根目录，电影、连续剧目录以及扫描规则中的目录，多个都匹配的时候使用最长的那个
+func getLibraryRootDir(fileFullPath string) string {
+
+	rootDirs := make([]string, 0)
+	rootDirs = append(rootDirs, settings.Get().CommonSettings.MoviePaths...)
+	rootDirs = append(rootDirs, settings.Get().CommonSettings.SeriesPaths...)
+	for _, rule := range settings.Get().AdvancedSettings.ScanRulesSettings.Rules {
+		rootDirs = append(rootDirs, rule.RootDirPath)
+	}
+	found := ""
+	for _, rootDir := range rootDirs {
+		rootDir = strings.TrimRight(rootDir, `/\`)
+		if rootDir == "" {
+			continue
+		}
+		if isSubPath(rootDir, fileFullPath) == true && len(rootDir) > len(found) {
+			found = rootDir
+		}
+	}
+	return found
+}
+
+// readIgnoreFile 读取目录中的 .csfignore，结果会缓存一段时间
+func readIgnoreFile(l *logrus.Logger, dir string) *ignoreFile {
+
+	ignoreFileCacheLocker.Lock()
+	defer ignoreFileCacheLocker.Unlock()
+
+	cached, found := ignoreFileCache[dir]
+	if found == true && time.Since(cached.checkTime) < ignoreFileCacheTime {
+		return cached
+	}
+	ignore := &ignoreFile{patterns: make([]string, 0), checkTime: time.Now()}
+	ignoreFileBytes, err := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if err == nil {
+		ignore.exists = true
+		for _, line := range strings.Split(string(ignoreFileBytes), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") == true {
+				continue
+			}
+			ignore.patterns = append(ignore.patterns, line)
+		}
+	} else if os.IsNotExist(err) == false {
+		l.Warningln("ScanRules, read", IgnoreFileName, dir, err)
+	}
+	ignoreFileCache[dir] = ignore
+	return ignore
+}
+
+var (
+	ignoreFileCache       = make(map[string]*ignoreFile)
+	ignoreFileCacheLocker sync.Mutex
+)
+
+// ignoreFileCacheTime .csfignore 的读取结果缓存的时间，避免每个文件都去读取一次
+const ignoreFileCacheTime = 30 * time.Second
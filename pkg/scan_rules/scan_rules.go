@@ -0,0 +1,244 @@
+package scan_rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+// IsSkipped 根据扫描规则、.csfignore 判断这个文件（或者目录）是否需要跳过，.csfignore 只看 UseIgnoreFile，没有启用扫描规则也生效
+func IsSkipped(l *logrus.Logger, fileFullPath string, isDir bool) bool {
+
+	if settings.IsConfigRootPathSet() == false {
+		return false
+	}
+	rulesSettings := settings.Get().AdvancedSettings.ScanRulesSettings
+	if rulesSettings == nil {
+		return false
+	}
+
+	// 没有启用扫描规则的时候 GetRule 返回 nil
+	rule := GetRule(fileFullPath)
+	if rule != nil {
+		relPath := getRelPath(rule.RootDirPath, fileFullPath)
+		for _, exclude := range rule.Excludes {
+			if matchPattern(l, exclude, relPath) == true {
+				l.Debugln("ScanRules, match exclude", exclude, fileFullPath)
+				return true
+			}
+		}
+		if len(rule.Includes) > 0 && isDir == false && pkg.IsWantedVideoExtDef(fileFullPath) == true {
+			included := false
+			for _, include := range rule.Includes {
+				if matchPattern(l, include, relPath) == true {
+					included = true
+					break
+				}
+			}
+			if included == false {
+				l.Debugln("ScanRules, not match any include", fileFullPath)
+				return true
+			}
+		}
+	}
+
+	if rulesSettings.UseIgnoreFile == true && isIgnoredByIgnoreFile(l, fileFullPath, isDir) == true {
+		l.Debugln("ScanRules, ignored by", IgnoreFileName, fileFullPath)
+		return true
+	}
+
+	return false
+}
+
+// GetRule 获取这个文件所在的视频库根目录的规则，多个根目录都匹配的时候使用最长的那个，没有返回 nil
+func GetRule(fileFullPath string) *settings.ScanRule {
+
+	if settings.IsConfigRootPathSet() == false {
+		return nil
+	}
+	rulesSettings := settings.Get().AdvancedSettings.ScanRulesSettings
+	if rulesSettings == nil || rulesSettings.Enable == false {
+		return nil
+	}
+	var found *settings.ScanRule
+	for i, rule := range rulesSettings.Rules {
+		if isSubPath(rule.RootDirPath, fileFullPath) == false {
+			continue
+		}
+		if found == nil || len(rule.RootDirPath) > len(found.RootDirPath) {
+			found = &rulesSettings.Rules[i]
+		}
+	}
+	return found
+}
+
+// GetSubNameFormatter 这个视频使用的字幕命名格式，规则中没有覆盖就使用全局的设置
+func GetSubNameFormatter(videoFPath string) int {
+
+	rule := GetRule(videoFPath)
+	if rule != nil && rule.SubNameFormatter != nil {
+		return *rule.SubNameFormatter
+	}
+	return settings.Get().AdvancedSettings.SubNameFormatter
+}
+
+// GetFixTimeLine 这个视频是否需要校正字幕的时间轴，规则中没有覆盖就使用全局的设置
+func GetFixTimeLine(videoFPath string) bool {
+
+	rule := GetRule(videoFPath)
+	if rule != nil && rule.FixTimeLine != nil {
+		return *rule.FixTimeLine
+	}
+	return settings.Get().AdvancedSettings.FixTimeLine
+}
+
+// AnyRuleFixTimeLine 是否有视频库的扫描规则开启了字幕时间轴校正，需要提前检查 ffmpeg
+func AnyRuleFixTimeLine() bool {
+
+	if settings.IsConfigRootPathSet() == false {
+		return false
+	}
+	rulesSettings := settings.Get().AdvancedSettings.ScanRulesSettings
+	if rulesSettings == nil || rulesSettings.Enable == false {
+		return false
+	}
+	for _, rule := range rulesSettings.Rules {
+		if rule.FixTimeLine != nil && *rule.FixTimeLine == true {
+			return true
+		}
+	}
+	return false
+}
+
+// GetChsChtChanger 这个视频的简繁转换设置，规则中设置了目标语言就认为启用了简繁转换
+// 目标只有简体、繁体两种，跟全局的简繁转换一样只处理中文字幕，英文等其他语言的字幕保持原样
+func GetChsChtChanger(videoFPath string) settings.ChsChtChanger {
+
+	rule := GetRule(videoFPath)
+	if rule != nil && rule.DesChineseLanguageType != nil {
+		return settings.ChsChtChanger{
+			Enable:                 true,
+			DesChineseLanguageType: *rule.DesChineseLanguageType,
+		}
+	}
+	return settings.Get().ExperimentalFunction.ChsChtChanger
+}
+
+/*
+	matchPattern 规则匹配相对路径，relPath 使用 / 分隔
+	1. 没有 / 的通配符，匹配路径中的任意一层
+	2. 有 / 的通配符，从头开始逐层匹配
+	3. re: 开头的是正则表达式，匹配整个相对路径
+	空行以及 # 开头的是注释
+*/
+func matchPattern(l *logrus.Logger, pattern string, relPath string) bool {
+
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") == true || relPath == "" {
+		return false
+	}
+	if strings.HasPrefix(pattern, regexPrefix) == true {
+		re, err := getRegexp(strings.TrimPrefix(pattern, regexPrefix))
+		if err != nil {
+			l.Warningln("ScanRules, wrong regex", pattern, err)
+			return false
+		}
+		return re.MatchString(relPath)
+	}
+
+	pattern = strings.ToLower(strings.Trim(filepath.ToSlash(pattern), "/"))
+	elems := strings.Split(strings.ToLower(relPath), "/")
+	parts := strings.Split(pattern, "/")
+	if len(parts) == 1 {
+		for _, elem := range elems {
+			if matched, _ := path.Match(pattern, elem); matched == true {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) > len(elems) {
+		return false
+	}
+	for i, part := range parts {
+		if matched, _ := path.Match(part, elems[i]); matched == false {
+			return false
+		}
+	}
+	return true
+}
+
+func getRegexp(expr string) (*regexp.Regexp, error) {
+
+	regexpCacheLocker.Lock()
+	defer regexpCacheLocker.Unlock()
+	if re, found := regexpCache[expr]; found == true {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache[expr] = re
+	return re, nil
+}
+
+// getRelPath 相对于 baseDir 的路径，使用 / 分隔，不在 baseDir 下返回空
+func getRelPath(baseDir, fileFullPath string) string {
+
+	relPath, err := filepath.Rel(baseDir, fileFullPath)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") == true {
+		return ""
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// IsInLibrary 文件是否在电影、连续剧的目录或者扫描规则的目录中，API 传入的路径需要先检查，路径中的 .. 会先处理掉
+func IsInLibrary(fileFullPath string) bool {
+
+	if fileFullPath == "" {
+		return false
+	}
+	return getLibraryRootDir(filepath.Clean(fileFullPath)) != ""
+}
+
+// CheckInLibrary API 传入的文件需要存在，并且在视频库中，v1、v2 的接口统一使用这个检查，返回 ErrFileNotFound 或者 ErrNotInLibrary
+func CheckInLibrary(fileFullPaths ...string) error {
+
+	for _, fileFullPath := range fileFullPaths {
+		if pkg.IsFile(fileFullPath) == false {
+			return fmt.Errorf("%w, %s", ErrFileNotFound, fileFullPath)
+		}
+		if IsInLibrary(fileFullPath) == false {
+			return fmt.Errorf("%w, %s", ErrNotInLibrary, fileFullPath)
+		}
+	}
+	return nil
+}
+
+// isSubPath fileFullPath 是否在 dirFPath 目录下
+func isSubPath(dirFPath, fileFullPath string) bool {
+	dirFPath = strings.TrimRight(dirFPath, `/\`)
+	return fileFullPath == dirFPath || strings.HasPrefix(fileFullPath, dirFPath+string(os.PathSeparator))
+}
+
+var (
+	ErrFileNotFound = errors.New("file not found")
+	ErrNotInLibrary = errors.New("file is not in the movie or series folders")
+)
+
+var (
+	regexpCache       = make(map[string]*regexp.Regexp)
+	regexpCacheLocker sync.Mutex
+)
+
+const regexPrefix = "re:"
@@ -0,0 +1,199 @@
+package scan_rules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+func TestMatchPattern(t *testing.T) {
+
+	l := log_helper.GetLogger4Tester()
+	tests := []struct {
+		pattern string
+		relPath string
+		want    bool
+	}{
+		{"Extras", "Foo (2020)/Extras/making of.mkv", true},
+		{"extras", "Foo (2020)/Extras/making of.mkv", true},
+		{"*sample*", "Foo (2020)/foo-Sample.mkv", true},
+		{"*sample*", "Foo (2020)/Foo.mkv", false},
+		{"Documentaries/*", "Documentaries/BBC/Planet.mkv", true},
+		{"Documentaries/*", "Movies/Documentaries/Planet.mkv", false},
+		{"re:(?i)\\.trailer\\.", "Foo (2020)/Foo.Trailer.mkv", true},
+		{"re:[", "Foo (2020)/Foo.mkv", false},
+		{"# comment", "comment", false},
+		{"", "Foo.mkv", false},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(l, tt.pattern, tt.relPath); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.relPath, got, tt.want)
+		}
+	}
+}
+
+func TestIsSkipped(t *testing.T) {
+
+	l := log_helper.GetLogger4Tester()
+	settings.SetConfigRootPath(t.TempDir())
+	movieRoot := t.TempDir()
+	otherRoot := t.TempDir()
+	fixTimeLine := true
+	subNameFormatter := 1
+	settings.Get().CommonSettings.MoviePaths = []string{movieRoot, otherRoot}
+	settings.Get().AdvancedSettings.ScanRulesSettings = &settings.ScanRulesSettings{
+		Enable:        true,
+		UseIgnoreFile: true,
+		Rules: []settings.ScanRule{
+			{
+				RootDirPath:      movieRoot,
+				Includes:         []string{"*.mkv"},
+				Excludes:         []string{"Extras", "*sample*"},
+				FixTimeLine:      &fixTimeLine,
+				SubNameFormatter: &subNameFormatter,
+			},
+		},
+	}
+	defer func() {
+		settings.Get().AdvancedSettings.ScanRulesSettings = settings.NewScanRulesSettings()
+	}()
+
+	tests := []struct {
+		fileFPath string
+		want      bool
+	}{
+		{filepath.Join(movieRoot, "Foo (2020)", "Foo.mkv"), false},
+		{filepath.Join(movieRoot, "Foo (2020)", "Foo.mp4"), true},
+		{filepath.Join(movieRoot, "Foo (2020)", "Extras", "Bar.mkv"), true},
+		{filepath.Join(movieRoot, "Foo (2020)", "Foo.sample.mkv"), true},
+		{filepath.Join(movieRoot, "Foo (2020)", "Foo.chinese(简).ass"), false},
+		{filepath.Join(otherRoot, "Bar (2021)", "Bar.mp4"), false},
+	}
+	for _, tt := range tests {
+		if got := IsSkipped(l, tt.fileFPath, false); got != tt.want {
+			t.Errorf("IsSkipped(%q) = %v, want %v", tt.fileFPath, got, tt.want)
+		}
+	}
+
+	if GetFixTimeLine(filepath.Join(movieRoot, "Foo (2020)", "Foo.mkv")) != true ||
+		GetFixTimeLine(filepath.Join(otherRoot, "Bar (2021)", "Bar.mp4")) != settings.Get().AdvancedSettings.FixTimeLine {
+		t.Error("GetFixTimeLine override wrong")
+	}
+	if GetSubNameFormatter(filepath.Join(movieRoot, "Foo (2020)", "Foo.mkv")) != subNameFormatter {
+		t.Error("GetSubNameFormatter override wrong")
+	}
+}
+
+func TestIsSkipped_IgnoreFile(t *testing.T) {
+
+	l := log_helper.GetLogger4Tester()
+	settings.SetConfigRootPath(t.TempDir())
+	seriesRoot := t.TempDir()
+	settings.Get().CommonSettings.SeriesPaths = []string{seriesRoot}
+	settings.Get().AdvancedSettings.ScanRulesSettings = &settings.ScanRulesSettings{Enable: true, UseIgnoreFile: true}
+	defer func() {
+		settings.Get().AdvancedSettings.ScanRulesSettings = settings.NewScanRulesSettings()
+	}()
+
+	// 空的 .csfignore 跳过整个目录，有内容的按规则跳过
+	documentaryDir := filepath.Join(seriesRoot, "Documentaries")
+	showDir := filepath.Join(seriesRoot, "Show")
+	for _, dir := range []string{filepath.Join(documentaryDir, "Planet"), filepath.Join(showDir, "Season 1")} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(documentaryDir, IgnoreFileName), []byte(""), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(showDir, IgnoreFileName), []byte("# specials\nSpecials\n*.iso\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fileFPath string
+		want      bool
+	}{
+		{filepath.Join(documentaryDir, "Planet", "tvshow.nfo"), true},
+		{filepath.Join(showDir, "Season 1", "Show - S01E01.mkv"), false},
+		{filepath.Join(showDir, "Specials", "Show - S00E01.mkv"), true},
+		{filepath.Join(showDir, "Season 1", "Show - S01E02.iso"), true},
+	}
+	for _, tt := range tests {
+		if got := IsSkipped(l, tt.fileFPath, false); got != tt.want {
+			t.Errorf("IsSkipped(%q) = %v, want %v", tt.fileFPath, got, tt.want)
+		}
+	}
+	// 没有启用扫描规则，.csfignore 也需要生效
+	settings.Get().AdvancedSettings.ScanRulesSettings.Enable = false
+	for _, tt := range tests {
+		if got := IsSkipped(l, tt.fileFPath, false); got != tt.want {
+			t.Errorf("Enable == false, IsSkipped(%q) = %v, want %v", tt.fileFPath, got, tt.want)
+		}
+	}
+	settings.Get().AdvancedSettings.ScanRulesSettings.UseIgnoreFile = false
+	if IsSkipped(l, filepath.Join(documentaryDir, "Planet", "tvshow.nfo"), false) == true {
+		t.Error("UseIgnoreFile == false, should not read", IgnoreFileName)
+	}
+}
+
+func TestIsInLibrary(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	movieRoot := t.TempDir()
+	settings.Get().CommonSettings.MoviePaths = []string{movieRoot}
+	settings.Get().AdvancedSettings.ScanRulesSettings = &settings.ScanRulesSettings{
+		Rules: []settings.ScanRule{{RootDirPath: ""}},
+	}
+	defer func() {
+		settings.Get().CommonSettings.MoviePaths = make([]string, 0)
+		settings.Get().AdvancedSettings.ScanRulesSettings = settings.NewScanRulesSettings()
+	}()
+
+	tests := []struct {
+		fileFPath string
+		want      bool
+	}{
+		{filepath.Join(movieRoot, "Foo (2020)", "Foo.mkv"), true},
+		{movieRoot + string(os.PathSeparator) + ".." + string(os.PathSeparator) + "Foo.mkv", false},
+		{filepath.Join(filepath.Dir(movieRoot), "Foo.mkv"), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsInLibrary(tt.fileFPath); got != tt.want {
+			t.Errorf("IsInLibrary(%q) = %v, want %v", tt.fileFPath, got, tt.want)
+		}
+	}
+}
+
+func TestCheckInLibrary(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	movieRoot := t.TempDir()
+	settings.Get().CommonSettings.MoviePaths = []string{movieRoot}
+	defer func() {
+		settings.Get().CommonSettings.MoviePaths = make([]string, 0)
+	}()
+
+	inLibrary := filepath.Join(movieRoot, "Foo.mkv")
+	outOfLibrary := filepath.Join(t.TempDir(), "Bar.mkv")
+	for _, fileFPath := range []string{inLibrary, outOfLibrary} {
+		if err := os.WriteFile(fileFPath, []byte(""), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := CheckInLibrary(inLibrary); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckInLibrary(inLibrary, filepath.Join(movieRoot, "NotExist.mkv")); errors.Is(err, ErrFileNotFound) == false {
+		t.Fatalf("err = %v, want ErrFileNotFound", err)
+	}
+	if err := CheckInLibrary(outOfLibrary); errors.Is(err, ErrNotInLibrary) == false {
+		t.Fatalf("err = %v, want ErrNotInLibrary", err)
+	}
+}
@@ -16,7 +16,9 @@ import (
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/filter"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sort_things"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_helper"
 	"github.com/emirpasic/gods/maps/treemap"
 	"github.com/sirupsen/logrus"
 )
@@ -149,6 +151,10 @@ func TVNfo(l *logrus.Logger, dir string) ([]string, error) {
 				//if filter.SkipFileInfo(l, curFile, fullPath) == true {
 				//	continue
 				//}
+				// 整个连续剧目录可能被扫描规则、.csfignore 排除了
+				if scan_rules.IsSkipped(l, fullPath, false) == true {
+					continue
+				}
 				fileFullPathList = append(fileFullPathList, fullPath)
 			}
 		}
@@ -228,12 +234,13 @@ func SeriesAllEpsAndSubtitles(l *logrus.Logger, dir string) (*backend.SeasonInfo
 			}
 
 			nowOneVideoInfo := backend.OneVideoInfo{
-				Name:         filepath.Base(oneVideo),
-				VideoFPath:   oneVideo,
-				Season:       skipInfo.Season(),
-				Episode:      skipInfo.Eps(),
-				SubFPathList: make([]string, 0),
-				SubUrlList:   make([]string, 0),
+				Name:            filepath.Base(oneVideo),
+				VideoFPath:      oneVideo,
+				Season:          skipInfo.Season(),
+				Episode:         skipInfo.Eps(),
+				SubFPathList:    make([]string, 0),
+				SubUrlList:      make([]string, 0),
+				SubIsForcedList: make([]bool, 0),
 			}
 			// 解析这个视频的 SxxExx 信息
 			for _, oneSub := range nowPathSubs {
@@ -243,6 +250,7 @@ func SeriesAllEpsAndSubtitles(l *logrus.Logger, dir string) (*backend.SeasonInfo
 				}
 				// 找到了对应的字幕
 				nowOneVideoInfo.SubFPathList = append(nowOneVideoInfo.SubFPathList, oneSub)
+				nowOneVideoInfo.SubIsForcedList = append(nowOneVideoInfo.SubIsForcedList, sub_helper.IsForcedSubFileName(oneSub))
 			}
 
 			seasonInfo.OneVideoInfos = append(seasonInfo.OneVideoInfos, nowOneVideoInfo)
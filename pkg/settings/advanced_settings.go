@@ -6,31 +6,58 @@ import (
 )
 
 type AdvancedSettings struct {
-	ProxySettings              *ProxySettings     `json:"proxy_settings"`
-	TmdbApiSettings            TmdbApiSettings    `json:"tmdb_api_settings"`
-	DebugMode                  bool               `json:"debug_mode"`                     // 是否开启调试模式，这个是写入一个特殊的文件来开启日志的 Debug 输出
-	SaveFullSeasonTmpSubtitles bool               `json:"save_full_season_tmp_subtitles"` // 保存整季的缓存字幕
-	SubTypePriority            int                `json:"sub_type_priority"`              // 字幕下载的优先级，0 是自动，1 是 srt 优先，2 是 ass/ssa 优先
-	SubNameFormatter           int                `json:"sub_name_formatter"`             // 字幕命名格式(默认不填写或者超出范围，则为 emby 格式)，0，emby 支持的的格式（AAA.chinese(简英,subhd).ass or AAA.chinese(简英,xunlei).default.ass），1常规格式（兼容性更好，AAA.zh.ass or AAA.zh.default.ass）
-	SaveMultiSub               bool               `json:"save_multi_sub"`                 // 保存多个网站的 Top 1 字幕
-	CustomVideoExts            []string           `json:"custom_video_exts""`             // 自定义视频扩展名，是在原有基础上新增。
-	FixTimeLine                bool               `json:"fix_time_line"`                  // 开启校正字幕时间轴，默认 false
-	Topic                      int                `json:"topic"`                          // 搜索结果的时候，返回 Topic N 以内的
-	SuppliersSettings          *SuppliersSettings `json:"suppliers_settings"`             // 每个字幕源的设置
-	ScanLogic                  *ScanLogic         `json:"scan_logic"`                     // 扫描的逻辑
-	TaskQueue                  *TaskQueue         `json:"task_queue"`                     // 任务队列的设置
-	DownloadFileCache          *DownloadFileCache `json:"download_file_cache"`            // 下载文件的缓存
+	ProxySettings              *ProxySettings           `json:"proxy_settings"`
+	TmdbApiSettings            TmdbApiSettings          `json:"tmdb_api_settings"`
+	DebugMode                  bool                     `json:"debug_mode"`                     // 是否开启调试模式，这个是写入一个特殊的文件来开启日志的 Debug 输出
+	SaveFullSeasonTmpSubtitles bool                     `json:"save_full_season_tmp_subtitles"` // 保存整季的缓存字幕
+	SubTypePriority            int                      `json:"sub_type_priority"`              // 字幕下载的优先级，0 是自动，1 是 srt 优先，2 是 ass/ssa 优先
+	SubNameFormatter           int                      `json:"sub_name_formatter"`             // 字幕命名格式(默认不填写或者超出范围，则为 emby 格式)，0，emby 支持的的格式（AAA.chinese(简英,subhd).ass or AAA.chinese(简英,xunlei).default.ass），1常规格式（兼容性更好，AAA.zh.ass or AAA.zh.default.ass）
+	SaveMultiSub               bool                     `json:"save_multi_sub"`                 // 保存多个网站的 Top 1 字幕
+	SaveForcedSub              bool                     `json:"save_forced_sub"`                // 如果下载到了 forced 字幕（只翻译画面文字、外语片段），额外带上 .forced 标记保存到视频旁边
+	CustomVideoExts            []string                 `json:"custom_video_exts""`             // 自定义视频扩展名，是在原有基础上新增。
+	FixTimeLine                bool                     `json:"fix_time_line"`                  // 开启校正字幕时间轴，默认 false
+	Topic                      int                      `json:"topic"`                          // 搜索结果的时候，返回 Topic N 以内的
+	SuppliersSettings          *SuppliersSettings       `json:"suppliers_settings"`             // 每个字幕源的设置
+	ScanLogic                  *ScanLogic               `json:"scan_logic"`                     // 扫描的逻辑
+	TaskQueue                  *TaskQueue               `json:"task_queue"`                     // 任务队列的设置
+	DownloadFileCache          *DownloadFileCache       `json:"download_file_cache"`            // 下载文件的缓存
+	SubScoreSettings           *SubScoreSettings        `json:"sub_score_settings"`             // 字幕评分系统的权重
+	NotifySettings             *NotifySettings          `json:"notify_settings"`                // 事件通知的设置
+	PostSaveHookSettings       *PostSaveHookSettings    `json:"post_save_hook_settings"`        // 字幕保存后的回调
+	IncomingWebhookSettings    *IncomingWebhookSettings `json:"incoming_webhook_settings"`      // 接收外部的 Webhook，视频入库后立即添加下载任务
+	FileWatcherSettings        *FileWatcherSettings     `json:"file_watcher_settings"`          // 监控视频目录的变化，新的视频立即加入下载队列
+	IncrementalScanSettings    *IncrementalScanSettings `json:"incremental_scan_settings"`      // 增量扫描
+	ScanRulesSettings          *ScanRulesSettings       `json:"scan_rules_settings"`            // 按视频库根目录设置的扫描规则
+	SubPostProcessSettings     *SubPostProcessSettings  `json:"sub_post_process_settings"`      // 字幕写入后的处理步骤
+	SubCleanerSettings         *SubCleanerSettings      `json:"sub_cleaner_settings"`           // 剔除字幕中的广告、字幕组署名
+	AssStyleSettings           *AssStyleSettings        `json:"ass_style_settings"`             // ass/ssa 字幕的样式统一
+	AssFontsSettings           *AssFontsSettings        `json:"ass_fonts_settings"`             // ass/ssa 字幕使用的字体检查、打包
+	PeerLibrarySettings        *PeerLibrarySettings     `json:"peer_library_settings"`          // 局域网内多个实例之间共享字幕缓存
+	SupplierHealthSettings     *SupplierHealthSettings  `json:"supplier_health_settings"`       // 字幕源的健康统计、自动禁用
 }
 
 func NewAdvancedSettings() *AdvancedSettings {
 	return &AdvancedSettings{
-		ProxySettings:     NewProxySettings(false, "http", local_http_proxy_server.LocalHttpProxyPort, "127.0.0.1", "10809", "", ""),
-		TmdbApiSettings:   *NewTmdbApiSettings(false, "", false),
-		CustomVideoExts:   make([]string, 0),
-		Topic:             common.DownloadSubsPerSite,
-		SuppliersSettings: NewSuppliersSettings(),
-		ScanLogic:         NewScanLogic(false, false),
-		TaskQueue:         NewTaskQueue(),
-		DownloadFileCache: NewDownloadFileCache(),
+		ProxySettings:           NewProxySettings(false, "http", local_http_proxy_server.LocalHttpProxyPort, "127.0.0.1", "10809", "", ""),
+		TmdbApiSettings:         *NewTmdbApiSettings(false, "", false),
+		CustomVideoExts:         make([]string, 0),
+		Topic:                   common.DownloadSubsPerSite,
+		SuppliersSettings:       NewSuppliersSettings(),
+		ScanLogic:               NewScanLogic(false, false),
+		TaskQueue:               NewTaskQueue(),
+		DownloadFileCache:       NewDownloadFileCache(),
+		SubScoreSettings:        NewSubScoreSettings(),
+		NotifySettings:          NewNotifySettings(),
+		PostSaveHookSettings:    NewPostSaveHookSettings(),
+		IncomingWebhookSettings: NewIncomingWebhookSettings(),
+		FileWatcherSettings:     NewFileWatcherSettings(),
+		IncrementalScanSettings: NewIncrementalScanSettings(),
+		ScanRulesSettings:       NewScanRulesSettings(),
+		SubPostProcessSettings:  NewSubPostProcessSettings(),
+		SubCleanerSettings:      NewSubCleanerSettings(),
+		AssStyleSettings:        NewAssStyleSettings(),
+		AssFontsSettings:        NewAssFontsSettings(),
+		PeerLibrarySettings:     NewPeerLibrarySettings(),
+		SupplierHealthSettings:  NewSupplierHealthSettings(),
 	}
 }
@@ -0,0 +1,46 @@
+package settings
+
+import "strings"
+
+/*
+	AssFontsSettings ass/ssa 字幕使用的字体检查，电视上的播放器通常没有字幕组使用的字体
+	1. 列出字幕中使用的字体（样式中的 Fontname 以及对白中的 \fn），在 FontsDirPath 中查找，找不到的记录下来，可以通过 API 查看
+	2. OutputMode 不为空的时候，把找到的字体放到视频旁边的 fonts 目录，或者作为附件写入 mkv 中
+	3. SubsetFonts 为 true 的时候，只保留字幕中用到的字符，需要安装 fonttools 的 pyftsubset
+*/
+type AssFontsSettings struct {
+	Enable              bool   `json:"enable"`                  // 是否启用
+	FontsDirPath        string `json:"fonts_dir_path"`          // 本地字体的目录，会查找子目录
+	OutputMode          string `json:"output_mode"`             // 找到的字体的输出方式，见 AssFontsOutputModeXXX，为空则只检查
+	SubsetFonts         bool   `json:"subset_fonts"`            // 是否只保留用到的字符
+	SubsetToolPath      string `json:"subset_tool_path"`        // pyftsubset 的路径，为空则从 PATH 中查找
+	MKVPropEditToolPath string `json:"mkv_prop_edit_tool_path"` // mkvpropedit 的路径，为空则从 PATH 中查找
+}
+
+func NewAssFontsSettings() *AssFontsSettings {
+	return &AssFontsSettings{
+		OutputMode: AssFontsOutputModeNone,
+	}
+}
+
+func (a *AssFontsSettings) Check() {
+
+	a.FontsDirPath = strings.TrimSpace(a.FontsDirPath)
+	switch a.OutputMode {
+	case AssFontsOutputModeNone, AssFontsOutputModeFontsFolder, AssFontsOutputModeMKVAttachment:
+	default:
+		a.OutputMode = AssFontsOutputModeNone
+	}
+	if strings.TrimSpace(a.SubsetToolPath) == "" {
+		a.SubsetToolPath = "pyftsubset"
+	}
+	if strings.TrimSpace(a.MKVPropEditToolPath) == "" {
+		a.MKVPropEditToolPath = "mkvpropedit"
+	}
+}
+
+const (
+	AssFontsOutputModeNone          = ""               // 只检查，不输出字体
+	AssFontsOutputModeFontsFolder   = "fonts_folder"   // 放到视频旁边的 fonts 目录
+	AssFontsOutputModeMKVAttachment = "mkv_attachment" // 作为附件写入 mkv，不是 mkv 的视频放到 fonts 目录
+)
@@ -0,0 +1,45 @@
+package settings
+
+import "strings"
+
+/*
+	AssStyleSettings ass/ssa 字幕的样式统一，切换剧集的时候字幕的字体、大小保持一致
+	1. PlayResX、PlayResY 不为 0 的时候，缩放到这个分辨率，所有样式的字号、边框、边距，以及对白中的 \pos \move \fs 等也会按比例缩放，保持原有的效果
+	2. 只修改主要对白的样式（使用最多、且不是特效、卡拉 OK 的样式），FontName、FontSize、Outline、Shadow 是缩放后分辨率下的值
+	3. FontMap 替换所有样式以及 \fn 中的字体，比如播放器没有的字体
+*/
+type AssStyleSettings struct {
+	Enable     bool              `json:"enable"`      // 是否启用
+	PlayResX   int               `json:"play_res_x"`  // 缩放的目标分辨率，0 则不缩放
+	PlayResY   int               `json:"play_res_y"`  // 缩放的目标分辨率，0 则不缩放
+	FontName   string            `json:"font_name"`   // 主要对白的字体，为空则保持原样
+	FontSize   float64           `json:"font_size"`   // 主要对白的字号，小于等于 0 则保持原样，双语字幕的多个样式会保持原有的比例
+	Outline    float64           `json:"outline"`     // 主要对白的边框宽度，小于 0 则保持原样
+	Shadow     float64           `json:"shadow"`      // 主要对白的阴影距离，小于 0 则保持原样
+	FontMap    map[string]string `json:"font_map"`    // 字体的替换，Key 是原始的字体名称，不区分大小写
+	KeepBackup bool              `json:"keep_backup"` // 修改前是否保留原始的字幕文件，后缀名见 ass_style_normalizer.BackUpExt
+}
+
+func NewAssStyleSettings() *AssStyleSettings {
+	return &AssStyleSettings{
+		PlayResX:   1920,
+		PlayResY:   1080,
+		Outline:    -1,
+		Shadow:     -1,
+		FontMap:    make(map[string]string),
+		KeepBackup: true,
+	}
+}
+
+func (a *AssStyleSettings) Check() {
+
+	// 需要同时设置才能缩放
+	if a.PlayResX <= 0 || a.PlayResY <= 0 {
+		a.PlayResX = 0
+		a.PlayResY = 0
+	}
+	a.FontName = strings.TrimSpace(a.FontName)
+	if a.FontMap == nil {
+		a.FontMap = make(map[string]string)
+	}
+}
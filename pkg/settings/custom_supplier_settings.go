@@ -0,0 +1,218 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+)
+
+/*
+	CustomSupplierSettings 完全由设置描述的字幕源，接入私有的字幕索引站点不需要修改代码
+	1. 搜索地址是模板，支持的占位符见 custom 字幕源的说明，电影先按 IMDB ID 搜索，没有结果再按 名称 + 年份 搜索，连续剧使用 Episode 开头的模板
+	2. ResponseType 为 json 的时候，选择器是 gjson 的路径（简单的 JSONPath 也可以，会被转换），为 html 的时候，是 CSS 选择器，后面可以加 @属性名，比如 a.download@href
+	gjson 的路径用 . 分隔，不需要 $ 开头，数组的下标直接写数字，比如 JSONPath 的 $.data[0].name 对应 data.0.name
+	以 $ 开头的选择器认为是 JSONPath，Check 时转换为 gjson 的路径，只支持 . [下标] [*] ['名称']，过滤、递归等无法转换的，这个字幕源会被去掉
+	列表使用 # 取每个元素的字段，比如 data.#.name，特殊字符用 \ 转义，完整的语法见 https://github.com/tidwall/gjson/blob/master/SYNTAX.md
+	3. 下载地址从搜索结果中提取，设置了 Selectors.DetailDownloadUrl 的时候，提取到的是详情页的地址，再从详情页中提取下载地址
+	4. RequestInterval 限制两次请求之间的间隔，DailyDownloadLimit 限制每日的下载次数，下载次数同样记录在 DailyDownloadInfo 中
+*/
+type CustomSupplierSettings struct {
+	Enabled                 bool                    `json:"enabled"`
+	Name                    string                  `json:"name"`                        // 字幕源的名称，只能是小写字母、数字、下划线，不能重复，也不能与内置的字幕源同名
+	RootUrl                 string                  `json:"root_url"`                    // 相对地址会拼接这个前缀
+	CheckAliveUrl           string                  `json:"check_alive_url"`             // 检查是否可用的地址，为空则使用 RootUrl
+	Headers                 map[string]string       `json:"headers"`                     // 每个请求都需要带上的 Header，比如 Api-Key
+	ResponseType            string                  `json:"response_type"`               // json 或者 html，默认 json
+	SearchMovieByImdbUrl    string                  `json:"search_movie_by_imdb_url"`    // 比如 /api/search?imdb={imdb_id}
+	SearchMovieByTitleUrl   string                  `json:"search_movie_by_title_url"`   // 比如 /api/search?q={title}&year={year}
+	SearchEpisodeByImdbUrl  string                  `json:"search_episode_by_imdb_url"`  // 比如 /api/search?imdb={imdb_id}&s={season}&e={episode}
+	SearchEpisodeByTitleUrl string                  `json:"search_episode_by_title_url"` // 比如 /search?q={title}+S{season_pad}E{episode_pad}
+	Selectors               CustomSupplierSelectors `json:"selectors"`
+	RequestInterval         int                     `json:"request_interval"`     // 两次请求之间至少间隔的时间，毫秒
+	DailyDownloadLimit      int                     `json:"daily_download_limit"` // 每日的下载次数，小于等于 0 则不限制
+	TimeOut                 int                     `json:"time_out"`             // 一个请求的超时时间，秒，小于等于 0 则使用默认值
+}
+
+/*
+	CustomSupplierSelectors 除了 Results、DetailDownloadUrl，其他的都是相对于一个搜索结果的
+	比如 json 返回的是 {"data":[{"name":"xx","files":[{"url":"/dl/1"}]}]}，那么 Results 为 data，Title 为 name，DownloadUrl 为 files.0.url
+*/
+type CustomSupplierSelectors struct {
+	Results           string `json:"results"`             // 搜索结果的列表，json 的时候为空则认为返回的就是列表
+	Title             string `json:"title"`               // 字幕的名称
+	Season            string `json:"season"`              // 为空或者取不到的时候，从字幕的名称中解析
+	Episode           string `json:"episode"`             // 为空或者取不到的时候，从字幕的名称中解析
+	DownloadUrl       string `json:"download_url"`        // 字幕文件的下载地址，或者详情页的地址
+	DetailDownloadUrl string `json:"detail_download_url"` // 在详情页中提取下载地址，为空则 DownloadUrl 就是下载地址
+}
+
+// jsonPath2GJson 把 JSONPath 写法的选择器转换为 gjson 的路径，返回无法转换的选择器的说明
+func (c *CustomSupplierSelectors) jsonPath2GJson() []string {
+
+	warnings := make([]string, 0)
+	for _, one := range []struct {
+		name     string
+		selector *string
+	}{
+		{"results", &c.Results},
+		{"title", &c.Title},
+		{"season", &c.Season},
+		{"episode", &c.Episode},
+		{"download_url", &c.DownloadUrl},
+		{"detail_download_url", &c.DetailDownloadUrl},
+	} {
+		gjsonPath, err := jsonPath2GJsonPath(*one.selector)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("selectors.%s %q is not supported, %v, use a gjson path instead", one.name, *one.selector, err))
+			continue
+		}
+		*one.selector = gjsonPath
+	}
+	return warnings
+}
+
+/*
+	jsonPath2GJsonPath 不是 $ 开头的认为已经是 gjson 的路径，原样返回
+	$.data[0].name -> data.0.name，$.data[*].name -> data.#.name，$['data'][0] -> data.0，$ -> 空（整个返回）
+*/
+func jsonPath2GJsonPath(jsonPath string) (string, error) {
+
+	jsonPath = strings.TrimSpace(jsonPath)
+	if strings.HasPrefix(jsonPath, "$") == false {
+		return jsonPath, nil
+	}
+	if strings.Contains(jsonPath, "..") == true {
+		return "", errors.New("recursive descent is not supported")
+	}
+	parts := make([]string, 0)
+	rest := jsonPath[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" || name == "*" {
+				return "", errors.New("wildcard or empty name is not supported")
+			}
+			parts = append(parts, escapeGJsonName(name))
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return "", errors.New("missing ]")
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			if inner == "*" {
+				parts = append(parts, "#")
+			} else if jsonPathIndexRegex.MatchString(inner) == true {
+				parts = append(parts, inner)
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				parts = append(parts, escapeGJsonName(inner[1:len(inner)-1]))
+			} else {
+				return "", errors.New("only [index], [*] and ['name'] are supported")
+			}
+			rest = rest[end+1:]
+		default:
+			return "", errors.New("unexpected " + string(rest[0]))
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// escapeGJsonName gjson 路径中有特殊含义的字符需要用 \ 转义
+func escapeGJsonName(name string) string {
+
+	var sb strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`.*?|#@!\`, r) == true {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+/*
+	checkCustomSuppliers 去掉名称不合法、与内置字幕源重名、重复以及没有任何搜索模板的字幕源
+	去掉的原因在第二个返回值中，settings 中没有日志，由使用的地方输出警告
+*/
+func checkCustomSuppliers(customSuppliers []CustomSupplierSettings) ([]CustomSupplierSettings, []string) {
+
+	names := make(map[string]bool)
+	outSuppliers := make([]CustomSupplierSettings, 0)
+	warnings := make([]string, 0)
+	for index, one := range customSuppliers {
+		one.Name = strings.ToLower(strings.TrimSpace(one.Name))
+		if customSupplierNameRegex.MatchString(one.Name) == false {
+			warnings = append(warnings, fmt.Sprintf("custom_suppliers[%d] name %q is invalid, only a-z 0-9 _ are allowed, skip", index, one.Name))
+			continue
+		}
+		if isBuiltInSupplierName(one.Name) == true {
+			warnings = append(warnings, fmt.Sprintf("custom_suppliers[%d] name %q is the same as a built-in supplier, skip", index, one.Name))
+			continue
+		}
+		if _, found := names[one.Name]; found == true {
+			warnings = append(warnings, fmt.Sprintf("custom_suppliers[%d] name %q is duplicated, skip", index, one.Name))
+			continue
+		}
+		one.RootUrl = strings.TrimRight(strings.TrimSpace(one.RootUrl), "/")
+		one.ResponseType = strings.ToLower(strings.TrimSpace(one.ResponseType))
+		if one.ResponseType != CustomSupplierResponseHtml {
+			one.ResponseType = CustomSupplierResponseJson
+		}
+		if one.ResponseType == CustomSupplierResponseJson {
+			selectorWarnings := one.Selectors.jsonPath2GJson()
+			if len(selectorWarnings) > 0 {
+				for _, selectorWarning := range selectorWarnings {
+					warnings = append(warnings, fmt.Sprintf("custom_suppliers[%d] %q %s, skip", index, one.Name, selectorWarning))
+				}
+				continue
+			}
+		}
+		if one.SearchMovieByImdbUrl == "" && one.SearchMovieByTitleUrl == "" &&
+			one.SearchEpisodeByImdbUrl == "" && one.SearchEpisodeByTitleUrl == "" {
+			warnings = append(warnings, fmt.Sprintf("custom_suppliers[%d] %q has no search url, skip", index, one.Name))
+			continue
+		}
+		if one.Headers == nil {
+			one.Headers = make(map[string]string)
+		}
+		if one.RequestInterval < 0 {
+			one.RequestInterval = 0
+		}
+		names[one.Name] = true
+		outSuppliers = append(outSuppliers, one)
+	}
+	return outSuppliers, warnings
+}
+
+// isBuiltInSupplierName 与内置的字幕源同名，虽然字幕源的名称会加上前缀，同名的话在设置、日志中很容易混淆
+func isBuiltInSupplierName(name string) bool {
+
+	for _, builtInName := range []string{
+		common.SubSiteChineseSubFinder, common.SubSiteZiMuKu, common.SubSiteSubHd, common.SubSiteShooter,
+		common.SubSiteXunLei, common.SubSiteAssrt, common.SubSiteA4K, common.SubSiteSubtitleBest,
+		common.SubSitePeerLibrary, common.SubSiteLocalFolder, common.SubSiteOpenSubtitles,
+	} {
+		if name == builtInName {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	CustomSupplierResponseJson = "json"
+	CustomSupplierResponseHtml = "html"
+)
+
+var (
+	customSupplierNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+	jsonPathIndexRegex      = regexp.MustCompile(`^[0-9]+$`)
+)
@@ -0,0 +1,29 @@
+package settings
+
+// FileWatcherSettings 监控电影、连续剧目录，新的视频复制完成后立即加入下载队列，不需要等待定时扫描
+type FileWatcherSettings struct {
+	Enable                 bool     `json:"enable"`                   // 是否启用
+	DebounceSeconds        int      `json:"debounce_seconds"`         // 视频文件大小在这个时间内没有变化，才认为复制完成了
+	PollingIntervalMinutes int      `json:"polling_interval_minutes"` // 无法使用 inotify 的目录（网络挂载），定时轮询的间隔
+	PollingPaths           []string `json:"polling_paths"`            // 强制使用轮询的目录，比如 inotify 没有报错但是收不到事件的挂载点
+}
+
+func NewFileWatcherSettings() *FileWatcherSettings {
+	return &FileWatcherSettings{
+		DebounceSeconds:        10,
+		PollingIntervalMinutes: 10,
+		PollingPaths:           make([]string, 0),
+	}
+}
+
+func (f *FileWatcherSettings) Check() {
+	if f.DebounceSeconds < 1 || f.DebounceSeconds > 600 {
+		f.DebounceSeconds = 10
+	}
+	if f.PollingIntervalMinutes < 1 || f.PollingIntervalMinutes > 24*60 {
+		f.PollingIntervalMinutes = 10
+	}
+	if f.PollingPaths == nil {
+		f.PollingPaths = make([]string, 0)
+	}
+}
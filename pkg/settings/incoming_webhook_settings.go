@@ -0,0 +1,17 @@
+package settings
+
+// IncomingWebhookSettings 接收 Sonarr、Radarr、Emby、Jellyfin 的 Webhook，视频入库后立即添加下载任务
+type IncomingWebhookSettings struct {
+	Enable bool `json:"enable"` // 是否启用
+	/*
+		本程序的物理路径 -> Sonarr、Radarr、媒体服务器中看到的路径，与 EmbySettings 中的路径映射方向一致
+		比如 X:\连续剧 -> /tv，没有匹配上的路径会原样使用
+	*/
+	PathsMapping map[string]string `json:"paths_mapping"`
+}
+
+func NewIncomingWebhookSettings() *IncomingWebhookSettings {
+	return &IncomingWebhookSettings{
+		PathsMapping: make(map[string]string),
+	}
+}
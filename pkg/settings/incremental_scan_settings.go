@@ -0,0 +1,19 @@
+package settings
+
+// IncrementalScanSettings 增量扫描，使用持久化的视频索引，只处理有变化的目录
+type IncrementalScanSettings struct {
+	Enable               bool `json:"enable"`                  // 是否启用
+	FullScanIntervalDays int  `json:"full_scan_interval_days"` // 间隔多少天进行一次全量扫描，修正索引可能遗漏的变化（比如原地覆盖写入的视频）
+}
+
+func NewIncrementalScanSettings() *IncrementalScanSettings {
+	return &IncrementalScanSettings{
+		FullScanIntervalDays: 7,
+	}
+}
+
+func (i *IncrementalScanSettings) Check() {
+	if i.FullScanIntervalDays < 1 || i.FullScanIntervalDays > 90 {
+		i.FullScanIntervalDays = 7
+	}
+}
@@ -0,0 +1,11 @@
+package settings
+
+/*
+	LocalFolderSettings 本地（或者 NAS 上）的字幕库作为字幕源，不需要联网
+	FolderPaths 中的字幕文件、字幕压缩包会被索引，按字幕旁边 nfo 中的 IMDB、TMDB ID，或者从文件名解析出的名称、年份、季、集与视频匹配
+*/
+type LocalFolderSettings struct {
+	Enabled              bool     `json:"enabled"`
+	FolderPaths          []string `json:"folder_paths"`           // 字幕库的目录，会查找子目录
+	IndexRefreshInterval int      `json:"index_refresh_interval"` // 重新建立索引的间隔，分钟，小于等于 0 则使用默认值
+}
@@ -0,0 +1,14 @@
+package settings
+
+/*
+	OpenSubtitlesSettings OpenSubtitles.com 的 REST API，主要用于下载英文等非中文的字幕，作为双语合并的参考
+	ApiKey 需要在 OpenSubtitles.com 的个人设置中申请，UserName、Password 不填写也可以使用，但是每日的下载次数会少很多
+	Languages 中的非中文字幕（比如 en）不参与评分，保存到参考字幕的缓存中，中文字幕与其他字幕源的一起参与评分
+*/
+type OpenSubtitlesSettings struct {
+	Enabled   bool     `json:"enabled"`
+	ApiKey    string   `json:"api_key"`
+	UserName  string   `json:"user_name"`
+	Password  string   `json:"password"`
+	Languages []string `json:"languages"` // 需要下载的语言，ISO 639-1，比如 en、zh-cn、zh-tw，为空则只下载 en
+}
@@ -0,0 +1,54 @@
+package settings
+
+import "strings"
+
+/*
+	PeerLibrarySettings 局域网内多个本程序的实例之间，共享字幕缓存（cache/CSF-ShareSubCache）中已经确认过的字幕
+	1. ServeEnabled 为 true 并且设置了 ServeToken 的时候，对外提供只读的接口 /peer-library/v1，按视频的特征码，或者 IMDB ID + 季 + 集 查询字幕
+		开启了字幕共享（ShareSubEnabled）的时候，下载后评分选中的字幕会复制到字幕缓存中，对外提供的就是这些字幕
+	2. PeerEnabled 为 true 的时候，新增一个字幕源，下载字幕时先去 Peers 中查询，找到的字幕与其他字幕源的一起参与评分
+	3. SkipOthersWhenFound 为 true 的时候，Peers 中找到了字幕，就不再去其他字幕源搜索
+*/
+type PeerLibrarySettings struct {
+	ServeEnabled        bool              `json:"serve_enabled"`          // 是否对外提供字幕缓存的查询
+	ServeToken          string            `json:"serve_token"`            // 对外提供查询时需要的 Token，为空则拒绝访问，Peers 中需要填写相同的 Token
+	PeerEnabled         bool              `json:"peer_enabled"`           // 是否从 Peers 中查询字幕
+	Peers               []PeerLibraryPeer `json:"peers"`                  // 其他实例，按顺序查询
+	SkipOthersWhenFound bool              `json:"skip_others_when_found"` // Peers 中找到了字幕，就不再去其他字幕源搜索
+	TimeOut             int               `json:"time_out"`               // 请求一个 Peer 的超时时间，秒
+}
+
+// PeerLibraryPeer 一个其他的实例
+type PeerLibraryPeer struct {
+	Name  string `json:"name"`  // 名称，只用于日志
+	Url   string `json:"url"`   // 实例的地址，比如 http://192.168.1.10:19035
+	Token string `json:"token"` // 对方设置的 ServeToken
+}
+
+func NewPeerLibrarySettings() *PeerLibrarySettings {
+	return &PeerLibrarySettings{
+		Peers:   make([]PeerLibraryPeer, 0),
+		TimeOut: 5,
+	}
+}
+
+func (p *PeerLibrarySettings) Check() {
+
+	p.ServeToken = strings.TrimSpace(p.ServeToken)
+	peers := make([]PeerLibraryPeer, 0)
+	for _, peer := range p.Peers {
+		peer.Url = strings.TrimRight(strings.TrimSpace(peer.Url), "/")
+		if peer.Url == "" {
+			continue
+		}
+		peer.Token = strings.TrimSpace(peer.Token)
+		if strings.TrimSpace(peer.Name) == "" {
+			peer.Name = peer.Url
+		}
+		peers = append(peers, peer)
+	}
+	p.Peers = peers
+	if p.TimeOut <= 0 {
+		p.TimeOut = 5
+	}
+}
@@ -0,0 +1,49 @@
+package settings
+
+// PostSaveHookSettings 字幕保存到视频旁边后，需要执行的后续处理（回调）
+type PostSaveHookSettings struct {
+	Enable bool           `json:"enable"` // 是否启用
+	Hooks  []PostSaveHook `json:"hooks"`  // 需要执行的回调，按顺序执行
+}
+
+/*
+	PostSaveHook 一个回调
+	http    向 Url POST 一个 JSON，内容见 post_save_hook.Payload
+	command 执行外部的命令，视频、字幕等信息通过 CSF_ 开头的环境变量传递
+*/
+type PostSaveHook struct {
+	Name           string            `json:"name"`            // 回调的名称，用于区分
+	Enable         bool              `json:"enable"`          // 是否启用
+	Type           string            `json:"type"`            // http or command
+	Url            string            `json:"url"`             // http 回调的地址
+	Headers        map[string]string `json:"headers"`         // http 回调额外的 Header
+	Command        string            `json:"command"`         // 需要执行的命令
+	Args           []string          `json:"args"`            // 命令的参数
+	TimeoutSeconds int               `json:"timeout_seconds"` // 单次执行的超时时间，秒
+	RetryTimes     int               `json:"retry_times"`     // 失败后重试的次数
+}
+
+func NewPostSaveHookSettings() *PostSaveHookSettings {
+	return &PostSaveHookSettings{
+		Hooks: make([]PostSaveHook, 0),
+	}
+}
+
+func (p *PostSaveHookSettings) Check() {
+
+	for i := range p.Hooks {
+		if p.Hooks[i].TimeoutSeconds <= 0 {
+			p.Hooks[i].TimeoutSeconds = DefaultPostSaveHookTimeoutSeconds
+		}
+		if p.Hooks[i].RetryTimes < 0 {
+			p.Hooks[i].RetryTimes = 0
+		}
+	}
+}
+
+const (
+	PostSaveHookTypeHttp    = "http"
+	PostSaveHookTypeCommand = "command"
+
+	DefaultPostSaveHookTimeoutSeconds = 30
+)
@@ -0,0 +1,158 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	GetRedactedSettings 当前的设置，密码、Token、API Key 等敏感的信息替换为 ******，给非管理员、登录的回复、API 使用
+	需要编辑设置的管理员使用 GetNoPasswordSettings
+*/
+func (s *Settings) GetRedactedSettings() *Settings {
+
+	nowSettings := s.GetNoPasswordSettings()
+	nowSettings.Redact()
+	return nowSettings
+}
+
+// Redact 把所有不为空的敏感字段替换为 ******
+func (s *Settings) Redact() {
+
+	s.walkSecrets(func(key string, secret *string) {
+		if *secret != "" {
+			*secret = noPassword4Show
+		}
+	})
+}
+
+/*
+	RestoreRedacted 保存设置的时候，还是 ****** 的敏感字段（Web 端拿到的是打码后的设置，没有修改过）使用 oldSettings 中的值
+	列表中的项按名称（没有名称的按地址）对应，改名后需要重新填写
+*/
+func (s *Settings) RestoreRedacted(oldSettings *Settings) {
+
+	if oldSettings == nil {
+		return
+	}
+	oldSecrets := make(map[string]string)
+	oldSettings.walkSecrets(func(key string, secret *string) {
+		oldSecrets[key] = *secret
+	})
+	s.walkSecrets(func(key string, secret *string) {
+		if *secret == noPassword4Show {
+			*secret = oldSecrets[key]
+		}
+	})
+}
+
+/*
+	walkSecrets 遍历所有的敏感字段，key 是这个字段在设置中的位置，新增了敏感的字段需要在这里添加
+	通知渠道的 URL 中包含了 Token，所以整个 URL 都认为是敏感的
+	自定义字幕源的地址模板中，只有名称像密钥的查询参数（比如 ?apikey=）是敏感的，见 walkUrlQuerySecrets
+*/
+func (s *Settings) walkSecrets(fn func(key string, secret *string)) {
+
+	if s.UserInfo != nil {
+		fn("user_info.password", &s.UserInfo.Password)
+	}
+	if s.EmbySettings != nil {
+		fn("emby_settings.api_key", &s.EmbySettings.APIKey)
+	}
+	if s.DeveloperSettings != nil {
+		fn("developer_settings.bark_server_address", &s.DeveloperSettings.BarkServerAddress)
+	}
+	if s.SubtitleSources != nil {
+		fn("subtitle_sources.assrt_settings.token", &s.SubtitleSources.AssrtSettings.Token)
+		fn("subtitle_sources.subtitle_best_settings.api_key", &s.SubtitleSources.SubtitleBestSettings.ApiKey)
+		fn("subtitle_sources.opensubtitles_settings.api_key", &s.SubtitleSources.OpenSubtitlesSettings.ApiKey)
+		fn("subtitle_sources.opensubtitles_settings.password", &s.SubtitleSources.OpenSubtitlesSettings.Password)
+		for i := range s.SubtitleSources.CustomSuppliers {
+			customSupplier := &s.SubtitleSources.CustomSuppliers[i]
+			keyPrefix := fmt.Sprintf("subtitle_sources.custom_suppliers[%s]", customSupplier.Name)
+			walkMapSecrets(keyPrefix+".headers", customSupplier.Headers, fn)
+			walkUrlQuerySecrets(keyPrefix+".root_url", &customSupplier.RootUrl, fn)
+			walkUrlQuerySecrets(keyPrefix+".check_alive_url", &customSupplier.CheckAliveUrl, fn)
+			walkUrlQuerySecrets(keyPrefix+".search_movie_by_imdb_url", &customSupplier.SearchMovieByImdbUrl, fn)
+			walkUrlQuerySecrets(keyPrefix+".search_movie_by_title_url", &customSupplier.SearchMovieByTitleUrl, fn)
+			walkUrlQuerySecrets(keyPrefix+".search_episode_by_imdb_url", &customSupplier.SearchEpisodeByImdbUrl, fn)
+			walkUrlQuerySecrets(keyPrefix+".search_episode_by_title_url", &customSupplier.SearchEpisodeByTitleUrl, fn)
+		}
+	}
+	if s.ExperimentalFunction != nil {
+		fn("experimental_function.api_key_settings.key", &s.ExperimentalFunction.ApiKeySettings.Key)
+		fn("experimental_function.share_sub_settings.sub_share_center.sender_email_pwd", &s.ExperimentalFunction.ShareSubSettings.SubShareCenter.SenderEmailPwd)
+		fn("experimental_function.share_sub_settings.http_upload_token", &s.ExperimentalFunction.ShareSubSettings.HttpUploadToken)
+	}
+	if s.AdvancedSettings == nil {
+		return
+	}
+	if s.AdvancedSettings.ProxySettings != nil {
+		fn("advanced_settings.proxy_settings.input_proxy_password", &s.AdvancedSettings.ProxySettings.InputProxyPassword)
+	}
+	fn("advanced_settings.tmdb_api_settings.api_key", &s.AdvancedSettings.TmdbApiSettings.ApiKey)
+	if s.AdvancedSettings.NotifySettings != nil {
+		for i := range s.AdvancedSettings.NotifySettings.Channels {
+			fn(fmt.Sprintf("advanced_settings.notify_settings.channels[%s].url", s.AdvancedSettings.NotifySettings.Channels[i].Name),
+				&s.AdvancedSettings.NotifySettings.Channels[i].Url)
+		}
+	}
+	if s.AdvancedSettings.PostSaveHookSettings != nil {
+		for i := range s.AdvancedSettings.PostSaveHookSettings.Hooks {
+			walkMapSecrets(fmt.Sprintf("advanced_settings.post_save_hook_settings.hooks[%s].headers", s.AdvancedSettings.PostSaveHookSettings.Hooks[i].Name),
+				s.AdvancedSettings.PostSaveHookSettings.Hooks[i].Headers, fn)
+		}
+	}
+	if s.AdvancedSettings.PeerLibrarySettings != nil {
+		fn("advanced_settings.peer_library_settings.serve_token", &s.AdvancedSettings.PeerLibrarySettings.ServeToken)
+		for i := range s.AdvancedSettings.PeerLibrarySettings.Peers {
+			fn(fmt.Sprintf("advanced_settings.peer_library_settings.peers[%s].token", s.AdvancedSettings.PeerLibrarySettings.Peers[i].Url),
+				&s.AdvancedSettings.PeerLibrarySettings.Peers[i].Token)
+		}
+	}
+}
+
+// walkMapSecrets Header 之类的 map，每一个值都认为是敏感的
+func walkMapSecrets(key string, secrets map[string]string, fn func(key string, secret *string)) {
+
+	for k, v := range secrets {
+		secret := v
+		fn(key+"."+k, &secret)
+		secrets[k] = secret
+	}
+}
+
+/*
+	walkUrlQuerySecrets 地址（模板）中名称像密钥的查询参数，比如 ?apikey=xxx&q={title} 中的 apikey，其他部分原样保留
+	地址模板中有 {title} 之类的占位符，不能用 url.Values 重新编码，所以按 & = 拆分后原样拼接回去
+*/
+func walkUrlQuerySecrets(key string, urlTemplate *string, fn func(key string, secret *string)) {
+
+	index := strings.Index(*urlTemplate, "?")
+	if index < 0 {
+		return
+	}
+	params := strings.Split((*urlTemplate)[index+1:], "&")
+	for i, param := range params {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || isSecretQueryName(parts[0]) == false {
+			continue
+		}
+		secret := parts[1]
+		fn(key+"."+parts[0], &secret)
+		params[i] = parts[0] + "=" + secret
+	}
+	*urlTemplate = (*urlTemplate)[:index+1] + strings.Join(params, "&")
+}
+
+// isSecretQueryName 查询参数的名称是否像密钥，比如 apikey、api_key、access_token、password、sign
+func isSecretQueryName(name string) bool {
+
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	for _, suffix := range []string{"key", "token", "secret", "password", "passwd", "pwd"} {
+		if strings.HasSuffix(name, suffix) == true {
+			return true
+		}
+	}
+	return name == "sign" || name == "signature" || name == "auth"
+}
@@ -0,0 +1,48 @@
+package settings
+
+import "strings"
+
+// ScanRulesSettings 扫描规则，按视频库的根目录设置包含、排除的规则，以及覆盖部分全局的设置
+type ScanRulesSettings struct {
+	Enable        bool       `json:"enable"`          // 是否启用 Rules
+	UseIgnoreFile bool       `json:"use_ignore_file"` // 是否读取目录中的 .csfignore 文件，与 Enable 无关
+	Rules         []ScanRule `json:"rules"`           // 每个视频库根目录的规则
+}
+
+/*
+	ScanRule 一个视频库根目录的规则，规则匹配的是相对于根目录的路径（使用 / 分隔），不区分大小写
+	1. 没有 / 的通配符，匹配路径中的任意一层，比如 Extras、Featurettes、*sample*
+	2. 有 / 的通配符，从根目录开始匹配，比如 Documentaries/*、纪录片/BBC
+	3. re: 开头的是正则表达式，匹配整个相对路径，比如 re:(?i)\.(sample|trailer)\.
+	Includes 只对视频文件生效，不为空的时候，视频需要匹配其中一个才会处理
+*/
+type ScanRule struct {
+	RootDirPath            string   `json:"root_dir_path"`             // 视频库的根目录，电影、连续剧目录中的一个
+	Includes               []string `json:"includes"`                  // 包含的规则
+	Excludes               []string `json:"excludes"`                  // 排除的规则，匹配其中一个就跳过
+	SubNameFormatter       *int     `json:"sub_name_formatter"`        // 覆盖全局的字幕命名格式，为空则使用全局的设置
+	FixTimeLine            *bool    `json:"fix_time_line"`             // 覆盖全局的字幕时间轴校正，为空则使用全局的设置
+	DesChineseLanguageType *int     `json:"des_chinese_language_type"` // 覆盖全局的简繁转换的目标，0 是简体，1 是繁体，为空则使用全局的设置。只作用于中文字幕，其他语言的字幕不转换
+}
+
+func NewScanRulesSettings() *ScanRulesSettings {
+	return &ScanRulesSettings{
+		UseIgnoreFile: true,
+		Rules:         make([]ScanRule, 0),
+	}
+}
+
+func (s *ScanRulesSettings) Check() {
+	rules := make([]ScanRule, 0)
+	for _, rule := range s.Rules {
+		rule.RootDirPath = strings.TrimSpace(rule.RootDirPath)
+		if rule.RootDirPath == "" {
+			continue
+		}
+		if rule.DesChineseLanguageType != nil && (*rule.DesChineseLanguageType < 0 || *rule.DesChineseLanguageType > 1) {
+			rule.DesChineseLanguageType = nil
+		}
+		rules = append(rules, rule)
+	}
+	s.Rules = rules
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/strcut_json"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 )
 
 type Settings struct {
@@ -90,6 +91,11 @@ func SetConfigRootPath(configRootPath string) {
 	_configRootPath = configRootPath
 }
 
+// IsConfigRootPathSet 是否已经设置了配置文件的根目录，没有设置的时候调用 Get 会 panic
+func IsConfigRootPathSet() bool {
+	return _configRootPath != ""
+}
+
 func NewSettings(configRootDirFPath string) *Settings {
 
 	nowConfigFPath := filepath.Join(configRootDirFPath, configName)
@@ -170,6 +176,63 @@ func (s *Settings) Check() {
 	// 这里需要做一次 Default 的检查，因为有设置会被改写低于预期，至少要在 Default 之上
 	s.AdvancedSettings.TaskQueue.Check()
 	s.AdvancedSettings.DownloadFileCache.Check()
+	if s.AdvancedSettings.SubScoreSettings == nil {
+		s.AdvancedSettings.SubScoreSettings = NewSubScoreSettings()
+	}
+	s.AdvancedSettings.SubScoreSettings.Check()
+	if s.AdvancedSettings.NotifySettings == nil {
+		s.AdvancedSettings.NotifySettings = NewNotifySettings()
+	}
+	if s.AdvancedSettings.PostSaveHookSettings == nil {
+		s.AdvancedSettings.PostSaveHookSettings = NewPostSaveHookSettings()
+	}
+	s.AdvancedSettings.PostSaveHookSettings.Check()
+	if s.AdvancedSettings.IncomingWebhookSettings == nil {
+		s.AdvancedSettings.IncomingWebhookSettings = NewIncomingWebhookSettings()
+	}
+	if s.AdvancedSettings.FileWatcherSettings == nil {
+		s.AdvancedSettings.FileWatcherSettings = NewFileWatcherSettings()
+	}
+	s.AdvancedSettings.FileWatcherSettings.Check()
+	if s.AdvancedSettings.IncrementalScanSettings == nil {
+		s.AdvancedSettings.IncrementalScanSettings = NewIncrementalScanSettings()
+	}
+	s.AdvancedSettings.IncrementalScanSettings.Check()
+	if s.AdvancedSettings.ScanRulesSettings == nil {
+		s.AdvancedSettings.ScanRulesSettings = NewScanRulesSettings()
+	}
+	s.AdvancedSettings.ScanRulesSettings.Check()
+	if s.AdvancedSettings.SubPostProcessSettings == nil {
+		s.AdvancedSettings.SubPostProcessSettings = NewSubPostProcessSettings()
+	}
+	s.AdvancedSettings.SubPostProcessSettings.Check()
+	if s.AdvancedSettings.SubCleanerSettings == nil {
+		s.AdvancedSettings.SubCleanerSettings = NewSubCleanerSettings()
+	}
+	s.AdvancedSettings.SubCleanerSettings.Check()
+	if s.AdvancedSettings.AssStyleSettings == nil {
+		s.AdvancedSettings.AssStyleSettings = NewAssStyleSettings()
+	}
+	s.AdvancedSettings.AssStyleSettings.Check()
+	if s.AdvancedSettings.AssFontsSettings == nil {
+		s.AdvancedSettings.AssFontsSettings = NewAssFontsSettings()
+	}
+	s.AdvancedSettings.AssFontsSettings.Check()
+	if s.AdvancedSettings.PeerLibrarySettings == nil {
+		s.AdvancedSettings.PeerLibrarySettings = NewPeerLibrarySettings()
+	}
+	s.AdvancedSettings.PeerLibrarySettings.Check()
+	if s.AdvancedSettings.SupplierHealthSettings == nil {
+		s.AdvancedSettings.SupplierHealthSettings = NewSupplierHealthSettings()
+	}
+	s.AdvancedSettings.SupplierHealthSettings.Check()
+	if s.AdvancedSettings.SuppliersSettings != nil && s.AdvancedSettings.SuppliersSettings.OpenSubtitles == nil {
+		s.AdvancedSettings.SuppliersSettings.OpenSubtitles = NewOneSupplierSettings(common.SubSiteOpenSubtitles, common.SubOpenSubtitlesRootUrlDef, common.SubOpenSubtitlesSearchUrl, -1)
+	}
+	if s.SubtitleSources == nil {
+		s.SubtitleSources = NewSubtitleSources()
+	}
+	s.SubtitleSources.Check()
 
 }
 
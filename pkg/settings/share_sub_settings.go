@@ -1,5 +1,23 @@
 package settings
 
+/*
+	ShareSubSettings 字幕共享的设置，开启后会定时把缓存中还没有发送的字幕上传到字幕共享中心
+	下载后评分选中、写入视频旁边的字幕会复制到缓存中，等待上传
+	smtp 方式使用 SubShareCenter 中的邮箱设置，每一批字幕作为一封邮件的附件发送
+	http 方式 POST multipart/form-data 到 HttpUploadUrl，items 字段是字幕信息的 JSON，file_0、file_1 ... 是对应的字幕文件
+*/
 type ShareSubSettings struct {
-	ShareSubEnabled bool `json:"share_sub_enabled"`
+	ShareSubEnabled bool           `json:"share_sub_enabled"`
+	Transport       string         `json:"transport"`         // 上传的方式，见 ShareSubTransportXXX，为空则使用 smtp
+	SubShareCenter  SubShareCenter `json:"sub_share_center"`  // smtp 方式的邮箱设置
+	HttpUploadUrl   string         `json:"http_upload_url"`   // http 方式的上传地址
+	HttpUploadToken string         `json:"http_upload_token"` // http 方式的 Token，会放在 Authorization: Bearer 中
+	BatchSize       int            `json:"batch_size"`        // 每一批上传的字幕数量，小于等于 0 则使用默认值
+	RetryTimes      int            `json:"retry_times"`       // 一批上传失败后重试的次数，小于等于 0 则使用默认值
+	UploadLowTrust  bool           `json:"upload_low_trust"`  // 是否扫描并上传低可信度的字幕（视频目录中已有的字幕），默认不上传
 }
+
+const (
+	ShareSubTransportSMTP = "smtp"
+	ShareSubTransportHTTP = "http"
+)
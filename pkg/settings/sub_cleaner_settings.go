@@ -0,0 +1,53 @@
+package settings
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+	SubCleanerSettings 剔除字幕中的广告、字幕组署名等对白
+	内置的规则：网址、QQ 群、微信公众号等联系方式，字幕组署名之类的语句（只在字幕开头、结尾的几句对白中查找），超出视频时长的对白
+	CustomRegexes 是用户自定义的正则表达式，匹配对白的文本（去除了特效标签），任何位置都会生效
+*/
+type SubCleanerSettings struct {
+	Enable              bool     `json:"enable"`                 // 是否启用
+	UseBuiltInRules     bool     `json:"use_built_in_rules"`     // 是否使用内置的规则
+	EdgeDialogueCount   int      `json:"edge_dialogue_count"`    // 字幕组署名之类的语句，只在开头、结尾的这么多句对白中查找，避免误删正常的对白
+	RemoveOutOfDuration bool     `json:"remove_out_of_duration"` // 剔除开始时间超出视频时长的对白，需要 ffprobe
+	CustomRegexes       []string `json:"custom_regexes"`         // 自定义的正则表达式
+	KeepBackup          bool     `json:"keep_backup"`            // 修改前是否保留原始的字幕文件，后缀名见 sub_cleaner.BackUpExt
+}
+
+func NewSubCleanerSettings() *SubCleanerSettings {
+	return &SubCleanerSettings{
+		UseBuiltInRules:     true,
+		EdgeDialogueCount:   DefaultSubCleanerEdgeDialogueCount,
+		RemoveOutOfDuration: true,
+		CustomRegexes:       make([]string, 0),
+		KeepBackup:          true,
+	}
+}
+
+func (s *SubCleanerSettings) Check() {
+
+	if s.EdgeDialogueCount < 0 {
+		s.EdgeDialogueCount = DefaultSubCleanerEdgeDialogueCount
+	}
+	// 写错的正则表达式直接去掉，不然每个字幕都会报错
+	customRegexes := make([]string, 0)
+	for _, expr := range s.CustomRegexes {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		_, err := regexp.Compile(expr)
+		if err != nil {
+			continue
+		}
+		customRegexes = append(customRegexes, expr)
+	}
+	s.CustomRegexes = customRegexes
+}
+
+const DefaultSubCleanerEdgeDialogueCount = 10
@@ -0,0 +1,104 @@
+package settings
+
+import "strings"
+
+/*
+	SubPostProcessSettings 字幕写入到视频旁边后，按顺序执行的处理步骤
+	每个步骤是否真的执行，还要看对应功能本身的设置，比如时间轴校正、编码转换、简繁转换的开关
+	某一个步骤失败了，不会中断字幕的保存，后面的步骤继续执行，结果会记录到任务的历史中
+	双语合并、内嵌到视频默认不启用，内嵌需要重新封装视频文件，只支持 mkv
+*/
+type SubPostProcessSettings struct {
+	Stages []SubPostProcessStage `json:"stages"` // 处理的步骤，按顺序执行
+}
+
+// SubPostProcessStage 一个处理步骤
+type SubPostProcessStage struct {
+	Name   string `json:"name"`   // 步骤的名称，见 SubPostProcessStageXXX
+	Enable bool   `json:"enable"` // 是否启用
+}
+
+func NewSubPostProcessSettings() *SubPostProcessSettings {
+	return &SubPostProcessSettings{
+		Stages: defaultSubPostProcessStages(),
+	}
+}
+
+func (s *SubPostProcessSettings) Check() {
+
+	stages := make([]SubPostProcessStage, 0)
+	names := make(map[string]bool)
+	for _, stage := range s.Stages {
+		stage.Name = strings.TrimSpace(stage.Name)
+		if stage.Name == "" {
+			continue
+		}
+		// 同一个步骤只执行一次
+		if _, found := names[stage.Name]; found == true {
+			continue
+		}
+		names[stage.Name] = true
+		stages = append(stages, stage)
+	}
+	// 之前的版本没有这个设置，或者新增了步骤，补上缺少的，放到默认顺序中前一个步骤的后面，保持之前的执行顺序
+	prevName := ""
+	for _, stage := range defaultSubPostProcessStages() {
+		if _, found := names[stage.Name]; found == false {
+			stages = insertStageAfter(stages, prevName, stage)
+			names[stage.Name] = true
+		}
+		prevName = stage.Name
+	}
+	s.Stages = stages
+}
+
+// insertStageAfter 把 stage 插入到名称为 prevName 的步骤后面，prevName 为空的时候放到最前面
+func insertStageAfter(stages []SubPostProcessStage, prevName string, stage SubPostProcessStage) []SubPostProcessStage {
+
+	index := 0
+	if prevName != "" {
+		index = len(stages)
+		for i := range stages {
+			if stages[i].Name == prevName {
+				index = i + 1
+				break
+			}
+		}
+	}
+	newStages := make([]SubPostProcessStage, 0, len(stages)+1)
+	newStages = append(newStages, stages[:index]...)
+	newStages = append(newStages, stage)
+	newStages = append(newStages, stages[index:]...)
+	return newStages
+}
+
+/*
+	defaultSubPostProcessStages 默认的顺序：剔除广告 -> 时间轴校正 -> 双语合并 -> 样式统一 -> 字体检查 -> 格式转换 -> 编码转换 -> 简繁转换 -> 内嵌到视频
+	时间轴校正、编码转换、简繁转换与之前写死的顺序一致，格式转换会丢掉 ass 的样式，默认不启用
+	双语合并会改变字幕的内容，内嵌到视频会重写视频文件，默认不启用
+*/
+func defaultSubPostProcessStages() []SubPostProcessStage {
+	return []SubPostProcessStage{
+		{Name: SubPostProcessStageAdClean, Enable: true},
+		{Name: SubPostProcessStageTimelineFix, Enable: true},
+		{Name: SubPostProcessStageBilingualMerge, Enable: false},
+		{Name: SubPostProcessStageAssStyle, Enable: true},
+		{Name: SubPostProcessStageAssFonts, Enable: true},
+		{Name: SubPostProcessStageFormatConvert, Enable: false},
+		{Name: SubPostProcessStageChangeEncode, Enable: true},
+		{Name: SubPostProcessStageChsChtChange, Enable: true},
+		{Name: SubPostProcessStageEmbed, Enable: false},
+	}
+}
+
+const (
+	SubPostProcessStageAdClean        = "ad_clean"        // 剔除广告、字幕组署名
+	SubPostProcessStageTimelineFix    = "timeline_fix"    // 字幕时间轴校正
+	SubPostProcessStageBilingualMerge = "bilingual_merge" // 与视频旁边的外语字幕（或者下载时保存的参考字幕）合并为双语字幕
+	SubPostProcessStageAssStyle       = "ass_style"       // ass/ssa 字幕的样式统一
+	SubPostProcessStageAssFonts       = "ass_fonts"       // ass/ssa 字幕使用的字体检查、打包
+	SubPostProcessStageFormatConvert  = "format_convert"  // ass/ssa 字幕转换为 srt
+	SubPostProcessStageChangeEncode   = "change_encode"   // 字幕编码转换
+	SubPostProcessStageChsChtChange   = "chs_cht_change"  // 简繁转换
+	SubPostProcessStageEmbed          = "embed"           // 字幕作为字幕轨道封装进 mkv
+)
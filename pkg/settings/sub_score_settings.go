@@ -0,0 +1,66 @@
+package settings
+
+// SubScoreSettings 字幕评分系统的权重设置，每一项的权重范围 0 - 10，0 就是不参与评分
+type SubScoreSettings struct {
+	ReleaseNameWeight      float64 `json:"release_name_weight"`      // 字幕名称与视频文件名的相似度
+	ReleaseMatchWeight     float64 `json:"release_match_weight"`     // 字幕与视频的发布组、片源、版本是否一致
+	DialogueCoverageWeight float64 `json:"dialogue_coverage_weight"` // 字幕对白覆盖的时长与视频时长的匹配程度
+	TimelineMatchWeight    float64 `json:"timeline_match_weight"`    // 字幕与视频音频 VAD 的时间轴匹配程度，需要 ffmpeg，比较耗时，默认关闭
+	BilingualWeight        float64 `json:"bilingual_weight"`         // 是否是中文双语字幕
+	FormatWeight           float64 `json:"format_weight"`            // 字幕格式是否符合 SubTypePriority 的设置
+	SourceReputationWeight float64 `json:"source_reputation_weight"` // 字幕来源网站的信誉（优先级）
+	LineLengthWeight       float64 `json:"line_length_weight"`       // 每一句对白的长度是否正常
+	EncodingWeight         float64 `json:"encoding_weight"`          // 字幕是否存在乱码
+	UpgradeMinScoreDiff    float64 `json:"upgrade_min_score_diff"`   // 新下载的字幕评分需要比已有的字幕高出多少分，才会进行替换（升级），满分 100
+}
+
+func NewSubScoreSettings() *SubScoreSettings {
+	return &SubScoreSettings{
+		ReleaseNameWeight:      3,
+		ReleaseMatchWeight:     4,
+		DialogueCoverageWeight: 4,
+		TimelineMatchWeight:    0,
+		BilingualWeight:        5,
+		FormatWeight:           3,
+		SourceReputationWeight: 2,
+		LineLengthWeight:       1,
+		EncodingWeight:         4,
+		UpgradeMinScoreDiff:    5,
+	}
+}
+
+func (s *SubScoreSettings) Check() {
+
+	weights := []*float64{
+		&s.ReleaseNameWeight,
+		&s.ReleaseMatchWeight,
+		&s.DialogueCoverageWeight,
+		&s.TimelineMatchWeight,
+		&s.BilingualWeight,
+		&s.FormatWeight,
+		&s.SourceReputationWeight,
+		&s.LineLengthWeight,
+		&s.EncodingWeight,
+	}
+	allZero := true
+	for _, weight := range weights {
+		if *weight < 0 {
+			*weight = 0
+		}
+		if *weight > 10 {
+			*weight = 10
+		}
+		if *weight > 0 {
+			allZero = false
+		}
+	}
+	// 全部为 0 就没法评分了，恢复默认值
+	if allZero == true {
+		def := NewSubScoreSettings()
+		def.UpgradeMinScoreDiff = s.UpgradeMinScoreDiff
+		*s = *def
+	}
+	if s.UpgradeMinScoreDiff < 0 || s.UpgradeMinScoreDiff > 100 {
+		s.UpgradeMinScoreDiff = 5
+	}
+}
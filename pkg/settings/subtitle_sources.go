@@ -1,10 +1,29 @@
 package settings
 
 type SubtitleSources struct {
-	AssrtSettings        AssrtSettings        `json:"assrt_settings"`
-	SubtitleBestSettings SubtitleBestSettings `json:"subtitle_best_settings"`
+	AssrtSettings         AssrtSettings            `json:"assrt_settings"`
+	SubtitleBestSettings  SubtitleBestSettings     `json:"subtitle_best_settings"`
+	LocalFolderSettings   LocalFolderSettings      `json:"local_folder_settings"`
+	OpenSubtitlesSettings OpenSubtitlesSettings    `json:"opensubtitles_settings"`
+	CustomSuppliers       []CustomSupplierSettings `json:"custom_suppliers"` // 由设置描述的字幕源
+
+	customSupplierWarnings []string // Check 时去掉的 CustomSuppliers 以及原因
 }
 
 func NewSubtitleSources() *SubtitleSources {
-	return &SubtitleSources{}
+	return &SubtitleSources{
+		CustomSuppliers: make([]CustomSupplierSettings, 0),
+	}
+}
+
+func (s *SubtitleSources) Check() {
+	customSuppliers, warnings := checkCustomSuppliers(s.CustomSuppliers)
+	s.CustomSuppliers = customSuppliers
+	// 再次 Check 的时候不合法的已经去掉了，保留之前的原因
+	s.customSupplierWarnings = append(s.customSupplierWarnings, warnings...)
+}
+
+// CustomSupplierWarnings Check 时去掉的 CustomSuppliers 以及原因
+func (s *SubtitleSources) CustomSupplierWarnings() []string {
+	return s.customSupplierWarnings
 }
@@ -0,0 +1,43 @@
+package settings
+
+/*
+	SupplierHealthSettings 字幕源的健康统计，以及连续失败后的自动禁用
+	1. 每个字幕源每天的搜索次数、命中率、下载、被选中的次数、耗时、下载次数的使用情况都会记录在数据库中
+	2. AutoDisable 为 true 的时候，连续 FailureThreshold 次失败（网络、HTTP 状态码、下载次数用完的搜索错误，或者 CheckAlive 失败）后禁用这个字幕源 CoolDown 分钟
+	默认不开启，视频本身的问题（比如 找不到 IMDB ID）不算失败
+	3. 冷却结束后重新启用，成功一次就清零失败的次数，再失败一次就再次禁用
+*/
+type SupplierHealthSettings struct {
+	AutoDisable      bool `json:"auto_disable"`      // 是否自动禁用连续失败的字幕源
+	FailureThreshold int  `json:"failure_threshold"` // 连续失败多少次后禁用
+	CoolDown         int  `json:"cool_down"`         // 禁用多久，分钟
+	HistoryDays      int  `json:"history_days"`      // 保留多少天的统计
+}
+
+func NewSupplierHealthSettings() *SupplierHealthSettings {
+	return &SupplierHealthSettings{
+		AutoDisable:      false,
+		FailureThreshold: DefaultSupplierFailureThreshold,
+		CoolDown:         DefaultSupplierCoolDown,
+		HistoryDays:      DefaultSupplierHistoryDays,
+	}
+}
+
+func (s *SupplierHealthSettings) Check() {
+
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultSupplierFailureThreshold
+	}
+	if s.CoolDown <= 0 {
+		s.CoolDown = DefaultSupplierCoolDown
+	}
+	if s.HistoryDays <= 0 {
+		s.HistoryDays = DefaultSupplierHistoryDays
+	}
+}
+
+const (
+	DefaultSupplierFailureThreshold = 5
+	DefaultSupplierCoolDown         = 60
+	DefaultSupplierHistoryDays      = 30
+)
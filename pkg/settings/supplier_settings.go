@@ -5,13 +5,14 @@ import (
 )
 
 type SuppliersSettings struct {
-	Xunlei       *OneSupplierSettings `json:"xunlei"`
-	Shooter      *OneSupplierSettings `json:"shooter"`
-	Assrt        *OneSupplierSettings `json:"assrt"`
-	A4k          *OneSupplierSettings `json:"a4k"`
-	SubHD        *OneSupplierSettings `json:"subhd"`
-	Zimuku       *OneSupplierSettings `json:"zimuku"`
-	SubtitleBest *OneSupplierSettings `json:"subtitle_best"`
+	Xunlei        *OneSupplierSettings `json:"xunlei"`
+	Shooter       *OneSupplierSettings `json:"shooter"`
+	Assrt         *OneSupplierSettings `json:"assrt"`
+	A4k           *OneSupplierSettings `json:"a4k"`
+	SubHD         *OneSupplierSettings `json:"subhd"`
+	Zimuku        *OneSupplierSettings `json:"zimuku"`
+	SubtitleBest  *OneSupplierSettings `json:"subtitle_best"`
+	OpenSubtitles *OneSupplierSettings `json:"opensubtitles"`
 }
 
 func NewSuppliersSettings() *SuppliersSettings {
@@ -21,6 +22,8 @@ func NewSuppliersSettings() *SuppliersSettings {
 		Assrt:        NewOneSupplierSettings(common.SubSiteAssrt, common.SubAssrtRootUrlDef, "", -1),
 		A4k:          NewOneSupplierSettings(common.SubSiteA4K, common.SubA4kRootUrlDef, common.SubA4kSearchUrl, -1),
 		SubtitleBest: NewOneSupplierSettings(common.SubSiteSubtitleBest, common.SubSubtitleBestRootUrlDef, common.SubSubtitleBestSearchMovieUrl, -1),
+		// -1 的时候以 OpenSubtitles 返回的剩余下载次数为准
+		OpenSubtitles: NewOneSupplierSettings(common.SubSiteOpenSubtitles, common.SubOpenSubtitlesRootUrlDef, common.SubOpenSubtitlesSearchUrl, -1),
 		// 依然需要给出来，用于手动搜索字幕使用
 		SubHD:  NewOneSupplierSettings(common.SubSiteSubHd, common.SubSubHDRootUrlDef, common.SubSubHDSearchUrl, 20),
 		Zimuku: NewOneSupplierSettings(common.SubSiteZiMuKu, common.SubZiMuKuRootUrlDef, common.SubZiMuKuSearchFormatUrl, 20),
@@ -33,6 +36,11 @@ func (s *SuppliersSettings) ReSetSearchUrl() {
 	s.SubtitleBest.SearchUrl = common.SubSubtitleBestSearchMovieUrl
 	s.SubHD.SearchUrl = common.SubSubHDSearchUrl
 	s.Zimuku.SearchUrl = common.SubZiMuKuSearchFormatUrl
+	// 之前的版本没有 OpenSubtitles
+	if s.OpenSubtitles == nil {
+		s.OpenSubtitles = NewOneSupplierSettings(common.SubSiteOpenSubtitles, common.SubOpenSubtitlesRootUrlDef, common.SubOpenSubtitlesSearchUrl, -1)
+	}
+	s.OpenSubtitles.SearchUrl = common.SubOpenSubtitlesSearchUrl
 }
 
 type OneSupplierSettings struct {
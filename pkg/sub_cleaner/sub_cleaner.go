@@ -0,0 +1,237 @@
+package sub_cleaner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/regex_things"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+/*
+	Cleaner 剔除字幕中的广告、字幕组署名等对白
+	先在 subparser.FileInfo.Dialogues 中找出需要剔除的对白，然后在 Content 中删除对应的 Dialogue 行（ass/ssa）或者对白块（srt），其他内容保持原样
+*/
+type Cleaner struct {
+	rules             []rule
+	edgeDialogueCount int
+}
+
+// RemovedDialogue 一句被剔除的对白
+type RemovedDialogue struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Text      string `json:"text"` // 去除了特效标签的文本
+	Rule      string `json:"rule"` // 匹配上的规则名称
+}
+
+type rule struct {
+	name     string
+	re       *regexp.Regexp
+	edgeOnly bool // 只在开头、结尾的几句对白中查找
+}
+
+func NewCleaner(cleanerSettings *settings.SubCleanerSettings) *Cleaner {
+
+	c := Cleaner{
+		rules:             make([]rule, 0),
+		edgeDialogueCount: cleanerSettings.EdgeDialogueCount,
+	}
+	if cleanerSettings.UseBuiltInRules == true {
+		c.rules = append(c.rules, builtInRules...)
+	}
+	for i, expr := range cleanerSettings.CustomRegexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			// Check 的时候已经去除了写错的，这里忽略
+			continue
+		}
+		c.rules = append(c.rules, rule{name: fmt.Sprintf("custom_%d", i), re: re})
+	}
+	return &c
+}
+
+/*
+	Clean 返回剔除后的字幕内容，以及被剔除的对白，没有需要剔除的时候返回原始的 Content
+	videoDuration 视频的时长，秒，大于 0 的时候，开始时间超出视频时长的对白也会被剔除
+*/
+func (c *Cleaner) Clean(info *subparser.FileInfo, videoDuration float64) (string, []RemovedDialogue, error) {
+
+	removed := make([]RemovedDialogue, 0)
+	removeKeys := make(map[string]int)
+	isEdge := c.getEdgeDialogues(info.Dialogues)
+	for i, dialogue := range info.Dialogues {
+
+		text := dialogueText(dialogue)
+		ruleName := ""
+		if videoDuration > 0 {
+			startTime, err := pkg.ParseTime(dialogue.StartTime)
+			if err == nil && pkg.Time2SecondNumber(startTime) > videoDuration {
+				ruleName = "out_of_duration"
+			}
+		}
+		if ruleName == "" {
+			for _, oneRule := range c.rules {
+				if oneRule.edgeOnly == true && isEdge[i] == false {
+					continue
+				}
+				if oneRule.re.MatchString(text) == true {
+					ruleName = oneRule.name
+					break
+				}
+			}
+		}
+		if ruleName == "" {
+			continue
+		}
+		removeKeys[dialogueKey(info.Ext, dialogue)]++
+		removed = append(removed, RemovedDialogue{
+			StartTime: dialogue.StartTime,
+			EndTime:   dialogue.EndTime,
+			Text:      text,
+			Rule:      ruleName,
+		})
+	}
+	if len(removed) == 0 {
+		return info.Content, removed, nil
+	}
+
+	switch strings.ToLower(info.Ext) {
+	case ".ass", ".ssa":
+		return removeASSDialogues(info.Content, removeKeys), removed, nil
+	case ".srt":
+		return removeSRTDialogues(info.Content, removeKeys), removed, nil
+	default:
+		return "", nil, errors.New(fmt.Sprintf("sub_cleaner not support sub ext: %s", info.Ext))
+	}
+}
+
+// getEdgeDialogues 按开始时间排序后，开头、结尾的 edgeDialogueCount 句对白，ass 中对白的顺序不一定是时间顺序
+func (c *Cleaner) getEdgeDialogues(dialogues []subparser.OneDialogue) map[int]bool {
+
+	indexes := make([]int, len(dialogues))
+	startTimes := make([]float64, len(dialogues))
+	for i, dialogue := range dialogues {
+		indexes[i] = i
+		startTime, err := pkg.ParseTime(dialogue.StartTime)
+		if err == nil {
+			startTimes[i] = pkg.Time2SecondNumber(startTime)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return startTimes[indexes[i]] < startTimes[indexes[j]]
+	})
+	isEdge := make(map[int]bool)
+	for i, index := range indexes {
+		if i < c.edgeDialogueCount || i >= len(indexes)-c.edgeDialogueCount {
+			isEdge[index] = true
+		}
+	}
+	return isEdge
+}
+
+// dialogueText 对白的文本，去除 {\fn微软雅黑} 这样的特效标签，多行合并为一行
+func dialogueText(dialogue subparser.OneDialogue) string {
+
+	text := strings.Join(dialogue.Lines, " ")
+	text = regex_things.ReMatchBrace.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, `\N`, " ")
+	text = strings.ReplaceAll(text, `\n`, " ")
+	return strings.TrimSpace(text)
+}
+
+// dialogueKey 用于在 Content 中找到这句对白，ass 使用时间以及原始的文本，srt 使用索引以及时间
+func dialogueKey(ext string, dialogue subparser.OneDialogue) string {
+
+	if strings.ToLower(ext) == ".srt" {
+		return strconv.Itoa(dialogue.Index) + "|" + dialogue.StartTime + "|" + dialogue.EndTime
+	}
+	text := ""
+	if len(dialogue.Lines) > 0 {
+		text = dialogue.Lines[0]
+	}
+	return dialogue.StartTime + "|" + dialogue.EndTime + "|" + text
+}
+
+// removeASSDialogues 删除匹配的 Dialogue 行，使用与 ass 解析器相同的正则表达式
+func removeASSDialogues(content string, removeKeys map[string]int) string {
+
+	lines := strings.Split(content, "\n")
+	outLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimLine := strings.TrimRight(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(trimLine), "Dialogue:") == true {
+			matched := regex_things.ReMatchDialogueASS.FindStringSubmatch(trimLine)
+			if matched != nil {
+				key := matched[1] + "|" + matched[2] + "|" + matched[4]
+				if removeKeys[key] > 0 {
+					removeKeys[key]--
+					continue
+				}
+			}
+		}
+		outLines = append(outLines, line)
+	}
+	return strings.Join(outLines, "\n")
+}
+
+// removeSRTDialogues 删除匹配的对白块，从索引行开始到下一个对白块的索引行之前（包含空行）
+func removeSRTDialogues(content string, removeKeys map[string]int) string {
+
+	lines := strings.Split(content, "\n")
+	outLines := make([]string, 0, len(lines))
+	removing := false
+	for i, line := range lines {
+		key, isHeader := srtBlockKey(lines, i)
+		if isHeader == true {
+			removing = false
+			if removeKeys[key] > 0 {
+				removeKeys[key]--
+				removing = true
+			}
+		}
+		if removing == true {
+			continue
+		}
+		outLines = append(outLines, line)
+	}
+	return strings.Join(outLines, "\n")
+}
+
+// srtBlockKey 这一行是否是 srt 对白块的索引行（下一行是时间轴），与 srt 解析器的判断一致
+func srtBlockKey(lines []string, i int) (string, bool) {
+
+	index, err := strconv.Atoi(pkg.ReplaceSpecString(strings.TrimRight(lines[i], "\r"), ""))
+	if err != nil || i+1 >= len(lines) {
+		return "", false
+	}
+	timeLine := strings.TrimRight(lines[i+1], "\r")
+	matched := regex_things.ReMatchDialogueTimeSRT.FindAllStringSubmatch(timeLine, -1)
+	if matched == nil || len(matched) < 1 || matched[0][0] != timeLine {
+		matched = regex_things.ReMatchDialogueTimeSRT2.FindAllStringSubmatch(timeLine, -1)
+		if matched == nil || len(matched) < 1 || matched[0][0] != timeLine {
+			return "", false
+		}
+	}
+	return strconv.Itoa(index) + "|" + matched[0][1] + "|" + matched[0][2], true
+}
+
+// BackUpExt 剔除之前的原始字幕的备份后缀名，与时间轴校正的 .csf-bk 区分开
+const BackUpExt = ".csf-clean-bk"
+
+var builtInRules = []rule{
+	// 网址、联系方式，任何位置都剔除
+	{name: "url", re: regexp.MustCompile(`(?i)(https?://|www\.)\S+`)},
+	{name: "qq", re: regexp.MustCompile(`(?i)(QQ|企鹅)\s*群?\s*号?\s*[:：]?\s*\d{5,}|群号\s*[:：]?\s*\d{5,}`)},
+	{name: "wechat", re: regexp.MustCompile(`(?i)(微信|公众号|微博|weixin|wechat)\s*(号|搜索|关注)?\s*[:：@]`)},
+	// 没有协议头的域名、字幕组的署名，正常的对白也可能出现，只在开头、结尾查找
+	{name: "domain", re: regexp.MustCompile(`(?i)\b[a-z0-9-]+\.(com|net|org|cn|cc|tv|me|io|info|top|xyz|vip)\b`), edgeOnly: true},
+	{name: "credit", re: regexp.MustCompile(`字幕组|字幕社|字幕站|本字幕|字幕由|字幕来源|(翻译|校对|时间轴|后期|压制|特效|监制)\s*[:：]|更多.{0,6}字幕|仅供.{0,10}(学习|交流)|禁止.{0,10}(商业|商用)|欢迎.{0,4}(访问|关注|加入)`), edgeOnly: true},
+	{name: "credit_en", re: regexp.MustCompile(`(?i)subtitles?\s+(by|from)|sync(ed)?\s+(and|&)\s+correct|(re)?synced\s+by|downloaded\s+from|opensubtitles|addic7ed|subscene`), edgeOnly: true},
+}
@@ -0,0 +1,104 @@
+package sub_cleaner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+)
+
+const testSRT = `1
+00:00:01,000 --> 00:00:03,000
+本字幕由 XX 字幕组 翻译
+
+2
+00:00:05,000 --> 00:00:07,000
+我们得走了
+We have to go.
+
+3
+00:00:08,000 --> 00:00:09,000
+帮我翻译一下这句话
+
+4
+00:00:10,000 --> 00:00:12,000
+更多中文字幕请访问 www.example.com
+
+5
+00:20:00,000 --> 00:20:02,000
+视频结束之后的对白
+`
+
+const testASS = `[Script Info]
+ScriptType: v4.00+
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:03.00,Default,,0,0,0,,{\fn微软雅黑}QQ群：123456789
+Dialogue: 0,0:00:05.00,0:00:07.00,Default,,0,0,0,,我们得走了\NWe have to go.
+Dialogue: 0,0:00:08.00,0:00:09.00,Default,,0,0,0,,广告词
+Dialogue: 0,0:00:10.00,0:00:12.00,Default,,0,0,0,,好的
+`
+
+func TestCleaner_CleanSRT(t *testing.T) {
+
+	cleanerSettings := settings.NewSubCleanerSettings()
+	cleanerSettings.EdgeDialogueCount = 1
+	bok, info, err := srt.NewParser(log_helper.GetLogger4Tester()).DetermineFileTypeFromBytes([]byte(testSRT), ".srt")
+	if err != nil || bok == false {
+		t.Fatal("DetermineFileTypeFromBytes", bok, err)
+	}
+	// 视频 10 分钟，最后一句超出了视频的时长
+	newContent, removed, err := NewCleaner(cleanerSettings).Clean(info, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRules := []string{"credit", "url", "out_of_duration"}
+	if len(removed) != len(wantRules) {
+		t.Fatalf("removed = %+v", removed)
+	}
+	for i, one := range removed {
+		if one.Rule != wantRules[i] {
+			t.Errorf("removed[%d] = %+v, want rule %v", i, one, wantRules[i])
+		}
+	}
+	// 中间的对白不在开头、结尾，不会按署名的规则剔除
+	if strings.Contains(newContent, "帮我翻译一下这句话") == false || strings.Contains(newContent, "We have to go.") == false {
+		t.Fatalf("newContent = %v", newContent)
+	}
+	if strings.Contains(newContent, "字幕组") == true || strings.Contains(newContent, "www.example.com") == true ||
+		strings.Contains(newContent, "视频结束之后的对白") == true {
+		t.Fatalf("newContent = %v", newContent)
+	}
+}
+
+func TestCleaner_CleanASS(t *testing.T) {
+
+	cleanerSettings := settings.NewSubCleanerSettings()
+	cleanerSettings.CustomRegexes = []string{"^广告"}
+	bok, info, err := ass.NewParser(log_helper.GetLogger4Tester()).DetermineFileTypeFromBytes([]byte(testASS), ".ass")
+	if err != nil || bok == false {
+		t.Fatal("DetermineFileTypeFromBytes", bok, err)
+	}
+	newContent, removed, err := NewCleaner(cleanerSettings).Clean(info, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].Rule != "qq" || removed[1].Rule != "custom_0" {
+		t.Fatalf("removed = %+v", removed)
+	}
+	wantContent := strings.Replace(testASS, "Dialogue: 0,0:00:01.00,0:00:03.00,Default,,0,0,0,,{\\fn微软雅黑}QQ群：123456789\n", "", 1)
+	wantContent = strings.Replace(wantContent, "Dialogue: 0,0:00:08.00,0:00:09.00,Default,,0,0,0,,广告词\n", "", 1)
+	if newContent != wantContent {
+		t.Fatalf("newContent = %v", newContent)
+	}
+	// 不使用内置的规则
+	cleanerSettings.UseBuiltInRules = false
+	_, removed, err = NewCleaner(cleanerSettings).Clean(info, 0)
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("removed = %+v, err = %v", removed, err)
+	}
+}
@@ -0,0 +1,37 @@
+package sub_helper
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+)
+
+/*
+	GetSeasonAndEpisodeFromSubFileNameEx 在 decode.GetSeasonAndEpisodeFromSubFileName 的基础上，支持动画命名的字幕
+	1. [Group] Title - 137.ass 绝对集数命名的，通过 seriesInfo 转换为 季、集
+	2. [Group] Title S2 - 05.ass 这样带着季的信息的，直接使用
+	seriesInfo 为 nil 或者不是动画的时候，与 decode.GetSeasonAndEpisodeFromSubFileName 一致
+	注意 S2 - 05 这样的会被 decode 认为是季的字幕包，所以只要没有解析出集数，都需要再尝试动画的命名
+*/
+func GetSeasonAndEpisodeFromSubFileNameEx(subFileName string, seriesInfo *series.SeriesInfo) (bool, int, int, error) {
+
+	isFullSeason, season, episode, err := decode.GetSeasonAndEpisodeFromSubFileName(subFileName)
+	if err != nil || episode != 0 {
+		return isFullSeason, season, episode, err
+	}
+	if seriesInfo == nil || seriesInfo.IsAnime == false {
+		return isFullSeason, season, episode, err
+	}
+	animeNameInfo, found := anime_helper.ParseAnimeFileName(subFileName)
+	if found == false {
+		return isFullSeason, season, episode, err
+	}
+	if animeNameInfo.IsAbsolute == false {
+		return false, animeNameInfo.Season, animeNameInfo.Episode, nil
+	}
+	animeSeason, animeEpisode, ok := seriesInfo.GetSeasonEpisodeByAbsolute(animeNameInfo.Episode)
+	if ok == false {
+		return isFullSeason, season, episode, err
+	}
+	return false, animeSeason, animeEpisode, nil
+}
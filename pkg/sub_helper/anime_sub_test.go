@@ -0,0 +1,40 @@
+package sub_helper
+
+import (
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
+)
+
+func TestGetSeasonAndEpisodeFromSubFileNameEx(t *testing.T) {
+
+	seriesInfo := &series.SeriesInfo{
+		IsAnime:            true,
+		AbsoluteEpisodeMap: anime_helper.NewEpisodeMapFromSeasonEpisodeCounts(map[int]int{1: 12, 2: 12}),
+	}
+	tests := []struct {
+		name        string
+		subFileName string
+		seriesInfo  *series.SeriesInfo
+		wantSeason  int
+		wantEpisode int
+	}{
+		{name: "00", subFileName: "Title.S02E03.chs.ass", seriesInfo: seriesInfo, wantSeason: 2, wantEpisode: 3},
+		{name: "01", subFileName: "[Group] Title - 15 [1080p].chs.ass", seriesInfo: seriesInfo, wantSeason: 2, wantEpisode: 3},
+		{name: "02", subFileName: "[Group] Title S2 - 05.ass", seriesInfo: seriesInfo, wantSeason: 2, wantEpisode: 5},
+		{name: "03", subFileName: "[Group] Title - 15 [1080p].chs.ass", seriesInfo: nil, wantSeason: 0, wantEpisode: 0},
+		{name: "04", subFileName: "[Group] Title - 30 [1080p].chs.ass", seriesInfo: seriesInfo, wantSeason: 0, wantEpisode: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gotSeason, gotEpisode, err := GetSeasonAndEpisodeFromSubFileNameEx(tt.subFileName, tt.seriesInfo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotSeason != tt.wantSeason || gotEpisode != tt.wantEpisode {
+				t.Errorf("GetSeasonAndEpisodeFromSubFileNameEx() = S%vE%v, want S%vE%v", gotSeason, gotEpisode, tt.wantSeason, tt.wantEpisode)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package sub_helper
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// IsForcedSubFileName 从字幕的文件名判断是否是 forced（特效、招牌、只翻译画面文字）字幕
+func IsForcedSubFileName(subFileName string) bool {
+
+	nowFileName := strings.ToLower(filepath.Base(subFileName))
+	// 带有 .forced. 标记的，本程序或者用户已经确认过了
+	if strings.Contains(nowFileName, subparser.Sub_Ext_Mark_Forced+".") == true {
+		return true
+	}
+	// 去掉后缀名再做关键词的判断，避免误伤
+	nowFileName = strings.TrimSuffix(nowFileName, filepath.Ext(nowFileName))
+	return reMatchForcedFileName.MatchString(nowFileName)
+}
+
+// IsForcedStyleName ASS 的 StyleName 是否是 forced 类型的，比如 Signs、Forced、招牌
+func IsForcedStyleName(styleName string) bool {
+	return reMatchForcedStyleName.MatchString(styleName)
+}
+
+/*
+	IsForcedSubtitle 判断这个字幕是否是 forced 字幕，这类字幕只翻译画面中的文字或者外语片段，对白非常稀疏
+	1. 文件名中带有 .forced. 或者 signs、forced 这类关键词
+	2. ASS 字幕中，绝大部分对白使用的 Style 是 Signs、Forced 这类
+	3. 对白的密度（每分钟对白数、对白覆盖时长占视频时长的比例）远低于正常的字幕
+	videoDuration 是视频的时长（秒），如果获取不到（<= 0），那么就使用字幕本身的时长来替代
+*/
+func IsForcedSubtitle(fileInfo *subparser.FileInfo, videoDuration float64) bool {
+
+	if fileInfo == nil {
+		return false
+	}
+	if IsForcedSubFileName(fileInfo.Name) == true {
+		return true
+	}
+	if len(fileInfo.Dialogues) < 1 {
+		return false
+	}
+	// ASS 的 Style 统计
+	forcedStyleCount := 0
+	for _, dialogue := range fileInfo.Dialogues {
+		if IsForcedStyleName(dialogue.StyleName) == true {
+			forcedStyleCount++
+		}
+	}
+	if float64(forcedStyleCount)/float64(len(fileInfo.Dialogues)) >= forcedStylePer {
+		return true
+	}
+	// 对白密度的统计
+	if videoDuration <= 0 {
+		videoDuration = pkg.Time2SecondNumber(fileInfo.GetEndTime())
+	}
+	if videoDuration < forcedMinVideoDuration {
+		// 太短的视频没有统计意义
+		return false
+	}
+	dialogueCount := 0
+	dialogueDuration := 0.0
+	for _, dialogue := range fileInfo.Dialogues {
+		if len(dialogue.Lines) < 1 || pkg.ReplaceSpecString(strings.Join(dialogue.Lines, ""), "") == "" {
+			continue
+		}
+		oneDuration := pkg.Time2SecondNumber(dialogue.GetEndTime()) - pkg.Time2SecondNumber(dialogue.GetStartTime())
+		if oneDuration <= 0 {
+			continue
+		}
+		dialogueCount++
+		dialogueDuration += oneDuration
+	}
+	dialoguePerMinute := float64(dialogueCount) / (videoDuration / 60.0)
+	coveragePer := dialogueDuration / videoDuration
+	if dialoguePerMinute < forcedMaxDialoguePerMinute && coveragePer < forcedMaxCoveragePer {
+		return true
+	}
+
+	return false
+}
+
+const (
+	forcedStylePer             = 0.8   // 使用 forced 类型 Style 的对白占比超过这个值，就认为是 forced 字幕
+	forcedMinVideoDuration     = 300.0 // 低于 5 分钟的视频不做对白密度的判断
+	forcedMaxDialoguePerMinute = 2.0   // 正常的字幕每分钟有 10 句以上的对白，forced 字幕往往 1 分钟都不到 1 句
+	forcedMaxCoveragePer       = 0.1   // 对白覆盖视频时长的占比，正常的字幕在 40% 以上
+)
+
+// 匹配文件名中 forced 字幕的关键词，需要是 .signs. [forced] (signs) 这样独立的标记，避免误伤 Signs (2002) 这类片名
+var reMatchForcedFileName = regexp.MustCompile(`(?i)[.\[(](forced|signs?|signs?\s*&\s*songs?)[.\])]|[.\[(](招牌|特效)[.\])]`)
+
+// 匹配 ASS 中 forced 字幕的 StyleName
+var reMatchForcedStyleName = regexp.MustCompile(`(?i)(^|[^a-z])(forced|signs?|signs?\s*&\s*songs?)([^a-z]|$)|招牌|特效`)
@@ -0,0 +1,77 @@
+package sub_helper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+func TestIsForcedSubFileName(t *testing.T) {
+	type args struct {
+		subFileName string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{name: "00", args: args{subFileName: "Movie (2020).chinese(简,assrt).forced.ass"}, want: true},
+		{name: "01", args: args{subFileName: "Movie (2020).zh.forced.srt"}, want: true},
+		{name: "02", args: args{subFileName: "[Group] Movie [Signs].ass"}, want: true},
+		{name: "03", args: args{subFileName: "Movie.2020.signs&songs.chs.ass"}, want: true},
+		{name: "04", args: args{subFileName: "Movie (2020).chinese(简英,assrt).default.ass"}, want: false},
+		{name: "05", args: args{subFileName: "Signs (2002).zh.ass"}, want: false},
+		{name: "06", args: args{subFileName: "[assrt]_0_Signs_S0E0.ass"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsForcedSubFileName(tt.args.subFileName); got != tt.want {
+				t.Errorf("IsForcedSubFileName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsForcedSubtitle(t *testing.T) {
+
+	// 每 step 秒一句对白，每句持续 2 秒
+	makeSub := func(name, styleName string, count, step int) *subparser.FileInfo {
+		fileInfo := subparser.FileInfo{Name: name, Dialogues: make([]subparser.OneDialogue, 0)}
+		for i := 0; i < count; i++ {
+			start := i * step
+			fileInfo.Dialogues = append(fileInfo.Dialogues, subparser.OneDialogue{
+				StartTime: fmt.Sprintf("%d:%02d:%02d.00", start/3600, start%3600/60, start%60),
+				EndTime:   fmt.Sprintf("%d:%02d:%02d.00", (start+2)/3600, (start+2)%3600/60, (start+2)%60),
+				StyleName: styleName,
+				Lines:     []string{"对白"},
+			})
+		}
+		return &fileInfo
+	}
+
+	type args struct {
+		fileInfo      *subparser.FileInfo
+		videoDuration float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{name: "full sub", args: args{fileInfo: makeSub("a.ass", "Default", 1200, 5), videoDuration: 6000}, want: false},
+		{name: "sparse sub", args: args{fileInfo: makeSub("a.ass", "Default", 40, 150), videoDuration: 6000}, want: true},
+		{name: "signs style", args: args{fileInfo: makeSub("a.ass", "Signs", 1200, 5), videoDuration: 6000}, want: true},
+		{name: "name mark", args: args{fileInfo: makeSub("a.forced.ass", "Default", 1200, 5), videoDuration: 6000}, want: true},
+		{name: "short video", args: args{fileInfo: makeSub("a.ass", "Default", 2, 60), videoDuration: 120}, want: false},
+		{name: "no video duration", args: args{fileInfo: makeSub("a.ass", "Default", 1200, 5), videoDuration: 0}, want: false},
+		{name: "nil", args: args{fileInfo: nil, videoDuration: 6000}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsForcedSubtitle(tt.args.fileInfo, tt.args.videoDuration); got != tt.want {
+				t.Errorf("IsForcedSubtitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package sub_helper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+/*
+	IsCombinedMultiEpisodeSub 判断这个字幕是否是多集合并的视频对应的“合并”字幕，可以直接使用
+	1. 字幕的文件名就是对应的集数范围，比如 S01E01E02
+	2. 字幕的时长覆盖了整个视频，而不是其中的一集
+*/
+func IsCombinedMultiEpisodeSub(fileInfo *subparser.FileInfo, season, episodeStart, episodeEnd int, videoDuration float64) bool {
+
+	if fileInfo == nil {
+		return false
+	}
+	found, subSeason, subEpisodeStart, subEpisodeEnd := decode.GetEpisodeRangeFromFileName(fileInfo.Name)
+	if found == true {
+		return subSeason == season && subEpisodeStart == episodeStart && subEpisodeEnd == episodeEnd
+	}
+	if videoDuration <= 0 || len(fileInfo.Dialogues) < 1 {
+		return false
+	}
+	// 最后一集的字幕需要覆盖到，那么字幕的时长至少要超过前面几集的时长
+	partCount := float64(episodeEnd - episodeStart + 1)
+	subEndTime := pkg.Time2SecondNumber(fileInfo.GetEndTime())
+	return subEndTime >= videoDuration*(partCount-combinedSubMinLastPartPer)/partCount
+}
+
+/*
+	GetMultiEpisodePartDurations 多集合并的视频，每一集的时长（秒），视频的时长以及章节都是 ffprobe 读取的
+	1. 章节的数量与集数一致的时候，每个章节就是一集，直接使用章节的时长
+	2. 章节更多的时候，找到最接近均分位置的章节作为分界，每一集的时长就是分界之间的章节时长之和
+	3. 没有合适的章节才均分视频的时长，这时候只是估算的
+*/
+func GetMultiEpisodePartDurations(videoDuration float64, chapterStartTimes []float64, partCount int) []float64 {
+
+	durations := make([]float64, 0, partCount)
+	if partCount < 1 || videoDuration <= 0 {
+		return durations
+	}
+	offsets := make([]float64, 0, partCount)
+	offsets = append(offsets, 0)
+	if len(chapterStartTimes) == partCount {
+		offsets = append(offsets, chapterStartTimes[1:]...)
+	} else {
+		partDuration := videoDuration / float64(partCount)
+		for i := 1; i < partCount; i++ {
+			evenOffset := partDuration * float64(i)
+			nowOffset := evenOffset
+			minDiff := math.MaxFloat64
+			for _, chapterStartTime := range chapterStartTimes {
+				diff := math.Abs(chapterStartTime - evenOffset)
+				if diff < minDiff && diff <= partDuration*chapterMaxDiffPer {
+					minDiff = diff
+					nowOffset = chapterStartTime
+				}
+			}
+			offsets = append(offsets, nowOffset)
+		}
+	}
+	for i := range offsets {
+		nextOffset := videoDuration
+		if i+1 < len(offsets) {
+			nextOffset = offsets[i+1]
+		}
+		if nextOffset <= offsets[i] {
+			// 章节的信息不对
+			return make([]float64, 0, partCount)
+		}
+		durations = append(durations, nextOffset-offsets[i])
+	}
+	return durations
+}
+
+/*
+	MergeMultiEpisodeSubtitles 把每一集的字幕按照前面几集的时长之和偏移后，合并为一个字幕
+	有 ASS、SSA 的时候输出为 ASS，使用第一个 ASS 的头部信息，其他集的样式会补充进去，SRT 的对白使用 Default 样式
+	都是 SRT 的时候输出为 SRT
+*/
+func MergeMultiEpisodeSubtitles(parts []subparser.FileInfo, partDurations []float64) (*subparser.FileInfo, error) {
+
+	if len(parts) < 1 || len(parts) != len(partDurations) {
+		return nil, errors.New("MergeMultiEpisodeSubtitles parts and partDurations not match")
+	}
+	partOffsets := make([]time.Duration, 0, len(parts))
+	offset := 0.0
+	for _, partDuration := range partDurations {
+		partOffsets = append(partOffsets, time.Duration(offset*float64(time.Second)))
+		offset += partDuration
+	}
+	assIndex := -1
+	for i := range parts {
+		if ass_style_normalizer.IsAssOrSsa(parts[i].Ext) == true {
+			assIndex = i
+			break
+		}
+	}
+	if assIndex < 0 {
+		return mergeSrtSubtitles(parts, partOffsets)
+	}
+	return mergeAssSubtitles(parts, partOffsets, assIndex)
+}
+
+func mergeSrtSubtitles(parts []subparser.FileInfo, partOffsets []time.Duration) (*subparser.FileInfo, error) {
+
+	mergedFileInfo := newMergedFileInfo(parts[0], common.SubExtSRT)
+	var sb strings.Builder
+	index := 1
+	for i, part := range parts {
+		for _, dialogue := range part.Dialogues {
+			startTime, endTime, err := shiftDialogueTime(dialogue, partOffsets[i])
+			if err != nil {
+				return nil, err
+			}
+			oneDialogue := dialogue
+			oneDialogue.Index = index
+			oneDialogue.StartTime = pkg.Time2SubTimeString(startTime, common.TimeFormatPoint3)
+			oneDialogue.EndTime = pkg.Time2SubTimeString(endTime, common.TimeFormatPoint3)
+			mergedFileInfo.Dialogues = append(mergedFileInfo.Dialogues, oneDialogue)
+
+			sb.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index,
+				startTime.Format(common.TimeFormatPoint3), endTime.Format(common.TimeFormatPoint3),
+				strings.Join(dialogue.Lines, "\n")))
+			index++
+		}
+	}
+	mergedFileInfo.Content = sb.String()
+	mergedFileInfo.Data = []byte(mergedFileInfo.Content)
+
+	return &mergedFileInfo, nil
+}
+
+// mergeAssSubtitles ASS、SSA 的对白按行偏移时间，保留原有的样式、特效等信息
+func mergeAssSubtitles(parts []subparser.FileInfo, partOffsets []time.Duration, assIndex int) (*subparser.FileInfo, error) {
+
+	mergedFileInfo := newMergedFileInfo(parts[assIndex], parts[assIndex].Ext)
+	header := strings.ReplaceAll(parts[assIndex].PrefixDialogueString, "\r", "")
+	// 其他集的样式，同名的以第一个 ASS 的为准
+	styleNames := make(map[string]bool)
+	for _, line := range strings.Split(header, "\n") {
+		if styleName, ok := getAssStyleName(line); ok == true {
+			styleNames[styleName] = true
+		}
+	}
+	extraStyles := make([]string, 0)
+	for i := range parts {
+		if i == assIndex || ass_style_normalizer.IsAssOrSsa(parts[i].Ext) == false {
+			continue
+		}
+		for _, line := range strings.Split(strings.ReplaceAll(parts[i].PrefixDialogueString, "\r", ""), "\n") {
+			styleName, ok := getAssStyleName(line)
+			if ok == false || styleNames[styleName] == true {
+				continue
+			}
+			styleNames[styleName] = true
+			extraStyles = append(extraStyles, strings.TrimSpace(line))
+		}
+	}
+	header = insertAssStyles(header, extraStyles)
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	if strings.HasSuffix(header, "\n") == false {
+		sb.WriteString("\n")
+	}
+	for i, part := range parts {
+		if ass_style_normalizer.IsAssOrSsa(part.Ext) == true {
+			for _, line := range strings.Split(strings.ReplaceAll(part.Content, "\r", ""), "\n") {
+				shiftedLine, ok, err := shiftAssEventLine(line, partOffsets[i])
+				if err != nil {
+					return nil, err
+				}
+				if ok == true {
+					sb.WriteString(shiftedLine + "\n")
+				}
+			}
+		} else {
+			for _, dialogue := range part.Dialogues {
+				startTime, endTime, err := shiftDialogueTime(dialogue, partOffsets[i])
+				if err != nil {
+					return nil, err
+				}
+				sb.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n",
+					pkg.Time2SubTimeString(startTime, common.TimeFormatPoint2),
+					pkg.Time2SubTimeString(endTime, common.TimeFormatPoint2),
+					strings.Join(dialogue.Lines, `\N`)))
+			}
+		}
+		for _, dialogue := range part.Dialogues {
+			startTime, endTime, err := shiftDialogueTime(dialogue, partOffsets[i])
+			if err != nil {
+				return nil, err
+			}
+			oneDialogue := dialogue
+			oneDialogue.Index = len(mergedFileInfo.Dialogues) + 1
+			oneDialogue.StartTime = pkg.Time2SubTimeString(startTime, common.TimeFormatPoint2)
+			oneDialogue.EndTime = pkg.Time2SubTimeString(endTime, common.TimeFormatPoint2)
+			mergedFileInfo.Dialogues = append(mergedFileInfo.Dialogues, oneDialogue)
+		}
+	}
+	mergedFileInfo.Content = sb.String()
+	mergedFileInfo.Data = []byte(mergedFileInfo.Content)
+
+	return &mergedFileInfo, nil
+}
+
+func newMergedFileInfo(first subparser.FileInfo, ext string) subparser.FileInfo {
+
+	mergedFileInfo := first
+	mergedFileInfo.Ext = ext
+	mergedFileInfo.Dialogues = make([]subparser.OneDialogue, 0)
+	mergedFileInfo.DialoguesFilter = make([]subparser.OneDialogue, 0)
+	mergedFileInfo.DialoguesFilterEx = make([]subparser.OneDialogueEx, 0)
+	return mergedFileInfo
+}
+
+func shiftDialogueTime(dialogue subparser.OneDialogue, offsetTime time.Duration) (time.Time, time.Time, error) {
+
+	startTime, err := pkg.ParseTime(dialogue.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endTime, err := pkg.ParseTime(dialogue.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startTime.Add(offsetTime), endTime.Add(offsetTime), nil
+}
+
+// shiftAssEventLine Dialogue、Comment 行偏移开始和结束的时间，其他的行返回 false
+func shiftAssEventLine(line string, offsetTime time.Duration) (string, bool, error) {
+
+	colonIndex := strings.Index(line, ":")
+	if colonIndex < 0 {
+		return "", false, nil
+	}
+	eventType := line[:colonIndex]
+	if eventType != "Dialogue" && eventType != "Comment" {
+		return "", false, nil
+	}
+	// Layer, Start, End, 剩下的
+	fields := strings.SplitN(line[colonIndex+1:], ",", 4)
+	if len(fields) < 4 {
+		return "", false, nil
+	}
+	startTime, err := pkg.ParseTime(strings.TrimSpace(fields[1]))
+	if err != nil {
+		return "", false, err
+	}
+	endTime, err := pkg.ParseTime(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return "", false, err
+	}
+	fields[1] = pkg.Time2SubTimeString(startTime.Add(offsetTime), common.TimeFormatPoint2)
+	fields[2] = pkg.Time2SubTimeString(endTime.Add(offsetTime), common.TimeFormatPoint2)
+	return eventType + ":" + strings.Join(fields, ","), true, nil
+}
+
+func getAssStyleName(line string) (string, bool) {
+
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "Style:") == false {
+		return "", false
+	}
+	fields := strings.SplitN(strings.TrimPrefix(line, "Style:"), ",", 2)
+	return strings.TrimSpace(fields[0]), true
+}
+
+// insertAssStyles 补充的样式加到最后一个 Style 行的后面
+func insertAssStyles(header string, styles []string) string {
+
+	if len(styles) < 1 {
+		return header
+	}
+	lines := strings.Split(header, "\n")
+	lastStyleIndex := -1
+	for i, line := range lines {
+		if _, ok := getAssStyleName(line); ok == true {
+			lastStyleIndex = i
+		}
+	}
+	if lastStyleIndex < 0 {
+		return header
+	}
+	outLines := make([]string, 0, len(lines)+len(styles))
+	outLines = append(outLines, lines[:lastStyleIndex+1]...)
+	outLines = append(outLines, styles...)
+	outLines = append(outLines, lines[lastStyleIndex+1:]...)
+	return strings.Join(outLines, "\n")
+}
+
+const (
+	combinedSubMinLastPartPer = 0.5 // 合并字幕至少需要覆盖最后一集一半的时长
+	chapterMaxDiffPer         = 0.1 // 章节的位置与均分位置的差值，不能超过一集时长的 10%
+)
@@ -0,0 +1,102 @@
+package sub_helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+func TestGetMultiEpisodePartDurations(t *testing.T) {
+	type args struct {
+		videoDuration     float64
+		chapterStartTimes []float64
+		partCount         int
+	}
+	tests := []struct {
+		name string
+		args args
+		want []float64
+	}{
+		{name: "00", args: args{videoDuration: 2400, chapterStartTimes: nil, partCount: 2}, want: []float64{1200, 1200}},
+		{name: "01", args: args{videoDuration: 2400, chapterStartTimes: []float64{0, 300, 1180, 1500}, partCount: 2}, want: []float64{1180, 1220}},
+		{name: "02", args: args{videoDuration: 2400, chapterStartTimes: []float64{0, 600, 1800, 2000}, partCount: 2}, want: []float64{1200, 1200}},
+		{name: "03", args: args{videoDuration: 3600, chapterStartTimes: []float64{0, 1210, 2390, 3000}, partCount: 3}, want: []float64{1210, 1180, 1210}},
+		// 章节的数量与集数一致，每一集的时长差别很大也直接使用
+		{name: "04", args: args{videoDuration: 3600, chapterStartTimes: []float64{0, 1200}, partCount: 2}, want: []float64{1200, 2400}},
+		{name: "05", args: args{videoDuration: 0, chapterStartTimes: nil, partCount: 2}, want: []float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetMultiEpisodePartDurations(tt.args.videoDuration, tt.args.chapterStartTimes, tt.args.partCount); reflect.DeepEqual(got, tt.want) == false {
+				t.Errorf("GetMultiEpisodePartDurations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMultiEpisodeSubtitles(t *testing.T) {
+
+	parts := []subparser.FileInfo{
+		{Name: "Show.S01E01.chs.ass", Ext: ".ass", Dialogues: []subparser.OneDialogue{
+			{StartTime: "0:00:01.00", EndTime: "0:00:02.50", Lines: []string{"第一集"}},
+		}},
+		{Name: "Show.S01E02.chs.srt", Ext: ".srt", Dialogues: []subparser.OneDialogue{
+			{StartTime: "00:00:03,000", EndTime: "00:00:04,000", Lines: []string{"第二集", "second"}},
+		}},
+	}
+	parts[0].Ext = ".srt"
+	got, err := MergeMultiEpisodeSubtitles(parts, []float64{1200.5, 1200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantContent := "1\n00:00:01,000 --> 00:00:02,500\n第一集\n\n" +
+		"2\n00:20:03,500 --> 00:20:04,500\n第二集\nsecond\n\n"
+	if got.Content != wantContent {
+		t.Errorf("MergeMultiEpisodeSubtitles() Content = %q, want %q", got.Content, wantContent)
+	}
+	if got.Ext != ".srt" || len(got.Dialogues) != 2 || got.Dialogues[1].StartTime != "0:20:03,500" {
+		t.Errorf("MergeMultiEpisodeSubtitles() = %v", got)
+	}
+
+	_, err = MergeMultiEpisodeSubtitles(parts, []float64{0})
+	if err == nil {
+		t.Errorf("MergeMultiEpisodeSubtitles() want error when offsets not match")
+	}
+}
+
+func TestMergeMultiEpisodeSubtitles_Ass(t *testing.T) {
+
+	header1 := "[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n"
+	header2 := "[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Times\nStyle: Sign,Arial\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n"
+	parts := []subparser.FileInfo{
+		{Name: "Show.S01E01.chs.ass", Ext: ".ass", PrefixDialogueString: header1,
+			Content: header1 + "Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,{\\an8}第一集\n",
+			Dialogues: []subparser.OneDialogue{
+				{StartTime: "0:00:01.00", EndTime: "0:00:02.50", Lines: []string{"第一集"}},
+			}},
+		{Name: "Show.S01E02.chs.ass", Ext: ".ass", PrefixDialogueString: header2,
+			Content: header2 + "Comment: 0,0:00:00.00,0:00:00.50,Sign,,0,0,0,,note\nDialogue: 0,0:00:03.00,0:00:04.00,Sign,,0,0,0,,第二集\n",
+			Dialogues: []subparser.OneDialogue{
+				{StartTime: "0:00:03.00", EndTime: "0:00:04.00", Lines: []string{"第二集"}},
+			}},
+		{Name: "Show.S01E03.chs.srt", Ext: ".srt", Dialogues: []subparser.OneDialogue{
+			{StartTime: "00:00:05,000", EndTime: "00:00:06,000", Lines: []string{"第三集", "third"}},
+		}},
+	}
+	got, err := MergeMultiEpisodeSubtitles(parts, []float64{1200, 1300, 1200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantContent := "[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\nStyle: Sign,Arial\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,{\\an8}第一集\n" +
+		"Comment: 0,0:20:00.00,0:20:00.50,Sign,,0,0,0,,note\n" +
+		"Dialogue: 0,0:20:03.00,0:20:04.00,Sign,,0,0,0,,第二集\n" +
+		"Dialogue: 0,0:41:45.00,0:41:46.00,Default,,0,0,0,,第三集\\Nthird\n"
+	if got.Content != wantContent {
+		t.Errorf("MergeMultiEpisodeSubtitles() Content = %q, want %q", got.Content, wantContent)
+	}
+	if got.Ext != ".ass" || len(got.Dialogues) != 3 || got.Dialogues[2].StartTime != "0:41:45.00" {
+		t.Errorf("MergeMultiEpisodeSubtitles() = %v", got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/series"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
 
@@ -26,7 +27,8 @@ import (
 )
 
 // OrganizeDlSubFiles 需要从汇总来是网站字幕中，解压对应的压缩包中的字幕出来
-func OrganizeDlSubFiles(log *logrus.Logger, tmpFolderName string, subInfos []supplier.SubInfo, isMovie bool) (map[string][]string, error) {
+// seriesInfo 电影的时候为 nil，是动画的时候，压缩包中绝对集数命名的字幕也能对应到具体的一集
+func OrganizeDlSubFiles(log *logrus.Logger, tmpFolderName string, subInfos []supplier.SubInfo, isMovie bool, seriesInfo *series.SeriesInfo) (map[string][]string, error) {
 
 	// 缓存列表，整理后的字幕列表
 	// SxEx - []string 字幕的路径
@@ -90,7 +92,7 @@ func OrganizeDlSubFiles(log *logrus.Logger, tmpFolderName string, subInfos []sup
 				if isMovie == false {
 					// 连续剧的情况
 					// 从解压的文件名称推断 Season 和 Episode 信息
-					_, nowSeason, nowEps, err := decode.GetSeasonAndEpisodeFromSubFileName(filepath.Base(fileFullPath))
+					_, nowSeason, nowEps, err := GetSeasonAndEpisodeFromSubFileNameEx(filepath.Base(fileFullPath), seriesInfo)
 					if err != nil {
 						continue
 					}
@@ -309,7 +311,7 @@ func SearchMatchedSubFileByOneVideo(l *logrus.Logger, oneVideoFullPath string) (
 	return matchedSubs, nil
 }
 
-// SearchVideoMatchSubFileAndRemoveExtMark 找到找个视频目录下相匹配的字幕，同时去除这些字幕中 .default 或者 .forced 的标记。注意这两个标记不应该同时出现，否则无法正确去除
+// SearchVideoMatchSubFileAndRemoveExtMark 找到找个视频目录下相匹配的字幕，同时去除这些字幕中 .default 的标记。.forced 的标记是 forced 字幕专用的，不会去除
 func SearchVideoMatchSubFileAndRemoveExtMark(l *logrus.Logger, oneVideoFullPath string) error {
 
 	dir := filepath.Dir(oneVideoFullPath)
@@ -349,15 +351,8 @@ func SearchVideoMatchSubFileAndRemoveExtMark(l *logrus.Logger, oneVideoFullPath
 				if err != nil {
 					return err
 				}
-			} else if strings.Contains(nowFileName, subparser.Sub_Ext_Mark_Forced+".") == true {
-				// 得包含 .forced. 找个关键词
-				oldPath := dir + pathSep + curFile.Name()
-				newPath := dir + pathSep + strings.ReplaceAll(curFile.Name(), subparser.Sub_Ext_Mark_Forced+".", ".")
-				err = os.Rename(oldPath, newPath)
-				if err != nil {
-					return err
-				}
 			} else {
+				// .forced. 标记的是真正的 forced 字幕（IsForcedSubtitle），需要保留，否则会被当做正常的字幕
 				continue
 			}
 		}
@@ -0,0 +1,94 @@
+package sub_post_process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ffmpeg_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_cleaner"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/sirupsen/logrus"
+)
+
+// AdCleanProcessor 剔除字幕中的广告、字幕组署名，修改前可以保留原始的字幕
+type AdCleanProcessor struct {
+	log          *logrus.Logger
+	subParserHub *sub_parser_hub.SubParserHub
+	ffmpegHelper *ffmpeg_helper.FFMPEGHelper
+}
+
+func NewAdCleanProcessor(log *logrus.Logger) *AdCleanProcessor {
+	return &AdCleanProcessor{
+		log:          log,
+		subParserHub: sub_parser_hub.NewSubParserHub(log, ass.NewParser(log), srt.NewParser(log)),
+		ffmpegHelper: ffmpeg_helper.NewFFMPEGHelper(log),
+	}
+}
+
+func (a *AdCleanProcessor) GetStageName() string {
+	return settings.SubPostProcessStageAdClean
+}
+
+func (a *AdCleanProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	cleanerSettings := settings.Get().AdvancedSettings.SubCleanerSettings
+	if cleanerSettings == nil || cleanerSettings.Enable == false {
+		return false, "sub_cleaner is false", nil
+	}
+	bFind, subFileInfo, err := a.subParserHub.DetermineFileTypeFromFile(pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	if bFind == false {
+		return false, "not support sub type", nil
+	}
+	videoDuration := 0.0
+	if cleanerSettings.RemoveOutOfDuration == true {
+		// 获取不到时长的时候是 0，就不按时长剔除了
+		videoDuration = a.ffmpegHelper.GetVideoDuration(pCtx.VideoFPath)
+	}
+	newContent, removed, err := sub_cleaner.NewCleaner(cleanerSettings).Clean(subFileInfo, videoDuration)
+	if err != nil {
+		return false, "", err
+	}
+	if len(removed) == 0 {
+		return false, "nothing to remove", nil
+	}
+
+	if cleanerSettings.KeepBackup == true {
+		err = pkg.CopyFile(pCtx.SubFPath, pCtx.SubFPath+sub_cleaner.BackUpExt)
+		if err != nil {
+			return false, "", err
+		}
+	}
+	// 解析的时候已经转换为 UTF-8 了
+	err = pkg.WriteFile(pCtx.SubFPath, []byte(newContent))
+	if err != nil {
+		return false, "", err
+	}
+	pCtx.IsUTF8 = true
+
+	texts := make([]string, 0)
+	for _, one := range removed {
+		a.log.Infoln("AdClean Removed:", one.Rule, one.StartTime, one.Text)
+		texts = append(texts, one.Text)
+	}
+	return true, truncateMessage(fmt.Sprintf("removed %d dialogues: %s", len(removed), strings.Join(texts, " | "))), nil
+}
+
+// truncateMessage 记录到任务历史中的信息不需要太长
+func truncateMessage(message string) string {
+
+	runes := []rune(message)
+	if len(runes) > maxMessageLen {
+		return string(runes[:maxMessageLen]) + "..."
+	}
+	return message
+}
+
+const maxMessageLen = 512
@@ -0,0 +1,48 @@
+package sub_post_process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_fonts"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/sirupsen/logrus"
+)
+
+// AssFontsProcessor 检查 ass/ssa 字幕使用的字体，按照设置输出到 fonts 目录或者写入 mkv，缺少的字体记录下来
+type AssFontsProcessor struct {
+	log *logrus.Logger
+}
+
+func NewAssFontsProcessor(log *logrus.Logger) *AssFontsProcessor {
+	return &AssFontsProcessor{log: log}
+}
+
+func (a *AssFontsProcessor) GetStageName() string {
+	return settings.SubPostProcessStageAssFonts
+}
+
+func (a *AssFontsProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	fontsSettings := settings.Get().AdvancedSettings.AssFontsSettings
+	if fontsSettings == nil || fontsSettings.Enable == false {
+		return false, "ass_fonts is false", nil
+	}
+	if ass_style_normalizer.IsAssOrSsa(pCtx.SubFPath) == false {
+		return false, "not ass or ssa", nil
+	}
+	report, err := ass_fonts.NewChecker(a.log, fontsSettings).Check(pCtx.VideoFPath, pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	if len(report.Fonts) == 0 {
+		return false, "no fonts used", nil
+	}
+	message := fmt.Sprintf("fonts: %d, missing: %d, output: %d", len(report.Fonts), len(report.MissingFonts), len(report.OutputFiles))
+	if len(report.MissingFonts) > 0 {
+		message += ", missing fonts: " + strings.Join(report.MissingFonts, ", ")
+	}
+	return true, truncateMessage(message), nil
+}
@@ -0,0 +1,40 @@
+package sub_post_process
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+)
+
+// AssStyleProcessor ass/ssa 字幕的样式统一
+type AssStyleProcessor struct {
+}
+
+func NewAssStyleProcessor() *AssStyleProcessor {
+	return &AssStyleProcessor{}
+}
+
+func (a *AssStyleProcessor) GetStageName() string {
+	return settings.SubPostProcessStageAssStyle
+}
+
+func (a *AssStyleProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	styleSettings := settings.Get().AdvancedSettings.AssStyleSettings
+	if styleSettings == nil || styleSettings.Enable == false {
+		return false, "ass_style is false", nil
+	}
+	if ass_style_normalizer.IsAssOrSsa(pCtx.SubFPath) == false {
+		return false, "not ass or ssa", nil
+	}
+	changed, err := ass_style_normalizer.NormalizeFile(pCtx.SubFPath, styleSettings)
+	if err != nil {
+		return false, "", err
+	}
+	if changed == false {
+		return false, "nothing to change", nil
+	}
+	// 读取的时候已经转换为 UTF-8 了
+	pCtx.IsUTF8 = true
+	return true, "", nil
+}
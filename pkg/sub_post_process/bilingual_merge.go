@@ -0,0 +1,349 @@
+package sub_post_process
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	language2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	BilingualMergeProcessor 把中文字幕与一个外语的参考字幕合并为双语字幕，外语的台词放在中文的下面
+	1. 参考字幕是视频旁边同名的非中文字幕，比如 Foo.en.srt，优先使用英文的，没有的话使用下载时保存的参考字幕（见 SaveReferenceSub）
+	2. 每一句外语的台词，放到时间上重叠最多的那一句中文台词下面，重叠不到这句外语台词时长的一半就丢掉
+	3. 已经是双语的字幕、forced 字幕不处理
+	srt 会重新生成，ass/ssa 只在 Dialogue 的台词后面追加，保留原有的样式
+*/
+type BilingualMergeProcessor struct {
+	subParserHub *sub_parser_hub.SubParserHub
+}
+
+func NewBilingualMergeProcessor(log *logrus.Logger) *BilingualMergeProcessor {
+	return &BilingualMergeProcessor{
+		subParserHub: sub_parser_hub.NewSubParserHub(log, ass.NewParser(log), srt.NewParser(log)),
+	}
+}
+
+func (b *BilingualMergeProcessor) GetStageName() string {
+	return settings.SubPostProcessStageBilingualMerge
+}
+
+func (b *BilingualMergeProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	if pCtx.SubFileInfo.IsForced == true {
+		return false, "forced sub", nil
+	}
+	bFind, subFileInfo, err := b.subParserHub.DetermineFileTypeFromFile(pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	if bFind == false {
+		return false, "not support sub type", nil
+	}
+	if language.HasChineseLang(subFileInfo.Lang) == false {
+		return false, "not Chinese sub", nil
+	}
+	if language.IsBilingualSubtitle(subFileInfo.Lang) == true {
+		return false, "already bilingual", nil
+	}
+	refSubFileInfo := b.findReferenceSub(pCtx)
+	if refSubFileInfo == nil {
+		return false, "no reference sub", nil
+	}
+	refDialogues, err := toTimedDialogues(refSubFileInfo.Dialogues)
+	if err != nil {
+		return false, "", err
+	}
+
+	var newContent string
+	var merged int
+	if ass_style_normalizer.IsAssOrSsa(pCtx.SubFPath) == true {
+		newContent, merged, err = mergeAss(subFileInfo.Content, refDialogues)
+	} else {
+		newContent, merged, err = mergeSrt(subFileInfo, refDialogues)
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if merged == 0 {
+		return false, "no dialogue matched " + filepath.Base(refSubFileInfo.FileFullPath), nil
+	}
+	// 解析的时候已经转换为 UTF-8 了
+	err = pkg.WriteFile(pCtx.SubFPath, []byte(newContent))
+	if err != nil {
+		return false, "", err
+	}
+	pCtx.IsUTF8 = true
+	return true, fmt.Sprintf("%s, %d dialogues", filepath.Base(refSubFileInfo.FileFullPath), merged), nil
+}
+
+// findReferenceSub 视频旁边同名的非中文字幕，优先使用英文的，没有的话使用下载时保存的参考字幕，都没有返回 nil
+func (b *BilingualMergeProcessor) findReferenceSub(pCtx *sub_post_process.Context) *subparser.FileInfo {
+
+	found := b.findSiblingReferenceSub(pCtx)
+	if found != nil {
+		return found
+	}
+	refSubFPath, err := getReferenceSubFPath(pCtx.VideoFPath)
+	if err != nil || refSubFPath == "" {
+		return nil
+	}
+	bFind, subFileInfo, err := b.subParserHub.DetermineFileTypeFromFile(refSubFPath)
+	if err != nil || bFind == false {
+		return nil
+	}
+	return subFileInfo
+}
+
+// findSiblingReferenceSub 视频旁边同名的非中文字幕，优先使用英文的，没有返回 nil
+func (b *BilingualMergeProcessor) findSiblingReferenceSub(pCtx *sub_post_process.Context) *subparser.FileInfo {
+
+	videoDir := filepath.Dir(pCtx.VideoFPath)
+	videoName := strings.TrimSuffix(filepath.Base(pCtx.VideoFPath), filepath.Ext(pCtx.VideoFPath))
+	files, err := os.ReadDir(videoDir)
+	if err != nil {
+		return nil
+	}
+	var found *subparser.FileInfo
+	for _, file := range files {
+		if file.IsDir() == true || strings.HasPrefix(file.Name(), videoName) == false || sub_parser_hub.IsSubExtWanted(file.Name()) == false {
+			continue
+		}
+		subFPath := filepath.Join(videoDir, file.Name())
+		if subFPath == pCtx.SubFPath {
+			continue
+		}
+		bFind, subFileInfo, err := b.subParserHub.DetermineFileTypeFromFile(subFPath)
+		if err != nil || bFind == false {
+			continue
+		}
+		if subFileInfo.Lang == language2.Unknown || language.HasChineseLang(subFileInfo.Lang) == true {
+			continue
+		}
+		if subFileInfo.Lang == language2.English {
+			return subFileInfo
+		}
+		if found == nil {
+			found = subFileInfo
+		}
+	}
+	return found
+}
+
+/*
+	SaveReferenceSub 下载时找到的非中文字幕不参与评分，保存到参考字幕的缓存中，给双语合并使用
+	每个视频只保留一个参考字幕，以视频的全路径区分，新的会替换旧的
+*/
+func SaveReferenceSub(videoFPath string, refSubFile *subparser.FileInfo) error {
+
+	if refSubFile == nil {
+		return nil
+	}
+	rootFolder, err := pkg.GetReferenceSubCacheFolder()
+	if err != nil {
+		return err
+	}
+	err = removeReferenceSub(rootFolder, videoFPath)
+	if err != nil {
+		return err
+	}
+	return pkg.WriteFile(filepath.Join(rootFolder, getReferenceSubName(videoFPath)+refSubFile.Ext), refSubFile.Data)
+}
+
+// getReferenceSubFPath 这个视频保存的参考字幕的全路径，没有则返回空
+func getReferenceSubFPath(videoFPath string) (string, error) {
+
+	rootFolder, err := pkg.GetReferenceSubCacheFolder()
+	if err != nil {
+		return "", err
+	}
+	matches, err := filepath.Glob(filepath.Join(rootFolder, getReferenceSubName(videoFPath)+".*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) < 1 {
+		return "", nil
+	}
+	return matches[0], nil
+}
+
+// removeReferenceSub 删除这个视频之前保存的参考字幕，字幕的格式可能不同
+func removeReferenceSub(rootFolder, videoFPath string) error {
+
+	matches, err := filepath.Glob(filepath.Join(rootFolder, getReferenceSubName(videoFPath)+".*"))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		err = os.Remove(match)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getReferenceSubName 参考字幕的文件名（不含后缀名），视频全路径的 sha256 的前 16 位
+func getReferenceSubName(videoFPath string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(videoFPath)))[:16]
+}
+
+// timedDialogue 解析了开始、结束时间的一句台词，text 是去掉了 ass 标签的纯文本，多行使用 \n 分隔
+type timedDialogue struct {
+	start time.Duration
+	end   time.Duration
+	text  string
+}
+
+func toTimedDialogues(dialogues []subparser.OneDialogue) ([]timedDialogue, error) {
+
+	timedDialogues := make([]timedDialogue, 0, len(dialogues))
+	for _, dialogue := range dialogues {
+		text := assText2SrtText(strings.Join(dialogue.Lines, `\N`))
+		if text == "" {
+			continue
+		}
+		start, end, err := parseDialogueTime(dialogue.StartTime, dialogue.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		timedDialogues = append(timedDialogues, timedDialogue{start: start, end: end, text: text})
+	}
+	return timedDialogues, nil
+}
+
+func parseDialogueTime(startTime, endTime string) (time.Duration, time.Duration, error) {
+
+	start, err := pkg.ParseTime(strings.TrimSpace(startTime))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := pkg.ParseTime(strings.TrimSpace(endTime))
+	if err != nil {
+		return 0, 0, err
+	}
+	zero := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	return start.Sub(zero), end.Sub(zero), nil
+}
+
+// matchReference 每一句参考字幕的台词放到重叠最多的那一句中文台词下面，返回每一句中文台词对应的参考台词
+func matchReference(chDialogues []timedDialogue, refDialogues []timedDialogue) map[int][]string {
+
+	matched := make(map[int][]string)
+	for _, ref := range refDialogues {
+		bestIndex := -1
+		var bestOverlap time.Duration
+		for i, ch := range chDialogues {
+			overlap := minDuration(ch.end, ref.end) - maxDuration(ch.start, ref.start)
+			if overlap > bestOverlap {
+				bestOverlap = overlap
+				bestIndex = i
+			}
+		}
+		if bestIndex < 0 || bestOverlap*2 < ref.end-ref.start {
+			continue
+		}
+		matched[bestIndex] = append(matched[bestIndex], ref.text)
+	}
+	return matched
+}
+
+// mergeSrt 重新生成 srt，外语的台词放在中文的下面
+func mergeSrt(subFileInfo *subparser.FileInfo, refDialogues []timedDialogue) (string, int, error) {
+
+	subFileInfo.SortDialogues()
+	chDialogues, err := toTimedDialogues(subFileInfo.Dialogues)
+	if err != nil {
+		return "", 0, err
+	}
+	matched := matchReference(chDialogues, refDialogues)
+	var sb strings.Builder
+	for i, ch := range chDialogues {
+		text := ch.text
+		if refTexts, found := matched[i]; found == true {
+			text += "\n" + strings.Join(refTexts, "\n")
+		}
+		sb.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n\n", i+1,
+			formatSrtTime(ch.start), formatSrtTime(ch.end), text))
+	}
+	return sb.String(), len(matched), nil
+}
+
+/*
+	mergeAss 在 ass/ssa 的 Dialogue 台词后面追加外语的台词，其他的行保持原样
+	Dialogue: Layer,Start,End,Style,Name,MarginL,MarginR,MarginV,Effect,Text
+*/
+func mergeAss(content string, refDialogues []timedDialogue) (string, int, error) {
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r", ""), "\n")
+	chDialogues := make([]timedDialogue, 0)
+	chLineIndexes := make([]int, 0)
+	for i, line := range lines {
+		if strings.HasPrefix(line, assDialoguePrefix) == false {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimPrefix(line, assDialoguePrefix), ",", assDialogueFieldCount)
+		if len(fields) != assDialogueFieldCount {
+			continue
+		}
+		text := assText2SrtText(fields[assDialogueFieldCount-1])
+		if text == "" {
+			// 只有特效标签的对白
+			continue
+		}
+		start, end, err := parseDialogueTime(fields[1], fields[2])
+		if err != nil {
+			return "", 0, err
+		}
+		chDialogues = append(chDialogues, timedDialogue{start: start, end: end, text: text})
+		chLineIndexes = append(chLineIndexes, i)
+	}
+	if len(chDialogues) == 0 {
+		return "", 0, errors.New("no dialogue to merge")
+	}
+	matched := matchReference(chDialogues, refDialogues)
+	for i, refTexts := range matched {
+		refText := strings.ReplaceAll(strings.Join(refTexts, "\n"), "\n", `\N`)
+		lines[chLineIndexes[i]] += `\N` + refText
+	}
+	return strings.Join(lines, "\n"), len(matched), nil
+}
+
+func formatSrtTime(d time.Duration) string {
+	return time.Time{}.Add(d).Format(common.TimeFormatPoint3)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+const (
+	assDialoguePrefix     = "Dialogue:"
+	assDialogueFieldCount = 10
+)
@@ -0,0 +1,75 @@
+package sub_post_process
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ffmpeg_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	language2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	EmbedProcessor 把字幕作为一条字幕轨道封装进 mkv，外挂的字幕文件保留，给不读取外挂字幕的播放器使用
+	需要重写整个视频文件，视频所在的磁盘需要有足够的空间，所以默认不启用，只支持 mkv
+	之前封装进去的字幕轨道会被替换掉，主字幕与 forced 字幕各自一条
+*/
+type EmbedProcessor struct {
+	ffmpegHelper *ffmpeg_helper.FFMPEGHelper
+}
+
+func NewEmbedProcessor(log *logrus.Logger) *EmbedProcessor {
+	return &EmbedProcessor{ffmpegHelper: ffmpeg_helper.NewFFMPEGHelper(log)}
+}
+
+func (e *EmbedProcessor) GetStageName() string {
+	return settings.SubPostProcessStageEmbed
+}
+
+func (e *EmbedProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	if strings.ToLower(filepath.Ext(pCtx.VideoFPath)) != ".mkv" {
+		return false, "not mkv", nil
+	}
+	if pkg.IsFile(pCtx.VideoFPath) == false {
+		return false, "video file not found", nil
+	}
+	title := embedSubTitle
+	if pCtx.SubFileInfo.IsForced == true {
+		title = embedForcedSubTitle
+	}
+	lang := lang2ISO6392(pCtx.SubFileInfo.Lang)
+	err := e.ffmpegHelper.EmbedSubtitle2MKV(pCtx.VideoFPath, pCtx.SubFPath, lang, title, pCtx.SubFileInfo.IsForced)
+	if err != nil {
+		return false, "", err
+	}
+	return true, title + ", " + lang, nil
+}
+
+// lang2ISO6392 mkv 中字幕轨道的语言使用 ISO 639-2，双语的字幕按第一语言
+func lang2ISO6392(lang language2.MyLanguage) string {
+
+	switch lang {
+	case language2.ChineseSimple, language2.ChineseTraditional,
+		language2.ChineseSimpleEnglish, language2.ChineseTraditionalEnglish,
+		language2.ChineseSimpleJapanese, language2.ChineseTraditionalJapanese,
+		language2.ChineseSimpleKorean, language2.ChineseTraditionalKorean:
+		return "chi"
+	case language2.English:
+		return "eng"
+	case language2.Japanese:
+		return "jpn"
+	case language2.Korean:
+		return "kor"
+	default:
+		return "und"
+	}
+}
+
+const (
+	embedSubTitle       = "ChineseSubFinder"
+	embedForcedSubTitle = "ChineseSubFinder Forced"
+)
@@ -0,0 +1,110 @@
+package sub_post_process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	FormatConvertProcessor 把 ass/ssa 字幕转换为 srt，给不支持 ass 的播放器使用
+	样式、特效会丢失，对白中的 {\xxx} 标签会被去掉，所以默认不启用，需要在步骤中打开
+	转换后删除原来的 ass/ssa 字幕，后面的步骤处理的是 srt
+*/
+type FormatConvertProcessor struct {
+	subParserHub *sub_parser_hub.SubParserHub
+}
+
+func NewFormatConvertProcessor(log *logrus.Logger) *FormatConvertProcessor {
+	return &FormatConvertProcessor{
+		subParserHub: sub_parser_hub.NewSubParserHub(log, ass.NewParser(log)),
+	}
+}
+
+func (f *FormatConvertProcessor) GetStageName() string {
+	return settings.SubPostProcessStageFormatConvert
+}
+
+func (f *FormatConvertProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	if ass_style_normalizer.IsAssOrSsa(pCtx.SubFPath) == false {
+		return false, "not ass or ssa", nil
+	}
+	bFind, subFileInfo, err := f.subParserHub.DetermineFileTypeFromFile(pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	if bFind == false {
+		return false, "not support sub type", nil
+	}
+	subFileInfo.SortDialogues()
+	var sb strings.Builder
+	index := 1
+	for _, dialogue := range subFileInfo.Dialogues {
+		text := assText2SrtText(strings.Join(dialogue.Lines, `\N`))
+		if text == "" {
+			// 只有特效标签的对白
+			continue
+		}
+		startTime, err := pkg.ParseTime(dialogue.StartTime)
+		if err != nil {
+			return false, "", err
+		}
+		endTime, err := pkg.ParseTime(dialogue.EndTime)
+		if err != nil {
+			return false, "", err
+		}
+		sb.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index,
+			startTime.Format(common.TimeFormatPoint3), endTime.Format(common.TimeFormatPoint3), text))
+		index++
+	}
+	if index == 1 {
+		return false, "", errors.New("no dialogue to convert")
+	}
+
+	desSubFPath := strings.TrimSuffix(pCtx.SubFPath, filepath.Ext(pCtx.SubFPath)) + common.SubExtSRT
+	// 解析的时候已经转换为 UTF-8 了
+	err = pkg.WriteFile(desSubFPath, []byte(sb.String()))
+	if err != nil {
+		return false, "", err
+	}
+	err = os.Remove(pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	oldExt := filepath.Ext(pCtx.SubFPath)
+	pCtx.SubFPath = desSubFPath
+	pCtx.IsUTF8 = true
+	return true, fmt.Sprintf("%s to %s, %d dialogues", oldExt, common.SubExtSRT, index-1), nil
+}
+
+// assText2SrtText 去掉 ass 对白中的 {\xxx} 标签，\N \n 换为换行
+func assText2SrtText(text string) string {
+
+	text = regAssTag.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, `\N`, "\n")
+	text = strings.ReplaceAll(text, `\n`, "\n")
+	text = strings.ReplaceAll(text, `\h`, " ")
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var regAssTag = regexp.MustCompile(`\{[^}]*\}`)
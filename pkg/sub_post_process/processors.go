@@ -0,0 +1,100 @@
+package sub_post_process
+
+import (
+	"errors"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/change_file_encode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/chs_cht_changer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_rules"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+)
+
+// TimelineFixProcessor 字幕时间轴校正，视频库的扫描规则中可能覆盖了全局的设置
+type TimelineFixProcessor struct {
+	subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx
+}
+
+func NewTimelineFixProcessor(subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx) *TimelineFixProcessor {
+	return &TimelineFixProcessor{subTimelineFixerHelperEx: subTimelineFixerHelperEx}
+}
+
+func (t *TimelineFixProcessor) GetStageName() string {
+	return settings.SubPostProcessStageTimelineFix
+}
+
+func (t *TimelineFixProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	if scan_rules.GetFixTimeLine(pCtx.VideoFPath) == false {
+		return false, "fix_time_line is false", nil
+	}
+	// forced 字幕的对白太少了，无法进行时间轴的校正
+	if pCtx.SubFileInfo.IsForced == true {
+		return false, "forced sub", nil
+	}
+	if t.subTimelineFixerHelperEx == nil {
+		return false, "", errors.New("SubTimelineFixerHelperEx is nil")
+	}
+	err := t.subTimelineFixerHelperEx.Process(pCtx.VideoFPath, pCtx.SubFPath)
+	if err != nil {
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+// ChangeEncodeProcessor 字幕编码转换
+type ChangeEncodeProcessor struct {
+}
+
+func NewChangeEncodeProcessor() *ChangeEncodeProcessor {
+	return &ChangeEncodeProcessor{}
+}
+
+func (c *ChangeEncodeProcessor) GetStageName() string {
+	return settings.SubPostProcessStageChangeEncode
+}
+
+func (c *ChangeEncodeProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	autoChangeSubEncode := settings.Get().ExperimentalFunction.AutoChangeSubEncode
+	if autoChangeSubEncode.Enable == false {
+		return false, "auto_change_sub_encode is false", nil
+	}
+	err := change_file_encode.Process(pCtx.SubFPath, autoChangeSubEncode.DesEncodeType)
+	if err != nil {
+		return false, "", err
+	}
+	pCtx.IsUTF8 = autoChangeSubEncode.DesEncodeType == 0
+	return true, "to " + autoChangeSubEncode.GetDesEncodeType(), nil
+}
+
+// ChsChtChangeProcessor 简繁转换，视频库的扫描规则中可能覆盖了全局的设置
+type ChsChtChangeProcessor struct {
+}
+
+func NewChsChtChangeProcessor() *ChsChtChangeProcessor {
+	return &ChsChtChangeProcessor{}
+}
+
+func (c *ChsChtChangeProcessor) GetStageName() string {
+	return settings.SubPostProcessStageChsChtChange
+}
+
+func (c *ChsChtChangeProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+
+	chsChtChanger := scan_rules.GetChsChtChanger(pCtx.VideoFPath)
+	if chsChtChanger.Enable == false {
+		return false, "chs_cht_changer is false", nil
+	}
+	// 一定得是 UTF-8 才能够执行简繁转换，需要在这个步骤之前转换为 UTF-8
+	// 测试了先转 UTF-8 进行简繁转换然后再转 GBK，有些时候会出错，所以还是不支持这样先
+	if pCtx.IsUTF8 == false {
+		return false, "sub is not converted to UTF-8 before this stage", nil
+	}
+	err := chs_cht_changer.Process(pCtx.SubFPath, chsChtChanger.DesChineseLanguageType)
+	if err != nil {
+		return false, "", err
+	}
+	return true, "to " + chsChtChanger.GetDesChineseLanguageTypeString(), nil
+}
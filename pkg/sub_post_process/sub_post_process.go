@@ -0,0 +1,136 @@
+package sub_post_process
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_timeline_fixer"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+	"github.com/sirupsen/logrus"
+)
+
+// Pipeline 字幕写入后的处理流水线，步骤的顺序以及是否启用由 settings.SubPostProcessSettings 决定
+type Pipeline struct {
+	log        *logrus.Logger
+	processors map[string]ifaces.ISubPostProcessor // Key 是步骤的名称
+}
+
+func NewPipeline(log *logrus.Logger, processors ...ifaces.ISubPostProcessor) *Pipeline {
+
+	p := Pipeline{log: log, processors: make(map[string]ifaces.ISubPostProcessor)}
+	for _, processor := range processors {
+		p.processors[processor.GetStageName()] = processor
+	}
+	return &p
+}
+
+// NewDefaultPipeline 注册了所有内置步骤的流水线
+func NewDefaultPipeline(log *logrus.Logger, subTimelineFixerHelperEx *sub_timeline_fixer.SubTimelineFixerHelperEx) *Pipeline {
+
+	return NewPipeline(log,
+		NewAdCleanProcessor(log),
+		NewTimelineFixProcessor(subTimelineFixerHelperEx),
+		NewBilingualMergeProcessor(log),
+		NewAssStyleProcessor(),
+		NewAssFontsProcessor(log),
+		NewFormatConvertProcessor(log),
+		NewChangeEncodeProcessor(),
+		NewChsChtChangeProcessor(),
+		NewEmbedProcessor(log),
+	)
+}
+
+/*
+	Process 按设置的顺序执行每一个步骤，返回每个步骤的结果以及处理后字幕的全路径
+	某一个步骤失败了只会记录下来，后面的步骤继续执行，字幕文件已经写入了，不会因此丢掉
+*/
+func (p *Pipeline) Process(videoFPath, subFPath string, subFileInfo subparser.FileInfo) ([]sub_post_process.StageResult, string) {
+
+	pCtx := &sub_post_process.Context{
+		VideoFPath:  videoFPath,
+		SubFPath:    subFPath,
+		SubFileInfo: subFileInfo,
+	}
+	results := make([]sub_post_process.StageResult, 0)
+	for _, stage := range p.getStages() {
+
+		result := sub_post_process.StageResult{Stage: stage.Name}
+		processor, found := p.processors[stage.Name]
+		if found == false {
+			result.Status = sub_post_process.StatusFailure
+			result.Message = fmt.Sprintf("not support sub post process stage: %s", stage.Name)
+		} else if stage.Enable == false {
+			result.Status = sub_post_process.StatusSkip
+			result.Message = "stage disabled"
+		} else {
+			startTime := time.Now()
+			processed, message, err := processor.Process(pCtx)
+			result.DurationMs = time.Since(startTime).Milliseconds()
+			if err != nil {
+				result.Status = sub_post_process.StatusFailure
+				result.Message = err.Error()
+			} else if processed == false {
+				result.Status = sub_post_process.StatusSkip
+				result.Message = message
+			} else {
+				result.Status = sub_post_process.StatusSuccess
+				result.Message = message
+			}
+		}
+
+		if result.Status == sub_post_process.StatusFailure {
+			p.log.Errorln("SubPostProcess", result.Stage, "Failed:", pCtx.SubFPath, result.Message)
+		} else if result.Status == sub_post_process.StatusSuccess {
+			p.log.Infoln("SubPostProcess", result.Stage, "Done:", pCtx.SubFPath, result.Message)
+		} else {
+			p.log.Debugln("SubPostProcess", result.Stage, "Skip:", pCtx.SubFPath, result.Message)
+		}
+		results = append(results, result)
+	}
+	return results, pCtx.SubFPath
+}
+
+func (p *Pipeline) getStages() []settings.SubPostProcessStage {
+
+	postProcessSettings := settings.Get().AdvancedSettings.SubPostProcessSettings
+	if postProcessSettings == nil {
+		postProcessSettings = settings.NewSubPostProcessSettings()
+	}
+	return postProcessSettings.Stages
+}
+
+// SaveRecords 把一个字幕的处理结果记录到任务的历史中
+func SaveRecords(log *logrus.Logger, jobID, videoFPath, subFPath string, results []sub_post_process.StageResult) {
+
+	for _, result := range results {
+		record := models.SubPostProcessRecord{
+			JobID:      jobID,
+			VideoFPath: videoFPath,
+			SubFPath:   subFPath,
+			Stage:      result.Stage,
+			Status:     result.Status,
+			Message:    result.Message,
+			DurationMs: result.DurationMs,
+		}
+		err := dao.GetDb().Create(&record).Error
+		if err != nil {
+			log.Errorln("SubPostProcess", result.Stage, "Save Record Error:", err)
+		}
+	}
+}
+
+// GetRecords 获取一个任务的字幕处理记录，最新的在前面
+func GetRecords(jobID string) ([]models.SubPostProcessRecord, error) {
+
+	records := make([]models.SubPostProcessRecord, 0)
+	err := dao.GetDb().Where("job_id = ?", jobID).Order("id desc").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,352 @@
+package sub_post_process
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_post_process"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+type fakeProcessor struct {
+	name      string
+	processed bool
+	err       error
+	called    int
+}
+
+func (f *fakeProcessor) GetStageName() string {
+	return f.name
+}
+
+func (f *fakeProcessor) Process(pCtx *sub_post_process.Context) (bool, string, error) {
+	f.called++
+	return f.processed, "", f.err
+}
+
+func TestPipeline_Process(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	settings.Get().AdvancedSettings.SubPostProcessSettings = &settings.SubPostProcessSettings{
+		Stages: []settings.SubPostProcessStage{
+			{Name: "failed", Enable: true},
+			{Name: "not_exist", Enable: true},
+			{Name: "disabled", Enable: false},
+			{Name: "skipped", Enable: true},
+			{Name: "done", Enable: true},
+		},
+	}
+	defer func() {
+		settings.Get().AdvancedSettings.SubPostProcessSettings = settings.NewSubPostProcessSettings()
+	}()
+
+	failed := &fakeProcessor{name: "failed", err: errors.New("failed")}
+	disabled := &fakeProcessor{name: "disabled", processed: true}
+	skipped := &fakeProcessor{name: "skipped"}
+	done := &fakeProcessor{name: "done", processed: true}
+	pipeline := NewPipeline(log_helper.GetLogger4Tester(), failed, disabled, skipped, done)
+
+	results, subFPath := pipeline.Process("/movies/Foo/Foo.mkv", "/movies/Foo/Foo.ass", subparser.FileInfo{})
+	if subFPath != "/movies/Foo/Foo.ass" {
+		t.Fatalf("subFPath = %v", subFPath)
+	}
+	wantStatus := []string{
+		sub_post_process.StatusFailure,
+		sub_post_process.StatusFailure,
+		sub_post_process.StatusSkip,
+		sub_post_process.StatusSkip,
+		sub_post_process.StatusSuccess,
+	}
+	if len(results) != len(wantStatus) {
+		t.Fatalf("results = %+v", results)
+	}
+	for i, result := range results {
+		if result.Status != wantStatus[i] {
+			t.Errorf("results[%d] = %+v, want status %v", i, result, wantStatus[i])
+		}
+	}
+	// 失败的步骤不会中断后面的步骤，没有启用的步骤不会被调用
+	if disabled.called != 0 || done.called != 1 {
+		t.Fatalf("disabled.called = %v, done.called = %v", disabled.called, done.called)
+	}
+}
+
+func TestPipeline_ChsChtNeedUTF8(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	settings.Get().ExperimentalFunction.AutoChangeSubEncode = settings.AutoChangeSubEncode{Enable: true, DesEncodeType: 0}
+	settings.Get().ExperimentalFunction.ChsChtChanger = settings.ChsChtChanger{Enable: true, DesChineseLanguageType: 0}
+	defer func() {
+		settings.Get().ExperimentalFunction.AutoChangeSubEncode = settings.AutoChangeSubEncode{}
+		settings.Get().ExperimentalFunction.ChsChtChanger = settings.ChsChtChanger{}
+		settings.Get().AdvancedSettings.SubPostProcessSettings = settings.NewSubPostProcessSettings()
+	}()
+
+	subFPath := filepath.Join(t.TempDir(), "Foo.srt")
+	writeSub := func() {
+		err := os.WriteFile(subFPath, []byte("1\n00:00:01,000 --> 00:00:02,000\n這是繁體字幕\n"), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pipeline := NewPipeline(log_helper.GetLogger4Tester(), NewChangeEncodeProcessor(), NewChsChtChangeProcessor())
+
+	// 简繁转换在编码转换之前，还不能确定是 UTF-8，跳过
+	writeSub()
+	settings.Get().AdvancedSettings.SubPostProcessSettings = &settings.SubPostProcessSettings{
+		Stages: []settings.SubPostProcessStage{
+			{Name: settings.SubPostProcessStageChsChtChange, Enable: true},
+			{Name: settings.SubPostProcessStageChangeEncode, Enable: true},
+		},
+	}
+	results, _ := pipeline.Process("Foo.mkv", subFPath, subparser.FileInfo{})
+	if results[0].Status != sub_post_process.StatusSkip || results[1].Status != sub_post_process.StatusSuccess {
+		t.Fatalf("results = %+v", results)
+	}
+
+	writeSub()
+	settings.Get().AdvancedSettings.SubPostProcessSettings = settings.NewSubPostProcessSettings()
+	results, _ = pipeline.Process("Foo.mkv", subFPath, subparser.FileInfo{})
+	for _, result := range results {
+		if result.Stage == settings.SubPostProcessStageChsChtChange && result.Status != sub_post_process.StatusSuccess {
+			t.Fatalf("results = %+v", results)
+		}
+	}
+	fBytes, err := os.ReadFile(subFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fBytes) != "1\n00:00:01,000 --> 00:00:02,000\n这是繁体字幕\n" {
+		t.Fatalf("sub = %v", string(fBytes))
+	}
+}
+
+func TestFormatConvertProcessor(t *testing.T) {
+
+	subFPath := filepath.Join(t.TempDir(), "Foo.chinese(简).ass")
+	assContent := `[Script Info]
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:03.50,0:00:04.00,Default,,0,0,0,,第二句
+Dialogue: 0,0:00:01.00,0:00:02.25,Default,,0,0,0,,{\fn微软雅黑\b1}第一句\N第一行
+Dialogue: 0,0:00:05.00,0:00:06.00,Default,,0,0,0,,{\p1}
+`
+	err := os.WriteFile(subFPath, []byte(assContent), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pCtx := &sub_post_process.Context{VideoFPath: "Foo.mkv", SubFPath: subFPath}
+	processed, _, err := NewFormatConvertProcessor(log_helper.GetLogger4Tester()).Process(pCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed == false || pCtx.IsUTF8 == false {
+		t.Fatalf("processed = %v, IsUTF8 = %v", processed, pCtx.IsUTF8)
+	}
+	if pCtx.SubFPath != filepath.Join(filepath.Dir(subFPath), "Foo.chinese(简).srt") {
+		t.Fatalf("SubFPath = %v", pCtx.SubFPath)
+	}
+	if _, err = os.Stat(subFPath); os.IsNotExist(err) == false {
+		t.Fatalf("ass sub should be removed")
+	}
+	fBytes, err := os.ReadFile(pCtx.SubFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,250\n第一句\n第一行\n\n2\n00:00:03,500 --> 00:00:04,000\n第二句\n\n"
+	if string(fBytes) != want {
+		t.Fatalf("sub = %q, want %q", string(fBytes), want)
+	}
+}
+
+func TestBilingualMergeProcessor(t *testing.T) {
+
+	videoDir := t.TempDir()
+	videoFPath := filepath.Join(videoDir, "Foo.mkv")
+	writeFile := func(fileName, content string) string {
+		fileFPath := filepath.Join(videoDir, fileName)
+		err := os.WriteFile(fileFPath, []byte(content), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fileFPath
+	}
+	writeFile("Foo.en.srt", `1
+00:00:01,000 --> 00:00:02,000
+How are you today?
+
+2
+00:00:03,000 --> 00:00:04,000
+I am fine, thank you very much.
+
+3
+00:00:10,000 --> 00:00:11,000
+Nobody is talking here at all.
+`)
+	processor := NewBilingualMergeProcessor(log_helper.GetLogger4Tester())
+
+	srtFPath := writeFile("Foo.chinese(简).srt", `1
+00:00:01,100 --> 00:00:02,100
+你今天怎么样？
+
+2
+00:00:03,000 --> 00:00:04,200
+我很好，非常感谢你。
+`)
+	pCtx := &sub_post_process.Context{VideoFPath: videoFPath, SubFPath: srtFPath}
+	processed, message, err := processor.Process(pCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed == false {
+		t.Fatalf("processed = false, message = %v", message)
+	}
+	fBytes, err := os.ReadFile(srtFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,100 --> 00:00:02,100\n你今天怎么样？\nHow are you today?\n\n2\n00:00:03,000 --> 00:00:04,200\n我很好，非常感谢你。\nI am fine, thank you very much.\n\n"
+	if string(fBytes) != want {
+		t.Fatalf("sub = %q, want %q", string(fBytes), want)
+	}
+
+	assFPath := writeFile("Foo.chinese(简).ass", `[Script Info]
+ScriptType: v4.00+
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.10,0:00:02.10,Default,,0,0,0,,{\b1}你今天怎么样？
+Dialogue: 0,0:00:03.00,0:00:04.20,Default,,0,0,0,,我很好，非常感谢你。
+`)
+	pCtx = &sub_post_process.Context{VideoFPath: videoFPath, SubFPath: assFPath}
+	processed, message, err = processor.Process(pCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed == false {
+		t.Fatalf("processed = false, message = %v", message)
+	}
+	fBytes, err = os.ReadFile(assFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(fBytes), `Dialogue: 0,0:00:01.10,0:00:02.10,Default,,0,0,0,,{\b1}你今天怎么样？\NHow are you today?`) == false ||
+		strings.Contains(string(fBytes), `我很好，非常感谢你。\NI am fine, thank you very much.`) == false {
+		t.Fatalf("sub = %v", string(fBytes))
+	}
+
+	// 已经是双语的字幕不再合并
+	processed, _, err = processor.Process(pCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed == true {
+		t.Fatalf("bilingual sub should be skipped")
+	}
+}
+
+// TestBilingualMergeProcessor_ReferenceCache 视频旁边没有外语字幕的时候，使用下载时保存的参考字幕
+func TestBilingualMergeProcessor_ReferenceCache(t *testing.T) {
+
+	nowWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(nowWd)
+	}()
+
+	videoDir := t.TempDir()
+	videoFPath := filepath.Join(videoDir, "Foo.mkv")
+	refData := []byte("1\n00:00:01,000 --> 00:00:02,000\nHow are you today?\n\n")
+	err = SaveReferenceSub(videoFPath, &subparser.FileInfo{Ext: ".srt", Data: refData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srtFPath := filepath.Join(videoDir, "Foo.chinese(简).srt")
+	err = os.WriteFile(srtFPath, []byte("1\n00:00:01,100 --> 00:00:02,100\n你今天怎么样？\n\n"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processor := NewBilingualMergeProcessor(log_helper.GetLogger4Tester())
+	processed, message, err := processor.Process(&sub_post_process.Context{VideoFPath: videoFPath, SubFPath: srtFPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed == false {
+		t.Fatalf("processed = false, message = %v", message)
+	}
+	fBytes, err := os.ReadFile(srtFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(fBytes), "你今天怎么样？\nHow are you today?") == false {
+		t.Fatalf("sub = %q", string(fBytes))
+	}
+
+	// 新的参考字幕替换旧的
+	err = SaveReferenceSub(videoFPath, &subparser.FileInfo{Ext: ".ass", Data: []byte("[Script Info]")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refSubFPath, err := getReferenceSubFPath(videoFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(refSubFPath) != ".ass" {
+		t.Fatalf("reference sub = %v, want .ass", refSubFPath)
+	}
+}
+
+// TestSubPostProcessSettings_Check 之前版本保存的步骤，新增的步骤插入到默认顺序中前一个步骤的后面
+func TestSubPostProcessSettings_Check(t *testing.T) {
+
+	postProcessSettings := settings.SubPostProcessSettings{
+		Stages: []settings.SubPostProcessStage{
+			{Name: settings.SubPostProcessStageChsChtChange, Enable: true},
+			{Name: settings.SubPostProcessStageAssFonts, Enable: false},
+			{Name: settings.SubPostProcessStageChangeEncode, Enable: true},
+		},
+	}
+	postProcessSettings.Check()
+	want := []string{
+		settings.SubPostProcessStageAdClean,
+		settings.SubPostProcessStageTimelineFix,
+		settings.SubPostProcessStageBilingualMerge,
+		settings.SubPostProcessStageAssStyle,
+		settings.SubPostProcessStageChsChtChange,
+		settings.SubPostProcessStageEmbed,
+		settings.SubPostProcessStageAssFonts,
+		settings.SubPostProcessStageFormatConvert,
+		settings.SubPostProcessStageChangeEncode,
+	}
+	if len(postProcessSettings.Stages) != len(want) {
+		t.Fatalf("stages = %+v", postProcessSettings.Stages)
+	}
+	for i := range want {
+		if postProcessSettings.Stages[i].Name != want[i] {
+			t.Fatalf("stages = %+v", postProcessSettings.Stages)
+		}
+	}
+	if postProcessSettings.Stages[6].Enable == true || postProcessSettings.Stages[7].Enable == true {
+		t.Fatalf("saved enable should be kept and format_convert should be disabled by default")
+	}
+	if postProcessSettings.Stages[2].Enable == true || postProcessSettings.Stages[5].Enable == true {
+		t.Fatalf("bilingual_merge and embed should be disabled by default")
+	}
+}
@@ -0,0 +1,154 @@
+package sub_share_center
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/decode"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/imdb_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/ass"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/logic/sub_parser/srt"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/media_info_dealers"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_file_hash"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/sub_parser_hub"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	AddSavedSub 下载后评分选中、写入视频旁边的字幕，复制到共享的缓存目录中，作为还没有发送的字幕等待 UploadPending 上传
+	只有开启了字幕共享的时候才会添加，多集合并的视频（S01E01E02）字幕对应不上单独的一集，不共享
+	本地文件夹、自定义、局域网内其他实例这些来源的字幕不是公开的字幕，不共享
+	VideoSubInfo 以视频的特征码为主键，同一个视频只保留最新的字幕，字幕升级后会重新上传
+*/
+func AddSavedSub(log *logrus.Logger, dealers *media_info_dealers.Dealers, videoFPath, subFPath, source string) error {
+
+	if settings.Get().ExperimentalFunction.ShareSubSettings.ShareSubEnabled == false {
+		return nil
+	}
+	if isPublicSource(source) == false {
+		log.Debugln("AddSavedSub, not public source, skip", source, subFPath)
+		return nil
+	}
+	isMovie := decode.GetSeriesDirRootFPath(videoFPath) == ""
+	season, episode := 0, 0
+	if isMovie == false {
+		found, _, episodeStart, episodeEnd := decode.GetEpisodeRangeFromFileName(filepath.Base(videoFPath))
+		if found == true && episodeEnd > episodeStart {
+			log.Debugln("AddSavedSub, multi episode video, skip", videoFPath)
+			return nil
+		}
+		epsNfoInfo, err := decode.GetVideoNfoInfo4OneSeriesEpisode(videoFPath)
+		if err != nil {
+			return err
+		}
+		season, episode = epsNfoInfo.Season, epsNfoInfo.Episode
+	}
+	imdbInfo, err := imdb_helper.GetIMDBInfoFromVideoFile(dealers, videoFPath, isMovie)
+	if err != nil {
+		return err
+	}
+	if imdbInfo.IMDBID == "" {
+		return errors.New(fmt.Sprintf("AddSavedSub, imdb id is empty, %s", videoFPath))
+	}
+	fileHash, err := sub_file_hash.Calculate(videoFPath)
+	if err != nil {
+		return err
+	}
+	savedSub := savedSubInfo{
+		imdbID:   imdbInfo.IMDBID,
+		year:     imdbInfo.Year,
+		feature:  fileHash,
+		isMovie:  isMovie,
+		season:   season,
+		episode:  episode,
+		source:   source,
+		subFPath: subFPath,
+	}
+	return addVideoSubInfo(log, savedSub)
+}
+
+// savedSubInfo 写入 VideoSubInfo 需要的视频以及字幕的信息
+type savedSubInfo struct {
+	imdbID   string
+	year     int
+	feature  string // 视频的特征码
+	isMovie  bool
+	season   int
+	episode  int
+	source   string // 字幕的来源网站
+	subFPath string // 视频旁边的字幕
+}
+
+// addVideoSubInfo 复制字幕到共享的缓存目录，并记录为还没有发送的 VideoSubInfo，已经有相同 sha256 的字幕则跳过
+func addVideoSubInfo(log *logrus.Logger, savedSub savedSubInfo) error {
+
+	sha256String, err := pkg.GetFileSHA256String(savedSub.subFPath)
+	if err != nil {
+		return err
+	}
+	var videoSubInfos []models.VideoSubInfo
+	err = dao.GetDb().Where("sha256 = ?", sha256String).Find(&videoSubInfos).Error
+	if err != nil {
+		return err
+	}
+	if len(videoSubInfos) > 0 {
+		log.Debugln("AddSavedSub, SHA256 Exist == true, Skip", savedSub.subFPath)
+		return nil
+	}
+	shareRootDir, err := pkg.GetShareSubRootFolder()
+	if err != nil {
+		return err
+	}
+	bok, subCacheFPath := CopySub2Cache(log, savedSub.subFPath, savedSub.imdbID, savedSub.year, false)
+	if bok == false {
+		return errors.New(fmt.Sprintf("AddSavedSub, copy sub to share cache failed, %s", savedSub.subFPath))
+	}
+	bok, fileInfo, err := sub_parser_hub.NewSubParserHub(log, ass.NewParser(log), srt.NewParser(log)).DetermineFileTypeFromFile(subCacheFPath)
+	if err != nil {
+		return err
+	}
+	if bok == false {
+		return errors.New(fmt.Sprintf("AddSavedSub, not support sub type, %s", subCacheFPath))
+	}
+	subRelPath, err := filepath.Rel(shareRootDir, subCacheFPath)
+	if err != nil {
+		return err
+	}
+
+	oneVideoSubInfo := models.NewVideoSubInfo(
+		savedSub.feature,
+		filepath.Base(subCacheFPath),
+		language.MyLang2ISO_639_1_String(fileInfo.Lang),
+		language.IsBilingualSubtitle(fileInfo.Lang),
+		language.MyLang2ChineseISO(fileInfo.Lang),
+		fileInfo.Lang.String(),
+		subRelPath,
+		savedSub.source,
+		sha256String,
+		savedSub.isMovie,
+	)
+	oneVideoSubInfo.Season = savedSub.season
+	oneVideoSubInfo.Episode = savedSub.episode
+	oneVideoSubInfo.IMDBInfoID = savedSub.imdbID
+	return dao.GetDb().Save(oneVideoSubInfo).Error
+}
+
+// isPublicSource 字幕的来源是否是公开的字幕网站，本地文件夹、自定义字幕源、局域网内其他实例的字幕不是
+func isPublicSource(source string) bool {
+
+	if source == common.SubSiteLocalFolder || source == common.SubSitePeerLibrary {
+		return false
+	}
+	if strings.HasPrefix(source, common.SubSiteCustomPrefix) == true {
+		return false
+	}
+	return true
+}
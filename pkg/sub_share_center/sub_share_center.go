@@ -1,44 +1,207 @@
 package sub_share_center
 
 import (
-	"crypto/tls"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/ifaces"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
-	goMail "gopkg.in/mail.v2"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+	"github.com/sirupsen/logrus"
 )
 
+/*
+	SubShareCenter 把缓存中还没有发送的字幕上传到字幕共享中心
+	字幕按 BatchSize 分批上传，一批失败了整体重试 RetryTimes 次，成功的才会标记 IsSend
+	低可信度的字幕（LowVideoSubInfo）只有设置了 UploadLowTrust 才会上传，上传的时候会带上 low_trust 的标记
+*/
 type SubShareCenter struct {
-	center settings.SubShareCenter
-	client *goMail.Dialer
-	dialer goMail.SendCloser
+	log           *logrus.Logger
+	transport     ifaces.ISubShareTransport
+	shareSettings settings.ShareSubSettings
+	retryInterval time.Duration // 重试的间隔，第 N 次重试等待 N 倍
 }
 
-func NewSubShareCenter(center settings.SubShareCenter) *SubShareCenter {
-
-	nowClient := goMail.NewDialer(center.SenderSMTPAddress, center.SenderSMTPPort, center.SenderEmailAddress, center.SenderEmailPwd)
-	nowClient.TLSConfig = &tls.Config{InsecureSkipVerify: center.InsecureSkipVerify}
+func NewSubShareCenter(log *logrus.Logger, transport ifaces.ISubShareTransport, shareSettings settings.ShareSubSettings) *SubShareCenter {
 	return &SubShareCenter{
-		center: center,
-		client: nowClient,
+		log:           log,
+		transport:     transport,
+		shareSettings: shareSettings,
+		retryInterval: defRetryInterval,
 	}
 }
 
-func (s *SubShareCenter) Login() error {
+// NewTransport 按照设置的上传方式新建
+func NewTransport(shareSettings settings.ShareSubSettings) (ifaces.ISubShareTransport, error) {
 
-	var err error
-	s.dialer, err = s.client.Dial()
+	switch shareSettings.Transport {
+	case "", settings.ShareSubTransportSMTP:
+		return newSMTPTransport(shareSettings.SubShareCenter)
+	case settings.ShareSubTransportHTTP:
+		return newHTTPTransport(shareSettings.HttpUploadUrl, shareSettings.HttpUploadToken)
+	default:
+		return nil, errors.New(fmt.Sprintf("not support share sub transport: %s", shareSettings.Transport))
+	}
+}
+
+// SendSubtitle 分批上传字幕，返回上传成功的字幕，某一批重试后还是失败的，返回最后一个错误，后面的批次继续上传
+func (s *SubShareCenter) SendSubtitle(items []*sub_share.ShareItem) ([]*sub_share.ShareItem, error) {
+
+	sentItems := make([]*sub_share.ShareItem, 0)
+	var lastErr error
+	batchSize := s.getBatchSize()
+	for start := 0; start < len(items); start += batchSize {
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batch := items[start:end]
+		err := s.sendBatch(batch)
+		if err != nil {
+			s.log.Errorln("SubShareCenter.SendSubtitle", s.transport.GetTransportName(), "batch", start/batchSize, err)
+			lastErr = err
+			continue
+		}
+		sentItems = append(sentItems, batch...)
+	}
+	return sentItems, lastErr
+}
+
+// UploadPending 上传数据库中还没有发送的字幕，成功的标记 IsSend，返回成功上传的数量
+func (s *SubShareCenter) UploadPending() (int, error) {
+
+	items, err := s.getPendingItems()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if len(items) < 1 {
+		return 0, nil
+	}
+	s.log.Infoln("SubShareCenter.UploadPending", s.transport.GetTransportName(), "pending:", len(items))
+	sentItems, sendErr := s.SendSubtitle(items)
+	for _, item := range sentItems {
+		if item.LowTrust == true {
+			err = dao.GetDb().Model(&models.LowVideoSubInfo{}).Where("sha256 = ?", item.SHA256).Update("is_send", true).Error
+		} else {
+			err = dao.GetDb().Model(&models.VideoSubInfo{}).Where("sha256 = ?", item.SHA256).Update("is_send", true).Error
+		}
+		if err != nil {
+			s.log.Errorln("SubShareCenter.UploadPending.Update IsSend", item.SubName, err)
+		}
 	}
+	s.log.Infoln("SubShareCenter.UploadPending", s.transport.GetTransportName(), "sent:", len(sentItems))
+	return len(sentItems), sendErr
+}
+
+// sendBatch 上传一批字幕，失败则重试
+func (s *SubShareCenter) sendBatch(batch []*sub_share.ShareItem) error {
 
-	return nil
+	var err error
+	for i := 0; i <= s.getRetryTimes(); i++ {
+		if i > 0 {
+			s.log.Warningln("SubShareCenter.sendBatch", s.transport.GetTransportName(), "retry", i, err)
+			time.Sleep(s.retryInterval * time.Duration(i))
+		}
+		err = s.transport.Send(batch)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
 }
 
-func (s *SubShareCenter) Logout() error {
-	return s.dialer.Close()
+// getPendingItems 还没有发送的字幕，缓存中字幕文件已经不存在的跳过
+func (s *SubShareCenter) getPendingItems() ([]*sub_share.ShareItem, error) {
+
+	shareRootDir, err := pkg.GetShareSubRootFolder()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*sub_share.ShareItem, 0)
+	var videoSubInfos []models.VideoSubInfo
+	err = dao.GetDb().Where("is_send = ?", false).Find(&videoSubInfos).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range videoSubInfos {
+		item := &sub_share.ShareItem{
+			IMDBID:       info.IMDBInfoID,
+			Feature:      info.Feature,
+			IsMovie:      info.IsMovie,
+			Season:       info.Season,
+			Episode:      info.Episode,
+			LanguageISO:  info.LanguageISO,
+			ChineseISO:   info.ChineseISO,
+			MyLanguage:   info.MyLanguage,
+			IsDouble:     info.IsDouble,
+			ExtraPreName: info.ExtraPreName,
+			SHA256:       info.SHA256,
+			SubName:      info.SubName,
+			SubFPath:     filepath.Join(shareRootDir, info.StoreRPath),
+		}
+		if pkg.IsFile(item.SubFPath) == false {
+			s.log.Debugln("SubShareCenter.getPendingItems, sub file not exist, skip", item.SubFPath)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if s.shareSettings.UploadLowTrust == false {
+		return items, nil
+	}
+	var lowVideoSubInfos []models.LowVideoSubInfo
+	err = dao.GetDb().Where("is_send = ?", false).Find(&lowVideoSubInfos).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range lowVideoSubInfos {
+		item := &sub_share.ShareItem{
+			IMDBID:       info.IMDBID,
+			TMDBID:       info.TMDBID,
+			Feature:      info.Feature,
+			IsMovie:      info.IsMovie,
+			Season:       info.Season,
+			Episode:      info.Episode,
+			LanguageISO:  info.LanguageISO,
+			ChineseISO:   info.ChineseISO,
+			MyLanguage:   info.MyLanguage,
+			IsDouble:     info.IsDouble,
+			ExtraPreName: info.ExtraPreName,
+			SHA256:       info.SHA256,
+			SubName:      info.SubName,
+			LowTrust:     true,
+			SubFPath:     filepath.Join(shareRootDir, info.StoreRPath),
+		}
+		if pkg.IsFile(item.SubFPath) == false {
+			s.log.Debugln("SubShareCenter.getPendingItems, sub file not exist, skip", item.SubFPath)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
 }
 
-func (s *SubShareCenter) SendSubtitle(imdbID, videoName, feature, subFIleFPath string) error {
+func (s *SubShareCenter) getBatchSize() int {
+	if s.shareSettings.BatchSize <= 0 {
+		return defBatchSize
+	}
+	return s.shareSettings.BatchSize
+}
 
-	return nil
+func (s *SubShareCenter) getRetryTimes() int {
+	if s.shareSettings.RetryTimes <= 0 {
+		return defRetryTimes
+	}
+	return s.shareSettings.RetryTimes
 }
+
+const (
+	defBatchSize     = 10
+	defRetryTimes    = 3
+	defRetryInterval = 10 * time.Second
+)
@@ -0,0 +1,393 @@
+package sub_share_center
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/log_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+func TestSubShareCenter_SendSubtitle_SMTP(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	host, port, mails := startSMTPStub(t)
+	shareSettings := settings.ShareSubSettings{
+		Transport: settings.ShareSubTransportSMTP,
+		SubShareCenter: settings.SubShareCenter{
+			SenderSMTPAddress:       host,
+			SenderSMTPPort:          port,
+			SenderEmailAddress:      "sender@csf.local",
+			ShareCenterEmailAddress: "center@csf.local",
+		},
+		BatchSize: 2,
+	}
+	transport, err := NewTransport(shareSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := NewSubShareCenter(log_helper.GetLogger4Tester(), transport, shareSettings)
+
+	items := newTestItems(t, 3)
+	sentItems, err := center.SendSubtitle(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sentItems) != 3 {
+		t.Fatalf("SendSubtitle() sent %d, want 3", len(sentItems))
+	}
+	// 3 个字幕，每批 2 个，需要 2 封邮件
+	gotMails := mails()
+	if len(gotMails) != 2 {
+		t.Fatalf("smtp stub got %d mails, want 2", len(gotMails))
+	}
+	for _, want := range []string{"center@csf.local", "0_sub_0.ass", "1_sub_1.ass", "tt0000001"} {
+		if strings.Contains(gotMails[0], want) == false {
+			t.Errorf("first mail missing %q", want)
+		}
+	}
+	if strings.Contains(gotMails[1], "0_sub_2.ass") == false {
+		t.Errorf("second mail missing 0_sub_2.ass")
+	}
+}
+
+func TestSubShareCenter_SendSubtitle_HTTPRetry(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	var locker sync.Mutex
+	requestCount := 0
+	gotItems := make([]sub_share.ShareItem, 0)
+	gotFiles := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locker.Lock()
+		defer locker.Unlock()
+		requestCount++
+		// 第一次请求失败，需要重试
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		err := r.ParseMultipartForm(1024 * 1024)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		items := make([]sub_share.ShareItem, 0)
+		err = json.Unmarshal([]byte(r.FormValue("items")), &items)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for i := range items {
+			f, _, err := r.FormFile("file_" + strconv.Itoa(i))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(f)
+			_ = f.Close()
+			gotFiles = append(gotFiles, string(content))
+		}
+		gotItems = append(gotItems, items...)
+	}))
+	defer server.Close()
+
+	shareSettings := settings.ShareSubSettings{
+		Transport:       settings.ShareSubTransportHTTP,
+		HttpUploadUrl:   server.URL + "/upload",
+		HttpUploadToken: "token123",
+		BatchSize:       5,
+		RetryTimes:      2,
+	}
+	transport, err := NewTransport(shareSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := NewSubShareCenter(log_helper.GetLogger4Tester(), transport, shareSettings)
+	center.retryInterval = 0
+
+	items := newTestItems(t, 2)
+	items[1].LowTrust = true
+	sentItems, err := center.SendSubtitle(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sentItems) != 2 || requestCount != 2 {
+		t.Fatalf("SendSubtitle() sent %d, requests %d, want 2, 2", len(sentItems), requestCount)
+	}
+	if len(gotItems) != 2 || gotItems[0].IMDBID != "tt0000000" || gotItems[1].LowTrust != true {
+		t.Errorf("http stub got items %v", gotItems)
+	}
+	if len(gotFiles) != 2 || gotFiles[1] != "sub content 1" {
+		t.Errorf("http stub got files %v", gotFiles)
+	}
+}
+
+func TestSubShareCenter_SendSubtitle_BatchFailed(t *testing.T) {
+
+	transport := &fakeTransport{failBatch: 1}
+	center := NewSubShareCenter(log_helper.GetLogger4Tester(), transport, settings.ShareSubSettings{BatchSize: 1, RetryTimes: 1})
+	center.retryInterval = 0
+
+	items := newTestItems(t, 3)
+	sentItems, err := center.SendSubtitle(items)
+	if err == nil {
+		t.Fatal("SendSubtitle() should return the error of the failed batch")
+	}
+	// 失败的那一批不算发送成功，后面的批次继续
+	if len(sentItems) != 2 || sentItems[0] != items[0] || sentItems[1] != items[2] {
+		t.Errorf("SendSubtitle() sent %v", sentItems)
+	}
+	// 3 批，失败的那一批重试 1 次
+	if transport.sendCount != 4 {
+		t.Errorf("transport send %d times, want 4", transport.sendCount)
+	}
+}
+
+func TestIsPublicSource(t *testing.T) {
+
+	cases := map[string]bool{
+		common.SubSiteZiMuKu:               true,
+		common.SubSiteLocalFolder:          false,
+		common.SubSitePeerLibrary:          false,
+		common.SubSiteCustomPrefix + "nas": false,
+	}
+	for source, want := range cases {
+		if got := isPublicSource(source); got != want {
+			t.Errorf("isPublicSource(%s) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+
+	_, err := NewTransport(settings.ShareSubSettings{})
+	if err == nil {
+		t.Error("smtp transport without smtp address should return error")
+	}
+	_, err = NewTransport(settings.ShareSubSettings{Transport: settings.ShareSubTransportHTTP})
+	if err == nil {
+		t.Error("http transport without upload url should return error")
+	}
+	_, err = NewTransport(settings.ShareSubSettings{Transport: "ftp"})
+	if err == nil {
+		t.Error("not support transport should return error")
+	}
+}
+
+// TestSubShareCenter_UploadPending 评分选中的字幕加入到共享的缓存中，上传成功后标记为已发送，不会重复上传
+func TestSubShareCenter_UploadPending(t *testing.T) {
+
+	settings.SetConfigRootPath(t.TempDir())
+	// 共享的缓存目录在当前的工作目录下
+	nowWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(nowWd)
+	}()
+	const feature = "upload-pending-test-feature"
+	defer dao.GetDb().Where("feature = ?", feature).Delete(&models.VideoSubInfo{})
+
+	subFPath := filepath.Join(t.TempDir(), "Foo (2020).chinese(简,subhd).srt")
+	err = os.WriteFile(subFPath, []byte("1\n00:00:01,000 --> 00:00:02,000\n这是一个测试的中文字幕\n\n"+
+		"2\n00:00:03,000 --> 00:00:04,000\n上传到字幕共享中心\n\n"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedSub := savedSubInfo{
+		imdbID:   "tt7654321",
+		year:     2020,
+		feature:  feature,
+		isMovie:  true,
+		source:   "subhd",
+		subFPath: subFPath,
+	}
+	l := log_helper.GetLogger4Tester()
+	for i := 0; i < 2; i++ {
+		// 同一个字幕只会添加一次
+		err = addVideoSubInfo(l, savedSub)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transport := &fakeTransport{failBatch: -1}
+	center := NewSubShareCenter(l, transport, settings.ShareSubSettings{})
+	sentCount, err := center.UploadPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sentCount != 1 || len(transport.sentItems) != 1 {
+		t.Fatalf("UploadPending() sent %d, transport got %d, want 1", sentCount, len(transport.sentItems))
+	}
+	item := transport.sentItems[0]
+	if item.IMDBID != "tt7654321" || item.Feature != feature || item.IsMovie == false || item.ExtraPreName != "subhd" ||
+		item.SubName != filepath.Base(subFPath) || item.ChineseISO == "" {
+		t.Errorf("UploadPending() item = %+v", item)
+	}
+	cacheBytes, err := os.ReadFile(item.SubFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subBytes, err := os.ReadFile(subFPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cacheBytes) != string(subBytes) {
+		t.Errorf("share cache sub = %q", string(cacheBytes))
+	}
+
+	var videoSubInfo models.VideoSubInfo
+	err = dao.GetDb().Where("feature = ?", feature).First(&videoSubInfo).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if videoSubInfo.IsSend == false {
+		t.Error("VideoSubInfo should be marked as sent")
+	}
+	sentCount, err = center.UploadPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sentCount != 0 {
+		t.Errorf("UploadPending() sent %d again, want 0", sentCount)
+	}
+}
+
+type fakeTransport struct {
+	failBatch int
+	sendCount int
+	sentItems []*sub_share.ShareItem
+}
+
+func (f *fakeTransport) GetTransportName() string {
+	return "fake"
+}
+
+func (f *fakeTransport) Send(items []*sub_share.ShareItem) error {
+	f.sendCount++
+	if items[0].SubName == "sub_"+strconv.Itoa(f.failBatch)+".ass" {
+		return errors.New("fake send error")
+	}
+	f.sentItems = append(f.sentItems, items...)
+	return nil
+}
+
+func newTestItems(t *testing.T, count int) []*sub_share.ShareItem {
+
+	items := make([]*sub_share.ShareItem, 0, count)
+	for i := 0; i < count; i++ {
+		subName := "sub_" + strconv.Itoa(i) + ".ass"
+		subFPath := filepath.Join(t.TempDir(), subName)
+		err := os.WriteFile(subFPath, []byte("sub content "+strconv.Itoa(i)), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, &sub_share.ShareItem{
+			IMDBID:   "tt000000" + strconv.Itoa(i),
+			IsMovie:  true,
+			SHA256:   strconv.Itoa(i),
+			SubName:  subName,
+			SubFPath: subFPath,
+		})
+	}
+	return items
+}
+
+// startSMTPStub 只实现了发送邮件需要的命令的 SMTP 服务，不支持 STARTTLS、AUTH，返回收到的邮件
+func startSMTPStub(t *testing.T) (string, int, func() []string) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	var locker sync.Mutex
+	mails := make([]string, 0)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() {
+					_ = conn.Close()
+				}()
+				reader := bufio.NewReader(conn)
+				reply := func(line string) {
+					_, _ = conn.Write([]byte(line + "\r\n"))
+				}
+				reply("220 localhost ESMTP stub")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+						reply("250-localhost")
+						reply("250 8BITMIME")
+					case strings.HasPrefix(command, "DATA"):
+						reply("354 end with <CRLF>.<CRLF>")
+						var sb strings.Builder
+						for {
+							dataLine, err := reader.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if dataLine == ".\r\n" {
+								break
+							}
+							sb.WriteString(dataLine)
+						}
+						locker.Lock()
+						mails = append(mails, sb.String())
+						locker.Unlock()
+						reply("250 OK")
+					case strings.HasPrefix(command, "QUIT"):
+						reply("221 bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, func() []string {
+		locker.Lock()
+		defer locker.Unlock()
+		return append([]string{}, mails...)
+	}
+}
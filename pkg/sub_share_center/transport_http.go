@@ -0,0 +1,64 @@
+package sub_share_center
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+)
+
+// httpTransport POST multipart/form-data，items 字段是字幕信息的 JSON，file_N 是第 N 个字幕文件
+type httpTransport struct {
+	uploadUrl string
+	token     string
+}
+
+func newHTTPTransport(uploadUrl, token string) (*httpTransport, error) {
+
+	if uploadUrl == "" {
+		return nil, errors.New("share sub http transport need http_upload_url")
+	}
+	return &httpTransport{uploadUrl: uploadUrl, token: token}, nil
+}
+
+func (h *httpTransport) GetTransportName() string {
+	return settings.ShareSubTransportHTTP
+}
+
+func (h *httpTransport) Send(items []*sub_share.ShareItem) error {
+
+	itemsBytes, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	client, err := pkg.NewHttpClient()
+	if err != nil {
+		return err
+	}
+	req := client.R().SetFormData(map[string]string{"items": string(itemsBytes)})
+	if h.token != "" {
+		req.SetAuthToken(h.token)
+	}
+	for i, item := range items {
+		f, err := os.Open(item.SubFPath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		req.SetFileReader(fmt.Sprintf("file_%d", i), item.SubName, f)
+	}
+	resp, err := req.Post(h.uploadUrl)
+	if err != nil {
+		return err
+	}
+	if resp.IsSuccess() == false {
+		return errors.New(fmt.Sprintf("post %s, status code: %d, resp: %s", h.uploadUrl, resp.StatusCode(), resp.String()))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package sub_share_center
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+	goMail "gopkg.in/mail.v2"
+)
+
+// smtpTransport 每一批字幕作为一封邮件发送到 ShareCenterEmailAddress，正文是字幕信息的 JSON，字幕是附件
+type smtpTransport struct {
+	center settings.SubShareCenter
+	client *goMail.Dialer
+}
+
+func newSMTPTransport(center settings.SubShareCenter) (*smtpTransport, error) {
+
+	if center.SenderSMTPAddress == "" || center.ShareCenterEmailAddress == "" {
+		return nil, errors.New("share sub smtp transport need sender_smtp_address and share_center_email_address")
+	}
+	nowClient := goMail.NewDialer(center.SenderSMTPAddress, center.SenderSMTPPort, center.SenderEmailAddress, center.SenderEmailPwd)
+	nowClient.TLSConfig = &tls.Config{InsecureSkipVerify: center.InsecureSkipVerify, ServerName: center.SenderSMTPAddress}
+	nowClient.Timeout = smtpTimeOut
+	return &smtpTransport{
+		center: center,
+		client: nowClient,
+	}, nil
+}
+
+func (s *smtpTransport) GetTransportName() string {
+	return settings.ShareSubTransportSMTP
+}
+
+func (s *smtpTransport) Send(items []*sub_share.ShareItem) error {
+
+	body, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	m := goMail.NewMessage()
+	m.SetHeader("From", s.center.SenderEmailAddress)
+	m.SetHeader("To", s.center.ShareCenterEmailAddress)
+	m.SetHeader("Subject", fmt.Sprintf("%s %d subtitles", mailSubjectPrefix, len(items)))
+	m.SetBody("application/json", string(body))
+	for i, item := range items {
+		// 附件的名称加上序号，与正文中的顺序对应，同名的字幕不会冲突
+		m.Attach(item.SubFPath, goMail.Rename(fmt.Sprintf("%d_%s", i, item.SubName)))
+	}
+	return s.client.DialAndSend(m)
+}
+
+const (
+	smtpTimeOut       = 30 * time.Second
+	mailSubjectPrefix = "[ChineseSubFinder Share]"
+)
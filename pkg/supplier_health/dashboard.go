@@ -0,0 +1,110 @@
+package supplier_health
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/backend"
+)
+
+// GetDashboard 汇总最近 days 天每个字幕源的统计以及当前的状态
+func GetDashboard(days int) (*backend.ReplySupplierHealth, error) {
+
+	dailies, err := GetDailies(days)
+	if err != nil {
+		return nil, err
+	}
+	states, err := GetStates()
+	if err != nil {
+		return nil, err
+	}
+	return &backend.ReplySupplierHealth{
+		Days:      days,
+		Suppliers: summarize(dailies, states, time.Now()),
+	}, nil
+}
+
+// summarize dailies 需要是最新的在前面，今天没有统计的字幕源，下载次数的使用情况是 0
+func summarize(dailies []models.SupplierHealthDaily, states []models.SupplierHealthState, nowTime time.Time) []backend.SupplierHealth {
+
+	healthMap := make(map[string]*backend.SupplierHealth)
+	latencyMsSum := make(map[string]int64)
+	getOne := func(supplierName string) *backend.SupplierHealth {
+		one, found := healthMap[supplierName]
+		if found == false {
+			one = &backend.SupplierHealth{
+				Name:       supplierName,
+				QuotaLimit: -1,
+				History:    make([]backend.SupplierHealthDay, 0),
+			}
+			healthMap[supplierName] = one
+		}
+		return one
+	}
+
+	today := getDay(nowTime)
+	for _, daily := range dailies {
+		one := getOne(daily.SupplierName)
+		one.QueryCount += daily.QueryCount
+		one.HitCount += daily.HitCount
+		one.ErrorCount += daily.ErrorCount
+		one.DownloadCount += daily.DownloadCount
+		one.DownloadFailedCount += daily.DownloadFailedCount
+		one.WinnerCount += daily.WinnerCount
+		one.CheckCount += daily.CheckCount
+		one.CheckFailedCount += daily.CheckFailedCount
+		latencyMsSum[daily.SupplierName] += daily.LatencyMsSum
+		if daily.Day == today {
+			one.QuotaUsed = daily.QuotaUsed
+			one.QuotaLimit = daily.QuotaLimit
+		}
+		one.History = append(one.History, backend.SupplierHealthDay{
+			Day:           daily.Day,
+			QueryCount:    daily.QueryCount,
+			HitCount:      daily.HitCount,
+			ErrorCount:    daily.ErrorCount,
+			DownloadCount: daily.DownloadCount,
+			WinnerCount:   daily.WinnerCount,
+			AvgLatencyMs:  divide(daily.LatencyMsSum, daily.QueryCount),
+			QuotaUsed:     daily.QuotaUsed,
+			QuotaLimit:    daily.QuotaLimit,
+		})
+	}
+	for i := range states {
+		state := &states[i]
+		one := getOne(state.SupplierName)
+		one.Disabled = isDisabled(state, nowTime)
+		if one.Disabled == true {
+			one.DisabledUntil = state.DisabledUntil
+		}
+		one.DisabledCount = state.DisabledCount
+		one.ConsecutiveFailures = state.ConsecutiveFailures
+		one.LastError = state.LastError
+		one.LastErrorTime = state.LastErrorTime
+		one.LastSuccessTime = state.LastSuccessTime
+	}
+
+	outHealths := make([]backend.SupplierHealth, 0, len(healthMap))
+	for supplierName, one := range healthMap {
+		one.AvgLatencyMs = divide(latencyMsSum[supplierName], one.QueryCount)
+		if one.QueryCount > 0 {
+			one.HitRate = float64(one.HitCount) / float64(one.QueryCount)
+		}
+		if one.DownloadCount+one.DownloadFailedCount > 0 {
+			one.DownloadSuccessRate = float64(one.DownloadCount) / float64(one.DownloadCount+one.DownloadFailedCount)
+		}
+		outHealths = append(outHealths, *one)
+	}
+	sort.Slice(outHealths, func(i, j int) bool {
+		return outHealths[i].Name < outHealths[j].Name
+	})
+	return outHealths
+}
+
+func divide(sum, count int64) int64 {
+	if count <= 0 {
+		return 0
+	}
+	return sum / count
+}
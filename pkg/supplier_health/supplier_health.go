@@ -0,0 +1,331 @@
+package supplier_health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+	RecordQuery 记录一次搜索（一部电影或者一部连续剧）的结果，没有搜索到字幕不算失败
+	只有字幕源自身的问题（见 IsSupplierError）才算作一次失败，视频本身的问题，比如 找不到 IMDB ID、计算不出特征码，既不算失败也不算成功
+*/
+func RecordQuery(log *logrus.Logger, supplierName string, subInfos []supplier.SubInfo, queryErr error, latency time.Duration) {
+
+	isSupplierError := IsSupplierError(queryErr)
+	err := updateDaily(supplierName, func(daily *models.SupplierHealthDaily) {
+		daily.QueryCount++
+		daily.LatencyMsSum += latency.Milliseconds()
+		if isSupplierError == true {
+			daily.ErrorCount++
+			return
+		}
+		if len(subInfos) > 0 {
+			daily.HitCount++
+		}
+		for _, subInfo := range subInfos {
+			if len(subInfo.Data) > 0 {
+				daily.DownloadCount++
+			}
+		}
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.RecordQuery", supplierName, err)
+	}
+	if isSupplierError == true {
+		recordResult(log, supplierName, false, queryErr.Error())
+	} else if queryErr == nil {
+		recordResult(log, supplierName, true, "")
+	}
+}
+
+// RecordDownloadFailed 记录一次字幕文件下载失败，只是统计，不影响连续失败的次数
+func RecordDownloadFailed(log *logrus.Logger, supplierName string, downloadErr error) {
+
+	err := updateDaily(supplierName, func(daily *models.SupplierHealthDaily) {
+		daily.DownloadFailedCount++
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.RecordDownloadFailed", supplierName, downloadErr, err)
+	}
+}
+
+/*
+	IsSupplierError 是否是字幕源自身的问题：网络不通、超时，返回了非预期的 HTTP 状态码，或者今日的下载次数用完了
+	其他的错误多半是这个视频本身的问题，换一个字幕源也一样，不能说明这个字幕源不可用
+*/
+func IsSupplierError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) == true {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) == true {
+		return true
+	}
+	var statusCodeErr *supplier.StatusCodeError
+	if errors.As(err, &statusCodeErr) == true {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) == true || errors.Is(err, supplier.ErrOverDailyDownloadLimit) == true
+}
+
+// RecordCheck 记录一次 CheckAlive 的结果，顺便清理过期的统计
+func RecordCheck(log *logrus.Logger, supplierName string, alive bool) {
+
+	err := updateDaily(supplierName, func(daily *models.SupplierHealthDaily) {
+		daily.CheckCount++
+		if alive == false {
+			daily.CheckFailedCount++
+		}
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.RecordCheck", supplierName, err)
+	}
+	recordResult(log, supplierName, alive, "check alive failed")
+
+	err = cleanUp()
+	if err != nil {
+		log.Errorln("SupplierHealth.cleanUp", err)
+	}
+}
+
+// RecordWinner 记录一次被选为最终字幕，拼接的字幕等没有来源的忽略
+func RecordWinner(log *logrus.Logger, supplierName string) {
+
+	if supplierName == "" {
+		return
+	}
+	err := updateDaily(supplierName, func(daily *models.SupplierHealthDaily) {
+		daily.WinnerCount++
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.RecordWinner", supplierName, err)
+	}
+}
+
+// RecordQuotaUsed 记录当天已经使用的下载次数，used 是 DailyDownloadCountAdd 之后的值
+func RecordQuotaUsed(log *logrus.Logger, supplierName string, used int) {
+
+	err := updateDaily(supplierName, func(daily *models.SupplierHealthDaily) {
+		daily.QuotaUsed = used
+		daily.QuotaLimit = getDailyDownloadLimit(supplierName)
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.RecordQuotaUsed", supplierName, err)
+	}
+}
+
+// IsDisabled 字幕源是否因为连续失败被自动禁用，冷却结束后就不再是禁用的
+func IsDisabled(supplierName string) bool {
+
+	if getHealthSettings().AutoDisable == false {
+		return false
+	}
+	locker.Lock()
+	defer locker.Unlock()
+
+	var states []models.SupplierHealthState
+	dao.GetDb().Where("supplier_name = ?", supplierName).Limit(1).Find(&states)
+	if len(states) < 1 {
+		return false
+	}
+	return isDisabled(&states[0], time.Now())
+}
+
+// Enable 手动重新启用一个被自动禁用的字幕源，同时清零失败的次数
+func Enable(supplierName string) error {
+
+	return updateState(supplierName, func(state *models.SupplierHealthState) {
+		state.DisabledUntil = 0
+		state.ConsecutiveFailures = 0
+	})
+}
+
+// GetDailies 获取最近 days 天的统计，包括今天
+func GetDailies(days int) ([]models.SupplierHealthDaily, error) {
+
+	locker.Lock()
+	defer locker.Unlock()
+
+	dailies := make([]models.SupplierHealthDaily, 0)
+	err := dao.GetDb().Where("day >= ?", getDay(time.Now().AddDate(0, 0, 1-days))).
+		Order("day desc").Find(&dailies).Error
+	if err != nil {
+		return nil, err
+	}
+	return dailies, nil
+}
+
+// GetStates 获取所有字幕源当前的状态
+func GetStates() ([]models.SupplierHealthState, error) {
+
+	locker.Lock()
+	defer locker.Unlock()
+
+	states := make([]models.SupplierHealthState, 0)
+	err := dao.GetDb().Find(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// recordResult 更新连续失败的次数，达到阈值的时候自动禁用
+func recordResult(log *logrus.Logger, supplierName string, ok bool, errMsg string) {
+
+	healthSettings := getHealthSettings()
+	disabled := false
+	err := updateState(supplierName, func(state *models.SupplierHealthState) {
+		disabled = applyResult(state, ok, errMsg, time.Now(), healthSettings)
+	})
+	if err != nil {
+		log.Errorln("SupplierHealth.recordResult", supplierName, err)
+		return
+	}
+	if disabled == true {
+		log.Warningln("SupplierHealth", supplierName, "Failed", healthSettings.FailureThreshold,
+			"Times In A Row, Disabled For", healthSettings.CoolDown, "Minutes")
+	}
+}
+
+/*
+	applyResult 把一次结果应用到状态上，返回是否因为这次的结果而被禁用
+	成功则清零失败的次数，失败则累加，达到阈值且当前没有被禁用的时候，禁用 CoolDown 分钟
+	冷却结束后失败的次数不会清零，所以重新启用后再失败一次就会再次禁用
+*/
+func applyResult(state *models.SupplierHealthState, ok bool, errMsg string, nowTime time.Time, healthSettings settings.SupplierHealthSettings) bool {
+
+	if ok == true {
+		state.ConsecutiveFailures = 0
+		state.LastSuccessTime = nowTime.Unix()
+		if isDisabled(state, nowTime) == false {
+			state.DisabledUntil = 0
+		}
+		return false
+	}
+	state.ConsecutiveFailures++
+	state.LastError = errMsg
+	state.LastErrorTime = nowTime.Unix()
+	if healthSettings.AutoDisable == false || state.ConsecutiveFailures < healthSettings.FailureThreshold {
+		return false
+	}
+	if isDisabled(state, nowTime) == true {
+		return false
+	}
+	state.DisabledUntil = nowTime.Add(time.Duration(healthSettings.CoolDown) * time.Minute).Unix()
+	state.DisabledCount++
+	return true
+}
+
+func isDisabled(state *models.SupplierHealthState, nowTime time.Time) bool {
+	return state.DisabledUntil > nowTime.Unix()
+}
+
+func updateDaily(supplierName string, update func(daily *models.SupplierHealthDaily)) error {
+
+	locker.Lock()
+	defer locker.Unlock()
+
+	day := getDay(time.Now())
+	var daily models.SupplierHealthDaily
+	err := dao.GetDb().Where("supplier_name = ? AND day = ?", supplierName, day).
+		FirstOrInit(&daily, models.SupplierHealthDaily{SupplierName: supplierName, Day: day}).Error
+	if err != nil {
+		return err
+	}
+	if daily.ID == 0 {
+		// 新的一天，下载次数的限制先填上，没有下载的时候也能看到
+		daily.QuotaLimit = getDailyDownloadLimit(supplierName)
+	}
+	update(&daily)
+	return dao.GetDb().Save(&daily).Error
+}
+
+func updateState(supplierName string, update func(state *models.SupplierHealthState)) error {
+
+	locker.Lock()
+	defer locker.Unlock()
+
+	var state models.SupplierHealthState
+	err := dao.GetDb().Where("supplier_name = ?", supplierName).
+		FirstOrInit(&state, models.SupplierHealthState{SupplierName: supplierName}).Error
+	if err != nil {
+		return err
+	}
+	update(&state)
+	return dao.GetDb().Save(&state).Error
+}
+
+// cleanUp 删除超过 HistoryDays 天的统计
+func cleanUp() error {
+
+	locker.Lock()
+	defer locker.Unlock()
+
+	historyDays := getHealthSettings().HistoryDays
+	return dao.GetDb().Unscoped().Where("day < ?", getDay(time.Now().AddDate(0, 0, -historyDays))).
+		Delete(&models.SupplierHealthDaily{}).Error
+}
+
+// getDailyDownloadLimit 字幕源每天的下载次数限制，小于 0 是没有限制
+func getDailyDownloadLimit(supplierName string) int {
+
+	if strings.HasPrefix(supplierName, common.SubSiteCustomPrefix) == true {
+		for _, customSettings := range settings.Get().SubtitleSources.CustomSuppliers {
+			if common.SubSiteCustomPrefix+customSettings.Name != supplierName {
+				continue
+			}
+			if customSettings.DailyDownloadLimit <= 0 {
+				return -1
+			}
+			return customSettings.DailyDownloadLimit
+		}
+		return -1
+	}
+	suppliersSettings := settings.Get().AdvancedSettings.SuppliersSettings
+	if suppliersSettings == nil {
+		return -1
+	}
+	for _, oneSupplierSettings := range []*settings.OneSupplierSettings{
+		suppliersSettings.Xunlei, suppliersSettings.Shooter, suppliersSettings.Assrt, suppliersSettings.A4k,
+		suppliersSettings.SubHD, suppliersSettings.Zimuku, suppliersSettings.SubtitleBest, suppliersSettings.OpenSubtitles,
+	} {
+		if oneSupplierSettings != nil && oneSupplierSettings.Name == supplierName {
+			return oneSupplierSettings.DailyDownloadLimit
+		}
+	}
+	return -1
+}
+
+// getHealthSettings 旧的配置文件中没有这个设置，还没有 Check 的时候使用默认值
+func getHealthSettings() settings.SupplierHealthSettings {
+
+	healthSettings := settings.Get().AdvancedSettings.SupplierHealthSettings
+	if healthSettings == nil {
+		return *settings.NewSupplierHealthSettings()
+	}
+	return *healthSettings
+}
+
+func getDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// locker sqlite 的写入需要串行，先读再写的也需要
+var locker sync.Mutex
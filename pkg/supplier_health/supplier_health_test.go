@@ -0,0 +1,130 @@
+package supplier_health
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/supplier"
+)
+
+func TestApplyResult(t *testing.T) {
+
+	healthSettings := settings.SupplierHealthSettings{
+		AutoDisable:      true,
+		FailureThreshold: 3,
+		CoolDown:         60,
+		HistoryDays:      30,
+	}
+	nowTime := time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local)
+	state := &models.SupplierHealthState{SupplierName: "xunlei"}
+
+	// 没有达到阈值的时候不禁用
+	for i := 0; i < 2; i++ {
+		if applyResult(state, false, "timeout", nowTime, healthSettings) == true {
+			t.Fatal("disabled before threshold")
+		}
+	}
+	if isDisabled(state, nowTime) == true || state.LastError != "timeout" {
+		t.Fatal("state", state)
+	}
+	// 达到阈值，禁用 CoolDown 分钟
+	if applyResult(state, false, "timeout", nowTime, healthSettings) == false {
+		t.Fatal("not disabled at threshold")
+	}
+	if isDisabled(state, nowTime.Add(59*time.Minute)) == false || state.DisabledCount != 1 {
+		t.Fatal("not disabled in cool down", state)
+	}
+	// 禁用中再失败，不会重复计算禁用的次数
+	if applyResult(state, false, "timeout", nowTime.Add(time.Minute), healthSettings) == true || state.DisabledCount != 1 {
+		t.Fatal("disabled twice", state)
+	}
+	// 冷却结束后重新启用，再失败一次就会再次禁用
+	afterCoolDown := nowTime.Add(61 * time.Minute)
+	if isDisabled(state, afterCoolDown) == true {
+		t.Fatal("still disabled after cool down")
+	}
+	if applyResult(state, false, "timeout", afterCoolDown, healthSettings) == false || state.DisabledCount != 2 {
+		t.Fatal("not disabled again after cool down", state)
+	}
+	// 成功则清零
+	afterCoolDown = afterCoolDown.Add(61 * time.Minute)
+	if applyResult(state, true, "", afterCoolDown, healthSettings) == true {
+		t.Fatal("disabled by success")
+	}
+	if state.ConsecutiveFailures != 0 || state.DisabledUntil != 0 || state.LastSuccessTime != afterCoolDown.Unix() {
+		t.Fatal("success not reset", state)
+	}
+	// 关闭了自动禁用
+	healthSettings.AutoDisable = false
+	for i := 0; i < 5; i++ {
+		if applyResult(state, false, "timeout", afterCoolDown, healthSettings) == true {
+			t.Fatal("disabled when AutoDisable is false")
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+
+	nowTime := time.Date(2022, 6, 2, 12, 0, 0, 0, time.Local)
+	dailies := []models.SupplierHealthDaily{
+		{SupplierName: "zimuku", Day: "2022-06-02", QueryCount: 2, HitCount: 1, LatencyMsSum: 400,
+			DownloadCount: 3, DownloadFailedCount: 1, WinnerCount: 1, QuotaUsed: 3, QuotaLimit: 10},
+		{SupplierName: "assrt", Day: "2022-06-02", QueryCount: 1, ErrorCount: 1, LatencyMsSum: 1000, QuotaLimit: -1},
+		{SupplierName: "zimuku", Day: "2022-06-01", QueryCount: 2, HitCount: 2, LatencyMsSum: 200,
+			DownloadCount: 4, QuotaUsed: 4, QuotaLimit: 10},
+	}
+	states := []models.SupplierHealthState{
+		{SupplierName: "assrt", ConsecutiveFailures: 5, DisabledUntil: nowTime.Add(time.Hour).Unix(), DisabledCount: 1, LastError: "timeout"},
+		{SupplierName: "subhd", DisabledUntil: nowTime.Add(-time.Hour).Unix()},
+	}
+
+	healths := summarize(dailies, states, nowTime)
+	if len(healths) != 3 || healths[0].Name != "assrt" || healths[1].Name != "subhd" || healths[2].Name != "zimuku" {
+		t.Fatal("sort", healths)
+	}
+	assrt, subhd, zimuku := healths[0], healths[1], healths[2]
+	if assrt.Disabled == false || assrt.LastError != "timeout" || assrt.AvgLatencyMs != 1000 || assrt.HitRate != 0 {
+		t.Fatal("assrt", assrt)
+	}
+	// 冷却已经结束
+	if subhd.Disabled == true || subhd.DisabledUntil != 0 || subhd.QuotaLimit != -1 {
+		t.Fatal("subhd", subhd)
+	}
+	if zimuku.QueryCount != 4 || zimuku.HitRate != 0.75 || zimuku.AvgLatencyMs != 150 || zimuku.DownloadSuccessRate != 0.875 {
+		t.Fatal("zimuku", zimuku)
+	}
+	// 下载次数的使用情况取今天的
+	if zimuku.QuotaUsed != 3 || zimuku.QuotaLimit != 10 || len(zimuku.History) != 2 || zimuku.History[0].AvgLatencyMs != 200 {
+		t.Fatal("zimuku quota", zimuku)
+	}
+}
+
+func TestIsSupplierError(t *testing.T) {
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&url.Error{Op: "Get", URL: "http://root", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("http get error: %w", &url.Error{Op: "Get", URL: "http://root", Err: errors.New("timeout")}), true},
+		{supplier.NewStatusCodeError("search", 503, ""), true},
+		{fmt.Errorf("opensubtitles %w", supplier.ErrOverDailyDownloadLimit), true},
+		// 视频本身的问题
+		{common.XunLeiCIdIsEmpty, false},
+		{common.ShooterFileHashIsEmpty, false},
+		{common.VideoFileIsTooSmall, false},
+		{common.CanNotFindIMDBID, false},
+	}
+	for _, tt := range tests {
+		if got := IsSupplierError(tt.err); got != tt.want {
+			t.Errorf("IsSupplierError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package task_queue
+
+import (
+	task_queue2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+)
+
+// JobStatusChangedFunc 任务状态变化时的回调，注意调用的时候队列的锁还没有释放，所以回调中不能再调用 TaskQueue 的方法，也不要阻塞
+type JobStatusChangedFunc func(oneJob task_queue2.OneJob)
+
+// AddJobStatusChangedListener 注册任务状态变化的回调，name 相同的会被覆盖
+func (t *TaskQueue) AddJobStatusChangedListener(name string, f JobStatusChangedFunc) {
+
+	defer t.listenersLock.Unlock()
+	t.listenersLock.Lock()
+
+	t.jobStatusChangedListeners[name] = f
+}
+
+// RemoveJobStatusChangedListener 移除任务状态变化的回调
+func (t *TaskQueue) RemoveJobStatusChangedListener(name string) {
+
+	defer t.listenersLock.Unlock()
+	t.listenersLock.Lock()
+
+	delete(t.jobStatusChangedListeners, name)
+}
+
+// notifyJobStatusChanged 通知所有的回调，任务新增或者任务的状态发生变化的时候调用
+func (t *TaskQueue) notifyJobStatusChanged(oneJob task_queue2.OneJob) {
+
+	defer t.listenersLock.Unlock()
+	t.listenersLock.Lock()
+
+	for _, f := range t.jobStatusChangedListeners {
+		f(oneJob)
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/emby"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 	task_queue2 "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/cache_center"
@@ -28,6 +31,9 @@ type TaskQueue struct {
 	taskKeyMap          *treemap.Map              // 以每个任务的唯一 JobID 来存储每个 Job 的 优先级在哪里，这样可以快速查询
 	taskGroupBySeries   *treemap.Map              // 以每个任务的 SeriesRootPath 来存储每个任务，然后内层是一个 treeset，后续可以遍历删除即可
 	queueLock           sync.Mutex                // 公用这个锁
+
+	jobStatusChangedListeners map[string]JobStatusChangedFunc // 任务状态变化的回调
+	listenersLock             sync.Mutex
 }
 
 func NewTaskQueue(center *cache_center.CacheCenter) *TaskQueue {
@@ -38,6 +44,8 @@ func NewTaskQueue(center *cache_center.CacheCenter) *TaskQueue {
 		taskPriorityMapList: make([]*treemap.Map, 0),
 		taskKeyMap:          treemap.NewWithStringComparator(),
 		taskGroupBySeries:   treemap.NewWithStringComparator(),
+
+		jobStatusChangedListeners: make(map[string]JobStatusChangedFunc),
 	}
 	for i := 0; i <= taskPriorityCount; i++ {
 		tq.taskPriorityMapList = append(tq.taskPriorityMapList, treemap.NewWithStringComparator())
@@ -140,6 +148,8 @@ func (t *TaskQueue) Add(oneJob task_queue2.OneJob) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	// 新增的任务也通知一次
+	t.notifyJobStatusChanged(oneJob)
 
 	return true, nil
 }
@@ -156,6 +166,12 @@ func (t *TaskQueue) update(oneJob task_queue2.OneJob) (bool, error) {
 	// 这里需要判断是否有优先级的 Update，如果有就需要把之前缓存的表给更新
 	// 然后再插入到新的表中
 	taskPriorityIndex, _ := t.taskKeyMap.Get(oneJob.Id)
+	// 记录之前的任务状态，用于判断是否需要通知
+	oldJobStatus := oneJob.JobStatus
+	oldOneJobObj, found := t.taskPriorityMapList[taskPriorityIndex.(int)].Get(oneJob.Id)
+	if found == true {
+		oldJobStatus = oldOneJobObj.(task_queue2.OneJob).JobStatus
+	}
 	// 检查权限范围
 	oneJob = t.checkPriority(oneJob)
 	if oneJob.TaskPriority != taskPriorityIndex {
@@ -175,6 +191,9 @@ func (t *TaskQueue) update(oneJob task_queue2.OneJob) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if oldJobStatus != oneJob.JobStatus {
+		t.notifyJobStatusChanged(oneJob)
+	}
 
 	return true, nil
 }
@@ -255,6 +274,14 @@ func (t *TaskQueue) AutoDetectUpdateJobStatus(oneJob task_queue2.OneJob, inErr e
 		t.log.Warningln("AutoDetectUpdateJobStatus ==", oneJob.VideoFPath, "Job.ID", oneJob.Id, "Not Found")
 		return
 	}
+	if oneJob.JobStatus == task_queue2.Failed {
+		notify_center.Publish(notify.NewEvent(notify.JobFailed, filepath.Base(oneJob.VideoFPath)+", "+oneJob.ErrorInfo, map[string]string{
+			"job_id":         oneJob.Id,
+			"video_f_path":   oneJob.VideoFPath,
+			"error_info":     oneJob.ErrorInfo,
+			"download_times": strconv.Itoa(oneJob.DownloadTimes),
+		}))
+	}
 }
 
 func (t *TaskQueue) del(jobId string) (bool, error) {
@@ -347,3 +347,53 @@ func TestTaskQueue_AddAndGetOneJob(t *testing.T) {
 	}
 
 }
+
+func TestTaskQueue_JobStatusChangedListener(t *testing.T) {
+
+	defer func() {
+		cache_center.DelDb(taskQueueName)
+	}()
+	cache_center.DelDb(taskQueueName)
+
+	taskQueue := NewTaskQueue(cache_center.NewCacheCenter(taskQueueName, log_helper.GetLogger4Tester()))
+	defer func() {
+		taskQueue.Close()
+	}()
+	changedJobs := make([]task_queue2.OneJob, 0)
+	taskQueue.AddJobStatusChangedListener("test", func(oneJob task_queue2.OneJob) {
+		changedJobs = append(changedJobs, oneJob)
+	})
+
+	oneJob := *task_queue2.NewOneJob(common.Movie, pkg.RandStringBytesMaskImprSrcSB(10), DefaultTaskPriorityLevel)
+	bok, err := taskQueue.Add(oneJob)
+	if err != nil || bok == false {
+		t.Fatal("TestTaskQueue.Add", bok, err)
+	}
+	// 状态没有变化，不通知
+	oneJob.RetryTimes = 1
+	bok, err = taskQueue.Update(oneJob)
+	if err != nil || bok == false {
+		t.Fatal("TestTaskQueue.Update", bok, err)
+	}
+	oneJob.JobStatus = task_queue2.Downloading
+	bok, err = taskQueue.Update(oneJob)
+	if err != nil || bok == false {
+		t.Fatal("TestTaskQueue.Update", bok, err)
+	}
+	if len(changedJobs) != 2 {
+		t.Fatalf("len(changedJobs) = %d, want 2", len(changedJobs))
+	}
+	if changedJobs[1].JobStatus != task_queue2.Downloading {
+		t.Fatalf("changedJobs[1].JobStatus = %s, want %s", changedJobs[1].JobStatus, task_queue2.Downloading)
+	}
+
+	taskQueue.RemoveJobStatusChangedListener("test")
+	oneJob.JobStatus = task_queue2.Done
+	bok, err = taskQueue.Update(oneJob)
+	if err != nil || bok == false {
+		t.Fatal("TestTaskQueue.Update", bok, err)
+	}
+	if len(changedJobs) != 2 {
+		t.Fatalf("len(changedJobs) = %d, want 2 after remove listener", len(changedJobs))
+	}
+}
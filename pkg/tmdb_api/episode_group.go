@@ -0,0 +1,83 @@
+package tmdb_api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
+)
+
+/*
+	GetAbsoluteEpisodeMap 获取连续剧（动画）绝对集数与 季、集 的对应关系，tmdbID 是连续剧的 TMDB ID
+	1. 优先使用 TMDB 的剧集组（Episode Groups）中 Absolute 类型的那一个
+	2. 没有的话，就按每一季的集数推算
+*/
+func (t *TmdbApi) GetAbsoluteEpisodeMap(tmdbID string) (*anime_helper.EpisodeMap, error) {
+
+	intVar, err := strconv.Atoi(tmdbID)
+	if err != nil {
+		return nil, fmt.Errorf("error converting tmdb id = %s to int: %s", tmdbID, err)
+	}
+	options := make(map[string]string)
+	episodeGroups, err := t.tmdbClient.GetTVEpisodeGroups(intVar, options)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tmdb tv episode groups by id = %s: %s", tmdbID, err)
+	}
+	if episodeGroups.TVEpisodeGroupsResults != nil {
+		for _, result := range episodeGroups.Results {
+			if result.Type != episodeGroupTypeAbsolute {
+				continue
+			}
+			episodeMap, err := t.getEpisodeMapFromEpisodeGroup(result.ID)
+			if err != nil {
+				t.l.Warningln("GetAbsoluteEpisodeMap.getEpisodeMapFromEpisodeGroup", tmdbID, result.ID, err)
+				continue
+			}
+			if episodeMap.Len() > 0 {
+				return episodeMap, nil
+			}
+		}
+	}
+	// 没有 Absolute 类型的剧集组，按每一季的集数推算
+	tvDetails, err := t.tmdbClient.GetTVDetails(intVar, options)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tmdb tv details by id = %s: %s", tmdbID, err)
+	}
+	seasonEpisodeCounts := make(map[int]int)
+	for _, season := range tvDetails.Seasons {
+		seasonEpisodeCounts[season.SeasonNumber] = season.EpisodeCount
+	}
+	return anime_helper.NewEpisodeMapFromSeasonEpisodeCounts(seasonEpisodeCounts), nil
+}
+
+// getEpisodeMapFromEpisodeGroup 剧集组中按 Order 排序后的顺序就是绝对集数
+func (t *TmdbApi) getEpisodeMapFromEpisodeGroup(episodeGroupID string) (*anime_helper.EpisodeMap, error) {
+
+	options := make(map[string]string)
+	details, err := t.tmdbClient.GetTVEpisodeGroupsDetails(episodeGroupID, options)
+	if err != nil {
+		return nil, err
+	}
+	groups := details.Groups
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Order < groups[j].Order
+	})
+	items := make([]anime_helper.EpisodeMapItem, 0)
+	for _, group := range groups {
+		episodes := group.Episodes
+		sort.SliceStable(episodes, func(i, j int) bool {
+			return episodes[i].Order < episodes[j].Order
+		})
+		for _, episode := range episodes {
+			items = append(items, anime_helper.EpisodeMapItem{
+				Season:   episode.SeasonNumber,
+				Episode:  episode.EpisodeNumber,
+				Absolute: len(items) + 1,
+			})
+		}
+	}
+	return anime_helper.NewEpisodeMap(items), nil
+}
+
+const episodeGroupTypeAbsolute = 2 // TMDB 剧集组的类型，2 是 Absolute
@@ -0,0 +1,8 @@
+package backend
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/ass_style_normalizer"
+
+type ReplyAssStyleLibrary struct {
+	Status   string                             `json:"status"` // "status": "running","stopped"
+	Progress ass_style_normalizer.LibraryStatus `json:"progress"`
+}
@@ -0,0 +1,42 @@
+package backend
+
+import "time"
+
+// ReplyCandidateSearch 手动搜索一个视频的字幕，得到的候选字幕列表
+type ReplyCandidateSearch struct {
+	VideoFPath string          `json:"video_f_path"`
+	VideoType  int             `json:"video_type"`           // 0 是 movie or 1 是 series
+	Status     string          `json:"status"`               // running done failed
+	ErrorInfo  string          `json:"error_info,omitempty"` // 失败的原因
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	Candidates []CandidateInfo `json:"candidates"` // 按评分从高到低，forced 字幕在最后
+}
+
+type CandidateInfo struct {
+	ID           string  `json:"id"`            // 候选字幕的 ID，选择的时候使用
+	SupplierName string  `json:"supplier_name"` // 从那个字幕网站下载的
+	FileName     string  `json:"file_name"`     // 字幕的文件名
+	ReleaseName  string  `json:"release_name"`  // 字幕网站标注的发布名称，没有的时候是下载的原始文件名
+	Ext          string  `json:"ext"`           // 字幕的格式
+	Language     string  `json:"language"`      // 识别出来的语言
+	Score        float64 `json:"score"`         // 字幕评分系统给出的总分 0 - 100
+	IsForced     bool    `json:"is_forced"`     // 是否是 forced 字幕
+}
+
+// ReplyCandidateChoose 选择一个候选字幕，在后台保存到视频目录，通过 JobID 查询结果
+type ReplyCandidateChoose struct {
+	JobID       string    `json:"job_id"`
+	VideoFPath  string    `json:"video_f_path"`
+	CandidateID string    `json:"candidate_id"`
+	Status      string    `json:"status"`               // running done failed
+	ErrorInfo   string    `json:"error_info,omitempty"` // 失败的原因
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+const (
+	CandidateSearchRunning = "running"
+	CandidateSearchDone    = "done"
+	CandidateSearchFailed  = "failed"
+)
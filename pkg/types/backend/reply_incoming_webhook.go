@@ -0,0 +1,8 @@
+package backend
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/incoming_webhook"
+
+type ReplyIncomingWebhook struct {
+	Message string                    `json:"message"`
+	Results []incoming_webhook.Result `json:"results"` // 每个操作的结果，其他不需要处理的事件（比如 Test）为空
+}
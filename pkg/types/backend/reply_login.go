@@ -4,6 +4,8 @@ import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
 
 type ReplyLogin struct {
 	AccessToken string            `json:"access_token,omitempty"` // 登录成功后返回令牌
+	Username    string            `json:"username,omitempty"`     // 登录的用户名
+	Role        string            `json:"role,omitempty"`         // 登录的用户的角色，admin operator read_only
 	Settings    settings.Settings `json:"settings,omitempty"`     // 登录成功后返回当前的 Setting 信息
 	Message     string            `json:"message,omitempty"`
 }
@@ -17,6 +17,7 @@ type MovieInfoV2 struct {
 }
 
 type MovieSubsInfo struct {
-	SubUrlList   []string `json:"sub_url_list"`
-	SubFPathList []string `json:"sub_f_path_list"`
+	SubUrlList      []string `json:"sub_url_list"`
+	SubFPathList    []string `json:"sub_f_path_list"`
+	SubIsForcedList []bool   `json:"sub_is_forced_list"` // 与 SubFPathList 一一对应，是否是 forced 字幕
 }
@@ -0,0 +1,8 @@
+package backend
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_share"
+
+// ReplyPeerLibrarySubtitles 局域网内其他实例查询字幕缓存的结果，字幕文件需要再按 sha_256 下载
+type ReplyPeerLibrarySubtitles struct {
+	Subtitles []sub_share.ShareItem `json:"subtitles"`
+}
@@ -0,0 +1,7 @@
+package backend
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+
+type ReplyPostSaveHookRecords struct {
+	Records []models.PostSaveHookRecord `json:"records"` // 任务的字幕保存后回调的执行记录，最新的在前面
+}
@@ -15,6 +15,7 @@ type OneVideoInfo struct {
 	Episode                  int      `json:"episode"`
 	SubFPathList             []string `json:"sub_f_path_list"`
 	SubUrlList               []string `json:"sub_url_list"`
+	SubIsForcedList          []bool   `json:"sub_is_forced_list"` // 与 SubFPathList 一一对应，是否是 forced 字幕
 	MediaServerInsideVideoID string   `json:"media_server_inside_video_id"`
 }
 
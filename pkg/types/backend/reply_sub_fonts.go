@@ -0,0 +1,13 @@
+package backend
+
+type ReplySubFontsReports struct {
+	Reports []SubFontsReport `json:"reports"` // 视频每个 ass/ssa 字幕的字体检查结果
+}
+
+type SubFontsReport struct {
+	SubFPath     string   `json:"sub_f_path"`
+	Fonts        []string `json:"fonts"`         // 字幕中使用的字体
+	MissingFonts []string `json:"missing_fonts"` // 在本地字体目录中没有找到的字体
+	OutputFiles  []string `json:"output_files"`  // 输出的字体文件，或者写入 mkv 的附件名称
+	UpdatedAt    int64    `json:"updated_at"`    // 检查的时间，Unix 秒
+}
@@ -0,0 +1,7 @@
+package backend
+
+import "github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+
+type ReplySubPostProcessRecords struct {
+	Records []models.SubPostProcessRecord `json:"records"` // 任务写入字幕后每个处理步骤的结果，最新的在前面
+}
@@ -0,0 +1,47 @@
+package backend
+
+type ReplySupplierHealth struct {
+	Days      int              `json:"days"`      // 统计最近多少天的数据，包括今天
+	Suppliers []SupplierHealth `json:"suppliers"` // 按字幕源的名称排序
+}
+
+type SupplierHealth struct {
+	Name                string              `json:"name"`
+	Disabled            bool                `json:"disabled"`             // 是否因为连续失败被自动禁用
+	DisabledUntil       int64               `json:"disabled_until"`       // 禁用到什么时候，Unix 秒
+	DisabledCount       int64               `json:"disabled_count"`       // 被自动禁用的次数
+	ConsecutiveFailures int                 `json:"consecutive_failures"` // 连续失败的次数
+	LastError           string              `json:"last_error"`
+	LastErrorTime       int64               `json:"last_error_time"`
+	LastSuccessTime     int64               `json:"last_success_time"`
+	QueryCount          int64               `json:"query_count"`
+	HitCount            int64               `json:"hit_count"`
+	HitRate             float64             `json:"hit_rate"` // 至少返回了一个字幕的比例，0 - 1
+	ErrorCount          int64               `json:"error_count"`
+	DownloadCount       int64               `json:"download_count"`
+	DownloadFailedCount int64               `json:"download_failed_count"`
+	DownloadSuccessRate float64             `json:"download_success_rate"` // 0 - 1
+	WinnerCount         int64               `json:"winner_count"`          // 被选为最终字幕的次数
+	AvgLatencyMs        int64               `json:"avg_latency_ms"`        // 搜索的平均耗时
+	CheckCount          int64               `json:"check_count"`
+	CheckFailedCount    int64               `json:"check_failed_count"`
+	QuotaUsed           int                 `json:"quota_used"`  // 今天已经使用的下载次数
+	QuotaLimit          int                 `json:"quota_limit"` // 今天的下载次数限制，小于 0 是没有限制
+	History             []SupplierHealthDay `json:"history"`     // 每天的统计，最新的在前面
+}
+
+type SupplierHealthDay struct {
+	Day           string `json:"day"`
+	QueryCount    int64  `json:"query_count"`
+	HitCount      int64  `json:"hit_count"`
+	ErrorCount    int64  `json:"error_count"`
+	DownloadCount int64  `json:"download_count"`
+	WinnerCount   int64  `json:"winner_count"`
+	AvgLatencyMs  int64  `json:"avg_latency_ms"`
+	QuotaUsed     int    `json:"quota_used"`
+	QuotaLimit    int    `json:"quota_limit"`
+}
+
+type ReqSupplierHealthEnable struct {
+	Name string `json:"name" binding:"required"` // 需要重新启用的字幕源的名称
+}
@@ -1,9 +1,13 @@
 package backend
 
+import "github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_index"
+
 type ReplySystemStatus struct {
 	IsSetup           bool   `json:"is_setup"`             // 是否进行给初始化设置（引导设置），设置用户名什么的
 	Version           string `json:"version"`              // 系统的版本 v0.0.0
 	OS                string `json:"os"`                   // 系统的版本
 	ARCH              string `json:"arch"`                 // 系统的架构
 	IsRunningInDocker bool   `json:"is_running_in_docker"` // 是否在 Docker 中运行
+	// LastScanStatistics 上一次增量扫描的统计信息，没有启用增量扫描或者还没有扫描过为空
+	LastScanStatistics *scan_index.Statistics `json:"last_scan_statistics,omitempty"`
 }
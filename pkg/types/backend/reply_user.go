@@ -0,0 +1,35 @@
+package backend
+
+import "time"
+
+type ReplyUsers struct {
+	Users []UserItem `json:"users"`
+}
+
+// UserItem 用户的信息，不包含密码
+type UserItem struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReplyApiKeys struct {
+	ApiKeys []ApiKeyItem `json:"api_keys"`
+}
+
+// ApiKeyItem API Key 的信息，Key 本身只在新建的时候返回一次
+type ApiKeyItem struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	KeyPrefix  string    `json:"key_prefix"` // Key 的开头几位，用于区分
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // 没有使用过是零值
+}
+
+type ReplyAddApiKey struct {
+	Key    string     `json:"key"` // 完整的 Key，只会返回这一次
+	ApiKey ApiKeyItem `json:"api_key"`
+}
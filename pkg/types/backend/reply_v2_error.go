@@ -0,0 +1,24 @@
+package backend
+
+// ReplyV2Error /api/v2 统一的错误回复，HTTP 状态码不是 2xx 的时候都是这个结构
+type ReplyV2Error struct {
+	Error V2ErrorInfo `json:"error"`
+}
+
+type V2ErrorInfo struct {
+	Code    string `json:"code"`    // 错误的类型，见下面的 V2ErrCode 常量
+	Message string `json:"message"` // 错误的详细信息
+}
+
+func NewReplyV2Error(code string, message string) ReplyV2Error {
+	return ReplyV2Error{Error: V2ErrorInfo{Code: code, Message: message}}
+}
+
+const (
+	V2ErrCodeInvalidRequest = "invalid_request" // 请求的参数不对，400
+	V2ErrCodeUnauthorized   = "unauthorized"    // API Key 不对，401
+	V2ErrCodeForbidden      = "forbidden"       // API Key 所属用户的角色权限不够，403
+	V2ErrCodeNotFound       = "not_found"       // 视频、任务、候选字幕不存在，404
+	V2ErrCodeConflict       = "conflict"        // 任务已经在队列中、搜索正在进行，409
+	V2ErrCodeInternal       = "internal_error"  // 内部错误，500
+)
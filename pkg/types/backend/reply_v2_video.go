@@ -0,0 +1,25 @@
+package backend
+
+// ReplyV2VideoList /api/v2 视频库的列表，需要先扫描过一次视频库才会有内容
+type ReplyV2VideoList struct {
+	Movies []MovieInfoV2  `json:"movies"`
+	Series []SeasonInfoV2 `json:"series"`
+}
+
+// ReplyV2Subtitles /api/v2 一个视频目录下已有的字幕
+type ReplyV2Subtitles struct {
+	VideoFPath string           `json:"video_f_path"`
+	Subtitles  []V2SubtitleInfo `json:"subtitles"`
+}
+
+type V2SubtitleInfo struct {
+	SubFPath string `json:"sub_f_path"` // 字幕的物理路径
+	Ext      string `json:"ext"`        // 字幕的后缀名
+	IsForced bool   `json:"is_forced"`  // 是否是 forced 字幕
+}
+
+// ReplyV2Job /api/v2 任务操作的结果
+type ReplyV2Job struct {
+	JobID   string `json:"job_id"`
+	Message string `json:"message"`
+}
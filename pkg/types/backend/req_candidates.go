@@ -0,0 +1,13 @@
+package backend
+
+// ReqCandidateSearch 使用所有可用的字幕源搜索一个视频的字幕
+type ReqCandidateSearch struct {
+	VideoFPath string `json:"video_f_path" binding:"required"` // 视频的物理路径
+	VideoType  int    `json:"video_type" binding:"oneof=0 1"`  // 0 是 movie or 1 是 series
+}
+
+// ReqOneCandidate 指定一个视频的一个候选字幕，预览或者选择的时候使用
+type ReqOneCandidate struct {
+	VideoFPath  string `json:"video_f_path" binding:"required"` // 视频的物理路径
+	CandidateID string `json:"candidate_id" binding:"required"` // 候选字幕的 ID
+}
@@ -0,0 +1,6 @@
+package backend
+
+type ReqSubFonts struct {
+	VideoFPath string `json:"video_f_path" binding:"required"`
+	SubFPath   string `json:"sub_f_path"` // 重新检查的时候需要，获取结果的时候不需要
+}
@@ -0,0 +1,19 @@
+package backend
+
+// ReqAddUser 管理员新增一个用户
+type ReqAddUser struct {
+	Username string `json:"username" binding:"required,alphanum"`                   // 用户名
+	Password string `json:"password" binding:"required,min=6,max=30"`               // 密码
+	Role     string `json:"role" binding:"required,oneof=admin operator read_only"` // 角色
+}
+
+// ReqUpdateUser 管理员修改一个用户的角色或者重置密码，为空的不修改
+type ReqUpdateUser struct {
+	Role     string `json:"role" binding:"omitempty,oneof=admin operator read_only"` // 角色
+	Password string `json:"password" binding:"omitempty,min=6,max=30"`               // 新的密码
+}
+
+// ReqAddApiKey 新建一个 API Key
+type ReqAddApiKey struct {
+	Name string `json:"name" binding:"required,max=64"` // 名称，比如 sonarr、emby
+}
@@ -0,0 +1,20 @@
+package backend
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/task_queue"
+)
+
+// ReqV2AddJob /api/v2 添加一个下载任务
+type ReqV2AddJob struct {
+	VideoType                 int    `json:"video_type" binding:"oneof=0 1"`                   // 0 是 movie or 1 是 series
+	PhysicalVideoFileFullPath string `json:"physical_video_file_full_path" binding:"required"` // 视频的物理路径
+	TaskPriorityLevel         int    `json:"task_priority_level" binding:"min=0,max=10"`       // 任务优先级，0 - 10 个级别，0 是最高
+	MediaServerInsideVideoID  string `json:"media_server_inside_video_id"`                     // 媒体服务器内部视频ID
+	IsBluray                  bool   `json:"is_bluray"`                                        // 是否是蓝光，目前只支持电影的蓝光
+}
+
+// ReqV2ChangeJob /api/v2 修改一个任务的优先级和状态
+type ReqV2ChangeJob struct {
+	TaskPriority string               `json:"task_priority" binding:"required,oneof=high middle low"` // 任务的优先级
+	JobStatus    task_queue.JobStatus `json:"job_status" binding:"oneof=0 5"`                         // 任务的状态 允许设置 Waiting(0) or Ignore(5)
+}
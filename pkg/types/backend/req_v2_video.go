@@ -0,0 +1,14 @@
+package backend
+
+import "mime/multipart"
+
+// ReqV2Video /api/v2 中指定一个视频的查询参数
+type ReqV2Video struct {
+	VideoFPath string `form:"video_f_path" json:"video_f_path" binding:"required"` // 视频的物理路径
+}
+
+// ReqV2UploadSubtitle /api/v2 上传一个字幕到视频目录，multipart/form-data
+type ReqV2UploadSubtitle struct {
+	VideoFPath string                `form:"video_f_path" binding:"required"` // 视频的物理路径
+	File       *multipart.FileHeader `form:"file" binding:"required"`         // 字幕文件
+}
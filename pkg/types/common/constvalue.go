@@ -22,6 +22,10 @@ const (
 	SubSiteAssrt            = "assrt"
 	SubSiteA4K              = "a4k"
 	SubSiteSubtitleBest     = "subtitle_best"
+	SubSitePeerLibrary      = "peer_library"
+	SubSiteLocalFolder      = "local_folder"
+	SubSiteOpenSubtitles    = "opensubtitles"
+	SubSiteCustomPrefix     = "custom_" // 由设置描述的字幕源，名称是这个前缀加上设置的名称
 )
 
 const (
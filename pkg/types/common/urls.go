@@ -21,4 +21,9 @@ const (
 	SubSubtitleBestSearchTVSeasonPackageUrl     = "/search-tv-season-package"
 	SubSubtitleBestSearchTVSeasonPackageByIDUrl = "/search-tv-season-package-id"
 	SubSubtitleBestGetDlURLUrl                  = "/get-dl-url"
+
+	SubOpenSubtitlesRootUrlDef  = "https://api.opensubtitles.com/api/v1"
+	SubOpenSubtitlesLoginUrl    = "/login"
+	SubOpenSubtitlesSearchUrl   = "/subtitles"
+	SubOpenSubtitlesDownloadUrl = "/download"
 )
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+type EventType int
+
+const (
+	SubDownloaded        EventType = iota // 视频第一次下载到字幕
+	SubUpgraded                           // 已有的字幕被评分更高的字幕替换
+	TimelineFixed                         // 字幕的时间轴被校正了
+	JobFailed                             // 任务超过了重试的期限，彻底失败了
+	SupplierDown                          // 字幕网站检测不可用
+	DailyQuotaReached                     // 字幕网站今日的下载次数用完了
+	ScanFinished                          // 一次视频扫描结束，附带统计信息
+	Test                                  // 测试通知渠道是否可用
+)
+
+func (c EventType) String() string {
+	switch c {
+	case SubDownloaded:
+		return "sub_downloaded"
+	case SubUpgraded:
+		return "sub_upgraded"
+	case TimelineFixed:
+		return "timeline_fixed"
+	case JobFailed:
+		return "job_failed"
+	case SupplierDown:
+		return "supplier_down"
+	case DailyQuotaReached:
+		return "daily_quota_reached"
+	case ScanFinished:
+		return "scan_finished"
+	case Test:
+		return "test"
+	}
+	return "N/A"
+}
+
+// Title 默认的通知标题
+func (c EventType) Title() string {
+	switch c {
+	case SubDownloaded:
+		return "字幕下载完成"
+	case SubUpgraded:
+		return "字幕已升级"
+	case TimelineFixed:
+		return "字幕时间轴已校正"
+	case JobFailed:
+		return "字幕下载任务失败"
+	case SupplierDown:
+		return "字幕网站不可用"
+	case DailyQuotaReached:
+		return "字幕网站今日下载次数已用完"
+	case ScanFinished:
+		return "视频扫描完成"
+	case Test:
+		return "通知测试"
+	}
+	return "N/A"
+}
+
+// Event 一次通知的事件，Fields 是附带的详细信息，可以在通知的模板中使用
+type Event struct {
+	Type    EventType         `json:"-"`
+	Name    string            `json:"event"`   // 事件类型的名称，EventType.String()
+	Title   string            `json:"title"`   // 通知的标题
+	Message string            `json:"message"` // 通知的内容
+	Fields  map[string]string `json:"fields"`  // 附带的详细信息，比如 video_f_path、supplier、score
+	Time    time.Time         `json:"time"`    // 事件发生的时间
+	// OnceADayKey 不为空的时候，同一个 Key 的事件一天只会通知一次，比如某个网站的下载次数用完了
+	OnceADayKey string `json:"-"`
+}
+
+func NewEvent(eventType EventType, message string, fields map[string]string) *Event {
+
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	return &Event{
+		Type:    eventType,
+		Name:    eventType.String(),
+		Title:   eventType.Title(),
+		Message: message,
+		Fields:  fields,
+		Time:    time.Now(),
+	}
+}
+
+// SetOnceADay 设置同一个 Key 的事件一天只通知一次
+func (e *Event) SetOnceADay(key string) *Event {
+	e.OnceADayKey = fmt.Sprintf("%s_%s", e.Name, key)
+	return e
+}
+
+// Text 标题 + 内容，给只支持纯文本的通知渠道使用
+func (e Event) Text() string {
+	return e.Title + "\n" + e.Message
+}
+
+// ParseEventType 从设置中的事件名称转换为 EventType
+func ParseEventType(name string) (EventType, bool) {
+	for i := SubDownloaded; i <= Test; i++ {
+		if i.String() == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
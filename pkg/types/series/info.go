@@ -1,8 +1,10 @@
 package series
 
 import (
-	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
 	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/anime_helper"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
 )
 
 /*
@@ -10,15 +12,35 @@ import (
 	所以命名很标注，使用 GetVideoInfoFromFileName 读取 SxxExx 问题不大
 */
 type SeriesInfo struct {
-	ImdbId           string
-	Name             string
-	Year             int
-	ReleaseDate      string
-	EpList           []EpisodeInfo
-	DirPath          string
-	SeasonDict       map[int]int
-	NeedDlSeasonDict map[int]int
-	NeedDlEpsKeyList map[string]EpisodeInfo // SxEx
+	ImdbId             string
+	TmdbId             string
+	Name               string
+	Year               int
+	ReleaseDate        string
+	EpList             []EpisodeInfo
+	DirPath            string
+	SeasonDict         map[int]int
+	NeedDlSeasonDict   map[int]int
+	NeedDlEpsKeyList   map[string]EpisodeInfo   // SxEx
+	IsAnime            bool                     // 是否是动画，动画会使用绝对集数去辅助搜索以及整理字幕
+	AbsoluteEpisodeMap *anime_helper.EpisodeMap // 动画的绝对集数与 季、集 的对应关系，获取不到的时候是 nil
+}
+
+// GetSeasonEpisodeByAbsolute 动画的绝对集数转为 季、集，优先使用 AbsoluteEpisodeMap，没有的话从已有的剧集中查找
+func (s SeriesInfo) GetSeasonEpisodeByAbsolute(absolute int) (int, int, bool) {
+
+	if s.AbsoluteEpisodeMap != nil {
+		season, episode, ok := s.AbsoluteEpisodeMap.GetSeasonEpisode(absolute)
+		if ok == true {
+			return season, episode, true
+		}
+	}
+	for _, episodeInfo := range s.EpList {
+		if episodeInfo.AbsoluteEpisode > 0 && episodeInfo.AbsoluteEpisode == absolute {
+			return episodeInfo.Season, episodeInfo.Episode, true
+		}
+	}
+	return 0, 0, false
 }
 
 type EpisodeInfo struct {
@@ -26,6 +48,8 @@ type EpisodeInfo struct {
 	Title                    string
 	Season                   int
 	Episode                  int
+	EpisodeEnd               int       // 多集合并的视频（S01E01E02），最后一集的集数，单集的视频是 0
+	AbsoluteEpisode          int       // 动画的绝对集数，获取不到的时候是 0
 	SubAlreadyDownloadedList []SubInfo // 已经下载在当前视频目录下的字幕列表
 	Dir                      string    // 这里需要记录字幕的位置，因为需要在同级目录匹配相应的字幕才行
 	FileFullPath             string    // 视频文件的全路径
@@ -33,6 +57,23 @@ type EpisodeInfo struct {
 	AiredTime                string    // 播出的时间
 }
 
+// IsMultiEpisode 是否是多集合并的视频
+func (e EpisodeInfo) IsMultiEpisode() bool {
+	return e.EpisodeEnd > e.Episode
+}
+
+// GetEpisodes 这个视频包含的所有集数
+func (e EpisodeInfo) GetEpisodes() []int {
+	if e.IsMultiEpisode() == false {
+		return []int{e.Episode}
+	}
+	episodes := make([]int, 0, e.EpisodeEnd-e.Episode+1)
+	for i := e.Episode; i <= e.EpisodeEnd; i++ {
+		episodes = append(episodes, i)
+	}
+	return episodes
+}
+
 type SubInfo struct {
 	Title        string
 	Season       int
@@ -0,0 +1,27 @@
+package sub_post_process
+
+import (
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/subparser"
+)
+
+// Context 一个字幕在处理步骤之间传递的信息
+type Context struct {
+	VideoFPath  string             // 视频的全路径
+	SubFPath    string             // 字幕的全路径，某些步骤可能会改变字幕的文件名，比如格式转换
+	SubFileInfo subparser.FileInfo // 写入的字幕的信息，是写入时的内容，不会随着处理步骤更新
+	IsUTF8      bool               // 字幕文件当前是否确认是 UTF-8 编码，由编码转换的步骤设置
+}
+
+// StageResult 一个处理步骤的执行结果
+type StageResult struct {
+	Stage      string `json:"stage"`       // 步骤的名称
+	Status     string `json:"status"`      // success skip failure
+	Message    string `json:"message"`     // 跳过的原因或者失败的原因
+	DurationMs int64  `json:"duration_ms"` // 执行的耗时，毫秒
+}
+
+const (
+	StatusSuccess = "success"
+	StatusSkip    = "skip"
+	StatusFailure = "failure"
+)
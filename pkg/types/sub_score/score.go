@@ -0,0 +1,82 @@
+package sub_score
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/vad"
+)
+
+// ScoreContext 一个视频进行字幕评分时候需要的上下文信息，一个视频对应一个
+type ScoreContext struct {
+	VideoFPath      string        // 视频的全路径
+	VideoDuration   float64       // 视频的时长（秒），获取不到是 0
+	AudioVADInfos   []vad.VADInfo // 视频音频的 VAD 信息，没有开启时间轴匹配评分的时候是 nil
+	SubTypePriority int           // 字幕格式的优先级，0 - 原样, 1 - srt , 2 - ass/ssa
+	SiteSequence    []string      // 网站的优先级，从高到低
+}
+
+func NewScoreContext(videoFPath string, videoDuration float64, audioVADInfos []vad.VADInfo, subTypePriority int, siteSequence []string) *ScoreContext {
+	return &ScoreContext{VideoFPath: videoFPath, VideoDuration: videoDuration, AudioVADInfos: audioVADInfos, SubTypePriority: subTypePriority, SiteSequence: siteSequence}
+}
+
+// ScoreItem 一项评分的结果
+type ScoreItem struct {
+	Name   string  `json:"name"`   // 评分项的名称
+	Score  float64 `json:"score"`  // 原始的评分 0 - 1
+	Weight float64 `json:"weight"` // 权重
+}
+
+/*
+	Breakdown 一个字幕的评分明细
+	WeightSum 是所有设置了权重的评分项的权重之和，包括这次没能评分的项（比如 没有 VAD 信息的时间轴匹配），
+	这样不同的字幕即使参与评分的项不一样，总分也是可以比较的，没能评分的项就是 0 分
+*/
+type Breakdown struct {
+	Total     float64     `json:"total"`      // 加权后的总分 0 - 100
+	WeightSum float64     `json:"weight_sum"` // 总分归一化使用的权重之和，为 0 的时候使用参与评分的项的权重之和
+	Items     []ScoreItem `json:"items"`      // 每一项的评分，不参与评分的项不会出现
+}
+
+func NewBreakdown(weightSum float64) *Breakdown {
+	return &Breakdown{WeightSum: weightSum, Items: make([]ScoreItem, 0)}
+}
+
+// Add 添加一项评分，并重新计算总分
+func (b *Breakdown) Add(name string, score, weight float64) {
+
+	b.Items = append(b.Items, ScoreItem{Name: name, Score: score, Weight: weight})
+
+	sumWeight := 0.0
+	sumScore := 0.0
+	for _, item := range b.Items {
+		sumWeight += item.Weight
+		sumScore += item.Score * item.Weight
+	}
+	if b.WeightSum > 0 {
+		sumWeight = b.WeightSum
+	}
+	if sumWeight <= 0 {
+		b.Total = 0
+		return
+	}
+	b.Total = sumScore / sumWeight * 100
+}
+
+// Get 获取某一项的评分
+func (b Breakdown) Get(name string) (float64, bool) {
+	for _, item := range b.Items {
+		if item.Name == name {
+			return item.Score, true
+		}
+	}
+	return 0, false
+}
+
+func (b Breakdown) String() string {
+	itemStrings := make([]string, 0, len(b.Items))
+	for _, item := range b.Items {
+		itemStrings = append(itemStrings, fmt.Sprintf("%s: %.2f x %.1f", item.Name, item.Score, item.Weight))
+	}
+	return fmt.Sprintf("%.2f [%s]", b.Total, strings.Join(itemStrings, ", "))
+}
@@ -0,0 +1,20 @@
+package sub_share
+
+// ShareItem 需要上传到字幕共享中心的一个字幕，来自 VideoSubInfo 或者 LowVideoSubInfo
+type ShareItem struct {
+	IMDBID       string `json:"imdb_id"`
+	TMDBID       string `json:"tmdb_id"`
+	Feature      string `json:"feature"` // 视频的特征码
+	IsMovie      bool   `json:"is_movie"`
+	Season       int    `json:"season"`
+	Episode      int    `json:"episode"`
+	LanguageISO  string `json:"language_iso"`
+	ChineseISO   string `json:"chinese_iso"`
+	MyLanguage   string `json:"my_language"`
+	IsDouble     bool   `json:"is_double"`
+	ExtraPreName string `json:"extra_pre_name"`
+	SHA256       string `json:"sha_256"`
+	SubName      string `json:"sub_name"`  // 字幕的文件名
+	LowTrust     bool   `json:"low_trust"` // 低可信度的字幕，用户目录中已有的字幕，不确定与视频是否匹配
+	SubFPath     string `json:"-"`         // 字幕在本地缓存中的全路径
+}
@@ -12,22 +12,33 @@ import (
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/common"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/language"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/sub_score"
 )
 
 type FileInfo struct {
-	PrefixDialogueString string              // 在 Dialogue: 这个关键词之前的字符串，ass 中的字体以及其他信息的描述
-	Content              string              // 字幕的内容
-	FromWhereSite        string              // 从那个网站下载的
-	Name                 string              // 字幕的名称，注意，这里需要额外的赋值，不会自动检测
-	Ext                  string              // 字幕的后缀名
-	Lang                 language.MyLanguage // 识别出来的语言
-	FileFullPath         string              // 字幕文件的全路径
-	Data                 []byte              // 字幕的二进制文件内容
-	Dialogues            []OneDialogue       // 整个字幕文件的所有对话，如果是做时间轴匹配，就使用原始的
-	DialoguesFilter      []OneDialogue       // 整个字幕文件的所有对话，过滤掉特殊字符的对白
-	DialoguesFilterEx    []OneDialogueEx     // 整个字幕文件的所有对话，过滤掉特殊字符的对白，这里会把一句话中支持的 中、英、韩、日 四国语言给分离出来
-	CHLines              []string            // 抽取出所有的中文对话
-	OtherLines           []string            // 抽取出所有的第二语言对话，可能是英文、韩文、日文
+	PrefixDialogueString string               // 在 Dialogue: 这个关键词之前的字符串，ass 中的字体以及其他信息的描述
+	Content              string               // 字幕的内容
+	FromWhereSite        string               // 从那个网站下载的
+	Name                 string               // 字幕的名称，注意，这里需要额外的赋值，不会自动检测
+	Ext                  string               // 字幕的后缀名
+	Lang                 language.MyLanguage  // 识别出来的语言
+	FileFullPath         string               // 字幕文件的全路径
+	Data                 []byte               // 字幕的二进制文件内容
+	Dialogues            []OneDialogue        // 整个字幕文件的所有对话，如果是做时间轴匹配，就使用原始的
+	DialoguesFilter      []OneDialogue        // 整个字幕文件的所有对话，过滤掉特殊字符的对白
+	DialoguesFilterEx    []OneDialogueEx      // 整个字幕文件的所有对话，过滤掉特殊字符的对白，这里会把一句话中支持的 中、英、韩、日 四国语言给分离出来
+	CHLines              []string             // 抽取出所有的中文对话
+	OtherLines           []string             // 抽取出所有的第二语言对话，可能是英文、韩文、日文
+	IsForced             bool                 // 是否是 forced 字幕（只翻译画面文字、外语片段），注意，这里需要额外的赋值，不会自动检测
+	ScoreBreakdown       *sub_score.Breakdown // 字幕评分系统给出的评分明细，注意，这里需要额外的赋值，不会自动计算
+}
+
+// GetScore 字幕评分系统给出的总分 0 - 100，没有评分过就是 0
+func (f FileInfo) GetScore() float64 {
+	if f.ScoreBreakdown == nil {
+		return 0
+	}
+	return f.ScoreBreakdown.Total
 }
 
 // SaveTranslated 保存字幕文件，注意，这里是用于翻译后的字幕文件
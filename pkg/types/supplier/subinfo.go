@@ -13,13 +13,14 @@ type SubInfo struct {
 	Name          string              `json:"name"`           // 字幕的名称，这个比较随意，优先是影片的名称，然后才是从网上下载字幕的对应名称
 	Language      language.MyLanguage `json:"language"`       // 字幕的语言
 	FileUrl       string              `json:"file-url"`       // 字幕文件下载的路径
-	Score         int64               `json:"score"`          // TODO 字幕的评分，需要有一个独立的评价体系。首先是每个网站自己的评价排序，然后再到统一的评分体系
+	Score         int64               `json:"score"`          // 字幕网站自己的评分（排序），统一的评分见 sub_scorer，在 MarkingSystem 中计算
 	Offset        int64               `json:"offset"`         // 字幕的偏移
 	Ext           string              `json:"ext"`            // 字幕文件的后缀名带点，有可能是直接能用的字幕文件，也可能是压缩包
 	Data          []byte              `json:"data"`           // 字幕文件的二进制数据
 	Season        int                 `json:"season"`         // 第几季，默认-1
 	Episode       int                 `json:"episode"`        // 第几集，默认-1
 	IsFullSeason  bool                `json:"is_full_season"` // 是否是全季的字幕
+	ReleaseName   string              `json:"release_name"`   // 字幕对应的发布名称，下载的原始文件名或者字幕网站标注的视频名称，用于匹配发布组、片源
 	fileUrlSha256 string              // 字幕文件的 FileUrl sha256 值
 }
 
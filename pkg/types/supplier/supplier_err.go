@@ -0,0 +1,27 @@
+package supplier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StatusCodeError 字幕源返回了非预期的 HTTP 状态码，健康统计中会算作字幕源的一次失败
+type StatusCodeError struct {
+	What       string // 哪个请求出错了，比如 search、download file
+	StatusCode int
+	Message    string // 字幕源返回的错误信息，可以为空
+}
+
+func NewStatusCodeError(what string, statusCode int, message string) *StatusCodeError {
+	return &StatusCodeError{What: what, StatusCode: statusCode, Message: message}
+}
+
+func (e *StatusCodeError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s status code: %d", e.What, e.StatusCode)
+	}
+	return fmt.Sprintf("%s status code: %d, message: %s", e.What, e.StatusCode, e.Message)
+}
+
+// ErrOverDailyDownloadLimit 字幕源今日的下载次数用完了，各个字幕源自己的错误可以 %w 包装这个
+var ErrOverDailyDownloadLimit = errors.New("over daily download limit")
@@ -23,6 +23,7 @@ type OneJob struct {
 	SeriesRootDirPath        string           `json:"series_root_dir_path"`         // 连续剧的目录
 	Season                   int              `json:"season"`                       // 如果对应的是电影则可能是 0，没有
 	Episode                  int              `json:"episode"`                      // 如果对应的是电影则可能是 0，没有
+	EpisodeEnd               int              `json:"episode_end"`                  // 多集合并的视频（S01E01E02），最后一集的集数，单集的视频是 0
 	JobStatus                JobStatus        `json:"job_status"`                   // 任务的状态
 	TaskPriority             int              `json:"task_priority" default:"5"`    // 任务的优先级，0 - 10 个级别，0 是最高，10 是最低
 	RetryTimes               int              `json:"retry_times"`                  // 重试了多少次
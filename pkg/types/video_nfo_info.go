@@ -4,16 +4,18 @@ import "strconv"
 
 // VideoNfoInfo 从 movie.xml *.nfo 中解析出的视频信息
 type VideoNfoInfo struct {
-	ImdbId        string
-	TmdbId        string
-	TVdbId        string
-	Season        int
-	Episode       int
-	Year          string
-	Title         string
-	OriginalTitle string
-	ReleaseDate   string
-	IsMovie       bool
+	ImdbId          string
+	TmdbId          string
+	TVdbId          string
+	Season          int
+	Episode         int
+	AbsoluteEpisode int      // 动画的绝对集数，nfo 中没有就是 0
+	Genres          []string // 类型，用于判断是否是动画
+	Year            string
+	Title           string
+	OriginalTitle   string
+	ReleaseDate     string
+	IsMovie         bool
 }
 
 func (v *VideoNfoInfo) GetYear() int {
@@ -0,0 +1,30 @@
+package user_center
+
+const (
+	RoleAdmin    = "admin"     // 管理员，可以修改设置、管理用户
+	RoleOperator = "operator"  // 操作员，可以下载字幕、管理任务，不能修改设置
+	RoleReadOnly = "read_only" // 只读，只能查看
+)
+
+// IsValidRole 是否是支持的角色
+func IsValidRole(role string) bool {
+	return roleLevel(role) > 0
+}
+
+// RoleAllowed role 是否满足 needRole 的权限要求，admin > operator > read_only
+func RoleAllowed(role, needRole string) bool {
+	return roleLevel(role) > 0 && roleLevel(role) >= roleLevel(needRole)
+}
+
+func roleLevel(role string) int {
+	switch role {
+	case RoleAdmin:
+		return 3
+	case RoleOperator:
+		return 2
+	case RoleReadOnly:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,477 @@
+package user_center
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/dao"
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+/*
+	UserCenter 多用户的管理，用户、会话、API Key 都持久化到数据库中
+	1. 密码使用 bcrypt 保存，会话的 Token 以及 API Key 只保存 sha256
+	2. 一个用户可以同时有多个会话，会话到期后需要重新登录
+	3. API Key 归属于某一个用户，权限跟随这个用户的角色
+*/
+type UserCenter struct {
+	log    *logrus.Logger
+	db     *gorm.DB
+	locker sync.Mutex // 修改用户的时候需要检查是否还有管理员，需要串行
+}
+
+func NewUserCenter(log *logrus.Logger, db *gorm.DB) *UserCenter {
+	return &UserCenter{log: log, db: db}
+}
+
+// Get 获取使用 dao 数据库的 UserCenter
+func Get(log *logrus.Logger) *UserCenter {
+	userCenterOnce.Do(func() {
+		userCenterInstance = NewUserCenter(log, dao.GetDb())
+	})
+	return userCenterInstance
+}
+
+// Identity 通过鉴权后的用户信息
+type Identity struct {
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	ApiKeyName string `json:"api_key_name,omitempty"` // 使用 API Key 鉴权的时候，Key 的名称
+}
+
+// IsSetup 是否已经有用户了，没有就需要进行 Setup
+func (u *UserCenter) IsSetup() bool {
+
+	var count int64
+	err := u.db.Model(&models.User{}).Count(&count).Error
+	if err != nil {
+		u.log.Errorln("UserCenter.IsSetup", err)
+		return false
+	}
+	return count > 0
+}
+
+// AddUser 新增一个用户
+func (u *UserCenter) AddUser(username, password, role string) (*models.User, error) {
+
+	if username == "" {
+		return nil, ErrUsernameEmpty
+	}
+	if IsValidRole(role) == false {
+		return nil, ErrInvalidRole
+	}
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	var count int64
+	err = u.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUserExists
+	}
+	user := models.User{
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+	}
+	err = u.db.Create(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers 所有的用户
+func (u *UserCenter) ListUsers() ([]models.User, error) {
+
+	users := make([]models.User, 0)
+	err := u.db.Order("id").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateUser 修改用户的角色或者密码，为空的不修改，修改了密码会让这个用户的所有会话失效
+func (u *UserCenter) UpdateUser(userID uint, role, password string) error {
+
+	if role != "" && IsValidRole(role) == false {
+		return ErrInvalidRole
+	}
+
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	user, err := u.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if role != "" && role != user.Role {
+		if user.Role == RoleAdmin {
+			err = u.checkOtherAdmin(userID)
+			if err != nil {
+				return err
+			}
+		}
+		user.Role = role
+	}
+	if password != "" {
+		user.PasswordHash, err = hashPassword(password)
+		if err != nil {
+			return err
+		}
+	}
+	err = u.db.Save(user).Error
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		return u.db.Where("user_id = ?", userID).Delete(&models.UserSession{}).Error
+	}
+	return nil
+}
+
+// DelUser 删除用户，以及这个用户的会话和 API Key，不能删除最后一个管理员
+func (u *UserCenter) DelUser(userID uint) error {
+
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	user, err := u.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == RoleAdmin {
+		err = u.checkOtherAdmin(userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&models.UserSession{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserApiKey{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(user).Error
+	})
+}
+
+// ChangePassword 用户修改自己的密码，需要验证原始的密码，成功后这个用户的所有会话失效
+func (u *UserCenter) ChangePassword(userID uint, orgPassword, newPassword string) error {
+
+	user, err := u.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if checkPassword(user.PasswordHash, orgPassword) == false {
+		return ErrOrgPasswordError
+	}
+	return u.UpdateUser(userID, "", newPassword)
+}
+
+// Login 验证用户名和密码，成功后新建一个会话，返回会话的 Token
+func (u *UserCenter) Login(username, password string) (string, *models.User, error) {
+
+	var user models.User
+	err := u.db.Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) == true {
+		return "", nil, ErrUsernameOrPasswordError
+	} else if err != nil {
+		return "", nil, err
+	}
+	if checkPassword(user.PasswordHash, password) == false {
+		return "", nil, ErrUsernameOrPasswordError
+	}
+
+	// 顺便清理过期的会话
+	err = u.db.Where("expires_at < ?", time.Now()).Delete(&models.UserSession{}).Error
+	if err != nil {
+		u.log.Warningln("UserCenter.Login, clean expired sessions", err)
+	}
+
+	token, err := randomToken("")
+	if err != nil {
+		return "", nil, err
+	}
+	err = u.db.Create(&models.UserSession{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(SessionExpiration),
+	}).Error
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &user, nil
+}
+
+// Logout 注销一个会话
+func (u *UserCenter) Logout(token string) error {
+	return u.db.Where("token_hash = ?", hashToken(token)).Delete(&models.UserSession{}).Error
+}
+
+// CheckSession 校验会话的 Token
+func (u *UserCenter) CheckSession(token string) (*Identity, error) {
+
+	if token == "" {
+		return nil, ErrSessionInvalid
+	}
+	var session models.UserSession
+	err := u.db.Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) == true {
+		return nil, ErrSessionInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	user, err := u.getUser(session.UserID)
+	if errors.Is(err, ErrUserNotFound) == true {
+		return nil, ErrSessionInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	return &Identity{UserID: user.ID, Username: user.Username, Role: user.Role}, nil
+}
+
+// AddApiKey 给用户新建一个 API Key，Key 只会在这里返回一次
+func (u *UserCenter) AddApiKey(userID uint, name string) (string, *models.UserApiKey, error) {
+
+	if name == "" {
+		return "", nil, ErrApiKeyNameEmpty
+	}
+	_, err := u.getUser(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := randomToken(apiKeyPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey, err := u.saveApiKey(userID, name, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// ListApiKeys 某一个用户的 API Key，userID 为 0 返回所有用户的
+func (u *UserCenter) ListApiKeys(userID uint) ([]models.UserApiKey, error) {
+
+	apiKeys := make([]models.UserApiKey, 0)
+	query := u.db.Order("id")
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Find(&apiKeys).Error
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// DelApiKey 吊销一个 API Key，userID 不为 0 的时候只能吊销这个用户自己的
+func (u *UserCenter) DelApiKey(apiKeyID uint, userID uint) error {
+
+	query := u.db.Unscoped().Where("id = ?", apiKeyID)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	result := query.Delete(&models.UserApiKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// CheckApiKey 校验 API Key
+func (u *UserCenter) CheckApiKey(key string) (*Identity, error) {
+
+	if key == "" {
+		return nil, ErrApiKeyInvalid
+	}
+	var apiKey models.UserApiKey
+	err := u.db.Where("key_hash = ?", hashToken(key)).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) == true {
+		return nil, ErrApiKeyInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	user, err := u.getUser(apiKey.UserID)
+	if errors.Is(err, ErrUserNotFound) == true {
+		return nil, ErrApiKeyInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	// 不需要每次调用都更新
+	if time.Since(apiKey.LastUsedAt) > time.Minute {
+		err = u.db.Model(&apiKey).UpdateColumn("last_used_at", time.Now()).Error
+		if err != nil {
+			u.log.Warningln("UserCenter.CheckApiKey, update last_used_at", err)
+		}
+	}
+	return &Identity{UserID: user.ID, Username: user.Username, Role: user.Role, ApiKeyName: apiKey.Name}, nil
+}
+
+/*
+	MigrateFromSettings 从之前单用户的设置迁移，返回 settings 是否被修改了，需要保存
+	1. 还没有用户的时候，settings 中的账号密码作为管理员，然后清除 settings 中的密码
+	2. 还没有 API Key 的时候，settings 中的 Key 作为这个管理员名为 default 的 API Key，然后清除 settings 中的 Key
+*/
+func (u *UserCenter) MigrateFromSettings(nowSettings *settings.Settings) (bool, error) {
+
+	changed := false
+	if nowSettings.UserInfo == nil || nowSettings.UserInfo.Password == "" {
+		return changed, nil
+	}
+	if u.IsSetup() == false {
+		if nowSettings.UserInfo.Username == "" {
+			return changed, nil
+		}
+		user, err := u.AddUser(nowSettings.UserInfo.Username, nowSettings.UserInfo.Password, RoleAdmin)
+		if err != nil {
+			return changed, err
+		}
+		u.log.Infoln("UserCenter.MigrateFromSettings, add admin user", user.Username)
+
+		apiKeySettings := &nowSettings.ExperimentalFunction.ApiKeySettings
+		if apiKeySettings.Key != "" {
+			_, err = u.saveApiKey(user.ID, defaultApiKeyName, apiKeySettings.Key)
+			if err != nil {
+				return changed, err
+			}
+			u.log.Infoln("UserCenter.MigrateFromSettings, add api key", defaultApiKeyName)
+			apiKeySettings.Key = ""
+		}
+	}
+	// 密码已经在数据库中了，不再明文保存在配置文件中
+	nowSettings.UserInfo.Password = ""
+	changed = true
+	return changed, nil
+}
+
+func (u *UserCenter) saveApiKey(userID uint, name, key string) (*models.UserApiKey, error) {
+
+	keyPrefix := key
+	if len(keyPrefix) > apiKeyShowLen {
+		keyPrefix = keyPrefix[:apiKeyShowLen]
+	}
+	apiKey := models.UserApiKey{
+		Name:      name,
+		KeyHash:   hashToken(key),
+		KeyPrefix: keyPrefix,
+		UserID:    userID,
+	}
+	err := u.db.Create(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (u *UserCenter) getUser(userID uint) (*models.User, error) {
+
+	var user models.User
+	err := u.db.First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) == true {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// checkOtherAdmin 除了这个用户，是否还有其他的管理员
+func (u *UserCenter) checkOtherAdmin(userID uint) error {
+
+	var count int64
+	err := u.db.Model(&models.User{}).Where("role = ? AND id <> ?", RoleAdmin, userID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+
+	if len(password) < passwordMinLen || len(password) > passwordMaxLen {
+		return "", ErrPasswordLength
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(passwordHash), nil
+}
+
+func checkPassword(passwordHash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+func hashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+func randomToken(prefix string) (string, error) {
+
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+const (
+	SessionExpiration = 7 * 24 * time.Hour // 会话的有效期
+	defaultApiKeyName = "default"          // 从 settings 迁移的 API Key 的名称
+	apiKeyPrefix      = "csf_"
+	apiKeyShowLen     = 8
+	passwordMinLen    = 6
+	passwordMaxLen    = 30
+)
+
+var (
+	userCenterInstance *UserCenter
+	userCenterOnce     sync.Once
+)
+
+var (
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUserExists              = errors.New("username already exists")
+	ErrUsernameEmpty           = errors.New("username is empty")
+	ErrInvalidRole             = errors.New("role must be admin, operator or read_only")
+	ErrPasswordLength          = errors.New("password length must be 6 to 30")
+	ErrLastAdmin               = errors.New("can not remove the last admin")
+	ErrUsernameOrPasswordError = errors.New("Username or Password Error")
+	ErrOrgPasswordError        = errors.New("Org Password Error")
+	ErrSessionInvalid          = errors.New("AccessToken Error")
+	ErrApiKeyInvalid           = errors.New("AccessToken Error")
+	ErrApiKeyNameEmpty         = errors.New("api key name is empty")
+	ErrApiKeyNotFound          = errors.New("api key not found")
+)
@@ -0,0 +1,174 @@
+package user_center
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChineseSubFinder/ChineseSubFinder/internal/models"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestUserCenter(t *testing.T) *UserCenter {
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.UserSession{}, &models.UserApiKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUserCenter(logrus.New(), db)
+}
+
+func TestUserCenter_Session(t *testing.T) {
+
+	uc := newTestUserCenter(t)
+	if uc.IsSetup() == true {
+		t.Fatal("IsSetup should be false")
+	}
+	admin, err := uc.AddUser("admin", "123456", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.AddUser("admin", "654321", RoleOperator)
+	if errors.Is(err, ErrUserExists) == false {
+		t.Fatalf("AddUser same username, err = %v", err)
+	}
+	_, _, err = uc.Login("admin", "wrong-password")
+	if errors.Is(err, ErrUsernameOrPasswordError) == false {
+		t.Fatalf("Login wrong password, err = %v", err)
+	}
+	// 同一个用户可以同时有多个会话
+	token1, _, err := uc.Login("admin", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token2, _, err := uc.Login("admin", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := uc.CheckSession(token1)
+	if err != nil || identity.UserID != admin.ID || identity.Role != RoleAdmin {
+		t.Fatalf("CheckSession token1 = %+v, %v", identity, err)
+	}
+	err = uc.Logout(token1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CheckSession(token1)
+	if errors.Is(err, ErrSessionInvalid) == false {
+		t.Fatalf("CheckSession after Logout, err = %v", err)
+	}
+	_, err = uc.CheckSession(token2)
+	if err != nil {
+		t.Fatalf("CheckSession token2 after token1 Logout, err = %v", err)
+	}
+	// 修改密码后所有的会话失效
+	err = uc.ChangePassword(admin.ID, "123456", "abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CheckSession(token2)
+	if errors.Is(err, ErrSessionInvalid) == false {
+		t.Fatalf("CheckSession after ChangePassword, err = %v", err)
+	}
+}
+
+func TestUserCenter_LastAdmin(t *testing.T) {
+
+	uc := newTestUserCenter(t)
+	admin, err := uc.AddUser("admin", "123456", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uc.UpdateUser(admin.ID, RoleReadOnly, "")
+	if errors.Is(err, ErrLastAdmin) == false {
+		t.Fatalf("UpdateUser last admin, err = %v", err)
+	}
+	err = uc.DelUser(admin.ID)
+	if errors.Is(err, ErrLastAdmin) == false {
+		t.Fatalf("DelUser last admin, err = %v", err)
+	}
+	other, err := uc.AddUser("other", "123456", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uc.DelUser(admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 删除后可以再次使用这个用户名
+	_, err = uc.AddUser("admin", "123456", RoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uc.UpdateUser(other.ID, "root", "")
+	if errors.Is(err, ErrInvalidRole) == false {
+		t.Fatalf("UpdateUser invalid role, err = %v", err)
+	}
+}
+
+func TestUserCenter_ApiKey(t *testing.T) {
+
+	uc := newTestUserCenter(t)
+	operator, err := uc.AddUser("operator", "123456", RoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, apiKey, err := uc.AddApiKey(operator.ID, "sonarr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := uc.CheckApiKey(key)
+	if err != nil || identity.Role != RoleOperator || identity.ApiKeyName != "sonarr" {
+		t.Fatalf("CheckApiKey = %+v, %v", identity, err)
+	}
+	// 只能吊销自己的
+	err = uc.DelApiKey(apiKey.ID, operator.ID+1)
+	if errors.Is(err, ErrApiKeyNotFound) == false {
+		t.Fatalf("DelApiKey other user, err = %v", err)
+	}
+	err = uc.DelApiKey(apiKey.ID, operator.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CheckApiKey(key)
+	if errors.Is(err, ErrApiKeyInvalid) == false {
+		t.Fatalf("CheckApiKey after DelApiKey, err = %v", err)
+	}
+}
+
+func TestUserCenter_MigrateFromSettings(t *testing.T) {
+
+	uc := newTestUserCenter(t)
+	nowSettings := &settings.Settings{
+		UserInfo:             settings.NewUserInfo("admin", "123456"),
+		ExperimentalFunction: &settings.ExperimentalFunction{ApiKeySettings: settings.ApiKeySettings{Enabled: true, Key: "old-api-key"}},
+	}
+	changed, err := uc.MigrateFromSettings(nowSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == false || nowSettings.UserInfo.Password != "" || nowSettings.ExperimentalFunction.ApiKeySettings.Key != "" {
+		t.Fatalf("settings not cleared, %+v %+v", nowSettings.UserInfo, nowSettings.ExperimentalFunction.ApiKeySettings)
+	}
+	_, _, err = uc.Login("admin", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 之前的 Key 继续可用
+	identity, err := uc.CheckApiKey("old-api-key")
+	if err != nil || identity.Role != RoleAdmin || identity.ApiKeyName != defaultApiKeyName {
+		t.Fatalf("CheckApiKey old key = %+v, %v", identity, err)
+	}
+	// 再次迁移不会有变化
+	changed, err = uc.MigrateFromSettings(nowSettings)
+	if err != nil || changed == true {
+		t.Fatalf("MigrateFromSettings again, changed = %v, err = %v", changed, err)
+	}
+}
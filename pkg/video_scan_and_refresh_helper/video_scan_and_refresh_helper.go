@@ -1,13 +1,18 @@
 package video_scan_and_refresh_helper
 
 import (
+	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/notify_center"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_logic"
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/types/notify"
 
+	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/scan_index"
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg/search"
 
 	"github.com/ChineseSubFinder/ChineseSubFinder/pkg"
@@ -125,20 +130,60 @@ func (v *VideoScanAndRefreshHelper) Start(scanLogic *scan_logic.ScanLogic) error
 		return err
 	}
 	// 过滤出需要下载的视频有那些，并放入队列中
+	queueSizeBefore := 0
+	if v.downloadQueue != nil {
+		queueSizeBefore = v.downloadQueue.Size()
+	}
 	err = v.FilterMovieAndSeriesNeedDownload(scanResult, scanLogic)
 	if err != nil {
 		v.log.Errorln("FilterMovieAndSeriesNeedDownload", err)
 		return err
 	}
-	if settings.Get().ExperimentalFunction.ShareSubSettings.ShareSubEnabled == true {
-		v.log.Infoln("ShareSubEnabled is true, will scan share sub")
-		// 根据上面得到的 scanResult 的 Normal 部分进行字幕的扫描，也存入到 VideoSubInfo 中，但是需要标记这个是低可信度的
-		//v.scanLowVideoSubInfo(scanResult)
+	if v.downloadQueue != nil {
+		v.publishScanFinished(scanResult, v.downloadQueue.Size()-queueSizeBefore, time.Since(startT))
+	}
+	if scanResult.Normal != nil && scanResult.Normal.scanIndex != nil {
+		scanResult.Normal.scanIndex.SetTotalCost(time.Since(startT))
+	}
+	if settings.Get().ExperimentalFunction.ShareSubSettings.ShareSubEnabled == true &&
+		settings.Get().ExperimentalFunction.ShareSubSettings.UploadLowTrust == true {
+		v.log.Infoln("ShareSubEnabled and UploadLowTrust is true, will scan share sub")
+		// 根据上面得到的 scanResult 的 Normal 部分进行字幕的扫描，存入到 LowVideoSubInfo 中，标记这个是低可信度的
+		v.scanLowVideoSubInfo(scanResult)
 	}
 
 	return nil
 }
 
+// publishScanFinished 扫描结束的通知，附带扫描到的视频数量以及新增的任务数量
+func (v *VideoScanAndRefreshHelper) publishScanFinished(scanResult *ScanVideoResult, addedJobCount int, cost time.Duration) {
+
+	movieCount := 0
+	seriesCount := 0
+	if scanResult.Emby != nil {
+		movieCount = len(scanResult.Emby.MovieSubNeedDlEmbyMixInfoList)
+		seriesCount = len(scanResult.Emby.SeriesSubNeedDlEmbyMixInfoMap)
+	} else if scanResult.Normal != nil {
+		if scanResult.Normal.MoviesDirMap != nil {
+			scanResult.Normal.MoviesDirMap.Each(func(key interface{}, value interface{}) {
+				movieCount += len(value.([]string))
+			})
+		}
+		if scanResult.Normal.SeriesDirMap != nil {
+			scanResult.Normal.SeriesDirMap.Each(func(key interface{}, value interface{}) {
+				seriesCount += len(value.([]string))
+			})
+		}
+	}
+	message := fmt.Sprintf("movies: %d, series: %d, new jobs: %d, cost: %.1f min", movieCount, seriesCount, addedJobCount, cost.Minutes())
+	notify_center.Publish(notify.NewEvent(notify.ScanFinished, message, map[string]string{
+		"movie_count":     strconv.Itoa(movieCount),
+		"series_count":    strconv.Itoa(seriesCount),
+		"added_job_count": strconv.Itoa(addedJobCount),
+		"cost_minutes":    fmt.Sprintf("%.1f", cost.Minutes()),
+	}))
+}
+
 func (v *VideoScanAndRefreshHelper) Cancel() {
 
 	v.locker.Lock()
@@ -195,45 +240,53 @@ func (v *VideoScanAndRefreshHelper) ScanNormalMovieAndSeries() (*ScanVideoResult
 	if v.NeedForcedScanAndDownSub == true {
 		v.log.Infoln("Forced Scan And DownSub")
 	}
-	wg := sync.WaitGroup{}
-	var errMovie, errSeries error
-	wg.Add(1)
-	go func() {
-		defer func() {
-			wg.Done()
+	if settings.Get().AdvancedSettings.IncrementalScanSettings.Enable == true {
+		// 增量扫描，只处理有变化的目录
+		err = v.scanNormalMovieAndSeriesByIndex(&normalScanResult)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		var errMovie, errSeries error
+		wg.Add(1)
+		go func() {
+			defer func() {
+				wg.Done()
+			}()
+			// --------------------------------------------------
+			// 电影
+			// 没有填写 emby_helper api 的信息，那么就走常规的全文件扫描流程
+			normalScanResult.MoviesDirMap, errMovie = search.MatchedVideoFileFromDirs(v.log, settings.Get().CommonSettings.MoviePaths)
 		}()
-		// --------------------------------------------------
-		// 电影
-		// 没有填写 emby_helper api 的信息，那么就走常规的全文件扫描流程
-		normalScanResult.MoviesDirMap, errMovie = search.MatchedVideoFileFromDirs(v.log, settings.Get().CommonSettings.MoviePaths)
-	}()
-	wg.Add(1)
-	go func() {
-
-		defer func() {
-			wg.Done()
+		wg.Add(1)
+		go func() {
+
+			defer func() {
+				wg.Done()
+			}()
+			// --------------------------------------------------
+			// 连续剧
+			// 遍历连续剧总目录下的第一层目录
+			normalScanResult.SeriesDirMap, errSeries = seriesHelper.GetSeriesListFromDirs(v.log, settings.Get().CommonSettings.SeriesPaths)
+			// ------------------------------------------------------------------------------
+			// 输出调试信息，有那些连续剧文件夹名称
+			if normalScanResult.SeriesDirMap == nil {
+				return
+			}
+			normalScanResult.SeriesDirMap.Each(func(key interface{}, value interface{}) {
+				for i, s := range value.([]string) {
+					v.log.Debugln("embyHelper == nil GetSeriesList", i, s)
+				}
+			})
 		}()
-		// --------------------------------------------------
-		// 连续剧
-		// 遍历连续剧总目录下的第一层目录
-		normalScanResult.SeriesDirMap, errSeries = seriesHelper.GetSeriesListFromDirs(v.log, settings.Get().CommonSettings.SeriesPaths)
-		// ------------------------------------------------------------------------------
-		// 输出调试信息，有那些连续剧文件夹名称
-		if normalScanResult.SeriesDirMap == nil {
-			return
+		wg.Wait()
+		if errMovie != nil {
+			return nil, errMovie
+		}
+		if errSeries != nil {
+			return nil, errSeries
 		}
-		normalScanResult.SeriesDirMap.Each(func(key interface{}, value interface{}) {
-			for i, s := range value.([]string) {
-				v.log.Debugln("embyHelper == nil GetSeriesList", i, s)
-			}
-		})
-	}()
-	wg.Wait()
-	if errMovie != nil {
-		return nil, errMovie
-	}
-	if errSeries != nil {
-		return nil, errSeries
 	}
 	// ------------------------------------------------------------------------------
 	outScanVideoResult.Normal = &normalScanResult
@@ -247,6 +300,36 @@ func (v *VideoScanAndRefreshHelper) ScanNormalMovieAndSeries() (*ScanVideoResult
 	return &outScanVideoResult, nil
 }
 
+// scanNormalMovieAndSeriesByIndex 使用持久化的视频索引进行扫描，只会重新读取有变化的目录
+func (v *VideoScanAndRefreshHelper) scanNormalMovieAndSeriesByIndex(normalScanResult *NormalScanVideoResult) error {
+
+	moviePaths := settings.Get().CommonSettings.MoviePaths
+	seriesPaths := settings.Get().CommonSettings.SeriesPaths
+	index := scan_index.Get(v.log)
+	// 强制扫描或者超过了全量扫描的间隔，都需要全量扫描
+	isFullScan := v.NeedForcedScanAndDownSub == true ||
+		index.NeedFullScan(settings.Get().AdvancedSettings.IncrementalScanSettings.FullScanIntervalDays) == true
+	index.BeginScan(isFullScan)
+	err := index.WalkRoots(moviePaths)
+	if err != nil {
+		return err
+	}
+	err = index.WalkRoots(seriesPaths)
+	if err != nil {
+		return err
+	}
+	_, err = index.EndScan(append(append([]string{}, moviePaths...), seriesPaths...))
+	if err != nil {
+		// 持久化失败不影响本次的扫描
+		v.log.Errorln("scanNormalMovieAndSeriesByIndex.EndScan", err)
+	}
+	normalScanResult.MoviesDirMap = index.VideoFilesByRoot(moviePaths)
+	normalScanResult.SeriesDirMap = index.SeriesDirsByRoot(seriesPaths)
+	normalScanResult.scanIndex = index
+
+	return nil
+}
+
 // ScanEmbyMovieAndSeries Emby媒体服务器，扫描出有那些电影、连续剧需要进行字幕下载的
 func (v *VideoScanAndRefreshHelper) ScanEmbyMovieAndSeries(scanVideoResult *ScanVideoResult) error {
 
@@ -1069,6 +1152,9 @@ func (v *VideoScanAndRefreshHelper) updateLocalVideoCacheInfo(scanVideoResult *S
 
 		//oneMovieDirRootPath := movieDirRootPath.(string)
 		for i, oneMovieFPath := range movieFPath.([]string) {
+			if scanVideoResult.Normal.isUnchanged(oneMovieFPath) == true {
+				continue
+			}
 			err := v.taskControl.Invoke(&task_control.TaskData{
 				Index: i,
 				Count: len(movieFPath.([]string)),
@@ -1121,6 +1207,9 @@ func (v *VideoScanAndRefreshHelper) updateLocalVideoCacheInfo(scanVideoResult *S
 	scanVideoResult.Normal.SeriesDirMap.Each(func(seriesRootPathName interface{}, seriesNames interface{}) {
 
 		for i, oneSeriesRootDir := range seriesNames.([]string) {
+			if scanVideoResult.Normal.isUnchanged(oneSeriesRootDir) == true {
+				continue
+			}
 			err := v.taskControl.Invoke(&task_control.TaskData{
 				Index: i,
 				Count: len(seriesNames.([]string)),
@@ -1176,6 +1265,10 @@ func (v *VideoScanAndRefreshHelper) filterMovieAndSeriesNeedDownloadNormal(norma
 				v.log.Debugln("filterMovieAndSeriesNeedDownloadNormal.Movie", oneMovieFPath, "skip")
 				continue
 			}
+			if normal.isUnchanged(oneMovieFPath) == true {
+				v.log.Debugln("filterMovieAndSeriesNeedDownloadNormal.Movie", oneMovieFPath, "unchanged, skip")
+				continue
+			}
 			// 放入队列
 			err := v.taskControl.Invoke(&task_control.TaskData{
 				Index: i,
@@ -1249,6 +1342,10 @@ func (v *VideoScanAndRefreshHelper) filterMovieAndSeriesNeedDownloadNormal(norma
 
 		for i, oneSeriesRootDir := range seriesNames.([]string) {
 
+			if normal.isUnchanged(oneSeriesRootDir) == true {
+				v.log.Debugln("filterMovieAndSeriesNeedDownloadNormal.Series", oneSeriesRootDir, "unchanged, skip")
+				continue
+			}
 			// 放入队列
 			err := v.taskControl.Invoke(&task_control.TaskData{
 				Index: i,
@@ -1433,6 +1530,15 @@ type ScanVideoResult struct {
 type NormalScanVideoResult struct {
 	MoviesDirMap *treemap.Map
 	SeriesDirMap *treemap.Map
+	scanIndex    *scan_index.ScanIndex // 增量扫描的时候才有，用于跳过没有变化的视频
+}
+
+// isUnchanged 增量扫描中，这个电影或者连续剧目录没有变化，可以跳过
+func (n NormalScanVideoResult) isUnchanged(fPath string) bool {
+	if n.scanIndex == nil {
+		return false
+	}
+	return n.scanIndex.IsChanged(fPath) == false
 }
 
 type EmbyScanVideoResult struct {